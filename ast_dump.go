@@ -0,0 +1,134 @@
+package cxgo
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"modernc.org/cc/v3"
+)
+
+// astDecls returns top-level C declarations of the translation unit declared in a given file,
+// or all declarations if the file is empty.
+func astDecls(tu *cc.AST, file string) []*cc.ExternalDeclaration {
+	file = strings.TrimPrefix(file, "./")
+	var out []*cc.ExternalDeclaration
+	for it := tu.TranslationUnit; it != nil; it = it.TranslationUnit {
+		d := it.ExternalDeclaration
+		if d == nil {
+			continue
+		}
+		if file != "" && strings.TrimPrefix(d.Position().Filename, "./") != file {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// DumpAST writes the C AST of top-level declarations from a given file, or all declarations if the file is empty.
+func DumpAST(w io.Writer, tu *cc.AST, file string) error {
+	bw := bufio.NewWriter(w)
+	for _, d := range astDecls(tu, file) {
+		fmt.Fprintf(bw, "%v:\n%s\n\n", d.Position(), strings.TrimSpace(cc.PrettyString(d)))
+	}
+	return bw.Flush()
+}
+
+// DumpASTJSON is the same as DumpAST, but writes the AST as a JSON array of declarations.
+//
+// Each AST node is an object with the "node" type name, the "case" of the node, if any, and its child nodes by field names.
+// Recursive lists of nodes, like a list of statements, are written as arrays. Tokens are written with their positions.
+func DumpASTJSON(w io.Writer, tu *cc.AST, file string) error {
+	var list []any
+	for _, d := range astDecls(tu, file) {
+		list = append(list, astJSON(reflect.ValueOf(d)))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(list)
+}
+
+var (
+	tokenType    = reflect.TypeOf(cc.Token{})
+	stringIDType = reflect.TypeOf(cc.StringID(0))
+)
+
+// astJSON converts a C AST node to a JSON-friendly value. It returns nil for values that should be omitted.
+func astJSON(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		return astNodeJSON(v)
+	case reflect.Struct:
+		if v.Type() == tokenType {
+			tok := v.Interface().(cc.Token)
+			if tok.Value == 0 {
+				return nil
+			}
+			return map[string]any{"token": tok.Value.String(), "pos": tok.Position().String()}
+		}
+	case reflect.Bool:
+		if v.Bool() {
+			return true
+		}
+	}
+	return nil
+}
+
+// astNodeJSON converts a pointer to an AST node struct. Chains of nodes referring to the same type are flattened.
+func astNodeJSON(v reflect.Value) any {
+	t := v.Elem().Type()
+	next := -1
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type == v.Type() {
+			next = i
+			break
+		}
+	}
+	if next < 0 {
+		return astFieldsJSON(v.Elem(), -1)
+	}
+	var items []any
+	for ; !v.IsNil(); v = v.Elem().Field(next) {
+		items = append(items, astFieldsJSON(v.Elem(), next))
+	}
+	if len(items) == 1 {
+		return items[0]
+	}
+	return map[string]any{"node": t.Name(), "list": items}
+}
+
+func astFieldsJSON(v reflect.Value, skip int) map[string]any {
+	t := v.Type()
+	m := map[string]any{"node": t.Name()}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if i == skip || !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Name == "Case" {
+			m["case"] = fmt.Sprint(fv.Interface())
+			continue
+		}
+		if f.Type == stringIDType {
+			if id := fv.Interface().(cc.StringID); id != 0 {
+				m[f.Name] = id.String()
+			}
+			continue
+		}
+		if val := astJSON(fv); val != nil {
+			m[f.Name] = val
+		}
+	}
+	return m
+}
@@ -0,0 +1,141 @@
+package cxgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// BenchTag is a build tag that enables benchmarks of original C functions generated for BenchConfig.Cgo.
+const BenchTag = "cxgobench"
+
+// BenchConfig configures a generated Go benchmark for a C function.
+type BenchConfig struct {
+	Name string   `yaml:"name" json:"name"` // C function name
+	Args []string `yaml:"args" json:"args"` // Go expressions used as arguments; zero values are used by default
+	Cgo  bool     `yaml:"cgo" json:"cgo"`   // also benchmark the original C function called via cgo
+}
+
+type benchFunc struct {
+	conf BenchConfig
+	name *types.Ident
+	typ  *types.FuncType
+	cgo  *CgoFuncDecl // wrapper for the original C function; set only if conf.Cgo is set
+}
+
+// hasCBench checks if any of the benchmarks call the original C function.
+func (c *Config) hasCBench() bool {
+	for _, b := range c.Benchmarks {
+		if b.Cgo {
+			return true
+		}
+	}
+	return false
+}
+
+// benchCgo creates a cgo wrapper for benchmarking the original C function, if requested by the config.
+func (g *translator) benchCgo(name *types.Ident, ft *types.FuncType, ctype cc.Type) {
+	b := g.bench[name.Name]
+	if b == nil || !b.conf.Cgo {
+		return
+	}
+	id := types.NewIdentGo(name.Name, "cbench_"+name.GoIdent().Name, ft)
+	b.cgo = g.newCgoFuncDecl(id, ft, ctype)
+}
+
+// benchmarks generates Go benchmarks for translated functions listed in Config.Benchmarks.
+//
+// It returns benchmarks for Go functions, cgo wrappers for C functions, and benchmarks for those wrappers.
+// Functions that are not defined in the current file are ignored.
+func (g *translator) benchmarks() (bench, cwrap, cbench []GoDecl, _ error) {
+	for _, c := range g.conf.Benchmarks {
+		b := g.bench[c.Name]
+		if b == nil || b.name == nil {
+			continue
+		}
+		if n := len(c.Args); n != 0 && (n < b.typ.ArgN() || (n > b.typ.ArgN() && !b.typ.Variadic())) {
+			return nil, nil, nil, fmt.Errorf("benchmark %s: expected %d arguments, got %d", c.Name, b.typ.ArgN(), n)
+		}
+		name := "Benchmark" + asExportedName(b.name.GoIdent().Name)
+		args, err := g.benchArgs(b)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		bench = append(bench, goBenchFunc(name, call(b.name.GoIdent(), args...)))
+		if b.cgo == nil {
+			continue
+		}
+		args, err = g.benchArgs(b)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		cwrap = append(cwrap, b.cgo.AsDecl()...)
+		cbench = append(cbench, goBenchFunc(name+"_C", call(b.cgo.Name.GoIdent(), args...)))
+	}
+	return bench, cwrap, cbench, nil
+}
+
+func (g *translator) benchArgs(b *benchFunc) ([]GoExpr, error) {
+	if len(b.conf.Args) == 0 {
+		var args []GoExpr
+		for _, a := range b.typ.Args() {
+			args = append(args, g.ZeroValue(a.Type()).AsExpr())
+		}
+		return args, nil
+	}
+	args := make([]GoExpr, 0, len(b.conf.Args))
+	for _, s := range b.conf.Args {
+		x, err := parser.ParseExpr(s)
+		if err != nil {
+			return nil, fmt.Errorf("benchmark %s: invalid argument %q: %w", b.conf.Name, s, err)
+		}
+		args = append(args, x)
+	}
+	return args, nil
+}
+
+// goBenchFunc generates a benchmark function that evaluates an expression b.N times.
+func goBenchFunc(name string, x GoExpr) GoDecl {
+	i := ident("i")
+	return &ast.FuncDecl{
+		Name: ident(name),
+		Type: &ast.FuncType{Params: &ast.FieldList{List: []*ast.Field{{
+			Names: []*ast.Ident{ident("b")},
+			Type:  &ast.StarExpr{X: ident("testing.B")},
+		}}}},
+		Body: &ast.BlockStmt{List: []GoStmt{&ast.ForStmt{
+			Init: &ast.AssignStmt{Lhs: []GoExpr{i}, Tok: token.DEFINE, Rhs: []GoExpr{intLit(0)}},
+			Cond: &ast.BinaryExpr{X: i, Op: token.LSS, Y: &ast.SelectorExpr{X: ident("b"), Sel: ident("N")}},
+			Post: &ast.IncDecStmt{X: i, Tok: token.INC},
+			Body: &ast.BlockStmt{List: []GoStmt{&ast.ExprStmt{X: x}}},
+		}}},
+	}
+}
+
+// writeBenchmarks writes generated benchmarks next to the Go file. Benchmarks of C functions require BenchTag.
+func (p *TranslatorProject) writeBenchmarks(out, gofile, pkg string, bench, cwrap, cbench []GoDecl, conf Config) error {
+	base := strings.TrimSuffix(gofile, ".go")
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(out, base)
+	}
+	if len(bench) != 0 {
+		if err := writeGoFileTag(p.env, base+"_bench_test.go", pkg, "", bench, conf); err != nil {
+			return err
+		}
+	}
+	if len(cwrap) == 0 {
+		return nil
+	}
+	// cgo cannot be used in tests directly, thus wrappers are written to a regular Go file
+	if err := writeGoFileTag(p.env, base+"_cbench.go", pkg, BenchTag, cwrap, conf); err != nil {
+		return err
+	}
+	return writeGoFileTag(p.env, base+"_cbench_test.go", pkg, BenchTag, cbench, conf)
+}
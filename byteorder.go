@@ -0,0 +1,79 @@
+package cxgo
+
+import (
+	"go/ast"
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// byteOrderPtr checks if the dereference accesses an integer via a byte pointer (as in *(uint32_t*)buf),
+// which depends on the byte order. It returns the byte pointer and the integer type.
+//
+// Such accesses are only detected if Config.FixedByteOrder is set.
+func (e *Deref) byteOrderPtr() (PtrExpr, types.Type, bool) {
+	if !e.g.conf.FixedByteOrder {
+		return nil, nil, false
+	}
+	conv, ok := cUnwrap(e.X).(*PtrToPtr)
+	if !ok {
+		return nil, nil, false
+	}
+	t := conv.To.Elem()
+	if t == nil || !t.Kind().IsInt() {
+		return nil, nil, false
+	}
+	switch t.Sizeof() {
+	case 2, 4, 8:
+	default:
+		return nil, nil, false
+	}
+	if elem := conv.X.PtrType(nil).Elem(); elem != nil && (!elem.Kind().IsInt() || elem.Sizeof() != 1) {
+		return nil, nil, false
+	}
+	return conv.X, t, true
+}
+
+// isPlainUint checks if the type is the same as returned by encoding/binary functions.
+func isPlainUint(t types.Type) bool {
+	it, ok := t.(types.IntType)
+	return ok && !it.Signed()
+}
+
+// byteOrderSlice converts a byte pointer to a byte slice of a given size.
+func byteOrderSlice(p PtrExpr, size int) GoExpr {
+	x := p.AsExpr()
+	switch elem := p.PtrType(nil).Elem(); {
+	case elem == nil:
+		x = call(paren(&ast.StarExpr{X: ident("byte")}), x)
+	case !elem.Kind().IsUnsigned():
+		x = call(paren(&ast.StarExpr{X: ident("byte")}), call(unsafePtr(), x))
+	}
+	return call(ident("unsafe.Slice"), x, intLit(size))
+}
+
+// byteOrderFunc returns a name of the encoding/binary function for the target byte order.
+func (g *translator) byteOrderFunc(name string, size int) GoExpr {
+	order := "binary.LittleEndian."
+	if g.env.BigEndian() {
+		order = "binary.BigEndian."
+	}
+	return ident(order + name + "Uint" + strconv.Itoa(size*8))
+}
+
+// byteOrderLoad reads an integer from a byte pointer in the target byte order.
+func (g *translator) byteOrderLoad(p PtrExpr, t types.Type) GoExpr {
+	x := call(g.byteOrderFunc("", t.Sizeof()), byteOrderSlice(p, t.Sizeof()))
+	if !isPlainUint(t) {
+		x = call(t.GoType(), x)
+	}
+	return x
+}
+
+// byteOrderStore writes an integer to a byte pointer in the target byte order.
+func (g *translator) byteOrderStore(p PtrExpr, t types.Type, v GoExpr) GoStmt {
+	if !isPlainUint(t) {
+		v = call(types.UintT(t.Sizeof()).GoType(), v)
+	}
+	return &ast.ExprStmt{X: call(g.byteOrderFunc("Put", t.Sizeof()), byteOrderSlice(p, t.Sizeof()), v)}
+}
@@ -0,0 +1,130 @@
+package cxgo
+
+import "bytes"
+
+// c23Predefine declares C23 keywords that are not supported by the C parser as macros.
+const c23Predefine = `
+#include <stdbool.h>
+
+#define nullptr ((void*)0)
+typedef void* nullptr_t;
+
+#define typeof_unqual typeof
+#define alignas _Alignas
+#define alignof _Alignof
+#define static_assert _Static_assert
+#define thread_local _Thread_local
+`
+
+func isIdentByte(b byte) bool {
+	return b == '_' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isHexDigit(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+// rewriteC23 removes digit separators (1'000'000) and [[attributes]] from C source, keeping strings,
+// char literals and comments intact. Attributes are replaced with spaces to preserve token positions.
+func rewriteC23(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			j := bytes.IndexByte(data[i:], '\n')
+			if j < 0 {
+				j = len(data) - i
+			}
+			out = append(out, data[i:i+j]...)
+			i += j
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			j := bytes.Index(data[i+2:], []byte("*/"))
+			if j < 0 {
+				j = len(data) - i - 2
+			} else {
+				j += 2
+			}
+			out = append(out, data[i:i+2+j]...)
+			i += 2 + j
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(data) && data[j] != c && data[j] != '\n' {
+				if data[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(data) && data[j] == c {
+				j++
+			}
+			out = append(out, data[i:j]...)
+			i = j
+		case c >= '0' && c <= '9' && (i == 0 || !isIdentByte(data[i-1])):
+			// pp-number, possibly with digit separators
+			j := i
+			for j < len(data) {
+				b := data[j]
+				switch {
+				case isIdentByte(b) || b == '.':
+					out = append(out, b)
+				case (b == '+' || b == '-') && j > i && (data[j-1]|0x20 == 'e' || data[j-1]|0x20 == 'p'):
+					out = append(out, b)
+				case b == '\'' && j+1 < len(data) && isHexDigit(data[j-1]) && isHexDigit(data[j+1]):
+					// digit separator
+				default:
+					goto end
+				}
+				j++
+			}
+		end:
+			i = j
+		case c == '[' && i+1 < len(data) && data[i+1] == '[':
+			j, ok := c23AttrEnd(data, i)
+			if !ok {
+				out = append(out, c)
+				i++
+				continue
+			}
+			for _, b := range data[i:j] {
+				if b == '\n' {
+					out = append(out, b)
+				} else {
+					out = append(out, ' ')
+				}
+			}
+			i = j
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out
+}
+
+// c23AttrEnd finds the end of the attribute specifier that starts at i.
+func c23AttrEnd(data []byte, i int) (int, bool) {
+	depth := 0
+	for j := i; j < len(data); j++ {
+		switch data[j] {
+		case '"', '\'':
+			q := data[j]
+			for j++; j < len(data) && data[j] != q; j++ {
+				if data[j] == '\\' {
+					j++
+				}
+			}
+		case '[', '(':
+			depth++
+		case ')':
+			depth--
+		case ']':
+			depth--
+			if depth == 0 {
+				// the attribute must be closed with "]]"
+				return j + 1, j > i && data[j-1] == ']'
+			}
+		}
+	}
+	return 0, false
+}
@@ -1,7 +1,6 @@
 package cxgo
 
 import (
-	"fmt"
 	"go/ast"
 	"go/token"
 
@@ -102,6 +101,7 @@ func (d *CVarSpec) Uses() []types.Usage {
 type CVarDecl struct {
 	Const  bool
 	Single bool
+	Static bool // static global variable with internal linkage
 	CVarSpec
 }
 
@@ -183,28 +183,17 @@ func (d *CTypeDef) Visit(v Visitor) {
 }
 
 func (d *CTypeDef) AsDecl() []GoDecl {
-	var decls []GoDecl
-	if false && d.Kind().Is(types.Struct) {
-		decls = append(decls, &ast.GenDecl{
-			Tok: token.VAR,
+	return []GoDecl{
+		&ast.GenDecl{
+			Tok: token.TYPE,
 			Specs: []ast.Spec{
-				&ast.ValueSpec{
-					Names:  []*ast.Ident{ident("_")},
-					Values: []GoExpr{ident(fmt.Sprintf("([1]struct{}{})[%d-unsafe.Sizeof(%s{})]", d.Sizeof(), d.Name().Name))},
+				&ast.TypeSpec{
+					Name: d.Name().GoIdent(),
+					Type: d.Underlying().GoType(),
 				},
 			},
-		})
-	}
-	decls = append(decls, &ast.GenDecl{
-		Tok: token.TYPE,
-		Specs: []ast.Spec{
-			&ast.TypeSpec{
-				Name: d.Name().GoIdent(),
-				Type: d.Underlying().GoType(),
-			},
 		},
-	})
-	return decls
+	}
 }
 
 func (d *CTypeDef) Uses() []types.Usage {
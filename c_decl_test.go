@@ -712,6 +712,106 @@ type Bar struct {
 			withRename("foo", "Bar"),
 		},
 	},
+	{
+		name: "rename local var",
+		src: `
+int index;
+int foo(int n) {
+	int index = n;
+	return index;
+}
+int bar() {
+	int index = 1;
+	return index;
+}
+`,
+		exp: `
+var index int32
+
+func foo(n int32) int32 {
+	var sum int32 = n
+	return sum
+}
+func bar() int32 {
+	var index int32 = 1
+	return index
+}
+`,
+		configFuncs: []configFunc{
+			withIdentField("foo", IdentConfig{Name: "index", Rename: "sum"}),
+		},
+	},
+	{
+		name: "local var hints",
+		src: `
+void foo(int n) {
+	const char* s = "a";
+	int* buf = 0;
+	for (int i = 0; i < n; i++) {
+		buf[i] = s[0];
+	}
+}
+`,
+		exp: `
+func foo(n int32) {
+	var (
+		s   string  = "a"
+		buf []int32 = nil
+	)
+	_ = buf
+	for i := int32(0); i < n; i++ {
+		buf[i] = int32(s[0])
+	}
+}
+`,
+		configFuncs: []configFunc{
+			withIdent(IdentConfig{Name: "foo", Fields: []IdentConfig{
+				{Name: "s", Type: HintString},
+				{Name: "buf", Type: HintSlice},
+			}}),
+		},
+	},
+	{
+		name: "return hints",
+		src: `
+const char* name(int x) {
+	if (x) return "a";
+	return "b";
+}
+int ok(int x) {
+	if (x == 2) return 1;
+	return x > 0;
+}
+int use(int x) {
+	if (ok(x)) return name(x)[0];
+	return ok(x) + name(x)[1];
+}
+`,
+		exp: `
+func name(x int32) string {
+	if x != 0 {
+		return "a"
+	}
+	return "b"
+}
+func ok(x int32) bool {
+	if x == 2 {
+		return true
+	}
+	return x > 0
+}
+func use(x int32) int32 {
+	if ok(x) {
+		return int32(name(x)[0])
+	}
+	return int32(byte(int8(libc.BoolToInt(ok(x)))) + name(x)[1])
+}
+`,
+		configFuncs: []configFunc{
+			withIdent(IdentConfig{Name: "name", Return: HintString}),
+			withIdent(IdentConfig{Name: "ok", Return: HintBool}),
+		},
+	},
 	{
 		name: "args partially named",
 		src: `
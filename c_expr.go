@@ -335,6 +335,9 @@ func (g *translator) newCBinaryExpr(exp types.Type, x Expr, op BinaryOp, y Expr)
 	typ := g.env.CommonType(xt, yt)
 	x = g.cCast(typ, x)
 	y = g.cCast(typ, y)
+	if e, ok := g.wrapSigned(typ, x, op, y); ok {
+		return e
+	}
 	return g.cCast(typ, &CBinaryExpr{
 		Left:  cParenLazyOp(x, op),
 		Op:    op,
@@ -342,7 +345,13 @@ func (g *translator) newCBinaryExpr(exp types.Type, x Expr, op BinaryOp, y Expr)
 	})
 }
 
-func (g *translator) NewCBinaryExprT(x Expr, op BinaryOp, y Expr, _ types.Type) Expr {
+func (g *translator) NewCBinaryExprT(x Expr, op BinaryOp, y Expr, typ types.Type) Expr {
+	// Go uint is used for C unsigned int with UseGoInt; C wraparound can only be evaluated if their sizes match
+	if typ != g.env.Go().Uint() || typ.Sizeof() == g.env.IntSize() {
+		if l, ok := g.foldUnsignedLit(x, op, y, typ); ok {
+			return l
+		}
+	}
 	return g.NewCBinaryExpr(x, op, y)
 }
 
@@ -609,6 +618,9 @@ func (e *CParentExpr) Uses() []types.Usage {
 
 func (g *translator) NewCIndexExpr(x, ind Expr, typ types.Type) Expr {
 	ind = cUnwrap(ind)
+	if row, ok := cUnwrap(x).(*CFlatRow); ok {
+		return g.flatIndex(row, ind, typ)
+	}
 	if types.Same(x.CType(nil), g.env.Go().String()) {
 		return &CIndexExpr{
 			ctype: g.env.Go().Byte(),
@@ -629,6 +641,9 @@ func (g *translator) NewCIndexExpr(x, ind Expr, typ types.Type) Expr {
 		return g.cDeref(cPtrOffset(x, ind))
 	}
 	if arr, ok := types.Unwrap(x.CType(nil)).(types.ArrayType); ok {
+		if row := g.flatRow(x, arr, ind, typ); row != nil {
+			return row
+		}
 		typ = arr.Elem()
 	} else if typ == nil {
 		panic("no type for an element")
@@ -782,6 +797,9 @@ func (e *CIncrExpr) ToStmt() []CStmt {
 }
 
 func (e *CIncrExpr) AsExpr() GoExpr {
+	if a, ok := cUnwrap(e.Expr).(*AtomicIdent); ok {
+		return a.incrExpr(e.Decr, e.Prefix)
+	}
 	pi := types.NewIdent("p", e.g.env.PtrT(e.Expr.CType(nil)))
 	p := pi.GoIdent()
 	y := e.g.cAddr(e.Expr).AsExpr()
@@ -921,10 +939,18 @@ func (g *translator) NewCUnaryExpr(op UnaryOp, x Expr) Expr {
 	case UnarySizeof:
 		return g.cSizeofE(x)
 	case UnaryXor, UnaryMinus, UnaryPlus:
-		if xk := x.CType(nil).Kind(); xk.IsBool() {
+		if xt := x.CType(nil); xt.Kind().IsBool() {
 			x = g.cCast(g.env.DefIntT(), x)
 		} else if v, ok := x.(IntLit); ok && op == UnaryMinus {
 			return v.Negate()
+		} else if pt := g.env.IntPromote(xt); pt != xt {
+			// (-uc) -> -int(uc)
+			x = g.cCast(pt, x)
+		}
+		if op == UnaryMinus {
+			if e, ok := g.wrapSignedNeg(x); ok {
+				return e
+			}
 		}
 	}
 	return g.newUnaryExpr(op, x)
@@ -1186,6 +1212,17 @@ func (e *CAssignExpr) AsExpr() GoExpr {
 			stmts...,
 		)
 	}
+	if a, ok := e.Stmt.Left.(*AtomicIdent); ok {
+		y := e.Stmt.Right.AsExpr()
+		if e.Stmt.Op == BinOpAdd {
+			return a.add(y)
+		}
+		return callLambda(
+			ret.GoType(),
+			a.store(e.Stmt.Op, y),
+			returnStmt(a.AsExpr()),
+		)
+	}
 	x, y := &TakeAddr{g: e.Stmt.g, X: e.Stmt.Left}, e.Stmt.Right
 	p := types.NewIdent("p", x.CType(nil))
 	pg := p.GoIdent()
@@ -1345,7 +1382,7 @@ func (e *CCompLitExpr) isZero() bool {
 }
 
 func (e *CCompLitExpr) AsExpr() GoExpr {
-	if e.isZero() {
+	if at, ok := types.Unwrap(e.Type).(types.ArrayType); e.isZero() && !(ok && at.IsSlice()) {
 		// special case: MyStruct{0} usually means the same in C as MyStruct{} in Go
 		return &ast.CompositeLit{
 			Type: e.Type.GoType(),
@@ -1361,7 +1398,7 @@ func (e *CCompLitExpr) AsExpr() GoExpr {
 	if isArr {
 		// check if array elements are ordered so we can skip indexes in the init
 		at, ok := types.Unwrap(e.CType(nil)).(types.ArrayType)
-		if ok && at.Len() == len(e.Fields) {
+		if ok && (at.Len() == len(e.Fields) || at.IsSlice()) {
 			ordered = true
 			for i, f := range e.Fields {
 				if f.Index == nil {
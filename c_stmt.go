@@ -3,6 +3,9 @@ package cxgo
 import (
 	"go/ast"
 	"go/token"
+	"strconv"
+
+	token2 "modernc.org/token"
 
 	"github.com/gotranspile/cxgo/types"
 )
@@ -39,6 +42,8 @@ func cEachBlockStmt(fnc func([]CStmt), stmts []CStmt) {
 			}
 		case *CForStmt:
 			cEachBlockStmt(fnc, s.Body.Stmts)
+		case *CRangeStmt:
+			cEachBlockStmt(fnc, s.Body.Stmts)
 		case *CSwitchStmt:
 			for _, c := range s.Cases {
 				cEachBlockStmt(fnc, c.Stmts)
@@ -183,28 +188,88 @@ func (g *translator) NewCaseStmt(exp Expr, stmts ...CStmt) *CCaseStmt {
 	return &CCaseStmt{g: g, Expr: exp, Stmts: stmts}
 }
 
+// maxCaseRange is the maximal number of values in a GNU case range that is expanded to a list of values.
+const maxCaseRange = 8
+
+// NewCaseRange creates a case for a GNU case range: "case lo ... hi:". Short constant ranges are expanded
+// to a list of values, while others are matched with comparisons (see CSwitchStmt.AsStmt).
+func (g *translator) NewCaseRange(lo, hi Expr, stmts ...CStmt) *CCaseStmt {
+	c := &CCaseStmt{g: g, Expr: lo, To: hi, Stmts: stmts}
+	l, ok1 := constIntValue(lo)
+	h, ok2 := constIntValue(hi)
+	if !ok1 || !ok2 || h < l || h-l >= maxCaseRange {
+		return c
+	}
+	base := 10
+	if lit, ok := cUnwrap(lo).(IntLit); ok && lit.base != 0 {
+		base = lit.base
+	}
+	c.To = nil
+	for v := l + 1; v < h; v++ {
+		c.Alt = append(c.Alt, cIntLit(v, base))
+	}
+	if h > l {
+		c.Alt = append(c.Alt, hi)
+	}
+	return c
+}
+
 type CCaseStmt struct {
 	g     *translator
 	Expr  Expr
+	To    Expr   // upper bound of a GNU case range, starting from Expr
+	Alt   []Expr // additional values matched by the same case
 	Stmts []CStmt
 }
 
 func (s *CCaseStmt) Visit(v Visitor) {
 	v(s.Expr)
+	v(s.To)
+	for _, e := range s.Alt {
+		v(e)
+	}
 	for _, st := range s.Stmts {
 		v(st)
 	}
 }
 
-func (s *CCaseStmt) GoCaseClause() *ast.CaseClause {
-	stmts := s.g.NewCBlock(s.Stmts...).GoBlockStmt()
+// withStmts returns a copy of the case label with different statements.
+func (s *CCaseStmt) withStmts(stmts []CStmt) *CCaseStmt {
+	return &CCaseStmt{g: s.g, Expr: s.Expr, To: s.To, Alt: s.Alt, Stmts: stmts}
+}
+
+// goValues returns Go expressions for values matched by the case. If x is set, the case is a part of the switch
+// without a tag, thus values are compared with x.
+func (s *CCaseStmt) goValues(x Expr) []GoExpr {
 	if s.Expr == nil {
-		return &ast.CaseClause{
-			Body: stmts.List,
+		return nil
+	}
+	if x == nil {
+		list := []GoExpr{s.Expr.AsExpr()}
+		for _, e := range s.Alt {
+			list = append(list, e.AsExpr())
 		}
+		return list
+	}
+	var list []GoExpr
+	if s.To != nil {
+		list = append(list, And(
+			s.g.Compare(x, BinOpGte, s.Expr),
+			s.g.Compare(x, BinOpLte, s.To),
+		).AsExpr())
+	} else {
+		list = append(list, s.g.Compare(x, BinOpEq, s.Expr).AsExpr())
+	}
+	for _, e := range s.Alt {
+		list = append(list, s.g.Compare(x, BinOpEq, e).AsExpr())
 	}
+	return list
+}
+
+func (s *CCaseStmt) GoCaseClause() *ast.CaseClause {
+	stmts := s.g.NewCBlock(s.Stmts...).GoBlockStmt()
 	return &ast.CaseClause{
-		List: []GoExpr{s.Expr.AsExpr()},
+		List: s.goValues(nil),
 		Body: stmts.List,
 	}
 }
@@ -218,6 +283,12 @@ func (s *CCaseStmt) Uses() []types.Usage {
 	if s.Expr != nil {
 		list = append(list, types.UseRead(s.Expr)...)
 	}
+	if s.To != nil {
+		list = append(list, types.UseRead(s.To)...)
+	}
+	for _, e := range s.Alt {
+		list = append(list, types.UseRead(e)...)
+	}
 	for _, c := range s.Stmts {
 		list = append(list, c.Uses()...)
 	}
@@ -351,6 +422,17 @@ func (s *CSwitchStmt) Visit(v Visitor) {
 func (s *CSwitchStmt) addStmts(stmts []CStmt) {
 	for _, st := range s.g.NewCBlock(stmts...).Stmts {
 		if c, ok := st.(*CCaseStmt); ok {
+			if c.Expr != nil && s.Cond != nil {
+				// typed constants, like enums, must match the type of the condition in Go
+				if _, ok := c.Expr.CType(nil).(types.Named); ok {
+					if ct := s.Cond.CType(nil); !types.Same(ct, c.Expr.CType(nil)) {
+						c.Expr = s.g.cCast(ct, c.Expr)
+						if c.To != nil {
+							c.To = s.g.cCast(ct, c.To)
+						}
+					}
+				}
+			}
 			sub := c.Stmts
 			c.Stmts = nil
 			s.Cases = append(s.Cases, c)
@@ -379,10 +461,74 @@ func (s *CSwitchStmt) EachStmt(fnc CStmtFunc) bool {
 	return gmod
 }
 
+// hasRanges checks if the switch has GNU case ranges that are not expanded to a list of values.
+func (s *CSwitchStmt) hasRanges() bool {
+	for _, c := range s.Cases {
+		if c.To != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// scopedName returns a name of a variable that doesn't shadow identifiers used in given nodes.
+func scopedName(prefix string, nodes ...Node) string {
+	used := make(map[string]struct{})
+	for _, n := range nodes {
+		Walk(n, func(n Node) bool {
+			if id, ok := n.(Ident); ok {
+				if id := id.Identifier(); id != nil {
+					used[id.GoIdent().Name] = struct{}{}
+				}
+			}
+			return true
+		})
+	}
+	name := prefix
+	for i := 2; ; i++ {
+		if _, ok := used[name]; !ok {
+			return name
+		}
+		name = prefix + strconv.Itoa(i)
+	}
+}
+
+// AsStmt converts the switch to Go. Empty cases are merged with the next one, and cases that don't end with a jump
+// fall through to the next one explicitly. Switches with case ranges use comparisons instead of the tag, and the
+// condition is evaluated only once.
 func (s *CSwitchStmt) AsStmt() []GoStmt {
-	var stmts []GoStmt
+	var (
+		init GoStmt
+		tag  GoExpr
+		x    Expr
+	)
+	if s.hasRanges() {
+		x = s.Cond
+		if _, ok := x.(IdentExpr); !ok {
+			id := types.NewIdent(scopedName("v", s), s.Cond.CType(nil))
+			init = define(id.GoIdent(), s.Cond.AsExpr())
+			x = IdentExpr{id}
+		}
+	} else {
+		tag = s.Cond.AsExpr()
+	}
+	var (
+		stmts   []GoStmt
+		pending []GoExpr // values of empty cases merged with the next one
+	)
 	for i, c := range s.Cases {
-		cs := c.GoCaseClause()
+		cs := &ast.CaseClause{
+			List: c.goValues(x),
+			Body: c.g.NewCBlock(c.Stmts...).GoBlockStmt().List,
+		}
+		if len(cs.Body) == 0 && c.Expr != nil && i != len(s.Cases)-1 && s.Cases[i+1].Expr != nil {
+			pending = append(pending, cs.List...)
+			continue
+		}
+		if c.Expr != nil {
+			cs.List = append(pending, cs.List...)
+			pending = nil
+		}
 		sub := cs.Body
 		if len(sub) == 0 {
 			if i != len(s.Cases)-1 {
@@ -391,7 +537,7 @@ func (s *CSwitchStmt) AsStmt() []GoStmt {
 		} else {
 			switch b := sub[len(sub)-1].(type) {
 			case *ast.BranchStmt:
-				if b.Tok == token.BREAK {
+				if b.Tok == token.BREAK && b.Label == nil {
 					sub = sub[:len(sub)-1]
 				}
 			case *ast.ReturnStmt:
@@ -405,7 +551,8 @@ func (s *CSwitchStmt) AsStmt() []GoStmt {
 		stmts = append(stmts, cs)
 	}
 	return []GoStmt{&ast.SwitchStmt{
-		Tag:  s.Cond.AsExpr(),
+		Init: init,
+		Tag:  tag,
 		Body: block(stmts...),
 	}}
 }
@@ -483,6 +630,11 @@ func (s *CForStmt) AsStmt() []GoStmt {
 					)
 					for i := range sp.Names {
 						lhs = append(lhs, sp.Names[i])
+						if v, ok := sp.Values[i].(*ast.Ident); ok && (v.Name == "true" || v.Name == "false") {
+							// bool constants don't need a conversion
+							rhs = append(rhs, v)
+							continue
+						}
 						rhs = append(rhs, &ast.CallExpr{Fun: sp.Type, Args: []ast.Expr{sp.Values[i]}})
 					}
 					init = &ast.AssignStmt{
@@ -551,7 +703,7 @@ func (g *translator) switchCanBreak(s *CSwitchStmt) bool {
 	}
 	for i, c := range s.Cases {
 		if len(c.Stmts) == 0 {
-			if i == len(c.Stmts)-1 {
+			if i == len(s.Cases)-1 {
 				// last is empty - will exit
 				return true
 			}
@@ -565,13 +717,76 @@ func (g *translator) switchCanBreak(s *CSwitchStmt) bool {
 	return false
 }
 
+// NewCDoWhileStmt creates a loop for "do { ... } while (cond)", which becomes "for { ...; if !cond { break } }".
+//
+// Constant conditions are recognized: "while (1)" is an infinite loop, and the body of "while (0)" is executed once,
+// thus it becomes a block, unless it breaks from the loop. Since continue must check the condition in C,
+// loops with continue become "for ok := true; ok; ok = cond { ... }" instead.
 func (g *translator) NewCDoWhileStmt(cond Expr, stmts []CStmt) CStmt {
+	brk, cont := loopJumps(stmts)
+	v, isConst := cIsBoolConst(cond)
+	switch {
+	case isConst && v:
+		return g.NewCForStmt(nil, nil, nil, stmts)
+	case isConst && !brk && !cont:
+		return g.newBlockStmt(stmts...)
+	case isConst && !cont:
+		return g.NewCForStmt(nil, nil, nil, append(stmts, &CBreakStmt{}))
+	case cont:
+		nodes := []Node{cond}
+		for _, st := range stmts {
+			nodes = append(nodes, st)
+		}
+		ok := types.NewIdent(scopedName("ok", nodes...), g.env.Go().Bool())
+		iter := g.NewCAssignStmt(IdentExpr{ok}, "", g.ToBool(cond))
+		if len(iter) != 1 {
+			break
+		}
+		f := g.NewCForDeclStmt(&CVarDecl{CVarSpec: CVarSpec{
+			g:     g,
+			Type:  ok.CType(nil),
+			Names: []*types.Ident{ok},
+			Inits: []Expr{Bool(true)},
+		}}, g.ToBool(IdentExpr{ok}), nil, stmts)
+		f.Iter = iter[0]
+		return f
+	}
 	stmts = append(stmts, g.NewCIfStmt(
 		g.cNot(cond), []CStmt{&CBreakStmt{}}, nil,
 	))
 	return g.NewCForStmt(nil, nil, nil, stmts)
 }
 
+// loopJumps checks if statements of the loop body break from the loop, or continue it.
+func loopJumps(stmts []CStmt) (brk, cont bool) {
+	for _, st := range stmts {
+		Walk(st, func(n Node) bool {
+			switch n := n.(type) {
+			case *CForStmt, *CRangeStmt:
+				return false
+			case *CSwitchStmt:
+				// breaks only exit the switch
+				for _, c := range n.Cases {
+					if _, c2 := loopJumps(c.Stmts); c2 {
+						cont = true
+					}
+				}
+				return false
+			case *CBreakStmt:
+				if n.Label == "" {
+					brk = true
+				}
+			case *CContinueStmt:
+				if n.Label == "" {
+					cont = true
+				}
+			}
+			return true
+		})
+	}
+	return brk, cont
+}
+
 type CGotoStmt struct {
 	Label string
 }
@@ -614,24 +829,36 @@ func countBreak(stmts ...CStmt) int {
 	return cnt
 }
 
-type CContinueStmt struct{}
+type CContinueStmt struct {
+	Label string // optional loop label
+}
 
 func (s *CContinueStmt) Visit(v Visitor) {}
 
 func (s *CContinueStmt) AsStmt() []GoStmt {
-	return []GoStmt{&ast.BranchStmt{Tok: token.CONTINUE}}
+	st := &ast.BranchStmt{Tok: token.CONTINUE}
+	if s.Label != "" {
+		st.Label = ident(s.Label)
+	}
+	return []GoStmt{st}
 }
 
 func (s *CContinueStmt) Uses() []types.Usage {
 	return nil
 }
 
-type CBreakStmt struct{}
+type CBreakStmt struct {
+	Label string // optional loop or switch label
+}
 
 func (s *CBreakStmt) Visit(v Visitor) {}
 
 func (s *CBreakStmt) AsStmt() []GoStmt {
-	return []GoStmt{&ast.BranchStmt{Tok: token.BREAK}}
+	st := &ast.BranchStmt{Tok: token.BREAK}
+	if s.Label != "" {
+		st.Label = ident(s.Label)
+	}
+	return []GoStmt{st}
 }
 
 func (s *CBreakStmt) Uses() []types.Usage {
@@ -653,10 +880,11 @@ func (g *translator) NewReturnStmt(x Expr, rtyp types.Type) []CStmt {
 func (g *translator) NewReturnStmt1(x Expr, rtyp types.Type) CStmt {
 	x = cUnwrap(x)
 	if rtyp != nil {
-		x = g.cCast(rtyp, x)
+		x = g.implicitCast(rtyp, x)
 	}
 	return &CReturnStmt{
 		Expr: x,
+		pos:  g.pos,
 	}
 }
 
@@ -666,6 +894,7 @@ func (g *translator) NewZeroReturnStmt(rtyp types.Type) []CStmt {
 
 type CReturnStmt struct {
 	Expr Expr
+	pos  token2.Position // position in C source; the return type may be set later
 }
 
 func (s *CReturnStmt) Visit(v Visitor) {
@@ -694,6 +923,7 @@ func (g *translator) setReturnType(ret types.Type, stmts []CStmt) {
 	for _, s := range stmts {
 		switch s := s.(type) {
 		case *CReturnStmt:
+			g.checkConversionAt(s.pos, ret, s.Expr)
 			s.Expr = g.cCast(ret, s.Expr)
 		case *BlockStmt:
 			g.setReturnType(ret, s.Stmts)
@@ -759,6 +989,9 @@ func (g *translator) isReturnOrExit(s CStmt) bool {
 					g.env.Go().PanicFunc():
 					return true
 				}
+				if id.Identifier().GoName == stdioExitName {
+					return true
+				}
 			}
 		}
 	}
@@ -1340,6 +1573,8 @@ func (g *translator) NewCDeclStmt1(decl CDecl) CStmt {
 
 func (g *translator) NewCDeclStmt(decl CDecl) []CStmt {
 	switch d := decl.(type) {
+	case *CStaticAssert:
+		return []CStmt{d}
 	case *CVarDecl:
 		for i, v := range d.Inits {
 			switch v.(type) {
@@ -1418,6 +1653,18 @@ func (s *CIncrStmt) AsStmt() []GoStmt {
 		x := cPtrOffset(s.g.ToPointer(s.Expr), arg)
 		return asStmts(s.g.NewCAssignStmt(s.Expr, "", x))
 	}
+	switch e := cUnwrap(s.Expr).(type) {
+	case *Deref:
+		if _, _, ok := e.byteOrderPtr(); ok {
+			op := BinOpAdd
+			if s.Decr {
+				op = BinOpSub
+			}
+			return asStmts(s.g.NewCAssignStmt(e, op, cIntLit(1, 10)))
+		}
+	case *AtomicIdent:
+		return []GoStmt{e.incr(s.Decr)}
+	}
 	var tok token.Token
 	if s.Decr {
 		tok = token.DEC
@@ -1440,7 +1687,8 @@ func (s *CIncrStmt) Uses() []types.Usage {
 func (g *translator) NewCAssignStmtP(x Expr, op BinaryOp, y Expr) *CAssignStmt {
 	x = cUnwrap(x)
 	y = cUnwrap(y)
-	r := g.cCast(x.CType(nil), y)
+	op, y = g.arithAssign(x, op, y)
+	r := g.implicitCast(x.CType(nil), y)
 	return &CAssignStmt{
 		g:     g,
 		Left:  x,
@@ -1492,14 +1740,54 @@ func (g *translator) NewCAssignStmt(x Expr, op BinaryOp, y Expr) []CStmt {
 			y = cPtrOffset(g.ToPointer(x), y)
 		}
 	}
+	op, y = g.arithAssign(x, op, y)
 	return []CStmt{&CAssignStmt{
 		g:     g,
 		Left:  x,
 		Op:    op,
-		Right: g.cCast(x.CType(nil), y),
+		Right: g.implicitCast(x.CType(nil), y),
 	}}
 }
 
+// arithAssign expands compound division and modulo assignments if the result depends on C arithmetic conversions:
+// x /= y -> x = T(x / y). Other operations give the same result when done directly in the type of x,
+// unless x is an integer and y is a float: x *= 1.5 -> x = T(float64(x) * 1.5).
+func (g *translator) arithAssign(x Expr, op BinaryOp, y Expr) (BinaryOp, Expr) {
+	if op.IsArithm() && !x.HasSideEffects() && types.Unwrap(x.CType(nil)).Kind().IsInt() && y.CType(nil).Kind().IsFloat() {
+		return "", g.NewCBinaryExpr(x, op, y)
+	}
+	if g.conf.WrapSigned && op.wrapsOnOverflow() && !x.HasSideEffects() {
+		if _, ok := wrapUnsigned(x.CType(nil)); ok {
+			// x += y -> x = int32(uint32(x) + uint32(y))
+			return "", g.NewCBinaryExpr(x, op, y)
+		}
+	}
+	if op != BinOpDiv && op != BinOpMod {
+		return op, y
+	}
+	if x.HasSideEffects() || !g.needsArithConv(x.CType(nil), y) {
+		return op, y
+	}
+	return "", g.NewCBinaryExpr(g.cCast(g.env.IntPromote(x.CType(nil)), x), op, y)
+}
+
+// needsArithConv checks if the result of division of x by y depends on C arithmetic conversions,
+// thus it cannot be done directly in the type of x.
+func (g *translator) needsArithConv(xt types.Type, y Expr) bool {
+	xi, ok := types.Unwrap(xt).(types.IntType)
+	if !ok {
+		return false
+	}
+	if l, ok := cUnwrap(y).(IntLit); ok {
+		return !litCanStore(xi, l)
+	}
+	yi, ok := types.Unwrap(y.CType(nil)).(types.IntType)
+	if !ok || yi.Kind().IsUntyped() {
+		return false
+	}
+	return yi.Signed() != xi.Signed() || yi.Sizeof() > xi.Sizeof()
+}
+
 type CAssignStmt struct {
 	g     *translator
 	Left  Expr
@@ -1513,6 +1801,21 @@ func (s *CAssignStmt) Visit(v Visitor) {
 }
 
 func (s *CAssignStmt) AsStmt() []GoStmt {
+	switch d := cUnwrap(s.Left).(type) {
+	case *Deref:
+		if p, t, ok := d.byteOrderPtr(); ok && (s.Op == "" || !p.HasSideEffects()) {
+			y := s.Right.AsExpr()
+			if s.Op != "" {
+				y = &ast.BinaryExpr{X: d.AsExpr(), Op: s.Op.GoToken(), Y: y}
+			}
+			return []GoStmt{s.g.byteOrderStore(p, t, y)}
+		} else if ok {
+			// cannot evaluate the pointer twice; keep the host byte order
+			return []GoStmt{assignTok(deref(d.X.AsExpr()), s.Op.GoAssignToken(), s.Right.AsExpr())}
+		}
+	case *AtomicIdent:
+		return []GoStmt{d.store(s.Op, s.Right.AsExpr())}
+	}
 	x := s.Left.AsExpr()
 	y := s.Right.AsExpr()
 	return []GoStmt{
@@ -2,6 +2,7 @@ package cxgo
 
 import (
 	"fmt"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
@@ -34,13 +35,39 @@ func (g *translator) convertTypeOper(p cc.Operand, where token.Position) types.T
 // convertType is similar to newTypeCC but it will first consult the type cache.
 func (g *translator) convertType(conf IdentConfig, t cc.Type, where token.Position) types.Type {
 	defer func() {
-		if r := recover(); r != nil {
+		switch r := recover().(type) {
+		case nil:
+		case error:
+			panic(fmt.Errorf("type conversion failed at %v: %w", where, r))
+		default:
 			panic(fmt.Errorf("type conversion failed at %v: %v", where, r))
 		}
 	}()
 	// custom type overrides coming from the config
 	// note that we don't save them since they might depend
 	// not only on the input type, but also on a field name
+	if ht := g.hintType(conf, func() types.Type {
+		return g.newTypeCC(IdentConfig{}, t, where)
+	}, where); ht != nil {
+		return ht
+	}
+	// allow invalid types, they might still be useful
+	// since one may define them in a separate Go file
+	// and make the code valid
+	if t.Kind() == cc.Invalid {
+		return types.UnkT(g.env.PtrSize())
+	}
+	if ct, ok := g.ctypes[t]; ok {
+		return ct
+	}
+	ct := g.newTypeCC(conf, t, where)
+	g.ctypes[t] = ct
+	return ct
+}
+
+// hintType returns a type for the type hint from the config, or nil if there's no hint.
+// The function is called to convert the C type without the hint.
+func (g *translator) hintType(conf IdentConfig, conv func() types.Type, where token.Position) types.Type {
 	switch conf.Type {
 	case HintBool:
 		return g.env.Go().Bool()
@@ -49,7 +76,7 @@ func (g *translator) convertType(conf IdentConfig, t cc.Type, where token.Positi
 	case HintString:
 		return g.env.Go().String()
 	case HintSlice:
-		ct := g.newTypeCC(IdentConfig{}, t, where)
+		ct := conv()
 		var elem types.Type
 		switch ct := ct.(type) {
 		case types.PtrType:
@@ -63,19 +90,10 @@ func (g *translator) convertType(conf IdentConfig, t cc.Type, where token.Positi
 			elem = g.env.Go().Byte()
 		}
 		return types.SliceT(elem)
+	case HintSlices, HintFlat:
+		return g.multiDimType(conf.Type, conv(), where)
 	}
-	// allow invalid types, they might still be useful
-	// since one may define them in a separate Go file
-	// and make the code valid
-	if t.Kind() == cc.Invalid {
-		return types.UnkT(g.env.PtrSize())
-	}
-	if ct, ok := g.ctypes[t]; ok {
-		return ct
-	}
-	ct := g.newTypeCC(conf, t, where)
-	g.ctypes[t] = ct
-	return ct
+	return nil
 }
 
 // convertTypeRoot is the same as convertType, but it applies a workaround for
@@ -137,6 +155,7 @@ func (g *translator) newNamedTypeAt(name string, typ, elem cc.Type, where token.
 		g.aliases[name] = sub
 		return sub
 	}
+	g.checkDef(g.typeDefs, "type", name, ccDefKey(elem), where)
 	return g.newOrFindNamedType(name, func() types.Type {
 		return g.convertTypeRoot(conf, elem, where)
 	})
@@ -166,7 +185,9 @@ func (g *translator) newOrFindNamedType(name string, underlying func() types.Typ
 	if typ, ok := g.named[name]; ok {
 		return typ
 	}
+	g.typeScope = append(g.typeScope, name)
 	und := underlying()
+	g.typeScope = g.typeScope[:len(g.typeScope)-1]
 	if typ, ok := g.named[name]; ok {
 		return typ
 	}
@@ -180,6 +201,7 @@ func (g *translator) newNamedType(name string, underlying types.Type) types.Name
 	}
 	goname := ""
 	if c, ok := g.idents[name]; ok && c.Rename != "" {
+		g.conf.debug("renaming type", "name", name, "to", c.Rename)
 		goname = c.Rename
 	}
 	nt := types.NamedTGo(name, goname, underlying)
@@ -371,6 +393,11 @@ func (g *translator) convertStructType(conf IdentConfig, t cc.Type, where token.
 	if c, ok := g.idents[sname]; ok {
 		conf = c
 	}
+	// name of the enclosing named type, used to derive names for anonymous field types
+	parent := sname
+	if parent == "" && len(g.typeScope) != 0 {
+		parent = g.typeScope[len(g.typeScope)-1]
+	}
 	fconf := make(map[string]IdentConfig)
 	for _, f := range conf.Fields {
 		fconf[f.Name] = f
@@ -380,7 +407,12 @@ func (g *translator) convertStructType(conf IdentConfig, t cc.Type, where token.
 		for i := 0; i < t.NumField(); i++ {
 			f := t.FieldByIndex([]int{i})
 			fc := fconf[f.Name().String()]
-			ft := g.convertTypeRoot(fc, f.Type(), where)
+			var ft types.Type
+			if nt := g.convertAnonFieldType(parent, fc, f, where); nt != nil {
+				ft = nt
+			} else {
+				ft = g.convertTypeRoot(fc, f.Type(), where)
+			}
 			if f.Name() == 0 {
 				st := types.Unwrap(ft).(*types.StructType)
 				fields = append(fields, st.Fields()...)
@@ -388,6 +420,7 @@ func (g *translator) convertStructType(conf IdentConfig, t cc.Type, where token.
 			}
 			fname := g.newIdent(f.Name().String(), ft)
 			if fc.Rename != "" {
+				g.conf.debug("renaming field", "name", fname.Name, "to", fc.Rename)
 				fname.GoName = fc.Rename
 			} else if !g.conf.UnexportedFields {
 				fname.GoName = asExportedName(fname.Name)
@@ -406,17 +439,65 @@ func (g *translator) convertStructType(conf IdentConfig, t cc.Type, where token.
 			s = types.StructT(fields)
 		}
 		s.Where = where.String()
-		if t.Name() == 0 {
-			return s
-		}
+		g.addLayout(conf, t, s)
 		return s
 	}
 	if t.Name() == 0 {
 		return buildType()
 	}
+	if !t.IsIncomplete() {
+		g.checkDef(g.typeDefs, "type", sname, ccDefKey(t), where)
+	}
 	return g.newOrFindNamedType(sname, buildType)
 }
 
+// anonTypeName returns C and Go names for an anonymous struct or union type declared as a field of the parent type.
+//
+// C name is a dot-separated path to the field (e.g. "foo.bar"), and can be used in IdentConfig to rename the type.
+// Go name is derived from the parent type name and the field name (e.g. "fooBar").
+func (g *translator) anonTypeName(parent, field string) (string, string) {
+	cname := parent + "." + field
+	if c, ok := g.idents[cname]; ok && c.Rename != "" {
+		return cname, c.Rename
+	}
+	pname := parent
+	if nt, ok := g.named[parent]; ok {
+		pname = nt.Name().GoIdent().Name
+	} else if c, ok := g.idents[parent]; ok && c.Rename != "" {
+		pname = c.Rename
+	} else if i := strings.LastIndexByte(parent, '.'); i >= 0 {
+		_, pname = g.anonTypeName(parent[:i], parent[i+1:])
+	}
+	return cname, pname + asExportedName(field)
+}
+
+// convertAnonFieldType creates a named type for an anonymous struct or union declared as a field,
+// if Config.AnonTypeNames is set. It returns nil if the type should be kept anonymous.
+func (g *translator) convertAnonFieldType(parent string, conf IdentConfig, f cc.Field, where token.Position) types.Type {
+	if !g.conf.AnonTypeNames || parent == "" || f.Name() == 0 || conf.Type != "" {
+		return nil
+	}
+	ft := f.Type()
+	if k := ft.Kind(); (k != cc.Struct && k != cc.Union) || ft.Name() != 0 || ft != ft.Alias() {
+		return nil
+	}
+	if t, ok := g.ctypes[ft]; ok {
+		return t
+	}
+	cname, goname := g.anonTypeName(parent, f.Name().String())
+	if nt, ok := g.named[cname]; ok {
+		return nt
+	}
+	g.typeScope = append(g.typeScope, cname)
+	und := g.convertStructType(conf, ft, where)
+	g.typeScope = g.typeScope[:len(g.typeScope)-1]
+	nt := types.NamedTGo(cname, goname, und)
+	g.named[cname] = nt
+	g.ctypes[ft] = nt
+	g.anonTypes[parent] = append(g.anonTypes[parent], nt)
+	return nt
+}
+
 func (g *translator) convertFuncType(conf IdentConfig, d *cc.Declarator, t cc.Type, where token.Position) *types.FuncType {
 	if kind := t.Kind(); kind != cc.Function {
 		panic(kind)
@@ -475,6 +556,9 @@ func (g *translator) convertFuncType(conf IdentConfig, d *cc.Declarator, t cc.Ty
 			}
 		}
 	}
+	if conf.Return != "" {
+		rconf.Type = conf.Return
+	}
 	ret := g.convertTypeRootOpt(rconf, t.Result(), where)
 	if t.IsVariadic() {
 		return g.env.VarFuncT(ret, args...)
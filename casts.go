@@ -1,7 +1,6 @@
 package cxgo
 
 import (
-	"fmt"
 	"math"
 
 	"github.com/gotranspile/cxgo/types"
@@ -20,6 +19,11 @@ func (g *translator) cCast(toType types.Type, x Expr) Expr {
 	if toType == g.env.Go().Any() {
 		return x
 	}
+	if pt, ok := toType.(types.PtrType); ok && g.conf.ContainerOf {
+		if e := g.containerOf(pt, x); e != nil {
+			return e
+		}
+	}
 	if at, ok := toType.(types.ArrayType); ok && at.IsSlice() {
 		switch x := x.(type) {
 		case Nil:
@@ -48,6 +52,9 @@ func (g *translator) cCast(toType types.Type, x Expr) Expr {
 				}
 			}
 		}
+		if e := g.reallocSlice(at, x); e != nil {
+			return e
+		}
 	}
 	if xKind.Is(types.Array) && !toKind.Is(types.Array) {
 		x = g.cAddr(x)
@@ -151,12 +158,24 @@ func (g *translator) cCast(toType types.Type, x Expr) Expr {
 	case toKind.IsPtr():
 		return g.cPtrToPtr(toType, g.ToPointer(x))
 	case toKind.IsInt():
+		if ti, ok := types.Unwrap(toType).(types.IntType); ok {
+			if l, ok := g.constUintLit(x, ti); ok {
+				x = l
+			}
+		}
 		if l, ok := cUnwrap(x).(IntLit); ok {
 			ti, ok := types.Unwrap(toType).(types.IntType)
 			if l.IsUint() && ok && ti.Signed() && !litCanStore(ti, l) {
 				// try overflowing it
 				return l.OverflowInt(ti.Sizeof())
 			}
+			if !l.IsNeg() && ok && !ti.Signed() && !litCanStore(ti, l) {
+				// truncate it, as C does for unsigned types
+				return &CCastExpr{
+					Type: toType,
+					Expr: l.TruncUint(ti.Sizeof()),
+				}
+			}
 			if l.IsUint() || (ok && ti.Signed()) {
 				return &CCastExpr{
 					Type: toType,
@@ -239,46 +258,17 @@ func (g *translator) cCast(toType types.Type, x Expr) Expr {
 			return g.cCast(toType, x)
 		}
 		ft, fx := types.Unwrap(toType).(*types.FuncType), types.Unwrap(xType).(*types.FuncType)
-		if (ft.Variadic() == fx.Variadic() || !ft.Variadic()) && ft.ArgN() >= fx.ArgN() && ((ft.Return() != nil) == (fx.Return() != nil) || (ft.Return() == nil && fx.Return() != nil)) {
-			// cannot cast directly, but can return lambda instead
-			callArgs := make([]Expr, 0, ft.ArgN())
-			funcArgs := make([]*types.Field, 0, ft.ArgN())
-			for i, a := range ft.Args() {
-				at := a.Type()
-				name := types.NewIdent(fmt.Sprintf("arg%d", i+1), at)
-				if a.Name != nil && !a.Name.IsUnnamed() {
-					name = types.NewIdent(a.Name.Name, at)
-				}
-				funcArgs = append(funcArgs, &types.Field{
-					Name: name,
-				})
-				callArgs = append(callArgs, IdentExpr{name})
+		if types.Same(ft, fx) {
+			// same signature, only the names differ
+			_, ok1 := toType.(types.Named)
+			_, ok2 := xType.(types.Named)
+			if ok1 && ok2 {
+				return &CCastExpr{Type: toType, Expr: x}
 			}
-			argn := fx.ArgN()
-			if ft.Variadic() {
-				callArgs = append(callArgs, &ExpandExpr{X: IdentExpr{types.NewIdent("_rest", types.UnkT(1))}})
-				argn++
-			}
-			e := g.NewCCallExpr(g.ToFunc(x, nil), callArgs[:argn])
-			var stmts []CStmt
-			if ft.Return() != nil {
-				stmts = g.NewReturnStmt(e, ft.Return())
-			} else {
-				stmts = NewCExprStmt(e)
-			}
-			var litT *types.FuncType
-			if ft.Variadic() {
-				litT = g.env.VarFuncT(
-					ft.Return(),
-					funcArgs...,
-				)
-			} else {
-				litT = g.env.FuncT(
-					ft.Return(),
-					funcArgs...,
-				)
-			}
-			return g.NewFuncLit(litT, stmts...)
+			return x
+		}
+		if e := g.funcAdapter(toType, x); e != nil {
+			return e
 		}
 		// incompatible function types - force error
 		return x
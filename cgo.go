@@ -0,0 +1,489 @@
+package cxgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	gotypes "go/types"
+	"strconv"
+
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// CgoFuncDecl is a function declaration that calls a C function with the same name via cgo.
+//
+// It is used instead of CFuncDecl if Config.Cgo or IdentConfig.Cgo is set. Go signature is the same as for translated function,
+// arguments and return values are converted to cgo types. Structs and pointers are passed as is, thus their memory layout
+// is checked at compile time; see layoutChecks.
+type CgoFuncDecl struct {
+	Name *types.Ident
+	Type *types.FuncType
+	// C type of the function; used to derive cgo type names
+	ctype cc.Type
+}
+
+// hasCgo checks if any of the functions are called via cgo, either because of Cgo or IdentConfig.Cgo.
+func (c *Config) hasCgo() bool {
+	if c.Cgo {
+		return true
+	}
+	for _, id := range c.Idents {
+		if id.Cgo {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *translator) newCgoFuncDecl(name *types.Ident, ft *types.FuncType, ctype cc.Type) *CgoFuncDecl {
+	return &CgoFuncDecl{Name: name, Type: ft, ctype: ctype}
+}
+
+func (d *CgoFuncDecl) Visit(v Visitor) {
+	v(IdentExpr{d.Name})
+}
+
+func (d *CgoFuncDecl) Uses() []types.Usage {
+	return []types.Usage{{Ident: d.Name, Access: types.AccessDefine}}
+}
+
+func (d *CgoFuncDecl) AsDecl() []GoDecl {
+	ft := d.Type.GoFuncType()
+	// all arguments must be named to pass them to C
+	var args []*ast.Ident
+	for i, f := range ft.Params.List {
+		if len(f.Names) == 0 || f.Names[0].Name == "_" {
+			f.Names = []*ast.Ident{ident("arg" + strconv.Itoa(i+1))}
+		}
+		if i < d.Type.ArgN() {
+			args = append(args, f.Names[0])
+		}
+	}
+	body, err := d.goBody(args)
+	if err != nil {
+		// keep the same API; the function can be implemented manually later
+		body = []GoStmt{&ast.ExprStmt{X: call(ident("panic"), &ast.BasicLit{
+			Kind:  token.STRING,
+			Value: strconv.Quote(fmt.Sprintf("cxgo: cannot call %s via cgo: %v", d.Name.Name, err)),
+		})}}
+	}
+	return []GoDecl{&ast.FuncDecl{
+		Name: d.Name.GoIdent(),
+		Type: ft,
+		Body: &ast.BlockStmt{List: body},
+	}}
+}
+
+// layoutChecks returns compile-time assertions that Go types of structs and pointer elements have the same size
+// and field offsets as cgo types, since values are reinterpreted in memory. Assertions already in seen are skipped.
+// See cgoLayoutCheck.
+func (d *CgoFuncDecl) layoutChecks(seen map[string]struct{}) []GoStmt {
+	if d.ctype.IsVariadic() {
+		return nil
+	}
+	var stmts []GoStmt
+	check := func(fnc string, x, y GoExpr) {
+		stmts = append(stmts, cgoCheck(seen, fnc, x, y)...)
+	}
+	add := func(t types.Type, ct cc.Type) {
+		if ct.Kind() == cc.Ptr {
+			pt, ok := types.Unwrap(t).(types.PtrType)
+			if !ok || pt.Elem() == nil || ct.Elem().Kind() == cc.Void {
+				return
+			}
+			t, ct = pt.Elem(), ct.Elem()
+		} else if cgoNumeric(t, ct) {
+			// passed by value with a conversion
+			return
+		}
+		name, err := cgoTypeName(ct)
+		if err != nil {
+			return
+		}
+		zero := func(typ GoType) GoExpr {
+			return &ast.StarExpr{X: call(ident("new"), typ)}
+		}
+		st, isStruct := types.Unwrap(t).(*types.StructType)
+		if isStruct && ct.Kind() == cc.Struct {
+			zero = func(typ GoType) GoExpr {
+				return &ast.CompositeLit{Type: typ}
+			}
+		}
+		check("unsafe.Sizeof", zero(t.GoType()), zero(name))
+		if !isStruct || ct.Kind() != cc.Struct {
+			return
+		}
+		for i := 0; i < ct.NumField(); i++ {
+			f := ct.FieldByIndex([]int{i})
+			if f.Name() == 0 || f.IsBitField() {
+				continue
+			}
+			for _, sf := range st.Fields() {
+				if sf.Name.Name != f.Name().String() {
+					continue
+				}
+				cname := f.Name().String()
+				if token.IsKeyword(cname) {
+					// cgo prefixes Go keywords with an underscore
+					cname = "_" + cname
+				}
+				check("unsafe.Offsetof",
+					&ast.SelectorExpr{X: zero(t.GoType()), Sel: sf.Name.GoIdent()},
+					&ast.SelectorExpr{X: zero(name), Sel: ident(cname)},
+				)
+				break
+			}
+		}
+	}
+	params := d.ctype.Parameters()
+	for i, a := range d.Type.Args() {
+		if i < len(params) {
+			add(a.Type(), params[i].Type())
+		}
+	}
+	if ret := d.Type.Return(); ret != nil {
+		add(ret, d.ctype.Result())
+	}
+	return stmts
+}
+
+// cgoCheck returns an assertion that fnc returns the same value for x and y, unless it's already in seen.
+func cgoCheck(seen map[string]struct{}, fnc string, x, y GoExpr) []GoStmt {
+	ind := &ast.BinaryExpr{X: call(ident(fnc), x), Op: token.SUB, Y: call(ident(fnc), y)}
+	key := gotypes.ExprString(ind)
+	if _, ok := seen[key]; ok {
+		return nil
+	}
+	seen[key] = struct{}{}
+	return []GoStmt{&ast.AssignStmt{
+		Lhs: []GoExpr{ident("_")},
+		Tok: token.ASSIGN,
+		Rhs: []GoExpr{&ast.IndexExpr{X: ident("x"), Index: ind}},
+	}}
+}
+
+// CgoVarDecl is a global variable that points to a C variable with the same name via cgo.
+//
+// It is used instead of CVarDecl if some functions of the file are kept in C by IdentConfig.Cgo, since Go and C functions
+// must use the same variable. Uses of the variable are replaced with a dereference of the pointer; see cgoGlobals.
+//
+// Static variables cannot be referenced by cgo directly, thus their address is returned by a C function
+// added to the preamble; see cgoAccessor.
+type CgoVarDecl struct {
+	Name   *types.Ident // pointer to the C variable
+	CName  string
+	Static bool
+}
+
+func (d *CgoVarDecl) Visit(v Visitor) {
+	v(IdentExpr{d.Name})
+}
+
+func (d *CgoVarDecl) Uses() []types.Usage {
+	return []types.Usage{{Ident: d.Name, Access: types.AccessDefine}}
+}
+
+// cVar returns a cgo expression for the C variable.
+func (d *CgoVarDecl) cVar() GoExpr {
+	if d.Static {
+		return &ast.StarExpr{X: call(ident("C." + cgoAccessorName(d.CName)))}
+	}
+	return ident("C." + d.CName)
+}
+
+func (d *CgoVarDecl) AsDecl() []GoDecl {
+	var addr GoExpr
+	if d.Static {
+		addr = call(ident("C." + cgoAccessorName(d.CName)))
+	} else {
+		addr = &ast.UnaryExpr{Op: token.AND, X: d.cVar()}
+	}
+	ptr := convExpr(d.Name.CType(nil).GoType(), call(unsafePtr(), addr))
+	return []GoDecl{&ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{
+		&ast.ValueSpec{Names: []*ast.Ident{d.Name.GoIdent()}, Values: []GoExpr{ptr}},
+	}}}
+}
+
+// layoutChecks returns an assertion that the Go type of the variable has the same size as the C variable.
+func (d *CgoVarDecl) layoutChecks(seen map[string]struct{}) []GoStmt {
+	elem := d.Name.CType(nil).(types.PtrType).Elem()
+	return cgoCheck(seen, "unsafe.Sizeof", &ast.StarExpr{X: call(ident("new"), elem.GoType())}, d.cVar())
+}
+
+func cgoAccessorName(name string) string {
+	return "cxgo_addr_" + name
+}
+
+// cgoAccessor returns a C function for the preamble that returns the address of a static variable.
+func cgoAccessor(name string) string {
+	return fmt.Sprintf("static __typeof__(%s) *%s(void) { return &%s; }", name, cgoAccessorName(name), name)
+}
+
+// cgoGlobals replaces global variables of the file with pointers to C variables, if some of its functions are kept
+// in C by IdentConfig.Cgo. Otherwise, Go and C functions would use separate copies of the variables.
+// Variables accessed with sync/atomic are kept in Go. Accessors of static variables are added to cgoPreamble.
+func (g *translator) cgoGlobals(decl []CDecl) {
+	if g.conf.Cgo || !g.conf.hasCgo() {
+		return
+	}
+	vars := make(map[*types.Ident]*types.Ident)
+	for _, d := range decl {
+		vd, ok := d.(*CVarDecl)
+		if !ok || vd.Const || len(vd.Names) != 1 {
+			continue
+		}
+		id := vd.Names[0]
+		vars[id] = types.NewIdentGo(id.Name, id.GoIdent().Name, g.env.PtrT(id.CType(nil)))
+	}
+	for _, d := range decl {
+		Walk(d, func(n Node) bool {
+			if a, ok := n.(*AtomicIdent); ok {
+				delete(vars, a.X.Ident)
+			}
+			return true
+		})
+	}
+	if len(vars) == 0 {
+		return
+	}
+	for i, d := range decl {
+		if vd, ok := d.(*CVarDecl); ok && len(vd.Names) == 1 {
+			if ptr := vars[vd.Names[0]]; ptr != nil {
+				decl[i] = &CgoVarDecl{Name: ptr, CName: vd.Names[0].Name, Static: vd.Static}
+				if vd.Static {
+					g.cgoPreamble = append(g.cgoPreamble, cgoAccessor(vd.Names[0].Name))
+				}
+				continue
+			}
+		}
+		Rewrite(d, nil, func(c *Cursor) bool {
+			id, ok := c.Node().(Ident)
+			if !ok {
+				return true
+			}
+			ptr := vars[id.Identifier()]
+			if ptr == nil {
+				return true
+			}
+			x := &Deref{g: g, X: PtrIdent{ptr}}
+			switch id.(type) {
+			case IdentExpr:
+				c.Replace(x)
+			case PtrIdent:
+				c.Replace(g.ToPointer(x))
+			case BoolIdent:
+				c.Replace(g.ToBool(x))
+			}
+			return true
+		})
+	}
+}
+
+// cgoLayoutCheck returns a function with layout assertions of cgo bindings in the file. This is similar
+// to translator.layoutCheck, but compares with the C compiler used by cgo:
+//
+//	func _() {
+//		var x [1]struct{}
+//		_ = x[unsafe.Sizeof(T{})-unsafe.Sizeof(C.T{})]
+//		_ = x[unsafe.Offsetof(T{}.F)-unsafe.Offsetof(C.T{}.f)]
+//	}
+//
+// The index is out of range, or overflows, if the values differ.
+func cgoLayoutCheck(stmts []GoStmt) GoDecl {
+	stmts = append([]GoStmt{
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{
+			&ast.ValueSpec{Names: []*ast.Ident{ident("x")}, Type: &ast.ArrayType{
+				Len: &ast.BasicLit{Kind: token.INT, Value: "1"},
+				Elt: ident("struct{}"),
+			}},
+		}}},
+	}, stmts...)
+	return &ast.FuncDecl{
+		Name: ident("_"),
+		Type: &ast.FuncType{Params: &ast.FieldList{}},
+		Body: &ast.BlockStmt{List: stmts},
+	}
+}
+
+func (d *CgoFuncDecl) goBody(args []*ast.Ident) ([]GoStmt, error) {
+	if d.ctype.IsVariadic() {
+		return nil, errors.New("variadic functions are not supported by cgo")
+	}
+	params := d.ctype.Parameters()
+	if len(params) == 1 && params[0].Type().Kind() == cc.Void {
+		params = nil
+	}
+	if len(params) != len(args) {
+		return nil, fmt.Errorf("unexpected number of arguments: %d vs %d", len(params), len(args))
+	}
+	cargs := make([]GoExpr, 0, len(args))
+	for i, p := range params {
+		x, err := cgoArg(args[i], d.Type.Args()[i].Type(), p.Type())
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+		cargs = append(cargs, x)
+	}
+	res := call(ident("C."+d.Name.Name), cargs...)
+	ret := d.Type.Return()
+	if ret == nil {
+		return []GoStmt{&ast.ExprStmt{X: res}}, nil
+	}
+	ctyp := d.ctype.Result()
+	if _, err := cgoTypeName(ctyp); err != nil {
+		return nil, fmt.Errorf("return value: %w", err)
+	}
+	switch {
+	case cgoNumeric(ret, ctyp):
+		return []GoStmt{returnStmt(convExpr(ret.GoType(), res))}, nil
+	case ctyp.Kind() == cc.Ptr:
+		return []GoStmt{returnStmt(convExpr(ret.GoType(), call(unsafePtr(), res)))}, nil
+	default:
+		// struct is returned by value; reinterpret the memory
+		r := ident("r")
+		return []GoStmt{
+			&ast.AssignStmt{Lhs: []GoExpr{r}, Tok: token.DEFINE, Rhs: []GoExpr{res}},
+			returnStmt(&ast.StarExpr{X: convExpr(&ast.StarExpr{X: ret.GoType()},
+				call(unsafePtr(), &ast.UnaryExpr{Op: token.AND, X: r}))}),
+		}, nil
+	}
+}
+
+// convExpr converts an expression to a given type, adding parentheses if necessary.
+func convExpr(typ GoType, x GoExpr) GoExpr {
+	if _, ok := typ.(*ast.Ident); ok {
+		return call(typ, x)
+	}
+	return call(paren(typ), x)
+}
+
+// cgoNumeric checks if the value can be converted between Go and cgo types with a regular conversion.
+func cgoNumeric(t types.Type, ct cc.Type) bool {
+	k := t.Kind()
+	return (k.IsInt() || k.IsFloat() || k.IsBool()) && ct.Kind() != cc.Ptr
+}
+
+// cgoArg converts a Go argument to a cgo type.
+func cgoArg(x *ast.Ident, t types.Type, ct cc.Type) (GoExpr, error) {
+	name, err := cgoTypeName(ct)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case cgoNumeric(t, ct):
+		return convExpr(name, x), nil
+	case ct.Kind() == cc.Ptr:
+		p := call(unsafePtr(), x)
+		if id, ok := name.(*ast.Ident); ok && id.Name == "unsafe.Pointer" {
+			return p, nil
+		}
+		return convExpr(name, p), nil
+	default:
+		// struct is passed by value; reinterpret the memory
+		return &ast.StarExpr{X: convExpr(&ast.StarExpr{X: name},
+			call(unsafePtr(), &ast.UnaryExpr{Op: token.AND, X: x}))}, nil
+	}
+}
+
+// cgoTypeName returns a cgo name of a C type, for example C.int or *C.struct_foo.
+func cgoTypeName(t cc.Type) (GoExpr, error) {
+	if t.Kind() == cc.Ptr && t.Elem().Kind() == cc.Function {
+		return nil, errors.New("function pointers are not supported")
+	}
+	if t.IsAliasType() {
+		if d := t.AliasDeclarator(); d != nil {
+			return ident("C." + d.Name().String()), nil
+		}
+	}
+	var name string
+	switch t.Kind() {
+	case cc.Char:
+		name = "char"
+	case cc.SChar:
+		name = "schar"
+	case cc.UChar:
+		name = "uchar"
+	case cc.Short:
+		name = "short"
+	case cc.UShort:
+		name = "ushort"
+	case cc.Int:
+		name = "int"
+	case cc.UInt:
+		name = "uint"
+	case cc.Long:
+		name = "long"
+	case cc.ULong:
+		name = "ulong"
+	case cc.LongLong:
+		name = "longlong"
+	case cc.ULongLong:
+		name = "ulonglong"
+	case cc.Float:
+		name = "float"
+	case cc.Double:
+		name = "double"
+	case cc.Bool:
+		name = "_Bool"
+	case cc.Enum:
+		if t.Tag() == 0 {
+			name = "int"
+		} else {
+			name = "enum_" + t.Tag().String()
+		}
+	case cc.Struct, cc.Union:
+		if t.Tag() == 0 {
+			return nil, errors.New("anonymous struct types are not supported")
+		}
+		pref := "struct_"
+		if t.Kind() == cc.Union {
+			pref = "union_"
+		}
+		name = pref + t.Tag().String()
+	case cc.Ptr:
+		elem := t.Elem()
+		if elem.Kind() == cc.Void {
+			return unsafePtr(), nil
+		}
+		sub, err := cgoTypeName(elem)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.StarExpr{X: sub}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %v", t)
+	}
+	return ident("C." + name), nil
+}
+
+// usesCgo checks if declarations reference the "C" pseudo-package.
+func usesCgo(decls []GoDecl) bool {
+	used := make(map[string]struct{})
+	goUsedImports(used, decls)
+	_, ok := used["C"]
+	return ok
+}
+
+// insertCgoPreamble adds import "C" with a given preamble after the package clause of Go source.
+func insertCgoPreamble(src []byte, preamble string) []byte {
+	i := bytes.Index(src, []byte("package "))
+	if i < 0 {
+		return src
+	}
+	j := bytes.IndexByte(src[i:], '\n')
+	if j < 0 {
+		return src
+	}
+	i += j + 1
+	var buf bytes.Buffer
+	buf.Write(src[:i])
+	buf.WriteString("\n/*\n")
+	buf.WriteString(preamble)
+	buf.WriteString("\n*/\nimport \"C\"\n")
+	buf.Write(src[i:])
+	return buf.Bytes()
+}
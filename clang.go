@@ -0,0 +1,286 @@
+package cxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// clangNode is a node of the AST dumped by clang with -ast-dump=json.
+// Only the attributes used by the translator are decoded.
+type clangNode struct {
+	ID           string          `json:"id"`
+	Kind         string          `json:"kind"`
+	Loc          *clangLoc       `json:"loc"`
+	Range        *clangRange     `json:"range"`
+	Name         string          `json:"name"`
+	Type         *clangType      `json:"type"`
+	ArgType      *clangType      `json:"argType"`    // UnaryExprOrTypeTraitExpr
+	Opcode       string          `json:"opcode"`     // BinaryOperator, UnaryOperator
+	IsPostfix    bool            `json:"isPostfix"`  // UnaryOperator
+	IsArrow      bool            `json:"isArrow"`    // MemberExpr
+	IsImplicit   bool            `json:"isImplicit"` // declarations
+	CastKind     string          `json:"castKind"`   // casts
+	Value        json.RawMessage `json:"value"`      // literals
+	TagUsed      string          `json:"tagUsed"`    // RecordDecl
+	Complete     bool            `json:"completeDefinition"`
+	StorageClass string          `json:"storageClass"` // VarDecl, FunctionDecl
+	Init         string          `json:"init"`         // VarDecl
+	PreviousDecl string          `json:"previousDecl"` // redeclarations
+	DeclID       string          `json:"declId"`       // LabelStmt
+	TargetLabel  string          `json:"targetLabelDeclId"`
+	RefDecl      *clangNode      `json:"referencedDecl"` // DeclRefExpr
+	Field        *clangNode      `json:"field"`          // InitListExpr of a union
+	Inner        []*clangNode    `json:"inner"`
+}
+
+type clangType struct {
+	QualType string `json:"qualType"`
+}
+
+type clangLoc struct {
+	Offset    int       `json:"offset"`
+	File      string    `json:"file"`
+	Line      int       `json:"line"`
+	Col       int       `json:"col"`
+	Spelling  *clangLoc `json:"spellingLoc"`
+	Expansion *clangLoc `json:"expansionLoc"`
+}
+
+type clangRange struct {
+	Begin clangLoc `json:"begin"`
+	End   clangLoc `json:"end"`
+}
+
+// Position returns the position of the node. Locations in macro expansions are resolved to the expansion site.
+func (n *clangNode) Position() token.Position {
+	l := n.Loc
+	if (l == nil || l.Col == 0) && n.Range != nil {
+		l = &n.Range.Begin
+	}
+	if l == nil {
+		return token.Position{}
+	}
+	return token.Position{Filename: l.File, Offset: l.Offset, Line: l.Line, Column: l.Col}
+}
+
+// clangLocs restores file names and lines of locations in the AST.
+// Clang omits them if they are the same as in the previously written location.
+type clangLocs struct {
+	file string
+	line int
+}
+
+func (s *clangLocs) fill(l *clangLoc) {
+	if l == nil {
+		return
+	}
+	if l.Spelling != nil || l.Expansion != nil {
+		s.fill(l.Spelling)
+		s.fill(l.Expansion)
+		if e := l.Expansion; e != nil {
+			l.Offset, l.File, l.Line, l.Col = e.Offset, e.File, e.Line, e.Col
+		}
+		return
+	}
+	if l.Col == 0 {
+		// invalid location, for example of a builtin declaration
+		return
+	}
+	if l.File == "" {
+		l.File = s.file
+	} else {
+		s.file = l.File
+	}
+	if l.Line == 0 {
+		l.Line = s.line
+	} else {
+		s.line = l.Line
+	}
+}
+
+// walk visits locations in the same order as clang writes them.
+func (s *clangLocs) walk(n *clangNode) {
+	if n == nil {
+		return
+	}
+	s.fill(n.Loc)
+	if n.Range != nil {
+		s.fill(&n.Range.Begin)
+		s.fill(&n.Range.End)
+	}
+	for _, c := range n.Inner {
+		s.walk(c)
+	}
+}
+
+// parseClangAST decodes a translation unit dumped by clang with -ast-dump=json.
+func parseClangAST(data []byte) (*clangNode, error) {
+	var tu clangNode
+	if err := json.Unmarshal(data, &tu); err != nil {
+		return nil, fmt.Errorf("cannot decode clang AST: %w", err)
+	}
+	if tu.Kind != "TranslationUnitDecl" {
+		return nil, fmt.Errorf("expected a clang translation unit, got: %q", tu.Kind)
+	}
+	var locs clangLocs
+	locs.walk(&tu)
+	return &tu, nil
+}
+
+// clangUnit is a translation unit read from the clang AST.
+type clangUnit struct {
+	tu *clangNode
+}
+
+func (u clangUnit) convertDecls(g *translator, cur string) []CDecl {
+	g.file = nil
+	return newClangConv(g).convertTU(cur, u.tu)
+}
+
+// clangArgs returns arguments for dumping the AST of a C file with clang.
+// Include paths and macros are passed the same way as to the built-in parser, except for bundled headers,
+// which are only usable by the built-in parser. System headers are searched by clang.
+func clangArgs(root, fname, predef string, conf Config) []string {
+	cmd := strings.Fields(conf.Clang)
+	if len(cmd) == 0 {
+		cmd = []string{"clang"}
+	}
+	args := append(cmd, "-Xclang", "-ast-dump=json", "-fsyntax-only", "-x", "c")
+	if conf.C23 {
+		args = append(args, "-std=c2x")
+	}
+	for _, d := range conf.Define {
+		if d.Value != "" {
+			args = append(args, "-D"+strings.TrimSpace(d.Name)+"="+strings.TrimSpace(d.Value))
+		} else {
+			args = append(args, "-D"+strings.TrimSpace(d.Name))
+		}
+	}
+	for _, dir := range conf.Include {
+		args = append(args, "-I", dir)
+	}
+	for _, dir := range conf.SysInclude {
+		args = append(args, "-isystem", dir)
+	}
+	if !conf.IgnoreIncludeDir {
+		args = append(args,
+			"-I", filepath.Join(root, "includes"),
+			"-I", filepath.Join(root, "include"),
+		)
+	}
+	if predef != "" {
+		args = append(args, "-include", predef)
+	}
+	args = append(args, conf.ClangArgs...)
+	return append(args, fname)
+}
+
+// runClang dumps the AST of a C file with clang.
+func runClang(root, fname string, conf Config) ([]byte, error) {
+	if conf.FS != nil {
+		return nil, errors.New("clang frontend cannot read files from Config.FS")
+	}
+	var predef string
+	if conf.Predef != "" {
+		f, err := os.CreateTemp("", "cxgo_predef_*.h")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(conf.Predef)
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+		if err != nil {
+			return nil, err
+		}
+		predef = f.Name()
+	}
+	args := clangArgs(root, fname, predef, conf)
+	ctx := conf.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w\n%s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", args[0], err)
+	}
+	// diagnostics like #warning are printed by clang
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		if line != "" {
+			conf.logger().WarnContext(ctx, line)
+		}
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseClangAndTranslate is the same as parseAndTranslate, but uses clang to parse the file.
+func (p *TranslatorProject) parseClangAndTranslate(root, fname string, conf Config) (*translation, error) {
+	if conf.Cgo || conf.Coverage {
+		return nil, errors.New("cgo and coverage modes are not supported by the clang frontend")
+	}
+	conf.progress(fname, PassParse, 0, 0)
+	data, err := runClang(root, fname, conf)
+	if err != nil {
+		return nil, err
+	}
+	tu, err := parseClangAST(data)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Root == "" {
+		conf.Root = root
+	}
+	return p.translateUnit(fname, clangUnit{tu}, conf)
+}
+
+// TranslateClangAST takes a C translation unit dumped by clang and converts it to a list of Go declarations.
+// Types are shared with all other files translated by this project.
+//
+// The AST must be dumped as JSON with "clang -Xclang -ast-dump=json -fsyntax-only", and fname must match
+// the name of the file passed to clang. Only declarations from this file are translated.
+// Unlike the built-in parser, the clang AST has no macros, thus those are always expanded.
+func (p *TranslatorProject) TranslateClangAST(fname string, data []byte, conf Config) ([]GoDecl, error) {
+	if conf.Cgo || conf.Coverage {
+		return nil, errors.New("cgo and coverage modes are not supported by the clang frontend")
+	}
+	tu, err := parseClangAST(data)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := p.translateUnit(fname, clangUnit{tu}, conf)
+	if err != nil {
+		return nil, err
+	}
+	decls := tr.decls
+	if conf.TreeShake {
+		decls = filterReachable(decls, tr.roots)
+	}
+	return decls, nil
+}
+
+// TranslateClangAST takes a C translation unit dumped by clang and converts it to a list of Go declarations.
+// See TranslatorProject.TranslateClangAST for details.
+func TranslateClangAST(fname string, data []byte, env *libs.Env, conf Config) ([]GoDecl, error) {
+	return NewProject(env).TranslateClangAST(fname, data, conf)
+}
@@ -0,0 +1,1110 @@
+package cxgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// clangConv converts the clang AST to cxgo declarations.
+type clangConv struct {
+	g        *translator
+	root     string                  // absolute path of the project root; headers outside of it are system headers
+	decls    map[string]*types.Ident // identifiers of declarations, by node ID
+	typedefs map[string]*clangNode   // typedef declarations, by name
+	records  map[string]*clangNode   // struct, union and enum declarations, by tag key; see clangTagKey
+	labels   map[string]string       // label names, by declaration ID
+	types    map[string]types.Type   // converted types, by the type printed by clang
+}
+
+func newClangConv(g *translator) *clangConv {
+	return &clangConv{
+		g:        g,
+		decls:    make(map[string]*types.Ident),
+		typedefs: make(map[string]*clangNode),
+		records:  make(map[string]*clangNode),
+		labels:   make(map[string]string),
+		types:    make(map[string]types.Type),
+	}
+}
+
+func (c *clangConv) unsupported(n *clangNode) error {
+	return ErrorfWithPos(n.Position(), "clang: unsupported %s", n.Kind)
+}
+
+// child returns the i-th child of the node. Clang writes missing children as empty objects.
+func (c *clangConv) child(n *clangNode, i int) *clangNode {
+	if i >= len(n.Inner) || n.Inner[i].Kind == "" {
+		panic(c.unsupported(n))
+	}
+	return n.Inner[i]
+}
+
+func (n *clangNode) isDefinition() bool {
+	return n.Complete || (n.Kind == "EnumDecl" && len(n.Inner) != 0)
+}
+
+// tagKey returns a key of the struct, union or enum declaration in clangConv.records.
+func (n *clangNode) tagKey() string {
+	kind := n.TagUsed
+	if n.Kind == "EnumDecl" {
+		kind = "enum"
+	}
+	if n.Name != "" {
+		return kind + " " + n.Name
+	}
+	p := n.Position()
+	return fmt.Sprintf("%s @%s:%d:%d", kind, p.Filename, p.Line, p.Column)
+}
+
+// clangTagKey returns a key of the struct, union or enum type printed by clang, for example "struct point"
+// or "struct (unnamed struct at a.c:1:9)". It returns an empty string for other types.
+func clangTagKey(typ string) string {
+	for _, kind := range []string{"struct", "union", "enum"} {
+		rest, ok := strings.CutPrefix(typ, kind+" ")
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(rest, "(") && strings.HasSuffix(rest, ")") {
+			i := strings.LastIndex(rest, " at ")
+			if i < 0 {
+				return ""
+			}
+			return kind + " @" + rest[i+len(" at "):len(rest)-1]
+		}
+		return kind + " " + rest
+	}
+	return ""
+}
+
+// findRecord finds a declaration of the struct, union or enum, preferring complete definitions.
+func (c *clangConv) findRecord(key string) *clangNode {
+	if rec, ok := c.records[key]; ok {
+		return rec
+	}
+	// locations of unnamed types may be printed with a different file path
+	kind, loc, ok := strings.Cut(key, " @")
+	if !ok {
+		return nil
+	}
+	dir, base := filepath.Split(loc)
+	for k, rec := range c.records {
+		if k2, loc2, ok := strings.Cut(k, " @"); ok && k2 == kind && filepath.Base(loc2) == base {
+			if strings.HasSuffix(filepath.ToSlash(loc2), filepath.ToSlash(dir+base)) || dir == "" {
+				return rec
+			}
+		}
+	}
+	return nil
+}
+
+// index records declarations that types and statements may refer to.
+func (c *clangConv) index(n *clangNode) {
+	switch n.Kind {
+	case "TypedefDecl":
+		if _, ok := c.typedefs[n.Name]; !ok {
+			c.typedefs[n.Name] = n
+		}
+	case "RecordDecl", "EnumDecl":
+		key := n.tagKey()
+		if prev := c.records[key]; prev == nil || (!prev.isDefinition() && n.isDefinition()) {
+			c.records[key] = n
+		}
+	case "LabelStmt":
+		c.labels[n.DeclID] = n.Name
+	}
+	for _, s := range n.Inner {
+		c.index(s)
+	}
+}
+
+// inRoot checks if the file belongs to the project, as opposed to system headers.
+func (c *clangConv) inRoot(fname string) bool {
+	if fname == "" {
+		return false
+	}
+	abs, err := filepath.Abs(fname)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(c.root, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// loadLibrary loads the cxgo library for a system header, if there is one. Libraries provide Go declarations
+// for C functions and types, which replace the declarations from the header.
+func (c *clangConv) loadLibrary(fname string) {
+	if c.inRoot(fname) {
+		return
+	}
+	parts := strings.Split(filepath.ToSlash(fname), "/")
+	for n := 2; n >= 1; n-- {
+		if len(parts) < n {
+			continue
+		}
+		if _, ok := c.g.env.GetLibrary(strings.Join(parts[len(parts)-n:], "/")); ok {
+			return
+		}
+	}
+}
+
+func (c *clangConv) convertTU(cur string, tu *clangNode) []CDecl {
+	g := c.g
+	root := g.conf.Root
+	if root == "" {
+		root = filepath.Dir(cur)
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	c.root = root
+	headers := make(map[string]struct{})
+	for _, d := range tu.Inner {
+		c.index(d)
+		if f := d.Position().Filename; f != "" && !g.inCurFile(d) {
+			if _, ok := headers[f]; !ok {
+				headers[f] = struct{}{}
+				c.loadLibrary(f)
+			}
+		}
+	}
+	var decl []CDecl
+	total := len(tu.Inner)
+	for i := 0; i < total; i++ {
+		d := tu.Inner[i]
+		g.conf.progress(cur, PassConvert, i, total)
+		if d.IsImplicit || !g.inCurFile(d) {
+			continue
+		}
+		g.checkCancel()
+		g.pos = d.Position()
+		var cd []CDecl
+		switch d.Kind {
+		case "FunctionDecl":
+			cd = c.convertFunc(d)
+		case "VarDecl":
+			if d.StorageClass != "extern" {
+				cd = []CDecl{c.convertVar(d)}
+			}
+		case "EnumDecl":
+			var td *clangNode
+			if i+1 < total && c.isEnumTypedef(tu.Inner[i+1], d) {
+				td = tu.Inner[i+1]
+				i++
+			}
+			cd = c.convertEnum(d, td)
+		case "TypedefDecl", "RecordDecl":
+			cd = c.convertTypeDecl(d)
+		case "StaticAssertDecl", "EmptyDecl":
+			// already checked by clang
+		default:
+			panic(c.unsupported(d))
+		}
+		if g.needDeclPos() {
+			for _, x := range cd {
+				g.declPos[x] = d.Position()
+			}
+		}
+		decl = append(decl, cd...)
+	}
+	g.conf.progress(cur, PassConvert, total, total)
+	return decl
+}
+
+// declIdent returns an identifier for the declaration, creating it if necessary.
+func (c *clangConv) declIdent(n *clangNode, t types.Type) *types.Ident {
+	g := c.g
+	if id, ok := c.decls[n.ID]; ok {
+		return id
+	}
+	if id, ok := c.decls[n.PreviousDecl]; ok && n.PreviousDecl != "" {
+		c.decls[n.ID] = id
+		return id
+	}
+	var id *types.Ident
+	if lc, ok := g.locals[n.Name]; ok && lc.Rename != "" {
+		// don't reuse identifiers with the same name, the rename only affects this function
+		id = types.NewIdent(n.Name, t)
+		id.GoName = lc.Rename
+	} else {
+		id = g.newIdent(n.Name, t)
+		if to, ok := g.idents[n.Name]; ok && to.Rename != "" {
+			g.conf.debug("renaming identifier", "name", n.Name, "to", to.Rename)
+			id.GoName = to.Rename
+		}
+	}
+	c.decls[n.ID] = id
+	return id
+}
+
+// typeRoot is similar to convertTypeRoot, but accepts a type printed by clang.
+func (c *clangConv) typeRoot(conf IdentConfig, typ string) types.Type {
+	if t := c.g.hintType(conf, func() types.Type {
+		return c.parseType(typ)
+	}, c.g.pos); t != nil {
+		return t
+	}
+	return rootType(c.parseType(typ))
+}
+
+// exprType returns the type of the expression node.
+func (c *clangConv) exprType(n *clangNode) types.Type {
+	if n.Type == nil {
+		panic(c.unsupported(n))
+	}
+	return rootType(c.parseType(n.Type.QualType))
+}
+
+// typedefType converts a type defined by typedef. Typedefs from system headers are replaced with underlying
+// types, unless cxgo provides a replacement for them.
+func (c *clangConv) typedefType(name string) types.Type {
+	g := c.g
+	if t, ok := g.replaceType(name); ok {
+		return t
+	}
+	if t, ok := g.aliases[name]; ok {
+		return t
+	}
+	td := c.typedefs[name]
+	if td == nil || td.Type == nil {
+		panic(fmt.Errorf("clang: unknown type: %q", name))
+	}
+	conf := g.idents[name]
+	if !c.inRoot(td.Position().Filename) {
+		return c.typeRoot(conf, td.Type.QualType)
+	}
+	if k := clangTagKey(td.Type.QualType); k == "struct "+name || k == "union "+name {
+		// typedef struct x x
+		return c.parseType(td.Type.QualType)
+	}
+	nt := g.newOrFindNamedTypedef(name, func() types.Type {
+		return c.typeRoot(conf, td.Type.QualType)
+	})
+	if nt == nil {
+		return g.aliases[name]
+	}
+	return nt
+}
+
+// tagBase returns a base type for struct, union or enum with a given name or location.
+func (c *clangConv) tagBase(kind, name, loc string) clangBase {
+	key := kind + " " + name
+	if name == "" {
+		key = kind + " @" + loc
+	}
+	if kind == "enum" {
+		// same as the built-in parser, enums are translated as their underlying type
+		return clangBase{conv: func() types.Type { return c.g.env.C().Int() }}
+	}
+	return clangBase{name: name, conv: func() types.Type { return c.recordType(name, key) }}
+}
+
+// recordType converts a struct or union type, the same way as convertStructType.
+func (c *clangConv) recordType(name, key string) types.Type {
+	g := c.g
+	if name != "" {
+		if t, ok := g.replaceType(name); ok {
+			return t
+		}
+	}
+	rec := c.findRecord(key)
+	if rec == nil || !rec.Complete {
+		if name == "" {
+			panic(fmt.Errorf("clang: unknown type: %q", key))
+		}
+		return g.newOrFindNamedType(name, func() types.Type {
+			return types.StructT(nil)
+		})
+	}
+	conf := g.idents[name]
+	fconf := make(map[string]IdentConfig)
+	for _, f := range conf.Fields {
+		fconf[f.Name] = f
+	}
+	buildType := func() types.Type {
+		var fields []*types.Field
+		for _, f := range rec.Inner {
+			if f.Kind != "FieldDecl" {
+				continue
+			}
+			fc := fconf[f.Name]
+			ft := c.typeRoot(fc, f.Type.QualType)
+			if f.Name == "" {
+				st, ok := types.Unwrap(ft).(*types.StructType)
+				if !ok {
+					panic(c.unsupported(f))
+				}
+				fields = append(fields, st.Fields()...)
+				continue
+			}
+			fname := g.newIdent(f.Name, ft)
+			if fc.Rename != "" {
+				g.conf.debug("renaming field", "name", fname.Name, "to", fc.Rename)
+				fname.GoName = fc.Rename
+			} else if !g.conf.UnexportedFields {
+				fname.GoName = asExportedName(fname.Name)
+			}
+			fields = append(fields, &types.Field{
+				Name: fname,
+			})
+		}
+		var s *types.StructType
+		if rec.TagUsed == "union" {
+			s = types.UnionT(fields)
+		} else {
+			s = types.StructT(fields)
+		}
+		s.Where = rec.Position().String()
+		return s
+	}
+	if name == "" {
+		return buildType()
+	}
+	return g.newOrFindNamedType(name, buildType)
+}
+
+// convertTypeDecl converts typedef, struct and union declarations.
+func (c *clangConv) convertTypeDecl(d *clangNode) []CDecl {
+	g := c.g
+	var t types.Type
+	switch d.Kind {
+	case "TypedefDecl":
+		if _, ok := g.replaceType(d.Name); ok {
+			return nil
+		}
+		t = c.typedefType(d.Name)
+	case "RecordDecl":
+		if d.Name == "" || (!d.Complete && !g.conf.ForwardDecl) {
+			return nil
+		}
+		t = c.parseType(d.tagKey())
+	default:
+		panic(c.unsupported(d))
+	}
+	nt, ok := t.(types.Named)
+	if !ok {
+		// aliased or replaced
+		return nil
+	}
+	return []CDecl{&CTypeDef{nt}}
+}
+
+// isEnumTypedef checks if the typedef declares a name for the enum. Clang writes them as separate declarations.
+func (c *clangConv) isEnumTypedef(td, d *clangNode) bool {
+	if td.Kind != "TypedefDecl" || td.Type == nil {
+		return false
+	}
+	key := clangTagKey(td.Type.QualType)
+	return key != "" && c.findRecord(key) == d
+}
+
+// convertEnum converts enum constants, the same way as the built-in parser does.
+// If the enum has a typedef, constants are declared with that type.
+func (c *clangConv) convertEnum(d, td *clangNode) []CDecl {
+	g := c.g
+	var (
+		typ   types.Type
+		decls []CDecl
+	)
+	if name := d.Name; td != nil || name != "" {
+		if td != nil {
+			name = td.Name
+		}
+		nt := g.newOrFindNamedType(name, func() types.Type {
+			return g.env.DefIntT()
+		})
+		typ = nt
+		decls = append(decls, &CTypeDef{nt})
+	}
+	var (
+		consts []*clangNode
+		inits  []Expr
+	)
+	for _, e := range d.Inner {
+		if e.Kind != "EnumConstantDecl" {
+			continue
+		}
+		consts = append(consts, e)
+		var init Expr
+		if len(e.Inner) != 0 {
+			init = c.expr(e.Inner[0])
+		}
+		inits = append(inits, init)
+	}
+	return append(decls, g.newEnumDecl(typ, inits, func(i int, typ types.Type) *types.Ident {
+		return c.declIdent(consts[i], typ)
+	})...)
+}
+
+// initNode returns an initializer of the variable, or nil if there's none.
+func (c *clangConv) initNode(d *clangNode) *clangNode {
+	if d.Init == "" {
+		return nil
+	}
+	for i := len(d.Inner) - 1; i >= 0; i-- {
+		if n := d.Inner[i]; !strings.HasSuffix(n.Kind, "Attr") {
+			return n
+		}
+	}
+	return nil
+}
+
+func (c *clangConv) convertVar(d *clangNode) *CVarDecl {
+	g := c.g
+	vt := c.typeRoot(g.declConfig(d.Name), d.Type.QualType)
+	var inits []Expr
+	if n := c.initNode(d); n != nil {
+		init := c.initValue(vt, n)
+		g.checkConversion(vt, init)
+		inits = []Expr{init}
+	}
+	return &CVarDecl{
+		Static: d.StorageClass == "static",
+		CVarSpec: CVarSpec{
+			g:     g,
+			Type:  vt,
+			Names: []*types.Ident{c.declIdent(d, vt)},
+			Inits: inits,
+		},
+	}
+}
+
+func (c *clangConv) initValue(typ types.Type, n *clangNode) Expr {
+	if n.Kind != "InitListExpr" {
+		return c.expr(n)
+	}
+	var items []*CompLitField
+	if n.Field != nil {
+		// union initializer
+		id, ok := c.g.tryConvertIdentOn(typ, n.Field.Name)
+		if !ok {
+			panic(c.unsupported(n))
+		}
+		return c.g.NewCCompLitExpr(typ, []*CompLitField{{Field: id, Value: c.initValue(id.CType(nil), c.child(n, 0))}})
+	}
+	for i, x := range n.Inner {
+		if x.Kind == "ImplicitValueInitExpr" {
+			continue
+		}
+		var val Expr
+		if x.Kind == "InitListExpr" {
+			val = c.initValue(c.exprType(x), x)
+		} else {
+			val = c.expr(x)
+		}
+		items = append(items, &CompLitField{Index: cIntLit(int64(i), 10), Value: val})
+	}
+	return c.g.NewCCompLitExpr(typ, items)
+}
+
+// convertFunc converts a function declaration, the same way as convertFuncDef and convertFuncType.
+func (c *clangConv) convertFunc(d *clangNode) []CDecl {
+	g := c.g
+	name := d.Name
+	conf := g.idents[name]
+	var (
+		params []*clangNode
+		body   *clangNode
+	)
+	for _, n := range d.Inner {
+		switch n.Kind {
+		case "ParmVarDecl":
+			params = append(params, n)
+		case "CompoundStmt":
+			body = n
+		}
+	}
+	ft := c.funcType(conf, d, params)
+	if body == nil {
+		// forward declaration
+		if l, id, ok := g.tenv.LibIdentByName(name); ok && id.CType(nil).Kind().IsFunc() {
+			// forward declaration of stdlib function
+			// we must first load the corresponding library to the real env
+			l, ok = g.env.GetLibrary(l.Name)
+			if !ok {
+				panic("cannot load stdlib")
+			}
+			id, ok = l.Idents[name]
+			if !ok {
+				panic("cannot find stdlib ident")
+			}
+			c.decls[d.ID] = id
+			return nil
+		}
+		id := c.declIdent(d, ft)
+		if !g.conf.ForwardDecl {
+			return nil
+		}
+		return []CDecl{&CFuncDecl{Name: id, Type: ft}}
+	}
+	id := c.declIdent(d, ft)
+	var names []string
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	prevLocals := g.locals
+	g.locals = localConfigs(conf, names)
+	b := c.block(body).In(ft)
+	g.locals = prevLocals
+	pos := d.Position()
+	if r := d.Range; r != nil && r.Begin.Col != 0 {
+		pos.Offset, pos.Line = r.Begin.Offset, r.Begin.Line
+	}
+	return []CDecl{
+		&CFuncDecl{
+			Name: id,
+			Type: ft,
+			Body: g.traceFunc(name, ft, b),
+			Range: &Range{
+				Start:     pos.Offset,
+				StartLine: pos.Line,
+			},
+		},
+	}
+}
+
+func (c *clangConv) funcType(conf IdentConfig, d *clangNode, params []*clangNode) *types.FuncType {
+	g := c.g
+	t, ok := c.parseType(d.Type.QualType).(*types.FuncType)
+	if !ok {
+		panic(c.unsupported(d))
+	}
+	var rconf IdentConfig
+	aconf := make(map[string]IdentConfig)
+	iconf := make(map[int]IdentConfig)
+	for _, f := range conf.Fields {
+		if f.Name != "" {
+			if f.Name == "return" {
+				rconf = f
+			} else {
+				aconf[f.Name] = f
+			}
+		} else {
+			iconf[f.Index] = f
+		}
+	}
+	var (
+		args  []*types.Field
+		named int
+	)
+	for i, p := range params {
+		var fc IdentConfig
+		if ac, ok := aconf[p.Name]; ok {
+			fc = ac
+		} else if ac, ok = iconf[i]; ok {
+			fc = ac
+		}
+		at := c.typeRoot(fc, p.Type.QualType)
+		var name *types.Ident
+		if p.Name != "" {
+			name = c.declIdent(p, at)
+			named++
+		} else {
+			name = types.NewUnnamed(at)
+		}
+		args = append(args, &types.Field{
+			Name: name,
+		})
+	}
+	if named != 0 && len(args) != named {
+		for i, a := range args {
+			if a.Name.Name == "" && a.Name.GoName == "" {
+				a.Name.GoName = fmt.Sprintf("a%d", i+1)
+			}
+		}
+	}
+	if conf.Return != "" {
+		rconf.Type = conf.Return
+	}
+	ret := t.Return()
+	if rt := g.hintType(rconf, t.Return, g.pos); rt != nil {
+		ret = rt
+	}
+	if t.Variadic() {
+		return g.env.VarFuncT(ret, args...)
+	}
+	return g.env.FuncT(ret, args...)
+}
+
+// localDecls converts declarations of the declaration statement.
+func (c *clangConv) localDecls(n *clangNode) []CDecl {
+	var decls []CDecl
+	for i := 0; i < len(n.Inner); i++ {
+		d := n.Inner[i]
+		switch d.Kind {
+		case "VarDecl":
+			if d.StorageClass != "extern" {
+				decls = append(decls, c.convertVar(d))
+			}
+		case "EnumDecl":
+			var td *clangNode
+			if i+1 < len(n.Inner) && c.isEnumTypedef(n.Inner[i+1], d) {
+				td = n.Inner[i+1]
+				i++
+			}
+			decls = append(decls, c.convertEnum(d, td)...)
+		case "TypedefDecl", "RecordDecl":
+			decls = append(decls, c.convertTypeDecl(d)...)
+		case "StaticAssertDecl":
+			// already checked by clang
+		default:
+			panic(c.unsupported(d))
+		}
+	}
+	return decls
+}
+
+func (c *clangConv) block(n *clangNode) *BlockStmt {
+	stmts := c.stmts(n)
+	if len(stmts) == 1 {
+		if b, ok := stmts[0].(*BlockStmt); ok {
+			return b
+		}
+	}
+	return c.g.NewCBlock(stmts...)
+}
+
+func (c *clangConv) oneStmt(n *clangNode) CStmt {
+	stmts := c.stmts(n)
+	if len(stmts) == 1 {
+		return stmts[0]
+	}
+	return c.g.NewCBlock(stmts...)
+}
+
+func (c *clangConv) exprOpt(n *clangNode) Expr {
+	if n == nil || n.Kind == "" {
+		return nil
+	}
+	return c.expr(n)
+}
+
+func (c *clangConv) condOpt(n *clangNode) BoolExpr {
+	x := c.exprOpt(n)
+	if x == nil {
+		return nil
+	}
+	return c.g.ToBool(x)
+}
+
+// stmts converts a statement, the same way as convertStmt.
+func (c *clangConv) stmts(n *clangNode) []CStmt {
+	g := c.g
+	prev := g.pos
+	if p := n.Position(); p.IsValid() {
+		g.pos = p
+	}
+	defer func() { g.pos = prev }()
+	switch n.Kind {
+	case "CompoundStmt":
+		var stmts []CStmt
+		for _, s := range n.Inner {
+			stmts = append(stmts, c.stmts(s)...)
+		}
+		return []CStmt{g.newBlockStmt(stmts...)}
+	case "DeclStmt":
+		var stmts []CStmt
+		for _, d := range c.localDecls(n) {
+			stmts = append(stmts, g.NewCDeclStmt(d)...)
+		}
+		return stmts
+	case "NullStmt":
+		return nil
+	case "ReturnStmt":
+		var x Expr
+		if len(n.Inner) != 0 {
+			x = c.expr(n.Inner[0])
+		}
+		return g.NewReturnStmt(x, nil)
+	case "IfStmt": // cond, then, [else]
+		cond := c.expr(c.child(n, 0))
+		var els IfElseStmt
+		if len(n.Inner) > 2 {
+			els = g.toElseStmt(c.oneStmt(c.child(n, 2)))
+		}
+		return []CStmt{
+			g.NewCIfStmt(
+				g.ToBool(cond),
+				[]CStmt{c.block(c.child(n, 1))},
+				els,
+			),
+		}
+	case "WhileStmt": // cond, body
+		return []CStmt{
+			g.NewCForStmt(
+				nil,
+				g.ToBool(c.expr(c.child(n, 0))),
+				nil,
+				[]CStmt{c.block(c.child(n, 1))},
+			),
+		}
+	case "DoStmt": // body, cond
+		return []CStmt{
+			g.NewCDoWhileStmt(
+				c.expr(c.child(n, 1)),
+				[]CStmt{c.block(c.child(n, 0))},
+			),
+		}
+	case "ForStmt": // init, condition variable, cond, inc, body
+		if len(n.Inner) != 5 {
+			panic(c.unsupported(n))
+		}
+		init := n.Inner[0]
+		cond := c.condOpt(n.Inner[2])
+		iter := c.exprOpt(n.Inner[3])
+		body := []CStmt{c.block(c.child(n, 4))}
+		if init.Kind == "DeclStmt" {
+			return []CStmt{
+				g.NewCForDeclStmt(mergeVarDecls(c.localDecls(init), g.pos), cond, iter, body),
+			}
+		}
+		return []CStmt{
+			g.NewCForStmt(c.exprOpt(init), cond, iter, body),
+		}
+	case "SwitchStmt": // cond, body
+		return []CStmt{g.NewCSwitchStmt(
+			c.expr(c.child(n, 0)),
+			[]CStmt{c.block(c.child(n, 1))},
+		)}
+	case "CaseStmt": // value, stmt
+		if len(n.Inner) == 3 {
+			// GNU case range: low, high, stmt
+			return []CStmt{
+				g.NewCaseRange(c.expr(c.child(n, 0)), c.expr(c.child(n, 1)), c.stmts(c.child(n, 2))...),
+			}
+		}
+		if len(n.Inner) != 2 {
+			panic(c.unsupported(n))
+		}
+		return []CStmt{
+			g.NewCaseStmt(c.expr(c.child(n, 0)), c.stmts(c.child(n, 1))...),
+		}
+	case "DefaultStmt":
+		return []CStmt{
+			g.NewCaseStmt(nil, c.stmts(c.child(n, 0))...),
+		}
+	case "LabelStmt":
+		return append([]CStmt{
+			&CLabelStmt{Label: n.Name},
+		}, c.stmts(c.child(n, 0))...)
+	case "GotoStmt":
+		return []CStmt{
+			&CGotoStmt{Label: c.labels[n.TargetLabel]},
+		}
+	case "ContinueStmt":
+		return []CStmt{
+			&CContinueStmt{},
+		}
+	case "BreakStmt":
+		return []CStmt{
+			&CBreakStmt{},
+		}
+	case "GCCAsmStmt", "MSAsmStmt":
+		return g.convertAsmStmt(nil)
+	}
+	return NewCExprStmt(c.expr(n))
+}
+
+var (
+	clangBinOps = map[string]BinaryOp{
+		"*": BinOpMult, "/": BinOpDiv, "%": BinOpMod,
+		"+": BinOpAdd, "-": BinOpSub,
+		"<<": BinOpLsh, ">>": BinOpRsh,
+		"&": BinOpBitAnd, "^": BinOpBitXor, "|": BinOpBitOr,
+	}
+	clangCmpOps = map[string]ComparisonOp{
+		"<": BinOpLt, ">": BinOpGt, "<=": BinOpLte, ">=": BinOpGte,
+		"==": BinOpEq, "!=": BinOpNeq,
+	}
+)
+
+// expr converts an expression. Implicit casts are skipped, since the translator inserts conversions
+// the same way as for the built-in parser, which has no implicit casts in the AST.
+func (c *clangConv) expr(n *clangNode) Expr {
+	g := c.g
+	switch n.Kind {
+	case "ImplicitCastExpr", "ConstantExpr", "ExprWithCleanups", "PredefinedExpr":
+		return c.expr(c.child(n, 0))
+	case "ParenExpr":
+		return cParen(c.expr(c.child(n, 0)))
+	case "IntegerLiteral":
+		v, err := parseCIntLit(c.stringValue(n), g.conf.IntReformat)
+		if err != nil {
+			panic(ErrorWithPos(err, n.Position()))
+		}
+		return v
+	case "FloatingLiteral":
+		v, err := parseCFloatLit(c.stringValue(n))
+		if err != nil {
+			panic(ErrorWithPos(err, n.Position()))
+		}
+		return g.cCast(c.exprType(n), v)
+	case "CharacterLiteral":
+		var v int64
+		if err := json.Unmarshal(n.Value, &v); err != nil {
+			panic(ErrorWithPos(err, n.Position()))
+		}
+		if v < 0 || v > 0xff {
+			return cLitT(string(rune(v)), CLitWChar, c.exprType(n))
+		}
+		return cLitT(string(rune(byte(v))), CLitChar, c.exprType(n))
+	case "StringLiteral":
+		s, wide, err := unquoteClangString(c.stringValue(n))
+		if err != nil {
+			panic(ErrorWithPos(err, n.Position()))
+		}
+		if wide {
+			v, _ := g.parseCWStringLit(s)
+			return v
+		}
+		v, _ := g.parseCStringLit(s)
+		return v
+	case "DeclRefExpr":
+		return c.declRef(n)
+	case "CStyleCastExpr":
+		x := c.expr(c.child(n, 0))
+		if n.CastKind == "ToVoid" {
+			return x
+		}
+		return g.cCast(c.exprType(n), x)
+	case "BinaryOperator":
+		return c.binaryExpr(n)
+	case "CompoundAssignOperator":
+		op, ok := clangBinOps[strings.TrimSuffix(n.Opcode, "=")]
+		if !ok {
+			panic(c.unsupported(n))
+		}
+		x := c.expr(c.child(n, 0))
+		y := c.expr(c.child(n, 1))
+		return g.NewCAssignExpr(x, op, y)
+	case "UnaryOperator":
+		return c.unaryExpr(n)
+	case "CallExpr":
+		fnc := c.expr(c.child(n, 0))
+		var args []Expr
+		for _, a := range n.Inner[1:] {
+			args = append(args, c.expr(a))
+		}
+		return g.NewCCallExpr(g.ToFunc(fnc, ToFuncExpr(fnc.CType(nil))), args)
+	case "MemberExpr":
+		exp := c.expr(c.child(n, 0))
+		if n.Name == "" {
+			// anonymous struct or union, fields are merged into the parent
+			return exp
+		}
+		id, ok := g.tryConvertIdentOn(exp.CType(nil), n.Name)
+		if !ok {
+			panic(c.unsupported(n))
+		}
+		if _, ok := exp.CType(nil).(types.ArrayType); ok && n.IsArrow { // pointer accesses might be an array
+			return NewCSelectExpr(
+				g.NewCIndexExpr(exp, cUintLit(0, 10), c.exprType(n)), id,
+			)
+		}
+		return NewCSelectExpr(exp, id)
+	case "ArraySubscriptExpr":
+		return g.NewCIndexExpr(
+			c.expr(c.child(n, 0)),
+			c.expr(c.child(n, 1)),
+			c.exprType(n),
+		)
+	case "ConditionalOperator":
+		cond := c.expr(c.child(n, 0))
+		return g.NewCTernaryExpr(
+			g.ToBool(cond),
+			c.expr(c.child(n, 1)),
+			c.expr(c.child(n, 2)),
+		)
+	case "UnaryExprOrTypeTraitExpr":
+		switch n.Name {
+		case "sizeof":
+			if n.ArgType != nil {
+				return g.SizeofT(c.parseType(n.ArgType.QualType), nil)
+			}
+			return g.NewCUnaryExprT(UnarySizeof, c.expr(c.child(n, 0)), c.exprType(n))
+		case "alignof", "_Alignof", "__alignof":
+			if n.ArgType != nil {
+				return g.AlignofT(c.parseType(n.ArgType.QualType), nil)
+			}
+		}
+	case "InitListExpr":
+		return c.initValue(c.exprType(n), n)
+	case "CompoundLiteralExpr":
+		return c.initValue(c.parseType(n.Type.QualType), c.child(n, 0))
+	}
+	panic(c.unsupported(n))
+}
+
+func (c *clangConv) stringValue(n *clangNode) string {
+	var s string
+	if err := json.Unmarshal(n.Value, &s); err != nil {
+		panic(ErrorWithPos(err, n.Position()))
+	}
+	return s
+}
+
+func (c *clangConv) declRef(n *clangNode) Expr {
+	g := c.g
+	rd := n.RefDecl
+	if rd == nil || rd.Type == nil {
+		panic(c.unsupported(n))
+	}
+	id, ok := c.decls[rd.ID]
+	if !ok {
+		// declared in another file
+		id = g.newIdent(rd.Name, c.typeRoot(g.idents[rd.Name], rd.Type.QualType))
+		if to, ok := g.idents[rd.Name]; ok && to.Rename != "" {
+			id.GoName = to.Rename
+		}
+		c.decls[rd.ID] = id
+	}
+	switch rd.Kind {
+	case "FunctionDecl":
+		g.funcIdents[id] = struct{}{}
+	case "EnumConstantDecl":
+		return IdentExpr{id}
+	}
+	return g.atomicIdent(IdentExpr{id}, strings.HasPrefix(rd.Type.QualType, "volatile "))
+}
+
+func (c *clangConv) binaryExpr(n *clangNode) Expr {
+	g := c.g
+	x := c.expr(c.child(n, 0))
+	y := c.expr(c.child(n, 1))
+	switch n.Opcode {
+	case "=":
+		return g.NewCAssignExpr(x, "", y)
+	case ",":
+		return g.NewCMultiExpr(x, y)
+	case "&&":
+		return And(g.ToBool(x), g.ToBool(y))
+	case "||":
+		return Or(g.ToBool(x), g.ToBool(y))
+	}
+	if op, ok := clangCmpOps[n.Opcode]; ok {
+		return g.Compare(x, op, y)
+	}
+	if op, ok := clangBinOps[n.Opcode]; ok {
+		return g.NewCBinaryExprT(x, op, y, c.exprType(n))
+	}
+	panic(c.unsupported(n))
+}
+
+func (c *clangConv) unaryExpr(n *clangNode) Expr {
+	g := c.g
+	x := c.expr(c.child(n, 0))
+	var op UnaryOp
+	switch n.Opcode {
+	case "++", "--":
+		if n.IsPostfix {
+			return g.NewCPostfixExpr(x, n.Opcode == "--")
+		}
+		return g.NewCPrefixExpr(x, n.Opcode == "--")
+	case "&":
+		return g.cAddr(x)
+	case "*":
+		return g.cDerefT(x, c.exprType(n))
+	case "!":
+		return g.cNot(x)
+	case "__extension__":
+		return x
+	case "+":
+		op = UnaryPlus
+	case "-":
+		op = UnaryMinus
+	case "~":
+		op = UnaryXor
+	default:
+		panic(c.unsupported(n))
+	}
+	return g.NewCUnaryExprT(op, x, c.exprType(n))
+}
+
+// unquoteClangString decodes a string literal printed by clang. It reports whether the literal is wide.
+func unquoteClangString(lit string) (string, bool, error) {
+	wide := false
+	switch {
+	case strings.HasPrefix(lit, "u8"):
+		lit = lit[2:]
+	case strings.HasPrefix(lit, "L"), strings.HasPrefix(lit, "u"), strings.HasPrefix(lit, "U"):
+		lit, wide = lit[1:], true
+	}
+	if len(lit) < 2 || lit[0] != '"' || lit[len(lit)-1] != '"' {
+		return "", false, fmt.Errorf("invalid string literal: %s", lit)
+	}
+	s := lit[1 : len(lit)-1]
+	var buf []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			buf = append(buf, s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", false, fmt.Errorf("invalid string literal: %s", lit)
+		}
+		switch e := s[i]; e {
+		case 'a':
+			buf = append(buf, '\a')
+		case 'b':
+			buf = append(buf, '\b')
+		case 'e':
+			buf = append(buf, 0x1b)
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'v':
+			buf = append(buf, '\v')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i:j], 8, 32)
+			buf = appendClangChar(buf, v, wide)
+			i = j - 1
+		case 'x', 'u', 'U':
+			n := len(s)
+			if e == 'u' {
+				n = min(i+5, len(s))
+			} else if e == 'U' {
+				n = min(i+9, len(s))
+			}
+			j := i + 1
+			for j < n && isHexDigit(s[j]) {
+				j++
+			}
+			v, err := strconv.ParseUint(s[i+1:j], 16, 32)
+			if err != nil {
+				return "", false, fmt.Errorf("invalid string literal: %s", lit)
+			}
+			if e == 'x' {
+				buf = appendClangChar(buf, v, wide)
+			} else {
+				buf = utf8.AppendRune(buf, rune(v))
+			}
+			i = j - 1
+		default:
+			// \\, \", \', \?
+			buf = append(buf, e)
+		}
+	}
+	return string(buf), wide, nil
+}
+
+func appendClangChar(buf []byte, v uint64, wide bool) []byte {
+	if wide {
+		return utf8.AppendRune(buf, rune(v))
+	}
+	return append(buf, byte(v))
+}
@@ -0,0 +1,281 @@
+package cxgo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+// clangTestAST is an abridged AST dumped by clang for the following file. Only the attributes used by the translator are kept.
+//
+//	typedef struct point {
+//		int x;
+//		int y;
+//	} point;
+//
+//	enum color { RED, GREEN = 3, BLUE };
+//
+//	int sum(point *p, int n) {
+//		int s = 0;
+//		for (int i = 0; i < n; i++) {
+//			s += p[i].x * p[i].y;
+//		}
+//		return s;
+//	}
+//
+//	int call(void) {
+//		point pt = {1, 2};
+//		const char *msg = "hi\n";
+//		if (msg[0] == 'h') {
+//			return sum(&pt, GREEN);
+//		}
+//		return -1;
+//	}
+const clangTestAST = `{"id":"0x1","kind":"TranslationUnitDecl","loc":{},"range":{"begin":{},"end":{}},"inner":[
+{"id":"0x2","kind":"TypedefDecl","loc":{},"range":{"begin":{},"end":{}},"isImplicit":true,"name":"__int128_t","type":{"qualType":"__int128"}},
+{"id":"0x10","kind":"RecordDecl","loc":{"offset":15,"file":"a.c","line":1,"col":16},"range":{"begin":{"offset":8,"col":9},"end":{"offset":47,"line":4,"col":1}},
+ "name":"point","tagUsed":"struct","completeDefinition":true,"inner":[
+ {"id":"0x11","kind":"FieldDecl","loc":{"offset":29,"line":2,"col":6},"range":{"begin":{"offset":25,"col":2},"end":{"offset":29,"col":6}},"name":"x","type":{"qualType":"int"}},
+ {"id":"0x12","kind":"FieldDecl","loc":{"offset":38,"line":3,"col":6},"range":{"begin":{"offset":34,"col":2},"end":{"offset":38,"col":6}},"name":"y","type":{"qualType":"int"}}
+]},
+{"id":"0x13","kind":"TypedefDecl","loc":{"offset":49,"line":4,"col":3},"range":{"begin":{"offset":0,"line":1,"col":1},"end":{"offset":49,"line":4,"col":3}},
+ "name":"point","type":{"desugaredQualType":"struct point","qualType":"struct point"}},
+{"id":"0x20","kind":"EnumDecl","loc":{"offset":62,"line":6,"col":6},"range":{"begin":{"offset":57,"col":1},"end":{"offset":91,"col":35}},"name":"color","inner":[
+ {"id":"0x21","kind":"EnumConstantDecl","loc":{"offset":70,"col":14},"range":{"begin":{"offset":70,"col":14},"end":{"offset":70,"col":14}},"name":"RED","type":{"qualType":"int"}},
+ {"id":"0x22","kind":"EnumConstantDecl","loc":{"offset":75,"col":19},"range":{"begin":{"offset":75,"col":19},"end":{"offset":83,"col":27}},"name":"GREEN","type":{"qualType":"int"},"inner":[
+  {"id":"0x23","kind":"ConstantExpr","type":{"qualType":"int"},"value":"3","inner":[
+   {"id":"0x24","kind":"IntegerLiteral","type":{"qualType":"int"},"value":"3"}
+  ]}
+ ]},
+ {"id":"0x25","kind":"EnumConstantDecl","loc":{"offset":86,"col":30},"range":{"begin":{"offset":86,"col":30},"end":{"offset":86,"col":30}},"name":"BLUE","type":{"qualType":"int"}}
+]},
+{"id":"0x30","kind":"FunctionDecl","loc":{"offset":98,"line":8,"col":5},"range":{"begin":{"offset":94,"col":1},"end":{"offset":182,"line":14,"col":1}},"name":"sum","type":{"qualType":"int (point *, int)"},"inner":[
+ {"id":"0x31","kind":"ParmVarDecl","loc":{"offset":109,"line":8,"col":16},"range":{"begin":{"offset":102,"col":9},"end":{"offset":109,"col":16}},"name":"p","type":{"desugaredQualType":"struct point *","qualType":"point *"}},
+ {"id":"0x32","kind":"ParmVarDecl","loc":{"offset":116,"col":23},"range":{"begin":{"offset":112,"col":19},"end":{"offset":116,"col":23}},"name":"n","type":{"qualType":"int"}},
+ {"id":"0x33","kind":"CompoundStmt","range":{"begin":{"offset":119,"col":26},"end":{"offset":182,"line":14,"col":1}},"inner":[
+  {"id":"0x34","kind":"DeclStmt","range":{"begin":{"offset":122,"line":9,"col":2},"end":{"offset":131,"col":11}},"inner":[
+   {"id":"0x35","kind":"VarDecl","loc":{"offset":126,"col":6},"range":{"begin":{"offset":122,"col":2},"end":{"offset":130,"col":10}},"name":"s","type":{"qualType":"int"},"init":"c","inner":[
+    {"id":"0x36","kind":"IntegerLiteral","type":{"qualType":"int"},"value":"0"}
+   ]}
+  ]},
+  {"id":"0x37","kind":"ForStmt","range":{"begin":{"offset":133,"line":10,"col":2},"end":{"offset":168,"line":12,"col":2}},"inner":[
+   {"id":"0x38","kind":"DeclStmt","range":{"begin":{"offset":138,"line":10,"col":7},"end":{"offset":147,"col":16}},"inner":[
+    {"id":"0x39","kind":"VarDecl","loc":{"offset":142,"col":11},"range":{"begin":{"offset":138,"col":7},"end":{"offset":146,"col":15}},"name":"i","type":{"qualType":"int"},"init":"c","inner":[
+     {"id":"0x3a","kind":"IntegerLiteral","type":{"qualType":"int"},"value":"0"}
+    ]}
+   ]},
+   {},
+   {"id":"0x3b","kind":"BinaryOperator","type":{"qualType":"int"},"opcode":"<","inner":[
+    {"id":"0x3c","kind":"ImplicitCastExpr","type":{"qualType":"int"},"castKind":"LValueToRValue","inner":[
+     {"id":"0x3d","kind":"DeclRefExpr","type":{"qualType":"int"},"referencedDecl":{"id":"0x39","kind":"VarDecl","name":"i","type":{"qualType":"int"}}}
+    ]},
+    {"id":"0x3e","kind":"ImplicitCastExpr","type":{"qualType":"int"},"castKind":"LValueToRValue","inner":[
+     {"id":"0x3f","kind":"DeclRefExpr","type":{"qualType":"int"},"referencedDecl":{"id":"0x32","kind":"ParmVarDecl","name":"n","type":{"qualType":"int"}}}
+    ]}
+   ]},
+   {"id":"0x40","kind":"UnaryOperator","type":{"qualType":"int"},"isPostfix":true,"opcode":"++","inner":[
+    {"id":"0x41","kind":"DeclRefExpr","type":{"qualType":"int"},"referencedDecl":{"id":"0x39","kind":"VarDecl","name":"i","type":{"qualType":"int"}}}
+   ]},
+   {"id":"0x42","kind":"CompoundStmt","range":{"begin":{"offset":162,"col":31},"end":{"offset":188,"line":12,"col":2}},"inner":[
+    {"id":"0x43","kind":"CompoundAssignOperator","type":{"qualType":"int"},"opcode":"+=","inner":[
+     {"id":"0x44","kind":"DeclRefExpr","type":{"qualType":"int"},"referencedDecl":{"id":"0x35","kind":"VarDecl","name":"s","type":{"qualType":"int"}}},
+     {"id":"0x45","kind":"BinaryOperator","type":{"qualType":"int"},"opcode":"*","inner":[
+      {"id":"0x46","kind":"ImplicitCastExpr","type":{"qualType":"int"},"castKind":"LValueToRValue","inner":[
+       {"id":"0x47","kind":"MemberExpr","type":{"qualType":"int"},"name":"x","isArrow":false,"inner":[
+        {"id":"0x48","kind":"ArraySubscriptExpr","type":{"desugaredQualType":"struct point","qualType":"point"},"inner":[
+         {"id":"0x49","kind":"ImplicitCastExpr","type":{"qualType":"point *"},"castKind":"LValueToRValue","inner":[
+          {"id":"0x4a","kind":"DeclRefExpr","type":{"qualType":"point *"},"referencedDecl":{"id":"0x31","kind":"ParmVarDecl","name":"p","type":{"qualType":"point *"}}}
+         ]},
+         {"id":"0x4b","kind":"ImplicitCastExpr","type":{"qualType":"int"},"castKind":"LValueToRValue","inner":[
+          {"id":"0x4c","kind":"DeclRefExpr","type":{"qualType":"int"},"referencedDecl":{"id":"0x39","kind":"VarDecl","name":"i","type":{"qualType":"int"}}}
+         ]}
+        ]}
+       ]}
+      ]},
+      {"id":"0x4d","kind":"ImplicitCastExpr","type":{"qualType":"int"},"castKind":"LValueToRValue","inner":[
+       {"id":"0x4e","kind":"MemberExpr","type":{"qualType":"int"},"name":"y","isArrow":false,"inner":[
+        {"id":"0x4f","kind":"ArraySubscriptExpr","type":{"desugaredQualType":"struct point","qualType":"point"},"inner":[
+         {"id":"0x50","kind":"ImplicitCastExpr","type":{"qualType":"point *"},"castKind":"LValueToRValue","inner":[
+          {"id":"0x51","kind":"DeclRefExpr","type":{"qualType":"point *"},"referencedDecl":{"id":"0x31","kind":"ParmVarDecl","name":"p","type":{"qualType":"point *"}}}
+         ]},
+         {"id":"0x52","kind":"ImplicitCastExpr","type":{"qualType":"int"},"castKind":"LValueToRValue","inner":[
+          {"id":"0x53","kind":"DeclRefExpr","type":{"qualType":"int"},"referencedDecl":{"id":"0x39","kind":"VarDecl","name":"i","type":{"qualType":"int"}}}
+         ]}
+        ]}
+       ]}
+      ]}
+     ]}
+    ]}
+   ]}
+  ]},
+  {"id":"0x54","kind":"ReturnStmt","range":{"begin":{"offset":171,"line":13,"col":2},"end":{"offset":178,"col":9}},"inner":[
+   {"id":"0x55","kind":"ImplicitCastExpr","type":{"qualType":"int"},"castKind":"LValueToRValue","inner":[
+    {"id":"0x56","kind":"DeclRefExpr","type":{"qualType":"int"},"referencedDecl":{"id":"0x35","kind":"VarDecl","name":"s","type":{"qualType":"int"}}}
+   ]}
+  ]}
+ ]}
+]},
+{"id":"0x60","kind":"FunctionDecl","loc":{"offset":189,"line":16,"col":5},"range":{"begin":{"offset":185,"col":1},"end":{"offset":298,"line":23,"col":1}},"name":"call","type":{"qualType":"int (void)"},"inner":[
+ {"id":"0x61","kind":"CompoundStmt","range":{"begin":{"offset":200,"line":16,"col":16},"end":{"offset":298,"line":23,"col":1}},"inner":[
+  {"id":"0x62","kind":"DeclStmt","range":{"begin":{"offset":203,"line":17,"col":2},"end":{"offset":221,"col":20}},"inner":[
+   {"id":"0x63","kind":"VarDecl","loc":{"offset":209,"col":8},"range":{"begin":{"offset":203,"col":2},"end":{"offset":220,"col":19}},"name":"pt","type":{"desugaredQualType":"struct point","qualType":"point"},"init":"c","inner":[
+    {"id":"0x64","kind":"InitListExpr","type":{"desugaredQualType":"struct point","qualType":"point"},"inner":[
+     {"id":"0x65","kind":"IntegerLiteral","type":{"qualType":"int"},"value":"1"},
+     {"id":"0x66","kind":"IntegerLiteral","type":{"qualType":"int"},"value":"2"}
+    ]}
+   ]}
+  ]},
+  {"id":"0x67","kind":"DeclStmt","range":{"begin":{"offset":223,"line":18,"col":2},"end":{"offset":247,"col":26}},"inner":[
+   {"id":"0x68","kind":"VarDecl","loc":{"offset":235,"col":14},"range":{"begin":{"offset":223,"col":2},"end":{"offset":241,"col":20}},"name":"msg","type":{"qualType":"const char *"},"init":"c","inner":[
+    {"id":"0x69","kind":"ImplicitCastExpr","type":{"qualType":"char *"},"castKind":"ArrayToPointerDecay","inner":[
+     {"id":"0x6a","kind":"StringLiteral","type":{"qualType":"char[4]"},"value":"\"hi\\n\""}
+    ]}
+   ]}
+  ]},
+  {"id":"0x6b","kind":"IfStmt","range":{"begin":{"offset":249,"line":19,"col":2},"end":{"offset":285,"line":21,"col":2}},"inner":[
+   {"id":"0x6c","kind":"BinaryOperator","type":{"qualType":"int"},"opcode":"==","inner":[
+    {"id":"0x6d","kind":"ImplicitCastExpr","type":{"qualType":"int"},"castKind":"IntegralCast","inner":[
+     {"id":"0x6e","kind":"ImplicitCastExpr","type":{"qualType":"char"},"castKind":"LValueToRValue","inner":[
+      {"id":"0x6f","kind":"ArraySubscriptExpr","type":{"qualType":"const char"},"inner":[
+       {"id":"0x70","kind":"ImplicitCastExpr","type":{"qualType":"const char *"},"castKind":"LValueToRValue","inner":[
+        {"id":"0x71","kind":"DeclRefExpr","type":{"qualType":"const char *"},"referencedDecl":{"id":"0x68","kind":"VarDecl","name":"msg","type":{"qualType":"const char *"}}}
+       ]},
+       {"id":"0x72","kind":"IntegerLiteral","type":{"qualType":"int"},"value":"0"}
+      ]}
+     ]}
+    ]},
+    {"id":"0x73","kind":"CharacterLiteral","type":{"qualType":"int"},"value":104}
+   ]},
+   {"id":"0x74","kind":"CompoundStmt","range":{"begin":{"offset":268,"col":21},"end":{"offset":296,"line":21,"col":2}},"inner":[
+    {"id":"0x75","kind":"ReturnStmt","range":{"begin":{"offset":272,"line":20,"col":3},"end":{"offset":293,"col":24}},"inner":[
+     {"id":"0x76","kind":"CallExpr","type":{"qualType":"int"},"inner":[
+      {"id":"0x77","kind":"ImplicitCastExpr","type":{"qualType":"int (*)(point *, int)"},"castKind":"FunctionToPointerDecay","inner":[
+       {"id":"0x78","kind":"DeclRefExpr","type":{"qualType":"int (point *, int)"},"referencedDecl":{"id":"0x30","kind":"FunctionDecl","name":"sum","type":{"qualType":"int (point *, int)"}}}
+      ]},
+      {"id":"0x79","kind":"UnaryOperator","type":{"qualType":"point *"},"isPostfix":false,"opcode":"&","inner":[
+       {"id":"0x7a","kind":"DeclRefExpr","type":{"desugaredQualType":"struct point","qualType":"point"},"referencedDecl":{"id":"0x63","kind":"VarDecl","name":"pt","type":{"qualType":"point"}}}
+      ]},
+      {"id":"0x7b","kind":"DeclRefExpr","type":{"qualType":"int"},"referencedDecl":{"id":"0x22","kind":"EnumConstantDecl","name":"GREEN","type":{"qualType":"int"}}}
+     ]}
+    ]}
+   ]}
+  ]},
+  {"id":"0x7c","kind":"ReturnStmt","range":{"begin":{"offset":287,"line":22,"col":2},"end":{"offset":295,"col":10}},"inner":[
+   {"id":"0x7d","kind":"UnaryOperator","type":{"qualType":"int"},"isPostfix":false,"opcode":"-","inner":[
+    {"id":"0x7e","kind":"IntegerLiteral","type":{"qualType":"int"},"value":"1"}
+   ]}
+  ]}
+ ]}
+]}
+]}`
+
+func TestTranslateClangAST(t *testing.T) {
+	env := libs.NewEnv(types.Config32())
+	decls, err := TranslateClangAST("a.c", []byte(clangTestAST), env, Config{})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	err = PrintGo(&buf, "lib", append(ImportsFor(env, decls), decls...), false)
+	require.NoError(t, err)
+	require.Equal(t, `package lib
+
+import (
+	"github.com/gotranspile/cxgo/runtime/libc"
+	"unsafe"
+)
+
+type point struct {
+	X int32
+	Y int32
+}
+type color int32
+
+const (
+	RED   color = 0
+	GREEN color = 3
+	BLUE  color = 4
+)
+
+func sum(p *point, n int32) int32 {
+	var s int32 = 0
+	for i := int32(0); i < n; i++ {
+		s += (*(*point)(unsafe.Add(unsafe.Pointer(p), unsafe.Sizeof(point{})*uintptr(i)))).X * (*(*point)(unsafe.Add(unsafe.Pointer(p), unsafe.Sizeof(point{})*uintptr(i)))).Y
+	}
+	return s
+}
+func call() int32 {
+	var (
+		pt  point = point{X: 1, Y: 2}
+		msg *byte = libc.CString("hi\n")
+	)
+	if *msg == 'h' {
+		return sum(&pt, int32(GREEN))
+	}
+	return -1
+}
+`, buf.String())
+}
+
+func TestParseClangType(t *testing.T) {
+	env := libs.NewEnv(types.Config32())
+	g := newTranslator(env, Config{})
+	c := newClangConv(g)
+	u8, i32 := env.Go().Byte(), env.C().Int()
+	arg := func(t types.Type) *types.Field {
+		return &types.Field{Name: types.NewUnnamed(t)}
+	}
+	cases := []struct {
+		typ string
+		exp types.Type
+	}{
+		{"void", nil},
+		{"unsigned long long", env.C().UnsignedLongLong()},
+		{"const char *", env.C().String()},
+		{"char[16]", types.ArrayT(u8, 16)},
+		{"int *[4]", types.ArrayT(env.PtrT(i32), 4)},
+		{"int (*)[4]", env.PtrT(types.ArrayT(i32, 4))},
+		{"void *restrict", env.PtrT(nil)},
+		{"int (*)(void *, const char *, ...)", env.PtrT(env.VarFuncT(i32, arg(env.PtrT(nil)), arg(env.C().String())))},
+		{"void (*(*)(int))(void)", env.PtrT(env.FuncT(env.FuncT(nil), arg(i32)))},
+	}
+	for _, c2 := range cases {
+		t.Run(c2.typ, func(t *testing.T) {
+			require.Equal(t, c2.exp, c.parseType(c2.typ))
+		})
+	}
+}
+
+func TestUnquoteClangString(t *testing.T) {
+	s, wide, err := unquoteClangString(`"a\tb\\\"\x41\101\0"`)
+	require.NoError(t, err)
+	require.False(t, wide)
+	require.Equal(t, "a\tb\\\"AA\x00", s)
+	s, wide, err = unquoteClangString(`L"é"`)
+	require.NoError(t, err)
+	require.True(t, wide)
+	require.Equal(t, "é", s)
+}
+
+func TestClangArgs(t *testing.T) {
+	args := clangArgs("/src", "/src/a.c", "/tmp/predef.h", Config{
+		Clang:      "clang-17 --target=i386-linux-gnu",
+		ClangArgs:  []string{"-Wno-everything"},
+		Define:     []Define{{Name: "A"}, {Name: "B", Value: "2"}},
+		Include:    []string{"/inc"},
+		SysInclude: []string{"/sys"},
+	})
+	require.Equal(t, []string{
+		"clang-17", "--target=i386-linux-gnu",
+		"-Xclang", "-ast-dump=json", "-fsyntax-only", "-x", "c",
+		"-DA", "-DB=2",
+		"-I", "/inc",
+		"-isystem", "/sys",
+		"-I", "/src/includes", "-I", "/src/include",
+		"-include", "/tmp/predef.h",
+		"-Wno-everything",
+		"/src/a.c",
+	}, args)
+}
@@ -0,0 +1,335 @@
+package cxgo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// clangBase is a base type of the C type printed by clang, before applying pointers, arrays and functions.
+// The base type is converted lazily, because pointers to named types may refer to a type that is being converted.
+type clangBase struct {
+	name string // C name of the named type, used to break recursion via pointers
+	char bool   // plain char, which needs special handling in pointers and arrays
+	void bool
+	conv func() types.Type
+}
+
+type clangDerivKind int
+
+const (
+	clangPtr = clangDerivKind(iota)
+	clangArray
+	clangFunc
+)
+
+// clangDeriv is a pointer, array or function type derived from the base type.
+type clangDeriv struct {
+	kind     clangDerivKind
+	n        int          // array length, or -1 for incomplete arrays
+	args     []types.Type // function arguments
+	variadic bool
+}
+
+// clangQualifiers are type qualifiers that are ignored in types printed by clang.
+var clangQualifiers = map[string]bool{
+	"const": true, "volatile": true, "restrict": true, "__restrict": true, "__unaligned": true,
+	"_Nonnull": true, "_Nullable": true, "_Null_unspecified": true,
+}
+
+// clangTypeParser parses C types printed by clang, for example "const char *" or "int (*)(void *, int)".
+type clangTypeParser struct {
+	c *clangConv
+	s string
+	i int
+}
+
+// parseType converts a C type printed by clang. It returns nil for void.
+func (c *clangConv) parseType(s string) types.Type {
+	if t, ok := c.types[s]; ok {
+		return t
+	}
+	p := &clangTypeParser{c: c, s: s}
+	t := p.typ()
+	if p.space(); p.i != len(p.s) {
+		p.fail()
+	}
+	c.types[s] = t
+	return t
+}
+
+func (p *clangTypeParser) fail() {
+	panic(fmt.Errorf("clang: unsupported type: %q", p.s))
+}
+
+func (p *clangTypeParser) space() {
+	for p.i < len(p.s) && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *clangTypeParser) peek() byte {
+	p.space()
+	if p.i >= len(p.s) {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *clangTypeParser) expect(b byte) {
+	if p.peek() != b {
+		p.fail()
+	}
+	p.i++
+}
+
+func (p *clangTypeParser) ident() string {
+	p.space()
+	j := p.i
+	for j < len(p.s) && isIdentByte(p.s[j]) {
+		j++
+	}
+	w := p.s[p.i:j]
+	p.i = j
+	return w
+}
+
+// paren reads text in parentheses, for example "(unnamed struct at a.c:1:9)".
+func (p *clangTypeParser) paren() string {
+	p.expect('(')
+	start, depth := p.i, 1
+	for ; p.i < len(p.s); p.i++ {
+		switch p.s[p.i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				p.i++
+				return p.s[start : p.i-1]
+			}
+		}
+	}
+	p.fail()
+	return ""
+}
+
+func (p *clangTypeParser) typ() types.Type {
+	b := p.base()
+	return p.apply(b, p.declarator())
+}
+
+// base parses type qualifiers and specifiers.
+func (p *clangTypeParser) base() clangBase {
+	var (
+		words []string
+		tag   *clangBase
+	)
+	for isIdentByte(p.peek()) {
+		w := p.ident()
+		switch {
+		case clangQualifiers[w]:
+			// ignore
+		case w == "struct" || w == "union" || w == "enum":
+			var b clangBase
+			if p.peek() == '(' {
+				// unnamed type
+				desc := p.paren()
+				i := strings.LastIndex(desc, " at ")
+				if i < 0 {
+					p.fail()
+				}
+				b = p.c.tagBase(w, "", desc[i+len(" at "):])
+			} else {
+				b = p.c.tagBase(w, p.ident(), "")
+			}
+			tag = &b
+		default:
+			words = append(words, w)
+		}
+	}
+	if tag != nil {
+		if len(words) != 0 {
+			p.fail()
+		}
+		return *tag
+	}
+	g := p.c.g
+	name := strings.Join(words, " ")
+	var t types.Type
+	switch name {
+	case "void":
+		return clangBase{void: true, conv: func() types.Type { return nil }}
+	case "char":
+		return clangBase{char: true, conv: func() types.Type { return g.env.C().Char() }}
+	case "signed char":
+		t = g.env.C().SignedChar()
+	case "unsigned char":
+		t = g.env.C().UnsignedChar()
+	case "short":
+		t = g.env.C().Short()
+	case "unsigned short":
+		t = g.env.C().UnsignedShort()
+	case "int":
+		t = g.env.C().Int()
+	case "unsigned int":
+		t = g.env.C().UnsignedInt()
+	case "long":
+		t = g.env.C().Long()
+	case "unsigned long":
+		t = g.env.C().UnsignedLong()
+	case "long long":
+		t = g.env.C().LongLong()
+	case "unsigned long long":
+		t = g.env.C().UnsignedLongLong()
+	case "float":
+		t = g.env.C().Float()
+	case "double", "long double":
+		t = g.env.C().Double()
+	case "_Bool", "bool":
+		t = g.env.C().Bool()
+	default:
+		if len(words) != 1 {
+			p.fail()
+		}
+		return clangBase{name: name, conv: func() types.Type { return p.c.typedefType(name) }}
+	}
+	return clangBase{conv: func() types.Type { return t }}
+}
+
+// declarator parses an abstract declarator. Derived types are returned in the order they must be applied to the base type.
+func (p *clangTypeParser) declarator() []clangDeriv {
+	var ptrs []clangDeriv
+	for p.peek() == '*' {
+		p.i++
+		ptrs = append(ptrs, clangDeriv{kind: clangPtr})
+		for isIdentByte(p.peek()) {
+			i := p.i
+			if !clangQualifiers[p.ident()] {
+				p.i = i
+				break
+			}
+		}
+	}
+	var inner []clangDeriv
+	if p.peek() == '(' && p.i+1 < len(p.s) {
+		if c := p.s[p.i+1]; c == '*' || c == '(' || c == '[' {
+			p.i++
+			inner = p.declarator()
+			p.expect(')')
+		}
+	}
+	var suffix []clangDeriv
+	for {
+		switch p.peek() {
+		case '[':
+			p.i++
+			d := clangDeriv{kind: clangArray, n: -1}
+			if p.peek() != ']' {
+				j := p.i
+				for j < len(p.s) && p.s[j] >= '0' && p.s[j] <= '9' {
+					j++
+				}
+				n, err := strconv.Atoi(p.s[p.i:j])
+				if err != nil {
+					p.fail()
+				}
+				d.n, p.i = n, j
+			}
+			p.expect(']')
+			suffix = append(suffix, d)
+			continue
+		case '(':
+			p.i++
+			d := clangDeriv{kind: clangFunc}
+			for p.peek() != ')' {
+				if len(d.args) != 0 || d.variadic {
+					p.expect(',')
+				}
+				if p.space(); strings.HasPrefix(p.s[p.i:], "...") {
+					p.i += 3
+					d.variadic = true
+					continue
+				}
+				at := p.typ()
+				if at == nil {
+					// (void)
+					continue
+				}
+				d.args = append(d.args, at)
+			}
+			p.expect(')')
+			suffix = append(suffix, d)
+			continue
+		}
+		break
+	}
+	out := ptrs
+	for i := len(suffix) - 1; i >= 0; i-- {
+		out = append(out, suffix[i])
+	}
+	return append(out, inner...)
+}
+
+// apply derives pointer, array and function types from the base type, the same way as the built-in parser does.
+func (p *clangTypeParser) apply(b clangBase, ds []clangDeriv) types.Type {
+	g := p.c.g
+	var t types.Type
+	if len(ds) != 0 && ds[0].kind == clangPtr {
+		switch {
+		case b.char:
+			t = g.env.C().String()
+		case b.void:
+			t = g.env.PtrT(nil)
+		case b.name != "":
+			if pt, ok := g.namedPtrs[b.name]; ok {
+				t = pt
+				break
+			}
+			pt := g.env.PtrT(nil) // incomplete
+			g.namedPtrs[b.name] = pt
+			pt.SetElem(b.conv())
+			t = pt
+		default:
+			t = g.env.PtrT(b.conv())
+		}
+		ds = ds[1:]
+	} else if len(ds) != 0 && ds[0].kind == clangArray && b.char {
+		t = types.ArrayT(g.env.Go().Byte(), max(ds[0].n, 0))
+		ds = ds[1:]
+	} else {
+		t = b.conv()
+	}
+	for _, d := range ds {
+		switch d.kind {
+		case clangPtr:
+			t = g.env.PtrT(t)
+		case clangArray:
+			t = types.ArrayT(t, max(d.n, 0))
+		case clangFunc:
+			var args []*types.Field
+			for _, at := range d.args {
+				args = append(args, &types.Field{Name: types.NewUnnamed(rootType(at))})
+			}
+			if d.variadic {
+				t = g.env.VarFuncT(rootType(t), args...)
+			} else {
+				t = g.env.FuncT(rootType(t), args...)
+			}
+		}
+	}
+	return t
+}
+
+// rootType applies the same workaround for C function pointers and incomplete arrays as convertTypeRoot.
+func rootType(t types.Type) types.Type {
+	if p, ok := t.(types.PtrType); ok && p.ElemKind().IsFunc() {
+		t = p.Elem()
+	}
+	if p, ok := t.(types.ArrayType); ok && p.Len() == 0 {
+		t = types.SliceT(p.Elem())
+	}
+	return t
+}
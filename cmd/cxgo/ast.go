@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo"
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func init() {
+	cmdAST := &cobra.Command{
+		Use:   "ast file.c",
+		Short: "print C AST of a file after preprocessing",
+	}
+	Root.AddCommand(cmdAST)
+
+	fOut := cmdAST.Flags().StringP("out", "o", "", "output file to write to (default: stdout)")
+	fJSON := cmdAST.Flags().Bool("json", false, "print the AST as JSON")
+	fAll := cmdAST.Flags().Bool("all", false, "print declarations from included headers as well")
+	pf := addParseFlags(cmdAST)
+	cmdAST.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("exactly one file must be specified")
+		}
+		_, in, tu, err := pf.parse(args[0])
+		if err != nil {
+			return err
+		}
+		file := in
+		if *fAll {
+			file = ""
+		}
+		write := cxgo.DumpAST
+		if *fJSON {
+			write = cxgo.DumpASTJSON
+		}
+		return writeOut(*fOut, func(w io.Writer) error {
+			return write(w, tu, file)
+		})
+	}
+}
+
+// writeOut calls the function with the output file, or stdout if the path is empty.
+func writeOut(path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = write(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// parseFlags are flags of commands that parse a single C file.
+type parseFlags struct {
+	config  *string
+	include *[]string
+	define  *[]string
+	c23     *bool
+}
+
+func addParseFlags(cmd *cobra.Command) *parseFlags {
+	return &parseFlags{
+		config:  cmd.Flags().StringP("config", "c", "", "take defines and includes from this cxgo config file"),
+		include: cmd.Flags().StringSliceP("include", "I", nil, "include directories"),
+		define:  cmd.Flags().StringArrayP("define", "D", nil, "define a macro (NAME or NAME=VALUE)"),
+		c23:     cmd.Flags().Bool("c23", false, "enable C23 features"),
+	}
+}
+
+// parse a C file with the flags. The file is resolved relative to the config root, if the config is set.
+func (f *parseFlags) parse(in string) (*libs.Env, string, *cc.AST, error) {
+	tconf := types.Config{UseGoInt: true}
+	var sconf cxgo.SourceConfig
+	root := ""
+	if *f.config != "" {
+		c, err := loadParseConfig(*f.config)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		tconf, err = c.typesConfig()
+		if err != nil {
+			return nil, "", nil, err
+		}
+		tconf.UseGoInt = tconf.UseGoInt || c.UseGoInt
+		root = c.Root
+		if !filepath.IsAbs(in) {
+			in = filepath.Join(root, in)
+		}
+		sconf = cxgo.SourceConfig{
+			Profile:          cxgo.PredefProfile(c.Profile),
+			Predef:           c.Predef,
+			Define:           c.Define,
+			Include:          c.Include,
+			SysInclude:       c.SysInclude,
+			IgnoreIncludeDir: c.IgnoreIncludeDir,
+			C23:              c.C23,
+		}
+	}
+	sconf.Include = append(sconf.Include, *f.include...)
+	for _, d := range *f.define {
+		name, val, _ := strings.Cut(d, "=")
+		sconf.Define = append(sconf.Define, cxgo.Define{Name: name, Value: val})
+	}
+	sconf.C23 = sconf.C23 || *f.c23
+	env := libs.NewEnv(tconf)
+	tu, err := cxgo.Parse(env, root, in, sconf)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%s: parsing failed: %w", in, err)
+	}
+	return env, in, tu, nil
+}
+
+// loadParseConfig loads a cxgo config and resolves the root and include paths the same way run does.
+func loadParseConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err = yaml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(c.Root) {
+		c.Root = filepath.Join(filepath.Dir(path), c.Root)
+	}
+	for i, dir := range c.Include {
+		if !filepath.IsAbs(dir) {
+			c.Include[i] = filepath.Join(c.Root, dir)
+		}
+	}
+	for i, dir := range c.SysInclude {
+		if !filepath.IsAbs(dir) {
+			c.SysInclude[i] = filepath.Join(c.Root, dir)
+		}
+	}
+	return &c, nil
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gotranspile/cxgo"
+)
+
+// DirConfig is a per-directory override, loaded from cxgo.yml files in subdirectories of the root.
+// It applies to all files in the directory and its subdirectories.
+type DirConfig struct {
+	Include     []string           `yaml:"include"`
+	SysInclude  []string           `yaml:"sys_include"`
+	Define      []cxgo.Define      `yaml:"define"`
+	Predef      string             `yaml:"predef"`
+	FlattenAll  *bool              `yaml:"flatten_all"`
+	ForwardDecl *bool              `yaml:"forward_decl"`
+	Cgo         *bool              `yaml:"cgo"`
+	Skip        []string           `yaml:"skip"`
+	Only        []string           `yaml:"only"`
+	Idents      []cxgo.IdentConfig `yaml:"idents"`
+	Replace     []Replacement      `yaml:"replace"`
+
+	data []byte
+}
+
+type configDoc struct {
+	path string
+	data []byte
+}
+
+// loadConfig reads the config file and all configs it extends. It returns the merged config and the data of all
+// files, which can be used to detect config changes.
+//
+// The configs are applied in order: bases first, in the order they are listed, and the file itself last.
+// Values set in later configs override values from previous ones, lists are replaced and maps are merged.
+// Paths in base configs are relative to the base config file.
+//
+// Variables in paths are expanded after all the configs are merged, see expandVars.
+func loadConfig(path string) (Config, []byte, error) {
+	var c Config
+	confDir := filepath.Dir(path)
+	if abs, err := filepath.Abs(confDir); err == nil {
+		confDir = abs
+	}
+	docs, err := readConfigDocs(path, confDir, nil)
+	if err != nil {
+		return c, nil, err
+	}
+	paths := []*string{&c.Root, &c.Out, &c.ConversionReport, &c.Symbols}
+	keys := []string{"root", "out", "conversion_report", "symbols"}
+	dirs := make([]string, len(paths))
+	var all bytes.Buffer
+	for i, d := range docs {
+		if err = yaml.Unmarshal(d.data, &c); err != nil {
+			return c, nil, fmt.Errorf("%s: %w", d.path, err)
+		}
+		// paths are relative to the config that sets them, even if the value is the same as in the base
+		var set map[string]yaml.Node
+		if err = yaml.Unmarshal(d.data, &set); err != nil {
+			return c, nil, fmt.Errorf("%s: %w", d.path, err)
+		}
+		for j, key := range keys {
+			if _, ok := set[key]; !ok {
+				continue
+			}
+			dirs[j] = ""
+			if i != len(docs)-1 {
+				dirs[j] = filepath.Dir(d.path)
+			}
+		}
+		all.Write(d.data)
+	}
+	if err = c.expandVars(confDir); err != nil {
+		return c, nil, err
+	}
+	for j, p := range paths {
+		if dirs[j] == "" || *p == "" || filepath.IsAbs(*p) {
+			continue
+		}
+		if p == &c.Root && c.VCS != "" {
+			// relative to the repository root
+			continue
+		}
+		*p = filepath.Join(dirs[j], *p)
+	}
+	return c, all.Bytes(), nil
+}
+
+// readConfigDocs reads the config file, preceded by all configs it extends.
+func readConfigDocs(path, confDir string, stack []string) ([]configDoc, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range stack {
+		if p == abs {
+			return nil, fmt.Errorf("%s: config extends itself", path)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ext struct {
+		Extends []string `yaml:"extends"`
+	}
+	if err = yaml.Unmarshal(data, &ext); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var docs []configDoc
+	for _, base := range ext.Extends {
+		base, err := expandVars(base, confDir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(path), base)
+		}
+		bdocs, err := readConfigDocs(base, confDir, append(stack, abs))
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, bdocs...)
+	}
+	return append(docs, configDoc{path: path, data: data}), nil
+}
+
+// expandVars expands variables in paths and predefines of the config.
+func (c *Config) expandVars(confDir string) error {
+	list := []*string{&c.Root, &c.Out, &c.Predef}
+	for i := range c.Include {
+		list = append(list, &c.Include[i])
+	}
+	for i := range c.SysInclude {
+		list = append(list, &c.SysInclude[i])
+	}
+	for _, f := range c.Files {
+		list = append(list, &f.Predef)
+	}
+	return expandAll(list, confDir)
+}
+
+func expandAll(list []*string, confDir string) error {
+	for _, p := range list {
+		v, err := expandVars(*p, confDir)
+		if err != nil {
+			return err
+		}
+		*p = v
+	}
+	return nil
+}
+
+// expandVars expands $VAR and ${VAR} in the string. Variables are taken from the environment, additionally
+// CONFIG_DIR is set to the directory of the main config, and GOOS and GOARCH are set to the host platform.
+// The ${VAR:-default} form can be used to set a default value if the variable is unset or empty,
+// other undefined variables are reported as an error. The $$ is replaced with $.
+func expandVars(s, confDir string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var undef []string
+	out := os.Expand(s, func(name string) string {
+		name, def, hasDef := strings.Cut(name, ":-")
+		var (
+			v  string
+			ok bool
+		)
+		switch name {
+		case "$":
+			return "$"
+		case "CONFIG_DIR":
+			v, ok = confDir, true
+		case "GOOS":
+			v, ok = runtime.GOOS, true
+		case "GOARCH":
+			v, ok = runtime.GOARCH, true
+		default:
+			v, ok = os.LookupEnv(name)
+		}
+		if ok && (v != "" || !hasDef) {
+			return v
+		} else if !hasDef {
+			undef = append(undef, name)
+		}
+		return def
+	})
+	if len(undef) != 0 {
+		return "", fmt.Errorf("undefined variable %q in %q", undef[0], s)
+	}
+	return out, nil
+}
+
+// dirConfigs discovers and caches per-directory configs.
+type dirConfigs struct {
+	root    string
+	conf    string // the main config, which is never used as an override
+	confDir string
+	cache   map[string]*DirConfig
+}
+
+func newDirConfigs(root, conf string) *dirConfigs {
+	if abs, err := filepath.Abs(conf); err == nil {
+		conf = abs
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &dirConfigs{root: root, conf: conf, confDir: filepath.Dir(conf), cache: make(map[string]*DirConfig)}
+}
+
+// load reads an override from the directory. It returns nil if there is none.
+func (d *dirConfigs) load(dir string) (*DirConfig, error) {
+	if dc, ok := d.cache[dir]; ok {
+		return dc, nil
+	}
+	path := filepath.Join(dir, "cxgo.yml")
+	if path == d.conf {
+		d.cache[dir] = nil
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		d.cache[dir] = nil
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	dc := &DirConfig{data: data}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err = dec.Decode(dc); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	vars := []*string{&dc.Predef}
+	for _, list := range [][]string{dc.Include, dc.SysInclude} {
+		for i := range list {
+			vars = append(vars, &list[i])
+		}
+	}
+	if err = expandAll(vars, d.confDir); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, list := range [][]string{dc.Include, dc.SysInclude} {
+		for i := range list {
+			if !filepath.IsAbs(list[i]) {
+				list[i] = filepath.Join(dir, list[i])
+			}
+		}
+	}
+	d.cache[dir] = dc
+	return dc, nil
+}
+
+// apply finds overrides for the file and applies them to the config. Overrides from nested directories take
+// precedence. It returns the config without changes if there are no overrides. The second value is a hash
+// of the config and all the overrides.
+func (d *dirConfigs) apply(c Config, data []byte, name string) (Config, string, error) {
+	dir := d.root
+	rel := filepath.Dir(filepath.Clean(name))
+	var parts []string
+	if rel != "." {
+		parts = strings.Split(rel, string(filepath.Separator))
+	}
+	// parts may go up with "..", those directories are not the part of the project
+	h := sha256.New()
+	h.Write(data)
+	for _, p := range parts {
+		if p == ".." {
+			break
+		}
+		dir = filepath.Join(dir, p)
+		dc, err := d.load(dir)
+		if err != nil {
+			return c, "", err
+		} else if dc == nil {
+			continue
+		}
+		c = c.withDir(dc)
+		h.Write(dc.data)
+	}
+	return c, fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// withDir returns a copy of the config with a per-directory override applied to it.
+func (c Config) withDir(d *DirConfig) Config {
+	// always copy slices, since the config is shared between files
+	c.Include = append(append([]string{}, d.Include...), c.Include...)
+	c.SysInclude = append(append([]string{}, d.SysInclude...), c.SysInclude...)
+	defs := make([]cxgo.Define, 0, len(c.Define)+len(d.Define))
+	for _, v := range c.Define {
+		if !hasDefine(d.Define, v.Name) {
+			defs = append(defs, v)
+		}
+	}
+	c.Define = append(defs, d.Define...)
+	if d.Predef != "" {
+		c.Predef = d.Predef
+	}
+	if d.FlattenAll != nil {
+		c.FlattenAll = *d.FlattenAll
+	}
+	if d.ForwardDecl != nil {
+		c.ForwardDecl = *d.ForwardDecl
+	}
+	if d.Cgo != nil {
+		c.Cgo = *d.Cgo
+	}
+	c.Skip = append(append([]string{}, c.Skip...), d.Skip...)
+	c.Only = append(append([]string{}, c.Only...), d.Only...)
+	c.Idents = append(append([]cxgo.IdentConfig{}, c.Idents...), d.Idents...)
+	c.Replace = append(append([]Replacement{}, c.Replace...), d.Replace...)
+	return c
+}
+
+func hasDefine(list []cxgo.Define, name string) bool {
+	for _, v := range list {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
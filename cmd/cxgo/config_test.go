@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo"
+)
+
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	}
+}
+
+func TestLoadConfigExtends(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"common/base.yml": `
+root: ./src
+out: ./gen
+symbols: syms.json
+include: [inc]
+import_paths:
+  a: example.com/a
+  b: example.com/b
+`,
+		"common/more.yml": `
+package: lib
+include: [more]
+`,
+		"proj/cxgo.yml": `
+extends: [../common/base.yml, ../common/more.yml]
+root: ./src
+include: [own]
+import_paths:
+  b: example.com/fork/b
+`,
+	})
+	c, data, err := loadConfig(filepath.Join(dir, "proj", "cxgo.yml"))
+	require.NoError(t, err)
+	// set by the config itself, thus relative to it, even if the base has the same value
+	require.Equal(t, "./src", c.Root)
+	// set by the base, thus relative to the base
+	require.Equal(t, filepath.Join(dir, "common", "gen"), c.Out)
+	require.Equal(t, filepath.Join(dir, "common", "syms.json"), c.Symbols)
+	require.Equal(t, "lib", c.Package)
+	// lists are replaced, maps are merged
+	require.Equal(t, []string{"own"}, c.Include)
+	require.Equal(t, map[string]string{"a": "example.com/a", "b": "example.com/fork/b"}, c.ImportPaths)
+	require.Contains(t, string(data), "example.com/fork/b")
+	require.Contains(t, string(data), "package: lib")
+}
+
+func TestLoadConfigExtendsSelf(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yml": "extends: [b.yml]\n",
+		"b.yml": "extends: [a.yml]\n",
+	})
+	_, _, err := loadConfig(filepath.Join(dir, "a.yml"))
+	require.ErrorContains(t, err, "config extends itself")
+}
+
+func TestLoadConfigVars(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CXGO_TEST_BASE", "common")
+	writeFiles(t, dir, map[string]string{
+		"common/base.yml": "out: ${CXGO_TEST_OUT:-gen}\n",
+		"cxgo.yml": `
+extends: [$CXGO_TEST_BASE/base.yml]
+root: $CONFIG_DIR/src
+`,
+	})
+	c, _, err := loadConfig(filepath.Join(dir, "cxgo.yml"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "src"), c.Root)
+	require.Equal(t, filepath.Join(dir, "common", "gen"), c.Out)
+}
+
+func TestDirConfigs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cxgo.yml": "root: .\n",
+		"a/cxgo.yml": `
+include: [inc]
+define:
+  - name: A
+    value: "1"
+  - name: B
+    value: "a"
+flatten_all: true
+skip: [a_skip]
+`,
+		"a/b/cxgo.yml": `
+include: [/abs/inc]
+define:
+  - name: B
+    value: "b"
+predef: "#define P 1"
+flatten_all: false
+`,
+		"c/cxgo.yml": "unknown_key: 1\n",
+	})
+	base := Config{
+		Include: []string{"top"},
+		Define:  []cxgo.Define{{Name: "B", Value: "top"}, {Name: "C", Value: "top"}},
+		Skip:    []string{"top_skip"},
+	}
+	d := newDirConfigs(dir, filepath.Join(dir, "cxgo.yml"))
+
+	// the main config is never used as an override
+	c, h0, err := d.apply(base, []byte("data"), "main.c")
+	require.NoError(t, err)
+	require.Equal(t, base, c)
+
+	c, h1, err := d.apply(base, []byte("data"), filepath.Join("a", "x.c"))
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "a", "inc"), "top"}, c.Include)
+	require.Equal(t, []cxgo.Define{{Name: "C", Value: "top"}, {Name: "A", Value: "1"}, {Name: "B", Value: "a"}}, c.Define)
+	require.True(t, c.FlattenAll)
+	require.Equal(t, []string{"top_skip", "a_skip"}, c.Skip)
+	require.NotEqual(t, h0, h1)
+
+	// nested overrides take precedence
+	c, h2, err := d.apply(base, []byte("data"), filepath.Join("a", "b", "x.c"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/abs/inc", filepath.Join(dir, "a", "inc"), "top"}, c.Include)
+	require.Equal(t, []cxgo.Define{{Name: "C", Value: "top"}, {Name: "A", Value: "1"}, {Name: "B", Value: "b"}}, c.Define)
+	require.Equal(t, "#define P 1", c.Predef)
+	require.False(t, c.FlattenAll)
+	require.NotEqual(t, h1, h2)
+
+	// the base config is not modified
+	require.Equal(t, []string{"top"}, base.Include)
+
+	// files outside of the root are not affected
+	c, _, err = d.apply(base, []byte("data"), filepath.Join("..", "a", "x.c"))
+	require.NoError(t, err)
+	require.Equal(t, base, c)
+
+	_, _, err = d.apply(base, []byte("data"), filepath.Join("c", "x.c"))
+	require.ErrorContains(t, err, "unknown_key")
+}
+
+func TestExpandVars(t *testing.T) {
+	t.Setenv("CXGO_TEST_VAR", "val")
+	t.Setenv("CXGO_TEST_EMPTY", "")
+	for _, c := range []struct {
+		name string
+		src  string
+		exp  string
+		err  string
+	}{
+		{name: "plain", src: "a/b", exp: "a/b"},
+		{name: "env", src: "$CXGO_TEST_VAR/a", exp: "val/a"},
+		{name: "braces", src: "${CXGO_TEST_VAR}a", exp: "vala"},
+		{name: "empty", src: "a$CXGO_TEST_EMPTY", exp: "a"},
+		{name: "default", src: "${CXGO_TEST_UNSET:-def}/a", exp: "def/a"},
+		{name: "default empty", src: "${CXGO_TEST_EMPTY:-def}", exp: "def"},
+		{name: "default set", src: "${CXGO_TEST_VAR:-def}", exp: "val"},
+		{name: "dollar", src: "a$$b", exp: "a$b"},
+		{name: "config dir", src: "$CONFIG_DIR/a", exp: "/conf/a"},
+		{name: "platform", src: "${GOOS}_$GOARCH", exp: runtime.GOOS + "_" + runtime.GOARCH},
+		{name: "undefined", src: "$CXGO_TEST_UNSET/a", err: `undefined variable "CXGO_TEST_UNSET" in "$CXGO_TEST_UNSET/a"`},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			v, err := expandVars(c.src, "/conf")
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, v)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"log"
 	"path/filepath"
 	"strings"
 
@@ -23,6 +24,14 @@ func init() {
 	fPkg := cmdFile.Flags().StringP("pkg", "p", "main", "package name for a Go file")
 	fExportFields := cmdFile.Flags().Bool("export-fields", false, "export struct fields")
 	fDoNotEdit := cmdFile.Flags().Bool("donotedit", false, "add DO NOT EDIT comment header")
+	fOnly := cmdFile.Flags().StringSlice("only", nil, "translate only specified functions and their dependencies")
+	fTreeShake := cmdFile.Flags().Bool("tree-shake", false, "drop declarations unreachable from the roots")
+	fRoots := cmdFile.Flags().StringSlice("roots", nil, "root declarations for tree shaking (default: main)")
+	fCgo := cmdFile.Flags().Bool("cgo", false, "generate cgo bindings instead of translating function bodies")
+	fBench := cmdFile.Flags().StringSlice("bench", nil, "generate Go benchmarks for specified functions")
+	fC23 := cmdFile.Flags().Bool("c23", false, "support C23 keywords and syntax")
+	fWrap := cmdFile.Flags().Bool("wrap-signed", false, "emit signed arithmetic via unsigned types")
+	fCover := cmdFile.Flags().Bool("coverage", false, "count executions of C lines with the coverage runtime")
 	cmdFile.RunE = func(cmd *cobra.Command, args []string) error {
 		if len(args) != 1 {
 			return errors.New("exactly one file must be specified")
@@ -41,6 +50,19 @@ func init() {
 			MaxDecls:         -1,
 			UnexportedFields: !*fExportFields,
 			DoNotEdit:        *fDoNotEdit,
+			Only:             *fOnly,
+			TreeShake:        *fTreeShake || len(*fRoots) != 0,
+			Roots:            *fRoots,
+			Cgo:              *fCgo,
+			C23:              *fC23,
+			WrapSigned:       *fWrap,
+			Coverage:         *fCover,
+			OnNote: func(n cxgo.Note) {
+				log.Println(n)
+			},
+		}
+		for _, name := range *fBench {
+			fc.Benchmarks = append(fc.Benchmarks, cxgo.BenchConfig{Name: name})
 		}
 		return cxgo.Translate("", in, filepath.Dir(out), env, fc)
 	}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gotranspile/cxgo"
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func init() {
+	cmdGraph := &cobra.Command{
+		Use:   "graph",
+		Short: "print call and dependency graph of C files",
+	}
+	Root.AddCommand(cmdGraph)
+
+	fOut := cmdGraph.Flags().StringP("out", "o", "", "output file to write to (default: stdout)")
+	fFormat := cmdGraph.Flags().StringP("format", "f", "dot", "output format: dot or json")
+	fFiles := cmdGraph.Flags().Bool("files", false, "print dependencies between files instead of declarations")
+	fInclude := cmdGraph.Flags().StringSliceP("include", "I", nil, "include directories")
+	cmdGraph.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("at least one file must be specified")
+		}
+		var write func(g *cxgo.Graph, w io.Writer) error
+		switch *fFormat {
+		case "dot":
+			write = (*cxgo.Graph).WriteDOT
+		case "json":
+			write = (*cxgo.Graph).WriteJSON
+		default:
+			return fmt.Errorf("unsupported graph format: %q", *fFormat)
+		}
+		env := libs.NewEnv(types.Config{
+			UseGoInt: true,
+		})
+		proj := cxgo.NewProject(env)
+		dg := cxgo.NewDepGraph()
+		for _, in := range args {
+			tu, err := cxgo.Parse(env, "", in, cxgo.SourceConfig{
+				Include: *fInclude,
+			})
+			if err != nil {
+				return fmt.Errorf("%s: parsing failed: %w", in, err)
+			}
+			decls, err := proj.TranslateCAST(in, tu, cxgo.Config{})
+			if err != nil {
+				return fmt.Errorf("%s: %w", in, err)
+			}
+			dg.Add(in, decls)
+		}
+		g := dg.Decls()
+		if *fFiles {
+			g = dg.Files()
+		}
+		if *fOut == "" {
+			return write(g, os.Stdout)
+		}
+		f, err := os.Create(*fOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err = write(g, f); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gotranspile/cxgo"
+)
+
+func init() {
+	cmdInit := &cobra.Command{
+		Use:   "init [dir]",
+		Short: "scan a C project and write a starter config file",
+	}
+	Root.AddCommand(cmdInit)
+
+	fConfig := cmdInit.Flags().StringP("config", "c", "", "config file to write (default: cxgo.yml in the project directory)")
+	fOut := cmdInit.Flags().StringP("out", "o", ".", "output path for Go files, relative to the config file")
+	fPkg := cmdInit.Flags().StringP("pkg", "p", "", "Go package name (default: main for programs, directory name otherwise)")
+	fForce := cmdInit.Flags().BoolP("force", "f", false, "overwrite an existing config file")
+	cmdInit.RunE = func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		switch len(args) {
+		case 0:
+		case 1:
+			dir = args[0]
+		default:
+			return errors.New("at most one directory must be specified")
+		}
+		conf := *fConfig
+		if conf == "" {
+			conf = filepath.Join(dir, "cxgo.yml")
+		}
+		if !*fForce {
+			if _, err := os.Stat(conf); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", conf)
+			}
+		}
+		s, err := scanProject(dir)
+		if err != nil {
+			return err
+		}
+		if len(s.Sources) == 0 {
+			return fmt.Errorf("no C files found in %s", dir)
+		}
+		root, err := filepath.Rel(filepath.Dir(conf), dir)
+		if err != nil {
+			return err
+		}
+		pkg := *fPkg
+		if pkg == "" {
+			pkg = s.packageName()
+		}
+		data, err := s.Config(filepath.ToSlash(root), *fOut, pkg)
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(conf, data, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s: %d files, %d include directories\n", conf, len(s.Sources), len(s.Include))
+		return nil
+	}
+}
+
+// projectScan is a result of a lightweight scan of C project.
+//
+// The scan doesn't run the preprocessor, thus all the results are only a guess.
+type projectScan struct {
+	Root    string
+	Sources []*scannedFile // .c files
+	Headers []string       // .h files
+	Include []string       // include directories, relative to the root
+	Defines []string       // macros checked in conditional directives, but never defined
+	ConfigH bool           // the code checks HAVE_CONFIG_H and config.h is present
+}
+
+type scannedFile struct {
+	Name  string // relative to the root, with forward slashes
+	Main  bool
+	Notes []string // problematic constructs
+}
+
+var (
+	reInitInclude = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*include[ \t]*(?:"([^"]+)"|<([^>]+)>)`)
+	reInitDefine  = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*define[ \t]+([A-Za-z_]\w*)`)
+	reInitCond    = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*(ifdef|ifndef|if|elif)\b(.*)$`)
+	reInitIdent   = regexp.MustCompile(`[A-Za-z_]\w*`)
+	reInitMain    = regexp.MustCompile(`(?m)^[ \t]*(?:(?:int|void)[ \t]+)?main[ \t]*\([^;{)]*\)[ \t]*(?:\{|$)`)
+)
+
+// initConstructs are constructs that cxgo doesn't support or supports partially.
+var initConstructs = []struct {
+	re   *regexp.Regexp
+	note string
+}{
+	{regexp.MustCompile(`\bgoto[ \t]*\*`), "computed goto is not supported"},
+	{regexp.MustCompile(`\bgoto[ \t]+\w+[ \t]*;`), "goto may require flatten_all, if Go doesn't allow it"},
+	{regexp.MustCompile(`\b(?:sig)?(?:setjmp|longjmp)[ \t]*\(`), "setjmp compiles, but panics at runtime"},
+	{regexp.MustCompile(`\b(?:asm|__asm|__asm__)\b[ \t]*(?:volatile|__volatile__)?[ \t]*\(`), "inline assembly is not supported"},
+	{regexp.MustCompile(`\b(?:__thread|_Thread_local|thread_local)\b`), "thread local variables are translated as globals"},
+	{regexp.MustCompile(`#[ \t]*pragma[ \t]+pack\b|__attribute__[ \t]*\(\([ \t]*(?:__)?packed`), "packed structs are not supported"},
+}
+
+// initKnownMacros are checked by the code, but are defined by the compiler or the standard library.
+var initKnownMacros = map[string]bool{
+	"EOF": true, "NULL": true, "CHAR_BIT": true, "WIN32": true, "WIN64": true,
+	"BYTE_ORDER": true, "LITTLE_ENDIAN": true, "BIG_ENDIAN": true,
+}
+
+// scanProject finds C files in the directory and collects information for a starter config.
+func scanProject(root string) (*projectScan, error) {
+	s := &projectScan{Root: root}
+	contents := make(map[string]string)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(name, ".") || name == "build" || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(name)
+		if ext != ".c" && ext != ".h" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		contents[rel] = stripComments(string(data))
+		if ext == ".c" {
+			s.Sources = append(s.Sources, &scannedFile{Name: rel})
+		} else {
+			s.Headers = append(s.Headers, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]bool, len(s.Headers))
+	for _, h := range s.Headers {
+		headers[h] = true
+	}
+	var (
+		incDirs = make(map[string]bool)
+		defined = make(map[string]bool)
+		checked = make(map[string]bool)
+	)
+	for name, src := range contents {
+		for _, m := range reInitInclude.FindAllStringSubmatch(src, -1) {
+			inc, local := m[1], true
+			if inc == "" {
+				inc, local = m[2], false
+			}
+			if local && headers[path.Join(path.Dir(name), inc)] {
+				continue
+			}
+			if dir, ok := s.findIncludeDir(inc); ok {
+				incDirs[dir] = true
+			}
+		}
+		for _, m := range reInitDefine.FindAllStringSubmatch(src, -1) {
+			defined[m[1]] = true
+		}
+		for _, m := range reInitCond.FindAllStringSubmatch(src, -1) {
+			expr := m[2]
+			if m[1] == "ifdef" || m[1] == "ifndef" {
+				expr = reInitIdent.FindString(expr)
+			}
+			for _, id := range reInitIdent.FindAllString(expr, -1) {
+				if id != "defined" {
+					checked[id] = true
+				}
+			}
+		}
+	}
+	for dir := range incDirs {
+		s.Include = append(s.Include, dir)
+	}
+	sort.Strings(s.Include)
+	for name := range checked {
+		if defined[name] || !isConfigMacro(name) {
+			continue
+		}
+		if name == "HAVE_CONFIG_H" {
+			if _, ok := s.findIncludeDir("config.h"); ok {
+				s.ConfigH = true
+				continue
+			}
+		}
+		s.Defines = append(s.Defines, name)
+	}
+	sort.Strings(s.Defines)
+	for _, f := range s.Sources {
+		src := contents[f.Name]
+		f.Main = reInitMain.MatchString(src)
+		for _, c := range initConstructs {
+			if c.re.MatchString(src) {
+				f.Notes = append(f.Notes, c.note)
+			}
+		}
+	}
+	return s, nil
+}
+
+// findIncludeDir returns an include directory that contains a project header with a given path.
+// The shortest directory is selected if there are multiple candidates.
+func (s *projectScan) findIncludeDir(inc string) (string, bool) {
+	best, found := "", false
+	for _, h := range s.Headers {
+		var dir string
+		if h == inc {
+			dir = "."
+		} else if strings.HasSuffix(h, "/"+inc) {
+			dir = strings.TrimSuffix(h, "/"+inc)
+		} else {
+			continue
+		}
+		if !found || len(dir) < len(best) {
+			best, found = dir, true
+		}
+	}
+	return best, found
+}
+
+// isConfigMacro checks if the macro is likely a project-specific switch.
+func isConfigMacro(name string) bool {
+	if strings.HasPrefix(name, "_") || initKnownMacros[name] {
+		return false
+	}
+	if strings.HasSuffix(name, "_MAX") || strings.HasSuffix(name, "_MIN") {
+		return false
+	}
+	// switches are usually in the upper case
+	return strings.ToUpper(name) == name
+}
+
+// stripComments replaces C comments with spaces, preserving line breaks.
+func stripComments(src string) string {
+	var buf strings.Builder
+	buf.Grow(len(src))
+	for i := 0; i < len(src); i++ {
+		switch {
+		case src[i] == '"' || src[i] == '\'':
+			// copy string and char literals as-is
+			q := src[i]
+			buf.WriteByte(q)
+			for i++; i < len(src) && src[i] != q && src[i] != '\n'; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					buf.WriteByte(src[i])
+					i++
+				}
+				buf.WriteByte(src[i])
+			}
+			if i < len(src) {
+				buf.WriteByte(src[i])
+			}
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if i < len(src) {
+				buf.WriteByte('\n')
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 4
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(strings.Repeat("\n", strings.Count(src[i:end], "\n")))
+			i = end - 1
+		default:
+			buf.WriteByte(src[i])
+		}
+	}
+	return buf.String()
+}
+
+// mains returns the number of files that define main.
+func (s *projectScan) mains() int {
+	n := 0
+	for _, f := range s.Sources {
+		if f.Main {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *projectScan) packageName() string {
+	if s.mains() == 1 {
+		return "main"
+	}
+	abs, err := filepath.Abs(s.Root)
+	if err != nil {
+		return "lib"
+	}
+	name := strings.ToLower(filepath.Base(abs))
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, name)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return "lib"
+	}
+	return name
+}
+
+// Config generates a starter config file for the project.
+func (s *projectScan) Config(root, out, pkg string) ([]byte, error) {
+	type initFile struct {
+		Name     string `yaml:"name"`
+		Disabled bool   `yaml:"disabled,omitempty"`
+	}
+	type initConfig struct {
+		Root    string        `yaml:"root"`
+		Out     string        `yaml:"out"`
+		Package string        `yaml:"package"`
+		Include []string      `yaml:"include,omitempty"`
+		Define  []cxgo.Define `yaml:"define,omitempty"`
+		Files   []initFile    `yaml:"files"`
+	}
+	c := initConfig{
+		Root:    root,
+		Out:     out,
+		Package: pkg,
+		Include: s.Include,
+	}
+	if s.ConfigH {
+		c.Define = append(c.Define, cxgo.Define{Name: "HAVE_CONFIG_H", Value: "1"})
+	}
+	// a Go package can only have one main function
+	multiMain := s.mains() > 1
+	for _, f := range s.Sources {
+		c.Files = append(c.Files, initFile{Name: f.Name, Disabled: multiMain && f.Main})
+	}
+	var m yaml.Node
+	if err := m.Encode(c); err != nil {
+		return nil, err
+	}
+	m.HeadComment = "# generated by 'cxgo init', review it before running cxgo"
+	var defKey *yaml.Node
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key, val := m.Content[i], m.Content[i+1]
+		switch key.Value {
+		case "define":
+			defKey = key
+		case "files":
+			if defKey == nil {
+				defKey = key
+			}
+			for j, f := range s.Sources {
+				var notes []string
+				if f.Main {
+					notes = append(notes, "defines main")
+					if multiMain {
+						notes = append(notes, "disabled, since there are multiple main functions")
+					}
+				}
+				notes = append(notes, f.Notes...)
+				if len(notes) != 0 {
+					val.Content[j].HeadComment = "# " + strings.Join(notes, "\n# ")
+				}
+			}
+		}
+	}
+	if len(s.Defines) != 0 {
+		defKey.HeadComment = "# macros checked by the code, but never defined:\n# " + strings.Join(s.Defines, ", ")
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&m); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripComments(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		src  string
+		exp  string
+	}{
+		{name: "line", src: "int a; // b\nint c;", exp: "int a; \nint c;"},
+		{name: "block", src: "int a; /* b\nc */ int d;", exp: "int a;  \n int d;"},
+		{name: "unterminated", src: "int a; /* b", exp: "int a;  "},
+		{name: "string", src: `char *s = "/* a */"; // b`, exp: `char *s = "/* a */"; `},
+		{name: "escaped quote", src: `char *s = "\" // a"; // b`, exp: `char *s = "\" // a"; `},
+		{name: "char", src: `char c = '"'; /* a */`, exp: `char c = '"';  `},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, stripComments(c.src))
+		})
+	}
+}
+
+func TestIsConfigMacro(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		exp  bool
+	}{
+		{"USE_SSL", true},
+		{"HAVE_CONFIG_H", true},
+		{"_WIN32", false},
+		{"__GNUC__", false},
+		{"NULL", false},
+		{"INT_MAX", false},
+		{"SIZE_MIN", false},
+		{"debug", false},
+	} {
+		require.Equal(t, c.exp, isConfigMacro(c.name), c.name)
+	}
+}
+
+func TestFindIncludeDir(t *testing.T) {
+	s := &projectScan{Headers: []string{"a.h", "include/b.h", "src/include/b.h", "include/sub/c.h"}}
+	for _, c := range []struct {
+		inc string
+		dir string
+		ok  bool
+	}{
+		{"a.h", ".", true},
+		{"b.h", "include", true},
+		{"sub/c.h", "include", true},
+		{"c.h", "include/sub", true},
+		{"d.h", "", false},
+	} {
+		dir, ok := s.findIncludeDir(c.inc)
+		require.Equal(t, c.ok, ok, c.inc)
+		require.Equal(t, c.dir, dir, c.inc)
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	dir := t.TempDir()
+	for _, c := range []struct {
+		name  string
+		root  string
+		mains int
+		exp   string
+	}{
+		{name: "program", root: "proj", mains: 1, exp: "main"},
+		{name: "library", root: "My-Lib", exp: "mylib"},
+		{name: "multiple mains", root: "tools", mains: 2, exp: "tools"},
+		{name: "digit", root: "2d", exp: "lib"},
+		{name: "symbols", root: "---", exp: "lib"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			s := &projectScan{Root: filepath.Join(dir, c.root)}
+			for i := 0; i < c.mains; i++ {
+				s.Sources = append(s.Sources, &scannedFile{Main: true})
+			}
+			s.Sources = append(s.Sources, &scannedFile{})
+			require.Equal(t, c.exp, s.packageName())
+		})
+	}
+}
+
+func TestScanProject(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.h":          "#define VERSION 1\n",
+		"include/lib/api.h": "int api(void);\n",
+		"src/util.h":        "#define UTIL 1\n",
+		"src/main.c": `#ifdef HAVE_CONFIG_H
+#include "config.h"
+#endif
+#include "util.h"
+#include <lib/api.h>
+#include <stdio.h>
+
+#if defined(USE_SSL) && !defined(_WIN32)
+#endif
+#ifndef VERSION
+#endif
+// #ifdef IN_COMMENT
+
+int main(int argc, char **argv) {
+	goto end;
+end:
+	return api();
+}
+`,
+		"src/tool.c": `void main() {
+	__asm__ volatile ("nop");
+}
+`,
+		"src/lib.c":         "int api(void) { return 0; }\n",
+		".git/skip.c":       "int skipped;\n",
+		"build/generated.c": "int generated;\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	}
+	s, err := scanProject(dir)
+	require.NoError(t, err)
+	require.Equal(t, []*scannedFile{
+		{Name: "src/lib.c"},
+		{Name: "src/main.c", Main: true, Notes: []string{"goto may require flatten_all, if Go doesn't allow it"}},
+		{Name: "src/tool.c", Main: true, Notes: []string{"inline assembly is not supported"}},
+	}, s.Sources)
+	require.Equal(t, []string{".", "include"}, s.Include)
+	require.Equal(t, []string{"USE_SSL"}, s.Defines)
+	require.True(t, s.ConfigH)
+
+	data, err := s.Config("..", "out", "app")
+	require.NoError(t, err)
+	require.Equal(t, `# generated by 'cxgo init', review it before running cxgo
+root: ..
+out: out
+package: app
+include:
+  - .
+  - include
+# macros checked by the code, but never defined:
+# USE_SSL
+define:
+  - name: HAVE_CONFIG_H
+    value: "1"
+files:
+  - name: src/lib.c
+  # defines main
+  # disabled, since there are multiple main functions
+  # goto may require flatten_all, if Go doesn't allow it
+  - name: src/main.c
+    disabled: true
+  # defines main
+  # disabled, since there are multiple main functions
+  # inline assembly is not supported
+  - name: src/tool.c
+    disabled: true
+`, string(data))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gotranspile/cxgo"
+	"github.com/gotranspile/cxgo/ir"
+)
+
+func init() {
+	cmdIR := &cobra.Command{
+		Use:   "ir file.c",
+		Short: "print intermediate representation of a translated C file",
+	}
+	Root.AddCommand(cmdIR)
+
+	fOut := cmdIR.Flags().StringP("out", "o", "", "output file to write to (default: stdout)")
+	pf := addParseFlags(cmdIR)
+	cmdIR.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("exactly one file must be specified")
+		}
+		env, in, tu, err := pf.parse(args[0])
+		if err != nil {
+			return err
+		}
+		decls, err := cxgo.TranslateCAST(in, tu, env, cxgo.Config{})
+		if err != nil {
+			return err
+		}
+		return writeOut(*fOut, func(w io.Writer) error {
+			return ir.Dump(w, decls)
+		})
+	}
+}
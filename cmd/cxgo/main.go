@@ -2,19 +2,25 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go/format"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/bmatcuk/doublestar"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 
 	"github.com/gotranspile/cxgo"
 	"github.com/gotranspile/cxgo/internal/git"
@@ -34,14 +40,24 @@ var (
 	date    = ""
 )
 
-var configPath = "cxgo.yml"
+var (
+	configPath   = "cxgo.yml"
+	verbose      bool
+	showProgress bool
+	updateGolden bool
+	showMetrics  bool
+)
 
-func printVersion() {
+func fullVersion() string {
 	vers := version
 	if s := commit; s != "" {
 		vers = fmt.Sprintf("%s (%s)", vers, s[:8])
 	}
-	fmt.Printf("version: %s\n", vers)
+	return vers
+}
+
+func printVersion() {
+	fmt.Printf("version: %s\n", fullVersion())
 	if date != "" {
 		fmt.Printf("built: %s\n", date)
 	}
@@ -49,6 +65,10 @@ func printVersion() {
 
 func init() {
 	Root.Flags().StringVarP(&configPath, "config", "c", configPath, "config file path")
+	Root.Flags().BoolVarP(&verbose, "verbose", "v", false, "print additional details, such as debug logs")
+	Root.Flags().BoolVar(&showProgress, "progress", false, "show translation progress")
+	Root.Flags().BoolVar(&updateGolden, "update-golden", false, "record the generated output as the new golden snapshot")
+	Root.Flags().BoolVar(&showMetrics, "metrics", false, "print a summary of the generated code")
 	Root.AddCommand(&cobra.Command{
 		Use:   "version",
 		Short: "print cxgo version",
@@ -59,7 +79,10 @@ func init() {
 }
 
 func main() {
-	if err := Root.Execute(); err != nil {
+	// cancel the translation on Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := Root.ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -104,13 +127,26 @@ type File struct {
 	GoFile      string             `yaml:"go"`
 	FlattenAll  *bool              `yaml:"flatten_all"`
 	ForwardDecl *bool              `yaml:"forward_decl"`
+	Cgo         *bool              `yaml:"cgo"`
 	MaxDecls    int                `yaml:"max_decl"`
 	Skip        []string           `yaml:"skip"`
+	Only        []string           `yaml:"only"`
 	Idents      []cxgo.IdentConfig `yaml:"idents"`
 	Replace     []Replacement      `yaml:"replace"`
 }
 
+// TargetConfig is a platform for multi-target translation; see Config.Targets.
+type TargetConfig struct {
+	Target    string        `yaml:"target"`
+	DataModel string        `yaml:"data_model"`
+	Profile   string        `yaml:"predef_profile"`
+	Name      string        `yaml:"name"`
+	Build     string        `yaml:"build"`
+	Define    []cxgo.Define `yaml:"define"`
+}
+
 type Config struct {
+	Extends    []string          `yaml:"extends"`
 	VCS        string            `yaml:"vcs"`
 	Branch     string            `yaml:"branch"`
 	Root       string            `yaml:"root"`
@@ -118,30 +154,90 @@ type Config struct {
 	Package    string            `yaml:"package"`
 	Include    []string          `yaml:"include"`
 	SysInclude []string          `yaml:"sys_include"`
+	HostCC     string            `yaml:"host_cc"`
+	HostInc    bool              `yaml:"host_includes"`
+	HostDefs   bool              `yaml:"host_defines"`
 	IncludeMap map[string]string `yaml:"include_map"`
 	Hooks      bool              `yaml:"hooks"`
 	Define     []cxgo.Define     `yaml:"define"`
 	Predef     string            `yaml:"predef"`
+	Profile    string            `yaml:"predef_profile"`
+	C23        bool              `yaml:"c23"`
+	Encoding   string            `yaml:"source_encoding"`
+	Frontend   string            `yaml:"frontend"`
+	Clang      string            `yaml:"clang"`
+	ClangArgs  []string          `yaml:"clang_args"`
 	SubPackage bool              `yaml:"subpackage"`
+	Layout     string            `yaml:"layout"`
+	Order      string            `yaml:"order"`
+
+	DataModel string `yaml:"data_model"`
+	Target    string `yaml:"target"`
+	IntSize   int    `yaml:"int_size"`
+	LongSize  int    `yaml:"long_size"`
+	PtrSize   int    `yaml:"ptr_size"`
+	WcharSize int    `yaml:"wchar_size"`
+	UseGoInt  bool   `yaml:"use_go_int"`
+	ByteOrder string `yaml:"byte_order"`
 
-	IntSize   int  `yaml:"int_size"`
-	PtrSize   int  `yaml:"ptr_size"`
-	WcharSize int  `yaml:"wchar_size"`
-	UseGoInt  bool `yaml:"use_go_int"`
-
-	ForwardDecl      bool               `yaml:"forward_decl"`
-	FlattenAll       bool               `yaml:"flatten_all"`
-	FlattenFunc      []string           `yaml:"flatten"`
-	Skip             []string           `yaml:"skip"`
-	Replace          []Replacement      `yaml:"replace"`
-	Idents           []cxgo.IdentConfig `yaml:"idents"`
-	ImplicitReturns  bool               `yaml:"implicit_returns"`
-	IgnoreIncludeDir bool               `yaml:"ignore_include_dir"`
-	UnexportedFields bool               `yaml:"unexported_fields"`
-	IntReformat      bool               `yaml:"int_reformat"`
-	KeepFree         bool               `yaml:"keep_free"`
-	NoLibs           bool               `yaml:"no_libs"`
-	DoNotEdit        bool               `yaml:"do_not_edit"`
+	Targets []TargetConfig `yaml:"targets"`
+
+	ForwardDecl      bool                 `yaml:"forward_decl"`
+	FlattenAll       bool                 `yaml:"flatten_all"`
+	FlattenFunc      []string             `yaml:"flatten"`
+	FlattenAuto      bool                 `yaml:"flatten_auto"`
+	FlattenGotos     int                  `yaml:"flatten_gotos"`
+	Skip             []string             `yaml:"skip"`
+	Only             []string             `yaml:"only"`
+	TreeShake        bool                 `yaml:"tree_shake"`
+	Roots            []string             `yaml:"roots"`
+	Cgo              bool                 `yaml:"cgo"`
+	CgoPreamble      string               `yaml:"cgo_preamble"`
+	Benchmarks       []cxgo.BenchConfig   `yaml:"benchmarks"`
+	Trace            string               `yaml:"trace"`
+	Coverage         bool                 `yaml:"coverage"`
+	TinyGo           bool                 `yaml:"tinygo"`
+	WASM             bool                 `yaml:"wasm"`
+	PtrIntCast       string               `yaml:"ptr_int_cast"`
+	ConstPtrParams   string               `yaml:"const_ptr_params"`
+	UnusedParams     string               `yaml:"unused_params"`
+	UnusedVars       string               `yaml:"unused_vars"`
+	RangeLoops       bool                 `yaml:"range_loops"`
+	TailCalls        bool                 `yaml:"tail_calls"`
+	EmbedArrays      int                  `yaml:"embed_arrays"`
+	CompactTables    int                  `yaml:"compact_tables"`
+	InlineHeaders    bool                 `yaml:"inline_headers"`
+	StringBuilders   bool                 `yaml:"string_builders"`
+	GCAlloc          bool                 `yaml:"gc_alloc"`
+	Allocators       []cxgo.AllocConfig   `yaml:"allocators"`
+	Finalizers       bool                 `yaml:"finalizers"`
+	Rand             string               `yaml:"rand"`
+	Stdout           string               `yaml:"stdout"`
+	DefineGroups     bool                 `yaml:"define_groups"`
+	MacroComments    bool                 `yaml:"macro_comments"`
+	Generics         []cxgo.GenericConfig `yaml:"generics"`
+	Replace          []Replacement        `yaml:"replace"`
+	Idents           []cxgo.IdentConfig   `yaml:"idents"`
+	ImplicitReturns  bool                 `yaml:"implicit_returns"`
+	IgnoreIncludeDir bool                 `yaml:"ignore_include_dir"`
+	UnexportedFields bool                 `yaml:"unexported_fields"`
+	IntReformat      bool                 `yaml:"int_reformat"`
+	KeepFree         bool                 `yaml:"keep_free"`
+	NoLibs           bool                 `yaml:"no_libs"`
+	DoNotEdit        bool                 `yaml:"do_not_edit"`
+	AnonTypeNames    bool                 `yaml:"anon_type_names"`
+	ContainerOf      bool                 `yaml:"container_of"`
+	VolatileAtomic   bool                 `yaml:"volatile_atomic"`
+	WrapSigned       bool                 `yaml:"wrap_signed"`
+	ConversionReport string               `yaml:"conversion_report"`
+	Symbols          string               `yaml:"symbols"`
+	Golden           string               `yaml:"golden"`
+	Metrics          string               `yaml:"metrics"`
+	Header           string               `yaml:"header"`
+	Provenance       bool                 `yaml:"provenance"`
+	ImportPaths      map[string]string    `yaml:"import_paths"`
+	ImportAliases    map[string]string    `yaml:"import_aliases"`
+	Stream           bool                 `yaml:"stream"`
 
 	SrcFiles []*SrcFile `yaml:"src_files"`
 	FilePref string     `yaml:"file_pref"`
@@ -151,6 +247,84 @@ type Config struct {
 	ExecAfter  []string `yaml:"exec_after"`
 }
 
+// typesConfig returns a types config for the data model or the target, if any.
+func (c *Config) typesConfig() (types.Config, error) {
+	switch {
+	case c.DataModel != "" && c.Target != "":
+		return types.Config{}, errors.New("data_model and target cannot be used together")
+	case c.DataModel != "":
+		return types.ConfigFor(types.DataModel(strings.ToUpper(c.DataModel)))
+	case c.Target != "":
+		goos, goarch, ok := strings.Cut(c.Target, "/")
+		if !ok {
+			return types.Config{}, fmt.Errorf("target must be in GOOS/GOARCH format: %q", c.Target)
+		}
+		return types.ConfigForTarget(goos, goarch)
+	case c.Profile != "":
+		m, err := cxgo.PredefProfile(c.Profile).DataModel()
+		if err != nil {
+			return types.Config{}, err
+		}
+		return types.ConfigFor(m)
+	}
+	return types.Default(), nil
+}
+
+// bigEndian returns the byte order set by Config.ByteOrder. The second value is false if it is not set.
+func (c *Config) bigEndian() (bool, bool, error) {
+	switch strings.ToLower(c.ByteOrder) {
+	case "":
+		return false, false, nil
+	case "little":
+		return false, true, nil
+	case "big":
+		return true, true, nil
+	}
+	return false, false, fmt.Errorf("unsupported byte order: %q", c.ByteOrder)
+}
+
+// targets creates environments for all Config.Targets.
+func (c *Config) targets() ([]cxgo.Target, error) {
+	var out []cxgo.Target
+	for _, t := range c.Targets {
+		profile := t.Profile
+		if profile == "" {
+			profile = c.Profile
+		}
+		tc := Config{Target: t.Target, DataModel: t.DataModel, Profile: profile}
+		tconf, err := tc.typesConfig()
+		if err != nil {
+			return nil, err
+		}
+		tconf.UseGoInt = c.UseGoInt
+		if c.WcharSize != 0 {
+			tconf.WCharSize = c.WcharSize
+		}
+		name, build := t.Name, t.Build
+		if goos, goarch, ok := strings.Cut(t.Target, "/"); ok {
+			if name == "" {
+				name = goos + "_" + goarch
+			}
+			if build == "" {
+				build = goos + " && " + goarch
+			}
+		}
+		if name == "" {
+			name = strings.ToLower(t.DataModel)
+		}
+		if build == "" {
+			return nil, fmt.Errorf("target %s: build constraint must be set", name)
+		}
+		env := libs.NewEnv(tconf)
+		env.NoLibs = c.NoLibs
+		env.Map = c.IncludeMap
+		env.Rand = libs.RandMode(c.Rand)
+		env.Stdout = libs.StdoutMode(c.Stdout)
+		out = append(out, cxgo.Target{Name: name, Build: build, Env: env, Profile: cxgo.PredefProfile(profile), Define: t.Define})
+	}
+	return out, nil
+}
+
 func mergeBool(val *bool, def bool) bool {
 	if val == nil {
 		return def
@@ -161,14 +335,10 @@ func mergeBool(val *bool, def bool) bool {
 func run(cmd *cobra.Command, args []string) error {
 	defer cxgo.CallFinals()
 	conf, _ := cmd.Flags().GetString("config")
-	data, err := os.ReadFile(conf)
+	c, data, err := loadConfig(conf)
 	if err != nil {
 		return err
 	}
-	var c Config
-	if err = yaml.Unmarshal(data, &c); err != nil {
-		return err
-	}
 	if c.VCS != "" {
 		name := strings.TrimSuffix(c.VCS, ".git")
 		if i := strings.LastIndex(name, "/"); i > 0 {
@@ -214,19 +384,44 @@ func run(cmd *cobra.Command, args []string) error {
 	if err := os.MkdirAll(c.Out, 0755); err != nil {
 		return err
 	}
-	tconf := types.Default()
+	tconf, err := c.typesConfig()
+	if err != nil {
+		return err
+	}
 	if c.UseGoInt {
 		tconf.UseGoInt = c.UseGoInt
 	}
 	if c.IntSize != 0 {
 		tconf.IntSize = c.IntSize
 	}
+	if c.LongSize != 0 {
+		tconf.LongSize = c.LongSize
+	} else if c.IntSize != 0 && c.DataModel == "" && c.Target == "" {
+		tconf.LongSize = c.IntSize
+	}
 	if c.PtrSize != 0 {
 		tconf.PtrSize = c.PtrSize
 	}
 	if c.WcharSize != 0 {
 		tconf.WCharSize = c.WcharSize
 	}
+	bigEndian, fixedOrder, err := c.bigEndian()
+	if err != nil {
+		return err
+	}
+	if fixedOrder {
+		tconf.BigEndian = bigEndian
+	}
+	switch libs.RandMode(c.Rand) {
+	case "", libs.RandGo, libs.RandGlibc:
+	default:
+		return fmt.Errorf("unsupported rand mode: %q", c.Rand)
+	}
+	switch libs.StdoutMode(c.Stdout) {
+	case "", libs.StdoutDirect, libs.StdoutBuffered:
+	default:
+		return fmt.Errorf("unsupported stdout mode: %q", c.Stdout)
+	}
 	for i := range c.Include {
 		if filepath.IsAbs(c.Include[i]) {
 			continue
@@ -239,7 +434,72 @@ func run(cmd *cobra.Command, args []string) error {
 		}
 		c.SysInclude[i] = filepath.Join(c.Root, c.SysInclude[i])
 	}
+	// all files share the project: weak definitions and inline functions from headers are resolved across files,
+	// files split by declaration kind share the output files for types, consts and vars,
+	// and tree shaking must see all the files before writing any of them
+	var proj *cxgo.TranslatorProject
+	var tproj *cxgo.TargetProject
+	if len(c.Targets) != 0 {
+		targets, err := c.targets()
+		if err != nil {
+			return err
+		}
+		tproj, err = cxgo.NewTargetProject(targets)
+		if err != nil {
+			return err
+		}
+	} else {
+		env := libs.NewEnv(tconf)
+		env.NoLibs = c.NoLibs
+		env.Map = c.IncludeMap
+		env.Rand = libs.RandMode(c.Rand)
+		env.Stdout = libs.StdoutMode(c.Stdout)
+		proj = cxgo.NewProject(env)
+	}
+	if c.ConversionReport != "" && !filepath.IsAbs(c.ConversionReport) {
+		c.ConversionReport = filepath.Join(filepath.Dir(conf), c.ConversionReport)
+	}
+	if c.Symbols != "" && !filepath.IsAbs(c.Symbols) {
+		c.Symbols = filepath.Join(filepath.Dir(conf), c.Symbols)
+	}
+	if c.Golden != "" && !filepath.IsAbs(c.Golden) {
+		c.Golden = filepath.Join(filepath.Dir(conf), c.Golden)
+	}
+	if c.Metrics != "" && !filepath.IsAbs(c.Metrics) {
+		c.Metrics = filepath.Join(filepath.Dir(conf), c.Metrics)
+	}
+	var metrics *cxgo.Metrics
+	if c.Metrics != "" || showMetrics {
+		metrics = new(cxgo.Metrics)
+	}
+	var (
+		hostInc    []string
+		hostPredef string
+	)
+	if c.HostInc || c.HostDefs {
+		host, err := cxgo.DetectHostCompiler(c.HostCC)
+		if err != nil {
+			return err
+		}
+		if verbose {
+			log.Printf("host compiler %q: %d include dirs, %d macros", strings.Join(host.Command, " "), len(host.SysInclude), len(host.Defines))
+		}
+		if c.HostInc {
+			hostInc = host.SysInclude
+		}
+		if c.HostDefs {
+			hostPredef = host.Predef()
+		}
+	}
+	var convs []cxgo.Conversion
+	var syms []cxgo.Symbol
+	dirs := newDirConfigs(c.Root, conf)
 	seen := make(map[string]struct{})
+	var logger *slog.Logger
+	if verbose {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	var done, total int // processed files and the total number of files, for the progress
 	processFile := func(f *File) error {
 		if _, ok := seen[f.Name]; ok {
 			return fmt.Errorf("ducplicate entry for file: %q", f.Name)
@@ -252,33 +512,65 @@ func run(cmd *cobra.Command, args []string) error {
 			}
 			return os.WriteFile(filepath.Join(c.Out, f.Name), data, 0644)
 		}
-		idents := make(map[string]cxgo.IdentConfig)
-		for _, v := range c.Idents {
-			idents[v.Name] = v
+		c, confHash, err := dirs.apply(c, data, f.Name)
+		if err != nil {
+			return err
 		}
-		for _, v := range f.Idents {
-			idents[v.Name] = v
+		// file-level idents override global ones; keep the config order to make the output deterministic
+		idents := make(map[string]int)
+		var ilist []cxgo.IdentConfig
+		for _, list := range [][]cxgo.IdentConfig{c.Idents, f.Idents} {
+			for _, v := range list {
+				if i, ok := idents[v.Name]; ok {
+					// forced or disabled flattening is inherited, unless set again
+					if v.Flatten == nil {
+						v.Flatten = ilist[i].Flatten
+					}
+					ilist[i] = v
+					continue
+				}
+				idents[v.Name] = len(ilist)
+				ilist = append(ilist, v)
+			}
 		}
-		ilist := make([]cxgo.IdentConfig, 0, len(idents))
-		for _, v := range idents {
-			ilist = append(ilist, v)
+		for _, name := range c.FlattenFunc {
+			flatten := true
+			if i, ok := idents[name]; ok {
+				if ilist[i].Flatten == nil {
+					ilist[i].Flatten = &flatten
+				}
+				continue
+			}
+			idents[name] = len(ilist)
+			ilist = append(ilist, cxgo.IdentConfig{Name: name, Flatten: &flatten})
 		}
 
-		env := libs.NewEnv(tconf)
 		fc := cxgo.Config{
 			Root:               c.Root,
 			Package:            c.Package,
 			GoFile:             f.GoFile,
 			GoFilePref:         c.FilePref,
 			FlattenAll:         mergeBool(f.FlattenAll, c.FlattenAll),
+			FlattenAuto:        c.FlattenAuto,
+			FlattenGotos:       c.FlattenGotos,
 			ForwardDecl:        mergeBool(f.ForwardDecl, c.ForwardDecl),
 			MaxDecls:           -1,
+			Layout:             cxgo.OutputLayout(c.Layout),
+			DeclOrder:          cxgo.DeclOrder(c.Order),
 			Hooks:              c.Hooks,
 			Define:             c.Define,
+			Profile:            cxgo.PredefProfile(c.Profile),
+			C23:                c.C23,
+			SourceEncoding:     c.Encoding,
+			Frontend:           cxgo.Frontend(c.Frontend),
+			Clang:              c.Clang,
+			ClangArgs:          c.ClangArgs,
 			Predef:             f.Predef,
 			Idents:             ilist,
 			Include:            c.Include,
 			SysInclude:         c.SysInclude,
+			HostSysInclude:     hostInc,
+			HostPredef:         hostPredef,
 			IncludeMap:         c.IncludeMap,
 			FixImplicitReturns: c.ImplicitReturns,
 			IgnoreIncludeDir:   c.IgnoreIncludeDir,
@@ -286,9 +578,61 @@ func run(cmd *cobra.Command, args []string) error {
 			IntReformat:        c.IntReformat,
 			KeepFree:           c.KeepFree,
 			DoNotEdit:          c.DoNotEdit,
+			Header:             c.Header,
+			Version:            fullVersion(),
+			ConfigHash:         confHash,
+			Provenance:         c.Provenance,
+			ImportPaths:        c.ImportPaths,
+			ImportAliases:      c.ImportAliases,
+			Stream:             c.Stream,
+			Logger:             logger,
+			AnonTypeNames:      c.AnonTypeNames,
+			ContainerOf:        c.ContainerOf,
+			VolatileAtomic:     c.VolatileAtomic,
+			WrapSigned:         c.WrapSigned,
+			FixedByteOrder:     fixedOrder,
+			TreeShake:          c.TreeShake || len(c.Roots) != 0,
+			Roots:              c.Roots,
+			Cgo:                mergeBool(f.Cgo, c.Cgo),
+			CgoPreamble:        c.CgoPreamble,
+			Benchmarks:         c.Benchmarks,
+			Trace:              c.Trace,
+			Coverage:           c.Coverage,
+			TinyGo:             c.TinyGo,
+			WASM:               c.WASM,
+			PtrIntCast:         cxgo.PtrIntCast(c.PtrIntCast),
+			ConstPtrParams:     cxgo.ConstPtrParams(c.ConstPtrParams),
+			UnusedParams:       cxgo.UnusedPolicy(c.UnusedParams),
+			UnusedVars:         cxgo.UnusedPolicy(c.UnusedVars),
+			RangeLoops:         c.RangeLoops,
+			TailCalls:          c.TailCalls,
+			EmbedArrays:        c.EmbedArrays,
+			CompactTables:      c.CompactTables,
+			InlineHeaders:      c.InlineHeaders,
+			StringBuilders:     c.StringBuilders,
+			GCAlloc:            c.GCAlloc,
+			Allocators:         c.Allocators,
+			Finalizers:         c.Finalizers,
+			DefineGroups:       c.DefineGroups,
+			MacroComments:      c.MacroComments,
+			Generics:           c.Generics,
+			OnNote: func(n cxgo.Note) {
+				log.Println(n)
+			},
 		}
-		env.NoLibs = c.NoLibs
-		env.Map = c.IncludeMap
+		if c.ConversionReport != "" {
+			fc.OnConversion = func(cv cxgo.Conversion) {
+				convs = append(convs, cv)
+			}
+		}
+		fc.Metrics = metrics
+		if c.Symbols != "" {
+			fc.OnSymbol = func(s cxgo.Symbol) {
+				syms = append(syms, s)
+			}
+		}
+		fc.Only = append(fc.Only, c.Only...)
+		fc.Only = append(fc.Only, f.Only...)
 		if f.MaxDecls > 0 {
 			fc.MaxDecls = f.MaxDecls
 		}
@@ -309,51 +653,140 @@ func run(cmd *cobra.Command, args []string) error {
 			}
 			fc.Replace = append(fc.Replace, *rp)
 		}
-		if len(f.Skip) != 0 {
+		if len(c.Skip) != 0 || len(f.Skip) != 0 {
 			fc.SkipDecl = make(map[string]bool)
+			for _, s := range c.Skip {
+				fc.SkipDecl[s] = true
+			}
 			for _, s := range f.Skip {
 				fc.SkipDecl[s] = true
 			}
 		}
-		log.Println(f.Name)
-		if err := cxgo.Translate(c.Root, filepath.Join(c.Root, f.Name), c.Out, env, fc); err != nil {
-			return err
+		if showProgress {
+			last := ""
+			fc.OnProgress = func(p cxgo.Progress) {
+				line := progressLine(done, total, f.Name, p)
+				if line != last {
+					fmt.Fprint(os.Stderr, "\r\033[K"+line)
+					last = line
+				}
+			}
+		} else {
+			log.Println(f.Name)
 		}
-		return nil
+		if tproj != nil {
+			return tproj.TranslateContext(cmd.Context(), c.Root, filepath.Join(c.Root, f.Name), c.Out, fc)
+		}
+		return proj.TranslateContext(cmd.Context(), c.Root, filepath.Join(c.Root, f.Name), c.Out, fc)
 	}
 	if err := runCmd(c.Root, c.ExecBefore); err != nil {
 		return err
 	}
+	// expand globs first to know the total number of files
+	type plannedFile struct {
+		file  *File
+		where string // for errors
+	}
+	var (
+		files   []plannedFile
+		planned = make(map[string]struct{})
+	)
 	for _, f := range c.Files {
 		if f.Disabled {
-			seen[f.Name] = struct{}{}
+			planned[f.Name] = struct{}{}
+			files = append(files, plannedFile{file: f})
 			continue
 		}
-		if strings.Contains(f.Name, "*") {
-			paths, err := doublestar.Glob(filepath.Join(c.Root, f.Name))
+		if !strings.Contains(f.Name, "*") {
+			planned[f.Name] = struct{}{}
+			files = append(files, plannedFile{file: f, where: f.Name})
+			continue
+		}
+		paths, err := doublestar.Glob(filepath.Join(c.Root, f.Name))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			rel, err := filepath.Rel(c.Root, path)
 			if err != nil {
-				return err
+				return fmt.Errorf("%s: %w", path, err)
 			}
-			for _, path := range paths {
-				rel, err := filepath.Rel(c.Root, path)
-				if err != nil {
-					return fmt.Errorf("%s: %w", path, err)
-				}
-				if _, ok := seen[rel]; ok {
-					continue
-				} else if _, ok = seen["./"+rel]; ok {
-					continue
-				}
-				f2 := *f
-				f2.Name = rel
-				if err := processFile(&f2); err != nil {
-					return fmt.Errorf("%s: %w", path, err)
-				}
+			if _, ok := planned[rel]; ok {
+				continue
+			} else if _, ok = planned["./"+rel]; ok {
+				continue
 			}
-		} else {
-			if err := processFile(f); err != nil {
-				return fmt.Errorf("%s: %w", f.Name, err)
+			planned[rel] = struct{}{}
+			f2 := *f
+			f2.Name = rel
+			files = append(files, plannedFile{file: &f2, where: path})
+		}
+	}
+	for _, pf := range files {
+		if !pf.file.Disabled {
+			total++
+		}
+	}
+	// report errors for all files at once, instead of one per run
+	var errs cxgo.ErrorList
+	for _, pf := range files {
+		if pf.file.Disabled {
+			seen[pf.file.Name] = struct{}{}
+			continue
+		}
+		if showProgress {
+			fmt.Fprintf(os.Stderr, "\r\033[K[%d/%d] %s", done+1, total, pf.file.Name)
+		}
+		if err := processFile(pf.file); err != nil {
+			if showProgress {
+				fmt.Fprintln(os.Stderr)
+			}
+			if errors.Is(err, context.Canceled) {
+				return err
 			}
+			errs.Add(pf.where, err)
+		}
+		done++
+	}
+	if showProgress && total != 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+	if proj != nil {
+		if err := proj.Flush(); err != nil {
+			errs.Add(c.Out, err)
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return fmt.Errorf("%d errors in %d files:\n%w", errs.Len(), len(errs), err)
+	}
+	if c.ConversionReport != "" {
+		log.Printf("writing conversion report to %s", c.ConversionReport)
+		if err := writeConversionReport(c.ConversionReport, convs); err != nil {
+			return err
+		}
+	}
+	if c.Symbols != "" {
+		log.Printf("writing symbol manifest to %s", c.Symbols)
+		if err := writeSymbols(c.Symbols, c.Root, c.Out, syms); err != nil {
+			return err
+		}
+	}
+	if showMetrics {
+		log.Printf("metrics:\n%s", metrics)
+	}
+	if c.Metrics != "" {
+		log.Printf("writing metrics to %s", c.Metrics)
+		data, err := json.MarshalIndent(metrics, "", "\t")
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(c.Metrics, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	if c.Golden != "" {
+		if err := checkGolden(c.Golden, c.Out, data); err != nil {
+			return err
 		}
 	}
 	if !c.SubPackage {
@@ -366,7 +799,7 @@ go 1.18
 require (
 	%s %s
 )
-`, c.Package, libs.RuntimePackage, libs.RuntimePackageVers)
+`, c.Package, cxgo.ReplaceImportPath(c.ImportPaths, libs.RuntimePackage), libs.RuntimePackageVers)
 			if err := os.WriteFile(filepath.Join(c.Out, "go.mod"), buf.Bytes(), 0644); err != nil {
 				return err
 			}
@@ -378,6 +811,167 @@ require (
 	return nil
 }
 
+// progressLine formats the progress of the translation for a terminal.
+func progressLine(done, total int, name string, p cxgo.Progress) string {
+	line := fmt.Sprintf("[%d/%d] %s: %s", done+1, total, name, p.Pass)
+	if p.Total != 0 {
+		line += fmt.Sprintf(" %d%%", p.Done*100/p.Total)
+	}
+	return line
+}
+
+// writeConversionReport writes implicit conversions to a file, the riskiest first.
+func writeConversionReport(path string, convs []cxgo.Conversion) error {
+	sort.SliceStable(convs, func(i, j int) bool {
+		a, b := convs[i], convs[j]
+		if a.Risk != b.Risk {
+			return a.Risk > b.Risk
+		}
+		if a.Where.Filename != b.Where.Filename {
+			return a.Where.Filename < b.Where.Filename
+		}
+		if a.Where.Line != b.Where.Line {
+			return a.Where.Line < b.Where.Line
+		}
+		return a.Where.Column < b.Where.Column
+	})
+	count := make(map[cxgo.ConvRisk]int)
+	var lines []string
+	for i, cv := range convs {
+		// the same file may be translated for multiple targets
+		if i > 0 && cv == convs[i-1] {
+			continue
+		}
+		count[cv.Risk]++
+		lines = append(lines, cv.String())
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# implicit conversions: %d high, %d medium, %d low\n",
+		count[cxgo.ConvRiskHigh], count[cxgo.ConvRiskMedium], count[cxgo.ConvRiskLow])
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// symbolEntry is a single entry of the symbol manifest.
+type symbolEntry struct {
+	C      string `json:"c"`
+	Go     string `json:"go"`
+	Kind   string `json:"kind"`
+	GoFile string `json:"go_file"`
+	CFile  string `json:"c_file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// writeSymbols writes a manifest of C symbols and Go declarations generated for them. The CSV format is used
+// if the file has a .csv extension, JSON otherwise.
+func writeSymbols(path, root, out string, syms []cxgo.Symbol) error {
+	rel := func(dir, path string) string {
+		if r, err := filepath.Rel(dir, path); err == nil && filepath.IsAbs(path) {
+			path = r
+		}
+		return filepath.ToSlash(path)
+	}
+	list := make([]symbolEntry, 0, len(syms))
+	seen := make(map[symbolEntry]struct{})
+	for _, s := range syms {
+		e := symbolEntry{
+			C: s.C, Go: s.Go, Kind: string(s.Kind),
+			GoFile: rel(out, s.GoFile), Line: s.Where.Line,
+		}
+		if s.Where.Filename != "" {
+			e.CFile = rel(root, s.Where.Filename)
+		}
+		// the same file may be translated for multiple targets
+		if _, ok := seen[e]; ok {
+			continue
+		}
+		seen[e] = struct{}{}
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.GoFile != b.GoFile {
+			return a.GoFile < b.GoFile
+		}
+		if a.CFile != b.CFile {
+			return a.CFile < b.CFile
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.C < b.C
+	})
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		w := csv.NewWriter(&buf)
+		_ = w.Write([]string{"c", "go", "kind", "go_file", "c_file", "line"})
+		for _, e := range list {
+			line := ""
+			if e.Line != 0 {
+				line = fmt.Sprint(e.Line)
+			}
+			_ = w.Write([]string{e.C, e.Go, e.Kind, e.GoFile, e.CFile, line})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	} else {
+		data, err := json.MarshalIndent(list, "", "\t")
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// checkGolden compares declarations in the output directory with the golden snapshot, and fails if any of them changed.
+// The snapshot is recorded if it doesn't exist yet, or if --update-golden is set.
+func checkGolden(path, out string, config []byte) error {
+	cur, err := cxgo.SnapshotGolden(os.DirFS(out))
+	if err != nil {
+		return err
+	}
+	cur.Version = fullVersion()
+	cur.ConfigHash = fmt.Sprintf("%x", sha256.Sum256(config))
+	f, err := os.Open(path)
+	if os.IsNotExist(err) || updateGolden {
+		log.Printf("recording golden snapshot of %d declarations to %s", len(cur.Decls), path)
+		var buf bytes.Buffer
+		if _, err = cur.WriteTo(&buf); err != nil {
+			return err
+		}
+		return os.WriteFile(path, buf.Bytes(), 0644)
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	old, err := cxgo.ReadGolden(f)
+	if err != nil {
+		return fmt.Errorf("cannot read golden snapshot: %w", err)
+	}
+	if old.Version != cur.Version {
+		log.Printf("golden snapshot was recorded by cxgo %s", old.Version)
+	}
+	if old.ConfigHash != cur.ConfigHash {
+		log.Printf("config changed since the golden snapshot was recorded")
+	}
+	changes := old.Diff(cur)
+	if len(changes) == 0 {
+		log.Printf("output matches the golden snapshot")
+		return nil
+	}
+	for _, c := range changes {
+		log.Println(c)
+	}
+	return fmt.Errorf("%d declarations differ from the golden snapshot; run with --update-golden to accept them", len(changes))
+}
+
 func runCmd(wd string, args []string) error {
 	if len(args) == 0 {
 		return nil
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gotranspile/cxgo"
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func init() {
+	cmdVerify := &cobra.Command{
+		Use:   "verify file.c...",
+		Short: "compile C files and their Go translation, run both and compare the output",
+	}
+	Root.AddCommand(cmdVerify)
+
+	fCC := cmdVerify.Flags().String("cc", "cc", "C compiler used to build the original program")
+	fCFlags := cmdVerify.Flags().StringSlice("cflags", nil, "additional C compiler flags")
+	fInclude := cmdVerify.Flags().StringSliceP("include", "I", nil, "include directories")
+	fDefine := cmdVerify.Flags().StringArrayP("define", "D", nil, "define a macro (NAME or NAME=VALUE)")
+	fArgs := cmdVerify.Flags().StringArray("arg", nil, "command line argument passed to both programs")
+	fInputs := cmdVerify.Flags().StringArrayP("input", "i", nil, "file passed to stdin of both programs; each input is a separate run")
+	fRuntime := cmdVerify.Flags().String("runtime", "", "local path of the cxgo module to use for the runtime")
+	fKeep := cmdVerify.Flags().Bool("keep", false, "keep the work directory with both programs")
+	cmdVerify.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("at least one file must be specified")
+		}
+		// build and run failures are not usage errors
+		cmd.SilenceUsage = true
+		dir, err := os.MkdirTemp("", "cxgo-verify-")
+		if err != nil {
+			return err
+		}
+		if *fKeep {
+			log.Printf("work directory: %s", dir)
+		} else {
+			defer os.RemoveAll(dir)
+		}
+		v := &verifier{
+			dir:     dir,
+			files:   args,
+			include: *fInclude,
+			args:    *fArgs,
+		}
+		for _, d := range *fDefine {
+			name, val, _ := strings.Cut(d, "=")
+			v.define = append(v.define, cxgo.Define{Name: name, Value: val})
+		}
+		cbin, err := v.buildC(*fCC, *fCFlags)
+		if err != nil {
+			return err
+		}
+		gobin, err := v.buildGo(*fRuntime)
+		if err != nil {
+			return err
+		}
+		inputs := *fInputs
+		if len(inputs) == 0 {
+			inputs = []string{""}
+		}
+		failed := 0
+		for _, in := range inputs {
+			name := in
+			if name == "" {
+				name = "(no input)"
+			}
+			cres, err := v.run(cbin, in)
+			if err != nil {
+				return err
+			}
+			gores, err := v.run(gobin, in)
+			if err != nil {
+				return err
+			}
+			if diff := cres.diff(gores); diff != "" {
+				failed++
+				fmt.Printf("FAIL %s\n%s", name, diff)
+			} else {
+				fmt.Printf("ok   %s\n", name)
+			}
+		}
+		if failed != 0 {
+			return fmt.Errorf("%d of %d runs differ", failed, len(inputs))
+		}
+		return nil
+	}
+}
+
+// verifier builds the original C program and its Go translation in a work directory.
+type verifier struct {
+	dir     string
+	files   []string
+	include []string
+	define  []cxgo.Define
+	args    []string
+}
+
+// buildC compiles C files with the system compiler.
+func (v *verifier) buildC(cc string, cflags []string) (string, error) {
+	bin := filepath.Join(v.dir, "c.out")
+	args := append([]string{}, cflags...)
+	for _, dir := range v.include {
+		args = append(args, "-I", dir)
+	}
+	for _, d := range v.define {
+		if d.Value != "" {
+			args = append(args, "-D"+d.Name+"="+d.Value)
+		} else {
+			args = append(args, "-D"+d.Name)
+		}
+	}
+	args = append(args, "-o", bin)
+	args = append(args, v.files...)
+	if err := verifyCmd("", cc, args...); err != nil {
+		return "", fmt.Errorf("C compilation failed: %w", err)
+	}
+	return bin, nil
+}
+
+// buildGo translates C files to a Go module and builds it.
+func (v *verifier) buildGo(runtime string) (string, error) {
+	out := filepath.Join(v.dir, "go")
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return "", err
+	}
+	env := libs.NewEnv(types.Config{UseGoInt: true})
+	proj := cxgo.NewProject(env)
+	for _, in := range v.files {
+		err := proj.Translate(filepath.Dir(in), in, out, cxgo.Config{
+			Package:  "main",
+			MaxDecls: -1,
+			Include:  v.include,
+			Define:   v.define,
+		})
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", in, err)
+		}
+	}
+	if err := proj.Flush(); err != nil {
+		return "", err
+	}
+	gomod := fmt.Sprintf("module main\n\ngo 1.18\n\nrequire %s %s\n", libs.RuntimePackage, libs.RuntimePackageVers)
+	if runtime != "" {
+		abs, err := filepath.Abs(runtime)
+		if err != nil {
+			return "", err
+		}
+		gomod = fmt.Sprintf("module main\n\ngo 1.18\n\nrequire %s v0.0.0-local\n\nreplace %s v0.0.0-local => %s\n",
+			libs.RuntimePackage, libs.RuntimePackage, abs)
+	}
+	if err := os.WriteFile(filepath.Join(out, "go.mod"), []byte(gomod), 0644); err != nil {
+		return "", err
+	}
+	if err := verifyCmd(out, "go", "mod", "tidy"); err != nil {
+		return "", err
+	}
+	bin := filepath.Join(v.dir, "go.out")
+	if err := verifyCmd(out, "go", "build", "-o", bin, "."); err != nil {
+		return "", fmt.Errorf("Go compilation failed: %w", err)
+	}
+	return bin, nil
+}
+
+// verifyResult is the observable behavior of a single run.
+type verifyResult struct {
+	Out  string
+	Code int
+}
+
+// run executes the program with an optional stdin file.
+func (v *verifier) run(bin, input string) (*verifyResult, error) {
+	cmd := exec.Command(bin, v.args...)
+	if input != "" {
+		f, err := os.Open(input)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		cmd.Stdin = f
+	}
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	res := &verifyResult{Out: buf.String()}
+	var e *exec.ExitError
+	if errors.As(err, &e) && e.Exited() {
+		res.Code = e.ExitCode()
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", bin, err)
+	}
+	return res, nil
+}
+
+// diff describes the first difference between the output of the C program and the Go one.
+func (r *verifyResult) diff(g *verifyResult) string {
+	var buf strings.Builder
+	if r.Code != g.Code {
+		fmt.Fprintf(&buf, "\texit code: C %d, Go %d\n", r.Code, g.Code)
+	}
+	if r.Out != g.Out {
+		cl, gl := strings.SplitAfter(r.Out, "\n"), strings.SplitAfter(g.Out, "\n")
+		for i := 0; i < len(cl) || i < len(gl); i++ {
+			var cline, gline string
+			if i < len(cl) {
+				cline = cl[i]
+			}
+			if i < len(gl) {
+				gline = gl[i]
+			}
+			if cline != gline {
+				fmt.Fprintf(&buf, "\tstdout line %d:\n\t\tC:  %q\n\t\tGo: %q\n", i+1, cline, gline)
+				break
+			}
+		}
+	}
+	return buf.String()
+}
+
+// verifyCmd runs a build command, and returns its output with the error.
+func verifyCmd(wd, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = wd
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w\n%s", name, err, buf.String())
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyDiff(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		c    verifyResult
+		g    verifyResult
+		exp  string
+	}{
+		{
+			name: "same",
+			c:    verifyResult{Out: "a\nb\n", Code: 1},
+			g:    verifyResult{Out: "a\nb\n", Code: 1},
+		},
+		{
+			name: "code",
+			c:    verifyResult{Out: "a\n", Code: 1},
+			g:    verifyResult{Out: "a\n"},
+			exp:  "\texit code: C 1, Go 0\n",
+		},
+		{
+			name: "line",
+			c:    verifyResult{Out: "a\nb\nc\n"},
+			g:    verifyResult{Out: "a\nx\ny\n"},
+			exp:  "\tstdout line 2:\n\t\tC:  \"b\\n\"\n\t\tGo: \"x\\n\"\n",
+		},
+		{
+			name: "shorter",
+			c:    verifyResult{Out: "a\nb"},
+			g:    verifyResult{Out: "a\n"},
+			exp:  "\tstdout line 2:\n\t\tC:  \"b\"\n\t\tGo: \"\"\n",
+		},
+		{
+			name: "newline",
+			c:    verifyResult{Out: "a"},
+			g:    verifyResult{Out: "a\n"},
+			exp:  "\tstdout line 1:\n\t\tC:  \"a\"\n\t\tGo: \"a\\n\"\n",
+		},
+		{
+			name: "both",
+			c:    verifyResult{Out: "a\n", Code: 2},
+			g:    verifyResult{Out: "b\n", Code: 3},
+			exp:  "\texit code: C 2, Go 3\n\tstdout line 1:\n\t\tC:  \"a\\n\"\n\t\tGo: \"b\\n\"\n",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, c.c.diff(&c.g))
+		})
+	}
+}
+
+func TestVerifyRun(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.txt")
+	require.NoError(t, os.WriteFile(in, []byte("input\n"), 0644))
+	v := &verifier{dir: dir, args: []string{"-c", `echo "$0"; cat; exit 3`, "arg"}}
+
+	res, err := v.run(sh, in)
+	require.NoError(t, err)
+	require.Equal(t, &verifyResult{Out: "arg\ninput\n", Code: 3}, res)
+
+	res, err = v.run(sh, "")
+	require.NoError(t, err)
+	require.Equal(t, &verifyResult{Out: "arg\n", Code: 3}, res)
+
+	_, err = v.run(sh, filepath.Join(dir, "missing.txt"))
+	require.Error(t, err)
+}
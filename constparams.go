@@ -0,0 +1,291 @@
+package cxgo
+
+import (
+	"strings"
+
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// ConstPtrParams selects how const pointer parameters of static functions are translated; see Config.ConstPtrParams.
+type ConstPtrParams string
+
+const (
+	// ConstPtrDefault keeps const pointer parameters as Go pointers.
+	ConstPtrDefault = ConstPtrParams("")
+	// ConstPtrSlice translates const T* parameters to []T, if the function only reads elements of the parameter,
+	// and all calls pass arrays or nil. Elements of restrict T* parameters may also be written.
+	ConstPtrSlice = ConstPtrParams("slice")
+	// ConstPtrValue is the same as ConstPtrSlice, but also passes structs by value, if the function only reads
+	// the first element of the parameter (p->x or *p), and makes no calls or writes other than to local variables.
+	ConstPtrValue = ConstPtrParams("value")
+)
+
+// ccQualified checks if the C type has a given qualifier, for example "const".
+func ccQualified(t cc.Type, qual string) bool {
+	for _, w := range strings.Fields(t.String()) {
+		switch w {
+		case qual:
+			return true
+		case "atomic", "const", "volatile", "restrict", "inline":
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// constPtr is a pointer parameter of a static function, which may be converted by Config.ConstPtrParams.
+type constPtr struct {
+	Index int  // index of the parameter
+	Write bool // restrict pointer to non-const elements, which may be written
+}
+
+// recordConstPtrs remembers const and restrict pointer parameters of a static function for Config.ConstPtrParams.
+// C strings are excluded, since their length is only known from the terminating zero.
+func (g *translator) recordConstPtrs(d *cc.Declarator, name *types.Ident, ft *types.FuncType) {
+	if g.conf.ConstPtrParams == ConstPtrDefault || !d.IsStatic() {
+		return
+	}
+	var params []constPtr
+	i := 0
+	for _, p := range d.Type().Parameters() {
+		pt := p.Type()
+		if pt.Kind() == cc.Void {
+			continue
+		}
+		if pt.Kind() == cc.Ptr {
+			isConst, isRestrict := ccQualified(pt.Elem(), "const"), ccQualified(pt, "restrict")
+			switch pt.Elem().Kind() {
+			case cc.Void, cc.Function, cc.Char, cc.SChar, cc.UChar:
+			default:
+				if _, ok := ft.Args()[i].Type().(types.PtrType); ok && (isConst || isRestrict) {
+					params = append(params, constPtr{Index: i, Write: !isConst})
+				}
+			}
+		}
+		i++
+	}
+	if len(params) != 0 {
+		g.constPtrs[name] = params
+	}
+}
+
+// constPtrElem matches an access to an element of the pointer parameter: *p, p[i] or p->x.
+// It returns the index for *p and p[i], or nil for p->x.
+func constPtrElem(n Node, id *types.Ident) (Expr, bool) {
+	isParam := func(e Expr) bool {
+		x, ok := cUnwrap(e).(Ident)
+		return ok && x.Identifier() == id
+	}
+	switch n := n.(type) {
+	case *Deref:
+		if isParam(n.X) {
+			return cUintLit(0, 10), true
+		}
+		if off, ok := n.X.(*PtrElemOffset); ok && off.Conv == nil && isParam(off.X) {
+			return off.Ind, true
+		}
+	case *CSelectExpr:
+		if isParam(n.Expr) {
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// constPtrParams converts const pointer parameters of static functions to slices or values, if Config.ConstPtrParams
+// is set. Only functions that are not used as values are converted, since the signature changes.
+func (g *translator) constPtrParams(decl []CDecl) {
+	if len(g.constPtrs) == 0 {
+		return
+	}
+	uses := make(map[*types.Ident]int)
+	calls := make(map[*types.Ident][]*CallExpr)
+	for _, d := range decl {
+		Walk(d, func(n Node) bool {
+			switch n := n.(type) {
+			case *CallExpr:
+				if id, ok := n.Fun.(Ident); ok {
+					calls[id.Identifier()] = append(calls[id.Identifier()], n)
+				}
+			case Ident:
+				uses[n.Identifier()]++
+			}
+			return true
+		})
+		if f, ok := d.(*CFuncDecl); ok {
+			// the name of the declaration itself
+			uses[f.Name]--
+		}
+	}
+	for _, d := range decl {
+		f, ok := d.(*CFuncDecl)
+		if !ok || f.Body == nil {
+			continue
+		}
+		params := g.constPtrs[f.Name]
+		if len(params) == 0 || uses[f.Name] != len(calls[f.Name]) {
+			continue
+		}
+		args := append([]*types.Field{}, f.Type.Args()...)
+		changed := false
+		for _, p := range params {
+			if nid := g.constPtrParam(f, p, calls[f.Name]); nid != nil {
+				args[p.Index] = &types.Field{Name: nid}
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if f.Type.Variadic() {
+			f.Type = g.env.VarFuncT(f.Type.Return(), args...)
+		} else {
+			f.Type = g.env.FuncT(f.Type.Return(), args...)
+		}
+	}
+}
+
+// constPtrParam converts a single parameter of the function and all its calls, and returns the new identifier.
+// It returns nil, if the parameter is used in other ways, or if some calls cannot be converted.
+func (g *translator) constPtrParam(f *CFuncDecl, p constPtr, calls []*CallExpr) *types.Ident {
+	i := p.Index
+	id := f.Type.Args()[i].Name
+	elem := id.CType(nil).(types.PtrType).Elem()
+	if elem == nil {
+		return nil
+	}
+	total, elems, first := 0, 0, true // first is set if only the first element is read
+	Walk(f.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case Ident:
+			if n.Identifier() == id {
+				total++
+			}
+		case *CAssignStmt:
+			// values must not be written, since they are copies
+			if p.Write && countIdent(n.Left, id) != 0 {
+				first = false
+			}
+		case *CIncrStmt:
+			if p.Write && countIdent(n.Expr, id) != 0 {
+				first = false
+			}
+		case *CIncrExpr:
+			if p.Write && countIdent(n.Expr, id) != 0 {
+				first = false
+			}
+		case *TakeAddr:
+			if p.Write && countIdent(n.X, id) != 0 {
+				first = false
+			}
+		}
+		if ind, ok := constPtrElem(n, id); ok {
+			elems++
+			if ind != nil {
+				if v, ok := constIntValue(ind); !ok || v != 0 {
+					first = false
+				}
+			}
+		}
+		return true
+	})
+	if total != elems {
+		return nil
+	}
+	_, isStruct := types.Unwrap(elem).(*types.StructType)
+	// a copy is stale if the function writes the struct through other pointers or globals
+	value := g.conf.ConstPtrParams == ConstPtrValue && first && isStruct && !writesMemory(f, f.Body)
+	// all calls must be converted, thus check them first
+	conv := make([]Expr, 0, len(calls))
+	for _, c := range calls {
+		if i >= len(c.Args) {
+			return nil
+		}
+		var e Expr
+		if value {
+			e = g.constPtrValueArg(c.Args[i])
+		} else {
+			e = g.constPtrSliceArg(c.Args[i])
+		}
+		if e == nil {
+			return nil
+		}
+		conv = append(conv, e)
+	}
+	for j, c := range calls {
+		c.Args[i] = conv[j]
+	}
+	var nid *types.Ident
+	if value {
+		nid = types.NewIdentGo(id.Name, id.GoIdent().Name, elem)
+	} else {
+		nid = types.NewIdentGo(id.Name, id.GoIdent().Name, types.SliceT(elem))
+	}
+	elemOf := func(ind Expr) Expr {
+		if value {
+			return IdentExpr{nid}
+		}
+		return g.NewCIndexExpr(IdentExpr{nid}, ind, elem)
+	}
+	Rewrite(f.Body, nil, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case *Deref:
+			if ind, ok := constPtrElem(n, id); ok {
+				c.Replace(elemOf(ind))
+			}
+		case *CSelectExpr:
+			if _, ok := constPtrElem(n, id); ok {
+				n.Expr = elemOf(cUintLit(0, 10))
+			}
+		case *CParentExpr:
+			// (*p).x
+			if x, ok := n.Expr.(IdentExpr); ok && x.Ident == nid {
+				c.Replace(x)
+			}
+		}
+		return true
+	})
+	return nid
+}
+
+// constPtrSliceArg converts an argument of a const pointer parameter to a slice: &arr[i] becomes arr[i:].
+func (g *translator) constPtrSliceArg(x Expr) Expr {
+	switch x := cUnwrap(x).(type) {
+	case Nil:
+		return g.Nil()
+	case IntLit:
+		if x.IsZero() {
+			return g.Nil()
+		}
+	case *TakeAddr:
+		ind, ok := x.X.(*CIndexExpr)
+		if !ok {
+			return nil
+		}
+		if _, ok := types.Unwrap(ind.Expr.CType(nil)).(types.ArrayType); !ok {
+			return nil
+		}
+		if ind.IndexZero() {
+			return &SliceExpr{Expr: ind.Expr}
+		}
+		return &SliceExpr{Expr: ind.Expr, Low: ind.Index}
+	}
+	return nil
+}
+
+// constPtrValueArg converts an argument of a const pointer parameter to a value.
+func (g *translator) constPtrValueArg(x Expr) Expr {
+	switch x := cUnwrap(x).(type) {
+	case Nil, IntLit:
+		return nil
+	case *TakeAddr:
+		return x.X
+	}
+	if !types.IsPtr(x.CType(nil)) {
+		return nil
+	}
+	return g.cDeref(g.ToPointer(x))
+}
@@ -0,0 +1,81 @@
+package cxgo
+
+import (
+	"go/ast"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+const libcContainerOfName = "libc.ContainerOf"
+
+var _ PtrExpr = (*ContainerOf)(nil)
+
+// ContainerOf is a call of the libc.ContainerOf helper that replaces the container_of idiom from C: it returns a pointer
+// to a struct that contains the field pointed by X. The offset is taken from the Go struct, but the helper still uses
+// unsafe pointer arithmetic. It is only used if Config.ContainerOf is set.
+type ContainerOf struct {
+	X     PtrExpr
+	To    types.PtrType
+	Field *types.Field
+}
+
+// containerOf checks if the pointer cast matches the container_of idiom: (T*)((char*)(p) - offsetof(T, field)).
+// It returns nil if the expression does not match, or if p is not a pointer to the field type.
+func (g *translator) containerOf(to types.PtrType, x Expr) *ContainerOf {
+	if _, ok := types.Unwrap(to.Elem()).(*types.StructType); !ok {
+		return nil
+	}
+	off, ok := cUnwrap(x).(*PtrVarOffset)
+	if !ok || off.Mul != -1 || off.Conv != nil {
+		return nil
+	}
+	conv, ok := off.X.(*PtrToPtr)
+	if !ok {
+		return nil
+	}
+	if e := conv.To.Elem(); e != nil && e.Sizeof() != 1 {
+		return nil
+	}
+	fo, ok := unwrapCasts(off.Ind).(*OffsetofExpr)
+	if !ok || len(fo.Steps) != 1 || !types.Same(fo.Type, to.Elem()) {
+		return nil
+	}
+	f := fo.Steps[0].Field
+	if !types.Same(f.Type(), conv.X.PtrType(nil).Elem()) {
+		return nil
+	}
+	return &ContainerOf{X: conv.X, To: to, Field: f}
+}
+
+func (e *ContainerOf) Visit(v Visitor) {
+	v(e.X)
+}
+
+func (e *ContainerOf) CType(types.Type) types.Type {
+	return e.To
+}
+
+func (e *ContainerOf) PtrType(types.PtrType) types.PtrType {
+	return e.To
+}
+
+func (e *ContainerOf) AsExpr() GoExpr {
+	elem := e.To.Elem().GoType()
+	off := call(ident("unsafe.Offsetof"), &ast.SelectorExpr{
+		X:   &ast.CompositeLit{Type: elem},
+		Sel: e.Field.Name.GoIdent(),
+	})
+	return call(&ast.IndexExpr{X: ident(libcContainerOfName), Index: elem}, e.X.AsExpr(), off)
+}
+
+func (e *ContainerOf) IsConst() bool {
+	return false
+}
+
+func (e *ContainerOf) HasSideEffects() bool {
+	return e.X.HasSideEffects()
+}
+
+func (e *ContainerOf) Uses() []types.Usage {
+	return e.X.Uses()
+}
@@ -0,0 +1,103 @@
+package cxgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// TranslateContext is the same as Translate, but stops the translation when the context is cancelled.
+func TranslateContext(ctx context.Context, root, fname, out string, env *libs.Env, conf Config) error {
+	conf.ctx = ctx
+	return Translate(root, fname, out, env, conf)
+}
+
+// TranslateASTContext is the same as TranslateAST, but stops the translation when the context is cancelled.
+func TranslateASTContext(ctx context.Context, fname string, tu *cc.AST, env *libs.Env, conf Config) ([]GoDecl, error) {
+	conf.ctx = ctx
+	return TranslateAST(fname, tu, env, conf)
+}
+
+// TranslateContext is the same as Translate, but stops the translation when the context is cancelled.
+func (p *TranslatorProject) TranslateContext(ctx context.Context, root, fname, out string, conf Config) error {
+	conf.ctx = ctx
+	return p.Translate(root, fname, out, conf)
+}
+
+// TranslateASTContext is the same as TranslateAST, but stops the translation when the context is cancelled.
+func (p *TranslatorProject) TranslateASTContext(ctx context.Context, fname string, tu *cc.AST, conf Config) ([]GoDecl, error) {
+	conf.ctx = ctx
+	return p.TranslateAST(fname, tu, conf)
+}
+
+// TranslateContext is the same as Translate, but stops the translation when the context is cancelled.
+func (p *TargetProject) TranslateContext(ctx context.Context, root, fname, out string, conf Config) error {
+	conf.ctx = ctx
+	return p.Translate(root, fname, out, conf)
+}
+
+// cancelled is used to unwind the translation when the context is cancelled; see recoverError.
+type cancelled struct {
+	err error
+}
+
+// checkCancel stops the translation if the context is cancelled. It is called between and during long passes.
+func (g *translator) checkCancel() {
+	if g.conf.ctx == nil {
+		return
+	}
+	if err := g.conf.ctx.Err(); err != nil {
+		panic(cancelled{err: err})
+	}
+}
+
+// recoverError converts panics from checkCancel and for unsupported C constructs back to errors.
+func recoverError(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if c, ok := r.(cancelled); ok {
+		*err = c.err
+		return
+	}
+	var (
+		uerr *ErrUnsupportedConstruct
+		derr *ErrConflictingDefinition
+	)
+	if e, ok := r.(error); ok && (errors.As(e, &uerr) || errors.As(e, &derr)) {
+		*err = e
+		return
+	}
+	panic(r)
+}
+
+// newCancelFS returns a filesystem that fails when the context is cancelled. The parser opens files all the time,
+// thus this is the way to cancel parsing.
+func newCancelFS(ctx context.Context, fs cc.Filesystem) cc.Filesystem {
+	return cancelFS{ctx: ctx, fs: fs}
+}
+
+type cancelFS struct {
+	ctx context.Context
+	fs  cc.Filesystem
+}
+
+func (fs cancelFS) Stat(path string, sys bool) (os.FileInfo, error) {
+	if err := fs.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return fs.fs.Stat(path, sys)
+}
+
+func (fs cancelFS) Open(path string, sys bool) (io.ReadCloser, error) {
+	if err := fs.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return fs.fs.Open(path, sys)
+}
@@ -0,0 +1,112 @@
+package cxgo
+
+import (
+	"go/format"
+	token2 "go/token"
+	"strings"
+
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// ConvRisk is a risk level of an implicit conversion. Higher values are riskier.
+type ConvRisk int
+
+const (
+	ConvRiskLow    = ConvRisk(iota + 1) // sign change without narrowing
+	ConvRiskMedium                      // narrowing without sign change
+	ConvRiskHigh                        // narrowing with sign change or float to int conversion
+)
+
+func (r ConvRisk) String() string {
+	switch r {
+	case ConvRiskLow:
+		return "low"
+	case ConvRiskMedium:
+		return "medium"
+	case ConvRiskHigh:
+		return "high"
+	}
+	return "none"
+}
+
+// Conversion is an implicit C conversion that may change the value and was translated to an explicit Go conversion.
+type Conversion struct {
+	Where token.Position // position of the statement or declaration
+	From  string         // Go type of the value
+	To    string         // Go type of the result
+	Risk  ConvRisk
+}
+
+func (c Conversion) String() string {
+	return c.Where.String() + ": " + c.Risk.String() + ": " + c.From + " -> " + c.To
+}
+
+// convRisk returns a risk level for an implicit conversion of a value between given types.
+// Zero is returned for conversions that never change the value.
+func convRisk(from, to types.Type) ConvRisk {
+	from, to = types.Unwrap(from), types.Unwrap(to)
+	switch {
+	case from.Kind().IsFloat() && to.Kind().IsInt():
+		return ConvRiskHigh
+	case !from.Kind().IsInt() || !to.Kind().IsInt():
+		return 0
+	case from.Kind().IsUntyped() || to.Kind().IsUntyped():
+		return 0
+	}
+	fi, ok1 := from.(types.IntType)
+	ti, ok2 := to.(types.IntType)
+	if !ok1 || !ok2 {
+		return 0
+	}
+	narrow := ti.Sizeof() < fi.Sizeof()
+	switch {
+	case fi.Signed() == ti.Signed():
+		if narrow {
+			return ConvRiskMedium
+		}
+		return 0
+	case !fi.Signed() && ti.Sizeof() > fi.Sizeof():
+		// unsigned to a larger signed type
+		return 0
+	case narrow:
+		return ConvRiskHigh
+	}
+	return ConvRiskLow
+}
+
+// implicitCast is the same as cCast, but reports the conversion if it may change the value.
+// It must be used for conversions that are not written explicitly in C.
+func (g *translator) implicitCast(to types.Type, x Expr) Expr {
+	g.checkConversion(to, x)
+	return g.cCast(to, x)
+}
+
+// checkConversion reports an implicit conversion of x to a given type, if it may change the value.
+func (g *translator) checkConversion(to types.Type, x Expr) {
+	g.checkConversionAt(g.pos, to, x)
+}
+
+func (g *translator) checkConversionAt(pos token.Position, to types.Type, x Expr) {
+	if g.conf.OnConversion == nil || x.IsConst() {
+		return
+	}
+	from := x.CType(nil)
+	if risk := convRisk(from, to); risk != 0 {
+		g.conf.OnConversion(Conversion{
+			Where: pos,
+			From:  goTypeString(from),
+			To:    goTypeString(to),
+			Risk:  risk,
+		})
+	}
+}
+
+func goTypeString(t types.Type) string {
+	var buf strings.Builder
+	if err := format.Node(&buf, token2.NewFileSet(), t.GoType()); err != nil {
+		return "?"
+	}
+	return buf.String()
+}
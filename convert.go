@@ -2,6 +2,7 @@ package cxgo
 
 import (
 	"fmt"
+	gotypes "go/types"
 	"strings"
 
 	"modernc.org/cc/v3"
@@ -20,17 +21,26 @@ func (g *translator) newIdent(name string, t types.Type) *types.Ident {
 }
 
 func (g *translator) convMacro(name string, fnc func() Expr) Expr {
-	if g.env.ForceMacro(name) {
+	if g.env.ForceMacro(name) || g.isHostMacro(name) {
 		return fnc()
 	}
 	id, ok := g.macros[name]
 	if ok {
-		return IdentExpr{id}
+		if prev, ok := g.macroVals[name]; !ok || prev.unit == g.unit {
+			return IdentExpr{id}
+		}
 	}
 	x := fnc()
+	g.checkDef(g.macroVals, "macro", name, gotypes.ExprString(x.AsExpr()), g.pos)
+	if ok {
+		return IdentExpr{id}
+	}
 	typ := x.CType(nil)
 	id = g.newIdent(name, typ)
 	g.macros[name] = id
+	if l, ok := x.(IntLit); ok {
+		g.macroInts[id] = l
+	}
 	return IdentExpr{id}
 }
 
@@ -57,6 +67,7 @@ func (g *translator) convertIdentWith(name string, t types.Type, decls ...cc.Nod
 	}
 	id := g.newIdent(name, t)
 	if to, ok := g.idents[name]; ok && to.Rename != "" {
+		g.conf.debug("renaming identifier", "name", name, "to", to.Rename)
 		id.GoName = to.Rename
 	}
 	for _, d := range decls {
@@ -71,7 +82,7 @@ func (g *translator) replaceIdentWith(id *types.Ident, decls ...cc.Node) {
 	}
 }
 
-func (g *translator) tryConvertIdentOn(t types.Type, tok cc.Token) (*types.Ident, bool) {
+func (g *translator) tryConvertIdentOn(t types.Type, name string) (*types.Ident, bool) {
 loop:
 	for {
 		switch s := t.(type) {
@@ -87,13 +98,12 @@ loop:
 	}
 	switch t := t.(type) {
 	case *types.StructType:
-		name := tok.Value.String()
 		for _, f := range t.Fields() {
 			if name == f.Name.Name {
 				return f.Name, true
 			}
 			if f.Name.Name == "" {
-				if id, ok := g.tryConvertIdentOn(f.Type(), tok); ok {
+				if id, ok := g.tryConvertIdentOn(f.Type(), name); ok {
 					return id, true
 				}
 			}
@@ -103,7 +113,7 @@ loop:
 }
 
 func (g *translator) convertIdentOn(t types.Type, tok cc.Token) *types.Ident {
-	id, ok := g.tryConvertIdentOn(t, tok)
+	id, ok := g.tryConvertIdentOn(t, tok.Value.String())
 	if ok {
 		return id
 	}
@@ -117,15 +127,33 @@ func (g *translator) convertFuncDef(d *cc.FunctionDefinition) []CDecl {
 		sname := decl.Name().String()
 		conf := g.idents[sname]
 		ft := g.convertFuncType(conf, decl, decl.Type(), decl.Position())
-		if !g.inCurFile(d) {
+		inline := g.isHeaderInline(decl)
+		if !inline && !g.inCurFile(d) {
 			return nil
 		}
 		name := g.convertIdentWith(sname, ft, decl)
+		if inline {
+			g.inlines = append(g.inlines, name.Ident)
+		}
+		g.benchCgo(name.Ident, ft, decl.Type())
+		if g.conf.Cgo || conf.Cgo {
+			return []CDecl{g.newCgoFuncDecl(name.Ident, ft, decl.Type())}
+		}
+		g.recordConstPtrs(decl, name.Ident, ft)
+		g.recordVaList(decl, name.Ident, ft)
+		prevLocals := g.locals
+		var params []string
+		for _, p := range decl.Type().Parameters() {
+			params = append(params, p.Name().String())
+		}
+		g.locals = localConfigs(conf, params)
+		body := g.convertFuncBody(d.CompoundStatement).In(ft)
+		g.locals = prevLocals
 		return []CDecl{
 			&CFuncDecl{
 				Name: name.Ident,
 				Type: ft,
-				Body: g.convertCompBlockStmt(d.CompoundStatement).In(ft),
+				Body: g.traceFunc(sname, ft, body),
 				Range: &Range{
 					Start:     d.Position().Offset,
 					StartLine: d.Position().Line,
@@ -133,10 +161,51 @@ func (g *translator) convertFuncDef(d *cc.FunctionDefinition) []CDecl {
 			},
 		}
 	default:
-		panic(dd.Case.String() + " " + dd.Position().String())
+		panic(unsupported(dd, dd.Case))
 	}
 }
 
+// localConfigs returns configs for local variables of the function. Those are the function fields that don't refer
+// to arguments or the return value.
+func localConfigs(conf IdentConfig, params []string) map[string]IdentConfig {
+	if len(conf.Fields) == 0 {
+		return nil
+	}
+	args := make(map[string]struct{})
+	for _, name := range params {
+		args[name] = struct{}{}
+	}
+	var locals map[string]IdentConfig
+	for _, f := range conf.Fields {
+		if _, ok := args[f.Name]; ok || f.Name == "" || f.Name == "return" {
+			continue
+		}
+		if locals == nil {
+			locals = make(map[string]IdentConfig)
+		}
+		locals[f.Name] = f
+	}
+	return locals
+}
+
+// declConfig returns a config for a declaration. Configs of local variables take precedence over global ones.
+func (g *translator) declConfig(name string) IdentConfig {
+	if c, ok := g.locals[name]; ok {
+		return c
+	}
+	return g.idents[name]
+}
+
+func (g *translator) convertFuncBody(d *cc.CompoundStatement) *BlockStmt {
+	if !g.conf.Coverage {
+		return g.convertCompBlockStmt(d)
+	}
+	c := g.coverStmt(coverCompPos(d))
+	b := g.convertCompBlockStmt(d)
+	b.Stmts = append([]CStmt{c}, b.Stmts...)
+	return b
+}
+
 type positioner interface {
 	Position() token.Position
 }
@@ -202,7 +271,7 @@ func (g *translator) convertInitExpr(d *cc.Initializer) Expr {
 			d.InitializerList,
 		)
 	default:
-		panic(d.Case.String() + " " + d.Position().String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -210,28 +279,49 @@ func (g *translator) convertEnum(b *cc.Declaration, typ types.Type, d *cc.EnumSp
 	if d.EnumeratorList == nil {
 		return nil
 	}
+	var (
+		inits []Expr
+		enums []*cc.Enumerator
+	)
+	for it := d.EnumeratorList; it != nil; it = it.EnumeratorList {
+		e := it.Enumerator
+		enums = append(enums, e)
+		if e.Case == cc.EnumeratorExpr {
+			inits = append(inits, g.convertConstExpr(e.ConstantExpression))
+		} else {
+			inits = append(inits, nil)
+		}
+	}
+	return g.newEnumDecl(typ, inits, func(i int, typ types.Type) *types.Ident {
+		e := enums[i]
+		return g.convertIdentWith(e.Token.Value.String(), typ, e).Ident
+	})
+}
+
+// newEnumDecl creates a declaration of enum constants, given their initializers (nil for implicit values).
+// The function is called to create an identifier for each constant.
+func (g *translator) newEnumDecl(typ types.Type, inits []Expr, ident func(i int, typ types.Type) *types.Ident) []CDecl {
 	if typ == nil {
 		typ = types.UntypedIntT(g.env.IntSize())
 	}
 	vd := &CVarDecl{
 		Const:    true,
 		Single:   false,
-		CVarSpec: CVarSpec{g: g, Type: typ},
+		CVarSpec: CVarSpec{g: g, Type: typ, Inits: inits},
 	}
 	var (
 		autos  = 0 // number of implicit inits
 		values = 0 // number of explicit inits
 	)
-	for it := d.EnumeratorList; it != nil; it = it.EnumeratorList {
-		e := it.Enumerator
-		if e.Case == cc.EnumeratorExpr {
-			init := g.convertConstExpr(e.ConstantExpression)
-			vd.Inits = append(vd.Inits, init)
+	for _, init := range vd.Inits {
+		if init != nil {
 			values++
-			continue
+		} else {
+			autos++
 		}
-		vd.Inits = append(vd.Inits, nil)
-		autos++
+	}
+	if len(vd.Inits) == 0 {
+		return nil
 	}
 	if autos == 1 && vd.Inits[0] == nil {
 		autos--
@@ -253,8 +343,7 @@ func (g *translator) convertEnum(b *cc.Declaration, typ types.Type, d *cc.EnumSp
 		panic("TODO: mixed enums")
 	}
 	var next int64
-	for it, i := d.EnumeratorList, 0; it != nil; it, i = it.EnumeratorList, i+1 {
-		e := it.Enumerator
+	for i := range vd.Inits {
 		if isIota {
 			if i == 0 {
 				iot := g.Iota()
@@ -276,7 +365,7 @@ func (g *translator) convertEnum(b *cc.Declaration, typ types.Type, d *cc.EnumSp
 				next = l.Int() + 1
 			}
 		}
-		vd.Names = append(vd.Names, g.convertIdentWith(e.Token.Value.String(), typ, e).Ident)
+		vd.Names = append(vd.Names, ident(i, typ))
 	}
 	if len(vd.Names) == 0 {
 		return nil
@@ -296,7 +385,7 @@ func (g *translator) convertTypedefName(d *cc.Declaration) (cc.Token, *cc.Declar
 	}
 	id := d.InitDeclaratorList.InitDeclarator
 	if id.Case != cc.InitDeclaratorDecl {
-		panic(id.Case.String())
+		panic(unsupported(id, id.Case))
 	}
 	dd := id.Declarator
 	if dd.DirectDeclarator.Case != cc.DirectDeclaratorIdent {
@@ -347,7 +436,7 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 			case cc.TypeQualifierVolatile:
 				isVolatile = true
 			default:
-				panic(ds.Case.String())
+				panic(unsupported(ds, ds.Case))
 			}
 		case cc.DeclarationSpecifiersStorage:
 			ds := sp.StorageClassSpecifier
@@ -361,7 +450,7 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 			case cc.StorageClassSpecifierRegister:
 				// ignore
 			default:
-				panic(ds.Case.String())
+				panic(unsupported(ds, ds.Case))
 			}
 			if isTypedef {
 				panic("wrong type")
@@ -388,9 +477,16 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 					if isForward {
 						panic("already marked as a forward decl")
 					}
-					typeSpec = g.convertType(conf, su.Type(), d.Position())
+					if isTypedef && su.Type().Name() == 0 && len(names) != 0 {
+						// anonymous struct typedef: nested anonymous types should be named after the typedef
+						g.typeScope = append(g.typeScope, names[0])
+						typeSpec = g.convertType(conf, su.Type(), d.Position())
+						g.typeScope = g.typeScope[:len(g.typeScope)-1]
+					} else {
+						typeSpec = g.convertType(conf, su.Type(), d.Position())
+					}
 				default:
-					panic(su.Case.String())
+					panic(unsupported(su, su.Case))
 				}
 			case cc.TypeSpecifierEnum:
 				enumSpec = ds.EnumSpecifier
@@ -403,10 +499,9 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 			isFunc = true
 			// TODO: use specifiers
 		default:
-			panic(sp.Case.String() + " " + sp.Position().String())
+			panic(unsupported(sp, sp.Case))
 		}
 	}
-	_ = isStatic // FIXME: static
 	_ = isVolatile
 	_ = isAuto // FIXME: auto
 	var decls []CDecl
@@ -492,9 +587,15 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 		id := il.InitDeclarator
 		switch id.Case {
 		case cc.InitDeclaratorDecl, cc.InitDeclaratorInit:
+			if isStaticAssert(id) {
+				if inCur {
+					decls = append(decls, g.convertStaticAssert(id))
+				}
+				continue
+			}
 			dd := id.Declarator
 			dname := dd.Name().String()
-			conf := g.idents[dname]
+			conf := g.declConfig(dname)
 			vt := g.convertTypeRootOpt(conf, dd.Type(), id.Position())
 			if isTypedef && vt == nil {
 				vt = types.StructT(nil)
@@ -504,7 +605,15 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 				if isTypedef {
 					panic("init in typedef: " + id.Position().String())
 				}
+				g.pos = id.Position()
 				init = g.convertInitExpr(id.Initializer)
+				if init != nil && vt != nil {
+					g.checkConversion(vt, init)
+				}
+			}
+			if (conf.Type == HintSlices || conf.Type == HintFlat) && !isTypedef && !isExtern && inCur {
+				// C arrays are allocated, unlike slices
+				init = g.multiDimInit(conf.Type, g.convertType(IdentConfig{}, dd.Type(), id.Position()), vt, init, id.Position())
 			}
 			if isConst && propagateConst(vt) {
 				isConst = false
@@ -536,6 +645,12 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 				decls = append(decls, &CTypeDef{nt})
 				continue
 			}
+			if lc, ok := g.locals[dname]; ok && lc.Rename != "" {
+				// don't reuse identifiers with the same name, the rename only affects this function
+				id := types.NewIdent(dname, vt)
+				id.GoName = lc.Rename
+				g.replaceIdentWith(id, dd)
+			}
 			name := g.convertIdentWith(dd.NameTok().String(), vt, dd)
 			isDecl := false
 			for di := dd.DirectDeclarator; di != nil; di = di.DirectDeclarator {
@@ -564,6 +679,8 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 					}
 					g.replaceIdentWith(id, dd)
 					skipped++
+				} else if g.conf.Cgo {
+					decls = append(decls, g.newCgoFuncDecl(name.Ident, ft, dd.Type()))
 				} else if g.conf.ForwardDecl {
 					decls = append(decls, &CFuncDecl{
 						Name: name.Ident,
@@ -582,7 +699,8 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 					}
 					decls = append(decls, &CVarDecl{
 						// There is no real const in C
-						Const: false, // Const: isConst,
+						Const:  false, // Const: isConst,
+						Static: isStatic,
 						CVarSpec: CVarSpec{
 							g:     g,
 							Type:  vt,
@@ -595,7 +713,7 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 				}
 			}
 		default:
-			panic(id.Case.String())
+			panic(unsupported(id, id.Case))
 		}
 	}
 	if !inCur {
@@ -619,7 +737,7 @@ func (g *translator) convertCompStmt(d *cc.CompoundStatement) []CStmt {
 		case cc.BlockItemStmt:
 			stmts = append(stmts, g.convertStmt(st.Statement)...)
 		default:
-			panic(st.Case.String())
+			panic(unsupported(st, st.Case))
 		}
 	}
 	// TODO: shouldn't it return statements without a block? or call an optimizing version of block constructor?
@@ -675,7 +793,7 @@ func (g *translator) convertMulExpr(d *cc.MultiplicativeExpression) Expr {
 	case cc.MultiplicativeExpressionMod:
 		op = BinOpMod
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 	return g.NewCBinaryExprT(
 		x, op, y,
@@ -697,7 +815,7 @@ func (g *translator) convertAddExpr(d *cc.AdditiveExpression) Expr {
 	case cc.AdditiveExpressionSub:
 		op = BinOpSub
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 	return g.NewCBinaryExprT(
 		x, op, y,
@@ -719,7 +837,7 @@ func (g *translator) convertShiftExpr(d *cc.ShiftExpression) Expr {
 	case cc.ShiftExpressionRsh:
 		op = BinOpRsh
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 	return g.NewCBinaryExprT(
 		x, op, y,
@@ -745,7 +863,7 @@ func (g *translator) convertRelExpr(d *cc.RelationalExpression) Expr {
 	case cc.RelationalExpressionGeq:
 		op = BinOpGte
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 	return g.Compare(x, op, y)
 }
@@ -764,7 +882,7 @@ func (g *translator) convertEqExpr(d *cc.EqualityExpression) Expr {
 	case cc.EqualityExpressionNeq:
 		op = BinOpNeq
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 	return g.Compare(x, op, y)
 }
@@ -781,7 +899,7 @@ func (g *translator) convertAndExpr(d *cc.AndExpression) Expr {
 			g.convertTypeOper(d.Operand, d.Position()),
 		)
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -797,7 +915,7 @@ func (g *translator) convertLOrExcExpr(d *cc.ExclusiveOrExpression) Expr {
 			g.convertTypeOper(d.Operand, d.Position()),
 		)
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -813,7 +931,7 @@ func (g *translator) convertLOrIncExpr(d *cc.InclusiveOrExpression) Expr {
 			g.convertTypeOper(d.Operand, d.Position()),
 		)
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -826,7 +944,7 @@ func (g *translator) convertLAndExpr(d *cc.LogicalAndExpression) Expr {
 		y := g.convertLOrIncExpr(d.InclusiveOrExpression)
 		return And(g.ToBool(x), g.ToBool(y))
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -839,7 +957,7 @@ func (g *translator) convertLOrExpr(d *cc.LogicalOrExpression) Expr {
 		y := g.convertLAndExpr(d.LogicalAndExpression)
 		return Or(g.ToBool(x), g.ToBool(y))
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -855,7 +973,7 @@ func (g *translator) convertCondExpr(d *cc.ConditionalExpression) Expr {
 			g.convertCondExpr(d.ConditionalExpression),
 		)
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -868,7 +986,11 @@ func (g *translator) convertPriExpr(d *cc.PrimaryExpression) Expr {
 		if d.Operand == nil {
 			panic(ErrorfWithPos(d.Position(), "empty operand for %q", d.Token.String()))
 		}
-		return g.convertIdent(d.ResolvedIn(), d.Token, g.convertTypeOper(d.Operand, d.Position()))
+		id := g.convertIdent(d.ResolvedIn(), d.Token, g.convertTypeOper(d.Operand, d.Position()))
+		if t := d.Operand.Type(); t != nil && t.Kind() == cc.Function {
+			g.funcIdents[id.Ident] = struct{}{}
+		}
+		return g.atomicIdent(id, d.Operand.Type() != nil && d.Operand.Type().IsVolatile())
 	case cc.PrimaryExpressionEnum: // X
 		return g.convertIdent(d.ResolvedIn(), d.Token, g.convertTypeOper(d.Operand, d.Position()))
 	case cc.PrimaryExpressionInt: // 1
@@ -877,6 +999,9 @@ func (g *translator) convertPriExpr(d *cc.PrimaryExpression) Expr {
 			if err != nil {
 				panic(err)
 			}
+			if d.Operand != nil && hasIntSuffix(d.Token.String()) {
+				v = v.withSuffix(g.convertTypeOper(d.Operand, d.Position()))
+			}
 			return v
 		}
 		if m := d.Token.Macro(); m != 0 {
@@ -948,6 +1073,9 @@ func (g *translator) convertPriExpr(d *cc.PrimaryExpression) Expr {
 		}
 		return fnc()
 	case cc.PrimaryExpressionExpr: // "(x)"
+		if id := g.macroUse(d.Token, d.Token2, d.Operand); id != nil {
+			return IdentExpr{id}
+		}
 		e := g.convertExpr(d.Expression)
 		return cParen(e)
 	case cc.PrimaryExpressionStmt: // "({...; x})"
@@ -990,7 +1118,7 @@ func (g *translator) convertOneDesignator(typ types.Type, list *cc.DesignatorLis
 		f = &CompLitField{Field: g.convertIdentOn(typ, d.Token)}
 		sub = f.Field.CType(nil)
 	default:
-		panic(d.Case.String() + " " + d.Position().String())
+		panic(unsupported(d, d.Case))
 	}
 	if list.DesignatorList == nil {
 		f.Value = val
@@ -1049,7 +1177,7 @@ func (g *translator) convertPostfixExpr(d *cc.PostfixExpression) Expr {
 			d.InitializerList,
 		)
 	default:
-		panic(d.Case.String() + " " + d.Position().String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -1067,7 +1195,7 @@ func (g *translator) convertCastExpr(d *cc.CastExpression) Expr {
 			x,
 		)
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -1082,21 +1210,15 @@ func (g *translator) convertUnaryExpr(d *cc.UnaryExpression) Expr {
 		x := g.convertUnaryExpr(d.UnaryExpression)
 		return g.NewCPrefixExpr(x, true)
 	case cc.UnaryExpressionSizeofExpr: // sizeof x
-		return g.NewCUnaryExprT(
-			UnarySizeof,
-			g.convertUnaryExpr(d.UnaryExpression),
-			g.convertTypeOper(d.Operand, d.Position()),
-		)
+		return g.convertSizeofExpr(d)
 	case cc.UnaryExpressionSizeofType: // sizeof tp
-		return g.SizeofT(
-			g.convertType(IdentConfig{}, d.TypeName.Type(), d.Position()),
-			nil,
-		)
+		t := g.convertType(IdentConfig{}, d.TypeName.Type(), d.Position())
+		g.checkSizeofType(d.TypeName.Type(), t, d.Position(), "sizeof")
+		return g.SizeofT(t, nil)
 	case cc.UnaryExpressionAlignofType: // alignof tp
-		return g.AlignofT(
-			g.convertType(IdentConfig{}, d.TypeName.Type(), d.Position()),
-			nil,
-		)
+		t := g.convertType(IdentConfig{}, d.TypeName.Type(), d.Position())
+		g.checkSizeofType(d.TypeName.Type(), t, d.Position(), "alignof")
+		return g.AlignofT(t, nil)
 	}
 	var op UnaryOp
 	switch d.Case {
@@ -1117,7 +1239,12 @@ func (g *translator) convertUnaryExpr(d *cc.UnaryExpression) Expr {
 		x := g.convertCastExpr(d.CastExpression)
 		return g.cNot(x)
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
+	}
+	if tok, ok := ccPrimaryToken(d.CastExpression); ok {
+		if id := g.macroUse(d.Token, tok, d.Operand); id != nil {
+			return IdentExpr{id}
+		}
 	}
 	fnc := func() Expr {
 		x := g.convertCastExpr(d.CastExpression)
@@ -1174,7 +1301,7 @@ func (g *translator) convertAssignExpr(d *cc.AssignmentExpression) Expr {
 	case cc.AssignmentExpressionOr:
 		op = BinOpBitOr
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 	return g.NewCAssignExpr(
 		x, op, y,
@@ -1184,7 +1311,9 @@ func (g *translator) convertAssignExpr(d *cc.AssignmentExpression) Expr {
 func (g *translator) convertLabelStmt(st *cc.LabeledStatement) []CStmt {
 	switch st.Case {
 	case cc.LabeledStatementLabel: // label:
-		stmts := g.convertStmt(st.Statement)
+		stmts := g.withCover(coverPos(st.Statement), func() []CStmt {
+			return g.convertStmt(st.Statement)
+		})
 		return append([]CStmt{
 			&CLabelStmt{Label: st.Token.Value.String()},
 		}, stmts...)
@@ -1192,18 +1321,32 @@ func (g *translator) convertLabelStmt(st *cc.LabeledStatement) []CStmt {
 		return []CStmt{
 			g.NewCaseStmt(
 				g.convertConstExpr(st.ConstantExpression),
-				g.convertStmt(st.Statement)...,
+				g.withCover(coverPos(st.Statement), func() []CStmt {
+					return g.convertStmt(st.Statement)
+				})...,
+			),
+		}
+	case cc.LabeledStatementRange: // case xxx ... yyy:
+		return []CStmt{
+			g.NewCaseRange(
+				g.convertConstExpr(st.ConstantExpression),
+				g.convertConstExpr(st.ConstantExpression2),
+				g.withCover(coverPos(st.Statement), func() []CStmt {
+					return g.convertStmt(st.Statement)
+				})...,
 			),
 		}
 	case cc.LabeledStatementDefault: // default:
 		return []CStmt{
 			g.NewCaseStmt(
 				nil,
-				g.convertStmt(st.Statement)...,
+				g.withCover(coverPos(st.Statement), func() []CStmt {
+					return g.convertStmt(st.Statement)
+				})...,
 			),
 		}
 	default:
-		panic(st.Case.String())
+		panic(unsupported(st, st.Case))
 	}
 }
 
@@ -1226,7 +1369,7 @@ func (g *translator) convertSelStmt(st *cc.SelectionStatement) []CStmt {
 		return []CStmt{
 			g.NewCIfStmt(
 				g.ToBool(cond),
-				[]CStmt{g.convertBlockStmt(st.Statement)},
+				[]CStmt{g.convertCoverBlockStmt(st.Statement)},
 				nil,
 			),
 		}
@@ -1235,8 +1378,8 @@ func (g *translator) convertSelStmt(st *cc.SelectionStatement) []CStmt {
 		return []CStmt{
 			g.NewCIfStmt(
 				g.ToBool(cond),
-				[]CStmt{g.convertBlockStmt(st.Statement)},
-				g.toElseStmt(g.convertOneStmt(st.Statement2)),
+				[]CStmt{g.convertCoverBlockStmt(st.Statement)},
+				g.toElseStmt(g.convertElseStmt(st.Statement2)),
 			),
 		}
 	case cc.SelectionStatementSwitch: // switch (x)
@@ -1245,14 +1388,31 @@ func (g *translator) convertSelStmt(st *cc.SelectionStatement) []CStmt {
 			[]CStmt{g.convertBlockStmt(st.Statement)},
 		)}
 	default:
-		panic(st.Case.String())
+		panic(unsupported(st, st.Case))
 	}
 }
 
+// convertLoopCond converts a loop condition. Constant conditions, such as "1 == 1", become Go constants,
+// so that infinite loops are recognized.
+func (g *translator) convertLoopCond(d *cc.Expression) Expr {
+	if d == nil {
+		return nil
+	}
+	if d.IsSideEffectsFree && d.Operand != nil {
+		switch v := d.Operand.Value().(type) {
+		case cc.Int64Value:
+			return Bool(v != 0)
+		case cc.Uint64Value:
+			return Bool(v != 0)
+		}
+	}
+	return g.convertExpr(d)
+}
+
 func (g *translator) convertIterStmt(st *cc.IterationStatement) []CStmt {
 	switch st.Case {
 	case cc.IterationStatementWhile:
-		x := g.convertExprOpt(st.Expression)
+		x := g.convertLoopCond(st.Expression)
 		var cond BoolExpr
 		if x != nil {
 			cond = g.ToBool(x)
@@ -1262,18 +1422,18 @@ func (g *translator) convertIterStmt(st *cc.IterationStatement) []CStmt {
 				nil,
 				cond,
 				nil,
-				[]CStmt{g.convertBlockStmt(st.Statement)},
+				[]CStmt{g.convertCoverBlockStmt(st.Statement)},
 			),
 		}
 	case cc.IterationStatementDo:
 		return []CStmt{
 			g.NewCDoWhileStmt(
-				g.convertExprOpt(st.Expression),
-				[]CStmt{g.convertBlockStmt(st.Statement)},
+				g.convertLoopCond(st.Expression),
+				[]CStmt{g.convertCoverBlockStmt(st.Statement)},
 			),
 		}
 	case cc.IterationStatementFor:
-		x := g.convertExprOpt(st.Expression2)
+		x := g.convertLoopCond(st.Expression2)
 		var cond BoolExpr
 		if x != nil {
 			cond = g.ToBool(x)
@@ -1283,36 +1443,12 @@ func (g *translator) convertIterStmt(st *cc.IterationStatement) []CStmt {
 				g.convertExprOpt(st.Expression),
 				cond,
 				g.convertExprOpt(st.Expression3),
-				[]CStmt{g.convertBlockStmt(st.Statement)},
+				[]CStmt{g.convertCoverBlockStmt(st.Statement)},
 			),
 		}
 	case cc.IterationStatementForDecl:
-		var cur *CVarDecl
-		for _, d := range g.convertDecl(st.Declaration) {
-			d := d.(*CVarDecl)
-			if cur == nil {
-				cur = d
-				continue
-			}
-			if !types.Same(cur.Type, d.Type) {
-				panic(fmt.Errorf("different types in a declaration: %v vs %v (%s)", cur.Type, d.Type, st.Position()))
-			}
-			cur.Single = true
-			n1, n2 := len(cur.Names), len(d.Names)
-			cur.Names = append(cur.Names, d.Names...)
-			if len(cur.Inits) == 0 && len(d.Inits) == 0 {
-				continue
-			}
-			if len(cur.Inits) == 0 {
-				cur.Inits = make([]Expr, n1, n1+n2)
-			}
-			if len(d.Inits) == 0 {
-				cur.Inits = append(cur.Inits, make([]Expr, n2)...)
-			} else {
-				cur.Inits = append(cur.Inits, d.Inits...)
-			}
-		}
-		x := g.convertExprOpt(st.Expression)
+		cur := mergeVarDecls(g.convertDecl(st.Declaration), st.Position())
+		x := g.convertLoopCond(st.Expression)
 		var cond BoolExpr
 		if x != nil {
 			cond = g.ToBool(x)
@@ -1322,12 +1458,42 @@ func (g *translator) convertIterStmt(st *cc.IterationStatement) []CStmt {
 				cur,
 				cond,
 				g.convertExprOpt(st.Expression2),
-				[]CStmt{g.convertBlockStmt(st.Statement)},
+				[]CStmt{g.convertCoverBlockStmt(st.Statement)},
 			),
 		}
 	default:
-		panic(st.Case.String() + " " + st.Position().String())
+		panic(unsupported(st, st.Case))
+	}
+}
+
+// mergeVarDecls merges variables declared in the init statement of the for loop into a single declaration.
+func mergeVarDecls(decls []CDecl, where token.Position) *CVarDecl {
+	var cur *CVarDecl
+	for _, d := range decls {
+		d := d.(*CVarDecl)
+		if cur == nil {
+			cur = d
+			continue
+		}
+		if !types.Same(cur.Type, d.Type) {
+			panic(fmt.Errorf("different types in a declaration: %v vs %v (%s)", cur.Type, d.Type, where))
+		}
+		cur.Single = true
+		n1, n2 := len(cur.Names), len(d.Names)
+		cur.Names = append(cur.Names, d.Names...)
+		if len(cur.Inits) == 0 && len(d.Inits) == 0 {
+			continue
+		}
+		if len(cur.Inits) == 0 {
+			cur.Inits = make([]Expr, n1, n1+n2)
+		}
+		if len(d.Inits) == 0 {
+			cur.Inits = append(cur.Inits, make([]Expr, n2)...)
+		} else {
+			cur.Inits = append(cur.Inits, d.Inits...)
+		}
 	}
+	return cur
 }
 
 func (g *translator) convertJumpStmt(st *cc.JumpStatement) []CStmt {
@@ -1350,7 +1516,7 @@ func (g *translator) convertJumpStmt(st *cc.JumpStatement) []CStmt {
 			nil,
 		)
 	default:
-		panic(st.Case.String())
+		panic(unsupported(st, st.Case))
 	}
 }
 
@@ -1360,6 +1526,9 @@ func (g *translator) convertAsmStmt(d *cc.AsmStatement) []CStmt {
 }
 
 func (g *translator) convertStmt(d *cc.Statement) []CStmt {
+	prev := g.pos
+	g.pos = d.Position()
+	defer func() { g.pos = prev }()
 	switch d.Case {
 	case cc.StatementLabeled:
 		return g.convertLabelStmt(d.LabeledStatement)
@@ -1376,7 +1545,7 @@ func (g *translator) convertStmt(d *cc.Statement) []CStmt {
 	case cc.StatementAsm:
 		return g.convertAsmStmt(d.AsmStatement)
 	default:
-		panic(d.Case.String())
+		panic(unsupported(d, d.Case))
 	}
 }
 
@@ -0,0 +1,146 @@
+package cxgo
+
+import (
+	"go/ast"
+	token2 "go/token"
+	"strconv"
+	"strings"
+
+	"modernc.org/cc/v3"
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// coverFile collects lines of blocks with coverage counters for a single C file; see Config.Coverage.
+type coverFile struct {
+	Name  string // Go variable holding the counters
+	File  string
+	Lines []int
+}
+
+// coverVarName returns a name of the Go variable with coverage counters for a C file.
+func coverVarName(file string) string {
+	b := []byte(strings.TrimLeft(file, "./"))
+	for i, c := range b {
+		if !isIdentByte(c) {
+			b[i] = '_'
+		}
+	}
+	return "cxgoCover_" + string(b)
+}
+
+// coverStmt returns a statement incrementing a new coverage counter for the block that starts at a given position.
+func (g *translator) coverStmt(pos token.Position) CStmt {
+	f := g.cover[pos.Filename]
+	if f == nil {
+		f = &coverFile{Name: coverVarName(pos.Filename), File: strings.TrimPrefix(pos.Filename, "./")}
+		if g.cover == nil {
+			g.cover = make(map[string]*coverFile)
+		}
+		g.cover[pos.Filename] = f
+		g.coverOrder = append(g.coverOrder, f)
+	}
+	f.Lines = append(f.Lines, pos.Line)
+	return &CCoverStmt{File: f, Index: len(f.Lines) - 1}
+}
+
+// withCover converts statements of the block with a given function and prepends a coverage counter,
+// if Config.Coverage is set. The counter is allocated first to keep counters in the source order.
+func (g *translator) withCover(pos token.Position, conv func() []CStmt) []CStmt {
+	if !g.conf.Coverage {
+		return conv()
+	}
+	c := g.coverStmt(pos)
+	return append([]CStmt{c}, conv()...)
+}
+
+// coverPos returns the position of the first statement of the block; this is the line that C coverage tools report.
+func coverPos(st *cc.Statement) token.Position {
+	if st.Case == cc.StatementCompound {
+		return coverCompPos(st.CompoundStatement)
+	}
+	return st.Position()
+}
+
+func coverCompPos(d *cc.CompoundStatement) token.Position {
+	for it := d.BlockItemList; it != nil; it = it.BlockItemList {
+		// skip the implicit __func__ declaration at the block start
+		if p := it.BlockItem.Position(); p != d.Position() {
+			return p
+		}
+	}
+	return d.Position()
+}
+
+// convertCoverBlockStmt is the same as convertBlockStmt, but counts executions of the block; see Config.Coverage.
+func (g *translator) convertCoverBlockStmt(d *cc.Statement) *BlockStmt {
+	if !g.conf.Coverage {
+		return g.convertBlockStmt(d)
+	}
+	c := g.coverStmt(coverPos(d))
+	b := g.convertBlockStmt(d)
+	b.Stmts = append([]CStmt{c}, b.Stmts...)
+	return b
+}
+
+// convertElseStmt converts the else branch, counting its executions if Config.Coverage is set.
+// The "else if" chains are preserved, since the condition is counted as a part of the else branch.
+func (g *translator) convertElseStmt(d *cc.Statement) CStmt {
+	if !g.conf.Coverage || (d.Case == cc.StatementSelection && d.SelectionStatement.Case != cc.SelectionStatementSwitch) {
+		return g.convertOneStmt(d)
+	}
+	return g.convertCoverBlockStmt(d)
+}
+
+// coverDecls returns declarations of coverage counters collected for the current file.
+func (g *translator) coverDecls() []CDecl {
+	var decls []CDecl
+	for _, f := range g.coverOrder {
+		decls = append(decls, &CCoverDecl{File: f})
+	}
+	g.cover, g.coverOrder = nil, nil
+	return decls
+}
+
+// CCoverStmt increments a coverage counter.
+type CCoverStmt struct {
+	File  *coverFile
+	Index int
+}
+
+func (s *CCoverStmt) Visit(v Visitor) {}
+
+func (s *CCoverStmt) AsStmt() []GoStmt {
+	hit := &ast.SelectorExpr{X: ident(s.File.Name), Sel: ident("Hit")}
+	return []GoStmt{&ast.ExprStmt{X: call(hit, intLit(s.Index))}}
+}
+
+func (s *CCoverStmt) Uses() []types.Usage {
+	return nil
+}
+
+// CCoverDecl registers coverage counters for a C file in the runtime.
+type CCoverDecl struct {
+	File *coverFile
+}
+
+func (d *CCoverDecl) Visit(v Visitor) {}
+
+func (d *CCoverDecl) AsDecl() []GoDecl {
+	args := []GoExpr{&ast.BasicLit{Kind: token2.STRING, Value: strconv.Quote(d.File.File)}}
+	for _, line := range d.File.Lines {
+		args = append(args, intLit(line))
+	}
+	return []GoDecl{&ast.GenDecl{
+		Tok: token2.VAR,
+		Specs: []ast.Spec{&ast.ValueSpec{
+			Names:  []*ast.Ident{ident(d.File.Name)},
+			Values: []GoExpr{call(ident("ccover.Register"), args...)},
+		}},
+	}}
+}
+
+func (d *CCoverDecl) Uses() []types.Usage {
+	return nil
+}
@@ -0,0 +1,123 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// goDeclNames returns top-level Go names defined by the declaration.
+func goDeclNames(d GoDecl) []string {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil {
+			return nil
+		}
+		return []string{d.Name.Name}
+	case *ast.GenDecl:
+		var names []string
+		for _, s := range d.Specs {
+			switch s := s.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					if name.Name != "_" {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// goDeclRefs returns all identifiers referenced by the declaration, including the ones it defines.
+func goDeclRefs(d ast.Node) map[string]struct{} {
+	refs := make(map[string]struct{})
+	ast.Inspect(d, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			refs[id.Name] = struct{}{}
+		}
+		return true
+	})
+	return refs
+}
+
+// goRecvName returns a type name of the method receiver, if any.
+func goRecvName(d GoDecl) string {
+	fd, ok := d.(*ast.FuncDecl)
+	if !ok || fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return ""
+	}
+	t := fd.Recv.List[0].Type
+	if st, ok := t.(*ast.StarExpr); ok {
+		t = st.X
+	}
+	if id, ok := t.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// filterReachable removes all declarations not reachable from a given set of Go names.
+func filterReachable(decls []GoDecl, roots []string) []GoDecl {
+	keep := reachableDecls(decls, roots)
+	out := make([]GoDecl, 0, len(decls))
+	for i, d := range decls {
+		if keep[i] {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// reachableDecls checks which declarations are reachable from a given set of Go names.
+// Declarations that do not define any names (imports, blank vars) and init functions are always reachable.
+// Methods are reachable if the receiver type is reachable.
+func reachableDecls(decls []GoDecl, roots []string) []bool {
+	byName := make(map[string][]int)
+	for i, d := range decls {
+		for _, name := range goDeclNames(d) {
+			byName[name] = append(byName[name], i)
+		}
+		if recv := goRecvName(d); recv != "" {
+			byName[recv] = append(byName[recv], i)
+		}
+	}
+	keep := make([]bool, len(decls))
+	seen := make(map[string]struct{})
+	queue := append([]string{"init"}, roots...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		for _, i := range byName[name] {
+			if keep[i] {
+				continue
+			}
+			keep[i] = true
+			for ref := range goDeclRefs(decls[i]) {
+				if _, ok := seen[ref]; !ok {
+					queue = append(queue, ref)
+				}
+			}
+		}
+	}
+	for i, d := range decls {
+		if isUnnamedDecl(d) {
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+func isUnnamedDecl(d GoDecl) bool {
+	if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+		return true
+	}
+	return len(goDeclNames(d)) == 0 && goRecvName(d) == ""
+}
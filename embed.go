@@ -0,0 +1,140 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// embedFile is a binary file with an initializer of a byte array; see Config.EmbedArrays.
+type embedFile struct {
+	Name string // file name, relative to the directory of the Go file
+	Data []byte
+}
+
+// embedArray writes the initializer of a large global byte array to a binary file, if Config.EmbedArrays is set,
+// and returns declarations that read the array from the file embedded with go:embed:
+//
+//	//go:embed data.bin
+//	var data_bin []byte
+//	var data [4096]byte = [4096]byte(data_bin)
+//
+// It returns nil if the declaration is not such an array.
+func (g *translator) embedArray(d CDecl) []GoDecl {
+	if g.conf.EmbedArrays <= 0 {
+		return nil
+	}
+	v, ok := d.(*CVarDecl)
+	if !ok || len(v.Names) != 1 || len(v.Inits) != 1 {
+		return nil
+	}
+	at, ok := types.Unwrap(v.Type).(types.ArrayType)
+	if !ok || at.IsSlice() || at.Len() < g.conf.EmbedArrays {
+		return nil
+	}
+	// slices can only be converted to arrays with the same element type, thus named types are not supported
+	if et, ok := at.Elem().(types.IntType); !ok || et.Sizeof() != 1 || et.Signed() {
+		return nil
+	}
+	data, ok := byteArrayData(v.Inits[0], at.Len())
+	if !ok {
+		return nil
+	}
+	name := v.Names[0].GoIdent().Name
+	if strings.HasPrefix(name, "_") {
+		// go:embed ignores such files
+		return nil
+	}
+	file := embedFile{Name: name + ".bin", Data: data}
+	g.embeds = append(g.embeds, file)
+	bin := ident(name + "_bin")
+	return []GoDecl{
+		&ast.GenDecl{
+			Doc: &ast.CommentGroup{List: []*ast.Comment{{Text: "//go:embed " + file.Name}}},
+			Tok: token.VAR,
+			Specs: []ast.Spec{&ast.ValueSpec{
+				Names: []*ast.Ident{bin},
+				Type:  &ast.ArrayType{Elt: ident("byte")},
+			}},
+		},
+		&ast.GenDecl{
+			Tok: token.VAR,
+			Specs: []ast.Spec{&ast.ValueSpec{
+				Names:  []*ast.Ident{v.Names[0].GoIdent()},
+				Type:   at.GoType(),
+				Values: []ast.Expr{call(at.GoType(), bin)},
+			}},
+		},
+	}
+}
+
+// byteArrayData returns bytes of an array initializer, if all elements are constant.
+func byteArrayData(e Expr, n int) ([]byte, bool) {
+	lit, ok := cUnwrap(e).(*CCompLitExpr)
+	if !ok {
+		return nil, false
+	}
+	data := make([]byte, n)
+	i := 0
+	for _, f := range lit.Fields {
+		if f.Field != nil {
+			return nil, false
+		}
+		if f.Index != nil {
+			ind, ok := constIntValue(f.Index)
+			if !ok {
+				return nil, false
+			}
+			i = int(ind)
+		}
+		v, ok := constInitValue(f.Value)
+		if !ok || v < -128 || v > 255 || i < 0 || i >= n {
+			return nil, false
+		}
+		data[i] = byte(v)
+		i++
+	}
+	return data, true
+}
+
+// constInitValue returns the value of a constant integer or character in an initializer.
+func constInitValue(e Expr) (int64, bool) {
+	if l, ok := cUnwrap(e).(*CLiteral); ok && l.Kind == CLitChar {
+		if r := []rune(l.Value); len(r) == 1 {
+			return int64(r[0]), true
+		}
+		return 0, false
+	}
+	return constIntValue(e)
+}
+
+// usesEmbed checks if declarations have go:embed directives, thus the embed package must be imported.
+func usesEmbed(decls []GoDecl) bool {
+	for _, d := range decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Doc != nil {
+			for _, c := range gd.Doc.List {
+				if strings.HasPrefix(c.Text, "//go:embed ") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// writeEmbeds writes binary files for Config.EmbedArrays next to the Go file.
+func writeEmbeds(out, gofile string, files []embedFile, conf Config) error {
+	dir := filepath.Dir(gofile)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(out, dir)
+	}
+	for _, f := range files {
+		if err := conf.output().WriteFile(filepath.Join(dir, f.Name), f.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
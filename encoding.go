@@ -0,0 +1,127 @@
+package cxgo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// charset maps bytes 0x80-0xFF of a single-byte encoding to runes.
+type charset [128]rune
+
+var (
+	latin1Charset = newCharset(nil)
+	cp1252Charset = newCharset(map[byte]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+		0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+		0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+		0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+	})
+)
+
+func newCharset(over map[byte]rune) *charset {
+	var cs charset
+	for i := range cs {
+		cs[i] = rune(0x80 + i)
+	}
+	for b, r := range over {
+		cs[b-0x80] = r
+	}
+	return &cs
+}
+
+// sourceCharset returns a charset for the source encoding. It returns nil for UTF-8.
+func sourceCharset(name string) (*charset, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "_", "-")) {
+	case "", "utf-8", "utf8":
+		return nil, nil
+	case "latin1", "latin-1", "iso-8859-1":
+		return latin1Charset, nil
+	case "windows-1252", "cp1252":
+		return cp1252Charset, nil
+	}
+	return nil, fmt.Errorf("unsupported source encoding: %q", name)
+}
+
+// normalizeSource converts CR line endings to LF. The C parser accepts LF and CRLF, as well as the UTF-8
+// byte order mark, but treats files with CR line endings as a single line. The size of the file is preserved.
+func normalizeSource(data []byte) []byte {
+	i := bytes.IndexByte(data, '\r')
+	if i < 0 {
+		return data
+	}
+	out := append([]byte{}, data...)
+	for ; i < len(out); i++ {
+		if out[i] == '\r' && (i+1 == len(out) || out[i+1] != '\n') {
+			out[i] = '\n'
+		}
+	}
+	return out
+}
+
+// decodeSource converts C source from a single-byte encoding to UTF-8. Files that are already valid UTF-8 are left
+// as is. Non-ASCII characters in char literals are replaced with octal escapes to preserve their values,
+// since a multi-byte character would change the meaning of the literal.
+func decodeSource(data []byte, cs *charset) []byte {
+	if cs == nil || utf8.Valid(data) {
+		return data
+	}
+	out := make([]byte, 0, len(data)+len(data)/8)
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			end := []byte("\n")
+			if data[i+1] == '*' {
+				end = []byte("*/")
+			}
+			j := len(data)
+			if k := bytes.Index(data[i+2:], end); k >= 0 {
+				j = i + 2 + k
+			}
+			out = appendDecoded(out, data[i:j], cs)
+			i = j
+		case c == '"':
+			j := quotedEnd(data, i)
+			out = appendDecoded(out, data[i:j], cs)
+			i = j
+		case c == '\'':
+			j := quotedEnd(data, i)
+			for _, b := range data[i:j] {
+				if b < utf8.RuneSelf {
+					out = append(out, b)
+				} else {
+					out = append(out, fmt.Sprintf("\\%03o", b)...)
+				}
+			}
+			i = j
+		case c >= '0' && c <= '9' && (i == 0 || !isIdentByte(data[i-1])):
+			// pp-number, possibly with C23 digit separators
+			j := i + 1
+			for j < len(data) && (isIdentByte(data[j]) || data[j] == '.' || (data[j] == '\'' && j+1 < len(data) && isHexDigit(data[j+1]))) {
+				j++
+			}
+			out = append(out, data[i:j]...)
+			i = j
+		case c < utf8.RuneSelf:
+			out = append(out, c)
+			i++
+		default:
+			out = utf8.AppendRune(out, cs[c-0x80])
+			i++
+		}
+	}
+	return out
+}
+
+func appendDecoded(out, data []byte, cs *charset) []byte {
+	for _, c := range data {
+		if c < utf8.RuneSelf {
+			out = append(out, c)
+		} else {
+			out = utf8.AppendRune(out, cs[c-0x80])
+		}
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package cxgo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/cc/v3"
+	"modernc.org/token"
+)
+
+// FileErrors is a list of errors for a single C file.
+type FileErrors struct {
+	File string
+	Errs []error
+}
+
+func (e *FileErrors) Unwrap() []error {
+	return e.Errs
+}
+
+func (e *FileErrors) Error() string {
+	if len(e.Errs) == 1 {
+		return e.File + ": " + e.Errs[0].Error()
+	}
+	var buf strings.Builder
+	buf.WriteString(e.File + ":")
+	for _, err := range e.Errs {
+		buf.WriteString("\n\t" + strings.ReplaceAll(err.Error(), "\n", "\n\t"))
+	}
+	return buf.String()
+}
+
+// ErrorList collects errors of translating multiple C files, grouped by file in the order of translation.
+type ErrorList []*FileErrors
+
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, 0, len(l))
+	for _, e := range l {
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+func (l ErrorList) Error() string {
+	var lines []string
+	for _, e := range l {
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Add adds an error for a given file. Parse errors are added separately.
+func (l *ErrorList) Add(file string, err error) {
+	if err == nil {
+		return
+	}
+	errs := flattenErrors(err)
+	for _, e := range *l {
+		if e.File == file {
+			e.Errs = append(e.Errs, errs...)
+			return
+		}
+	}
+	*l = append(*l, &FileErrors{File: file, Errs: errs})
+}
+
+func flattenErrors(err error) []error {
+	if list, ok := err.(parseErrors); ok {
+		return list
+	}
+	return []error{err}
+}
+
+// parseErrors is a list of errors from the C parser.
+type parseErrors []error
+
+func (l parseErrors) Unwrap() []error {
+	return l
+}
+
+func (l parseErrors) Error() string {
+	return errors.Join(l...).Error()
+}
+
+// splitParseErrors splits errors from the C parser, which are reported as a single error, one per line.
+func splitParseErrors(err error) error {
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) == 1 {
+		return fmt.Errorf("%w: %w", ErrParse, err)
+	}
+	errs := make(parseErrors, 0, len(lines))
+	for _, line := range lines {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrParse, line))
+	}
+	return errs
+}
+
+// Len returns the total number of errors.
+func (l ErrorList) Len() int {
+	n := 0
+	for _, e := range l {
+		n += len(e.Errs)
+	}
+	return n
+}
+
+// Err returns the list as an error, or nil if it's empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+var (
+	// ErrParse is returned when the C parser fails.
+	ErrParse = errors.New("parsing failed")
+	// ErrFormat is returned when the generated Go code cannot be formatted, usually because of a bad replacement.
+	ErrFormat = errors.New("error formatting")
+)
+
+// ErrUnsupportedConstruct is returned for C constructs that the translator doesn't support yet.
+type ErrUnsupportedConstruct struct {
+	Node cc.Node        // C AST node of the construct
+	Kind string         // kind of the construct, for example the name of the AST node case
+	Pos  token.Position // position of the construct
+}
+
+func (e *ErrUnsupportedConstruct) Error() string {
+	return fmt.Sprintf("%v: unsupported C construct: %s", e.Pos, e.Kind)
+}
+
+// unsupported returns an error for an unsupported case of the C AST node.
+func unsupported(n cc.Node, kind fmt.Stringer) *ErrUnsupportedConstruct {
+	return &ErrUnsupportedConstruct{Node: n, Kind: kind.String(), Pos: n.Position()}
+}
+
+// ErrConflictingDefinition is returned when translation units of the same project define a type or a macro
+// with the same name differently. Such definitions cannot share the same Go declaration.
+type ErrConflictingDefinition struct {
+	Kind string         // "type" or "macro"
+	Name string         // C name
+	Pos  token.Position // position of the conflicting definition
+	Prev token.Position // position of the first definition
+}
+
+func (e *ErrConflictingDefinition) Error() string {
+	return fmt.Sprintf("%v: %s %s is defined differently than in another file (%v)", e.Pos, e.Kind, e.Name, e.Prev)
+}
@@ -0,0 +1,127 @@
+package cxgo
+
+import "bytes"
+
+// rewriteExternC removes C++ linkage specifications (extern "C") from headers shared with C++.
+//
+// Headers usually guard those with #ifdef __cplusplus, which is never defined by cxgo, but some only check
+// a project-specific macro, or use extern "C" unconditionally. The extern "C" { } blocks are removed, keeping
+// the declarations, and extern "C" before a single declaration becomes extern. Removed code is replaced with
+// spaces to preserve token positions. Strings, char literals and comments are kept intact.
+func rewriteExternC(data []byte) []byte {
+	if !bytes.Contains(data, []byte(`"C`)) {
+		return data
+	}
+	out := append([]byte{}, data...)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+	var (
+		depth  int
+		blocks []int // brace depth of open extern "C" blocks
+	)
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			j := bytes.IndexByte(data[i:], '\n')
+			if j < 0 {
+				j = len(data) - i
+			}
+			i += j
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			j := bytes.Index(data[i+2:], []byte("*/"))
+			if j < 0 {
+				j = len(data) - i - 2
+			} else {
+				j += 2
+			}
+			i += 2 + j
+		case c == '"' || c == '\'':
+			i = quotedEnd(data, i)
+		case isIdentByte(c):
+			j := i
+			for j < len(data) && isIdentByte(data[j]) {
+				j++
+			}
+			if string(data[i:j]) != "extern" {
+				i = j
+				continue
+			}
+			k := skipSpaces(data, j)
+			if k >= len(data) || data[k] != '"' {
+				i = j
+				continue
+			}
+			e := quotedEnd(data, k)
+			if lit := string(data[k:e]); lit != `"C"` && lit != `"C++"` {
+				i = e
+				continue
+			}
+			if b := skipSpaces(data, e); b < len(data) && data[b] == '{' {
+				blank(i, b+1)
+				blocks = append(blocks, depth)
+				i = b + 1
+				continue
+			}
+			blank(k, e)
+			i = e
+		case c == '{':
+			depth++
+			i++
+		case c == '}':
+			if n := len(blocks); n != 0 && blocks[n-1] == depth {
+				blocks = blocks[:n-1]
+				blank(i, i+1)
+			} else {
+				depth--
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	return out
+}
+
+// quotedEnd returns the end of the string or char literal that starts at i.
+func quotedEnd(data []byte, i int) int {
+	q := data[i]
+	j := i + 1
+	for j < len(data) && data[j] != q && data[j] != '\n' {
+		if data[j] == '\\' {
+			j++
+		}
+		j++
+	}
+	if j < len(data) && data[j] == q {
+		j++
+	}
+	if j > len(data) {
+		j = len(data)
+	}
+	return j
+}
+
+// skipSpaces skips whitespace, including newlines and line continuations.
+func skipSpaces(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n', '\f', '\v':
+			i++
+		case '\\':
+			if i+1 < len(data) && (data[i+1] == '\n' || data[i+1] == '\r') {
+				i += 2
+				continue
+			}
+			return i
+		default:
+			return i
+		}
+	}
+	return i
+}
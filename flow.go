@@ -58,35 +58,45 @@ type ControlFlow struct {
 	labels map[string]Block
 	breaks []Block
 	conts  []Block
-	doms   map[Block]BlockSet
+
+	order []Block           // reachable blocks in reverse postorder
+	index map[Block]int     // index of the block in order
+	preds map[Block][]Block // reachable predecessors, one per edge
+	idom  map[Block]Block   // immediate dominators
 }
 
+// eachBlock calls the function for each reachable block in depth-first preorder.
 func (cf *ControlFlow) eachBlock(fnc func(b Block)) {
-	cf.eachBlockSub(cf.Start, fnc, make(BlockSet))
+	if cf.Start == nil {
+		return
+	}
+	seen := make(BlockSet)
+	stack := []Block{cf.Start}
+	for len(stack) > 0 {
+		b := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := seen[b]; ok {
+			continue
+		}
+		seen[b] = struct{}{}
+		fnc(b)
+		next := b.NextBlocks()
+		for i := len(next) - 1; i >= 0; i-- {
+			if next[i] != nil {
+				stack = append(stack, next[i])
+			}
+		}
+	}
 }
 
 func (cf *ControlFlow) allBlocks() BlockSet {
 	m := make(BlockSet)
-	cf.eachBlockSub(cf.Start, nil, m)
+	cf.eachBlock(func(b Block) {
+		m[b] = struct{}{}
+	})
 	return m
 }
 
-func (cf *ControlFlow) eachBlockSub(b Block, fnc func(b Block), seen BlockSet) {
-	if b == nil {
-		return
-	}
-	if _, ok := seen[b]; ok {
-		return
-	}
-	seen[b] = struct{}{}
-	if fnc != nil {
-		fnc(b)
-	}
-	for _, b2 := range b.NextBlocks() {
-		cf.eachBlockSub(b2, fnc, seen)
-	}
-}
-
 func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 	if len(stmts) == 0 {
 		return after, false
@@ -203,7 +213,7 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 
 		b := &SwitchBlock{
 			Expr:   s.Cond,
-			Cases:  make([]Expr, len(s.Cases)),
+			Cases:  make([]*CCaseStmt, len(s.Cases)),
 			Blocks: make([]Block, len(s.Cases)),
 		}
 		bi := len(cf.breaks)
@@ -216,7 +226,7 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 			if c.Expr == nil {
 				hasDef = true
 			}
-			b.Cases[i] = c.Expr
+			b.Cases[i] = c.withStmts(nil)
 			cb, _ := cf.process(c.Stmts, fall)
 			b.Blocks[i] = cb
 			cb.AddPrevBlock(b)
@@ -224,7 +234,7 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 		}
 		cf.breaks = cf.breaks[:bi]
 		if !hasDef {
-			b.Cases = append(b.Cases, nil)
+			b.Cases = append(b.Cases, cf.g.NewCaseStmt(nil))
 			b.Blocks = append(b.Blocks, next)
 			next.AddPrevBlock(b)
 		}
@@ -233,9 +243,14 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 	if after == nil {
 		after = &ReturnBlock{CReturnStmt: &CReturnStmt{}}
 	}
+	// collect all straight-line statements into a single block at once
+	n := 1
+	for n < len(stmts) && isStraightStmt(stmts[n]) {
+		n++
+	}
 	b := &CodeBlock{}
-	b.Stmts = append(b.Stmts, stmts[0])
-	b2, merge := cf.process(stmts[1:], after)
+	b.Stmts = append(b.Stmts, stmts[:n]...)
+	b2, merge := cf.process(stmts[n:], after)
 	if c2, ok := b2.(*CodeBlock); ok && merge {
 		b.Stmts = append(b.Stmts, c2.Stmts...)
 		b.Next = c2.Next
@@ -255,6 +270,16 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 	return b, true
 }
 
+// isStraightStmt checks if the statement does not affect the control flow.
+func isStraightStmt(s CStmt) bool {
+	switch s.(type) {
+	case *BlockStmt, *CReturnStmt, *CLabelStmt, *CGotoStmt, *CContinueStmt, *CBreakStmt,
+		*CIfStmt, *CForStmt, *CCaseStmt, *CSwitchStmt:
+		return false
+	}
+	return true
+}
+
 type BaseBlock struct {
 	prev []Block
 }
@@ -330,7 +355,7 @@ func (b *CondBlock) ReplaceNext(old, rep Block) {
 type SwitchBlock struct {
 	BaseBlock
 	Expr   Expr
-	Cases  []Expr
+	Cases  []*CCaseStmt // case labels without statements; Expr is nil for the default case
 	Blocks []Block
 }
 
@@ -359,8 +384,20 @@ func (cf *ControlFlow) Dom(a, b Block) bool {
 	if a == b {
 		return true
 	}
-	_, ok := cf.doms[b][a]
-	return ok
+	ai, ok := cf.index[a]
+	if !ok {
+		return false
+	}
+	// dominators always come earlier in reverse postorder
+	for d := cf.idom[b]; d != nil; d = cf.idom[d] {
+		if d == a {
+			return true
+		}
+		if cf.index[d] < ai {
+			return false
+		}
+	}
+	return false
 }
 
 func (cf *ControlFlow) SDom(a, b Block) bool {
@@ -372,33 +409,7 @@ func (cf *ControlFlow) SDom(a, b Block) bool {
 }
 
 func (cf *ControlFlow) IDom(a Block) Block {
-	var doms []Block
-	for d := range cf.doms[a] {
-		if d != a {
-			doms = append(doms, d)
-		}
-	}
-	for len(doms) > 1 {
-	loop:
-		for i := 0; i < len(doms); i++ {
-			d1 := doms[i]
-			for j := 0; j < len(doms); j++ {
-				if i == j {
-					continue
-				}
-				d2 := doms[j]
-				if _, ok := cf.doms[d2][d1]; ok {
-					doms = append(doms[:i], doms[i+1:]...)
-					i--
-					continue loop
-				}
-			}
-		}
-	}
-	if len(doms) == 1 {
-		return doms[0]
-	}
-	return nil
+	return cf.idom[a]
 }
 
 type BlockSet map[Block]struct{}
@@ -460,51 +471,103 @@ func (b BlockSet) Contains(b2 BlockSet) bool {
 	return true
 }
 
+// buildDoms computes immediate dominators for all reachable blocks.
+//
+// It uses the algorithm by Cooper, Harvey and Kennedy ("A Simple, Fast Dominance Algorithm"),
+// which converges in a few linear passes on graphs produced from structured code.
 func (cf *ControlFlow) buildDoms() {
-	// TODO: quadratic complexity! use a different algorithm
-	blocks := cf.allBlocks()
-	cf.doms = make(map[Block]BlockSet, len(blocks))
-	for b := range blocks {
-		if b == cf.Start {
-			cf.doms[b] = BlockSet{b: {}}
-		} else {
-			cf.doms[b] = blocks
+	cf.order = nil
+	cf.index = make(map[Block]int)
+	cf.preds = make(map[Block][]Block)
+	cf.idom = make(map[Block]Block)
+	if cf.Start == nil {
+		return
+	}
+	cf.order = cf.reversePostorder()
+	for i, b := range cf.order {
+		cf.index[b] = i
+	}
+	for _, b := range cf.order {
+		for _, n := range b.NextBlocks() {
+			if n != nil {
+				cf.preds[n] = append(cf.preds[n], b)
+			}
 		}
 	}
-
-	changes := true
-	for changes {
-		changes = false
-		for b := range blocks {
-			d := cf.doms[b]
-			if len(d) == 1 {
-				continue
+	idom := make([]int, len(cf.order))
+	for i := range idom {
+		idom[i] = -1
+	}
+	idom[0] = 0
+	intersect := func(a, b int) int {
+		for a != b {
+			for a > b {
+				a = idom[a]
 			}
-			var m BlockSet
-			for _, b2 := range b.PrevBlocks() {
-				if b == b2 {
-					continue
-				}
-				d2 := cf.doms[b2]
-				if m == nil {
-					m = d2
+			for b > a {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changes := true; changes; {
+		changes = false
+		for i := 1; i < len(cf.order); i++ {
+			d := -1
+			for _, p := range cf.preds[cf.order[i]] {
+				pi := cf.index[p]
+				if idom[pi] < 0 {
 					continue
 				}
-				m = m.Intersect(d2)
-				if len(m) == 0 {
-					break
+				if d < 0 {
+					d = pi
+				} else {
+					d = intersect(pi, d)
 				}
 			}
-			m = m.Union(BlockSet{b: {}})
-			if len(d) != len(m) {
+			if idom[i] != d {
+				idom[i] = d
 				changes = true
-				cf.doms[b] = m
 			}
-			if len(d) < len(m) {
-				panic(fmt.Errorf("set size increased: %p: %v vs %v", b, d, m))
+		}
+	}
+	for i := 1; i < len(cf.order); i++ {
+		cf.idom[cf.order[i]] = cf.order[idom[i]]
+	}
+}
+
+// reversePostorder returns all blocks reachable from the start, in reverse postorder.
+func (cf *ControlFlow) reversePostorder() []Block {
+	type frame struct {
+		b    Block
+		next []Block
+		i    int
+	}
+	seen := BlockSet{cf.Start: {}}
+	stack := []frame{{b: cf.Start, next: cf.Start.NextBlocks()}}
+	var post []Block
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.i < len(top.next) {
+			n := top.next[top.i]
+			top.i++
+			if n == nil {
+				continue
+			}
+			if _, ok := seen[n]; ok {
+				continue
 			}
+			seen[n] = struct{}{}
+			stack = append(stack, frame{b: n, next: n.NextBlocks()})
+			continue
 		}
+		post = append(post, top.b)
+		stack = stack[:len(stack)-1]
+	}
+	for i, j := 0, len(post)-1; i < j; i, j = i+1, j-1 {
+		post[i], post[j] = post[j], post[i]
 	}
+	return post
 }
 
 type varDecls struct {
@@ -521,7 +584,7 @@ func (cf *ControlFlow) Flatten() []CStmt {
 		labels[b] = len(labels) + 1
 	})
 	var decls varDecls
-	stmts := cf.flatten(cf.Start, &decls, nil, labels, make(map[Block]struct{}))
+	stmts := cf.flatten(cf.Start, &decls, labels)
 	var out []CStmt
 	if len(decls.Decls) != 0 {
 		for _, d := range decls.Decls {
@@ -581,76 +644,69 @@ func (cf *ControlFlow) slitDecls(decl *varDecls, stmts []CStmt) []CStmt {
 	return out
 }
 
-func (cf *ControlFlow) flatten(b Block, decl *varDecls, stmts []CStmt, labels map[Block]int, seen map[Block]struct{}) []CStmt {
-	if b == nil {
-		return stmts
-	}
-	if _, ok := seen[b]; ok {
-		l, ok := labels[b]
-		if !ok {
-			panic(fmt.Errorf("must have a label: %T, %v", b, len(b.PrevBlocks())))
-		}
-		stmts = append(stmts, numGoto(l))
-		return stmts
-	}
-	seen[b] = struct{}{}
-	if id, ok := labels[b]; ok {
-		stmts = append(stmts, numLabel(id))
-	}
-	switch b := b.(type) {
-	case *CodeBlock:
-		cur := cf.slitDecls(decl, b.Stmts)
-		stmts = append(stmts, cur...)
-		if l, ok := labels[b.Next]; ok {
-			stmts = append(stmts, numGoto(l))
-			if _, ok := seen[b.Next]; ok {
-				return stmts
-			}
-		}
-		stmts = cf.flatten(b.Next, decl, stmts, labels, seen)
-	case *CondBlock:
-		then, ok := labels[b.Then]
-		if !ok {
-			panic("must have a label")
-		}
-		els, ok := labels[b.Else]
-		if !ok {
-			panic("must have a label")
-		}
-		stmts = append(stmts, &CIfStmt{
-			Cond: cf.g.ToBool(b.Expr),
-			Then: cf.g.NewCBlock(numGoto(then)),
-			Else: cf.g.NewCBlock(numGoto(els)),
-		})
-		if _, ok := seen[b.Then]; !ok {
-			stmts = cf.flatten(b.Then, decl, stmts, labels, seen)
+// flatten emits blocks in depth-first order, starting from a given one.
+// Each block is emitted once, and blocks with predecessors are reached by gotos.
+func (cf *ControlFlow) flatten(start Block, decl *varDecls, labels map[Block]int) []CStmt {
+	var stmts []CStmt
+	seen := make(BlockSet)
+	stack := []Block{start}
+	for len(stack) > 0 {
+		b := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if b == nil {
+			continue
 		}
-		if _, ok := seen[b.Else]; !ok {
-			stmts = cf.flatten(b.Else, decl, stmts, labels, seen)
+		if _, ok := seen[b]; ok {
+			continue
 		}
-	case *ReturnBlock:
-		stmts = append(stmts, b.CReturnStmt)
-	case *SwitchBlock:
-		s := &CSwitchStmt{
-			Cond: b.Expr,
+		seen[b] = struct{}{}
+		if id, ok := labels[b]; ok {
+			stmts = append(stmts, numLabel(id))
 		}
-		for i, e := range b.Cases {
-			l, ok := labels[b.Blocks[i]]
+		switch b := b.(type) {
+		case *CodeBlock:
+			cur := cf.slitDecls(decl, b.Stmts)
+			stmts = append(stmts, cur...)
+			if l, ok := labels[b.Next]; ok {
+				stmts = append(stmts, numGoto(l))
+			}
+			stack = append(stack, b.Next)
+		case *CondBlock:
+			then, ok := labels[b.Then]
+			if !ok {
+				panic("must have a label")
+			}
+			els, ok := labels[b.Else]
 			if !ok {
 				panic("must have a label")
 			}
-			s.Cases = append(s.Cases, cf.g.NewCaseStmt(
-				e, numGoto(l),
-			))
-		}
-		stmts = append(stmts, s)
-		for _, b := range b.Blocks {
-			if _, ok := seen[b]; !ok {
-				stmts = cf.flatten(b, decl, stmts, labels, seen)
+			stmts = append(stmts, &CIfStmt{
+				Cond: cf.g.ToBool(b.Expr),
+				Then: cf.g.NewCBlock(numGoto(then)),
+				Else: cf.g.NewCBlock(numGoto(els)),
+			})
+			stack = append(stack, b.Else, b.Then)
+		case *ReturnBlock:
+			stmts = append(stmts, b.CReturnStmt)
+		case *SwitchBlock:
+			s := &CSwitchStmt{
+				g:    cf.g,
+				Cond: b.Expr,
+			}
+			for i, c := range b.Cases {
+				l, ok := labels[b.Blocks[i]]
+				if !ok {
+					panic("must have a label")
+				}
+				s.Cases = append(s.Cases, c.withStmts([]CStmt{numGoto(l)}))
+			}
+			stmts = append(stmts, s)
+			for i := len(b.Blocks) - 1; i >= 0; i-- {
+				stack = append(stack, b.Blocks[i])
 			}
+		default:
+			panic(b)
 		}
-	default:
-		panic(b)
 	}
 	return stmts
 }
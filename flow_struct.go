@@ -0,0 +1,497 @@
+package cxgo
+
+import "sort"
+
+// Structure reconstructs structured control flow (loops, ifs and switches) from the control flow graph.
+//
+// The algorithm follows "Beyond Relooper" by N. Ramsey: code for each block is emitted in the scope of
+// its immediate dominator, back edges become loop continues, and blocks with multiple predecessors are
+// emitted once after a label and reached with forward jumps. Those jumps are later simplified to breaks
+// or removed completely, if the execution will reach the label anyway.
+//
+// It returns false if the graph is irreducible. In this case the caller should use Flatten instead.
+func (cf *ControlFlow) Structure() ([]CStmt, bool) {
+	if cf.Start == nil {
+		return nil, true
+	}
+	s := cf.newStructFlow()
+	if !s.reducible() {
+		return nil, false
+	}
+	s.buildLoops()
+	s.buildFollows()
+	stmts := s.tree(cf.Start, false)
+	stmts = s.simplify(stmts)
+	var out []CStmt
+	for _, d := range s.decls.Decls {
+		out = append(out, &CDeclStmt{Decl: d})
+	}
+	out = append(out, stmts...)
+	return out, true
+}
+
+type structFlow struct {
+	cf      *ControlFlow
+	loops   map[Block]BlockSet // natural loop bodies, by header
+	inner   map[Block]Block    // innermost loop header for each block
+	outer   map[Block]Block    // header of the enclosing loop, by loop header
+	follows map[Block][]Block  // blocks emitted after a label in the scope of a given block
+	follow  BlockSet           // blocks that are reached by jumps instead of being inlined
+	decls   varDecls
+}
+
+func (cf *ControlFlow) newStructFlow() *structFlow {
+	return &structFlow{
+		cf:      cf,
+		loops:   make(map[Block]BlockSet),
+		inner:   make(map[Block]Block),
+		outer:   make(map[Block]Block),
+		follows: make(map[Block][]Block),
+		follow:  make(BlockSet),
+	}
+}
+
+func (s *structFlow) isBackEdge(from, to Block) bool {
+	return s.cf.index[to] <= s.cf.index[from]
+}
+
+// reducible checks that each back edge points to a block that dominates the edge source.
+func (s *structFlow) reducible() bool {
+	for _, b := range s.cf.order {
+		for _, n := range b.NextBlocks() {
+			if n != nil && s.isBackEdge(b, n) && !s.cf.Dom(n, b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// buildLoops finds natural loops for each loop header.
+//
+// Headers of outer loops dominate headers of inner loops, thus outer loops are processed first
+// and the innermost loop of each block is the last one that includes it.
+func (s *structFlow) buildLoops() {
+	for _, h := range s.cf.order {
+		var stack []Block
+		for _, p := range s.cf.preds[h] {
+			if s.isBackEdge(p, h) {
+				stack = append(stack, p)
+			}
+		}
+		if len(stack) == 0 {
+			continue
+		}
+		if o, ok := s.inner[h]; ok {
+			s.outer[h] = o
+		}
+		body := BlockSet{h: {}}
+		for len(stack) > 0 {
+			b := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if _, ok := body[b]; ok {
+				continue
+			}
+			body[b] = struct{}{}
+			stack = append(stack, s.cf.preds[b]...)
+		}
+		for b := range body {
+			s.inner[b] = h
+		}
+		s.loops[h] = body
+	}
+}
+
+// buildFollows decides which blocks must be emitted after a label, and in which scope.
+//
+// Blocks with more than one incoming forward edge cannot be inlined into the predecessor. The same applies to
+// loop exits: those are moved out of the outermost loop they exit, instead of being inlined into the loop body.
+func (s *structFlow) buildFollows() {
+	for _, b := range s.cf.order {
+		p := s.cf.idom[b]
+		if p == nil {
+			continue
+		}
+		forward := 0
+		for _, pr := range s.cf.preds[b] {
+			if !s.isBackEdge(pr, b) {
+				forward++
+			}
+		}
+		// find the outermost loop that contains the dominator, but not the block itself
+		parent := p
+		h, ok := s.inner[p]
+		for ok {
+			if _, in := s.loops[h][b]; in {
+				break
+			}
+			parent = h
+			h, ok = s.outer[h]
+		}
+		if forward < 2 && parent == p {
+			continue
+		}
+		s.follow[b] = struct{}{}
+		s.follows[parent] = append(s.follows[parent], b)
+	}
+	for _, list := range s.follows {
+		sort.Slice(list, func(i, j int) bool {
+			return s.cf.index[list[i]] < s.cf.index[list[j]]
+		})
+	}
+}
+
+func (s *structFlow) label(b Block) string {
+	return numLabelName(s.cf.index[b] + 1)
+}
+
+// tree emits code for the block and all blocks it dominates.
+func (s *structFlow) tree(b Block, labeled bool) []CStmt {
+	body := s.node(b)
+	loop, isLoop := s.loops[b]
+	var after []CStmt
+	for _, f := range s.follows[b] {
+		code := []CStmt{&CLabelStmt{Label: s.label(f)}}
+		code = append(code, s.tree(f, true)...)
+		if _, in := loop[f]; isLoop && !in {
+			after = append(after, code...)
+		} else {
+			body = append(body, code...)
+		}
+	}
+	if !isLoop {
+		return body
+	}
+	var out []CStmt
+	if !labeled {
+		out = append(out, &CLabelStmt{Label: s.label(b)})
+	}
+	out = append(out, &CForStmt{Body: BlockStmt{Stmts: body}})
+	out = append(out, after...)
+	return out
+}
+
+// node emits code for a single block, including jumps to the following blocks.
+func (s *structFlow) node(b Block) []CStmt {
+	g := s.cf.g
+	switch b := b.(type) {
+	case *CodeBlock:
+		out := s.hoistDecls(b.Stmts)
+		if b.Next != nil {
+			out = append(out, s.branch(b, b.Next)...)
+		}
+		return out
+	case *CondBlock:
+		return []CStmt{&CIfStmt{
+			Cond: g.ToBool(b.Expr),
+			Then: g.NewCBlock(s.branch(b, b.Then)...),
+			Else: g.NewCBlock(s.branch(b, b.Else)...),
+		}}
+	case *ReturnBlock:
+		return []CStmt{b.CReturnStmt}
+	case *SwitchBlock:
+		sw := &CSwitchStmt{g: g, Cond: b.Expr}
+		for i, c := range b.Cases {
+			var body []CStmt
+			// cases jumping to the same block are merged, instead of duplicating the jump
+			if i+1 >= len(b.Cases) || b.Blocks[i] != b.Blocks[i+1] || c.Expr == nil || b.Cases[i+1].Expr == nil {
+				body = s.branch(b, b.Blocks[i])
+			}
+			sw.Cases = append(sw.Cases, c.withStmts(body))
+		}
+		return []CStmt{sw}
+	default:
+		panic(b)
+	}
+}
+
+// branch emits a jump from one block to another.
+func (s *structFlow) branch(from, to Block) []CStmt {
+	if s.isBackEdge(from, to) {
+		return []CStmt{&CContinueStmt{Label: s.label(to)}}
+	}
+	if _, ok := s.follow[to]; ok {
+		return []CStmt{&CGotoStmt{Label: s.label(to)}}
+	}
+	return s.tree(to, false)
+}
+
+// hoistDecls moves all variable declarations to the function scope, so that forward jumps are valid in Go.
+func (s *structFlow) hoistDecls(stmts []CStmt) []CStmt {
+	var out []CStmt
+	for _, st := range stmts {
+		if ds, ok := st.(*CDeclStmt); ok {
+			if d, ok := ds.Decl.(*CVarDecl); ok && !d.Const && len(d.Inits) == 0 && d.Names[0].Name != "__func__" {
+				s.decls.Decls = append(s.decls.Decls, d)
+				continue
+			}
+		}
+		out = append(out, s.cf.slitDecls(&s.decls, []CStmt{st})...)
+	}
+	return out
+}
+
+// fallContinue is a pseudo-label for the end of the loop body, where execution continues with the next iteration.
+func fallContinue(label string) string {
+	return "\x00" + label
+}
+
+// structScope is a loop or a switch statement that can be exited with a break.
+type structScope struct {
+	loop  bool   // loop or switch
+	label string // loop label
+	after string // label that directly follows the statement
+}
+
+// simplify replaces gotos with breaks and continues where possible, and removes redundant jumps and labels.
+func (s *structFlow) simplify(stmts []CStmt) []CStmt {
+	for {
+		n := 0
+		stmts = s.simplifyJumps(stmts, "", nil, &n)
+		used := make(map[string]struct{})
+		collectJumpLabels(stmts, used)
+		stmts = removeUnusedLabels(stmts, used, &n)
+		if n == 0 {
+			break
+		}
+	}
+	return cleanupStructured(s.cf.g, stmts)
+}
+
+func (s *structFlow) simplifyJumps(stmts []CStmt, fall string, scopes []structScope, changes *int) []CStmt {
+	out := make([]CStmt, 0, len(stmts))
+	for i, st := range stmts {
+		next := fall
+		if i+1 < len(stmts) {
+			next = ""
+			if l, ok := stmts[i+1].(*CLabelStmt); ok {
+				next = l.Label
+			}
+		}
+		switch st := st.(type) {
+		case *CGotoStmt:
+			if st.Label == next {
+				*changes++
+				continue
+			}
+			if rep := breakFor(st.Label, scopes); rep != nil {
+				*changes++
+				out = append(out, rep)
+				continue
+			}
+		case *CContinueStmt:
+			if st.Label == "" {
+				break
+			}
+			if fallContinue(st.Label) == next {
+				*changes++
+				continue
+			}
+			if l := innermostLoop(scopes); l != nil && l.label == st.Label {
+				*changes++
+				out = append(out, &CContinueStmt{})
+				continue
+			}
+		case *BlockStmt:
+			st.Stmts = s.simplifyJumps(st.Stmts, next, scopes, changes)
+		case *CIfStmt:
+			st.Then.Stmts = s.simplifyJumps(st.Then.Stmts, next, scopes, changes)
+			switch e := st.Else.(type) {
+			case *BlockStmt:
+				e.Stmts = s.simplifyJumps(e.Stmts, next, scopes, changes)
+			case *CIfStmt:
+				s.simplifyJumps([]CStmt{e}, next, scopes, changes)
+			}
+		case *CSwitchStmt:
+			// cases fall through in C, thus the end of the case is not the end of the statement
+			sub := append(scopes[:len(scopes):len(scopes)], structScope{after: next})
+			for _, c := range st.Cases {
+				c.Stmts = s.simplifyJumps(c.Stmts, "", sub, changes)
+			}
+		case *CForStmt:
+			label := ""
+			if len(out) != 0 {
+				if l, ok := out[len(out)-1].(*CLabelStmt); ok {
+					label = l.Label
+				}
+			}
+			sub := append(scopes[:len(scopes):len(scopes)], structScope{loop: true, label: label, after: next})
+			st.Body.Stmts = s.simplifyJumps(st.Body.Stmts, fallContinue(label), sub, changes)
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+func innermostLoop(scopes []structScope) *structScope {
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if scopes[i].loop {
+			return &scopes[i]
+		}
+	}
+	return nil
+}
+
+// breakFor returns a break statement that is equivalent to a goto to a given label, if possible.
+func breakFor(label string, scopes []structScope) CStmt {
+	if label == "" {
+		return nil
+	}
+	for i := len(scopes) - 1; i >= 0; i-- {
+		sc := scopes[i]
+		if sc.after != label {
+			continue
+		}
+		if i == len(scopes)-1 {
+			return &CBreakStmt{}
+		}
+		if sc.label == "" {
+			return nil
+		}
+		return &CBreakStmt{Label: sc.label}
+	}
+	return nil
+}
+
+func collectJumpLabels(stmts []CStmt, used map[string]struct{}) {
+	for _, st := range stmts {
+		switch st := st.(type) {
+		case *CGotoStmt:
+			used[st.Label] = struct{}{}
+		case *CBreakStmt:
+			if st.Label != "" {
+				used[st.Label] = struct{}{}
+			}
+		case *CContinueStmt:
+			if st.Label != "" {
+				used[st.Label] = struct{}{}
+			}
+		case *BlockStmt:
+			collectJumpLabels(st.Stmts, used)
+		case *CIfStmt:
+			collectJumpLabels(st.Then.Stmts, used)
+			if st.Else != nil {
+				collectJumpLabels([]CStmt{st.Else}, used)
+			}
+		case *CSwitchStmt:
+			for _, c := range st.Cases {
+				collectJumpLabels(c.Stmts, used)
+			}
+		case *CForStmt:
+			collectJumpLabels(st.Body.Stmts, used)
+		}
+	}
+}
+
+func removeUnusedLabels(stmts []CStmt, used map[string]struct{}, changes *int) []CStmt {
+	out := stmts[:0]
+	for _, st := range stmts {
+		switch st := st.(type) {
+		case *CLabelStmt:
+			if _, ok := used[st.Label]; !ok {
+				*changes++
+				continue
+			}
+		case *BlockStmt:
+			st.Stmts = removeUnusedLabels(st.Stmts, used, changes)
+		case *CIfStmt:
+			st.Then.Stmts = removeUnusedLabels(st.Then.Stmts, used, changes)
+			if st.Else != nil {
+				removeUnusedLabels([]CStmt{st.Else}, used, changes)
+			}
+		case *CSwitchStmt:
+			for _, c := range st.Cases {
+				c.Stmts = removeUnusedLabels(c.Stmts, used, changes)
+			}
+		case *CForStmt:
+			st.Body.Stmts = removeUnusedLabels(st.Body.Stmts, used, changes)
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// cleanupStructured makes the reconstructed code more idiomatic:
+// removes else branches after jumps, inverts if statements with an empty body,
+// converts leading conditional breaks to loop conditions and restores fallthrough in switches.
+func cleanupStructured(g *translator, stmts []CStmt) []CStmt {
+	out := make([]CStmt, 0, len(stmts))
+	for _, st := range stmts {
+		switch st := st.(type) {
+		case *BlockStmt:
+			st.Stmts = cleanupStructured(g, st.Stmts)
+		case *CIfStmt:
+			st.Then.Stmts = cleanupStructured(g, st.Then.Stmts)
+			if e, ok := st.Else.(*BlockStmt); ok {
+				e.Stmts = cleanupStructured(g, e.Stmts)
+				if len(e.Stmts) == 0 {
+					st.Else = nil
+				}
+			} else if e, ok := st.Else.(*CIfStmt); ok {
+				cleanupStructured(g, []CStmt{e})
+			}
+			if len(st.Then.Stmts) == 0 && st.Else != nil {
+				st.Cond = g.cNot(st.Cond)
+				st.Then = g.NewCBlock(st.Else)
+				st.Else = nil
+			}
+			if n := len(st.Then.Stmts); n != 0 && st.Else != nil && g.isJump(st.Then.Stmts[n-1]) {
+				els := st.Else
+				st.Else = nil
+				out = append(out, st)
+				if b, ok := els.(*BlockStmt); ok {
+					out = append(out, b.Stmts...)
+				} else {
+					out = append(out, els)
+				}
+				continue
+			}
+		case *CSwitchStmt:
+			for _, c := range st.Cases {
+				c.Stmts = cleanupStructured(g, c.Stmts)
+			}
+			switchFallthrough(st)
+		case *CForStmt:
+			st.Body.Stmts = cleanupStructured(g, st.Body.Stmts)
+			if st.Cond == nil && len(st.Body.Stmts) != 0 {
+				if ifs, ok := st.Body.Stmts[0].(*CIfStmt); ok && ifs.Else == nil && len(ifs.Then.Stmts) == 1 {
+					if br, ok := ifs.Then.Stmts[0].(*CBreakStmt); ok && br.Label == "" {
+						st.Cond = g.cNot(ifs.Cond)
+						st.Body.Stmts = st.Body.Stmts[1:]
+					}
+				}
+			}
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// switchFallthrough replaces a jump at the end of a case with a fallthrough, if the next case only has the same jump.
+func switchFallthrough(sw *CSwitchStmt) {
+	for i := 0; i+1 < len(sw.Cases); i++ {
+		c, next := sw.Cases[i], sw.Cases[i+1]
+		if len(c.Stmts) < 2 || len(next.Stmts) != 1 {
+			continue
+		}
+		if sameJump(c.Stmts[len(c.Stmts)-1], next.Stmts[0]) {
+			c.Stmts = c.Stmts[:len(c.Stmts)-1]
+		}
+	}
+}
+
+// sameJump checks if both statements are gotos, or labeled breaks or continues, with the same label.
+func sameJump(a, b CStmt) bool {
+	switch a := a.(type) {
+	case *CGotoStmt:
+		b, ok := b.(*CGotoStmt)
+		return ok && a.Label == b.Label
+	case *CBreakStmt:
+		b, ok := b.(*CBreakStmt)
+		return ok && a.Label != "" && a.Label == b.Label
+	case *CContinueStmt:
+		b, ok := b.(*CContinueStmt)
+		return ok && a.Label != "" && a.Label == b.Label
+	}
+	return false
+}
@@ -1271,3 +1271,255 @@ func TestControlFlow(t *testing.T) {
 		})
 	}
 }
+
+var casesTranslateFlatten = []parseCase{
+	{
+		name: "flatten loop",
+		src: `
+int foo(int n) {
+	int s = 0;
+	for (int i = 0; i < n; i++) {
+		if (i == 3) continue;
+		if (i == 7) break;
+		s += i;
+	}
+	return s;
+}
+`,
+		exp: `
+func foo(n int32) int32 {
+	var (
+		s int32
+		i int32
+	)
+	s = 0
+	i = 0
+	for i < n {
+		if i != 3 {
+			if i == 7 {
+				break
+			}
+			s += i
+		}
+		i++
+	}
+	return s
+}
+`,
+		configFuncs: []configFunc{withFlatten("foo")},
+	},
+	{
+		name: "flatten nested goto",
+		src: `
+int foo(int n) {
+	int r = 0;
+	if (n < 0) goto fail;
+	for (int i = 0; i < n; i++) {
+		for (int j = 0; j < n; j++) {
+			if (i*j > 50) goto done;
+			r += j;
+		}
+	}
+done:
+	return r;
+fail:
+	return -1;
+}
+`,
+		exp: `
+func foo(n int32) int32 {
+	var (
+		r int32
+		i int32
+		j int32
+	)
+	r = 0
+	if n < 0 {
+		return -1
+	}
+	i = 0
+L_4:
+	for i < n {
+		j = 0
+		for {
+			if j >= n {
+				i++
+				continue L_4
+			}
+			if i*j > 50 {
+				break L_4
+			}
+			r += j
+			j++
+		}
+	}
+	return r
+}
+`,
+		configFuncs: []configFunc{withFlatten("foo")},
+	},
+	{
+		name: "flatten switch",
+		src: `
+int foo(int n) {
+	int r = 0;
+	switch (n) {
+	case 1:
+		r = 1;
+	case 2:
+		r += 2;
+		break;
+	default:
+		return -1;
+	}
+	return r;
+}
+`,
+		exp: `
+func foo(n int32) int32 {
+	var r int32
+	r = 0
+	switch n {
+	case 1:
+		r = 1
+	case 2:
+	default:
+		return -1
+	}
+	r += 2
+	return r
+}
+`,
+		configFuncs: []configFunc{withFlatten("foo")},
+	},
+	{
+		name: "flatten switch fallthrough",
+		src: `
+void a(); void b();
+int foo(int n) {
+	int r = 0;
+again:
+	switch (n) {
+	case 1:
+	case 2:
+		n--;
+		goto again;
+	case 3:
+		a();
+	case 4:
+		r += 2;
+		b();
+		break;
+	case 5:
+		r = 1;
+		goto out;
+	default:
+		return -1;
+	}
+	r++;
+out:
+	return r;
+}
+`,
+		exp: `
+func a()
+func b()
+func foo(n int32) int32 {
+	var r int32
+	r = 0
+L_2:
+	for {
+		switch n {
+		case 1, 2:
+		case 3:
+			a()
+			fallthrough
+		case 4:
+			break L_2
+		case 5:
+			r = 1
+			goto L_8
+		default:
+			return -1
+		}
+		n--
+	}
+	r += 2
+	b()
+	r++
+L_8:
+	return r
+}
+`,
+		configFuncs: []configFunc{withFlatten("foo")},
+	},
+	{
+		name: "flatten irreducible",
+		src: `
+int foo(int n) {
+	if (n) goto b;
+a:
+	n++;
+b:
+	n += 2;
+	if (n < 10) goto a;
+	return n;
+}
+`,
+		exp: `
+func foo(n int32) int32 {
+	goto L_1
+L_1:
+	if n != 0 {
+		goto L_2
+	} else {
+		goto L_4
+	}
+L_2:
+	n += 2
+	goto L_3
+L_3:
+	if n < 10 {
+		goto L_4
+	} else {
+		goto L_5
+	}
+L_4:
+	n++
+	goto L_2
+L_5:
+	return n
+}
+`,
+		configFuncs: []configFunc{withFlatten("foo")},
+	},
+}
+
+func TestTranslateFlatten(t *testing.T) {
+	runTestTranslate(t, casesTranslateFlatten)
+}
+
+func TestControlFlowLarge(t *testing.T) {
+	const n = 5000
+	tr := newTranslator(libs.NewEnv(types.Config32()), Config{})
+	var stmts []CStmt
+	for i := 0; i < n; i++ {
+		stmts = append(stmts,
+			&CLabelStmt{Label: fmt.Sprintf("l%d", i)},
+			numStmt(i),
+			&CIfStmt{Cond: numCond(i), Then: newBlock(&CGotoStmt{Label: "end"})},
+			&CForStmt{Cond: numCond(i), Body: *newBlock(
+				numStmt(i),
+				&CIfStmt{Cond: numCond(i), Then: newBlock(&CBreakStmt{})},
+			)},
+		)
+	}
+	stmts = append(stmts, &CLabelStmt{Label: "end"}, ret(0))
+
+	cf := tr.NewControlFlow(stmts)
+	flat := cf.Flatten()
+	require.NotEmpty(t, flat)
+	st, ok := cf.Structure()
+	require.True(t, ok)
+	require.NotEmpty(t, st)
+}
@@ -76,10 +76,12 @@ func (cf *ControlFlow) dumpBlockDot(g *dot.Graph, b Block, seen map[Block]dot.No
 	case *SwitchBlock:
 		for i, c := range b.Blocks {
 			e := g.Edge(n, cf.dumpBlockDot(g, c, seen))
-			if v := b.Cases[i]; v == nil {
+			if v := b.Cases[i]; v.Expr == nil {
 				e.Attr("color", "#aa0000")
+			} else if v.To != nil {
+				e.Label(printExpr(v.Expr) + " ... " + printExpr(v.To))
 			} else {
-				e.Label(printExpr(v))
+				e.Label(printExpr(v.Expr))
 			}
 		}
 	case *ReturnBlock:
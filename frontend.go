@@ -0,0 +1,83 @@
+package cxgo
+
+import (
+	"fmt"
+
+	"modernc.org/cc/v3"
+)
+
+// Frontend selects the parser used to read C files.
+type Frontend string
+
+const (
+	// FrontendCC uses the built-in C parser (modernc.org/cc). This is the default, and can also be set as "cc".
+	FrontendCC = Frontend("")
+	// FrontendClang runs clang to dump the AST of each file as JSON, and converts it instead; see TranslateClangAST.
+	// It is useful for code that the built-in parser cannot handle, but macros are not translated.
+	FrontendClang = Frontend("clang")
+)
+
+func (f Frontend) validate() error {
+	switch f {
+	case FrontendCC, "cc", FrontendClang:
+		return nil
+	}
+	return fmt.Errorf("unsupported frontend: %q", string(f))
+}
+
+// sourceUnit is a C translation unit read by one of the frontends.
+// All frontends produce the same cxgo declarations, which are then translated to Go by a shared pipeline.
+type sourceUnit interface {
+	// convertDecls converts top-level declarations of the unit, including the ones from included headers.
+	convertDecls(g *translator, cur string) []CDecl
+}
+
+// ccUnit is a translation unit parsed by the built-in C parser.
+type ccUnit struct {
+	ast *cc.AST
+}
+
+func (u ccUnit) convertDecls(g *translator, cur string) []CDecl {
+	ast := u.ast
+	g.file = ast
+
+	decl := g.convertMacros(ast)
+	g.scanSymbolAttrs(cur)
+
+	total := 0
+	for tu := ast.TranslationUnit; tu != nil; tu = tu.TranslationUnit {
+		total++
+	}
+	tu := ast.TranslationUnit
+	for done := 0; tu != nil; done++ {
+		d := tu.ExternalDeclaration
+		tu = tu.TranslationUnit
+		g.conf.progress(cur, PassConvert, done, total)
+		if d == nil {
+			continue
+		}
+		g.checkCancel()
+		if len(g.symAttrs) != 0 {
+			g.declSymbolAttrs(d)
+		}
+		var cd []CDecl
+		switch d.Case {
+		case cc.ExternalDeclarationFuncDef:
+			cd = g.convertFuncDef(d.FunctionDefinition)
+		case cc.ExternalDeclarationDecl:
+			cd = g.convertDecl(d.Declaration)
+		case cc.ExternalDeclarationEmpty:
+			// TODO
+		default:
+			panic(unsupported(d, d.Case))
+		}
+		if g.needDeclPos() {
+			for _, c := range cd {
+				g.declPos[c] = d.Position()
+			}
+		}
+		decl = append(decl, cd...)
+	}
+	g.conf.progress(cur, PassConvert, total, total)
+	return decl
+}
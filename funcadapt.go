@@ -0,0 +1,91 @@
+package cxgo
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// funcAdapter is a generated function that calls a named C function via a function type with a different signature.
+type funcAdapter struct {
+	To   types.Type
+	Name *types.Ident
+}
+
+// funcAdapter converts a function to an incompatible function type, as C allows via casts. Named functions are
+// wrapped by a shared top-level adapter, thus function pointers stay comparable (fp == (cb_t)my_func); other
+// values are wrapped by a function literal. It returns nil if the types cannot be adapted.
+func (g *translator) funcAdapter(toType types.Type, x Expr) Expr {
+	ft := types.Unwrap(toType).(*types.FuncType)
+	fx := types.Unwrap(x.CType(nil)).(*types.FuncType)
+	if (ft.Variadic() && !fx.Variadic()) || ft.ArgN() < fx.ArgN() || (ft.Return() != nil && fx.Return() == nil) {
+		// incompatible function types
+		return nil
+	}
+	fnc, ok := cUnwrap(x).(IdentExpr)
+	if _, isFunc := g.funcIdents[fnc.Ident]; !ok || !isFunc {
+		typ, body := g.funcAdapterBody(ft, fx, x)
+		return g.NewFuncLit(typ, body...)
+	}
+	list := g.adapters[fnc.Name]
+	for _, a := range list {
+		if types.Same(a.To, toType) {
+			return FuncIdent{a.Name}
+		}
+	}
+	suffix := strconv.Itoa(len(list) + 1)
+	if nt, ok := toType.(types.Named); ok {
+		suffix = nt.Name().Name
+	}
+	typ, body := g.funcAdapterBody(ft, fx, x)
+	name := types.NewIdent("cxgoAdapt_"+fnc.Name+"_"+suffix, typ)
+	g.adapters[fnc.Name] = append(list, funcAdapter{To: toType, Name: name})
+	g.adapterDecls = append(g.adapterDecls, &CFuncDecl{
+		Name: name,
+		Type: typ,
+		Body: g.NewCBlock(body...),
+	})
+	return FuncIdent{name}
+}
+
+// funcAdapterBody returns the type and the body of an adapter calling x via a function type ft.
+// Extra arguments are dropped, and so is the return value, if ft has none.
+func (g *translator) funcAdapterBody(ft, fx *types.FuncType, x Expr) (*types.FuncType, []CStmt) {
+	callArgs := make([]Expr, 0, fx.ArgN()+1)
+	funcArgs := make([]*types.Field, 0, ft.ArgN())
+	for i, a := range ft.Args() {
+		at := a.Type()
+		name := types.NewIdent(fmt.Sprintf("arg%d", i+1), at)
+		if a.Name != nil && !a.Name.IsUnnamed() {
+			name = types.NewIdent(a.Name.Name, at)
+		}
+		funcArgs = append(funcArgs, &types.Field{
+			Name: name,
+		})
+		if i < fx.ArgN() {
+			callArgs = append(callArgs, IdentExpr{name})
+		}
+	}
+	if ft.Variadic() {
+		callArgs = append(callArgs, &ExpandExpr{X: IdentExpr{types.NewIdent("_rest", types.UnkT(1))}})
+	}
+	e := g.NewCCallExpr(g.ToFunc(x, nil), callArgs)
+	var stmts []CStmt
+	if ft.Return() != nil {
+		stmts = g.NewReturnStmt(e, ft.Return())
+	} else {
+		stmts = NewCExprStmt(e)
+	}
+	if ft.Variadic() {
+		return g.env.VarFuncT(ft.Return(), funcArgs...), stmts
+	}
+	return g.env.FuncT(ft.Return(), funcArgs...), stmts
+}
+
+// adapterDeclList returns declarations of function adapters generated for the current file.
+func (g *translator) adapterDeclList() []CDecl {
+	decls := g.adapterDecls
+	g.adapterDecls = nil
+	return decls
+}
@@ -111,6 +111,15 @@ func asSizeofT(e Expr) types.Type {
 func (g *translator) NewCCallExpr(fnc FuncExpr, args []Expr) Expr {
 	if id, ok := cUnwrap(fnc).(IdentExpr); ok {
 		// TODO: another way to hook into it?
+		if e := g.gcAllocCall(id.Ident, args); e != nil {
+			return e
+		}
+		if e := g.customAllocCall(id.Ident, args); e != nil {
+			return e
+		}
+		if e := g.offsetofCall(id.Ident, args); e != nil {
+			return e
+		}
 		switch id.Ident {
 		case g.env.C().AssertFunc():
 			// assert(!const) -> panic(const)
@@ -277,10 +286,14 @@ func (g *translator) NewCCallExpr(fnc FuncExpr, args []Expr) Expr {
 			atyp = ftargs[i].Type()
 		} else if ft.Variadic() {
 			atyp = types.UnkT(1)
+			if t := suffixType(cUnwrap(a)); t != nil && a.IsConst() {
+				// Go passes untyped constants as int
+				a = &CCastExpr{Expr: a, Type: t}
+			}
 		} else {
 			break
 		}
-		args[i] = g.cCast(atyp, a)
+		args[i] = g.implicitCast(atyp, a)
 	}
 	return &CallExpr{
 		Fun:  fnc,
@@ -60,6 +60,81 @@ var functions [1]*func() = [1]*func(){(*func())(unsafe.Pointer(libc.FuncAddr(foo
 func foo2() {
 	libc.AsFunc(functions[0], (*func())(nil)).(func())()
 }
+`,
+	},
+	{
+		name: "func ptr cast",
+		src: `
+typedef void (*cb_t)(void *);
+void my_func(int *p) { *p = 1; }
+cb_t reg;
+
+void set() {
+	reg = (cb_t)my_func;
+}
+int is_mine(cb_t fp) {
+	return fp == (cb_t)my_func;
+}
+cb_t wrap(void (*fp)(int *)) {
+	return (cb_t)fp;
+}
+`,
+		exp: `
+type cb_t func(unsafe.Pointer)
+
+func my_func(p *int32) {
+	*p = 1
+}
+
+var reg cb_t
+
+func set() {
+	reg = cxgoAdapt_my_func_cb_t
+}
+func is_mine(fp cb_t) int32 {
+	return libc.BoolToInt(libc.FuncAddr(fp) == libc.FuncAddr(cxgoAdapt_my_func_cb_t))
+}
+func wrap(fp func(*int32)) cb_t {
+	return func(arg1 unsafe.Pointer) {
+		fp((*int32)(arg1))
+	}
+}
+func cxgoAdapt_my_func_cb_t(arg1 unsafe.Pointer) {
+	my_func((*int32)(arg1))
+}
+`,
+	},
+	{
+		name: "func ptr cast drop return",
+		src: `
+typedef void (*cb_t)(int, int);
+int get(int a) { return a; }
+
+cb_t f() {
+	return (cb_t)get;
+}
+void (*g())(int, int) {
+	return (void (*)(int, int))get;
+}
+`,
+		exp: `
+type cb_t func(int32, int32)
+
+func get(a int32) int32 {
+	return a
+}
+func f() cb_t {
+	return cxgoAdapt_get_cb_t
+}
+func g() func(int32, int32) {
+	return cxgoAdapt_get_2
+}
+func cxgoAdapt_get_cb_t(arg1 int32, arg2 int32) {
+	get(arg1)
+}
+func cxgoAdapt_get_2(arg1 int32, arg2 int32) {
+	get(arg1)
+}
 `,
 	},
 }
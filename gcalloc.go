@@ -0,0 +1,261 @@
+package cxgo
+
+import (
+	"github.com/gotranspile/cxgo/types"
+)
+
+// AllocConfig declares a C function that allocates memory, for example from an arena; see Config.Allocators.
+// Calls to it are translated the same way as malloc or calloc calls with the same size, if the other arguments
+// have no side effects.
+type AllocConfig struct {
+	Name  string `yaml:"name" json:"name"`   // C function name
+	Size  int    `yaml:"size" json:"size"`   // index of the argument with the size in bytes
+	Count *int   `yaml:"count" json:"count"` // optional index of the argument with the number of elements, as in calloc
+}
+
+// customAllocCall converts calls to allocators from Config.Allocators to new and make, as for malloc or calloc.
+// It returns nil if the call is not an allocation, or if it cannot be converted.
+func (g *translator) customAllocCall(fnc *types.Ident, args []Expr) Expr {
+	for _, a := range g.conf.Allocators {
+		if a.Name != fnc.Name {
+			continue
+		}
+		if a.Size < 0 || a.Size >= len(args) || (a.Count != nil && (*a.Count < 0 || *a.Count >= len(args))) {
+			return nil
+		}
+		for i, arg := range args {
+			if i != a.Size && (a.Count == nil || i != *a.Count) && arg.HasSideEffects() {
+				// the call cannot be removed
+				return nil
+			}
+		}
+		gint := g.env.Go().Int()
+		var e Expr
+		if a.Count != nil {
+			calloc := g.env.C().CallocFunc()
+			e = g.NewCCallExpr(FuncIdent{calloc}, []Expr{g.cCast(gint, args[*a.Count]), g.cCast(gint, args[a.Size])})
+		} else {
+			malloc := g.env.C().MallocFunc()
+			e = g.NewCCallExpr(FuncIdent{malloc}, []Expr{g.cCast(gint, args[a.Size])})
+		}
+		if _, ok := e.(*CallExpr); ok {
+			// not converted, keep the original call
+			return nil
+		}
+		return e
+	}
+	return nil
+}
+
+// gcAllocOrig is an original call of an allocation converted by Config.GCAlloc.
+type gcAllocOrig struct {
+	fnc  *types.Ident
+	args []Expr
+}
+
+// gcAllocCall converts allocations that are not converted by default to new and make, if Config.GCAlloc is set:
+//
+//	malloc(n * sizeof(T)) -> make([]T, n)
+//	calloc(1, sizeof(T))  -> new(T)
+//
+// It returns nil if the call cannot be converted. The original call is kept in translator.gcAllocs,
+// since the conversion is reverted for memory passed to realloc; see gcReallocs.
+func (g *translator) gcAllocCall(fnc *types.Ident, args []Expr) Expr {
+	if !g.conf.GCAlloc {
+		return nil
+	}
+	e := g.gcAllocExpr(fnc, args)
+	if e != nil {
+		g.gcAllocs[e] = gcAllocOrig{fnc: fnc, args: args}
+	}
+	return e
+}
+
+func (g *translator) gcAllocExpr(fnc *types.Ident, args []Expr) Expr {
+	switch fnc {
+	case g.env.C().MallocFunc():
+		if len(args) != 1 {
+			return nil
+		}
+		mul, ok := unwrapCasts(args[0]).(*CBinaryExpr)
+		if !ok || mul.Op != BinOpMult {
+			return nil
+		}
+		n, tp := mul.Left, asSizeofT(mul.Right)
+		if tp == nil {
+			n, tp = mul.Right, asSizeofT(mul.Left)
+		}
+		if tp == nil {
+			return nil
+		}
+		return &MakeExpr{
+			e:    g.env.Env,
+			Elem: tp,
+			Size: g.cCast(g.env.Go().Int(), n),
+		}
+	case g.env.C().CallocFunc():
+		if len(args) != 2 {
+			return nil
+		}
+		if n, ok := unwrapCasts(args[0]).(IntLit); !ok || n.Uint() != 1 {
+			return nil
+		}
+		if tp := asSizeofT(args[1]); tp != nil {
+			return &NewExpr{
+				e:    g.env.Env,
+				Elem: tp,
+			}
+		}
+	}
+	return nil
+}
+
+// isGCAlloc checks if the expression is a Go allocation made by new or make.
+func isGCAlloc(e Expr) bool {
+	switch e := unwrapPtrCasts(e).(type) {
+	case *NewExpr, *MakeExpr:
+		return true
+	case *TakeAddr:
+		// &make([]T, n)[0]
+		if ind, ok := cUnwrap(e.X).(*CIndexExpr); ok && ind.IndexZero() {
+			_, ok = unwrapPtrCasts(ind.Expr).(*MakeExpr)
+			return ok
+		}
+	}
+	return false
+}
+
+// unwrapPtrCasts is similar to unwrapCasts, but also removes conversions between pointer types.
+func unwrapPtrCasts(e Expr) Expr {
+	for {
+		e = unwrapCasts(e)
+		c, ok := e.(*PtrToPtr)
+		if !ok {
+			return e
+		}
+		e = c.X
+	}
+}
+
+// gcReallocs reverts allocations converted by gcAllocCall for variables that are passed to realloc,
+// since libc.Realloc only accepts memory allocated by libc. Each reverted allocation is reported with Config.OnNote.
+func (g *translator) gcReallocs(d CDecl, f *CFuncDecl) {
+	realloc := make(map[*types.Ident]struct{})
+	Walk(f.Body, func(n Node) bool {
+		c, ok := n.(*CallExpr)
+		if !ok || len(c.Args) != 2 {
+			return true
+		}
+		if fnc, ok := c.Fun.(Ident); !ok || fnc.Identifier().GoName != "libc.Realloc" {
+			return true
+		}
+		if id, ok := unwrapPtrCasts(c.Args[0]).(Ident); ok {
+			realloc[id.Identifier()] = struct{}{}
+		}
+		return true
+	})
+	if len(realloc) == 0 {
+		return
+	}
+	revert := func(id *types.Ident, x Expr) Expr {
+		if _, ok := realloc[id]; !ok || !isGCAlloc(x) {
+			return x
+		}
+		var orig *gcAllocOrig
+		Walk(x, func(n Node) bool {
+			if e, ok := n.(Expr); ok {
+				if c, ok := g.gcAllocs[e]; ok {
+					orig = &c
+				}
+			}
+			return orig == nil
+		})
+		if orig == nil {
+			return x
+		}
+		g.conf.GCAlloc = false
+		e := g.NewCCallExpr(FuncIdent{orig.fnc}, orig.args)
+		g.conf.GCAlloc = true
+		if g.conf.OnNote != nil {
+			g.conf.OnNote(Note{Where: g.declPos[d], Msg: "kept the C allocation of " + id.Name + " passed to realloc in " + declDesc(d)})
+		}
+		return g.cCast(id.CType(nil), e)
+	}
+	Walk(f.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *CVarDecl:
+			for i, id := range n.Names {
+				if i < len(n.Inits) && n.Inits[i] != nil {
+					n.Inits[i] = revert(id, n.Inits[i])
+				}
+			}
+		case *CAssignStmt:
+			if id, ok := cUnwrap(n.Left).(Ident); ok {
+				n.Right = revert(id.Identifier(), n.Right)
+			}
+		}
+		return true
+	})
+}
+
+// gcFrees removes free() calls on variables that only hold Go allocations, if Config.GCAlloc is set.
+// Each removed call is reported with Config.OnNote. Allocations passed to realloc are reverted first; see gcReallocs.
+func (g *translator) gcFrees(decl []CDecl) {
+	if !g.conf.GCAlloc {
+		return
+	}
+	for _, d := range decl {
+		f, ok := d.(*CFuncDecl)
+		if !ok || f.Body == nil {
+			continue
+		}
+		g.gcReallocs(d, f)
+		gc := make(map[*types.Ident]bool)
+		assign := func(id *types.Ident, x Expr) {
+			if isGCAlloc(x) {
+				if _, ok := gc[id]; !ok {
+					gc[id] = true
+				}
+			} else if !IsNil(x) {
+				// may hold memory from libc or from the caller
+				gc[id] = false
+			}
+		}
+		Walk(f.Body, func(n Node) bool {
+			switch n := n.(type) {
+			case *CVarDecl:
+				for i, id := range n.Names {
+					if i < len(n.Inits) && n.Inits[i] != nil {
+						assign(id, n.Inits[i])
+					}
+				}
+			case *CAssignStmt:
+				if id, ok := cUnwrap(n.Left).(Ident); ok {
+					assign(id.Identifier(), n.Right)
+				}
+			}
+			return true
+		})
+		f.Body.Stmts, _ = cReplaceEachStmt(func(s CStmt) ([]CStmt, bool) {
+			st, ok := s.(*CExprStmt)
+			if !ok {
+				return nil, false
+			}
+			c, ok := st.Expr.(*CallExpr)
+			if !ok || len(c.Args) != 1 {
+				return nil, false
+			}
+			if fnc, ok := c.Fun.(Ident); !ok || fnc.Identifier() != g.env.C().FreeFunc() {
+				return nil, false
+			}
+			id, ok := unwrapPtrCasts(c.Args[0]).(Ident)
+			if !ok || !gc[id.Identifier()] {
+				return nil, false
+			}
+			if g.conf.OnNote != nil {
+				g.conf.OnNote(Note{Where: g.declPos[d], Msg: "removed free(" + id.Identifier().Name + ") of a Go allocation in " + declDesc(d)})
+			}
+			return nil, true
+		}, f.Body.Stmts)
+	}
+}
@@ -26,9 +26,20 @@ const gccPredefine = `
 #define __extension__
 #define __imag__
 #define __inline inline
+#define __forceinline inline
+#define __declspec(x)
+#define __cdecl
+#define __stdcall
+#define __fastcall
+#define __thiscall
+#define __vectorcall
+#define __w64
+#define __ptr32
+#define __ptr64
+#define __unaligned
 #define __real(x) __REAL()
 #define __real__
-#define __restrict
+#define __restrict restrict
 #define __sync_val_compare_and_swap(x, y, z, ...) __SYNC_VAL_COMPARE_AND_SWAP()
 #define __typeof typeof
 #define __volatile volatile
@@ -92,9 +103,6 @@ int __builtin_setjmp(void*);
 int __builtin_strcmp(char*, char*);
 int __builtin_strncmp(char*, char*, %[1]v);
 long long strlen (char*);
-unsigned __builtin_bswap32 (unsigned x);
-unsigned long long __builtin_bswap64 (unsigned long long x);
-unsigned short __builtin_bswap16 (unsigned short x);
 void __BUILTIN_LONGJMP();
 void __SYNC_VAL_COMPARE_AND_SWAP();
 void __builtin_bcopy(void*, void*, %[1]v);
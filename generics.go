@@ -0,0 +1,436 @@
+package cxgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	gotypes "go/types"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GenericConfig describes a family of declarations stamped out by a macro for different types,
+// for example by DEFINE_VECTOR(int) and DEFINE_VECTOR(float).
+//
+// Declarations of the family are merged into Go generic types and functions, while the original names
+// become aliases of instantiated generics.
+type GenericConfig struct {
+	// Names are patterns for Go names of declarations in the family, with {T} in place of the macro argument,
+	// for example "vector_{T}" and "vector_{T}_push". The name of the generic declaration is the pattern with {T} removed.
+	Names []string `yaml:"names" json:"names"`
+}
+
+const genericPlaceholder = "{T}"
+
+type genericPattern struct {
+	re   *regexp.Regexp
+	name string // name of the generic declaration
+	lit  int    // length of the literal part; more specific patterns are matched first
+}
+
+func compileGenericPatterns(c GenericConfig) ([]genericPattern, error) {
+	if len(c.Names) == 0 {
+		return nil, fmt.Errorf("generic family must have at least one name pattern")
+	}
+	var out []genericPattern
+	for _, s := range c.Names {
+		i := strings.Index(s, genericPlaceholder)
+		if i < 0 || strings.Count(s, genericPlaceholder) != 1 {
+			return nil, fmt.Errorf("generic name pattern must contain exactly one %s: %q", genericPlaceholder, s)
+		}
+		pref, suff := s[:i], s[i+len(genericPlaceholder):]
+		name := strings.Trim(strings.TrimSuffix(pref, "_")+"_"+strings.TrimPrefix(suff, "_"), "_")
+		if name == "" {
+			return nil, fmt.Errorf("generic name pattern must contain a name: %q", s)
+		}
+		out = append(out, genericPattern{
+			re:   regexp.MustCompile("^" + regexp.QuoteMeta(pref) + "([A-Za-z0-9_]+)" + regexp.QuoteMeta(suff) + "$"),
+			name: name,
+			lit:  len(pref) + len(suff),
+		})
+	}
+	return out, nil
+}
+
+func (g *translator) compileGenerics() error {
+	for _, c := range g.conf.Generics {
+		pats, err := compileGenericPatterns(c)
+		if err != nil {
+			return err
+		}
+		g.generics = append(g.generics, pats)
+	}
+	return nil
+}
+
+// applyGenerics merges declaration families from Config.Generics into Go generics.
+// Families that cannot be merged (instances differ in something other than a single type) are left as-is.
+func (g *translator) applyGenerics(decls []GoDecl) []GoDecl {
+	for _, pats := range g.generics {
+		if f := newGenericFamily(pats, decls); f != nil && f.merge() {
+			decls = f.apply()
+		}
+	}
+	return decls
+}
+
+type genericInstance struct {
+	decls []int          // declaration indexes, by pattern index
+	names map[string]int // Go names of declarations, mapped to pattern index
+	slots []genericSlot  // positions where the instance differs from the template
+	typ   GoExpr         // type argument
+}
+
+type genericSlot struct {
+	v   reflect.Value // settable expression in the template
+	typ GoExpr        // expression in the instance
+}
+
+type genericFamily struct {
+	pats  []genericPattern
+	insts []*genericInstance // the first one is used as a template
+	decls []GoDecl           // all declarations of the file
+	param string             // type parameter name
+}
+
+// newGenericFamily finds complete instances of a family. It returns nil if there are less than two instances.
+func newGenericFamily(pats []genericPattern, decls []GoDecl) *genericFamily {
+	// "vector_{T}" also matches "vector_int_push", thus longer patterns are checked first
+	order := make([]int, len(pats))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return pats[order[i]].lit > pats[order[j]].lit
+	})
+	defined := make(map[string]struct{})
+	byArg := make(map[string]*genericInstance)
+	var insts []*genericInstance
+	for di, d := range decls {
+		names := goDeclNames(d)
+		for _, name := range names {
+			defined[name] = struct{}{}
+		}
+		if len(names) != 1 {
+			continue
+		}
+		for _, pi := range order {
+			sub := pats[pi].re.FindStringSubmatch(names[0])
+			if sub == nil {
+				continue
+			}
+			inst := byArg[sub[1]]
+			if inst == nil {
+				inst = &genericInstance{decls: make([]int, len(pats)), names: make(map[string]int)}
+				for i := range inst.decls {
+					inst.decls[i] = -1
+				}
+				byArg[sub[1]] = inst
+				insts = append(insts, inst)
+			}
+			if inst.decls[pi] < 0 {
+				inst.decls[pi] = di
+				inst.names[names[0]] = pi
+			}
+			break
+		}
+	}
+	for _, name := range append(genericNames(pats), pats[0].name+"_types") {
+		if _, ok := defined[name]; ok {
+			// generic name conflicts with an existing declaration
+			return nil
+		}
+	}
+	var complete []*genericInstance
+	for _, inst := range insts {
+		ok := true
+		for _, di := range inst.decls {
+			if di < 0 || !isGenericDecl(decls[di]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			complete = append(complete, inst)
+		}
+	}
+	if len(complete) < 2 {
+		return nil
+	}
+	return &genericFamily{pats: pats, insts: complete, decls: decls}
+}
+
+func genericNames(pats []genericPattern) []string {
+	names := make([]string, 0, len(pats))
+	for _, p := range pats {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// isGenericDecl checks if the declaration can be converted to a Go generic.
+func isGenericDecl(d GoDecl) bool {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		return d.Recv == nil && d.Body != nil
+	case *ast.GenDecl:
+		return d.Tok == token.TYPE && len(d.Specs) == 1
+	}
+	return false
+}
+
+// merge compares all instances with the template and finds positions of the type parameter.
+// It returns false if the family cannot be converted to generics.
+func (f *genericFamily) merge() bool {
+	tmpl := f.insts[0]
+	var (
+		keys  []uintptr // template slots, must be the same for all instances
+		targ  string    // type argument of the template
+		iargs = make(map[string]struct{})
+	)
+	for i, inst := range f.insts[1:] {
+		for pi, di := range inst.decls {
+			a := reflect.ValueOf(f.decls[tmpl.decls[pi]])
+			b := reflect.ValueOf(f.decls[di])
+			if !f.diff(inst, a, b) {
+				return false
+			}
+		}
+		if len(inst.slots) == 0 {
+			return false
+		}
+		var ikeys []uintptr
+		for _, s := range inst.slots {
+			ikeys = append(ikeys, s.v.Addr().Pointer())
+			ta, ia := gotypes.ExprString(s.v.Interface().(GoExpr)), gotypes.ExprString(s.typ)
+			if targ == "" {
+				targ = ta
+				tmpl.typ = s.v.Interface().(GoExpr)
+			} else if ta != targ {
+				return false
+			}
+			if inst.typ == nil {
+				inst.typ = s.typ
+			} else if ia != gotypes.ExprString(inst.typ) {
+				return false
+			}
+		}
+		if i == 0 {
+			keys = ikeys
+		} else if !equalPtrs(keys, ikeys) {
+			return false
+		}
+		arg := gotypes.ExprString(inst.typ)
+		if _, ok := iargs[arg]; ok || arg == targ {
+			return false
+		}
+		iargs[arg] = struct{}{}
+	}
+	used := make(map[string]struct{})
+	for _, di := range tmpl.decls {
+		for name := range goDeclRefs(f.decls[di]) {
+			used[name] = struct{}{}
+		}
+	}
+	f.param = "T"
+	for i := 1; ; i++ {
+		if _, ok := used[f.param]; !ok {
+			break
+		}
+		f.param = fmt.Sprintf("T%d", i)
+	}
+	return true
+}
+
+func equalPtrs(a, b []uintptr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	goExprType  = reflect.TypeOf((*ast.Expr)(nil)).Elem()
+	goPosType   = reflect.TypeOf(token.NoPos)
+	goObjType   = reflect.TypeOf((*ast.Object)(nil))
+	goScopeType = reflect.TypeOf((*ast.Scope)(nil))
+	goCommType  = reflect.TypeOf((*ast.CommentGroup)(nil))
+)
+
+// diff compares template node a with instance node b and records differing expressions to the instance.
+// Differences are recorded at the deepest expression. It returns false if nodes differ in something other than an expression.
+func (f *genericFamily) diff(inst *genericInstance, a, b reflect.Value) bool {
+	switch a.Type() {
+	case goPosType, goObjType, goScopeType, goCommType:
+		return true
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		n := len(inst.slots)
+		if f.diff(inst, a.Elem(), b.Elem()) {
+			return true
+		}
+		if a.Type() != goExprType || !a.CanSet() {
+			return false
+		}
+		// sub-expressions are not the same, the whole expression is replaced
+		inst.slots = append(inst.slots[:n], genericSlot{v: a, typ: b.Interface().(GoExpr)})
+		return true
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		if x, ok := a.Interface().(*ast.Ident); ok {
+			y := b.Interface().(*ast.Ident)
+			return f.canonName(f.insts[0], x.Name) == f.canonName(inst, y.Name)
+		}
+		return f.diff(inst, a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !f.diff(inst, a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !f.diff(inst, a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return a.Interface() == b.Interface()
+}
+
+// canonName replaces names of the instance declarations with the same name for all instances.
+func (f *genericFamily) canonName(inst *genericInstance, name string) string {
+	if pi, ok := inst.names[name]; ok {
+		return fmt.Sprintf("{%d}", pi)
+	}
+	return name
+}
+
+// apply replaces the template with generic declarations and all instances with aliases.
+func (f *genericFamily) apply() []GoDecl {
+	tmpl := f.insts[0]
+	for _, s := range f.insts[1].slots {
+		s.v.Set(reflect.ValueOf(GoExpr(ident(f.param))))
+	}
+	for _, di := range tmpl.decls {
+		f.genericRefs(tmpl, reflect.ValueOf(f.decls[di]))
+	}
+	constraint := f.insts[0].typ
+	for _, inst := range f.insts[1:] {
+		constraint = &ast.BinaryExpr{X: constraint, Op: token.OR, Y: inst.typ}
+	}
+	// the constraint is shared by all generic declarations of the family
+	cname := f.pats[0].name + "_types"
+	cdecl := &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&ast.TypeSpec{
+		Name: ident(cname),
+		Type: &ast.InterfaceType{Methods: &ast.FieldList{List: []*ast.Field{{Type: constraint}}}},
+	}}}
+	tparams := &ast.FieldList{List: []*ast.Field{{
+		Names: []*ast.Ident{ident(f.param)},
+		Type:  ident(cname),
+	}}}
+	type declRef struct {
+		inst *genericInstance
+		pi   int
+	}
+	refs := make(map[int]declRef)
+	for _, inst := range f.insts {
+		for pi, di := range inst.decls {
+			refs[di] = declRef{inst: inst, pi: pi}
+		}
+	}
+	out := make([]GoDecl, 0, len(f.decls))
+	for di, d := range f.decls {
+		r, ok := refs[di]
+		if !ok {
+			out = append(out, d)
+			continue
+		}
+		name := goDeclNames(d)[0]
+		gen := f.pats[r.pi].name
+		inst := &ast.IndexExpr{X: ident(gen), Index: r.inst.typ}
+		if cdecl != nil {
+			out = append(out, cdecl)
+			cdecl = nil
+		}
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if r.inst == tmpl {
+				d.Name = ident(gen)
+				d.Type.TypeParams = tparams
+				out = append(out, d)
+			}
+			out = append(out, &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+				Names:  []*ast.Ident{ident(name)},
+				Values: []GoExpr{inst},
+			}}})
+		case *ast.GenDecl:
+			if r.inst == tmpl {
+				ts := d.Specs[0].(*ast.TypeSpec)
+				ts.Name = ident(gen)
+				ts.TypeParams = tparams
+				out = append(out, d)
+			}
+			out = append(out, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&ast.TypeSpec{
+				Name:   ident(name),
+				Assign: 1,
+				Type:   inst,
+			}}})
+		}
+	}
+	return out
+}
+
+// genericRefs replaces references to template declarations with instantiated generics.
+func (f *genericFamily) genericRefs(tmpl *genericInstance, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if id, ok := v.Interface().(*ast.Ident); ok && v.Type() == goExprType && v.CanSet() {
+			if pi, ok := tmpl.names[id.Name]; ok {
+				v.Set(reflect.ValueOf(GoExpr(&ast.IndexExpr{X: ident(f.pats[pi].name), Index: ident(f.param)})))
+			}
+			return
+		}
+		f.genericRefs(tmpl, v.Elem())
+	case reflect.Ptr:
+		switch v.Type() {
+		case goObjType, goScopeType, goCommType:
+			return
+		}
+		if !v.IsNil() {
+			f.genericRefs(tmpl, v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f.genericRefs(tmpl, v.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			f.genericRefs(tmpl, v.Index(i))
+		}
+	}
+}
@@ -45,6 +45,12 @@ func goUsedImports(used map[string]struct{}, decls []GoDecl) {
 
 // ImportsFor generates import specs for well-known imports required for given declarations.
 func ImportsFor(e *libs.Env, decls []GoDecl) []GoDecl {
+	return importsFor(e, decls, nil, nil)
+}
+
+// importsFor is the same as ImportsFor, but replaces import paths and renames packages in declarations
+// according to Config.ImportPaths and Config.ImportAliases.
+func importsFor(e *libs.Env, decls []GoDecl, paths, aliases map[string]string) []GoDecl {
 	used := make(map[string]struct{})
 	goUsedImports(used, decls)
 	var list []string
@@ -54,12 +60,42 @@ func ImportsFor(e *libs.Env, decls []GoDecl) []GoDecl {
 	sort.Strings(list)
 	var specs []ast.Spec
 	for _, name := range list {
-		path := e.ResolveImport(name)
-		specs = append(specs, &ast.ImportSpec{Path: &ast.BasicLit{
+		if name == "C" {
+			// cgo import must be preceded by the preamble; see insertCgoPreamble
+			continue
+		}
+		path := ReplaceImportPath(paths, e.ResolveImport(name))
+		spec := &ast.ImportSpec{Path: &ast.BasicLit{
 			Kind:  token2.STRING,
 			Value: strconv.Quote(path),
+		}}
+		if alias, ok := aliases[name]; ok {
+			spec.Name = ident(alias)
+		}
+		specs = append(specs, spec)
+	}
+	// drivers and other packages required by used packages
+	blank := make(map[string]struct{})
+	for _, name := range list {
+		for _, path := range e.BlankImports(name) {
+			path = ReplaceImportPath(paths, path)
+			if _, ok := blank[path]; ok {
+				continue
+			}
+			blank[path] = struct{}{}
+			specs = append(specs, &ast.ImportSpec{Name: ident("_"), Path: &ast.BasicLit{
+				Kind:  token2.STRING,
+				Value: strconv.Quote(path),
+			}})
+		}
+	}
+	if _, ok := used["embed"]; !ok && usesEmbed(decls) {
+		specs = append(specs, &ast.ImportSpec{Name: ident("_"), Path: &ast.BasicLit{
+			Kind:  token2.STRING,
+			Value: strconv.Quote("embed"),
 		}})
 	}
+	renameImports(aliases, decls)
 	if len(specs) == 0 {
 		return nil
 	}
@@ -60,6 +60,19 @@ func formatUint(v uint64, base int) string {
 	return s
 }
 
+// formatUintWidth is similar to formatUint, but pads digits with leading zeros to a given width.
+func formatUintWidth(v uint64, base, width int) string {
+	s := formatUint(v, base)
+	prefix := ""
+	if base != 10 {
+		prefix, s = s[:2], s[2:]
+	}
+	if n := width - len(s); n > 0 {
+		s = strings.Repeat("0", n) + s
+	}
+	return prefix + s
+}
+
 func intLit64(v int64, base int) GoExpr {
 	if base <= 0 {
 		base = 10
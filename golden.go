@@ -0,0 +1,171 @@
+package cxgo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Golden is a snapshot of generated Go declarations, used to report changes after upgrading cxgo or changing
+// the config; see SnapshotGolden and Golden.Diff.
+//
+// Declarations are identified by the package directory and the Go name, and compared by their formatted text
+// without comments, thus moving declarations between files or reordering them is not reported as a change.
+type Golden struct {
+	Version    string            `json:"version,omitempty"`
+	ConfigHash string            `json:"config_hash,omitempty"`
+	Decls      map[string]string `json:"decls"` // hashes of declarations, by key; see GoldenChange.Decl
+}
+
+// Kinds of golden changes.
+const (
+	GoldenAdded   = "added"
+	GoldenRemoved = "removed"
+	GoldenChanged = "changed"
+)
+
+// GoldenChange is a difference of a single declaration between two snapshots.
+type GoldenChange struct {
+	Decl string // slash-separated package directory and the Go name, for example "sub/Type.Method"
+	Kind string
+}
+
+func (c GoldenChange) String() string {
+	return c.Kind + ": " + c.Decl
+}
+
+// SnapshotGolden reads all Go files in the output tree and records their declarations.
+func SnapshotGolden(fsys fs.FS) (*Golden, error) {
+	g := &Golden{Decls: make(map[string]string)}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(name, ".go") {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		return g.addFile(path.Dir(name), name, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *Golden) addFile(dir, name string, data []byte) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, data, parser.SkipObjectResolution)
+	if err != nil {
+		return err
+	}
+	add := func(key string, n ast.Node) error {
+		if dir != "." {
+			key = dir + "/" + key
+		}
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, token.NewFileSet(), n); err != nil {
+			return err
+		}
+		// blank and init declarations may repeat
+		base := key
+		for i := 2; ; i++ {
+			if _, ok := g.Decls[key]; !ok {
+				break
+			}
+			key = base + "#" + strconv.Itoa(i)
+		}
+		g.Decls[key] = fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))[:16]
+		return nil
+	}
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			key := d.Name.Name
+			if recv := goRecvName(d); recv != "" {
+				key = recv + "." + key
+			}
+			if err := add(key, d); err != nil {
+				return err
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			for _, s := range d.Specs {
+				var names []string
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					names = append(names, s.Name.Name)
+				case *ast.ValueSpec:
+					for _, id := range s.Names {
+						names = append(names, id.Name)
+					}
+				}
+				// the type and values of const specs may be implied by previous specs in the block
+				n := ast.Node(s)
+				if vs, ok := s.(*ast.ValueSpec); ok && d.Tok == token.CONST && len(vs.Values) == 0 {
+					n = d
+				}
+				if err := add(strings.Join(names, ","), n); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ReadGolden reads a snapshot written by Golden.WriteTo.
+func ReadGolden(r io.Reader) (*Golden, error) {
+	var g Golden
+	if err := json.NewDecoder(r).Decode(&g); err != nil {
+		return nil, err
+	}
+	if g.Decls == nil {
+		g.Decls = make(map[string]string)
+	}
+	return &g, nil
+}
+
+// WriteTo writes the snapshot as JSON.
+func (g *Golden) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(g, "", "\t")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// Diff returns changes of declarations in the current snapshot compared to the golden one, sorted by declaration.
+func (g *Golden) Diff(cur *Golden) []GoldenChange {
+	var out []GoldenChange
+	for key, h := range g.Decls {
+		if h2, ok := cur.Decls[key]; !ok {
+			out = append(out, GoldenChange{Decl: key, Kind: GoldenRemoved})
+		} else if h != h2 {
+			out = append(out, GoldenChange{Decl: key, Kind: GoldenChanged})
+		}
+	}
+	for key := range cur.Decls {
+		if _, ok := g.Decls[key]; !ok {
+			out = append(out, GoldenChange{Decl: key, Kind: GoldenAdded})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Decl < out[j].Decl
+	})
+	return out
+}
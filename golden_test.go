@@ -0,0 +1,72 @@
+package cxgo
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoldenDiff(t *testing.T) {
+	old, err := SnapshotGolden(fstest.MapFS{
+		"a.go": {Data: []byte(`package lib
+
+const (
+	A = iota
+	B
+)
+
+// comments are ignored
+func f() int { return A }
+
+func (p *point) Len() int { return 0 }
+`)},
+		"sub/b.go": {Data: []byte(`package sub
+
+type point struct{ x, y int }
+
+var _ = 1
+var _ = 2
+`)},
+	})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	_, err = old.WriteTo(&buf)
+	require.NoError(t, err)
+	old, err = ReadGolden(&buf)
+	require.NoError(t, err)
+	require.Len(t, old.Decls, 7)
+
+	cur, err := SnapshotGolden(fstest.MapFS{
+		"a.go": {Data: []byte(`package lib
+
+func (p *point) Len() int { return 1 }
+`)},
+		"a2.go": {Data: []byte(`package lib
+
+func f() int {
+	return A
+}
+
+const (
+	A = iota
+	B
+	C
+)
+`)},
+		"sub/b.go": {Data: []byte(`package sub
+
+type point struct{ x, y int }
+
+var _ = 1
+`)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []GoldenChange{
+		{Decl: "B", Kind: GoldenChanged}, // implied value of a const block
+		{Decl: "C", Kind: GoldenAdded},
+		{Decl: "point.Len", Kind: GoldenChanged},
+		{Decl: "sub/_#2", Kind: GoldenRemoved},
+	}, old.Diff(cur))
+}
@@ -0,0 +1,182 @@
+package cxgo
+
+import "github.com/gotranspile/cxgo/types"
+
+// gotoPos is a position of a goto or a label: statement lists enclosing it, starting from the function body,
+// and the index of the enclosing statement in each list.
+type gotoPos struct {
+	lists []*[]CStmt
+	inds  []int
+}
+
+func (p gotoPos) enter(list *[]CStmt, i int) gotoPos {
+	return gotoPos{
+		lists: append(p.lists[:len(p.lists):len(p.lists)], list),
+		inds:  append(p.inds[:len(p.inds):len(p.inds)], i),
+	}
+}
+
+// before checks if the position precedes the other one in the function. Positions in different branches of the same
+// statement are not ordered.
+func (p gotoPos) before(p2 gotoPos) bool {
+	for k := 0; k < len(p.lists) && k < len(p2.lists); k++ {
+		if p.lists[k] != p2.lists[k] {
+			return false
+		}
+		if p.inds[k] != p2.inds[k] {
+			return p.inds[k] < p2.inds[k]
+		}
+	}
+	return false
+}
+
+type gotoRef struct {
+	label string
+	pos   gotoPos
+}
+
+// gotoScopes collects positions of gotos and labels of a function.
+type gotoScopes struct {
+	labels map[string]gotoPos
+	gotos  []gotoRef
+}
+
+func newGotoScopes(f *CFuncDecl) *gotoScopes {
+	s := &gotoScopes{labels: make(map[string]gotoPos)}
+	s.walk(&f.Body.Stmts, gotoPos{})
+	return s
+}
+
+func (s *gotoScopes) walk(list *[]CStmt, p gotoPos) {
+	for i, st := range *list {
+		s.walkStmt(st, p.enter(list, i))
+	}
+}
+
+func (s *gotoScopes) walkStmt(st CStmt, p gotoPos) {
+	switch st := st.(type) {
+	case *CGotoStmt:
+		s.gotos = append(s.gotos, gotoRef{label: st.Label, pos: p})
+	case *CLabelStmt:
+		s.labels[st.Label] = p
+	case *BlockStmt:
+		s.walk(&st.Stmts, p)
+	case *CIfStmt:
+		s.walk(&st.Then.Stmts, p)
+		if st.Else != nil {
+			s.walkStmt(st.Else, p)
+		}
+	case *CForStmt:
+		s.walk(&st.Body.Stmts, p)
+	case *CRangeStmt:
+		s.walk(&st.Body.Stmts, p)
+	case *CSwitchStmt:
+		for _, c := range st.Cases {
+			s.walk(&c.Stmts, p)
+		}
+	}
+}
+
+// gotoHoistedDecl returns a variable declaration that a forward goto cannot jump over in Go.
+func gotoHoistedDecl(st CStmt) *CVarDecl {
+	ds, ok := st.(*CDeclStmt)
+	if !ok {
+		return nil
+	}
+	d, ok := ds.Decl.(*CVarDecl)
+	if !ok || d.Const || d.Names[0].Name == "__func__" {
+		return nil
+	}
+	return d
+}
+
+// gotoShadows checks if statements use a different variable with the same Go name.
+func gotoShadows(id *types.Ident, stmts []CStmt) bool {
+	name := id.GoIdent().Name
+	found := false
+	for _, st := range stmts {
+		Walk(st, func(n Node) bool {
+			if e, ok := n.(IdentExpr); ok && e.Ident != id && e.GoIdent().Name == name {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// fixGotoScopes makes gotos of the function valid in Go, which doesn't allow jumping over variable declarations
+// or into blocks. Declarations between a forward goto and its label are moved to the beginning of the block,
+// and their initializers become assignments; variables are renamed if they would shadow others there. Jumps into
+// blocks cannot be fixed this way, and the function must be flattened instead; the reason is returned in this case.
+func (g *translator) fixGotoScopes(f *CFuncDecl) string {
+	if !hasGotos(f.Body.Stmts) {
+		return ""
+	}
+	s := newGotoScopes(f)
+	hoist := make(map[*[]CStmt]map[int]struct{})
+	var lists []*[]CStmt
+	for _, r := range s.gotos {
+		l, ok := s.labels[r.label]
+		if !ok {
+			continue
+		}
+		// lists form a tree, thus the label is reachable if its list encloses the goto
+		d := len(l.lists) - 1
+		if d >= len(r.pos.lists) || r.pos.lists[d] != l.lists[d] {
+			return "goto " + r.label + " jumps into a block"
+		}
+		list := l.lists[d]
+		for i := r.pos.inds[d] + 1; i < l.inds[d]; i++ {
+			if gotoHoistedDecl((*list)[i]) == nil {
+				continue
+			}
+			m := hoist[list]
+			if m == nil {
+				m = make(map[int]struct{})
+				hoist[list] = m
+				lists = append(lists, list)
+			}
+			m[i] = struct{}{}
+		}
+	}
+	// declarations moved to the beginning of the block must not shadow variables used before them
+	for _, list := range lists {
+		for i := range *list {
+			if _, ok := hoist[list][i]; !ok {
+				continue
+			}
+			for _, id := range gotoHoistedDecl((*list)[i]).Names {
+				if gotoShadows(id, (*list)[:i]) {
+					id.GoName = unusedName(f, id.GoIdent().Name)
+				}
+			}
+		}
+	}
+	for _, list := range lists {
+		var decls, stmts []CStmt
+		for i, st := range *list {
+			if _, ok := hoist[list][i]; !ok {
+				stmts = append(stmts, st)
+				continue
+			}
+			d := gotoHoistedDecl(st)
+			g.conf.debug("moving declaration before goto", "func", f.Name.Name, "var", d.Names[0].Name)
+			decls = append(decls, g.NewCDeclStmt(&CVarDecl{
+				Single: d.Single,
+				CVarSpec: CVarSpec{
+					g:     d.g,
+					Type:  d.Type,
+					Names: d.Names,
+				},
+			})...)
+			for j, val := range d.Inits {
+				if val != nil {
+					stmts = append(stmts, g.NewCAssignStmt(IdentExpr{d.Names[j]}, "", val)...)
+				}
+			}
+		}
+		*list = append(decls, stmts...)
+	}
+	return ""
+}
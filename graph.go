@@ -0,0 +1,321 @@
+package cxgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// Kinds of graph nodes.
+const (
+	GraphFunc  = "func"
+	GraphVar   = "var"
+	GraphConst = "const"
+	GraphType  = "type"
+	GraphFile  = "file"
+)
+
+// Kinds of graph edges.
+const (
+	GraphCall    = "call" // direct function call
+	GraphRef     = "ref"  // reference to a function or a variable, other than a call
+	GraphTypeRef = "type" // reference to a type
+	GraphDep     = "dep"  // dependency between files
+)
+
+// GraphNode is a declaration or a file in the dependency graph.
+type GraphNode struct {
+	Name   string `json:"name"`              // C name of the declaration, or the file path
+	GoName string `json:"go_name,omitempty"` // Go name of the declaration, if it's different from C name
+	Kind   string `json:"kind"`
+	File   string `json:"file,omitempty"` // C file that defines the declaration
+}
+
+// GraphEdge is a dependency between two nodes of the graph.
+type GraphEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Kind   string `json:"kind"`
+	Weight int    `json:"weight,omitempty"` // number of declaration edges between files
+}
+
+// Graph is a dependency graph of translated declarations or files.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// WriteJSON writes the graph as JSON.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(g)
+}
+
+// WriteDOT writes the graph in Graphviz DOT format.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printf("digraph cxgo {\n")
+	for _, n := range g.Nodes {
+		printf("\t%s [shape=%s", strconv.Quote(n.Name), dotShape(n.Kind))
+		if n.File != "" {
+			printf(", tooltip=%s", strconv.Quote(n.File))
+		}
+		printf("];\n")
+	}
+	for _, e := range g.Edges {
+		printf("\t%s -> %s", strconv.Quote(e.From), strconv.Quote(e.To))
+		switch {
+		case e.Weight != 0:
+			printf(" [label=%d]", e.Weight)
+		case e.Kind == GraphRef:
+			printf(" [style=dashed]")
+		case e.Kind == GraphTypeRef:
+			printf(" [style=dotted]")
+		}
+		printf(";\n")
+	}
+	printf("}\n")
+	return err
+}
+
+func dotShape(kind string) string {
+	switch kind {
+	case GraphFunc:
+		return "ellipse"
+	case GraphType:
+		return "box"
+	case GraphFile:
+		return "folder"
+	default:
+		return "note"
+	}
+}
+
+// DepGraph accumulates dependencies between declarations of one or more translation units.
+type DepGraph struct {
+	nodes  []*depNode
+	byName map[string]*depNode
+}
+
+type depNode struct {
+	GraphNode
+	refs  []depRef
+	seen  map[depRef]struct{}
+	files map[string]struct{} // all files that define the declaration
+}
+
+type depRef struct {
+	name string
+	kind string
+}
+
+func (n *depNode) addRef(name, kind string) {
+	r := depRef{name: name, kind: kind}
+	if _, ok := n.seen[r]; ok || name == n.Name {
+		return
+	}
+	n.seen[r] = struct{}{}
+	n.refs = append(n.refs, r)
+}
+
+// NewDepGraph creates an empty dependency graph.
+func NewDepGraph() *DepGraph {
+	return &DepGraph{byName: make(map[string]*depNode)}
+}
+
+// Add adds declarations of a given C file to the graph. Declarations are identified by C name,
+// thus the same declaration translated for multiple files (types from a common header) is only added once.
+func (d *DepGraph) Add(fname string, decls []CDecl) {
+	for _, decl := range decls {
+		var n *depNode
+		switch decl := decl.(type) {
+		case *CFuncDecl:
+			if decl.Body == nil {
+				continue
+			}
+			n = d.newNode(fname, decl.Name, GraphFunc)
+			if n == nil {
+				continue
+			}
+			locals := make(map[*types.Ident]struct{})
+			for _, a := range decl.Type.Args() {
+				if a.Name != nil {
+					locals[a.Name] = struct{}{}
+				}
+			}
+			n.addTypeRefs(decl.Type)
+			n.addBodyRefs(decl.Body, locals)
+		case *CVarDecl:
+			kind := GraphVar
+			if decl.Const {
+				kind = GraphConst
+			}
+			for _, name := range decl.Names {
+				if n = d.newNode(fname, name, kind); n == nil {
+					continue
+				}
+				n.addTypeRefs(decl.Type)
+				for _, x := range decl.Inits {
+					if x != nil {
+						n.addBodyRefs(x, nil)
+					}
+				}
+			}
+		case *CTypeDef:
+			if n = d.newNode(fname, decl.Name(), GraphType); n != nil {
+				n.addTypeRefs(decl.Underlying())
+			}
+		}
+	}
+}
+
+func (d *DepGraph) newNode(fname string, id *types.Ident, kind string) *depNode {
+	if id == nil || id.Name == "" {
+		return nil
+	}
+	if n, ok := d.byName[id.Name]; ok {
+		n.files[fname] = struct{}{}
+		return nil
+	}
+	n := &depNode{
+		GraphNode: GraphNode{Name: id.Name, Kind: kind, File: fname},
+		seen:      make(map[depRef]struct{}),
+		files:     map[string]struct{}{fname: {}},
+	}
+	if gname := id.String(); gname != id.Name {
+		n.GoName = gname
+	}
+	d.nodes = append(d.nodes, n)
+	d.byName[id.Name] = n
+	return n
+}
+
+// addTypeRefs adds references to named types used by a given type.
+func (n *depNode) addTypeRefs(t types.Type) {
+	switch t := t.(type) {
+	case nil:
+	case types.Named:
+		n.addRef(t.Name().Name, GraphTypeRef)
+	case types.PtrType:
+		n.addTypeRefs(t.Elem())
+	case types.ArrayType:
+		n.addTypeRefs(t.Elem())
+	case *types.FuncType:
+		for _, a := range t.Args() {
+			n.addTypeRefs(a.Type())
+		}
+		n.addTypeRefs(t.Return())
+	case *types.StructType:
+		for _, f := range t.Fields() {
+			n.addTypeRefs(f.Type())
+		}
+	}
+}
+
+// addBodyRefs adds references to functions, variables and types used by a function body or an expression.
+// Identifiers from the locals set, as well as local variables declared in the body are ignored.
+func (n *depNode) addBodyRefs(body Node, locals map[*types.Ident]struct{}) {
+	if locals == nil {
+		locals = make(map[*types.Ident]struct{})
+	}
+	ref := func(id *types.Ident, kind string) {
+		if id == nil {
+			return
+		}
+		if _, ok := locals[id]; !ok {
+			n.addRef(id.Name, kind)
+		}
+	}
+	var v Visitor
+	v = func(x Node) {
+		switch x := x.(type) {
+		case nil:
+			return
+		case *CDeclStmt:
+			if d, ok := x.Decl.(*CVarDecl); ok {
+				for _, name := range d.Names {
+					locals[name] = struct{}{}
+				}
+				n.addTypeRefs(d.Type)
+			}
+		case *CallExpr:
+			if id, ok := x.Fun.(Ident); ok {
+				ref(id.Identifier(), GraphCall)
+				for _, a := range x.Args {
+					v(a)
+				}
+				return
+			}
+		case *CSelectExpr:
+			// field names are not declarations
+			v(x.Expr)
+			return
+		case Ident:
+			ref(x.Identifier(), GraphRef)
+		}
+		if e, ok := x.(Expr); ok {
+			n.addTypeRefs(e.CType(nil))
+		}
+		x.Visit(v)
+	}
+	v(body)
+}
+
+// Decls returns a graph of declarations. Edges to declarations not added to the graph (libraries) are omitted.
+func (d *DepGraph) Decls() *Graph {
+	g := &Graph{Nodes: make([]GraphNode, 0, len(d.nodes))}
+	for _, n := range d.nodes {
+		g.Nodes = append(g.Nodes, n.GraphNode)
+		for _, r := range n.refs {
+			if _, ok := d.byName[r.name]; ok {
+				g.Edges = append(g.Edges, GraphEdge{From: n.Name, To: r.name, Kind: r.kind})
+			}
+		}
+	}
+	return g
+}
+
+// Files returns a graph of dependencies between C files. Edge weight is the number of declaration edges between files.
+// Declarations defined in both files (types from a common header) are not considered a dependency.
+func (d *DepGraph) Files() *Graph {
+	g := &Graph{}
+	edges := make(map[[2]string]int)
+	seen := make(map[string]struct{})
+	for _, n := range d.nodes {
+		if _, ok := seen[n.File]; !ok {
+			seen[n.File] = struct{}{}
+			g.Nodes = append(g.Nodes, GraphNode{Name: n.File, Kind: GraphFile})
+		}
+		for _, r := range n.refs {
+			dst, ok := d.byName[r.name]
+			if !ok {
+				continue
+			}
+			if _, local := dst.files[n.File]; local {
+				continue
+			}
+			edges[[2]string{n.File, dst.File}]++
+		}
+	}
+	for k, w := range edges {
+		g.Edges = append(g.Edges, GraphEdge{From: k[0], To: k[1], Kind: GraphDep, Weight: w})
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return g
+}
@@ -0,0 +1,80 @@
+package cxgo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func TestDepGraph(t *testing.T) {
+	files := []struct {
+		name string
+		src  string
+	}{
+		{"a.c", `
+typedef struct node { struct node* next; int v; } node;
+int total;
+static int helper(node* n) { return n->v; }
+int sum(node* n) {
+	int total = 0;
+	for (; n; n = n->next) total += helper(n);
+	return total;
+}
+`},
+		{"b.c", `
+typedef struct node { struct node* next; int v; } node;
+int sum(node* n);
+int (*fp)(node*) = sum;
+int main() { node x = {0, 1}; return sum(&x); }
+`},
+	}
+	p := NewProject(libs.NewEnv(types.Config32()))
+	dg := NewDepGraph()
+	for _, f := range files {
+		ast, err := ParseSource(p.Env(), ParseConfig{
+			Sources: []cc.Source{{Name: f.name, Value: f.src}},
+		})
+		require.NoError(t, err)
+		decls, err := p.TranslateCAST(f.name, ast, Config{})
+		require.NoError(t, err)
+		dg.Add(f.name, decls)
+	}
+	require.Equal(t, &Graph{
+		Nodes: []GraphNode{
+			{Name: "node", Kind: GraphType, File: "a.c"},
+			{Name: "total", Kind: GraphVar, File: "a.c"},
+			{Name: "helper", Kind: GraphFunc, File: "a.c"},
+			{Name: "sum", Kind: GraphFunc, File: "a.c"},
+			{Name: "fp", Kind: GraphVar, File: "b.c"},
+			{Name: "main", Kind: GraphFunc, File: "b.c"},
+		},
+		Edges: []GraphEdge{
+			{From: "helper", To: "node", Kind: GraphTypeRef},
+			{From: "sum", To: "node", Kind: GraphTypeRef},
+			{From: "sum", To: "helper", Kind: GraphCall},
+			{From: "fp", To: "node", Kind: GraphTypeRef},
+			{From: "fp", To: "sum", Kind: GraphRef},
+			{From: "main", To: "node", Kind: GraphTypeRef},
+			{From: "main", To: "sum", Kind: GraphCall},
+		},
+	}, dg.Decls())
+
+	fg := dg.Files()
+	require.Equal(t, []GraphEdge{
+		{From: "b.c", To: "a.c", Kind: GraphDep, Weight: 2},
+	}, fg.Edges)
+
+	var buf bytes.Buffer
+	require.NoError(t, fg.WriteDOT(&buf))
+	require.Equal(t, `digraph cxgo {
+	"a.c" [shape=folder];
+	"b.c" [shape=folder];
+	"b.c" -> "a.c" [label=2];
+}
+`, buf.String())
+}
@@ -0,0 +1,144 @@
+package cxgo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"modernc.org/cc/v3"
+)
+
+// hostPredefName is the name of the source with predefined macros of the host compiler.
+const hostPredefName = "cxgo_host_predef.h"
+
+// HostCompiler describes include directories and predefined macros of the C compiler installed on the host.
+// See DetectHostCompiler.
+type HostCompiler struct {
+	Command    []string // command used to run the compiler
+	Include    []string // directories searched for #include "..."
+	SysInclude []string // directories searched for #include <...>
+	Defines    []Define // predefined macros
+}
+
+// DetectHostCompiler runs the host C compiler to find its include directories and predefined macros.
+// The compiler is queried with "cc -E -v -dM", which is supported by GCC and Clang.
+//
+// The command may contain arguments, for example "ccache gcc" or "clang --target=x86_64-linux-gnu".
+// If it's empty, the CC environment variable is used, or the first of cc, clang and gcc found in PATH.
+func DetectHostCompiler(command string) (*HostCompiler, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		args = strings.Fields(os.Getenv("CC"))
+	}
+	if len(args) == 0 {
+		for _, name := range []string{"cc", "clang", "gcc"} {
+			if _, err := exec.LookPath(name); err == nil {
+				args = []string{name}
+				break
+			}
+		}
+		if len(args) == 0 {
+			return nil, errors.New("cannot find a host C compiler; set CC or install cc, clang or gcc")
+		}
+	}
+	cmd := exec.Command(args[0], append(args[1:len(args):len(args)], "-E", "-v", "-dM", "-x", "c", "-")...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader("")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w\n%s", strings.Join(args, " "), err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", strings.Join(args, " "), err)
+	}
+	h := parseHostCompiler(stdout.Bytes(), stderr.Bytes())
+	h.Command = args
+	if len(h.SysInclude) == 0 {
+		return nil, fmt.Errorf("%s: cannot find include directories in the compiler output", strings.Join(args, " "))
+	}
+	return h, nil
+}
+
+// parseHostCompiler parses macros printed by the compiler to stdout and the include search list printed to stderr.
+func parseHostCompiler(stdout, stderr []byte) *HostCompiler {
+	h := new(HostCompiler)
+	var cur *[]string
+	sc := bufio.NewScanner(bytes.NewReader(stderr))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, `#include "..." search starts here`):
+			cur = &h.Include
+		case strings.HasPrefix(line, `#include <...> search starts here`):
+			cur = &h.SysInclude
+		case strings.HasPrefix(line, "End of search list"):
+			cur = nil
+		case cur != nil && strings.HasPrefix(line, " "):
+			dir := strings.TrimSpace(line)
+			if strings.HasSuffix(dir, "(framework directory)") {
+				// macOS frameworks are not supported by the C parser
+				continue
+			}
+			*cur = append(*cur, dir)
+		}
+	}
+	sc = bufio.NewScanner(bytes.NewReader(stdout))
+	for sc.Scan() {
+		line, ok := strings.CutPrefix(strings.TrimRight(sc.Text(), "\r"), "#define ")
+		if !ok {
+			continue
+		}
+		name, val, _ := strings.Cut(line, " ")
+		if name == "" {
+			continue
+		}
+		h.Defines = append(h.Defines, Define{Name: name, Value: val})
+	}
+	sort.Slice(h.Defines, func(i, j int) bool {
+		return h.Defines[i].Name < h.Defines[j].Name
+	})
+	return h
+}
+
+// Predef returns C source that defines all predefined macros of the compiler. Macros that are already defined,
+// for example by cxgo or by the config, are not redefined.
+func (h *HostCompiler) Predef() string {
+	var buf strings.Builder
+	for _, d := range h.Defines {
+		name := d.Name
+		if i := strings.IndexByte(name, '('); i >= 0 {
+			name = name[:i]
+		}
+		fmt.Fprintf(&buf, "#ifndef %s\n#define %s %s\n#endif\n", name, d.Name, d.Value)
+	}
+	return buf.String()
+}
+
+// isHostMacro checks if the macro is predefined by the host compiler or defined in host headers.
+// These are never declared in Go, thus the value is always used instead.
+func (g *translator) isHostMacro(name string) bool {
+	if g.file == nil || (g.conf.HostPredef == "" && len(g.conf.HostSysInclude) == 0) {
+		return false
+	}
+	m := g.file.Macros[cc.String(name)]
+	if m == nil {
+		return false
+	}
+	fname := m.Position().Filename
+	if fname == hostPredefName {
+		return true
+	}
+	for _, dir := range g.conf.HostSysInclude {
+		if rel, err := filepath.Rel(dir, fname); err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package cxgo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func TestParseHostCompiler(t *testing.T) {
+	h := parseHostCompiler([]byte(`#define __STDC__ 1
+#define __x86_64__ 1
+#define __GNUC__ 12
+#define __has_include(x) 0
+`), []byte(`Using built-in specs.
+COLLECT_GCC=cc
+#include "..." search starts here:
+ /opt/local/include
+#include <...> search starts here:
+ /usr/lib/gcc/x86_64-linux-gnu/12/include
+ /usr/include
+ /System/Library/Frameworks (framework directory)
+End of search list.
+ /not/included
+`))
+	require.Equal(t, []string{"/opt/local/include"}, h.Include)
+	require.Equal(t, []string{"/usr/lib/gcc/x86_64-linux-gnu/12/include", "/usr/include"}, h.SysInclude)
+	require.Equal(t, []Define{
+		{Name: "__GNUC__", Value: "12"},
+		{Name: "__STDC__", Value: "1"},
+		{Name: "__has_include(x)", Value: "0"},
+		{Name: "__x86_64__", Value: "1"},
+	}, h.Defines)
+	require.Equal(t, `#ifndef __GNUC__
+#define __GNUC__ 12
+#endif
+#ifndef __STDC__
+#define __STDC__ 1
+#endif
+#ifndef __has_include
+#define __has_include(x) 0
+#endif
+#ifndef __x86_64__
+#define __x86_64__ 1
+#endif
+`, h.Predef())
+}
+
+func TestTranslateHostIncludes(t *testing.T) {
+	dir := t.TempDir()
+	cdir := filepath.Join(dir, "c")
+	hdir := filepath.Join(dir, "host")
+	out := filepath.Join(dir, "out")
+	require.NoError(t, os.MkdirAll(cdir, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(hdir, "sys"), 0755))
+	// bundled headers must take precedence
+	require.NoError(t, os.WriteFile(filepath.Join(hdir, "stdlib.h"), []byte(`#error host header used`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(hdir, "sys", "host.h"), []byte(`
+#define HOST_MAX 64
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(cdir, "a.c"), []byte(`#include <stdlib.h>
+#include <sys/host.h>
+#define LOCAL 2
+int f(void) { return HOST_MAX + HOST_VERSION + LOCAL; }
+`), 0644))
+	h := &HostCompiler{
+		SysInclude: []string{hdir},
+		Defines:    []Define{{Name: "HOST_VERSION", Value: "3"}},
+	}
+	err := Translate(cdir, filepath.Join(cdir, "a.c"), out, libs.NewEnv(types.Config32()), Config{
+		Package:        "lib",
+		HostSysInclude: h.SysInclude,
+		HostPredef:     h.Predef(),
+	})
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(out, "a.go"))
+	require.NoError(t, err)
+	require.Equal(t, `package lib
+
+const LOCAL = 2
+
+func f() int32 {
+	return int32(LOCAL + (64 + 3))
+}
+`, string(data))
+}
+
+func TestDetectHostCompiler(t *testing.T) {
+	if _, err := exec.LookPath("cc"); err != nil {
+		t.Skip("no host compiler")
+	}
+	h, err := DetectHostCompiler("cc")
+	require.NoError(t, err)
+	require.Equal(t, []string{"cc"}, h.Command)
+	require.NotEmpty(t, h.SysInclude)
+	require.NotEmpty(t, h.Defines)
+}
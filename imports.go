@@ -0,0 +1,67 @@
+package cxgo
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// ReplaceImportPath replaces the longest matching import path prefix with a value from the paths map; see Config.ImportPaths.
+// Prefixes only match whole path elements.
+func ReplaceImportPath(paths map[string]string, path string) string {
+	best := ""
+	for pref := range paths {
+		if path != pref && !strings.HasPrefix(path, pref+"/") {
+			continue
+		}
+		if len(pref) > len(best) {
+			best = pref
+		}
+	}
+	if best == "" {
+		return path
+	}
+	return paths[best] + strings.TrimPrefix(path, best)
+}
+
+// renameImports renames package references in the declarations according to import aliases; see Config.ImportAliases.
+func renameImports(aliases map[string]string, decls []GoDecl) {
+	if len(aliases) == 0 {
+		return
+	}
+	seen := make(map[*ast.Ident]struct{})
+	for _, d := range decls {
+		ast.Inspect(d, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if _, ok = seen[id]; ok {
+				return true
+			}
+			seen[id] = struct{}{}
+			sub := strings.SplitN(id.Name, ".", 2)
+			if len(sub) != 2 {
+				return true
+			}
+			if alias, ok := aliases[sub[0]]; ok {
+				id.Name = alias + "." + sub[1]
+			}
+			return true
+		})
+	}
+}
+
+// usedImportPaths returns sorted import paths of packages used by declarations.
+func usedImportPaths(e *libs.Env, decls []GoDecl) []string {
+	used := make(map[string]struct{})
+	goUsedImports(used, decls)
+	var list []string
+	for name := range used {
+		list = append(list, e.ResolveImport(name))
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,129 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// initCycles moves initializers of global variables that refer to themselves to a generated init function.
+//
+// C allows initializers to take addresses of any globals, including the variable being initialized, as well as
+// functions that use it. Go rejects these as initialization cycles:
+//
+//	var self node = node{next: &self}
+//
+// thus the variable is declared without a value, and it is assigned in init:
+//
+//	var self node
+//
+//	func init() {
+//		self = node{next: &self}
+//	}
+//
+// Other initializers are left as is, since Go orders them by dependencies on its own. Variables are moved one by one
+// in the declaration order, and moving one of them may break the cycle for the others. Moved initializers are
+// assigned in the order of dependencies between them. Only cycles between declarations of the same file are found.
+func initCycles(decls []GoDecl) []GoDecl {
+	refs := make(map[string]map[string]struct{}) // names referenced by each function or variable initializer
+	var specs []*ast.ValueSpec                   // global variables that can be moved
+	for _, d := range decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				refs[d.Name.Name] = goDeclRefs(d)
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.VAR {
+				continue
+			}
+			for _, s := range d.Specs {
+				vs, ok := s.(*ast.ValueSpec)
+				if !ok || len(vs.Values) != len(vs.Names) {
+					continue
+				}
+				movable := vs.Type != nil
+				for i, name := range vs.Names {
+					if name.Name == "_" {
+						movable = false
+						continue
+					}
+					refs[name.Name] = goDeclRefs(vs.Values[i])
+				}
+				if movable {
+					specs = append(specs, vs)
+				}
+			}
+		}
+	}
+	var (
+		moved []string
+		vals  = make(map[string]ast.Expr)
+	)
+	for _, vs := range specs {
+		// variables of a single spec can only be moved together
+		cycle := false
+		for _, name := range vs.Names {
+			if refersTo(refs, name.Name) {
+				cycle = true
+			}
+		}
+		if !cycle {
+			continue
+		}
+		for i, name := range vs.Names {
+			moved = append(moved, name.Name)
+			vals[name.Name] = vs.Values[i]
+			delete(refs, name.Name)
+		}
+		vs.Values = nil
+	}
+	if len(moved) == 0 {
+		return decls
+	}
+	// assign dependencies first
+	var (
+		stmts []GoStmt
+		seen  = make(map[string]struct{})
+		visit func(name string)
+	)
+	visit = func(name string) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		for ref := range goDeclRefs(vals[name]) {
+			if _, ok := vals[ref]; ok {
+				visit(ref)
+			}
+		}
+		stmts = append(stmts, assign(ident(name), vals[name]))
+	}
+	for _, name := range moved {
+		visit(name)
+	}
+	return append(decls, &ast.FuncDecl{
+		Name: ident("init"),
+		Type: &ast.FuncType{Params: &ast.FieldList{}},
+		Body: &ast.BlockStmt{List: stmts},
+	})
+}
+
+// refersTo checks if the initializer of a variable refers to it, directly or through other declarations.
+func refersTo(refs map[string]map[string]struct{}, name string) bool {
+	seen := make(map[string]struct{})
+	queue := []string{name}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for ref := range refs[cur] {
+			if ref == name {
+				return true
+			}
+			if _, ok := seen[ref]; !ok {
+				seen[ref] = struct{}{}
+				queue = append(queue, ref)
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package cxgo
+
+import (
+	"go/ast"
+	"strings"
+
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// layoutInlineFile is a shared file for inline functions defined in headers; see Config.InlineHeaders.
+const layoutInlineFile = "inline.go"
+
+// isHeaderInline checks if the function is an inline function defined in a project header, which is translated
+// once per project, if Config.InlineHeaders is set. This includes the translation of the header itself, thus
+// the function is not declared twice. Bundled and system headers are never translated.
+func (g *translator) isHeaderInline(d *cc.Declarator) bool {
+	if !g.conf.InlineHeaders || !d.Type().Inline() {
+		return false
+	}
+	name := strings.TrimLeft(d.Position().Filename, "./")
+	if strings.HasSuffix(name, ".c") || strings.HasPrefix(name, strings.TrimLeft(libs.IncludePath, "/")) {
+		return false
+	}
+	for _, dirs := range [][]string{g.conf.SysInclude, g.conf.HostSysInclude} {
+		for _, dir := range dirs {
+			if dir = strings.TrimLeft(dir, "./"); dir != "" && strings.HasPrefix(name, dir) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// splitInline moves inline functions from headers to the shared file of the project, which is written by Flush.
+// Each function is only kept the first time it is translated, thus files including the same header don't redeclare it.
+func (p *TranslatorProject) splitInline(f pendingFile, tr *translation) []GoDecl {
+	if len(tr.inlines) == 0 {
+		return f.decls
+	}
+	names := make(map[string]struct{}, len(tr.inlines))
+	for _, name := range tr.inlines {
+		names[name] = struct{}{}
+	}
+	if p.inline == nil {
+		f.conf.source = "" // shared by all files
+		p.inline = &pendingFile{out: f.out, gofile: layoutInlineFile, pkg: f.pkg, conf: f.conf}
+		p.inlines = make(map[string]struct{})
+	}
+	var out []GoDecl
+	for _, d := range f.decls {
+		fd, ok := d.(*ast.FuncDecl)
+		if !ok || fd.Recv != nil {
+			out = append(out, d)
+			continue
+		}
+		if _, ok := names[fd.Name.Name]; !ok {
+			out = append(out, d)
+			continue
+		}
+		if _, dup := p.inlines[fd.Name.Name]; !dup {
+			p.inlines[fd.Name.Name] = struct{}{}
+			p.inline.decls = append(p.inline.decls, d)
+		}
+	}
+	return out
+}
+
+// flushInline writes the shared file with inline functions from headers.
+func (p *TranslatorProject) flushInline() error {
+	f := p.inline
+	p.inline, p.inlines = nil, nil
+	if f == nil || len(f.decls) == 0 {
+		return nil
+	}
+	if f.conf.TreeShake {
+		p.pending = append(p.pending, *f)
+		return nil
+	}
+	return p.writeFile(f.out, f.gofile, f.pkg, f.decls, f.conf)
+}
@@ -0,0 +1,116 @@
+// Package ir exposes the intermediate representation used by cxgo for tools and AST hooks.
+//
+// The translator converts the C AST into declarations (Decl), statements (Stmt) and expressions (Expr)
+// that keep C semantics, but already follow Go typing rules. Those are later printed as Go code.
+//
+// Types declared here are aliases for the types of the cxgo package, thus values can be passed between
+// the two packages freely. Only the node types listed here are considered stable: other node types
+// returned by the translator (conversion helpers, pointer arithmetic, etc.) are implementation details
+// and should be handled via the Decl, Stmt and Expr interfaces.
+package ir
+
+import (
+	"io"
+
+	"github.com/gotranspile/cxgo"
+)
+
+type (
+	// Node is implemented by all IR nodes.
+	Node = cxgo.Node
+	// Visitor is called for child nodes by Node.Visit.
+	Visitor = cxgo.Visitor
+
+	// Decl is a top-level declaration.
+	Decl = cxgo.CDecl
+	// Stmt is a statement.
+	Stmt = cxgo.CStmt
+	// Expr is an expression.
+	Expr = cxgo.Expr
+	// Ident is an expression referring to a named declaration.
+	Ident = cxgo.Ident
+	// BoolExpr is an expression of a boolean type.
+	BoolExpr = cxgo.BoolExpr
+)
+
+// Declarations.
+type (
+	FuncDecl = cxgo.CFuncDecl
+	VarDecl  = cxgo.CVarDecl
+	VarSpec  = cxgo.CVarSpec
+	TypeDef  = cxgo.CTypeDef
+)
+
+// Statements.
+type (
+	BlockStmt    = cxgo.BlockStmt
+	DeclStmt     = cxgo.CDeclStmt
+	ExprStmt     = cxgo.CExprStmt
+	AssignStmt   = cxgo.CAssignStmt
+	IncrStmt     = cxgo.CIncrStmt
+	IfStmt       = cxgo.CIfStmt
+	ForStmt      = cxgo.CForStmt
+	RangeStmt    = cxgo.CRangeStmt
+	SwitchStmt   = cxgo.CSwitchStmt
+	CaseStmt     = cxgo.CCaseStmt
+	ReturnStmt   = cxgo.CReturnStmt
+	BreakStmt    = cxgo.CBreakStmt
+	ContinueStmt = cxgo.CContinueStmt
+	GotoStmt     = cxgo.CGotoStmt
+	LabelStmt    = cxgo.CLabelStmt
+)
+
+// Expressions.
+type (
+	IdentExpr   = cxgo.IdentExpr
+	FuncIdent   = cxgo.FuncIdent
+	CallExpr    = cxgo.CallExpr
+	BinaryExpr  = cxgo.CBinaryExpr
+	UnaryExpr   = cxgo.CUnaryExpr
+	AssignExpr  = cxgo.CAssignExpr
+	CastExpr    = cxgo.CCastExpr
+	IndexExpr   = cxgo.CIndexExpr
+	SelectExpr  = cxgo.CSelectExpr
+	TernaryExpr = cxgo.CTernaryExpr
+	ParenExpr   = cxgo.CParentExpr
+	CompLitExpr = cxgo.CCompLitExpr
+	SizeofExpr  = cxgo.CSizeofExpr
+	Comparison  = cxgo.Comparison
+	IntLit      = cxgo.IntLit
+	FloatLit    = cxgo.FloatLit
+	StringLit   = cxgo.StringLit
+	Literal     = cxgo.CLiteral
+
+	BinaryOp     = cxgo.BinaryOp
+	UnaryOp      = cxgo.UnaryOp
+	ComparisonOp = cxgo.ComparisonOp
+)
+
+// Cursor describes a node encountered during Rewrite and allows to replace it.
+type Cursor = cxgo.Cursor
+
+// RewriteFunc is called by Rewrite for each node.
+type RewriteFunc = cxgo.RewriteFunc
+
+// Walk traverses the IR in depth-first order, similar to ast.Inspect; see cxgo.Walk.
+func Walk(n Node, f func(n Node) bool) {
+	cxgo.Walk(n, f)
+}
+
+// Rewrite traverses the IR, similar to astutil.Apply, and returns the root node, which might be replaced; see cxgo.Rewrite.
+func Rewrite(root Node, pre, post RewriteFunc) Node {
+	return cxgo.Rewrite(root, pre, post)
+}
+
+// HookFunc is called with IR declarations of each translated file; see Register.
+type HookFunc = cxgo.ASTHookCFunc
+
+// Register adds a hook that is called for each translated file when Config.Hooks is set.
+func Register(fnc HookFunc) {
+	cxgo.RegisterASTHookC(fnc)
+}
+
+// Dump writes declarations as an indented tree of nodes. The format is intended for humans and may change.
+func Dump(w io.Writer, decls []Decl) error {
+	return cxgo.DumpIR(w, decls)
+}
@@ -0,0 +1,197 @@
+package cxgo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// DumpIR writes the intermediate representation of declarations as an indented tree.
+//
+// Each node is written as its type name, followed by the C type for expressions, and its exported fields.
+// Identifiers and types are written by name, while nodes without exported fields (literals, for example)
+// are written as the Go code they produce.
+func DumpIR(w io.Writer, decls []CDecl) error {
+	d := &irDumper{w: bufio.NewWriter(w)}
+	for _, decl := range decls {
+		d.value(0, "", reflect.ValueOf(decl))
+		d.w.WriteByte('\n')
+	}
+	return d.w.Flush()
+}
+
+var namedType = reflect.TypeOf((*types.Named)(nil)).Elem()
+
+type irDumper struct {
+	w *bufio.Writer
+}
+
+func (d *irDumper) line(depth int, name, s string) {
+	indent := strings.Repeat("  ", depth)
+	d.w.WriteString(indent)
+	if name != "" {
+		d.w.WriteString(name + ":")
+		if s != "" {
+			d.w.WriteByte(' ')
+		}
+	}
+	// multi-line values, like struct types, are indented at the same level
+	d.w.WriteString(strings.ReplaceAll(s, "\n", "\n"+indent))
+	d.w.WriteByte('\n')
+}
+
+// value writes a field value with a given name. Empty values are omitted.
+func (d *irDumper) value(depth int, name string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch x := v.Interface().(type) {
+	case Node:
+		d.node(depth, name, v)
+		return
+	case *types.Ident:
+		d.line(depth, name, x.String())
+		return
+	case types.Type:
+		d.line(depth, name, irTypeString(x))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return
+		}
+		d.line(depth, name, "")
+		for i := 0; i < v.Len(); i++ {
+			d.value(depth+1, "", v.Index(i))
+		}
+	case reflect.Ptr:
+		if v.Elem().Kind() == reflect.Struct {
+			d.line(depth, name, v.Elem().Type().Name())
+			d.fields(depth+1, v.Elem())
+		}
+	case reflect.Struct:
+		d.line(depth, name, v.Type().Name())
+		d.fields(depth+1, v)
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !v.IsZero() {
+			d.line(depth, name, fmt.Sprint(v.Interface()))
+		}
+	}
+}
+
+func (d *irDumper) node(depth int, name string, v reflect.Value) {
+	sv := v
+	if sv.Kind() == reflect.Ptr {
+		sv = sv.Elem()
+	}
+	n := v.Interface()
+	head := sv.Type().Name()
+	id, isIdent := n.(Ident)
+	e, isExpr := n.(Expr)
+	if isIdent {
+		head += " " + id.Identifier().String()
+	} else if isExpr && !irHasFields(sv.Type()) {
+		head += " " + irExprString(e)
+	}
+	if isExpr {
+		if t := irExprType(e); t != "" {
+			head += " (" + t + ")"
+		}
+	}
+	d.line(depth, name, head)
+	if !isIdent && sv.Kind() == reflect.Struct {
+		d.fields(depth+1, sv)
+	}
+}
+
+func (d *irDumper) fields(depth int, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && f.Type == namedType {
+			// type declarations, like CTypeDef; write the underlying type as well
+			if n, ok := v.Field(i).Interface().(types.Named); ok {
+				d.line(depth, "Name", n.Name().String())
+				d.line(depth, "Type", irGoString(func() any { return n.Underlying().GoType() }))
+			}
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			// embedded specs, like CVarSpec in CVarDecl; write them as fields of the parent
+			d.fields(depth, v.Field(i))
+			continue
+		}
+		d.value(depth, f.Name, v.Field(i))
+	}
+}
+
+func irHasFields(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+// irGoString prints a Go node. It returns an empty string if the node cannot be printed.
+func irGoString(fnc func() any) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = ""
+		}
+	}()
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), fnc()); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func irTypeString(t types.Type) string {
+	if n, ok := t.(types.Named); ok {
+		return n.Name().String()
+	}
+	return irGoString(func() any { return t.GoType() })
+}
+
+func irExprString(e Expr) string {
+	return irGoString(func() any { return e.AsExpr() })
+}
+
+// irExprType returns the C type of an expression. Some expressions cannot determine their type without the context,
+// in which case an empty string is returned.
+func irExprType(e Expr) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = ""
+		}
+	}()
+	t := e.CType(nil)
+	if t == nil {
+		return ""
+	}
+	return irTypeString(t)
+}
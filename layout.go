@@ -0,0 +1,167 @@
+package cxgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"maps"
+	"path/filepath"
+	"sort"
+)
+
+// OutputLayout controls how generated declarations are split into Go files.
+type OutputLayout string
+
+const (
+	// LayoutDefault writes one Go file per C file, optionally split by Config.MaxDecls.
+	LayoutDefault = OutputLayout("")
+	// LayoutByKind writes types, constants and global variables to types.go, consts.go and vars.go,
+	// while functions are written to a Go file corresponding to the C file.
+	//
+	// Shared files accumulate declarations from all files translated by the same TranslatorProject.
+	LayoutByKind = OutputLayout("kind")
+)
+
+const (
+	layoutTypesFile  = "types.go"
+	layoutConstsFile = "consts.go"
+	layoutVarsFile   = "vars.go"
+)
+
+// layoutKindFile returns a shared file name for a declaration, or empty string if the declaration
+// must be written to the file corresponding to the C source.
+func layoutKindFile(d GoDecl) string {
+	gd, ok := d.(*ast.GenDecl)
+	if !ok {
+		return ""
+	}
+	switch gd.Tok {
+	case token.TYPE:
+		return layoutTypesFile
+	case token.CONST:
+		return layoutConstsFile
+	case token.VAR:
+		return layoutVarsFile
+	}
+	return ""
+}
+
+func (p *TranslatorProject) writeByKind(out, gofile, pkg string, decls []GoDecl, conf Config) error {
+	if p.shared == nil {
+		p.shared = make(map[string][]GoDecl)
+	}
+	sconf, err := p.sharedConfig(conf)
+	if err != nil {
+		return err
+	}
+	var funcs []GoDecl
+	for _, d := range decls {
+		if name := layoutKindFile(d); name != "" {
+			p.shared[name] = append(p.shared[name], d)
+		} else {
+			funcs = append(funcs, d)
+		}
+	}
+	for _, name := range []string{layoutTypesFile, layoutConstsFile, layoutVarsFile} {
+		list := p.shared[name]
+		if len(list) == 0 {
+			continue
+		}
+		if err := writeGoFile(p.env, filepath.Join(out, name), pkg, list, sconf); err != nil {
+			return err
+		}
+	}
+	if len(funcs) == 0 {
+		return nil
+	}
+	gopath := gofile
+	if !filepath.IsAbs(gopath) {
+		gopath = filepath.Join(out, gopath)
+	}
+	return writeGoFile(p.env, gopath, pkg, funcs, conf)
+}
+
+// sharedConfig returns the config for shared files, which is the config of the first file written by the project.
+// Shared files contain declarations of all files, thus settings that change the content of Go files must be the same.
+func (p *TranslatorProject) sharedConfig(conf Config) (Config, error) {
+	conf.source = "" // shared by all files
+	if p.sharedConf == nil {
+		p.sharedConf = &conf
+		return conf, nil
+	}
+	if name := outputConfigDiff(*p.sharedConf, conf); name != "" {
+		return Config{}, fmt.Errorf("%s must be the same for all files written to shared files of the %q layout", name, LayoutByKind)
+	}
+	return *p.sharedConf, nil
+}
+
+// outputConfigDiff returns the name of the first setting that changes the content of Go files and is different in two configs,
+// or an empty string if there is none.
+func outputConfigDiff(a, b Config) string {
+	switch {
+	case !sameReplacers(a.Replace, b.Replace):
+		return "Replace"
+	case a.DoNotEdit != b.DoNotEdit:
+		return "DoNotEdit"
+	case a.Header != b.Header:
+		return "Header"
+	case a.CgoPreamble != b.CgoPreamble:
+		return "CgoPreamble"
+	case !maps.Equal(a.ImportPaths, b.ImportPaths):
+		return "ImportPaths"
+	case !maps.Equal(a.ImportAliases, b.ImportAliases):
+		return "ImportAliases"
+	}
+	return ""
+}
+
+func sameReplacers(a, b []Replacer) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Old != b[i].Old || a[i].New != b[i].New || (a[i].Re == nil) != (b[i].Re == nil) {
+			return false
+		}
+		if a[i].Re != nil && a[i].Re.String() != b[i].Re.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// DeclOrder controls the order of declarations in generated Go files.
+type DeclOrder string
+
+const (
+	// OrderSource keeps declarations in the order they appear in the C source.
+	// Constants generated from macros are emitted first.
+	OrderSource = DeclOrder("")
+	// OrderName sorts declarations by their Go name.
+	OrderName = DeclOrder("name")
+)
+
+// sortDecls sorts declarations according to a given order. The sort is stable.
+func sortDecls(decls []GoDecl, order DeclOrder) error {
+	switch order {
+	case OrderSource:
+		return nil
+	case OrderName:
+		sort.SliceStable(decls, func(i, j int) bool {
+			return goDeclSortName(decls[i]) < goDeclSortName(decls[j])
+		})
+		return nil
+	default:
+		return fmt.Errorf("unsupported declaration order: %q", order)
+	}
+}
+
+func goDeclSortName(d GoDecl) string {
+	if recv := goRecvName(d); recv != "" {
+		return recv + "." + d.(*ast.FuncDecl).Name.Name
+	}
+	if names := goDeclNames(d); len(names) != 0 {
+		return names[0]
+	}
+	return ""
+}
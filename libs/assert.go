@@ -20,7 +20,7 @@ func init() {
 			},
 			Header: `
 #include <` + stdboolH + `>
-#define static_assert(x, y) /* x, y */
+#define static_assert _Static_assert
 `,
 			Idents: map[string]*types.Ident{
 				"_Static_assert": c.NewIdent("_Static_assert", "libc.StaticAssert", staticAssert, c.FuncTT(nil, types.BoolT(), strT)),
@@ -2,6 +2,7 @@ package libs
 
 import (
 	"fmt"
+	"math/bits"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -74,6 +75,16 @@ func init() {
 #define _ILP32_ 1
 `
 		}
+		if c.BigEndian() {
+			pre += "#define __BYTE_ORDER__ __ORDER_BIG_ENDIAN__\n"
+		} else {
+			pre += "#define __BYTE_ORDER__ __ORDER_LITTLE_ENDIAN__\n"
+		}
+		pre += fmt.Sprintf(`
+#define __SIZEOF_INT__ %d
+#define __SIZEOF_LONG__ %d
+#define __SIZEOF_POINTER__ %d
+`, c.IntSize(), lsz, psz)
 		var post strings.Builder
 		maxIntTypeDefs(&post, "ptr", c.PtrSize()*8)
 		post.WriteString(`
@@ -86,7 +97,6 @@ typedef uintptr_t size_t;
 			Header: pre + `
 #define __ORDER_BIG_ENDIAN__ 4321
 #define __ORDER_LITTLE_ENDIAN__ 1234
-#define __BYTE_ORDER__ __ORDER_LITTLE_ENDIAN__
 
 #define __CXGO__
 #define __linux__
@@ -160,6 +170,10 @@ void* malloc(_cxgo_go_int);
 				"libc":   RuntimeLibc,
 				"stdio":  RuntimePrefix + "stdio", // for printf
 				"atomic": "sync/atomic",
+				"bits":   "math/bits",
+				"binary": "encoding/binary",
+				"base64": "encoding/base64",
+				"ccover": RuntimePrefix + "ccover",
 			},
 			Types: map[string]types.Type{
 				"__builtin_va_list": valistT,
@@ -198,17 +212,30 @@ void* malloc(_cxgo_go_int);
 			c.NewIdent("__sync_fetch_and_and", "libc.LoadAndInt32", libc.LoadAndInt32, c.FuncTT(int32T, c.PtrT(int32T), int32T)),
 			c.NewIdent("__sync_fetch_and_xor", "libc.LoadXorInt32", libc.LoadXorInt32, c.FuncTT(int32T, c.PtrT(int32T), int32T)),
 			c.NewIdent("__sync_fetch_and_nand", "libc.LoadNandInt32", libc.LoadNandInt32, c.FuncTT(int32T, c.PtrT(int32T), int32T)),
-			c.NewIdent("__builtin_printf", "stdio.Printf", stdio.Printf, c.VarFuncTT(c.Go().Int(), c.Go().String())),
 			c.NewIdent("_cxgo_va_copy", "libc.ArgCopy", libc.ArgCopy, c.FuncTT(nil, valistPtr, valistPtr)),
-			c.NewIdent("printf", "stdio.Printf", stdio.Printf, c.VarFuncTT(c.Go().Int(), c.Go().String())),
+			c.NewIdent("__builtin_bswap16", "bits.ReverseBytes16", bits.ReverseBytes16, c.FuncTT(types.UintT(2), types.UintT(2))),
+			c.NewIdent("__builtin_bswap32", "bits.ReverseBytes32", bits.ReverseBytes32, c.FuncTT(types.UintT(4), types.UintT(4))),
+			c.NewIdent("__builtin_bswap64", "bits.ReverseBytes64", bits.ReverseBytes64, c.FuncTT(types.UintT(8), types.UintT(8))),
 		)
+		printfT := c.VarFuncTT(c.Go().Int(), c.Go().String())
+		if c.Stdout == StdoutBuffered {
+			l.Declare(
+				c.NewIdent("__builtin_printf", "stdio.BufferedPrintf", stdio.BufferedPrintf, printfT),
+				c.NewIdent("printf", "stdio.BufferedPrintf", stdio.BufferedPrintf, printfT),
+			)
+		} else {
+			l.Declare(
+				c.NewIdent("__builtin_printf", "stdio.Printf", stdio.Printf, printfT),
+				c.NewIdent("printf", "stdio.Printf", stdio.Printf, printfT),
+			)
+		}
 		l.Header += `
 #define _cxgo_go_make(type, ...) _cxgo_go_make_impl((type)(0x1), __VA_ARGS__)
 #define _cxgo_go_make_same(arr, ...) _cxgo_go_make_impl(arr, __VA_ARGS__)
 
 _cxgo_go_int _cxgo_offsetof(_cxgo_go_any, _cxgo_go_string);
-#define __builtin_offsetof(type, member) _cxgo_offsetof((type)0, "#member")
-#define offsetof(type, member) _cxgo_offsetof((type)0, "#member")
+#define __builtin_offsetof(type, member) _cxgo_offsetof((type)0, #member)
+#define offsetof(type, member) _cxgo_offsetof((type)0, #member)
 
 #define __builtin_abort() _cxgo_go_panic("abort")
 #define __builtin_trap() _cxgo_go_panic("trap")
@@ -225,7 +252,9 @@ _cxgo_go_int _cxgo_offsetof(_cxgo_go_any, _cxgo_go_string);
 #define __builtin_va_copy(dst, src) _cxgo_va_copy(&dst, &src)
 void __builtin_va_arg_pack();
 
-#define _Static_assert(x, y) /* x, y */
+#define _cxgo_static_assert_name2(n) _cxgo_static_assert_##n
+#define _cxgo_static_assert_name(n) _cxgo_static_assert_name2(n)
+#define _Static_assert(x, ...) const char* _cxgo_static_assert_name(__COUNTER__) = (x) ? "" __VA_ARGS__ : ""
 #define __builtin_expect(x, y) ((x) == (y))
 
 #define NULL 0
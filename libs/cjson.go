@@ -0,0 +1,286 @@
+package libs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotranspile/cxgo/runtime/cjson"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	cjsonH      = "cJSON.h"
+	cjsonDirH   = "cjson/cJSON.h"
+	jsoncH      = "json-c/json.h"
+	jsoncObjH   = "json-c/json_object.h"
+	jsoncTokenH = "json-c/json_tokener.h"
+)
+
+func init() {
+	RegisterLibrary(cjsonH, func(c *Env) *Library {
+		gintT := c.Go().Int()
+		gstrT := c.Go().String()
+		strT := c.C().String()
+		boolT := types.BoolT()
+		intT := types.IntT(4)
+		dblT := types.FloatT(8)
+		itemPtrT := c.PtrT(nil)
+		itemT := types.NamedTGo("cJSON", "cjson.JSON", types.StructT([]*types.Field{
+			{Name: types.NewIdentGo("next", "Next", itemPtrT)},
+			{Name: types.NewIdentGo("prev", "Prev", itemPtrT)},
+			{Name: types.NewIdentGo("child", "Child", itemPtrT)},
+			{Name: types.NewIdentGo("type", "Type", intT)},
+			{Name: types.NewIdentGo("valuestring", "ValueString", strT)},
+			{Name: types.NewIdentGo("valueint", "ValueInt", intT)},
+			{Name: types.NewIdentGo("valuedouble", "ValueDouble", dblT)},
+			{Name: types.NewIdentGo("string", "String", strT)},
+		}))
+		itemPtrT.SetElem(itemT)
+
+		var hdr strings.Builder
+		hdr.WriteString(`
+#include <` + stdboolH + `>
+#include <` + stddefH + `>
+
+#define CJSON_VERSION_MAJOR 1
+#define CJSON_VERSION_MINOR 7
+#define CJSON_VERSION_PATCH 15
+
+#define cJSON_bool bool
+
+typedef struct cJSON {
+	struct cJSON* next;
+	struct cJSON* prev;
+	struct cJSON* child;
+	_cxgo_sint32 type;
+	char* valuestring;
+	_cxgo_sint32 valueint;
+	double valuedouble;
+	char* string;
+} cJSON;
+
+enum {
+`)
+		idents := make(map[string]*types.Ident)
+		for _, v := range []struct {
+			name  string
+			value int
+		}{
+			{"cJSON_Invalid", cjson.CJSON_Invalid},
+			{"cJSON_False", cjson.CJSON_False},
+			{"cJSON_True", cjson.CJSON_True},
+			{"cJSON_NULL", cjson.CJSON_NULL},
+			{"cJSON_Number", cjson.CJSON_Number},
+			{"cJSON_String", cjson.CJSON_String},
+			{"cJSON_Array", cjson.CJSON_Array},
+			{"cJSON_Object", cjson.CJSON_Object},
+			{"cJSON_Raw", cjson.CJSON_Raw},
+			{"cJSON_IsReference", cjson.CJSON_IsReference},
+			{"cJSON_StringIsConst", cjson.CJSON_StringIsConst},
+			{"CJSON_NESTING_LIMIT", cjson.CJSON_NESTING_LIMIT},
+		} {
+			idents[v.name] = c.NewIdent(v.name, "cjson."+strings.ToUpper(v.name[:1])+v.name[1:], v.value, types.AsUntypedIntT(intT))
+			fmt.Fprintf(&hdr, "\t%s = %d,\n", v.name, v.value)
+		}
+		hdr.WriteString(`};
+
+#define cJSON_ArrayForEach(element, array) for (element = cJSON_GetArrayItem(array, 0); element != NULL; element = element->next)
+#define cJSON_SetNumberValue(object, number) cJSON_SetNumberHelper(object, (double)(number))
+#define cJSON_SetIntValue(object, number) cJSON_SetNumberHelper(object, (double)(number))
+#define cJSON_malloc malloc
+`)
+		l := &Library{
+			Imports: map[string]string{
+				"cjson": RuntimePrefix + "cjson",
+			},
+			Types: map[string]types.Type{
+				"cJSON": itemT,
+			},
+			Idents: idents,
+			Header: hdr.String(),
+		}
+		l.Declare(
+			c.NewIdent("cJSON_Version", "cjson.CJSONVersion", cjson.CJSONVersion, c.FuncTT(strT)),
+			c.NewIdent("cJSON_Parse", "cjson.Parse", cjson.Parse, c.FuncTT(itemPtrT, strT)),
+			c.NewIdent("cJSON_ParseWithLength", "cjson.ParseWithLength", cjson.ParseWithLength, c.FuncTT(itemPtrT, strT, gintT)),
+			c.NewIdent("cJSON_GetErrorPtr", "cjson.GetErrorPtr", cjson.GetErrorPtr, c.FuncTT(strT)),
+			c.NewIdent("cJSON_Print", "cjson.Print", cjson.Print, c.FuncTT(strT, itemPtrT)),
+			c.NewIdent("cJSON_PrintUnformatted", "cjson.PrintUnformatted", cjson.PrintUnformatted, c.FuncTT(strT, itemPtrT)),
+			c.NewIdent("cJSON_Minify", "cjson.Minify", cjson.Minify, c.FuncTT(nil, strT)),
+			c.NewIdent("cJSON_Delete", "cjson.Delete", cjson.Delete, c.FuncTT(nil, itemPtrT)),
+			c.NewIdent("cJSON_free", "cjson.Free", cjson.Free, c.FuncTT(nil, c.PtrT(nil))),
+
+			c.NewIdent("cJSON_GetArraySize", "cjson.GetArraySize", cjson.GetArraySize, c.FuncTT(intT, itemPtrT)),
+			c.NewIdent("cJSON_GetArrayItem", "cjson.GetArrayItem", cjson.GetArrayItem, c.FuncTT(itemPtrT, itemPtrT, intT)),
+			c.NewIdent("cJSON_GetObjectItem", "cjson.GetObjectItem", cjson.GetObjectItem, c.FuncTT(itemPtrT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_GetObjectItemCaseSensitive", "cjson.GetObjectItemCaseSensitive", cjson.GetObjectItemCaseSensitive, c.FuncTT(itemPtrT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_HasObjectItem", "cjson.HasObjectItem", cjson.HasObjectItem, c.FuncTT(boolT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_GetStringValue", "cjson.GetStringValue", cjson.GetStringValue, c.FuncTT(strT, itemPtrT)),
+			c.NewIdent("cJSON_GetNumberValue", "cjson.GetNumberValue", cjson.GetNumberValue, c.FuncTT(dblT, itemPtrT)),
+
+			c.NewIdent("cJSON_IsInvalid", "cjson.IsInvalid", cjson.IsInvalid, c.FuncTT(boolT, itemPtrT)),
+			c.NewIdent("cJSON_IsFalse", "cjson.IsFalse", cjson.IsFalse, c.FuncTT(boolT, itemPtrT)),
+			c.NewIdent("cJSON_IsTrue", "cjson.IsTrue", cjson.IsTrue, c.FuncTT(boolT, itemPtrT)),
+			c.NewIdent("cJSON_IsBool", "cjson.IsBool", cjson.IsBool, c.FuncTT(boolT, itemPtrT)),
+			c.NewIdent("cJSON_IsNull", "cjson.IsNull", cjson.IsNull, c.FuncTT(boolT, itemPtrT)),
+			c.NewIdent("cJSON_IsNumber", "cjson.IsNumber", cjson.IsNumber, c.FuncTT(boolT, itemPtrT)),
+			c.NewIdent("cJSON_IsString", "cjson.IsString", cjson.IsString, c.FuncTT(boolT, itemPtrT)),
+			c.NewIdent("cJSON_IsArray", "cjson.IsArray", cjson.IsArray, c.FuncTT(boolT, itemPtrT)),
+			c.NewIdent("cJSON_IsObject", "cjson.IsObject", cjson.IsObject, c.FuncTT(boolT, itemPtrT)),
+			c.NewIdent("cJSON_IsRaw", "cjson.IsRaw", cjson.IsRaw, c.FuncTT(boolT, itemPtrT)),
+
+			c.NewIdent("cJSON_CreateNull", "cjson.CreateNull", cjson.CreateNull, c.FuncTT(itemPtrT)),
+			c.NewIdent("cJSON_CreateTrue", "cjson.CreateTrue", cjson.CreateTrue, c.FuncTT(itemPtrT)),
+			c.NewIdent("cJSON_CreateFalse", "cjson.CreateFalse", cjson.CreateFalse, c.FuncTT(itemPtrT)),
+			c.NewIdent("cJSON_CreateBool", "cjson.CreateBool", cjson.CreateBool, c.FuncTT(itemPtrT, boolT)),
+			c.NewIdent("cJSON_CreateNumber", "cjson.CreateNumber", cjson.CreateNumber, c.FuncTT(itemPtrT, dblT)),
+			c.NewIdent("cJSON_CreateString", "cjson.CreateString", cjson.CreateString, c.FuncTT(itemPtrT, gstrT)),
+			c.NewIdent("cJSON_CreateRaw", "cjson.CreateRaw", cjson.CreateRaw, c.FuncTT(itemPtrT, gstrT)),
+			c.NewIdent("cJSON_CreateArray", "cjson.CreateArray", cjson.CreateArray, c.FuncTT(itemPtrT)),
+			c.NewIdent("cJSON_CreateObject", "cjson.CreateObject", cjson.CreateObject, c.FuncTT(itemPtrT)),
+			c.NewIdent("cJSON_CreateIntArray", "cjson.CreateIntArray", cjson.CreateIntArray, c.FuncTT(itemPtrT, c.PtrT(intT), intT)),
+			c.NewIdent("cJSON_CreateDoubleArray", "cjson.CreateDoubleArray", cjson.CreateDoubleArray, c.FuncTT(itemPtrT, c.PtrT(dblT), intT)),
+			c.NewIdent("cJSON_CreateStringArray", "cjson.CreateStringArray", cjson.CreateStringArray, c.FuncTT(itemPtrT, c.PtrT(strT), intT)),
+			c.NewIdent("cJSON_Duplicate", "cjson.Duplicate", cjson.Duplicate, c.FuncTT(itemPtrT, itemPtrT, boolT)),
+			c.NewIdent("cJSON_Compare", "cjson.Compare", cjson.Compare, c.FuncTT(boolT, itemPtrT, itemPtrT, boolT)),
+
+			c.NewIdent("cJSON_AddItemToArray", "cjson.AddItemToArray", cjson.AddItemToArray, c.FuncTT(boolT, itemPtrT, itemPtrT)),
+			c.NewIdent("cJSON_AddItemToObject", "cjson.AddItemToObject", cjson.AddItemToObject, c.FuncTT(boolT, itemPtrT, gstrT, itemPtrT)),
+			c.NewIdent("cJSON_AddItemToObjectCS", "cjson.AddItemToObjectCS", cjson.AddItemToObjectCS, c.FuncTT(boolT, itemPtrT, gstrT, itemPtrT)),
+			c.NewIdent("cJSON_DetachItemViaPointer", "cjson.DetachItemViaPointer", cjson.DetachItemViaPointer, c.FuncTT(itemPtrT, itemPtrT, itemPtrT)),
+			c.NewIdent("cJSON_DetachItemFromArray", "cjson.DetachItemFromArray", cjson.DetachItemFromArray, c.FuncTT(itemPtrT, itemPtrT, intT)),
+			c.NewIdent("cJSON_DeleteItemFromArray", "cjson.DeleteItemFromArray", cjson.DeleteItemFromArray, c.FuncTT(nil, itemPtrT, intT)),
+			c.NewIdent("cJSON_DetachItemFromObject", "cjson.DetachItemFromObject", cjson.DetachItemFromObject, c.FuncTT(itemPtrT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_DetachItemFromObjectCaseSensitive", "cjson.DetachItemFromObjectCaseSensitive", cjson.DetachItemFromObjectCaseSensitive, c.FuncTT(itemPtrT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_DeleteItemFromObject", "cjson.DeleteItemFromObject", cjson.DeleteItemFromObject, c.FuncTT(nil, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_DeleteItemFromObjectCaseSensitive", "cjson.DeleteItemFromObjectCaseSensitive", cjson.DeleteItemFromObjectCaseSensitive, c.FuncTT(nil, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_ReplaceItemViaPointer", "cjson.ReplaceItemViaPointer", cjson.ReplaceItemViaPointer, c.FuncTT(boolT, itemPtrT, itemPtrT, itemPtrT)),
+			c.NewIdent("cJSON_ReplaceItemInArray", "cjson.ReplaceItemInArray", cjson.ReplaceItemInArray, c.FuncTT(boolT, itemPtrT, intT, itemPtrT)),
+			c.NewIdent("cJSON_ReplaceItemInObject", "cjson.ReplaceItemInObject", cjson.ReplaceItemInObject, c.FuncTT(boolT, itemPtrT, gstrT, itemPtrT)),
+			c.NewIdent("cJSON_ReplaceItemInObjectCaseSensitive", "cjson.ReplaceItemInObjectCaseSensitive", cjson.ReplaceItemInObjectCaseSensitive, c.FuncTT(boolT, itemPtrT, gstrT, itemPtrT)),
+
+			c.NewIdent("cJSON_AddNullToObject", "cjson.AddNullToObject", cjson.AddNullToObject, c.FuncTT(itemPtrT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_AddTrueToObject", "cjson.AddTrueToObject", cjson.AddTrueToObject, c.FuncTT(itemPtrT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_AddFalseToObject", "cjson.AddFalseToObject", cjson.AddFalseToObject, c.FuncTT(itemPtrT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_AddBoolToObject", "cjson.AddBoolToObject", cjson.AddBoolToObject, c.FuncTT(itemPtrT, itemPtrT, gstrT, boolT)),
+			c.NewIdent("cJSON_AddNumberToObject", "cjson.AddNumberToObject", cjson.AddNumberToObject, c.FuncTT(itemPtrT, itemPtrT, gstrT, dblT)),
+			c.NewIdent("cJSON_AddStringToObject", "cjson.AddStringToObject", cjson.AddStringToObject, c.FuncTT(itemPtrT, itemPtrT, gstrT, gstrT)),
+			c.NewIdent("cJSON_AddRawToObject", "cjson.AddRawToObject", cjson.AddRawToObject, c.FuncTT(itemPtrT, itemPtrT, gstrT, gstrT)),
+			c.NewIdent("cJSON_AddObjectToObject", "cjson.AddObjectToObject", cjson.AddObjectToObject, c.FuncTT(itemPtrT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_AddArrayToObject", "cjson.AddArrayToObject", cjson.AddArrayToObject, c.FuncTT(itemPtrT, itemPtrT, gstrT)),
+			c.NewIdent("cJSON_SetNumberHelper", "cjson.SetNumberHelper", cjson.SetNumberHelper, c.FuncTT(dblT, itemPtrT, dblT)),
+			c.NewIdent("cJSON_SetValuestring", "cjson.SetValuestring", cjson.SetValuestring, c.FuncTT(strT, itemPtrT, gstrT)),
+		)
+		return l
+	})
+	RegisterLibrary(cjsonDirH, func(c *Env) *Library {
+		return &Library{
+			Header: `#include <` + cjsonH + `>`,
+		}
+	})
+	RegisterLibrary(jsoncH, func(c *Env) *Library {
+		gintT := c.Go().Int()
+		gstrT := c.Go().String()
+		strT := c.C().String()
+		boolT := types.BoolT()
+		intT := types.IntT(4)
+		dblT := types.FloatT(8)
+		objT := types.NamedTGo("json_object", "cjson.Object", types.StructT(nil))
+		objPtrT := c.PtrT(objT)
+
+		var hdr strings.Builder
+		hdr.WriteString(`
+#include <` + stdboolH + `>
+#include <` + stddefH + `>
+#include <` + stdintH + `>
+
+#define json_bool bool
+#define json_type _cxgo_sint32
+
+typedef struct json_object {} json_object;
+
+enum {
+`)
+		idents := make(map[string]*types.Ident)
+		for _, v := range []struct {
+			name  string
+			value int
+		}{
+			{"json_type_null", cjson.Json_type_null},
+			{"json_type_boolean", cjson.Json_type_boolean},
+			{"json_type_double", cjson.Json_type_double},
+			{"json_type_int", cjson.Json_type_int},
+			{"json_type_object", cjson.Json_type_object},
+			{"json_type_array", cjson.Json_type_array},
+			{"json_type_string", cjson.Json_type_string},
+			{"JSON_C_TO_STRING_PLAIN", cjson.JSON_C_TO_STRING_PLAIN},
+			{"JSON_C_TO_STRING_SPACED", cjson.JSON_C_TO_STRING_SPACED},
+			{"JSON_C_TO_STRING_PRETTY", cjson.JSON_C_TO_STRING_PRETTY},
+			{"JSON_C_TO_STRING_PRETTY_TAB", cjson.JSON_C_TO_STRING_PRETTY_TAB},
+			{"JSON_C_TO_STRING_NOZERO", cjson.JSON_C_TO_STRING_NOZERO},
+			{"JSON_C_TO_STRING_NOSLASHESCAPE", cjson.JSON_C_TO_STRING_NOSLASHESCAPE},
+		} {
+			idents[v.name] = c.NewIdent(v.name, "cjson."+strings.ToUpper(v.name[:1])+v.name[1:], v.value, types.AsUntypedIntT(intT))
+			fmt.Fprintf(&hdr, "\t%s = %d,\n", v.name, v.value)
+		}
+		hdr.WriteString(`};
+
+#define json_object_object_foreach(obj, key, val) \
+	char* key = NULL; \
+	json_object* val = NULL; \
+	for (_cxgo_sint32 _cxgo_iter_##key = 0; _cxgo_json_object_iter(obj, _cxgo_iter_##key, &key, &val); _cxgo_iter_##key++)
+`)
+		l := &Library{
+			Imports: map[string]string{
+				"cjson": RuntimePrefix + "cjson",
+			},
+			Types: map[string]types.Type{
+				"json_object": objT,
+			},
+			Idents: idents,
+			Header: hdr.String(),
+		}
+		l.Declare(
+			c.NewIdent("json_tokener_parse", "cjson.TokenerParse", cjson.TokenerParse, c.FuncTT(objPtrT, gstrT)),
+			c.NewIdent("json_object_new_object", "cjson.ObjectNewObject", cjson.ObjectNewObject, c.FuncTT(objPtrT)),
+			c.NewIdent("json_object_new_array", "cjson.ObjectNewArray", cjson.ObjectNewArray, c.FuncTT(objPtrT)),
+			c.NewIdent("json_object_new_string", "cjson.ObjectNewString", cjson.ObjectNewString, c.FuncTT(objPtrT, gstrT)),
+			c.NewIdent("json_object_new_string_len", "cjson.ObjectNewStringLen", cjson.ObjectNewStringLen, c.FuncTT(objPtrT, strT, intT)),
+			c.NewIdent("json_object_new_int", "cjson.ObjectNewInt", cjson.ObjectNewInt, c.FuncTT(objPtrT, intT)),
+			c.NewIdent("json_object_new_int64", "cjson.ObjectNewInt64", cjson.ObjectNewInt64, c.FuncTT(objPtrT, types.IntT(8))),
+			c.NewIdent("json_object_new_double", "cjson.ObjectNewDouble", cjson.ObjectNewDouble, c.FuncTT(objPtrT, dblT)),
+			c.NewIdent("json_object_new_boolean", "cjson.ObjectNewBoolean", cjson.ObjectNewBoolean, c.FuncTT(objPtrT, boolT)),
+			c.NewIdent("json_object_get", "cjson.ObjectGet", cjson.ObjectGet, c.FuncTT(objPtrT, objPtrT)),
+			c.NewIdent("json_object_put", "cjson.ObjectPut", cjson.ObjectPut, c.FuncTT(intT, objPtrT)),
+			c.NewIdent("json_object_get_type", "cjson.ObjectGetType", cjson.ObjectGetType, c.FuncTT(intT, objPtrT)),
+			c.NewIdent("json_object_is_type", "cjson.ObjectIsType", cjson.ObjectIsType, c.FuncTT(boolT, objPtrT, intT)),
+			c.NewIdent("json_object_get_boolean", "cjson.ObjectGetBoolean", cjson.ObjectGetBoolean, c.FuncTT(boolT, objPtrT)),
+			c.NewIdent("json_object_get_int", "cjson.ObjectGetInt", cjson.ObjectGetInt, c.FuncTT(intT, objPtrT)),
+			c.NewIdent("json_object_get_int64", "cjson.ObjectGetInt64", cjson.ObjectGetInt64, c.FuncTT(types.IntT(8), objPtrT)),
+			c.NewIdent("json_object_get_double", "cjson.ObjectGetDouble", cjson.ObjectGetDouble, c.FuncTT(dblT, objPtrT)),
+			c.NewIdent("json_object_get_string", "cjson.ObjectGetString", cjson.ObjectGetString, c.FuncTT(strT, objPtrT)),
+			c.NewIdent("json_object_get_string_len", "cjson.ObjectGetStringLen", cjson.ObjectGetStringLen, c.FuncTT(intT, objPtrT)),
+			c.NewIdent("json_object_object_add", "cjson.ObjectObjectAdd", cjson.ObjectObjectAdd, c.FuncTT(intT, objPtrT, gstrT, objPtrT)),
+			c.NewIdent("json_object_object_get_ex", "cjson.ObjectObjectGetEx", cjson.ObjectObjectGetEx, c.FuncTT(boolT, objPtrT, gstrT, c.PtrT(objPtrT))),
+			c.NewIdent("json_object_object_get", "cjson.ObjectObjectGet", cjson.ObjectObjectGet, c.FuncTT(objPtrT, objPtrT, gstrT)),
+			c.NewIdent("json_object_object_del", "cjson.ObjectObjectDel", cjson.ObjectObjectDel, c.FuncTT(nil, objPtrT, gstrT)),
+			c.NewIdent("json_object_object_length", "cjson.ObjectObjectLength", cjson.ObjectObjectLength, c.FuncTT(intT, objPtrT)),
+			c.NewIdent("_cxgo_json_object_iter", "cjson.ObjectObjectIter", cjson.ObjectObjectIter, c.FuncTT(boolT, objPtrT, intT, c.PtrT(strT), c.PtrT(objPtrT))),
+			c.NewIdent("json_object_array_length", "cjson.ObjectArrayLength", cjson.ObjectArrayLength, c.FuncTT(gintT, objPtrT)),
+			c.NewIdent("json_object_array_get_idx", "cjson.ObjectArrayGetIdx", cjson.ObjectArrayGetIdx, c.FuncTT(objPtrT, objPtrT, gintT)),
+			c.NewIdent("json_object_array_add", "cjson.ObjectArrayAdd", cjson.ObjectArrayAdd, c.FuncTT(intT, objPtrT, objPtrT)),
+			c.NewIdent("json_object_array_put_idx", "cjson.ObjectArrayPutIdx", cjson.ObjectArrayPutIdx, c.FuncTT(intT, objPtrT, gintT, objPtrT)),
+			c.NewIdent("json_object_array_del_idx", "cjson.ObjectArrayDelIdx", cjson.ObjectArrayDelIdx, c.FuncTT(intT, objPtrT, gintT, gintT)),
+			c.NewIdent("json_object_to_json_string", "cjson.ObjectToJSONString", cjson.ObjectToJSONString, c.FuncTT(strT, objPtrT)),
+			c.NewIdent("json_object_to_json_string_ext", "cjson.ObjectToJSONStringExt", cjson.ObjectToJSONStringExt, c.FuncTT(strT, objPtrT, intT)),
+		)
+		return l
+	})
+	for _, name := range []string{jsoncObjH, jsoncTokenH} {
+		RegisterLibrary(name, func(c *Env) *Library {
+			return &Library{
+				Header: `#include <` + jsoncH + `>`,
+			}
+		})
+	}
+}
@@ -16,29 +16,55 @@ func NewEnv(conf types.Config) *Env {
 			"math":   "math",
 			"libc":   RuntimeLibc,
 		},
+		blank:  make(map[string][]string),
 		macros: make(map[string]bool),
 	}
 }
 
+// RandMode selects the implementation of rand, srand, random and srandom.
+type RandMode string
+
+const (
+	RandGo    = RandMode("go")    // use math/rand; the default
+	RandGlibc = RandMode("glibc") // reproduce sequences generated by glibc
+)
+
+// StdoutMode selects how writes to stdout are translated.
+type StdoutMode string
+
+const (
+	StdoutDirect   = StdoutMode("direct")   // write to os.Stdout directly, in order with Go output; the default
+	StdoutBuffered = StdoutMode("buffered") // buffer stdout as C does, until fflush, exit or a full buffer
+)
+
 type Env struct {
 	*types.Env
 	NoLibs  bool              // completely disable library lookups
 	Map     map[string]string // when searching for library name, consult the map first and search that name instead
+	Rand    RandMode          // implementation of pseudo-random number generators
+	Stdout  StdoutMode        // buffering of stdout
 	libs    map[string]*Library
+	order   []string // sorted library names, for deterministic lookups
 	imports map[string]string
+	blank   map[string][]string // blank imports, by package name
 	macros  map[string]bool
 }
 
 func (c *Env) Clone() *Env {
-	c2 := &Env{Env: c.Env, NoLibs: c.NoLibs}
+	c2 := &Env{Env: c.Env, NoLibs: c.NoLibs, Rand: c.Rand, Stdout: c.Stdout}
 	c2.libs = make(map[string]*Library)
 	for k, v := range c.libs {
 		c2.libs[k] = v
 	}
+	c2.order = append([]string{}, c.order...)
 	c2.imports = make(map[string]string)
 	for k, v := range c.imports {
 		c2.imports[k] = v
 	}
+	c2.blank = make(map[string][]string)
+	for k, v := range c.blank {
+		c2.blank[k] = v
+	}
 	c2.macros = make(map[string]bool)
 	for k, v := range c.macros {
 		c2.macros[k] = v
@@ -58,6 +84,11 @@ func (c *Env) ResolveImport(name string) string {
 	return path
 }
 
+// BlankImports returns import paths of packages that must be imported for side effects when a given package is used.
+func (c *Env) BlankImports(name string) []string {
+	return c.blank[name]
+}
+
 // LookupLibrary finds an already loaded Library. It is useful to prevent import loops.
 //
 // Typically, the GetLibrary function should be used instead, because it will load the library automatically, if needed.
@@ -0,0 +1,169 @@
+package libs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotranspile/cxgo/runtime/ccurl"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	curlH     = "curl/curl.h"
+	curlEasyH = "curl/easy.h"
+)
+
+func init() {
+	RegisterLibrary(curlH, func(c *Env) *Library {
+		gstrT := c.Go().String()
+		strT := c.C().String()
+		intT := types.IntT(4)
+		ptrT := c.PtrT(nil)
+		curlT := types.NamedTGo("CURL", "ccurl.CURL", types.StructT(nil))
+		curlPtrT := c.PtrT(curlT)
+		slistPtrT := c.PtrT(nil)
+		slistT := types.NamedTGo("curl_slist", "ccurl.Slist", types.StructT([]*types.Field{
+			{Name: types.NewIdentGo("data", "Data", strT)},
+			{Name: types.NewIdentGo("next", "Next", slistPtrT)},
+		}))
+		slistPtrT.SetElem(slistT)
+
+		var hdr strings.Builder
+		hdr.WriteString(`
+#include <` + stddefH + `>
+
+#define LIBCURL_VERSION "` + ccurl.Version + `"
+
+#define CURLcode _cxgo_sint32
+#define CURLoption _cxgo_sint32
+#define CURLINFO _cxgo_sint32
+
+typedef struct CURL {} CURL;
+
+typedef struct curl_slist {
+	char* data;
+	struct curl_slist* next;
+} curl_slist;
+
+typedef size_t (*curl_write_callback)(char* buffer, size_t size, size_t nitems, void* outstream);
+
+enum {
+`)
+		idents := make(map[string]*types.Ident)
+		for _, v := range []struct {
+			name  string
+			value int
+		}{
+			{"CURLE_OK", ccurl.CURLE_OK},
+			{"CURLE_UNSUPPORTED_PROTOCOL", ccurl.CURLE_UNSUPPORTED_PROTOCOL},
+			{"CURLE_FAILED_INIT", ccurl.CURLE_FAILED_INIT},
+			{"CURLE_URL_MALFORMAT", ccurl.CURLE_URL_MALFORMAT},
+			{"CURLE_COULDNT_RESOLVE_HOST", ccurl.CURLE_COULDNT_RESOLVE_HOST},
+			{"CURLE_COULDNT_CONNECT", ccurl.CURLE_COULDNT_CONNECT},
+			{"CURLE_HTTP_RETURNED_ERROR", ccurl.CURLE_HTTP_RETURNED_ERROR},
+			{"CURLE_WRITE_ERROR", ccurl.CURLE_WRITE_ERROR},
+			{"CURLE_READ_ERROR", ccurl.CURLE_READ_ERROR},
+			{"CURLE_OUT_OF_MEMORY", ccurl.CURLE_OUT_OF_MEMORY},
+			{"CURLE_OPERATION_TIMEDOUT", ccurl.CURLE_OPERATION_TIMEDOUT},
+			{"CURLE_ABORTED_BY_CALLBACK", ccurl.CURLE_ABORTED_BY_CALLBACK},
+			{"CURLE_BAD_FUNCTION_ARGUMENT", ccurl.CURLE_BAD_FUNCTION_ARGUMENT},
+			{"CURLE_TOO_MANY_REDIRECTS", ccurl.CURLE_TOO_MANY_REDIRECTS},
+			{"CURLE_UNKNOWN_OPTION", ccurl.CURLE_UNKNOWN_OPTION},
+			{"CURLE_GOT_NOTHING", ccurl.CURLE_GOT_NOTHING},
+			{"CURLE_RECV_ERROR", ccurl.CURLE_RECV_ERROR},
+			{"CURLE_PEER_FAILED_VERIFICATION", ccurl.CURLE_PEER_FAILED_VERIFICATION},
+
+			{"CURL_GLOBAL_NOTHING", ccurl.CURL_GLOBAL_NOTHING},
+			{"CURL_GLOBAL_SSL", ccurl.CURL_GLOBAL_SSL},
+			{"CURL_GLOBAL_WIN32", ccurl.CURL_GLOBAL_WIN32},
+			{"CURL_GLOBAL_ALL", ccurl.CURL_GLOBAL_ALL},
+			{"CURL_GLOBAL_DEFAULT", ccurl.CURL_GLOBAL_DEFAULT},
+			{"CURL_ERROR_SIZE", ccurl.CURL_ERROR_SIZE},
+			{"CURL_MAX_WRITE_SIZE", ccurl.CURL_MAX_WRITE_SIZE},
+
+			{"CURLOPT_WRITEDATA", ccurl.CURLOPT_WRITEDATA},
+			{"CURLOPT_URL", ccurl.CURLOPT_URL},
+			{"CURLOPT_USERPWD", ccurl.CURLOPT_USERPWD},
+			{"CURLOPT_ERRORBUFFER", ccurl.CURLOPT_ERRORBUFFER},
+			{"CURLOPT_WRITEFUNCTION", ccurl.CURLOPT_WRITEFUNCTION},
+			{"CURLOPT_TIMEOUT", ccurl.CURLOPT_TIMEOUT},
+			{"CURLOPT_POSTFIELDS", ccurl.CURLOPT_POSTFIELDS},
+			{"CURLOPT_REFERER", ccurl.CURLOPT_REFERER},
+			{"CURLOPT_USERAGENT", ccurl.CURLOPT_USERAGENT},
+			{"CURLOPT_COOKIE", ccurl.CURLOPT_COOKIE},
+			{"CURLOPT_HTTPHEADER", ccurl.CURLOPT_HTTPHEADER},
+			{"CURLOPT_HEADERDATA", ccurl.CURLOPT_HEADERDATA},
+			{"CURLOPT_CUSTOMREQUEST", ccurl.CURLOPT_CUSTOMREQUEST},
+			{"CURLOPT_VERBOSE", ccurl.CURLOPT_VERBOSE},
+			{"CURLOPT_NOPROGRESS", ccurl.CURLOPT_NOPROGRESS},
+			{"CURLOPT_NOBODY", ccurl.CURLOPT_NOBODY},
+			{"CURLOPT_FAILONERROR", ccurl.CURLOPT_FAILONERROR},
+			{"CURLOPT_POST", ccurl.CURLOPT_POST},
+			{"CURLOPT_FOLLOWLOCATION", ccurl.CURLOPT_FOLLOWLOCATION},
+			{"CURLOPT_POSTFIELDSIZE", ccurl.CURLOPT_POSTFIELDSIZE},
+			{"CURLOPT_SSL_VERIFYPEER", ccurl.CURLOPT_SSL_VERIFYPEER},
+			{"CURLOPT_MAXREDIRS", ccurl.CURLOPT_MAXREDIRS},
+			{"CURLOPT_CONNECTTIMEOUT", ccurl.CURLOPT_CONNECTTIMEOUT},
+			{"CURLOPT_HEADERFUNCTION", ccurl.CURLOPT_HEADERFUNCTION},
+			{"CURLOPT_HTTPGET", ccurl.CURLOPT_HTTPGET},
+			{"CURLOPT_SSL_VERIFYHOST", ccurl.CURLOPT_SSL_VERIFYHOST},
+			{"CURLOPT_ACCEPT_ENCODING", ccurl.CURLOPT_ACCEPT_ENCODING},
+			{"CURLOPT_TIMEOUT_MS", ccurl.CURLOPT_TIMEOUT_MS},
+			{"CURLOPT_CONNECTTIMEOUT_MS", ccurl.CURLOPT_CONNECTTIMEOUT_MS},
+
+			{"CURLINFO_EFFECTIVE_URL", ccurl.CURLINFO_EFFECTIVE_URL},
+			{"CURLINFO_RESPONSE_CODE", ccurl.CURLINFO_RESPONSE_CODE},
+			{"CURLINFO_TOTAL_TIME", ccurl.CURLINFO_TOTAL_TIME},
+			{"CURLINFO_SIZE_DOWNLOAD", ccurl.CURLINFO_SIZE_DOWNLOAD},
+			{"CURLINFO_CONTENT_TYPE", ccurl.CURLINFO_CONTENT_TYPE},
+			{"CURLINFO_REDIRECT_COUNT", ccurl.CURLINFO_REDIRECT_COUNT},
+		} {
+			idents[v.name] = c.NewIdent(v.name, "ccurl."+v.name, v.value, types.AsUntypedIntT(intT))
+			fmt.Fprintf(&hdr, "\t%s = %d,\n", v.name, v.value)
+		}
+		hdr.WriteString(`};
+
+// old names
+#define CURLOPT_FILE CURLOPT_WRITEDATA
+#define CURLOPT_WRITEHEADER CURLOPT_HEADERDATA
+#define CURLOPT_ENCODING CURLOPT_ACCEPT_ENCODING
+#define CURLINFO_HTTP_CODE CURLINFO_RESPONSE_CODE
+`)
+		l := &Library{
+			Imports: map[string]string{
+				"ccurl": RuntimePrefix + "ccurl",
+			},
+			Types: map[string]types.Type{
+				"CURL":       curlT,
+				"curl_slist": slistT,
+			},
+			Idents: idents,
+			Header: hdr.String(),
+		}
+		l.Declare(
+			c.NewIdent("curl_global_init", "ccurl.GlobalInit", ccurl.GlobalInit, c.FuncTT(intT, intT)),
+			c.NewIdent("curl_global_cleanup", "ccurl.GlobalCleanup", ccurl.GlobalCleanup, c.FuncTT(nil)),
+			c.NewIdent("curl_version", "ccurl.CurlVersion", ccurl.CurlVersion, c.FuncTT(strT)),
+			c.NewIdent("curl_free", "ccurl.Free", ccurl.Free, c.FuncTT(nil, ptrT)),
+			c.NewIdent("curl_slist_append", "ccurl.SlistAppend", ccurl.SlistAppend, c.FuncTT(slistPtrT, slistPtrT, gstrT)),
+			c.NewIdent("curl_slist_free_all", "ccurl.SlistFreeAll", ccurl.SlistFreeAll, c.FuncTT(nil, slistPtrT)),
+
+			c.NewIdent("curl_easy_init", "ccurl.EasyInit", ccurl.EasyInit, c.FuncTT(curlPtrT)),
+			c.NewIdent("curl_easy_cleanup", "ccurl.EasyCleanup", ccurl.EasyCleanup, c.FuncTT(nil, curlPtrT)),
+			c.NewIdent("curl_easy_reset", "ccurl.EasyReset", ccurl.EasyReset, c.FuncTT(nil, curlPtrT)),
+			c.NewIdent("curl_easy_duphandle", "ccurl.EasyDupHandle", ccurl.EasyDupHandle, c.FuncTT(curlPtrT, curlPtrT)),
+			c.NewIdent("curl_easy_setopt", "ccurl.EasySetopt", ccurl.EasySetopt, c.VarFuncTT(intT, curlPtrT, intT)),
+			c.NewIdent("curl_easy_perform", "ccurl.EasyPerform", ccurl.EasyPerform, c.FuncTT(intT, curlPtrT)),
+			c.NewIdent("curl_easy_getinfo", "ccurl.EasyGetinfo", ccurl.EasyGetinfo, c.VarFuncTT(intT, curlPtrT, intT)),
+			c.NewIdent("curl_easy_strerror", "ccurl.EasyStrerror", ccurl.EasyStrerror, c.FuncTT(strT, intT)),
+			c.NewIdent("curl_easy_escape", "ccurl.EasyEscape", ccurl.EasyEscape, c.FuncTT(strT, curlPtrT, strT, intT)),
+			c.NewIdent("curl_easy_unescape", "ccurl.EasyUnescape", ccurl.EasyUnescape, c.FuncTT(strT, curlPtrT, strT, intT, c.PtrT(intT))),
+		)
+		return l
+	})
+	RegisterLibrary(curlEasyH, func(c *Env) *Library {
+		return &Library{
+			Header: `#include <` + curlH + `>`,
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package libs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotranspile/cxgo/runtime/curses"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	cursesH  = "curses.h"
+	ncursesH = "ncurses.h"
+)
+
+func init() {
+	RegisterLibrary(cursesH, func(c *Env) *Library {
+		gstrT := c.Go().String()
+		boolT := types.BoolT()
+		intT := types.IntT(4)
+		shortT := types.IntT(2)
+		chT := types.UintT(4)
+		winT := types.NamedTGo("WINDOW", "curses.Window", types.StructT(nil))
+		winPtrT := c.PtrT(winT)
+
+		var hdr strings.Builder
+		hdr.WriteString(`
+#include <` + stdboolH + `>
+
+#define chtype _cxgo_uint32
+#define attr_t _cxgo_uint32
+
+typedef struct WINDOW {} WINDOW;
+
+#define TRUE 1
+#define FALSE 0
+
+enum {
+`)
+		idents := map[string]*types.Ident{
+			"LINES":  c.NewIdent("LINES", "curses.LINES", curses.LINES, intT),
+			"COLS":   c.NewIdent("COLS", "curses.COLS", curses.COLS, intT),
+			"stdscr": c.NewIdent("stdscr", "curses.Stdscr", curses.Stdscr, winPtrT),
+			"TRUE":   types.NewIdentGo("TRUE", "true", boolT),
+			"FALSE":  types.NewIdentGo("FALSE", "false", boolT),
+		}
+		for _, v := range []struct {
+			name  string
+			value int
+		}{
+			{"OK", curses.OK},
+			{"ERR", curses.ERR},
+			{"A_NORMAL", curses.A_NORMAL},
+			{"A_CHARTEXT", curses.A_CHARTEXT},
+			{"A_COLOR", curses.A_COLOR},
+			{"A_STANDOUT", curses.A_STANDOUT},
+			{"A_UNDERLINE", curses.A_UNDERLINE},
+			{"A_REVERSE", curses.A_REVERSE},
+			{"A_BLINK", curses.A_BLINK},
+			{"A_DIM", curses.A_DIM},
+			{"A_BOLD", curses.A_BOLD},
+			{"COLOR_BLACK", curses.COLOR_BLACK},
+			{"COLOR_RED", curses.COLOR_RED},
+			{"COLOR_GREEN", curses.COLOR_GREEN},
+			{"COLOR_YELLOW", curses.COLOR_YELLOW},
+			{"COLOR_BLUE", curses.COLOR_BLUE},
+			{"COLOR_MAGENTA", curses.COLOR_MAGENTA},
+			{"COLOR_CYAN", curses.COLOR_CYAN},
+			{"COLOR_WHITE", curses.COLOR_WHITE},
+			{"KEY_DOWN", curses.KEY_DOWN},
+			{"KEY_UP", curses.KEY_UP},
+			{"KEY_LEFT", curses.KEY_LEFT},
+			{"KEY_RIGHT", curses.KEY_RIGHT},
+			{"KEY_HOME", curses.KEY_HOME},
+			{"KEY_BACKSPACE", curses.KEY_BACKSPACE},
+			{"KEY_F0", curses.KEY_F0},
+			{"KEY_DC", curses.KEY_DC},
+			{"KEY_IC", curses.KEY_IC},
+			{"KEY_NPAGE", curses.KEY_NPAGE},
+			{"KEY_PPAGE", curses.KEY_PPAGE},
+			{"KEY_ENTER", curses.KEY_ENTER},
+			{"KEY_END", curses.KEY_END},
+			{"KEY_RESIZE", curses.KEY_RESIZE},
+		} {
+			idents[v.name] = c.NewIdent(v.name, "curses."+v.name, v.value, types.AsUntypedIntT(intT))
+			fmt.Fprintf(&hdr, "\t%s = %d,\n", v.name, v.value)
+		}
+		hdr.WriteString(`};
+_cxgo_sint32 LINES;
+_cxgo_sint32 COLS;
+WINDOW* stdscr;
+
+#define KEY_F(n) (KEY_F0+(n))
+#define getmaxyx(w, y, x) ((y) = getmaxy(w), (x) = getmaxx(w))
+#define getyx(w, y, x) ((y) = getcury(w), (x) = getcurx(w))
+#define wclrtobot wclrtoeol
+`)
+		l := &Library{
+			Imports: map[string]string{
+				"curses": RuntimePrefix + "curses",
+			},
+			Types: map[string]types.Type{
+				"WINDOW": winT,
+			},
+			Idents: idents,
+			Header: hdr.String(),
+			ForceMacros: map[string]bool{
+				"TRUE":  true,
+				"FALSE": true,
+			},
+		}
+		l.Declare(
+			c.NewIdent("initscr", "curses.Initscr", curses.Initscr, c.FuncTT(winPtrT)),
+			c.NewIdent("endwin", "curses.Endwin", curses.Endwin, c.FuncTT(intT)),
+			c.NewIdent("echo", "curses.Echo", curses.Echo, c.FuncTT(intT)),
+			c.NewIdent("noecho", "curses.Noecho", curses.Noecho, c.FuncTT(intT)),
+			c.NewIdent("cbreak", "curses.Cbreak", curses.Cbreak, c.FuncTT(intT)),
+			c.NewIdent("nocbreak", "curses.Nocbreak", curses.Nocbreak, c.FuncTT(intT)),
+			c.NewIdent("raw", "curses.Raw", curses.Raw, c.FuncTT(intT)),
+			c.NewIdent("noraw", "curses.Noraw", curses.Noraw, c.FuncTT(intT)),
+			c.NewIdent("keypad", "curses.Keypad", curses.Keypad, c.FuncTT(intT, winPtrT, boolT)),
+			c.NewIdent("nodelay", "curses.Nodelay", curses.Nodelay, c.FuncTT(intT, winPtrT, boolT)),
+			c.NewIdent("curs_set", "curses.CursSet", curses.CursSet, c.FuncTT(intT, intT)),
+			c.NewIdent("has_colors", "curses.HasColors", curses.HasColors, c.FuncTT(boolT)),
+			c.NewIdent("start_color", "curses.StartColor", curses.StartColor, c.FuncTT(intT)),
+			c.NewIdent("init_pair", "curses.InitPair", curses.InitPair, c.FuncTT(intT, shortT, shortT, shortT)),
+			c.NewIdent("COLOR_PAIR", "curses.ColorPair", curses.ColorPair, c.FuncTT(chT, intT)),
+			c.NewIdent("beep", "curses.Beep", curses.Beep, c.FuncTT(intT)),
+			c.NewIdent("napms", "curses.Napms", curses.Napms, c.FuncTT(intT, intT)),
+			c.NewIdent("newwin", "curses.Newwin", curses.Newwin, c.FuncTT(winPtrT, intT, intT, intT, intT)),
+			c.NewIdent("delwin", "curses.Delwin", curses.Delwin, c.FuncTT(intT, winPtrT)),
+			c.NewIdent("box", "curses.Box", curses.Box, c.FuncTT(intT, winPtrT, chT, chT)),
+			c.NewIdent("getmaxy", "curses.Getmaxy", curses.Getmaxy, c.FuncTT(intT, winPtrT)),
+			c.NewIdent("getmaxx", "curses.Getmaxx", curses.Getmaxx, c.FuncTT(intT, winPtrT)),
+			c.NewIdent("getcury", "curses.Getcury", curses.Getcury, c.FuncTT(intT, winPtrT)),
+			c.NewIdent("getcurx", "curses.Getcurx", curses.Getcurx, c.FuncTT(intT, winPtrT)),
+
+			c.NewIdent("refresh", "curses.Refresh", curses.Refresh, c.FuncTT(intT)),
+			c.NewIdent("clear", "curses.Clear", curses.Clear, c.FuncTT(intT)),
+			c.NewIdent("erase", "curses.Erase", curses.Erase, c.FuncTT(intT)),
+			c.NewIdent("clrtoeol", "curses.Clrtoeol", curses.Clrtoeol, c.FuncTT(intT)),
+			c.NewIdent("move", "curses.Move", curses.Move, c.FuncTT(intT, intT, intT)),
+			c.NewIdent("addch", "curses.Addch", curses.Addch, c.FuncTT(intT, chT)),
+			c.NewIdent("addstr", "curses.Addstr", curses.Addstr, c.FuncTT(intT, gstrT)),
+			c.NewIdent("mvaddch", "curses.Mvaddch", curses.Mvaddch, c.FuncTT(intT, intT, intT, chT)),
+			c.NewIdent("mvaddstr", "curses.Mvaddstr", curses.Mvaddstr, c.FuncTT(intT, intT, intT, gstrT)),
+			c.NewIdent("printw", "curses.Printw", curses.Printw, c.VarFuncTT(intT, gstrT)),
+			c.NewIdent("mvprintw", "curses.Mvprintw", curses.Mvprintw, c.VarFuncTT(intT, intT, intT, gstrT)),
+			c.NewIdent("attron", "curses.Attron", curses.Attron, c.FuncTT(intT, chT)),
+			c.NewIdent("attroff", "curses.Attroff", curses.Attroff, c.FuncTT(intT, chT)),
+			c.NewIdent("attrset", "curses.Attrset", curses.Attrset, c.FuncTT(intT, chT)),
+			c.NewIdent("timeout", "curses.Timeout", curses.Timeout, c.FuncTT(nil, intT)),
+			c.NewIdent("getch", "curses.Getch", curses.Getch, c.FuncTT(intT)),
+
+			c.NewIdent("wrefresh", "curses.Wrefresh", curses.Wrefresh, c.FuncTT(intT, winPtrT)),
+			c.NewIdent("wclear", "curses.Wclear", curses.Wclear, c.FuncTT(intT, winPtrT)),
+			c.NewIdent("werase", "curses.Werase", curses.Werase, c.FuncTT(intT, winPtrT)),
+			c.NewIdent("wclrtoeol", "curses.Wclrtoeol", curses.Wclrtoeol, c.FuncTT(intT, winPtrT)),
+			c.NewIdent("wmove", "curses.Wmove", curses.Wmove, c.FuncTT(intT, winPtrT, intT, intT)),
+			c.NewIdent("waddch", "curses.Waddch", curses.Waddch, c.FuncTT(intT, winPtrT, chT)),
+			c.NewIdent("waddstr", "curses.Waddstr", curses.Waddstr, c.FuncTT(intT, winPtrT, gstrT)),
+			c.NewIdent("mvwaddch", "curses.Mvwaddch", curses.Mvwaddch, c.FuncTT(intT, winPtrT, intT, intT, chT)),
+			c.NewIdent("mvwaddstr", "curses.Mvwaddstr", curses.Mvwaddstr, c.FuncTT(intT, winPtrT, intT, intT, gstrT)),
+			c.NewIdent("wprintw", "curses.Wprintw", curses.Wprintw, c.VarFuncTT(intT, winPtrT, gstrT)),
+			c.NewIdent("mvwprintw", "curses.Mvwprintw", curses.Mvwprintw, c.VarFuncTT(intT, winPtrT, intT, intT, gstrT)),
+			c.NewIdent("wattron", "curses.Wattron", curses.Wattron, c.FuncTT(intT, winPtrT, chT)),
+			c.NewIdent("wattroff", "curses.Wattroff", curses.Wattroff, c.FuncTT(intT, winPtrT, chT)),
+			c.NewIdent("wattrset", "curses.Wattrset", curses.Wattrset, c.FuncTT(intT, winPtrT, chT)),
+			c.NewIdent("wtimeout", "curses.Wtimeout", curses.Wtimeout, c.FuncTT(nil, winPtrT, intT)),
+			c.NewIdent("wgetch", "curses.Wgetch", curses.Wgetch, c.FuncTT(intT, winPtrT)),
+		)
+		return l
+	})
+	RegisterLibrary(ncursesH, func(c *Env) *Library {
+		return &Library{
+			Header: `#include <` + cursesH + `>`,
+		}
+	})
+}
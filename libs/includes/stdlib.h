@@ -61,8 +61,8 @@ char         *ptsname(int);
 int           putenv(char *);
 void          qsort(void *, _cxgo_uint32, _cxgo_uint32, _cxgo_int32 (*)(const void *, const void *));
 _cxgo_sint32  rand(void);
-int           rand_r(unsigned *);
-long          random(void);
+_cxgo_sint32  rand_r(_cxgo_uint32 *);
+_cxgo_sint32  random(void);
 void         *realloc(void *, _cxgo_go_int);
 char         *realpath(const char *restrict, char *restrict);
 unsigned short *seed48(unsigned short [3]);
@@ -71,7 +71,7 @@ void          setkey(const char *);
 char         *setstate(char *);
 void          srand(_cxgo_uint32);
 void          srand48(long);
-void          srandom(unsigned);
+void          srandom(_cxgo_uint32);
 double        strtod(const char *restrict, char **restrict);
 float         strtof(const char *restrict, char **restrict);
 long          strtol(const char *restrict, char **restrict, int);
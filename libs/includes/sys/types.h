@@ -8,6 +8,7 @@
 #define gid_t _cxgo_uint32
 #define uid_t _cxgo_uint32
 #define ino_t _cxgo_uint64
+#define useconds_t _cxgo_uint32
 
 #define u_short unsigned short
 #define u_long unsigned long
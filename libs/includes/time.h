@@ -3,6 +3,7 @@
 const _cxgo_go_int CLOCK_REALTIME = 0;
 const _cxgo_go_int CLOCK_MONOTONIC = 1;
 const _cxgo_go_int CLOCKS_PER_SEC = 1000000;
+const _cxgo_go_int TIMER_ABSTIME = 1;
 
 typedef _cxgo_int32 time_t;
 typedef _cxgo_uint32 timer_t;
@@ -37,6 +38,7 @@ char      *asctime_r(const struct tm *, char *);
 clock_t    clock(void);
 _cxgo_int32        clock_getres(clockid_t, struct timespec *);
 _cxgo_int32        clock_gettime(clockid_t, struct timespec *);
+_cxgo_int32        clock_nanosleep(clockid_t, _cxgo_int32, const struct timespec *, struct timespec *);
 _cxgo_int32        clock_settime(clockid_t, const struct timespec *);
 char      *ctime(const time_t *);
 char      *ctime_r(const time_t *, char *);
@@ -56,7 +56,7 @@ int          setgid(gid_t);
 int          setpgid(pid_t, pid_t);
 pid_t        setsid(void);
 int          setuid(uid_t);
-unsigned     sleep(unsigned);
+_cxgo_uint32 sleep(_cxgo_uint32);
 int          symlink(const char *, const char *);
 long         sysconf(int);
 pid_t        tcgetpgrp(int);
@@ -64,3 +64,4 @@ int          tcsetpgrp(int, pid_t);
 char        *ttyname(int);
 int          ttyname_r(int, char *, size_t);
 _cxgo_sint32 unlink(const char *);
+_cxgo_sint32 usleep(useconds_t);
@@ -1,8 +1,6 @@
 package libcc
 
 import (
-	"encoding/binary"
-
 	"github.com/gotranspile/cxgo/types"
 	"modernc.org/cc/v3"
 )
@@ -10,19 +8,20 @@ import (
 func NewABI(c *types.Env) cc.ABI {
 	intSize := c.IntSize()
 	ptrSize := c.PtrSize()
+	longSize := c.LongSize()
 	return cc.ABI{
-		ByteOrder: binary.LittleEndian,
+		ByteOrder: c.ByteOrder(),
 		Types: map[cc.Kind]cc.ABIType{
 			cc.Bool:      {1, 1, 1},
 			cc.Char:      {1, 1, 1},
 			cc.Int:       {uintptr(intSize), intSize, intSize},
-			cc.Long:      {uintptr(ptrSize), ptrSize, ptrSize},
+			cc.Long:      {uintptr(longSize), longSize, longSize},
 			cc.LongLong:  {8, 8, intSize},
 			cc.SChar:     {1, 1, 1},
 			cc.Short:     {2, 2, 2},
 			cc.UChar:     {1, 1, 1},
 			cc.UInt:      {uintptr(intSize), intSize, intSize},
-			cc.ULong:     {uintptr(ptrSize), ptrSize, ptrSize},
+			cc.ULong:     {uintptr(longSize), longSize, longSize},
 			cc.ULongLong: {8, 8, intSize},
 			cc.UShort:    {2, 2, 2},
 
@@ -0,0 +1,209 @@
+package libs
+
+import (
+	"github.com/gotranspile/cxgo/runtime/cssl"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	opensslMD5H  = "openssl/md5.h"
+	opensslSHAH  = "openssl/sha.h"
+	opensslEVPH  = "openssl/evp.h"
+	opensslHMACH = "openssl/hmac.h"
+)
+
+func init() {
+	RegisterLibrary(opensslMD5H, func(c *Env) *Library {
+		gintT := c.Go().Int()
+		intT := types.IntT(4)
+		bytesT := c.PtrT(types.UintT(1))
+		ptrT := c.PtrT(nil)
+		ctxT := types.NamedTGo("MD5_CTX", "cssl.MD5Ctx", types.StructT(nil))
+		ctxPtrT := c.PtrT(ctxT)
+		l := &Library{
+			Imports: map[string]string{
+				"cssl": RuntimePrefix + "cssl",
+			},
+			Types: map[string]types.Type{
+				"MD5_CTX": ctxT,
+			},
+			Idents: map[string]*types.Ident{
+				"MD5_DIGEST_LENGTH": c.NewIdent("MD5_DIGEST_LENGTH", "cssl.MD5_DIGEST_LENGTH", cssl.MD5_DIGEST_LENGTH, types.AsUntypedIntT(intT)),
+			},
+			Header: `
+#include <` + stddefH + `>
+
+enum {
+	MD5_DIGEST_LENGTH = 16,
+};
+
+typedef struct MD5_CTX {} MD5_CTX;
+`,
+		}
+		l.Declare(
+			c.NewIdent("MD5_Init", "cssl.MD5Init", cssl.MD5Init, c.FuncTT(intT, ctxPtrT)),
+			c.NewIdent("MD5_Update", "cssl.MD5Update", cssl.MD5Update, c.FuncTT(intT, ctxPtrT, ptrT, gintT)),
+			c.NewIdent("MD5_Final", "cssl.MD5Final", cssl.MD5Final, c.FuncTT(intT, bytesT, ctxPtrT)),
+			c.NewIdent("MD5", "cssl.MD5", cssl.MD5, c.FuncTT(bytesT, bytesT, gintT, bytesT)),
+		)
+		return l
+	})
+	RegisterLibrary(opensslSHAH, func(c *Env) *Library {
+		gintT := c.Go().Int()
+		intT := types.IntT(4)
+		bytesT := c.PtrT(types.UintT(1))
+		ptrT := c.PtrT(nil)
+		shaT := types.NamedTGo("SHA_CTX", "cssl.SHACtx", types.StructT(nil))
+		sha256T := types.NamedTGo("SHA256_CTX", "cssl.SHA256Ctx", types.StructT(nil))
+		sha512T := types.NamedTGo("SHA512_CTX", "cssl.SHA512Ctx", types.StructT(nil))
+		l := &Library{
+			Imports: map[string]string{
+				"cssl": RuntimePrefix + "cssl",
+			},
+			Types: map[string]types.Type{
+				"SHA_CTX":    shaT,
+				"SHA256_CTX": sha256T,
+				"SHA512_CTX": sha512T,
+			},
+			Idents: map[string]*types.Ident{
+				"SHA_DIGEST_LENGTH":    c.NewIdent("SHA_DIGEST_LENGTH", "cssl.SHA_DIGEST_LENGTH", cssl.SHA_DIGEST_LENGTH, types.AsUntypedIntT(intT)),
+				"SHA224_DIGEST_LENGTH": c.NewIdent("SHA224_DIGEST_LENGTH", "cssl.SHA224_DIGEST_LENGTH", cssl.SHA224_DIGEST_LENGTH, types.AsUntypedIntT(intT)),
+				"SHA256_DIGEST_LENGTH": c.NewIdent("SHA256_DIGEST_LENGTH", "cssl.SHA256_DIGEST_LENGTH", cssl.SHA256_DIGEST_LENGTH, types.AsUntypedIntT(intT)),
+				"SHA384_DIGEST_LENGTH": c.NewIdent("SHA384_DIGEST_LENGTH", "cssl.SHA384_DIGEST_LENGTH", cssl.SHA384_DIGEST_LENGTH, types.AsUntypedIntT(intT)),
+				"SHA512_DIGEST_LENGTH": c.NewIdent("SHA512_DIGEST_LENGTH", "cssl.SHA512_DIGEST_LENGTH", cssl.SHA512_DIGEST_LENGTH, types.AsUntypedIntT(intT)),
+			},
+			Header: `
+#include <` + stddefH + `>
+
+enum {
+	SHA_DIGEST_LENGTH = 20,
+	SHA224_DIGEST_LENGTH = 28,
+	SHA256_DIGEST_LENGTH = 32,
+	SHA384_DIGEST_LENGTH = 48,
+	SHA512_DIGEST_LENGTH = 64,
+};
+
+typedef struct SHA_CTX {} SHA_CTX;
+typedef struct SHA256_CTX {} SHA256_CTX;
+typedef struct SHA512_CTX {} SHA512_CTX;
+`,
+		}
+		for _, v := range []struct {
+			name string
+			ctx  types.Type
+			f    [4]interface{}
+		}{
+			{"SHA1", shaT, [4]interface{}{cssl.SHA1Init, cssl.SHA1Update, cssl.SHA1Final, cssl.SHA1}},
+			{"SHA224", sha256T, [4]interface{}{cssl.SHA224Init, cssl.SHA224Update, cssl.SHA224Final, cssl.SHA224}},
+			{"SHA256", sha256T, [4]interface{}{cssl.SHA256Init, cssl.SHA256Update, cssl.SHA256Final, cssl.SHA256}},
+			{"SHA384", sha512T, [4]interface{}{cssl.SHA384Init, cssl.SHA384Update, cssl.SHA384Final, cssl.SHA384}},
+			{"SHA512", sha512T, [4]interface{}{cssl.SHA512Init, cssl.SHA512Update, cssl.SHA512Final, cssl.SHA512}},
+		} {
+			ctxPtrT := c.PtrT(v.ctx)
+			l.Declare(
+				c.NewIdent(v.name+"_Init", "cssl."+v.name+"Init", v.f[0], c.FuncTT(intT, ctxPtrT)),
+				c.NewIdent(v.name+"_Update", "cssl."+v.name+"Update", v.f[1], c.FuncTT(intT, ctxPtrT, ptrT, gintT)),
+				c.NewIdent(v.name+"_Final", "cssl."+v.name+"Final", v.f[2], c.FuncTT(intT, bytesT, ctxPtrT)),
+				c.NewIdent(v.name, "cssl."+v.name, v.f[3], c.FuncTT(bytesT, bytesT, gintT, bytesT)),
+			)
+		}
+		return l
+	})
+	RegisterLibrary(opensslEVPH, func(c *Env) *Library {
+		gintT := c.Go().Int()
+		intT := types.IntT(4)
+		bytesT := c.PtrT(types.UintT(1))
+		sizePtrT := c.PtrT(types.UintT(4))
+		ptrT := c.PtrT(nil)
+		mdT := types.NamedTGo("EVP_MD", "cssl.MD", types.StructT(nil))
+		mdPtrT := c.PtrT(mdT)
+		ctxT := types.NamedTGo("EVP_MD_CTX", "cssl.MDCtx", types.StructT(nil))
+		ctxPtrT := c.PtrT(ctxT)
+		engT := types.NamedTGo("ENGINE", "cssl.Engine", types.StructT(nil))
+		engPtrT := c.PtrT(engT)
+		l := &Library{
+			Imports: map[string]string{
+				"cssl": RuntimePrefix + "cssl",
+			},
+			Types: map[string]types.Type{
+				"EVP_MD":     mdT,
+				"EVP_MD_CTX": ctxT,
+				"ENGINE":     engT,
+			},
+			Idents: map[string]*types.Ident{
+				"EVP_MAX_MD_SIZE": c.NewIdent("EVP_MAX_MD_SIZE", "cssl.EVP_MAX_MD_SIZE", cssl.EVP_MAX_MD_SIZE, types.AsUntypedIntT(intT)),
+			},
+			Header: `
+#include <` + stddefH + `>
+
+enum {
+	EVP_MAX_MD_SIZE = 64,
+};
+
+typedef struct EVP_MD {} EVP_MD;
+typedef struct EVP_MD_CTX {} EVP_MD_CTX;
+typedef struct ENGINE {} ENGINE;
+
+// OpenSSL 1.0 names
+#define EVP_MD_CTX_create EVP_MD_CTX_new
+#define EVP_MD_CTX_destroy EVP_MD_CTX_free
+#define EVP_MD_CTX_init EVP_MD_CTX_reset
+#define EVP_MD_CTX_cleanup EVP_MD_CTX_reset
+`,
+		}
+		l.Declare(
+			c.NewIdent("EVP_md5", "cssl.EVPMd5", cssl.EVPMd5, c.FuncTT(mdPtrT)),
+			c.NewIdent("EVP_sha1", "cssl.EVPSha1", cssl.EVPSha1, c.FuncTT(mdPtrT)),
+			c.NewIdent("EVP_sha224", "cssl.EVPSha224", cssl.EVPSha224, c.FuncTT(mdPtrT)),
+			c.NewIdent("EVP_sha256", "cssl.EVPSha256", cssl.EVPSha256, c.FuncTT(mdPtrT)),
+			c.NewIdent("EVP_sha384", "cssl.EVPSha384", cssl.EVPSha384, c.FuncTT(mdPtrT)),
+			c.NewIdent("EVP_sha512", "cssl.EVPSha512", cssl.EVPSha512, c.FuncTT(mdPtrT)),
+			c.NewIdent("EVP_MD_size", "cssl.EVPMDSize", cssl.EVPMDSize, c.FuncTT(intT, mdPtrT)),
+			c.NewIdent("EVP_MD_CTX_new", "cssl.EVPMDCtxNew", cssl.EVPMDCtxNew, c.FuncTT(ctxPtrT)),
+			c.NewIdent("EVP_MD_CTX_free", "cssl.EVPMDCtxFree", cssl.EVPMDCtxFree, c.FuncTT(nil, ctxPtrT)),
+			c.NewIdent("EVP_MD_CTX_reset", "cssl.EVPMDCtxReset", cssl.EVPMDCtxReset, c.FuncTT(intT, ctxPtrT)),
+			c.NewIdent("EVP_MD_CTX_md", "cssl.EVPMDCtxMD", cssl.EVPMDCtxMD, c.FuncTT(mdPtrT, ctxPtrT)),
+			c.NewIdent("EVP_DigestInit_ex", "cssl.EVPDigestInitEx", cssl.EVPDigestInitEx, c.FuncTT(intT, ctxPtrT, mdPtrT, engPtrT)),
+			c.NewIdent("EVP_DigestInit", "cssl.EVPDigestInit", cssl.EVPDigestInit, c.FuncTT(intT, ctxPtrT, mdPtrT)),
+			c.NewIdent("EVP_DigestUpdate", "cssl.EVPDigestUpdate", cssl.EVPDigestUpdate, c.FuncTT(intT, ctxPtrT, ptrT, gintT)),
+			c.NewIdent("EVP_DigestFinal_ex", "cssl.EVPDigestFinalEx", cssl.EVPDigestFinalEx, c.FuncTT(intT, ctxPtrT, bytesT, sizePtrT)),
+			c.NewIdent("EVP_DigestFinal", "cssl.EVPDigestFinal", cssl.EVPDigestFinal, c.FuncTT(intT, ctxPtrT, bytesT, sizePtrT)),
+			c.NewIdent("EVP_Digest", "cssl.EVPDigest", cssl.EVPDigest, c.FuncTT(intT, ptrT, gintT, bytesT, sizePtrT, mdPtrT, engPtrT)),
+		)
+		return l
+	})
+	RegisterLibrary(opensslHMACH, func(c *Env) *Library {
+		gintT := c.Go().Int()
+		intT := types.IntT(4)
+		bytesT := c.PtrT(types.UintT(1))
+		sizePtrT := c.PtrT(types.UintT(4))
+		ptrT := c.PtrT(nil)
+		mdPtrT := c.PtrT(c.GetLibraryType(opensslEVPH, "EVP_MD"))
+		engPtrT := c.PtrT(c.GetLibraryType(opensslEVPH, "ENGINE"))
+		ctxT := types.NamedTGo("HMAC_CTX", "cssl.HMACCtx", types.StructT(nil))
+		ctxPtrT := c.PtrT(ctxT)
+		l := &Library{
+			Imports: map[string]string{
+				"cssl": RuntimePrefix + "cssl",
+			},
+			Types: map[string]types.Type{
+				"HMAC_CTX": ctxT,
+			},
+			Header: `
+#include <` + opensslEVPH + `>
+
+typedef struct HMAC_CTX {} HMAC_CTX;
+`,
+		}
+		l.Declare(
+			c.NewIdent("HMAC_CTX_new", "cssl.HMACCtxNew", cssl.HMACCtxNew, c.FuncTT(ctxPtrT)),
+			c.NewIdent("HMAC_CTX_free", "cssl.HMACCtxFree", cssl.HMACCtxFree, c.FuncTT(nil, ctxPtrT)),
+			c.NewIdent("HMAC_CTX_reset", "cssl.HMACCtxReset", cssl.HMACCtxReset, c.FuncTT(intT, ctxPtrT)),
+			c.NewIdent("HMAC_Init_ex", "cssl.HMACInitEx", cssl.HMACInitEx, c.FuncTT(intT, ctxPtrT, ptrT, intT, mdPtrT, engPtrT)),
+			c.NewIdent("HMAC_Update", "cssl.HMACUpdate", cssl.HMACUpdate, c.FuncTT(intT, ctxPtrT, bytesT, gintT)),
+			c.NewIdent("HMAC_Final", "cssl.HMACFinal", cssl.HMACFinal, c.FuncTT(intT, ctxPtrT, bytesT, sizePtrT)),
+			c.NewIdent("HMAC", "cssl.HMAC", cssl.HMAC, c.FuncTT(bytesT, mdPtrT, ptrT, intT, bytesT, gintT, bytesT, sizePtrT)),
+		)
+		return l
+	})
+}
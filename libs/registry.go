@@ -3,6 +3,7 @@ package libs
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/gotranspile/cxgo/types"
@@ -14,6 +15,9 @@ type Library struct {
 	Name string
 	// Header is a content of a C header file for the library.
 	// It will be protected by a ifndef guard automatically.
+	//
+	// Integer constants mapped to Go constants should be declared as enum values rather than macros,
+	// so they can be used in switch cases.
 	Header string
 	// Types overrides type definitions parsed from the library's header with a custom ones.
 	Types map[string]types.Type
@@ -21,6 +25,9 @@ type Library struct {
 	Idents map[string]*types.Ident
 
 	Imports map[string]string
+	// BlankImports lists packages imported for side effects, by the name of a package from Imports that requires them.
+	// For example, a database driver used by the package.
+	BlankImports map[string][]string
 
 	ForceMacros map[string]bool
 }
@@ -185,10 +192,13 @@ func (c *Env) GetLibrary(name string) (*Library, bool) {
 	//		l.Idents[name] = named.Name()
 	//	}
 	//}
-	c.libs[name] = l
+	c.addLibrary(name, l)
 	for k, v := range l.Imports {
 		c.imports[k] = v
 	}
+	for k, v := range l.BlankImports {
+		c.blank[k] = v
+	}
 	for k, v := range l.ForceMacros {
 		c.macros[k] = v
 	}
@@ -222,6 +232,16 @@ func (c *Env) GetLibraryType(lib, typ string) types.Type {
 	return l.GetType(typ)
 }
 
+func (c *Env) addLibrary(name string, l *Library) {
+	if _, ok := c.libs[name]; !ok {
+		i := sort.SearchStrings(c.order, name)
+		c.order = append(c.order, "")
+		copy(c.order[i+1:], c.order[i:])
+		c.order[i] = name
+	}
+	c.libs[name] = l
+}
+
 func (c *Env) NewLibrary(path string) (*Library, bool) {
 	if !strings.HasPrefix(path, IncludePath+"/") {
 		return nil, false // only override ones in our fake lookup path
@@ -237,8 +257,8 @@ func (c *Env) TypeByName(name string) (types.Type, bool) {
 	if c.NoLibs && name != BuiltinH {
 		return nil, false
 	}
-	for _, l := range c.libs {
-		if t, ok := l.Types[name]; ok {
+	for _, lname := range c.order {
+		if t, ok := c.libs[lname].Types[name]; ok {
 			return t, true
 		}
 	}
@@ -252,7 +272,8 @@ func (c *Env) LibIdentByName(name string) (*Library, *types.Ident, bool) {
 	if c.NoLibs && name != BuiltinH {
 		return nil, nil, false
 	}
-	for _, l := range c.libs {
+	for _, lname := range c.order {
+		l := c.libs[lname]
 		if id, ok := l.Idents[name]; ok {
 			return l, id, true
 		}
@@ -22,7 +22,6 @@ var autoRegLibs = []string{
 	"inttypes.h",
 	"libgen.h",
 	"sched.h",
-	"semaphore.h",
 	"signal.h",
 	"strings.h",
 
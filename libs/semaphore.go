@@ -0,0 +1,116 @@
+package libs
+
+import (
+	"github.com/gotranspile/cxgo/runtime/pthread"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	semaphoreH = "semaphore.h"
+	mqueueH    = "mqueue.h"
+)
+
+func init() {
+	RegisterLibrary(semaphoreH, func(c *Env) *Library {
+		gstrT := c.Go().String()
+		intT := types.IntT(4)
+		uintT := types.UintT(4)
+		timespecT := c.GetLibraryType(timeH, "timespec")
+		semT := types.NamedTGo("sem_t", "pthread.Sem", types.StructT(nil))
+		semPtrT := c.PtrT(semT)
+		l := &Library{
+			Imports: map[string]string{
+				"pthread": RuntimePrefix + "pthread",
+			},
+			Types: map[string]types.Type{
+				"sem_t": semT,
+			},
+			Idents: map[string]*types.Ident{
+				"SEM_VALUE_MAX": c.NewIdent("SEM_VALUE_MAX", "pthread.SEM_VALUE_MAX", pthread.SEM_VALUE_MAX, types.AsUntypedIntT(intT)),
+				"sem_timedwait": c.NewIdent("sem_timedwait", "pthread.SemTimedWait", pthread.SemTimedWait, c.FuncTT(intT, semPtrT, c.PtrT(timespecT))),
+			},
+			Header: `
+#include <` + stddefH + `>
+#include <` + timeH + `>
+#include <` + sysTypesH + `>
+
+typedef struct sem_t {} sem_t;
+
+#define SEM_FAILED NULL
+const _cxgo_sint32 SEM_VALUE_MAX = 2147483647;
+
+_cxgo_sint32 sem_timedwait(sem_t*, const struct timespec*);
+`,
+		}
+		l.Declare(
+			c.NewIdent("sem_init", "pthread.SemInit", pthread.SemInit, c.FuncTT(intT, semPtrT, intT, uintT)),
+			c.NewIdent("sem_destroy", "pthread.SemDestroy", pthread.SemDestroy, c.FuncTT(intT, semPtrT)),
+			c.NewIdent("sem_post", "pthread.SemPost", pthread.SemPost, c.FuncTT(intT, semPtrT)),
+			c.NewIdent("sem_wait", "pthread.SemWait", pthread.SemWait, c.FuncTT(intT, semPtrT)),
+			c.NewIdent("sem_trywait", "pthread.SemTryWait", pthread.SemTryWait, c.FuncTT(intT, semPtrT)),
+			c.NewIdent("sem_getvalue", "pthread.SemGetValue", pthread.SemGetValue, c.FuncTT(intT, semPtrT, c.PtrT(intT))),
+			c.NewIdent("sem_open", "pthread.SemOpen", pthread.SemOpen, c.VarFuncTT(semPtrT, gstrT, intT)),
+			c.NewIdent("sem_close", "pthread.SemClose", pthread.SemClose, c.FuncTT(intT, semPtrT)),
+			c.NewIdent("sem_unlink", "pthread.SemUnlink", pthread.SemUnlink, c.FuncTT(intT, gstrT)),
+		)
+		return l
+	})
+	RegisterLibrary(mqueueH, func(c *Env) *Library {
+		gintT := c.Go().Int()
+		gstrT := c.Go().String()
+		strT := c.C().String()
+		intT := types.IntT(4)
+		uintT := types.UintT(4)
+		timespecT := c.GetLibraryType(timeH, "timespec")
+		mqT := types.NamedTGo("mqd_t", "pthread.MQ", intT)
+		attrT := types.NamedTGo("mq_attr", "pthread.MQAttr", types.StructT([]*types.Field{
+			{Name: types.NewIdentGo("mq_flags", "Flags", gintT)},
+			{Name: types.NewIdentGo("mq_maxmsg", "MaxMsg", gintT)},
+			{Name: types.NewIdentGo("mq_msgsize", "MsgSize", gintT)},
+			{Name: types.NewIdentGo("mq_curmsgs", "CurMsgs", gintT)},
+		}))
+		attrPtrT := c.PtrT(attrT)
+		l := &Library{
+			Imports: map[string]string{
+				"pthread": RuntimePrefix + "pthread",
+			},
+			Types: map[string]types.Type{
+				"mqd_t":   mqT,
+				"mq_attr": attrT,
+			},
+			Idents: map[string]*types.Ident{
+				"O_NONBLOCK":      c.NewIdent("O_NONBLOCK", "pthread.O_NONBLOCK", pthread.O_NONBLOCK, types.AsUntypedIntT(intT)),
+				"mq_timedsend":    c.NewIdent("mq_timedsend", "pthread.MqTimedSend", pthread.MqTimedSend, c.FuncTT(intT, mqT, strT, gintT, uintT, c.PtrT(timespecT))),
+				"mq_timedreceive": c.NewIdent("mq_timedreceive", "pthread.MqTimedReceive", pthread.MqTimedReceive, c.FuncTT(gintT, mqT, strT, gintT, c.PtrT(uintT), c.PtrT(timespecT))),
+			},
+			Header: `
+#include <` + timeH + `>
+#include <` + sysTypesH + `>
+
+typedef _cxgo_sint32 mqd_t;
+
+typedef struct mq_attr {
+	_cxgo_go_int mq_flags;
+	_cxgo_go_int mq_maxmsg;
+	_cxgo_go_int mq_msgsize;
+	_cxgo_go_int mq_curmsgs;
+} mq_attr;
+
+const _cxgo_sint32 O_NONBLOCK = 0x800;
+
+_cxgo_sint32 mq_timedsend(mqd_t, const char*, _cxgo_go_int, _cxgo_uint32, const struct timespec*);
+_cxgo_go_int mq_timedreceive(mqd_t, char*, _cxgo_go_int, _cxgo_uint32*, const struct timespec*);
+`,
+		}
+		l.Declare(
+			c.NewIdent("mq_open", "pthread.MqOpen", pthread.MqOpen, c.VarFuncTT(mqT, gstrT, intT)),
+			c.NewIdent("mq_close", "pthread.MqClose", pthread.MqClose, c.FuncTT(intT, mqT)),
+			c.NewIdent("mq_unlink", "pthread.MqUnlink", pthread.MqUnlink, c.FuncTT(intT, gstrT)),
+			c.NewIdent("mq_send", "pthread.MqSend", pthread.MqSend, c.FuncTT(intT, mqT, strT, gintT, uintT)),
+			c.NewIdent("mq_receive", "pthread.MqReceive", pthread.MqReceive, c.FuncTT(gintT, mqT, strT, gintT, c.PtrT(uintT))),
+			c.NewIdent("mq_getattr", "pthread.MqGetAttr", pthread.MqGetAttr, c.FuncTT(intT, mqT, attrPtrT)),
+			c.NewIdent("mq_setattr", "pthread.MqSetAttr", pthread.MqSetAttr, c.FuncTT(intT, mqT, attrPtrT, attrPtrT)),
+		)
+		return l
+	})
+}
@@ -0,0 +1,222 @@
+package libs
+
+import (
+	"github.com/gotranspile/cxgo/runtime/simd"
+	"github.com/gotranspile/cxgo/types"
+)
+
+// Intrinsics are implemented by scalar Go functions. A package with the same API may be used instead with
+// Config.ImportPaths, for example, to use native SIMD instructions.
+
+const (
+	xmmintrinH = "xmmintrin.h"
+	emmintrinH = "emmintrin.h"
+	armNeonH   = "arm_neon.h"
+)
+
+func init() {
+	RegisterLibrary(xmmintrinH, func(c *Env) *Library {
+		fltT := types.FloatT(4)
+		m128T := types.NamedTGo("__m128", "simd.M128", types.StructT([]*types.Field{
+			{Name: types.NewIdentGo("_v", "v", types.ArrayT(fltT, 4))},
+		}))
+		l := &Library{
+			Imports: map[string]string{
+				"simd": RuntimePrefix + "simd",
+			},
+			Types: map[string]types.Type{
+				"__m128": m128T,
+			},
+			Header: `
+typedef struct __m128 {
+	float _v[4];
+} __m128;
+
+#define _mm_load_ps _mm_loadu_ps
+#define _mm_store_ps _mm_storeu_ps
+`,
+		}
+		binT := c.FuncTT(m128T, m128T, m128T)
+		l.Declare(
+			c.NewIdent("_mm_setzero_ps", "simd.SetzeroPs", simd.SetzeroPs, c.FuncTT(m128T)),
+			c.NewIdent("_mm_set1_ps", "simd.Set1Ps", simd.Set1Ps, c.FuncTT(m128T, fltT)),
+			c.NewIdent("_mm_set_ps", "simd.SetPs", simd.SetPs, c.FuncTT(m128T, fltT, fltT, fltT, fltT)),
+			c.NewIdent("_mm_loadu_ps", "simd.LoadPs", simd.LoadPs, c.FuncTT(m128T, c.PtrT(fltT))),
+			c.NewIdent("_mm_storeu_ps", "simd.StorePs", simd.StorePs, c.FuncTT(nil, c.PtrT(fltT), m128T)),
+			c.NewIdent("_mm_cvtss_f32", "simd.CvtssF32", simd.CvtssF32, c.FuncTT(fltT, m128T)),
+			c.NewIdent("_mm_add_ps", "simd.AddPs", simd.AddPs, binT),
+			c.NewIdent("_mm_sub_ps", "simd.SubPs", simd.SubPs, binT),
+			c.NewIdent("_mm_mul_ps", "simd.MulPs", simd.MulPs, binT),
+			c.NewIdent("_mm_div_ps", "simd.DivPs", simd.DivPs, binT),
+			c.NewIdent("_mm_min_ps", "simd.MinPs", simd.MinPs, binT),
+			c.NewIdent("_mm_max_ps", "simd.MaxPs", simd.MaxPs, binT),
+			c.NewIdent("_mm_sqrt_ps", "simd.SqrtPs", simd.SqrtPs, c.FuncTT(m128T, m128T)),
+		)
+		return l
+	})
+	RegisterLibrary(emmintrinH, func(c *Env) *Library {
+		i8T, i16T, i32T, i64T := types.IntT(1), types.IntT(2), types.IntT(4), types.IntT(8)
+		dblT := types.FloatT(8)
+		m128iT := types.NamedTGo("__m128i", "simd.M128i", types.StructT([]*types.Field{
+			{Name: types.NewIdentGo("_v", "v", types.ArrayT(types.UintT(1), 16))},
+		}))
+		m128dT := types.NamedTGo("__m128d", "simd.M128d", types.StructT([]*types.Field{
+			{Name: types.NewIdentGo("_v", "v", types.ArrayT(dblT, 2))},
+		}))
+		l := &Library{
+			Imports: map[string]string{
+				"simd": RuntimePrefix + "simd",
+			},
+			Types: map[string]types.Type{
+				"__m128i": m128iT,
+				"__m128d": m128dT,
+			},
+			Header: `
+#include <` + xmmintrinH + `>
+
+typedef struct __m128i {
+	unsigned char _v[16];
+} __m128i;
+
+typedef struct __m128d {
+	double _v[2];
+} __m128d;
+
+#define _mm_load_si128 _mm_loadu_si128
+#define _mm_store_si128 _mm_storeu_si128
+#define _mm_load_pd _mm_loadu_pd
+#define _mm_store_pd _mm_storeu_pd
+`,
+		}
+		binT := c.FuncTT(m128iT, m128iT, m128iT)
+		shiftT := c.FuncTT(m128iT, m128iT, i32T)
+		binPdT := c.FuncTT(m128dT, m128dT, m128dT)
+		l.Declare(
+			c.NewIdent("_mm_setzero_si128", "simd.SetzeroSi128", simd.SetzeroSi128, c.FuncTT(m128iT)),
+			c.NewIdent("_mm_set1_epi8", "simd.Set1Epi8", simd.Set1Epi8, c.FuncTT(m128iT, i8T)),
+			c.NewIdent("_mm_set1_epi16", "simd.Set1Epi16", simd.Set1Epi16, c.FuncTT(m128iT, i16T)),
+			c.NewIdent("_mm_set1_epi32", "simd.Set1Epi32", simd.Set1Epi32, c.FuncTT(m128iT, i32T)),
+			c.NewIdent("_mm_set1_epi64x", "simd.Set1Epi64x", simd.Set1Epi64x, c.FuncTT(m128iT, i64T)),
+			c.NewIdent("_mm_set_epi32", "simd.SetEpi32", simd.SetEpi32, c.FuncTT(m128iT, i32T, i32T, i32T, i32T)),
+			c.NewIdent("_mm_loadu_si128", "simd.LoadSi128", simd.LoadSi128, c.FuncTT(m128iT, c.PtrT(m128iT))),
+			c.NewIdent("_mm_storeu_si128", "simd.StoreSi128", simd.StoreSi128, c.FuncTT(nil, c.PtrT(m128iT), m128iT)),
+			c.NewIdent("_mm_cvtsi32_si128", "simd.Cvtsi32Si128", simd.Cvtsi32Si128, c.FuncTT(m128iT, i32T)),
+			c.NewIdent("_mm_cvtsi128_si32", "simd.Cvtsi128Si32", simd.Cvtsi128Si32, c.FuncTT(i32T, m128iT)),
+			c.NewIdent("_mm_add_epi8", "simd.AddEpi8", simd.AddEpi8, binT),
+			c.NewIdent("_mm_add_epi16", "simd.AddEpi16", simd.AddEpi16, binT),
+			c.NewIdent("_mm_add_epi32", "simd.AddEpi32", simd.AddEpi32, binT),
+			c.NewIdent("_mm_add_epi64", "simd.AddEpi64", simd.AddEpi64, binT),
+			c.NewIdent("_mm_sub_epi8", "simd.SubEpi8", simd.SubEpi8, binT),
+			c.NewIdent("_mm_sub_epi16", "simd.SubEpi16", simd.SubEpi16, binT),
+			c.NewIdent("_mm_sub_epi32", "simd.SubEpi32", simd.SubEpi32, binT),
+			c.NewIdent("_mm_sub_epi64", "simd.SubEpi64", simd.SubEpi64, binT),
+			c.NewIdent("_mm_mullo_epi16", "simd.MulloEpi16", simd.MulloEpi16, binT),
+			c.NewIdent("_mm_and_si128", "simd.AndSi128", simd.AndSi128, binT),
+			c.NewIdent("_mm_andnot_si128", "simd.AndnotSi128", simd.AndnotSi128, binT),
+			c.NewIdent("_mm_or_si128", "simd.OrSi128", simd.OrSi128, binT),
+			c.NewIdent("_mm_xor_si128", "simd.XorSi128", simd.XorSi128, binT),
+			c.NewIdent("_mm_cmpeq_epi8", "simd.CmpeqEpi8", simd.CmpeqEpi8, binT),
+			c.NewIdent("_mm_cmpeq_epi16", "simd.CmpeqEpi16", simd.CmpeqEpi16, binT),
+			c.NewIdent("_mm_cmpeq_epi32", "simd.CmpeqEpi32", simd.CmpeqEpi32, binT),
+			c.NewIdent("_mm_cmpgt_epi8", "simd.CmpgtEpi8", simd.CmpgtEpi8, binT),
+			c.NewIdent("_mm_cmpgt_epi32", "simd.CmpgtEpi32", simd.CmpgtEpi32, binT),
+			c.NewIdent("_mm_movemask_epi8", "simd.MovemaskEpi8", simd.MovemaskEpi8, c.FuncTT(i32T, m128iT)),
+			c.NewIdent("_mm_slli_epi32", "simd.SlliEpi32", simd.SlliEpi32, shiftT),
+			c.NewIdent("_mm_srli_epi32", "simd.SrliEpi32", simd.SrliEpi32, shiftT),
+			c.NewIdent("_mm_srai_epi32", "simd.SraiEpi32", simd.SraiEpi32, shiftT),
+			c.NewIdent("_mm_setzero_pd", "simd.SetzeroPd", simd.SetzeroPd, c.FuncTT(m128dT)),
+			c.NewIdent("_mm_set1_pd", "simd.Set1Pd", simd.Set1Pd, c.FuncTT(m128dT, dblT)),
+			c.NewIdent("_mm_set_pd", "simd.SetPd", simd.SetPd, c.FuncTT(m128dT, dblT, dblT)),
+			c.NewIdent("_mm_loadu_pd", "simd.LoadPd", simd.LoadPd, c.FuncTT(m128dT, c.PtrT(dblT))),
+			c.NewIdent("_mm_storeu_pd", "simd.StorePd", simd.StorePd, c.FuncTT(nil, c.PtrT(dblT), m128dT)),
+			c.NewIdent("_mm_cvtsd_f64", "simd.CvtsdF64", simd.CvtsdF64, c.FuncTT(dblT, m128dT)),
+			c.NewIdent("_mm_add_pd", "simd.AddPd", simd.AddPd, binPdT),
+			c.NewIdent("_mm_sub_pd", "simd.SubPd", simd.SubPd, binPdT),
+			c.NewIdent("_mm_mul_pd", "simd.MulPd", simd.MulPd, binPdT),
+			c.NewIdent("_mm_div_pd", "simd.DivPd", simd.DivPd, binPdT),
+			c.NewIdent("_mm_sqrt_pd", "simd.SqrtPd", simd.SqrtPd, c.FuncTT(m128dT, m128dT)),
+		)
+		return l
+	})
+	RegisterLibrary(armNeonH, func(c *Env) *Library {
+		i32T, u32T, u8T, fltT := types.IntT(4), types.UintT(4), types.UintT(1), types.FloatT(4)
+		vecT := func(cname, goname string, elem types.Type, n int) types.Type {
+			return types.NamedTGo(cname, goname, types.StructT([]*types.Field{
+				{Name: types.NewIdentGo("_v", "v", types.ArrayT(elem, n))},
+			}))
+		}
+		s32x4T := vecT("int32x4_t", "simd.Int32x4", i32T, 4)
+		u32x4T := vecT("uint32x4_t", "simd.Uint32x4", u32T, 4)
+		u8x16T := vecT("uint8x16_t", "simd.Uint8x16", u8T, 16)
+		f32x4T := vecT("float32x4_t", "simd.Float32x4", fltT, 4)
+		l := &Library{
+			Imports: map[string]string{
+				"simd": RuntimePrefix + "simd",
+			},
+			Types: map[string]types.Type{
+				"int32x4_t":   s32x4T,
+				"uint32x4_t":  u32x4T,
+				"uint8x16_t":  u8x16T,
+				"float32x4_t": f32x4T,
+			},
+			Header: `
+#include <` + stdintH + `>
+
+typedef struct int32x4_t {
+	int32_t _v[4];
+} int32x4_t;
+
+typedef struct uint32x4_t {
+	uint32_t _v[4];
+} uint32x4_t;
+
+typedef struct uint8x16_t {
+	uint8_t _v[16];
+} uint8x16_t;
+
+typedef struct float32x4_t {
+	float _v[4];
+} float32x4_t;
+`,
+		}
+		binS32T := c.FuncTT(s32x4T, s32x4T, s32x4T)
+		binU8T := c.FuncTT(u8x16T, u8x16T, u8x16T)
+		binF32T := c.FuncTT(f32x4T, f32x4T, f32x4T)
+		l.Declare(
+			c.NewIdent("vdupq_n_s32", "simd.VdupqNS32", simd.VdupqNS32, c.FuncTT(s32x4T, i32T)),
+			c.NewIdent("vld1q_s32", "simd.Vld1qS32", simd.Vld1qS32, c.FuncTT(s32x4T, c.PtrT(i32T))),
+			c.NewIdent("vst1q_s32", "simd.Vst1qS32", simd.Vst1qS32, c.FuncTT(nil, c.PtrT(i32T), s32x4T)),
+			c.NewIdent("vgetq_lane_s32", "simd.VgetqLaneS32", simd.VgetqLaneS32, c.FuncTT(i32T, s32x4T, i32T)),
+			c.NewIdent("vaddq_s32", "simd.VaddqS32", simd.VaddqS32, binS32T),
+			c.NewIdent("vsubq_s32", "simd.VsubqS32", simd.VsubqS32, binS32T),
+			c.NewIdent("vmulq_s32", "simd.VmulqS32", simd.VmulqS32, binS32T),
+			c.NewIdent("vandq_s32", "simd.VandqS32", simd.VandqS32, binS32T),
+			c.NewIdent("vorrq_s32", "simd.VorrqS32", simd.VorrqS32, binS32T),
+			c.NewIdent("veorq_s32", "simd.VeorqS32", simd.VeorqS32, binS32T),
+			c.NewIdent("vceqq_s32", "simd.VceqqS32", simd.VceqqS32, c.FuncTT(u32x4T, s32x4T, s32x4T)),
+			c.NewIdent("vaddvq_s32", "simd.VaddvqS32", simd.VaddvqS32, c.FuncTT(i32T, s32x4T)),
+			c.NewIdent("vgetq_lane_u32", "simd.VgetqLaneU32", simd.VgetqLaneU32, c.FuncTT(u32T, u32x4T, i32T)),
+			c.NewIdent("vdupq_n_u8", "simd.VdupqNU8", simd.VdupqNU8, c.FuncTT(u8x16T, u8T)),
+			c.NewIdent("vld1q_u8", "simd.Vld1qU8", simd.Vld1qU8, c.FuncTT(u8x16T, c.PtrT(u8T))),
+			c.NewIdent("vst1q_u8", "simd.Vst1qU8", simd.Vst1qU8, c.FuncTT(nil, c.PtrT(u8T), u8x16T)),
+			c.NewIdent("vgetq_lane_u8", "simd.VgetqLaneU8", simd.VgetqLaneU8, c.FuncTT(u8T, u8x16T, i32T)),
+			c.NewIdent("vaddq_u8", "simd.VaddqU8", simd.VaddqU8, binU8T),
+			c.NewIdent("vsubq_u8", "simd.VsubqU8", simd.VsubqU8, binU8T),
+			c.NewIdent("vandq_u8", "simd.VandqU8", simd.VandqU8, binU8T),
+			c.NewIdent("vorrq_u8", "simd.VorrqU8", simd.VorrqU8, binU8T),
+			c.NewIdent("veorq_u8", "simd.VeorqU8", simd.VeorqU8, binU8T),
+			c.NewIdent("vceqq_u8", "simd.VceqqU8", simd.VceqqU8, binU8T),
+			c.NewIdent("vmaxvq_u8", "simd.VmaxvqU8", simd.VmaxvqU8, c.FuncTT(u8T, u8x16T)),
+			c.NewIdent("vdupq_n_f32", "simd.VdupqNF32", simd.VdupqNF32, c.FuncTT(f32x4T, fltT)),
+			c.NewIdent("vld1q_f32", "simd.Vld1qF32", simd.Vld1qF32, c.FuncTT(f32x4T, c.PtrT(fltT))),
+			c.NewIdent("vst1q_f32", "simd.Vst1qF32", simd.Vst1qF32, c.FuncTT(nil, c.PtrT(fltT), f32x4T)),
+			c.NewIdent("vgetq_lane_f32", "simd.VgetqLaneF32", simd.VgetqLaneF32, c.FuncTT(fltT, f32x4T, i32T)),
+			c.NewIdent("vaddq_f32", "simd.VaddqF32", simd.VaddqF32, binF32T),
+			c.NewIdent("vsubq_f32", "simd.VsubqF32", simd.VsubqF32, binF32T),
+			c.NewIdent("vmulq_f32", "simd.VmulqF32", simd.VmulqF32, binF32T),
+			c.NewIdent("vmlaq_f32", "simd.VmlaqF32", simd.VmlaqF32, c.FuncTT(f32x4T, f32x4T, f32x4T, f32x4T)),
+			c.NewIdent("vaddvq_f32", "simd.VaddvqF32", simd.VaddvqF32, c.FuncTT(fltT, f32x4T)),
+		)
+		return l
+	})
+}
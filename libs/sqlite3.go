@@ -0,0 +1,183 @@
+package libs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotranspile/cxgo/runtime/csqlite"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	sqlite3H = "sqlite3.h"
+	// sqliteDriver is a database/sql driver registered with csqlite.DriverName.
+	sqliteDriver = "modernc.org/sqlite"
+)
+
+func init() {
+	RegisterLibrary(sqlite3H, func(c *Env) *Library {
+		gstrT := c.Go().String()
+		strT := c.C().String()
+		ptrT := c.PtrT(nil)
+		intT := types.IntT(4)
+		uintT := types.UintT(4)
+		int64T := types.IntT(8)
+		dblT := types.FloatT(8)
+		dbT := types.NamedTGo("sqlite3", "csqlite.DB", types.StructT(nil))
+		dbPtrT := c.PtrT(dbT)
+		stmtT := types.NamedTGo("sqlite3_stmt", "csqlite.Stmt", types.StructT(nil))
+		stmtPtrT := c.PtrT(stmtT)
+		dtorT := types.NamedTGo("sqlite3_destructor_type", "csqlite.Destructor", c.FuncTT(nil, ptrT))
+		cbT := types.NamedTGo("sqlite3_callback", "csqlite.ExecCallback", c.FuncTT(intT, ptrT, intT, c.PtrT(strT), c.PtrT(strT)))
+
+		var hdr strings.Builder
+		hdr.WriteString(`
+#include <` + stddefH + `>
+
+#define SQLITE_VERSION "` + csqlite.Version + `"
+#define SQLITE_VERSION_NUMBER 3041002
+
+#define sqlite_int64 _cxgo_sint64
+#define sqlite_uint64 _cxgo_uint64
+#define sqlite3_int64 _cxgo_sint64
+#define sqlite3_uint64 _cxgo_uint64
+
+typedef struct sqlite3 {} sqlite3;
+typedef struct sqlite3_stmt {} sqlite3_stmt;
+typedef void (*sqlite3_destructor_type)(void*);
+typedef int (*sqlite3_callback)(void*, int, char**, char**);
+
+sqlite3_destructor_type SQLITE_STATIC;
+sqlite3_destructor_type SQLITE_TRANSIENT;
+
+enum {
+`)
+		idents := map[string]*types.Ident{
+			"SQLITE_STATIC":    c.NewIdent("SQLITE_STATIC", "csqlite.SQLITE_STATIC", csqlite.SQLITE_STATIC, dtorT),
+			"SQLITE_TRANSIENT": c.NewIdent("SQLITE_TRANSIENT", "csqlite.SQLITE_TRANSIENT", csqlite.SQLITE_TRANSIENT, dtorT),
+		}
+		for _, v := range []struct {
+			name  string
+			value int
+		}{
+			{"SQLITE_OK", csqlite.SQLITE_OK},
+			{"SQLITE_ERROR", csqlite.SQLITE_ERROR},
+			{"SQLITE_INTERNAL", csqlite.SQLITE_INTERNAL},
+			{"SQLITE_PERM", csqlite.SQLITE_PERM},
+			{"SQLITE_ABORT", csqlite.SQLITE_ABORT},
+			{"SQLITE_BUSY", csqlite.SQLITE_BUSY},
+			{"SQLITE_LOCKED", csqlite.SQLITE_LOCKED},
+			{"SQLITE_NOMEM", csqlite.SQLITE_NOMEM},
+			{"SQLITE_READONLY", csqlite.SQLITE_READONLY},
+			{"SQLITE_INTERRUPT", csqlite.SQLITE_INTERRUPT},
+			{"SQLITE_IOERR", csqlite.SQLITE_IOERR},
+			{"SQLITE_CORRUPT", csqlite.SQLITE_CORRUPT},
+			{"SQLITE_NOTFOUND", csqlite.SQLITE_NOTFOUND},
+			{"SQLITE_FULL", csqlite.SQLITE_FULL},
+			{"SQLITE_CANTOPEN", csqlite.SQLITE_CANTOPEN},
+			{"SQLITE_PROTOCOL", csqlite.SQLITE_PROTOCOL},
+			{"SQLITE_EMPTY", csqlite.SQLITE_EMPTY},
+			{"SQLITE_SCHEMA", csqlite.SQLITE_SCHEMA},
+			{"SQLITE_TOOBIG", csqlite.SQLITE_TOOBIG},
+			{"SQLITE_CONSTRAINT", csqlite.SQLITE_CONSTRAINT},
+			{"SQLITE_MISMATCH", csqlite.SQLITE_MISMATCH},
+			{"SQLITE_MISUSE", csqlite.SQLITE_MISUSE},
+			{"SQLITE_NOLFS", csqlite.SQLITE_NOLFS},
+			{"SQLITE_AUTH", csqlite.SQLITE_AUTH},
+			{"SQLITE_FORMAT", csqlite.SQLITE_FORMAT},
+			{"SQLITE_RANGE", csqlite.SQLITE_RANGE},
+			{"SQLITE_NOTADB", csqlite.SQLITE_NOTADB},
+			{"SQLITE_NOTICE", csqlite.SQLITE_NOTICE},
+			{"SQLITE_WARNING", csqlite.SQLITE_WARNING},
+			{"SQLITE_ROW", csqlite.SQLITE_ROW},
+			{"SQLITE_DONE", csqlite.SQLITE_DONE},
+			{"SQLITE_CONSTRAINT_CHECK", csqlite.SQLITE_CONSTRAINT_CHECK},
+			{"SQLITE_CONSTRAINT_FOREIGNKEY", csqlite.SQLITE_CONSTRAINT_FOREIGNKEY},
+			{"SQLITE_CONSTRAINT_NOTNULL", csqlite.SQLITE_CONSTRAINT_NOTNULL},
+			{"SQLITE_CONSTRAINT_PRIMARYKEY", csqlite.SQLITE_CONSTRAINT_PRIMARYKEY},
+			{"SQLITE_CONSTRAINT_UNIQUE", csqlite.SQLITE_CONSTRAINT_UNIQUE},
+			{"SQLITE_INTEGER", csqlite.SQLITE_INTEGER},
+			{"SQLITE_FLOAT", csqlite.SQLITE_FLOAT},
+			{"SQLITE_TEXT", csqlite.SQLITE_TEXT},
+			{"SQLITE_BLOB", csqlite.SQLITE_BLOB},
+			{"SQLITE_NULL", csqlite.SQLITE_NULL},
+			{"SQLITE_OPEN_READONLY", csqlite.SQLITE_OPEN_READONLY},
+			{"SQLITE_OPEN_READWRITE", csqlite.SQLITE_OPEN_READWRITE},
+			{"SQLITE_OPEN_CREATE", csqlite.SQLITE_OPEN_CREATE},
+			{"SQLITE_OPEN_URI", csqlite.SQLITE_OPEN_URI},
+			{"SQLITE_OPEN_MEMORY", csqlite.SQLITE_OPEN_MEMORY},
+			{"SQLITE_OPEN_NOMUTEX", csqlite.SQLITE_OPEN_NOMUTEX},
+			{"SQLITE_OPEN_FULLMUTEX", csqlite.SQLITE_OPEN_FULLMUTEX},
+			{"SQLITE_OPEN_SHAREDCACHE", csqlite.SQLITE_OPEN_SHAREDCACHE},
+			{"SQLITE_OPEN_PRIVATECACHE", csqlite.SQLITE_OPEN_PRIVATECACHE},
+		} {
+			idents[v.name] = c.NewIdent(v.name, "csqlite."+v.name, v.value, types.AsUntypedIntT(intT))
+			fmt.Fprintf(&hdr, "\t%s = %d,\n", v.name, v.value)
+		}
+		hdr.WriteString("};\n")
+		l := &Library{
+			Imports: map[string]string{
+				"csqlite": RuntimePrefix + "csqlite",
+			},
+			BlankImports: map[string][]string{
+				"csqlite": {sqliteDriver},
+			},
+			Types: map[string]types.Type{
+				"sqlite3":                 dbT,
+				"sqlite3_stmt":            stmtT,
+				"sqlite3_destructor_type": dtorT,
+				"sqlite3_callback":        cbT,
+			},
+			Idents: idents,
+			Header: hdr.String(),
+		}
+		l.Declare(
+			c.NewIdent("sqlite3_libversion", "csqlite.Libversion", csqlite.Libversion, c.FuncTT(strT)),
+			c.NewIdent("sqlite3_free", "csqlite.Free", csqlite.Free, c.FuncTT(nil, ptrT)),
+			c.NewIdent("sqlite3_open", "csqlite.Open", csqlite.Open, c.FuncTT(intT, gstrT, c.PtrT(dbPtrT))),
+			c.NewIdent("sqlite3_open_v2", "csqlite.OpenV2", csqlite.OpenV2, c.FuncTT(intT, gstrT, c.PtrT(dbPtrT), intT, strT)),
+			c.NewIdent("sqlite3_close", "csqlite.Close", csqlite.Close, c.FuncTT(intT, dbPtrT)),
+			c.NewIdent("sqlite3_close_v2", "csqlite.CloseV2", csqlite.CloseV2, c.FuncTT(intT, dbPtrT)),
+			c.NewIdent("sqlite3_errcode", "csqlite.Errcode", csqlite.Errcode, c.FuncTT(intT, dbPtrT)),
+			c.NewIdent("sqlite3_extended_errcode", "csqlite.ExtendedErrcode", csqlite.ExtendedErrcode, c.FuncTT(intT, dbPtrT)),
+			c.NewIdent("sqlite3_errmsg", "csqlite.Errmsg", csqlite.Errmsg, c.FuncTT(strT, dbPtrT)),
+			c.NewIdent("sqlite3_errstr", "csqlite.Errstr", csqlite.Errstr, c.FuncTT(strT, intT)),
+			c.NewIdent("sqlite3_changes", "csqlite.Changes", csqlite.Changes, c.FuncTT(intT, dbPtrT)),
+			c.NewIdent("sqlite3_total_changes", "csqlite.TotalChanges", csqlite.TotalChanges, c.FuncTT(intT, dbPtrT)),
+			c.NewIdent("sqlite3_last_insert_rowid", "csqlite.LastInsertRowid", csqlite.LastInsertRowid, c.FuncTT(int64T, dbPtrT)),
+			c.NewIdent("sqlite3_busy_timeout", "csqlite.BusyTimeout", csqlite.BusyTimeout, c.FuncTT(intT, dbPtrT, intT)),
+			c.NewIdent("sqlite3_exec", "csqlite.Exec", csqlite.Exec, c.FuncTT(intT, dbPtrT, gstrT, cbT, ptrT, c.PtrT(strT))),
+
+			c.NewIdent("sqlite3_prepare", "csqlite.Prepare", csqlite.Prepare, c.FuncTT(intT, dbPtrT, strT, intT, c.PtrT(stmtPtrT), c.PtrT(strT))),
+			c.NewIdent("sqlite3_prepare_v2", "csqlite.PrepareV2", csqlite.PrepareV2, c.FuncTT(intT, dbPtrT, strT, intT, c.PtrT(stmtPtrT), c.PtrT(strT))),
+			c.NewIdent("sqlite3_prepare_v3", "csqlite.PrepareV3", csqlite.PrepareV3, c.FuncTT(intT, dbPtrT, strT, intT, uintT, c.PtrT(stmtPtrT), c.PtrT(strT))),
+			c.NewIdent("sqlite3_sql", "csqlite.Sql", csqlite.Sql, c.FuncTT(strT, stmtPtrT)),
+			c.NewIdent("sqlite3_step", "csqlite.Step", csqlite.Step, c.FuncTT(intT, stmtPtrT)),
+			c.NewIdent("sqlite3_reset", "csqlite.Reset", csqlite.Reset, c.FuncTT(intT, stmtPtrT)),
+			c.NewIdent("sqlite3_finalize", "csqlite.Finalize", csqlite.Finalize, c.FuncTT(intT, stmtPtrT)),
+
+			c.NewIdent("sqlite3_bind_parameter_count", "csqlite.BindParameterCount", csqlite.BindParameterCount, c.FuncTT(intT, stmtPtrT)),
+			c.NewIdent("sqlite3_bind_parameter_index", "csqlite.BindParameterIndex", csqlite.BindParameterIndex, c.FuncTT(intT, stmtPtrT, gstrT)),
+			c.NewIdent("sqlite3_bind_parameter_name", "csqlite.BindParameterName", csqlite.BindParameterName, c.FuncTT(strT, stmtPtrT, intT)),
+			c.NewIdent("sqlite3_clear_bindings", "csqlite.ClearBindings", csqlite.ClearBindings, c.FuncTT(intT, stmtPtrT)),
+			c.NewIdent("sqlite3_bind_null", "csqlite.BindNull", csqlite.BindNull, c.FuncTT(intT, stmtPtrT, intT)),
+			c.NewIdent("sqlite3_bind_int", "csqlite.BindInt", csqlite.BindInt, c.FuncTT(intT, stmtPtrT, intT, intT)),
+			c.NewIdent("sqlite3_bind_int64", "csqlite.BindInt64", csqlite.BindInt64, c.FuncTT(intT, stmtPtrT, intT, int64T)),
+			c.NewIdent("sqlite3_bind_double", "csqlite.BindDouble", csqlite.BindDouble, c.FuncTT(intT, stmtPtrT, intT, dblT)),
+			c.NewIdent("sqlite3_bind_text", "csqlite.BindText", csqlite.BindText, c.FuncTT(intT, stmtPtrT, intT, strT, intT, dtorT)),
+			c.NewIdent("sqlite3_bind_blob", "csqlite.BindBlob", csqlite.BindBlob, c.FuncTT(intT, stmtPtrT, intT, ptrT, intT, dtorT)),
+
+			c.NewIdent("sqlite3_column_count", "csqlite.ColumnCount", csqlite.ColumnCount, c.FuncTT(intT, stmtPtrT)),
+			c.NewIdent("sqlite3_data_count", "csqlite.DataCount", csqlite.DataCount, c.FuncTT(intT, stmtPtrT)),
+			c.NewIdent("sqlite3_column_name", "csqlite.ColumnName", csqlite.ColumnName, c.FuncTT(strT, stmtPtrT, intT)),
+			c.NewIdent("sqlite3_column_type", "csqlite.ColumnType", csqlite.ColumnType, c.FuncTT(intT, stmtPtrT, intT)),
+			c.NewIdent("sqlite3_column_int", "csqlite.ColumnInt", csqlite.ColumnInt, c.FuncTT(intT, stmtPtrT, intT)),
+			c.NewIdent("sqlite3_column_int64", "csqlite.ColumnInt64", csqlite.ColumnInt64, c.FuncTT(int64T, stmtPtrT, intT)),
+			c.NewIdent("sqlite3_column_double", "csqlite.ColumnDouble", csqlite.ColumnDouble, c.FuncTT(dblT, stmtPtrT, intT)),
+			c.NewIdent("sqlite3_column_text", "csqlite.ColumnText", csqlite.ColumnText, c.FuncTT(c.PtrT(types.UintT(1)), stmtPtrT, intT)),
+			c.NewIdent("sqlite3_column_blob", "csqlite.ColumnBlob", csqlite.ColumnBlob, c.FuncTT(ptrT, stmtPtrT, intT)),
+			c.NewIdent("sqlite3_column_bytes", "csqlite.ColumnBytes", csqlite.ColumnBytes, c.FuncTT(intT, stmtPtrT, intT)),
+		)
+		return l
+	})
+}
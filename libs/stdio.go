@@ -99,14 +99,12 @@ const int _cxgo_EOF = -1;
 		}
 
 		l.Declare(
-			c.NewIdent("_cxgo_getStdout", "stdio.Stdout", stdio.Stdout, c.FuncTT(filePtr)),
 			c.NewIdent("_cxgo_getStderr", "stdio.Stderr", stdio.Stderr, c.FuncTT(filePtr)),
 			c.NewIdent("_cxgo_getStdin", "stdio.Stdin", stdio.Stdin, c.FuncTT(filePtr)),
 			c.NewIdent("_cxgo_fileByFD", "stdio.ByFD", stdio.ByFD, c.FuncTT(filePtr, c.Go().Uintptr())),
 			c.NewIdent("fopen", "stdio.FOpen", stdio.FOpen, c.FuncTT(filePtr, gstrT, gstrT)),
 			c.NewIdent("fdopen", "stdio.FDOpen", stdio.FDOpen, c.FuncTT(filePtr, c.Go().Uintptr(), gstrT)),
 			// note: printf itself is considered a builtin
-			c.NewIdent("vprintf", "stdio.Vprintf", stdio.Vprintf, c.FuncTT(gintT, gstrT, valistT)),
 			c.NewIdent("sprintf", "stdio.Sprintf", stdio.Sprintf, c.VarFuncTT(gintT, cstrT, gstrT)),
 			c.NewIdent("vsprintf", "stdio.Vsprintf", stdio.Vsprintf, c.FuncTT(gintT, cstrT, gstrT, valistT)),
 			c.NewIdent("snprintf", "stdio.Snprintf", stdio.Snprintf, c.VarFuncTT(gintT, cstrT, gintT, gstrT)),
@@ -123,6 +121,18 @@ const int _cxgo_EOF = -1;
 			c.NewIdent("rename", "stdio.Rename", stdio.Rename, c.VarFuncTT(gintT, gstrT, gstrT)),
 		)
 
+		if c.Stdout == StdoutBuffered {
+			l.Declare(
+				c.NewIdent("_cxgo_getStdout", "stdio.BufferedStdout", stdio.BufferedStdout, c.FuncTT(filePtr)),
+				c.NewIdent("vprintf", "stdio.BufferedVprintf", stdio.BufferedVprintf, c.FuncTT(gintT, gstrT, valistT)),
+			)
+		} else {
+			l.Declare(
+				c.NewIdent("_cxgo_getStdout", "stdio.Stdout", stdio.Stdout, c.FuncTT(filePtr)),
+				c.NewIdent("vprintf", "stdio.Vprintf", stdio.Vprintf, c.FuncTT(gintT, gstrT, valistT)),
+			)
+		}
+
 		l.Header += `
 #define stdout _cxgo_getStdout()
 #define stderr _cxgo_getStderr()
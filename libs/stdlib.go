@@ -6,6 +6,7 @@ import (
 
 	"github.com/gotranspile/cxgo/runtime/cmath"
 	"github.com/gotranspile/cxgo/runtime/libc"
+	"github.com/gotranspile/cxgo/runtime/stdio"
 	"github.com/gotranspile/cxgo/types"
 )
 
@@ -30,7 +31,6 @@ func init() {
 			},
 			Idents: map[string]*types.Ident{
 				"abs":      c.NewIdent("abs", "cmath.Abs", cmath.Abs, c.FuncTT(longT, longT)),
-				"_Exit":    c.Go().OsExitFunc(),
 				"malloc":   c.C().MallocFunc(),
 				"calloc":   c.C().CallocFunc(),
 				"realloc":  c.NewIdent("realloc", "libc.Realloc", libc.Realloc, c.FuncTT(voidPtr, voidPtr, gintT)),
@@ -38,14 +38,35 @@ func init() {
 				"atoi":     c.NewIdent("atoi", "libc.Atoi", libc.Atoi, c.FuncTT(gintT, gstrT)),
 				"atol":     c.NewIdent("atol", "libc.Atoi", libc.Atoi, c.FuncTT(gintT, gstrT)),
 				"atof":     c.NewIdent("atof", "libc.Atof", libc.Atof, c.FuncTT(types.FloatT(8), gstrT)),
-				"rand":     c.NewIdent("rand", "libc.Rand", libc.Rand, c.FuncTT(intT)),
-				"srand":    c.NewIdent("srand", "libc.SeedRand", libc.SeedRand, c.FuncTT(nil, uintT)),
+				"rand_r":   c.NewIdent("rand_r", "libc.RandR", libc.RandR, c.FuncTT(intT, c.PtrT(uintT))),
 				"qsort":    c.NewIdent("qsort", "libc.Sort", libc.Sort, c.FuncTT(nil, voidPtr, uintT, uintT, c.FuncTT(intT, voidPtr, voidPtr))),
 				"bsearch":  c.NewIdent("bsearch", "libc.Search", libc.Search, c.FuncTT(voidPtr, voidPtr, voidPtr, uintT, uintT, c.FuncTT(intT, voidPtr, voidPtr))),
 				"mbstowcs": c.NewIdent("mbstowcs", "libc.Mbstowcs", libc.Mbstowcs, c.FuncTT(uintT, wstrT, cstrT, uintT)),
 			},
 			Header: fmt.Sprintf("#define RAND_MAX %d\n", libc.RandMax),
 		}
+		l.Idents["_Exit"] = c.Go().OsExitFunc()
+		if c.Stdout == StdoutBuffered {
+			// exit must write the buffer of stdout, while _Exit terminates immediately
+			l.Imports["stdio"] = RuntimePrefix + "stdio"
+			l.Header += "#undef exit\n"
+			l.Declare(c.NewIdent("exit", "stdio.Exit", stdio.Exit, c.FuncTT(nil, gintT)))
+		}
+		randT, seedT := c.FuncTT(intT), c.FuncTT(nil, uintT)
+		for _, name := range []string{"rand", "random"} {
+			if c.Rand == RandGlibc {
+				l.Idents[name] = c.NewIdent(name, "libc.GlibcRand", libc.GlibcRand, randT)
+			} else {
+				l.Idents[name] = c.NewIdent(name, "libc.Rand", libc.Rand, randT)
+			}
+		}
+		for _, name := range []string{"srand", "srandom"} {
+			if c.Rand == RandGlibc {
+				l.Idents[name] = c.NewIdent(name, "libc.GlibcSeedRand", libc.GlibcSeedRand, seedT)
+			} else {
+				l.Idents[name] = c.NewIdent(name, "libc.SeedRand", libc.SeedRand, seedT)
+			}
+		}
 
 		l.Declare(
 			c.NewIdent("getenv", "os.Getenv", os.Getenv, c.FuncTT(c.Go().String(), c.Go().String())),
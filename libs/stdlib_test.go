@@ -0,0 +1,62 @@
+package libs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+func TestRandMode(t *testing.T) {
+	for _, c := range []struct {
+		mode RandMode
+		rand string
+		seed string
+	}{
+		{"", "libc.Rand", "libc.SeedRand"},
+		{RandGo, "libc.Rand", "libc.SeedRand"},
+		{RandGlibc, "libc.GlibcRand", "libc.GlibcSeedRand"},
+	} {
+		env := NewEnv(types.Config32())
+		env.Rand = c.mode
+		l, ok := env.GetLibrary(StdlibH)
+		require.True(t, ok)
+		for _, name := range []string{"rand", "random"} {
+			require.Equal(t, c.rand, l.Idents[name].GoName, "%q: %s", c.mode, name)
+		}
+		for _, name := range []string{"srand", "srandom"} {
+			require.Equal(t, c.seed, l.Idents[name].GoName, "%q: %s", c.mode, name)
+		}
+	}
+}
+
+func TestStdoutMode(t *testing.T) {
+	for _, c := range []struct {
+		mode   StdoutMode
+		printf string
+		stdout string
+		exit   string
+	}{
+		{"", "stdio.Printf", "stdio.Stdout", ""},
+		{StdoutDirect, "stdio.Printf", "stdio.Stdout", ""},
+		{StdoutBuffered, "stdio.BufferedPrintf", "stdio.BufferedStdout", "stdio.Exit"},
+	} {
+		env := NewEnv(types.Config32())
+		env.Stdout = c.mode
+		l, ok := env.GetLibrary(BuiltinH)
+		require.True(t, ok)
+		require.Equal(t, c.printf, l.Idents["printf"].GoName, "%q", c.mode)
+		l, ok = env.GetLibrary(StdioH)
+		require.True(t, ok)
+		require.Equal(t, c.stdout, l.Idents["_cxgo_getStdout"].GoName, "%q", c.mode)
+		l, ok = env.GetLibrary(StdlibH)
+		require.True(t, ok)
+		require.Equal(t, "os.Exit", l.Idents["_Exit"].GoName, "%q", c.mode)
+		if c.exit == "" {
+			require.NotContains(t, l.Idents, "exit", "%q", c.mode)
+		} else {
+			require.Equal(t, c.exit, l.Idents["exit"].GoName, "%q", c.mode)
+		}
+	}
+}
@@ -13,6 +13,9 @@ func init() {
 	RegisterLibrary(sysTypesH, func(c *Env) *Library {
 		intT := types.IntT(4)
 		return &Library{
+			Imports: map[string]string{
+				"csys": RuntimePrefix + "csys",
+			},
 			Idents: map[string]*types.Ident{
 				"O_RDONLY": c.NewIdent("O_RDONLY", "csys.O_RDONLY", csys.O_RDONLY, intT),
 				"O_WRONLY": c.NewIdent("O_WRONLY", "csys.O_WRONLY", csys.O_WRONLY, intT),
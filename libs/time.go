@@ -62,6 +62,9 @@ func init() {
 				"clock_settime":   c.NewIdent("clock_settime", "libc.ClockSetTime", libc.ClockSetTime, c.FuncTT(intT, clockT, c.PtrT(tsT))),
 				"clock_gettime":   c.NewIdent("clock_gettime", "libc.ClockGetTime", libc.ClockGetTime, c.FuncTT(intT, clockT, c.PtrT(tsT))),
 				"asctime":         c.NewIdent("asctime", "libc.AscTime", libc.AscTime, c.FuncTT(strT, c.PtrT(tmT))),
+				"nanosleep":       c.NewIdent("nanosleep", "libc.NanoSleep", libc.NanoSleep, c.FuncTT(intT, c.PtrT(tsT), c.PtrT(tsT))),
+				"clock_nanosleep": c.NewIdent("clock_nanosleep", "libc.ClockNanoSleep", libc.ClockNanoSleep, c.FuncTT(intT, clockT, intT, c.PtrT(tsT), c.PtrT(tsT))),
+				"TIMER_ABSTIME":   c.NewIdent("TIMER_ABSTIME", "libc.TIMER_ABSTIME", libc.TIMER_ABSTIME, gintT),
 				"CLOCK_REALTIME":  c.NewIdent("CLOCK_REALTIME", "libc.CLOCK_REALTIME", libc.CLOCK_REALTIME, gintT),
 				"CLOCK_MONOTONIC": c.NewIdent("CLOCK_MONOTONIC", "libc.CLOCK_MONOTONIC", libc.CLOCK_MONOTONIC, gintT),
 				"CLOCKS_PER_SEC":  c.NewIdent("CLOCKS_PER_SEC", "libc.CLOCKS_PER_SEC", libc.CLOCKS_PER_SEC, gintT),
@@ -2,6 +2,7 @@ package libs
 
 import (
 	"github.com/gotranspile/cxgo/runtime/cnet"
+	"github.com/gotranspile/cxgo/runtime/libc"
 	"github.com/gotranspile/cxgo/runtime/stdio"
 	"github.com/gotranspile/cxgo/types"
 )
@@ -16,11 +17,13 @@ func init() {
 		uintptrT := c.Go().Uintptr()
 		fdT := uintptrT
 		intT := types.IntT(4)
+		uintT := types.UintT(4)
 		gintT := c.Go().Int()
 		ulongT := types.UintT(8)
 		strT := c.C().String()
 		return &Library{
 			Imports: map[string]string{
+				"libc":  RuntimeLibc,
 				"stdio": RuntimePrefix + "stdio",
 				"csys":  RuntimePrefix + "csys",
 				"cnet":  RuntimePrefix + "cnet",
@@ -35,6 +38,8 @@ func init() {
 				"access":      c.NewIdent("access", "stdio.Access", stdio.Access, c.FuncTT(intT, strT, intT)),
 				"lseek":       c.NewIdent("lseek", "stdio.Lseek", stdio.Lseek, c.FuncTT(ulongT, fdT, ulongT, intT)),
 				"getcwd":      c.NewIdent("getcwd", "stdio.GetCwd", stdio.GetCwd, c.FuncTT(strT, strT, gintT)),
+				"sleep":       c.NewIdent("sleep", "libc.Sleep", libc.Sleep, c.FuncTT(uintT, uintT)),
+				"usleep":      c.NewIdent("usleep", "libc.USleep", libc.USleep, c.FuncTT(intT, uintT)),
 				"gethostname": c.NewIdent("gethostname", "cnet.GetHostname", cnet.GetHostname, c.FuncTT(gintT, strT, gintT)),
 			},
 		}
@@ -0,0 +1,166 @@
+package libs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotranspile/cxgo/runtime/czlib"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	zlibH = "zlib.h"
+)
+
+func init() {
+	RegisterLibrary(zlibH, func(c *Env) *Library {
+		gstrT := c.Go().String()
+		intT := types.IntT(4)
+		uintT := types.UintT(4)
+		ulongT := c.Go().Uint()
+		bytesT := c.PtrT(types.UintT(1))
+		ptrT := c.PtrT(nil)
+		streamT := types.NamedTGo("z_stream", "czlib.Stream", types.StructT([]*types.Field{
+			{Name: types.NewIdentGo("next_in", "NextIn", bytesT)},
+			{Name: types.NewIdentGo("avail_in", "AvailIn", uintT)},
+			{Name: types.NewIdentGo("total_in", "TotalIn", ulongT)},
+			{Name: types.NewIdentGo("next_out", "NextOut", bytesT)},
+			{Name: types.NewIdentGo("avail_out", "AvailOut", uintT)},
+			{Name: types.NewIdentGo("total_out", "TotalOut", ulongT)},
+			{Name: types.NewIdentGo("msg", "Msg", c.C().String())},
+			{Name: types.NewIdentGo("state", "state", ptrT)},
+			{Name: types.NewIdentGo("zalloc", "Zalloc", ptrT)},
+			{Name: types.NewIdentGo("zfree", "Zfree", ptrT)},
+			{Name: types.NewIdentGo("opaque", "Opaque", ptrT)},
+			{Name: types.NewIdentGo("data_type", "DataType", intT)},
+			{Name: types.NewIdentGo("adler", "Adler", ulongT)},
+			{Name: types.NewIdentGo("reserved", "Reserved", ulongT)},
+		}))
+		streamPtrT := c.PtrT(streamT)
+		gzT := types.NamedTGo("gzFile_s", "czlib.GzFile", types.StructT(nil))
+		gzPtrT := c.PtrT(gzT)
+
+		var hdr strings.Builder
+		hdr.WriteString(`
+#define Byte _cxgo_uint8
+#define Bytef _cxgo_uint8
+#define uInt _cxgo_uint32
+#define uIntf _cxgo_uint32
+#define uLong _cxgo_go_uint
+#define uLongf _cxgo_go_uint
+#define voidp void*
+#define voidpf void*
+#define voidpc const void*
+#define z_off_t _cxgo_sint64
+#define alloc_func void*
+#define free_func void*
+
+#define Z_NULL NULL
+#define ZLIB_VERSION "` + czlib.Version + `"
+
+typedef struct z_stream {
+	Bytef* next_in;
+	uInt avail_in;
+	uLong total_in;
+	Bytef* next_out;
+	uInt avail_out;
+	uLong total_out;
+	char* msg;
+	void* state;
+	alloc_func zalloc;
+	free_func zfree;
+	voidpf opaque;
+	_cxgo_sint32 data_type;
+	uLong adler;
+	uLong reserved;
+} z_stream;
+#define z_streamp z_stream*
+
+typedef struct gzFile_s {} gzFile_s;
+#define gzFile gzFile_s*
+
+enum {
+`)
+		idents := make(map[string]*types.Ident)
+		for _, v := range []struct {
+			name  string
+			value int
+		}{
+			{"Z_OK", czlib.Z_OK},
+			{"Z_STREAM_END", czlib.Z_STREAM_END},
+			{"Z_NEED_DICT", czlib.Z_NEED_DICT},
+			{"Z_ERRNO", czlib.Z_ERRNO},
+			{"Z_STREAM_ERROR", czlib.Z_STREAM_ERROR},
+			{"Z_DATA_ERROR", czlib.Z_DATA_ERROR},
+			{"Z_MEM_ERROR", czlib.Z_MEM_ERROR},
+			{"Z_BUF_ERROR", czlib.Z_BUF_ERROR},
+			{"Z_VERSION_ERROR", czlib.Z_VERSION_ERROR},
+			{"Z_NO_FLUSH", czlib.Z_NO_FLUSH},
+			{"Z_PARTIAL_FLUSH", czlib.Z_PARTIAL_FLUSH},
+			{"Z_SYNC_FLUSH", czlib.Z_SYNC_FLUSH},
+			{"Z_FULL_FLUSH", czlib.Z_FULL_FLUSH},
+			{"Z_FINISH", czlib.Z_FINISH},
+			{"Z_BLOCK", czlib.Z_BLOCK},
+			{"Z_NO_COMPRESSION", czlib.Z_NO_COMPRESSION},
+			{"Z_BEST_SPEED", czlib.Z_BEST_SPEED},
+			{"Z_BEST_COMPRESSION", czlib.Z_BEST_COMPRESSION},
+			{"Z_DEFAULT_COMPRESSION", czlib.Z_DEFAULT_COMPRESSION},
+			{"Z_DEFAULT_STRATEGY", czlib.Z_DEFAULT_STRATEGY},
+			{"Z_FILTERED", czlib.Z_FILTERED},
+			{"Z_HUFFMAN_ONLY", czlib.Z_HUFFMAN_ONLY},
+			{"Z_RLE", czlib.Z_RLE},
+			{"Z_FIXED", czlib.Z_FIXED},
+			{"Z_DEFLATED", czlib.Z_DEFLATED},
+			{"MAX_WBITS", czlib.MAX_WBITS},
+		} {
+			idents[v.name] = c.NewIdent(v.name, "czlib."+v.name, v.value, types.AsUntypedIntT(intT))
+			fmt.Fprintf(&hdr, "\t%s = %d,\n", v.name, v.value)
+		}
+		hdr.WriteString(`};
+`)
+		l := &Library{
+			Imports: map[string]string{
+				"czlib": RuntimePrefix + "czlib",
+			},
+			Types: map[string]types.Type{
+				"z_stream": streamT,
+				"gzFile_s": gzT,
+			},
+			Idents: idents,
+			Header: hdr.String(),
+		}
+		l.Declare(
+			c.NewIdent("zlibVersion", "czlib.ZlibVersion", czlib.ZlibVersion, c.FuncTT(c.C().String())),
+			c.NewIdent("deflateInit", "czlib.DeflateInit", czlib.DeflateInit, c.FuncTT(intT, streamPtrT, intT)),
+			c.NewIdent("deflateInit2", "czlib.DeflateInit2", czlib.DeflateInit2, c.FuncTT(intT, streamPtrT, intT, intT, intT, intT, intT)),
+			c.NewIdent("deflate", "czlib.Deflate", czlib.Deflate, c.FuncTT(intT, streamPtrT, intT)),
+			c.NewIdent("deflateEnd", "czlib.DeflateEnd", czlib.DeflateEnd, c.FuncTT(intT, streamPtrT)),
+			c.NewIdent("deflateBound", "czlib.DeflateBound", czlib.DeflateBound, c.FuncTT(ulongT, streamPtrT, ulongT)),
+			c.NewIdent("inflateInit", "czlib.InflateInit", czlib.InflateInit, c.FuncTT(intT, streamPtrT)),
+			c.NewIdent("inflateInit2", "czlib.InflateInit2", czlib.InflateInit2, c.FuncTT(intT, streamPtrT, intT)),
+			c.NewIdent("inflate", "czlib.Inflate", czlib.Inflate, c.FuncTT(intT, streamPtrT, intT)),
+			c.NewIdent("inflateReset", "czlib.InflateReset", czlib.InflateReset, c.FuncTT(intT, streamPtrT)),
+			c.NewIdent("inflateEnd", "czlib.InflateEnd", czlib.InflateEnd, c.FuncTT(intT, streamPtrT)),
+
+			c.NewIdent("compressBound", "czlib.CompressBound", czlib.CompressBound, c.FuncTT(ulongT, ulongT)),
+			c.NewIdent("compress", "czlib.Compress", czlib.Compress, c.FuncTT(intT, bytesT, c.PtrT(ulongT), bytesT, ulongT)),
+			c.NewIdent("compress2", "czlib.Compress2", czlib.Compress2, c.FuncTT(intT, bytesT, c.PtrT(ulongT), bytesT, ulongT, intT)),
+			c.NewIdent("uncompress", "czlib.Uncompress", czlib.Uncompress, c.FuncTT(intT, bytesT, c.PtrT(ulongT), bytesT, ulongT)),
+			c.NewIdent("crc32", "czlib.Crc32", czlib.Crc32, c.FuncTT(ulongT, ulongT, bytesT, uintT)),
+			c.NewIdent("adler32", "czlib.Adler32", czlib.Adler32, c.FuncTT(ulongT, ulongT, bytesT, uintT)),
+
+			c.NewIdent("gzopen", "czlib.Gzopen", czlib.Gzopen, c.FuncTT(gzPtrT, gstrT, gstrT)),
+			c.NewIdent("gzread", "czlib.Gzread", czlib.Gzread, c.FuncTT(intT, gzPtrT, ptrT, uintT)),
+			c.NewIdent("gzwrite", "czlib.Gzwrite", czlib.Gzwrite, c.FuncTT(intT, gzPtrT, ptrT, uintT)),
+			c.NewIdent("gzgets", "czlib.Gzgets", czlib.Gzgets, c.FuncTT(c.C().String(), gzPtrT, c.C().String(), intT)),
+			c.NewIdent("gzputs", "czlib.Gzputs", czlib.Gzputs, c.FuncTT(intT, gzPtrT, gstrT)),
+			c.NewIdent("gzgetc", "czlib.Gzgetc", czlib.Gzgetc, c.FuncTT(intT, gzPtrT)),
+			c.NewIdent("gzputc", "czlib.Gzputc", czlib.Gzputc, c.FuncTT(intT, gzPtrT, intT)),
+			c.NewIdent("gzprintf", "czlib.Gzprintf", czlib.Gzprintf, c.VarFuncTT(intT, gzPtrT, gstrT)),
+			c.NewIdent("gzflush", "czlib.Gzflush", czlib.Gzflush, c.FuncTT(intT, gzPtrT, intT)),
+			c.NewIdent("gzeof", "czlib.Gzeof", czlib.Gzeof, c.FuncTT(intT, gzPtrT)),
+			c.NewIdent("gzclose", "czlib.Gzclose", czlib.Gzclose, c.FuncTT(intT, gzPtrT)),
+		)
+		return l
+	})
+}
@@ -138,6 +138,370 @@ func foo(x float32, y float64) {
 	y = math.Pow(y, y)
 	y = math.Mod(y, y)
 }
+`,
+	},
+	{
+		name: "sse",
+		src: `
+#include <emmintrin.h>
+
+int foo(const int* p, float* f) {
+	__m128i v = _mm_loadu_si128((const __m128i*)p);
+	v = _mm_add_epi32(v, _mm_set1_epi32(1));
+	_mm_storeu_ps(f, _mm_mul_ps(_mm_loadu_ps(f), _mm_set1_ps(2)));
+	return _mm_movemask_epi8(_mm_cmpeq_epi8(v, _mm_setzero_si128()));
+}
+`,
+		exp: `
+func foo(p *int32, f *float32) int32 {
+	var v simd.M128i = simd.LoadSi128((*simd.M128i)(unsafe.Pointer(p)))
+	v = simd.AddEpi32(v, simd.Set1Epi32(1))
+	simd.StorePs(f, simd.MulPs(simd.LoadPs(f), simd.Set1Ps(2)))
+	return simd.MovemaskEpi8(simd.CmpeqEpi8(v, simd.SetzeroSi128()))
+}
+`,
+	},
+	{
+		name: "neon",
+		src: `
+#include <arm_neon.h>
+
+int foo(int* p) {
+	int32x4_t v = vaddq_s32(vld1q_s32(p), vdupq_n_s32(2));
+	vst1q_s32(p, v);
+	return vgetq_lane_s32(v, 1) + vaddvq_s32(v);
+}
+`,
+		exp: `
+func foo(p *int32) int32 {
+	var v simd.Int32x4 = simd.VaddqS32(simd.Vld1qS32(p), simd.VdupqNS32(2))
+	simd.Vst1qS32(p, v)
+	return simd.VgetqLaneS32(v, 1) + simd.VaddvqS32(v)
+}
+`,
+	},
+	{
+		name: "curses",
+		src: `
+#include <curses.h>
+
+void foo() {
+	initscr();
+	keypad(stdscr, TRUE);
+	attron(A_BOLD | COLOR_PAIR(1));
+	mvprintw(LINES/2, 0, "%d", 1);
+	if (getch() == KEY_UP) {
+		addstr("up");
+	}
+	endwin();
+}
+`,
+		exp: `
+func foo() {
+	curses.Initscr()
+	curses.Keypad(curses.Stdscr, true)
+	curses.Attron(curses.A_BOLD | curses.ColorPair(1))
+	curses.Mvprintw(curses.LINES/2, 0, "%d", 1)
+	if curses.Getch() == curses.KEY_UP {
+		curses.Addstr("up")
+	}
+	curses.Endwin()
+}
+`,
+	},
+	{
+		name: "zlib",
+		src: `
+#include <zlib.h>
+
+int foo(z_stream* s, unsigned char* p, unsigned int n) {
+	if (inflateInit2(s, MAX_WBITS + 16) != Z_OK) return -1;
+	s->next_out = p;
+	s->avail_out = n;
+	switch (inflate(s, Z_NO_FLUSH)) {
+	case Z_STREAM_END:
+		return crc32(0, Z_NULL, 0) == s->adler;
+	}
+	return inflateEnd(s);
+}
+`,
+		exp: `
+func foo(s *czlib.Stream, p *uint8, n uint32) int32 {
+	if czlib.InflateInit2(s, int32(czlib.MAX_WBITS+16)) != czlib.Z_OK {
+		return -1
+	}
+	s.NextOut = p
+	s.AvailOut = n
+	switch czlib.Inflate(s, czlib.Z_NO_FLUSH) {
+	case czlib.Z_STREAM_END:
+		return libc.BoolToInt(czlib.Crc32(0, nil, 0) == s.Adler)
+	}
+	return czlib.InflateEnd(s)
+}
+`,
+	},
+	{
+		name: "curl",
+		src: `
+#include <curl/curl.h>
+
+size_t write_cb(char* p, size_t size, size_t n, void* ud);
+
+long foo(const char* url) {
+	CURL* c = curl_easy_init();
+	struct curl_slist* hdrs = curl_slist_append(NULL, "Accept: */*");
+	curl_easy_setopt(c, CURLOPT_URL, url);
+	curl_easy_setopt(c, CURLOPT_HTTPHEADER, hdrs);
+	curl_easy_setopt(c, CURLOPT_WRITEFUNCTION, write_cb);
+	long code = 0;
+	if (curl_easy_perform(c) == CURLE_OK) {
+		curl_easy_getinfo(c, CURLINFO_RESPONSE_CODE, &code);
+	}
+	curl_slist_free_all(hdrs);
+	curl_easy_cleanup(c);
+	return code;
+}
+`,
+		exp: `
+func write_cb(p *byte, size uint32, n uint32, ud unsafe.Pointer) uint32
+func foo(url *byte) int32 {
+	var (
+		c    *ccurl.CURL  = ccurl.EasyInit()
+		hdrs *ccurl.Slist = ccurl.SlistAppend(nil, "Accept: */*")
+	)
+	ccurl.EasySetopt(c, ccurl.CURLOPT_URL, url)
+	ccurl.EasySetopt(c, ccurl.CURLOPT_HTTPHEADER, hdrs)
+	ccurl.EasySetopt(c, ccurl.CURLOPT_WRITEFUNCTION, write_cb)
+	var code int32 = 0
+	if ccurl.EasyPerform(c) == ccurl.CURLE_OK {
+		ccurl.EasyGetinfo(c, ccurl.CURLINFO_RESPONSE_CODE, &code)
+	}
+	ccurl.SlistFreeAll(hdrs)
+	ccurl.EasyCleanup(c)
+	return code
+}
+`,
+	},
+	{
+		name: "openssl",
+		src: `
+#include <openssl/sha.h>
+#include <openssl/evp.h>
+
+void foo(const unsigned char* p, int n, unsigned char* out) {
+	SHA256_CTX ctx;
+	SHA256_Init(&ctx);
+	SHA256_Update(&ctx, p, n);
+	SHA256_Final(out, &ctx);
+
+	unsigned int sz;
+	EVP_MD_CTX* c = EVP_MD_CTX_new();
+	EVP_DigestInit_ex(c, EVP_md5(), NULL);
+	EVP_DigestUpdate(c, p, n);
+	EVP_DigestFinal_ex(c, out + SHA256_DIGEST_LENGTH, &sz);
+	EVP_MD_CTX_free(c);
+}
+`,
+		exp: `
+func foo(p *uint8, n int32, out *uint8) {
+	var ctx cssl.SHA256Ctx
+	cssl.SHA256Init(&ctx)
+	cssl.SHA256Update(&ctx, unsafe.Pointer(p), int(n))
+	cssl.SHA256Final(out, &ctx)
+	var sz uint32
+	var c *cssl.MDCtx = cssl.EVPMDCtxNew()
+	cssl.EVPDigestInitEx(c, cssl.EVPMd5(), nil)
+	cssl.EVPDigestUpdate(c, unsafe.Pointer(p), int(n))
+	cssl.EVPDigestFinalEx(c, (*uint8)(unsafe.Add(unsafe.Pointer(out), cssl.SHA256_DIGEST_LENGTH)), &sz)
+	cssl.EVPMDCtxFree(c)
+}
+`,
+	},
+	{
+		name: "cjson",
+		src: `
+#include <cJSON.h>
+
+double foo(const char* s) {
+	cJSON* root = cJSON_Parse(s);
+	cJSON* it;
+	double sum = 0;
+	cJSON_ArrayForEach(it, cJSON_GetObjectItem(root, "items")) {
+		if (cJSON_IsNumber(it)) {
+			sum += it->valuedouble;
+		}
+	}
+	cJSON_Delete(root);
+	return sum;
+}
+`,
+		exp: `
+func foo(s *byte) float64 {
+	var (
+		root *cjson.JSON = cjson.Parse(s)
+		it   *cjson.JSON
+		sum  float64 = 0
+	)
+	for it = cjson.GetArrayItem(cjson.GetObjectItem(root, "items"), 0); it != nil; it = it.Next {
+		if cjson.IsNumber(it) {
+			sum += it.ValueDouble
+		}
+	}
+	cjson.Delete(root)
+	return sum
+}
+`,
+	},
+	{
+		name: "json-c",
+		src: `
+#include <json-c/json.h>
+
+int foo(json_object* o) {
+	json_object* v;
+	if (!json_object_object_get_ex(o, "n", &v) || !json_object_is_type(v, json_type_int)) {
+		return -1;
+	}
+	return json_object_get_int(v);
+}
+`,
+		exp: `
+func foo(o *cjson.Object) int32 {
+	var v *cjson.Object
+	if !cjson.ObjectObjectGetEx(o, "n", &v) || !cjson.ObjectIsType(v, cjson.Json_type_int) {
+		return -1
+	}
+	return cjson.ObjectGetInt(v)
+}
+`,
+	},
+	{
+		name: "sqlite3",
+		src: `
+#include <sqlite3.h>
+
+long long foo(sqlite3* db, const char* name) {
+	sqlite3_stmt* st;
+	long long id = -1;
+	if (sqlite3_prepare_v2(db, "SELECT id FROM t WHERE name = ?", -1, &st, NULL) != SQLITE_OK) {
+		return -1;
+	}
+	sqlite3_bind_text(st, 1, name, -1, SQLITE_STATIC);
+	if (sqlite3_step(st) == SQLITE_ROW) {
+		id = sqlite3_column_int64(st, 0);
+	}
+	sqlite3_finalize(st);
+	return id;
+}
+`,
+		exp: `
+func foo(db *csqlite.DB, name *byte) int64 {
+	var (
+		st *csqlite.Stmt
+		id int64 = -1
+	)
+	if csqlite.PrepareV2(db, libc.CString("SELECT id FROM t WHERE name = ?"), -1, &st, nil) != csqlite.SQLITE_OK {
+		return -1
+	}
+	csqlite.BindText(st, 1, name, -1, csqlite.SQLITE_STATIC)
+	if csqlite.Step(st) == csqlite.SQLITE_ROW {
+		id = csqlite.ColumnInt64(st, 0)
+	}
+	csqlite.Finalize(st)
+	return id
+}
+`,
+	},
+	{
+		name: "semaphore",
+		src: `
+#include <semaphore.h>
+
+sem_t ready;
+
+void foo() {
+	sem_init(&ready, 0, 0);
+	sem_post(&ready);
+	if (sem_trywait(&ready) != 0) {
+		sem_wait(&ready);
+	}
+	sem_destroy(&ready);
+}
+`,
+		exp: `
+var ready pthread.Sem
+
+func foo() {
+	pthread.SemInit(&ready, 0, 0)
+	pthread.SemPost(&ready)
+	if pthread.SemTryWait(&ready) != 0 {
+		pthread.SemWait(&ready)
+	}
+	pthread.SemDestroy(&ready)
+}
+`,
+	},
+	{
+		name: "mqueue",
+		src: `
+#include <fcntl.h>
+#include <mqueue.h>
+
+int foo(const char* msg, int n) {
+	struct mq_attr attr = {0};
+	mqd_t q;
+	attr.mq_maxmsg = 8;
+	attr.mq_msgsize = 128;
+	q = mq_open("/queue", O_CREAT | O_WRONLY, 0600, &attr);
+	if (q == (mqd_t)-1) {
+		return -1;
+	}
+	mq_send(q, msg, n, 1);
+	return mq_close(q);
+}
+`,
+		exp: `
+func foo(msg *byte, n int32) int32 {
+	var (
+		attr pthread.MQAttr = pthread.MQAttr{}
+		q    pthread.MQ
+	)
+	attr.MaxMsg = 8
+	attr.MsgSize = 128
+	q = pthread.MqOpen("/queue", csys.O_CREAT|csys.O_WRONLY, 0o600, &attr)
+	if q == -1 {
+		return -1
+	}
+	pthread.MqSend(q, msg, int(n), 1)
+	return pthread.MqClose(q)
+}
+`,
+	},
+	{
+		name: "nanosleep",
+		src: `
+#include <time.h>
+#include <unistd.h>
+#include <errno.h>
+
+void foo(struct timespec req) {
+	struct timespec rem;
+	while (nanosleep(&req, &rem) == -1 && errno == EINTR) {
+		req = rem;
+	}
+	usleep(500);
+	sleep(1);
+}
+`,
+		exp: `
+func foo(req libc.TimeSpec) {
+	var rem libc.TimeSpec
+	for libc.NanoSleep(&req, &rem) == -1 && libc.Errno == libc.EINTR {
+		req = rem
+	}
+	libc.USleep(500)
+	libc.Sleep(1)
+}
 `,
 	},
 	{
@@ -208,6 +572,139 @@ func foo() {
 }
 `,
 	},
+	{
+		name: "strcmp switch",
+		src: `
+#include <string.h>
+
+int foo(const char* cmd) {
+	if (!strcmp(cmd, "start")) {
+		return 1;
+	} else if (strcmp(cmd, "stop") == 0) {
+		return 2;
+	} else if (!strcmp(cmd, "restart") || !strcmp("reload", cmd)) {
+		return 3;
+	} else {
+		return 0;
+	}
+}
+`,
+		exp: `
+func foo(cmd string) int32 {
+	switch cmd {
+	case "start":
+		return 1
+	case "stop":
+		return 2
+	case "restart", "reload":
+		return 3
+	default:
+		return 0
+	}
+}
+`,
+		configFuncs: []configFunc{
+			withIdentField("foo", IdentConfig{Name: "cmd", Type: HintString}),
+		},
+	},
+	{
+		name: "strcmp switch break",
+		src: `
+#include <string.h>
+
+void foo(const char* cmd, int n) {
+	for (; n > 0; n--) {
+		if (!strcmp(cmd, "a")) {
+			break;
+		} else if (!strcmp(cmd, "b")) {
+			n--;
+		}
+	}
+}
+void foo2(const char* cmd) {
+	if (!strcmp(cmd, "a")) {
+		cmd = "b";
+	} else if (!strcmp(cmd, "b")) {
+		cmd = "a";
+	}
+}
+`,
+		exp: `
+func foo(cmd string, n int32) {
+	for ; n > 0; n-- {
+		if libc.StrCmp(libc.CString(cmd), libc.CString("a")) == 0 {
+			break
+		} else if libc.StrCmp(libc.CString(cmd), libc.CString("b")) == 0 {
+			n--
+		}
+	}
+}
+func foo2(cmd string) {
+	switch cmd {
+	case "a":
+		cmd = "b"
+	case "b":
+		cmd = "a"
+	}
+}
+`,
+		configFuncs: []configFunc{
+			withIdentField("foo", IdentConfig{Name: "cmd", Type: HintString}),
+			withIdentField("foo2", IdentConfig{Name: "cmd", Type: HintString}),
+		},
+	},
+	{
+		name: "string builder",
+		src: `
+#include <stdio.h>
+#include <string.h>
+
+void use(char* s);
+
+void foo(const char** names, int n) {
+	char buf[256];
+	buf[0] = 0;
+	for (int i = 0; i < n; i++) {
+		strcat(buf, *names);
+		strcat(buf, ",");
+		sprintf(buf + strlen(buf), "%d;", i);
+	}
+	printf("%s: %d\n", buf, (int)strlen(buf));
+	strcpy(buf, "done");
+	printf("%s\n", buf);
+}
+void foo2() {
+	char buf[16];
+	strcpy(buf, "a");
+	use(buf);
+}
+`,
+		exp: `
+func use(s *byte)
+func foo(names **byte, n int32) {
+	var buf strings.Builder
+	for i := int32(0); i < n; i++ {
+		buf.WriteString(libc.GoString(*names))
+		buf.WriteString(",")
+		stdio.FprintfGo(&buf, "%d;", i)
+	}
+	stdio.Printf("%s: %d\n", buf.String(), int32(buf.Len()))
+	buf.Reset()
+	buf.WriteString("done")
+	stdio.Printf("%s\n", buf.String())
+}
+func foo2() {
+	var buf [16]byte
+	libc.StrCpy(&buf[0], libc.CString("a"))
+	use(&buf[0])
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.StringBuilders = true
+			},
+		},
+	},
 }
 
 var casesRunLibs = []struct {
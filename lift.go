@@ -0,0 +1,312 @@
+package cxgo
+
+import (
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// liftExprs moves ternary and comma expressions out of the statements where they are evaluated unconditionally.
+//
+// Go has no equivalent for those operators, thus otherwise they are translated to function literals
+// that are called immediately. Instead, side effects of comma expressions are moved to preceding statements,
+// and ternary expressions are assigned to temporary variables with an if-else statement:
+//
+//	foo(a ? 1 : 2) -> var tmp int32; if a { tmp = 1 } else { tmp = 2 }; foo(tmp)
+//
+// Expressions that are evaluated conditionally (right side of && and ||, loop conditions, etc) are left as-is.
+func (g *translator) liftExprs(decl []CDecl) {
+	for _, d := range decl {
+		f, ok := d.(*CFuncDecl)
+		if !ok || f.Body == nil {
+			continue
+		}
+		l := &exprLifter{g: g, used: usedIdentNames(f)}
+		// declarations of temporary variables cannot be placed between gotos and labels, so move them to the top
+		l.hoist = g.isFlattened(f.Name.Name) || hasGotos(f.Body.Stmts)
+		stmts := l.stmts(f.Body.Stmts)
+		if len(l.decls) != 0 {
+			stmts = append(l.decls, stmts...)
+		}
+		f.Body.Stmts = stmts
+	}
+}
+
+type exprLifter struct {
+	g      *translator
+	used   map[string]struct{} // identifier names used in the function
+	hoist  bool                // declare all temporary variables at the beginning of the function
+	decls  []CStmt             // hoisted declarations
+	pre    []CStmt             // statements that must be executed before the current one
+	last   int                 // last temporary variable index
+	lifted int                 // number of lifted expressions
+}
+
+func usedIdentNames(f *CFuncDecl) map[string]struct{} {
+	used := make(map[string]struct{})
+	for _, a := range f.Type.Args() {
+		if a.Name != nil {
+			used[a.Name.String()] = struct{}{}
+		}
+	}
+	var v Visitor
+	v = func(n Node) {
+		if n == nil {
+			return
+		}
+		if id, ok := n.(Ident); ok {
+			if id := id.Identifier(); id != nil {
+				used[id.String()] = struct{}{}
+			}
+		}
+		n.Visit(v)
+	}
+	f.Body.Visit(v)
+	return used
+}
+
+func hasGotos(stmts []CStmt) bool {
+	found := false
+	cEachStmt(func(s CStmt) bool {
+		if _, ok := s.(*CGotoStmt); ok {
+			found = true
+		}
+		return !found
+	}, stmts)
+	return found
+}
+
+func (l *exprLifter) newTemp(typ types.Type) *types.Ident {
+	for {
+		l.last++
+		name := "tmp"
+		if l.last > 1 {
+			name += strconv.Itoa(l.last)
+		}
+		if _, ok := l.used[name]; ok {
+			continue
+		}
+		l.used[name] = struct{}{}
+		id := types.NewIdent(name, typ)
+		decl := &CDeclStmt{Decl: &CVarDecl{CVarSpec: CVarSpec{
+			g:     l.g,
+			Type:  typ,
+			Names: []*types.Ident{id},
+		}}}
+		if l.hoist {
+			l.decls = append(l.decls, decl)
+		} else {
+			l.pre = append(l.pre, decl)
+		}
+		return id
+	}
+}
+
+func (l *exprLifter) stmts(stmts []CStmt) []CStmt {
+	out := make([]CStmt, 0, len(stmts))
+	for _, st := range stmts {
+		out = append(out, l.stmt(st)...)
+	}
+	return out
+}
+
+// stmt lifts expressions from a single statement and returns it with all the preceding statements.
+//
+// Statements may be shared as well (see dupStmts), thus the statement is copied before any changes.
+func (l *exprLifter) stmt(st CStmt) []CStmt {
+	saved := l.pre
+	l.pre = nil
+	switch s := st.(type) {
+	case *CExprStmt:
+		c := *s
+		c.Expr = l.expr(s.Expr)
+		st = &c
+	case *CAssignStmt:
+		c := *s
+		c.Left = l.expr(s.Left)
+		c.Right = l.expr(s.Right)
+		st = &c
+	case *CIncrStmt:
+		c := *s
+		c.Expr = l.expr(s.Expr)
+		st = &c
+	case *CReturnStmt:
+		if s.Expr != nil {
+			st = &CReturnStmt{Expr: l.expr(s.Expr)}
+		}
+	case *CDeclStmt:
+		// other variables of the same declaration may be used in the expression
+		if d, ok := s.Decl.(*CVarDecl); ok && !d.Const && len(d.Names) == 1 {
+			n := l.lifted
+			inits := make([]Expr, len(d.Inits))
+			for i, x := range d.Inits {
+				if x != nil {
+					x = l.expr(x)
+				}
+				inits[i] = x
+			}
+			if l.lifted != n {
+				dc := *d
+				dc.Inits = inits
+				st = &CDeclStmt{Decl: &dc}
+			}
+		}
+	case *CIfStmt:
+		c := *s
+		c.Cond = l.boolExpr(s.Cond)
+		st = &c
+	case *CSwitchStmt:
+		c := *s
+		c.Cond = l.expr(s.Cond)
+		st = &c
+	case *CForStmt:
+		c := *s
+		// only init is evaluated once, condition and iteration must stay in place
+		if s.Init != nil {
+			init := l.stmt(s.Init)
+			c.Init = init[len(init)-1]
+			l.pre = append(l.pre, init[:len(init)-1]...)
+		}
+		st = &c
+	case *BlockStmt:
+		c := *s
+		st = &c
+	}
+	pre := l.pre
+	l.pre = saved
+	switch s := st.(type) {
+	case *BlockStmt:
+		s.Stmts = l.stmts(s.Stmts)
+	case *CIfStmt:
+		s.Then = &BlockStmt{g: s.Then.g, Stmts: l.stmts(s.Then.Stmts)}
+		switch e := s.Else.(type) {
+		case *BlockStmt:
+			s.Else = &BlockStmt{g: e.g, Stmts: l.stmts(e.Stmts)}
+		case *CIfStmt:
+			// else-if condition is evaluated only if the first one is false
+			if sub := l.stmt(e); len(sub) == 1 {
+				s.Else = sub[0].(*CIfStmt)
+			} else {
+				s.Else = l.g.NewCBlock(sub...)
+			}
+		}
+	case *CSwitchStmt:
+		cases := make([]*CCaseStmt, 0, len(s.Cases))
+		for _, c := range s.Cases {
+			cs := *c
+			cs.Stmts = l.stmts(c.Stmts)
+			cases = append(cases, &cs)
+		}
+		s.Cases = cases
+	case *CForStmt:
+		s.Body.Stmts = l.stmts(s.Body.Stmts)
+	}
+	if len(pre) == 0 {
+		return []CStmt{st}
+	}
+	// lifted statements may contain more expressions to lift
+	out := l.stmts(pre)
+	return append(out, st)
+}
+
+func (l *exprLifter) boolExpr(e BoolExpr) BoolExpr {
+	if b, ok := l.expr(e).(BoolExpr); ok {
+		return b
+	}
+	return e
+}
+
+// expr lifts ternary and comma expressions from an expression that is evaluated unconditionally.
+//
+// Expression nodes may be shared between statements, thus nodes are copied instead of being modified in place.
+func (l *exprLifter) expr(e Expr) Expr {
+	n := l.lifted
+	switch e := e.(type) {
+	case *CTernaryExpr:
+		typ := e.CType(nil)
+		if k := typ.Kind(); k.IsUntypedInt() {
+			typ = l.g.env.DefIntT()
+		} else if k.IsUntypedFloat() {
+			typ = l.g.env.DefFloatT()
+		}
+		id := l.newTemp(typ)
+		l.pre = append(l.pre, l.g.NewCAssignStmt(IdentExpr{id}, "", e)...)
+		l.lifted++
+		return IdentExpr{id}
+	case *CMultiExpr:
+		last := len(e.Exprs) - 1
+		for _, x := range e.Exprs[:last] {
+			l.pre = append(l.pre, NewCExprStmt(x)...)
+		}
+		l.lifted++
+		return l.expr(e.Exprs[last])
+	case *CParentExpr:
+		if x := l.expr(e.Expr); l.lifted != n {
+			return cParen(x)
+		}
+	case *CCastExpr:
+		if x := l.expr(e.Expr); l.lifted != n {
+			c := *e
+			c.Expr = x
+			return &c
+		}
+	case *CUnaryExpr:
+		if x := l.expr(e.Expr); l.lifted != n {
+			c := *e
+			c.Expr = x
+			return &c
+		}
+	case *CBinaryExpr:
+		x, y := l.expr(e.Left), l.expr(e.Right)
+		if l.lifted != n {
+			return &CBinaryExpr{Left: x, Op: e.Op, Right: y}
+		}
+	case *Comparison:
+		x, y := l.expr(e.X), l.expr(e.Y)
+		if l.lifted != n {
+			c := *e
+			c.X, c.Y = x, y
+			return &c
+		}
+	case *CIndexExpr:
+		x, ind := l.expr(e.Expr), l.expr(e.Index)
+		if l.lifted != n {
+			c := *e
+			c.Expr, c.Index = x, ind
+			return &c
+		}
+	case *CSelectExpr:
+		if x := l.expr(e.Expr); l.lifted != n {
+			return &CSelectExpr{Expr: x, Sel: e.Sel}
+		}
+	case *CallExpr:
+		args := make([]Expr, len(e.Args))
+		for i, a := range e.Args {
+			args[i] = l.expr(a)
+		}
+		if l.lifted != n {
+			return &CallExpr{Fun: e.Fun, Args: args}
+		}
+	case *Not:
+		if x := l.boolExpr(e.X); l.lifted != n {
+			return &Not{X: x}
+		}
+	case *BinaryBoolExpr:
+		// the right side is evaluated conditionally
+		if x := l.boolExpr(e.X); l.lifted != n {
+			return &BinaryBoolExpr{X: x, Op: e.Op, Y: e.Y}
+		}
+	case *BoolToInt:
+		if x := l.boolExpr(e.X); l.lifted != n {
+			return &BoolToInt{X: x}
+		}
+	case BoolAssert:
+		if x := l.expr(e.X); l.lifted != n {
+			if b, ok := x.(BoolExpr); ok {
+				return b
+			}
+			return BoolAssert{X: x}
+		}
+	}
+	return e
+}
@@ -4,6 +4,7 @@ import (
 	"go/ast"
 	"go/token"
 	"math"
+	"math/big"
 	"strconv"
 	"strings"
 
@@ -37,9 +38,14 @@ func parseCIntLit(s string, auto bool) (IntLit, error) {
 	uv, err := strconv.ParseUint(s, base, 64)
 	if err == nil {
 		if auto {
-			base = 0
+			return cUintLit(uv, 0), nil
 		}
-		return cUintLit(uv, base), nil
+		l := cUintLit(uv, base)
+		if base != 10 {
+			// keep leading zeros of masks like 0x00FF
+			l.width = len(s)
+		}
+		return l, nil
 	}
 	iv, err := strconv.ParseInt(s, base, 64)
 	if err != nil {
@@ -128,10 +134,13 @@ func litCanStore(t types.IntType, v IntLit) bool {
 var _ Number = IntLit{}
 
 type IntLit struct {
-	typ  types.IntType
-	val  uint64
-	base int
-	neg  bool
+	typ   types.IntType
+	val   uint64
+	base  int
+	neg   bool
+	width int // number of digits in the C literal, including leading zeros; only set for non-decimal literals
+	// suffix is the C type of a literal with a suffix, for example long for 100L
+	suffix types.Type
 }
 
 func (IntLit) Visit(v Visitor) {}
@@ -180,12 +189,19 @@ func (l IntLit) Negate() Number {
 
 func (l IntLit) NegateLit() IntLit {
 	if l.neg {
-		return cUintLit(l.val, l.base)
+		r := cUintLit(l.val, l.base)
+		r.width = l.width
+		return r
 	}
 	if l.val > math.MaxInt64 {
 		panic("cannot negate")
 	}
-	return cIntLit(-int64(l.val), l.base)
+	r := cIntLit(-int64(l.val), l.base)
+	r.width = l.width
+	if l.suffix != nil && l.suffix.Kind().IsSigned() {
+		r = r.withSuffix(l.suffix)
+	}
+	return r
 }
 
 func (l IntLit) MulLit(v int64) IntLit {
@@ -241,6 +257,14 @@ func (l IntLit) OverflowInt(sz int) IntLit {
 	return l
 }
 
+// TruncUint truncates a non-negative literal to a given size of unsigned integer.
+func (l IntLit) TruncUint(sz int) IntLit {
+	if sz <= 0 || sz >= 8 {
+		return l
+	}
+	return cUintLit(l.Uint()&(1<<(8*sz)-1), l.base)
+}
+
 func (l IntLit) OverflowUint(sz int) IntLit {
 	switch sz {
 	case 8:
@@ -259,6 +283,222 @@ func (l IntLit) OverflowUint(sz int) IntLit {
 	return l
 }
 
+// exactIntConst returns an exact value of an untyped constant integer expression, as Go evaluates it.
+// Constants converted from macros are resolved to their values.
+func (g *translator) exactIntConst(e Expr) (*big.Int, bool) {
+	switch e := e.(type) {
+	case IntLit:
+		v := new(big.Int).SetUint64(e.val)
+		if e.neg {
+			v.Neg(v)
+		}
+		return v, true
+	case IdentExpr:
+		if l, ok := g.macroInts[e.Ident]; ok {
+			return g.exactIntConst(l)
+		}
+	case *CParentExpr:
+		return g.exactIntConst(e.Expr)
+	case *CCastExpr:
+		t, ok := types.Unwrap(e.Type).(types.IntType)
+		if !ok {
+			return nil, false
+		}
+		v, ok := g.exactIntConst(e.Expr)
+		if !ok {
+			return nil, false
+		}
+		// Go doesn't allow constant conversions that overflow
+		switch {
+		case v.IsUint64():
+			ok = litCanStore(t, cUintLit(v.Uint64(), 10))
+		case v.IsInt64():
+			ok = litCanStore(t, cIntLit(v.Int64(), 10))
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, false
+		}
+		return v, true
+	case *CUnaryExpr:
+		v, ok := g.exactIntConst(e.Expr)
+		if !ok {
+			return nil, false
+		}
+		switch e.Op {
+		case UnaryPlus:
+			return v, true
+		case UnaryMinus:
+			return new(big.Int).Neg(v), true
+		case UnaryXor:
+			// Go complements typed unsigned constants within the size of the type
+			if t, ok := types.Unwrap(e.Expr.CType(nil)).(types.IntType); ok && !t.Signed() && !t.Kind().IsUntyped() && t.Sizeof() > 0 {
+				mask := new(big.Int).Lsh(big.NewInt(1), uint(8*t.Sizeof()))
+				return new(big.Int).Xor(v, mask.Sub(mask, big.NewInt(1))), true
+			}
+			return new(big.Int).Not(v), true
+		}
+		return nil, false
+	case *CBinaryExpr:
+		x, ok := g.exactIntConst(e.Left)
+		if !ok {
+			return nil, false
+		}
+		y, ok := g.exactIntConst(e.Right)
+		if !ok {
+			return nil, false
+		}
+		return evalIntOp(x, e.Op, y)
+	}
+	return nil, false
+}
+
+func evalIntOp(x *big.Int, op BinaryOp, y *big.Int) (*big.Int, bool) {
+	r := new(big.Int)
+	switch op {
+	case BinOpAdd:
+		return r.Add(x, y), true
+	case BinOpSub:
+		return r.Sub(x, y), true
+	case BinOpMult:
+		return r.Mul(x, y), true
+	case BinOpDiv:
+		if y.Sign() == 0 {
+			return nil, false
+		}
+		return r.Quo(x, y), true
+	case BinOpMod:
+		if y.Sign() == 0 {
+			return nil, false
+		}
+		return r.Rem(x, y), true
+	case BinOpBitAnd:
+		return r.And(x, y), true
+	case BinOpBitOr:
+		return r.Or(x, y), true
+	case BinOpBitXor:
+		return r.Xor(x, y), true
+	case BinOpLsh, BinOpRsh:
+		if y.Sign() < 0 || !y.IsInt64() || y.Int64() >= 64 {
+			return nil, false
+		}
+		if op == BinOpLsh {
+			return r.Lsh(x, uint(y.Int64())), true
+		}
+		return r.Rsh(x, uint(y.Int64())), true
+	}
+	return nil, false
+}
+
+// foldUnsignedLit evaluates a constant expression of an unsigned C type, for example 1U - 2 or 0xFFFFFFFFU << 4.
+// Go evaluates untyped constants exactly, while C wraps them around, thus the literal is only returned when
+// the results differ. Signed overflow is undefined in C, so signed expressions are never folded.
+func (g *translator) foldUnsignedLit(x Expr, op BinaryOp, y Expr, typ types.Type) (IntLit, bool) {
+	t, ok := types.Unwrap(typ).(types.IntType)
+	if !ok || t.Signed() || t.Sizeof() <= 0 || t.Sizeof() > 8 {
+		return IntLit{}, false
+	}
+	xv, ok := g.exactIntConst(x)
+	if !ok {
+		return IntLit{}, false
+	}
+	yv, ok := g.exactIntConst(y)
+	if !ok {
+		return IntLit{}, false
+	}
+	exact, ok := evalIntOp(xv, op, yv)
+	if !ok {
+		return IntLit{}, false
+	}
+	bits := uint(8 * t.Sizeof())
+	mod := new(big.Int).Lsh(big.NewInt(1), bits)
+	wrap := func(v *big.Int) *big.Int {
+		return new(big.Int).Mod(v, mod)
+	}
+	wy := yv
+	if op != BinOpLsh && op != BinOpRsh {
+		// shift count is not converted to the type of the expression
+		wy = wrap(yv)
+	} else if yv.Cmp(big.NewInt(int64(bits))) >= 0 {
+		return IntLit{}, false
+	}
+	r, ok := evalIntOp(wrap(xv), op, wy)
+	if !ok {
+		return IntLit{}, false
+	}
+	r = wrap(r)
+	if r.Cmp(exact) == 0 {
+		return IntLit{}, false
+	}
+	base := 10
+	if l, ok := cUnwrap(x).(IntLit); ok {
+		base = l.base
+	}
+	return cUintLit(r.Uint64(), base), true
+}
+
+// withSuffix sets the C type of the literal from its suffix, for example 100L. The literal is still untyped in Go,
+// but the type is used for sizeof and for variadic arguments.
+func (l IntLit) withSuffix(t types.Type) IntLit {
+	it, ok := types.Unwrap(t).(types.IntType)
+	if !ok || it.Sizeof() < l.typ.Sizeof() {
+		return l
+	}
+	l.typ = types.AsUntypedIntT(it)
+	l.suffix = t
+	return l
+}
+
+// suffixType returns the C type of a constant expression with literal suffixes, for example 1ULL << 40.
+// It returns nil if the expression has no such literals.
+func suffixType(e Expr) types.Type {
+	switch e := e.(type) {
+	case IntLit:
+		return e.suffix
+	case *CParentExpr:
+		return suffixType(e.Expr)
+	case *CBinaryExpr:
+		x := suffixType(e.Left)
+		if e.Op == BinOpLsh || e.Op == BinOpRsh {
+			return x
+		}
+		y := suffixType(e.Right)
+		if x == nil || (y != nil && y.Sizeof() > x.Sizeof()) {
+			return y
+		}
+		return x
+	}
+	return nil
+}
+
+// hasIntSuffix checks if the C integer literal has a suffix, for example 1U or 100L.
+func hasIntSuffix(s string) bool {
+	s = strings.ToLower(s)
+	return strings.HasSuffix(s, "u") || strings.HasSuffix(s, "l")
+}
+
+// constUintLit evaluates a constant expression of an unsigned type if its value cannot be stored in t, for example
+// 0xFFFFFFFFFFFFFFFFULL + 0 converted to long long. The literal is then converted to t as C does; see cCast.
+func (g *translator) constUintLit(x Expr, t types.IntType) (IntLit, bool) {
+	if _, ok := cUnwrap(x).(IntLit); ok {
+		return IntLit{}, false
+	}
+	xt, ok := types.Unwrap(x.CType(nil)).(types.IntType)
+	if !ok || xt.Signed() {
+		return IntLit{}, false
+	}
+	v, ok := g.exactIntConst(cUnwrap(x))
+	if !ok || v.Sign() < 0 || !v.IsUint64() {
+		return IntLit{}, false
+	}
+	l := cUintLit(v.Uint64(), 10)
+	if litCanStore(t, l) {
+		return IntLit{}, false
+	}
+	return l, true
+}
+
 func (l IntLit) AsExpr() GoExpr {
 	if l.neg {
 		val := -int64(l.val)
@@ -274,6 +514,12 @@ func (l IntLit) AsExpr() GoExpr {
 				return ident("math.MinInt8")
 			}
 		}
+		if l.width > 0 && l.base > 0 {
+			return &ast.BasicLit{
+				Kind:  token.INT,
+				Value: "-" + formatUintWidth(l.val, l.base, l.width),
+			}
+		}
 		return intLit64(val, l.base)
 	}
 	if minMaxLiteralToIdent {
@@ -296,6 +542,12 @@ func (l IntLit) AsExpr() GoExpr {
 			return ident("math.MaxInt8")
 		}
 	}
+	if l.width > 0 && l.base > 0 {
+		return &ast.BasicLit{
+			Kind:  token.INT,
+			Value: formatUintWidth(l.val, l.base, l.width),
+		}
+	}
 	return uintLit64(l.val, l.base)
 }
 
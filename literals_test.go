@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/types"
 )
 
 func TestIntLitString(t *testing.T) {
@@ -182,6 +184,36 @@ func foo(a int32) {
 	a = -2147483648
 	a = -1879037365
 }
+`,
+	},
+	{
+		name: "literal formatting",
+		src: `
+#define MASK 0x00FF
+int foo(int x, char c) {
+	int a = x & 0x0000FF00;
+	int b = -0x10;
+	int m = 0755;
+	if (c == '\n' || c == '\t') {
+		return a + b;
+	}
+	return m + (x & MASK) + 0b0011;
+}
+`,
+		exp: `
+const MASK = 0x00FF
+
+func foo(x int32, c int8) int32 {
+	var (
+		a int32 = x & 0x0000FF00
+		b int32 = -0x10
+		m int32 = 0o755
+	)
+	if int32(c) == '\n' || int32(c) == '\t' {
+		return a + b
+	}
+	return m + (x & MASK) + 0b0011
+}
 `,
 	},
 	{
@@ -473,6 +505,109 @@ func foo() {
 	var x float32 = 4 / 3.0
 	_ = x
 }
+`,
+	},
+	{
+		name: "unsigned suffix wraparound",
+		src: `
+long long a = 1U - 2;
+long long b = (1U << 31) * 2;
+unsigned int c = 0xFFFFFFFFU << 4;
+unsigned long long d = 0xFFFFFFFFULL + 1;
+unsigned int e = 1U << 3;
+`,
+		exp: `
+var a int64 = 4294967295
+var b int64 = 0
+var c uint32 = 0xFFFFFFF0
+var d uint64 = 0xFFFFFFFF + 1
+var e uint32 = 1 << 3
+`,
+	},
+	{
+		name: "unsigned suffix wraparound 64 bit",
+		src: `
+long long a = 1U - 2 + 0;
+long long b = 1ULL - 2;
+unsigned int c = -1U;
+long long d = -1U;
+long long e = 2U * -1;
+`,
+		exp: `
+var a int64 = -1
+var b int64 = -1
+var c uint64 = 18446744073709551615
+var d int64 = -1
+var e int64 = -2
+`,
+		envFuncs: []envFunc{func(c *types.Config) {
+			*c = types.Config64()
+		}},
+	},
+	{
+		name: "unsigned suffix wraparound go int",
+		src: `
+unsigned int a = 1U - 2;
+long long b = 1U - 2 + 0;
+long long c = 2U * -1;
+`,
+		exp: `
+var a uint = 18446744073709551615
+var b int64 = -1
+var c int64 = -2
+`,
+		envFuncs: []envFunc{func(c *types.Config) {
+			*c = types.Config64()
+			c.UseGoInt = true
+		}},
+	},
+	{
+		name: "integer suffix types",
+		src: `
+#include <stdio.h>
+
+void foo() {
+	printf("%llu %ld %d\n", 0xFFFFFFFFFFFFFFFFULL, 100L, 1);
+	printf("%lld %lld\n", 1LL << 40, -5000000000LL);
+	int n = sizeof(100L) + sizeof(1ULL) + sizeof(1U);
+}
+`,
+		exp: `
+func foo() {
+	stdio.Printf("%llu %ld %d\n", uint64(0xFFFFFFFFFFFFFFFF), int32(100), 1)
+	stdio.Printf("%lld %lld\n", int64(1<<40), int64(-5000000000))
+	var n int32 = int32(unsafe.Sizeof(int32(0)) + unsafe.Sizeof(uint64(0)) + unsafe.Sizeof(uint32(0)))
+	_ = n
+}
+`,
+	},
+	{
+		name: "unsigned suffix macros",
+		src: `
+#define M 0xFFFFFFFFu
+#define A (0u - 1)
+#define E (~0u)
+
+unsigned long long s = M + 1;
+unsigned long long a = A;
+unsigned long long e = E;
+unsigned long long m = M;
+unsigned long long t = E + 1;
+unsigned long long u = A + 1;
+long long v = M - 0xFFFFFFFFu;
+`,
+		exp: `
+const M = 0xFFFFFFFF
+const A = 0xFFFFFFFF
+const E = 0xFFFFFFFF
+
+var s uint64 = 0
+var a uint64 = 4294967295
+var e uint64 = uint64(^uint32(0))
+var m uint64 = M
+var t uint64 = 0
+var u uint64 = 0
+var v int64 = int64(M - 0xFFFFFFFF)
 `,
 	},
 }
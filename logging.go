@@ -0,0 +1,29 @@
+package cxgo
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LevelTrace is a log level for detailed events, such as resolution of each header; see Config.Logger.
+const LevelTrace = slog.LevelDebug - 4
+
+// logger returns the logger from the config, or the default one.
+func (c *Config) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
+
+func (c *Config) logContext() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+// debug logs a debug event, such as a decision made by the translator.
+func (c *Config) debug(msg string, args ...any) {
+	c.logger().DebugContext(c.logContext(), msg, args...)
+}
@@ -0,0 +1,205 @@
+package cxgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"strings"
+
+	"modernc.org/cc/v3"
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// macroDef is a constant converted from an object-like macro; see Config.MacroComments.
+type macroDef struct {
+	id  *types.Ident
+	m   *cc.Macro
+	val Expr
+}
+
+// addMacroDef remembers the macro of the constant, if Config.MacroComments is set.
+func (g *translator) addMacroDef(id *types.Ident, val Expr, tu *cc.AST) {
+	if !g.conf.MacroComments {
+		return
+	}
+	if m, ok := tu.Macros[cc.String(id.Name)]; ok {
+		g.macroDefs[id.Name] = macroDef{id: id, m: m, val: val}
+	}
+}
+
+// macroText returns the #define directive of the macro, as written in the source. Continued lines are joined.
+func (g *translator) macroText(name string, m *cc.Macro) string {
+	pos := m.Position()
+	if lines := g.sourceLines(pos.Filename); lines != nil {
+		var parts []string
+		for i := pos.Line - 1; i >= 0 && i < len(lines); i++ {
+			line := strings.TrimSpace(string(lines[i]))
+			cont := strings.HasSuffix(line, "\\")
+			parts = append(parts, strings.TrimSpace(strings.TrimSuffix(line, "\\")))
+			if !cont {
+				break
+			}
+		}
+		if text := strings.Join(parts, " "); strings.HasPrefix(text, "#") {
+			return text
+		}
+	}
+	toks := m.ReplacementTokens()
+	parts := make([]string, 0, len(toks))
+	for _, t := range toks {
+		parts = append(parts, t.Src.String())
+	}
+	return "#define " + name + " " + strings.Join(parts, " ")
+}
+
+// addMacroComments adds a comment with the macro text and the header to constants converted from macros;
+// see Config.MacroComments. Const blocks of define groups get a line for each constant.
+func (g *translator) addMacroComments(d CDecl, out []GoDecl) {
+	vd, ok := d.(*CVarDecl)
+	if !ok || !vd.Const || len(g.macroDefs) == 0 {
+		return
+	}
+	defs := make(map[string]macroDef)
+	for _, id := range vd.Names {
+		if def, ok := g.macroDefs[id.Name]; ok && def.id == id {
+			defs[id.GoIdent().Name] = def
+		}
+	}
+	for _, gd := range out {
+		gd, ok := gd.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, s := range gd.Specs {
+			vs, ok := s.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			var list []*ast.Comment
+			for _, name := range vs.Names {
+				if def, ok := defs[name.Name]; ok {
+					pos := def.m.Position()
+					list = append(list, &ast.Comment{Text: fmt.Sprintf("// from %s:%d: %s", sourceName(g.conf.Root, pos.Filename), pos.Line, g.macroText(def.id.Name, def.m))})
+				}
+			}
+			for _, c := range list {
+				gd.Doc = appendComment(gd.Doc, c)
+			}
+		}
+	}
+}
+
+// macroUse returns the constant of a macro, if tokens from first to last are an entire expansion of it,
+// and the value of the expression is the same as the value of the constant.
+// Single tokens from macros are already resolved by convMacro, while tokens of longer expansions are only marked
+// with the position of the macro name; thus the name is read from the source. Only macros that are a parenthesized
+// expression, or an unary operator applied to a single token, are resolved, since those cannot be a part of
+// a larger expression in the expansion.
+func (g *translator) macroUse(first, last cc.Token, op cc.Operand) *types.Ident {
+	if len(g.macroDefs) == 0 {
+		return nil
+	}
+	pos := first.Position()
+	if !pos.IsValid() || last.Position() != pos {
+		return nil
+	}
+	name := g.sourceIdent(pos)
+	def, ok := g.macroDefs[name]
+	if !ok || g.env.ForceMacro(name) || g.isSkipped(name) || !sameIntValue(def.val, op) {
+		return nil
+	}
+	toks := def.m.ReplacementTokens()
+	if len(toks) < 2 || toks[0].Value != first.Value {
+		return nil
+	}
+	switch toks[0].Rune {
+	case '-', '+', '~':
+		if len(toks) == 2 {
+			return def.id
+		}
+	case '(':
+		depth := 0
+		for i, t := range toks {
+			switch t.Rune {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 && i != len(toks)-1 {
+					return nil
+				}
+			}
+		}
+		if depth == 0 && toks[len(toks)-1].Rune == ')' {
+			return def.id
+		}
+	}
+	return nil
+}
+
+// sameIntValue checks if the constant operand has the value of the integer literal. Values of macros are evaluated
+// separately from expressions, thus they may differ, for example because of the type of the expression.
+func sameIntValue(x Expr, op cc.Operand) bool {
+	l, ok := cUnwrap(x).(IntLit)
+	if !ok || op == nil {
+		return false
+	}
+	var v IntLit
+	switch ov := op.Value().(type) {
+	case cc.Int64Value:
+		v = cIntLit(int64(ov), 0)
+	case cc.Uint64Value:
+		v = cUintLit(uint64(ov), 0)
+	default:
+		return false
+	}
+	return l.neg == v.neg && l.val == v.val
+}
+
+// ccPrimaryToken returns the token of a primary expression, for example a literal.
+func ccPrimaryToken(e *cc.CastExpression) (cc.Token, bool) {
+	if e.Case != cc.CastExpressionUnary || e.UnaryExpression.Case != cc.UnaryExpressionPostfix {
+		return cc.Token{}, false
+	}
+	p := e.UnaryExpression.PostfixExpression
+	if p.Case != cc.PostfixExpressionPrimary {
+		return cc.Token{}, false
+	}
+	return p.PrimaryExpression.Token, true
+}
+
+// sourceIdent returns the identifier at the position in the source, or an empty string.
+func (g *translator) sourceIdent(pos token.Position) string {
+	lines := g.sourceLines(pos.Filename)
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return ""
+	}
+	line := lines[pos.Line-1]
+	if pos.Column < 1 || pos.Column > len(line) {
+		return ""
+	}
+	line = line[pos.Column-1:]
+	n := 0
+	for n < len(line) && (line[n] == '_' || ('a' <= line[n] && line[n] <= 'z') || ('A' <= line[n] && line[n] <= 'Z') || (n != 0 && '0' <= line[n] && line[n] <= '9')) {
+		n++
+	}
+	return string(line[:n])
+}
+
+// sourceLines returns lines of the C file, or nil if it cannot be read.
+func (g *translator) sourceLines(fname string) [][]byte {
+	if lines, ok := g.srcLines[fname]; ok {
+		return lines
+	}
+	var lines [][]byte
+	if data, err := readSource(g.conf, fname); err == nil {
+		lines = bytes.Split(data, []byte("\n"))
+	}
+	if g.srcLines == nil {
+		g.srcLines = make(map[string][][]byte)
+	}
+	g.srcLines[fname] = lines
+	return lines
+}
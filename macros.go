@@ -2,6 +2,7 @@ package cxgo
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -51,27 +52,153 @@ func (g *translator) convertMacros(ast *cc.AST) []CDecl {
 		arr = append(arr, macro{name.String(), mc})
 	}
 	sort.Slice(arr, func(i, j int) bool {
-		return arr[i].m.Position().Offset < arr[j].m.Position().Offset
+		pi, pj := arr[i].m.Position(), arr[j].m.Position()
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		if pi.Offset != pj.Offset {
+			return pi.Offset < pj.Offset
+		}
+		return arr[i].name < arr[j].name
 	})
-	var decls []CDecl
+	var list []macroConst
 	for _, mc := range arr {
 		if val := g.evalMacro(mc.m, ast); val != nil {
-			typ := val.CType(nil)
-			id := types.NewIdent(mc.name, typ)
-			decls = append(decls, &CVarDecl{Const: true, CVarSpec: CVarSpec{
-				g: g, Type: typ,
-				Names: []*types.Ident{id},
-				Inits: []Expr{val},
-			}})
+			list = append(list, macroConst{name: mc.name, file: mc.m.Position().Filename, val: val})
+		}
+	}
+	var decls []CDecl
+	for len(list) != 0 {
+		if d, n := g.defineGroup(list, ast); n != 0 {
+			decls = append(decls, d...)
+			list = list[n:]
+			continue
 		}
+		mc := list[0]
+		list = list[1:]
+		typ := mc.val.CType(nil)
+		id := types.NewIdent(mc.name, typ)
+		g.addMacroDef(id, mc.val, ast)
+		decls = append(decls, &CVarDecl{Const: true, CVarSpec: CVarSpec{
+			g: g, Type: typ,
+			Names: []*types.Ident{id},
+			Inits: []Expr{mc.val},
+		}})
 	}
 	return decls
 }
 
+type macroConst struct {
+	name string
+	file string
+	val  Expr
+}
+
+// definePrefix returns the prefix of the macro name up to the last underscore, for example "COLOR_" for "COLOR_RED".
+func definePrefix(name string) string {
+	i := strings.LastIndexByte(name, '_')
+	if i <= 0 {
+		return ""
+	}
+	return name[:i+1]
+}
+
+// defineGroup converts a run of integer macros with a common prefix at the start of the list to a named type
+// and a const block, if Config.DefineGroups is set. The type is named after the prefix, without trailing
+// underscores. It returns the number of macros in the group, or zero if there is no group.
+func (g *translator) defineGroup(list []macroConst, ast *cc.AST) ([]CDecl, int) {
+	if !g.conf.DefineGroups {
+		return nil, 0
+	}
+	prefix := definePrefix(list[0].name)
+	name := strings.TrimRight(prefix, "_")
+	if name == "" {
+		return nil, 0
+	}
+	var vals []IntLit
+	for _, mc := range list {
+		l, ok := cUnwrap(mc.val).(IntLit)
+		if !ok || mc.file != list[0].file || definePrefix(mc.name) != prefix {
+			break
+		}
+		vals = append(vals, l)
+	}
+	if len(vals) < 2 {
+		return nil, 0
+	}
+	if _, ok := ast.Macros[cc.String(name)]; ok {
+		return nil, 0
+	}
+	if _, ok := g.env.IdentByName(name); ok {
+		return nil, 0
+	}
+	var (
+		neg, sequential = false, true
+		min, max        int64
+	)
+	for i, l := range vals {
+		v := l.Int()
+		if !l.IsNeg() && l.Uint() > math.MaxInt64 {
+			v = math.MaxInt64
+		}
+		neg = neg || l.IsNeg()
+		if i == 0 || v < min {
+			min = v
+		}
+		if i == 0 || v > max {
+			max = v
+		}
+		if i != 0 && v != vals[0].Int()+int64(i) {
+			sequential = false
+		}
+	}
+	var under types.Type
+	switch {
+	case min >= math.MinInt32 && max <= math.MaxInt32:
+		under = types.IntT(4)
+	case !neg && max <= math.MaxUint32:
+		under = types.UintT(4)
+	case neg:
+		under = types.IntT(8)
+	default:
+		under = types.UintT(8)
+	}
+	goName := name
+	if to, ok := g.idents[name]; ok && to.Rename != "" {
+		goName = to.Rename
+	}
+	typ := types.NamedTGo(name, goName, under)
+	vd := &CVarDecl{Const: true, CVarSpec: CVarSpec{g: g, Type: typ}}
+	for i, l := range vals {
+		id := types.NewIdent(list[i].name, typ)
+		g.macros[id.Name] = id
+		g.addMacroDef(id, l, ast)
+		vd.Names = append(vd.Names, id)
+		switch {
+		case !sequential:
+			vd.Inits = append(vd.Inits, l)
+		case i == 0:
+			var iot Expr = g.Iota()
+			if l.IsNeg() {
+				iot = &CBinaryExpr{Left: iot, Op: BinOpSub, Right: l.NegateLit()}
+			} else if !l.IsZero() {
+				iot = &CBinaryExpr{Left: iot, Op: BinOpAdd, Right: l}
+			}
+			vd.Inits = append(vd.Inits, &CCastExpr{Type: typ, Expr: iot})
+		default:
+			vd.Inits = append(vd.Inits, nil)
+		}
+	}
+	if sequential {
+		vd.Type = nil
+	}
+	return []CDecl{&CTypeDef{typ}, vd}, len(vals)
+}
+
 func (g *translator) evalMacro(m *cc.Macro, ast *cc.AST) Expr {
 	toks := m.ReplacementTokens()
 	if len(toks) != 1 {
-		return evalMacro2(m, ast)
+		return g.evalMacro2(m, ast)
 	}
 
 	src := strings.TrimSpace(toks[0].Src.String())
@@ -87,6 +214,9 @@ func (g *translator) evalMacro(m *cc.Macro, ast *cc.AST) Expr {
 		}
 	} else {
 		if l, err := parseCIntLit(src, g.conf.IntReformat); err == nil {
+			if hasIntSuffix(src) {
+				l = l.withSuffix(g.cIntLitType(src, l))
+			}
 			return l
 		}
 		if l, err := parseCFloatLit(src); err == nil {
@@ -94,10 +224,10 @@ func (g *translator) evalMacro(m *cc.Macro, ast *cc.AST) Expr {
 		}
 	}
 
-	return evalMacro2(m, ast)
+	return g.evalMacro2(m, ast)
 }
 
-func evalMacro2(m *cc.Macro, ast *cc.AST) Expr {
+func (g *translator) evalMacro2(m *cc.Macro, ast *cc.AST) Expr {
 	op, err := ast.Eval(m)
 	if err != nil {
 		return nil
@@ -107,8 +237,78 @@ func evalMacro2(m *cc.Macro, ast *cc.AST) Expr {
 	case cc.Int64Value:
 		return cIntLit(int64(x), 0)
 	case cc.Uint64Value:
-		return cUintLit(uint64(x), 0)
+		l := cUintLit(uint64(x), 0)
+		// macros are evaluated as in #if, where all values are 64 bit, for example (0u - 1)
+		if t := g.macroIntType(m.ReplacementTokens()); t != nil && !t.Kind().IsSigned() {
+			l = l.TruncUint(t.Sizeof()).withSuffix(t)
+		}
+		return l
 	default:
 		return nil
 	}
 }
+
+// macroIntType returns the C type of an integer macro, as if it were expanded in an expression. Only literals
+// and arithmetic operators are supported, nil is returned for other expansions.
+func (g *translator) macroIntType(toks []cc.Token) types.Type {
+	var (
+		typ   types.Type
+		shift bool
+	)
+	for _, t := range toks {
+		switch t.Rune {
+		case ' ', '(', ')', '+', '-', '*', '/', '%', '&', '|', '^', '~':
+			continue
+		case cc.LSH, cc.RSH:
+			// the right operand of a shift doesn't change the type
+			shift = true
+			continue
+		case cc.PPNUMBER:
+		default:
+			return nil
+		}
+		src := t.Src.String()
+		l, err := parseCIntLit(src, false)
+		if err != nil {
+			return nil
+		}
+		if shift {
+			shift = false
+			continue
+		}
+		lt := g.cIntLitType(src, l)
+		if typ == nil {
+			typ = lt
+		} else {
+			typ = g.env.CommonType(typ, lt)
+		}
+	}
+	if shift {
+		return nil
+	}
+	return typ
+}
+
+// cIntLitType returns the C type of an integer literal: the first type of its suffix that can store the value.
+// Decimal literals without the U suffix are always signed.
+func (g *translator) cIntLitType(src string, l IntLit) types.Type {
+	s := strings.ToLower(src)
+	suffix := s[len(strings.TrimRight(s, "ul")):]
+	unsigned := strings.Contains(suffix, "u")
+	decimal := s[0] != '0'
+	list := []types.Type{
+		g.env.C().Int(), g.env.C().UnsignedInt(),
+		g.env.C().Long(), g.env.C().UnsignedLong(),
+		g.env.C().LongLong(), g.env.C().UnsignedLongLong(),
+	}
+	list = list[2*strings.Count(suffix, "l"):]
+	for i, t := range list {
+		if signed := i%2 == 0; (unsigned && signed) || (decimal && !unsigned && !signed) {
+			continue
+		}
+		if it, ok := types.Unwrap(t).(types.IntType); ok && litCanStore(it, l) {
+			return t
+		}
+	}
+	return list[len(list)-1]
+}
@@ -0,0 +1,159 @@
+package cxgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// Metrics summarizes the generated code, which helps tracking the progress of porting a project over time;
+// see Config.Metrics. The same value can be passed to translations of all files of the project.
+type Metrics struct {
+	Files     int            `json:"files"`
+	Funcs     int            `json:"funcs"`
+	Types     int            `json:"types"`
+	Vars      int            `json:"vars"`
+	Consts    int            `json:"consts"`
+	Flattened int            `json:"flattened"` // functions with goto that were flattened to a state machine
+	Unsafe    int            `json:"unsafe"`    // references to the unsafe package
+	Stubs     int            `json:"stubs"`     // panics and asm left in place of code that cannot be translated
+	Runtime   map[string]int `json:"runtime"`   // calls of the runtime, by function, for example "libc.Malloc"
+
+	files map[string]struct{}
+	decls map[GoDecl]struct{}
+}
+
+func (m *Metrics) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%d files: %d funcs, %d types, %d vars, %d consts\n", m.Files, m.Funcs, m.Types, m.Vars, m.Consts)
+	fmt.Fprintf(&buf, "%d flattened funcs, %d unsafe usages, %d stubs\n", m.Flattened, m.Unsafe, m.Stubs)
+	if len(m.Runtime) != 0 {
+		names := make([]string, 0, len(m.Runtime))
+		total := 0
+		for name, n := range m.Runtime {
+			names = append(names, name)
+			total += n
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if a, b := m.Runtime[names[i]], m.Runtime[names[j]]; a != b {
+				return a > b
+			}
+			return names[i] < names[j]
+		})
+		fmt.Fprintf(&buf, "%d runtime calls:", total)
+		for i, name := range names {
+			if i == 10 {
+				fmt.Fprintf(&buf, " and %d more", len(names)-i)
+				break
+			}
+			fmt.Fprintf(&buf, " %s %d", name, m.Runtime[name])
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// reportMetrics adds declarations written to a Go file to Config.Metrics.
+// Each declaration is only counted once, even if the file is written multiple times.
+func reportMetrics(env *libs.Env, gopath string, decls []GoDecl, conf Config) {
+	m := conf.Metrics
+	if m == nil {
+		return
+	}
+	if m.files == nil {
+		m.files = make(map[string]struct{})
+		m.decls = make(map[GoDecl]struct{})
+		m.Runtime = make(map[string]int)
+	}
+	if _, ok := m.files[gopath]; !ok {
+		m.files[gopath] = struct{}{}
+		m.Files++
+	}
+	// runtime packages are resolved by the name, thus they must be checked before imports are renamed
+	runtime := make(map[string]bool)
+	isRuntime := func(pkg string) bool {
+		v, ok := runtime[pkg]
+		if !ok {
+			v = strings.HasPrefix(env.ResolveImport(pkg), libs.RuntimePrefix)
+			runtime[pkg] = v
+		}
+		return v
+	}
+	for _, d := range decls {
+		if _, ok := m.decls[d]; ok {
+			continue
+		}
+		m.decls[d] = struct{}{}
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			m.Funcs++
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					m.Types++
+				case *ast.ValueSpec:
+					if d.Tok == token.CONST {
+						m.Consts += len(s.Names)
+					} else {
+						m.Vars += len(s.Names)
+					}
+				}
+			}
+		}
+		ast.Inspect(d, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if pkg, _, ok := goQualified(n); ok {
+					if pkg == "unsafe" {
+						m.Unsafe++
+					}
+					// the package identifier is already checked
+					return false
+				}
+			case *ast.Ident:
+				if pkg, _, ok := goQualified(n); ok && pkg == "unsafe" {
+					m.Unsafe++
+				} else if n.Name == "asm" {
+					m.Stubs++
+				}
+			case *ast.CallExpr:
+				if pkg, name, ok := goQualified(n.Fun); ok && pkg != "unsafe" && isRuntime(pkg) {
+					m.Runtime[pkg+"."+name]++
+				} else if id, ok := n.Fun.(*ast.Ident); ok && id.Name == "panic" && len(n.Args) == 1 && isStubPanic(n.Args[0]) {
+					m.Stubs++
+				}
+			}
+			return true
+		})
+	}
+}
+
+// goQualified splits a reference to a declaration of another package, which is either a selector or an identifier
+// with the package name.
+func goQualified(e ast.Expr) (pkg, name string, ok bool) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return strings.Cut(e.Name, ".")
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok && !strings.Contains(x.Name, ".") {
+			return x.Name, e.Sel.Name, true
+		}
+	}
+	return "", "", false
+}
+
+// isStubPanic checks if the panic message marks code that was not translated.
+func isStubPanic(e ast.Expr) bool {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return false
+	}
+	msg, err := strconv.Unquote(lit.Value)
+	return err == nil && (strings.HasPrefix(msg, "cxgo:") || strings.Contains(msg, "TODO"))
+}
@@ -0,0 +1,32 @@
+package cxgo
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+
+	"modernc.org/token"
+)
+
+// reMSVCExt matches MSVC extensions that are accepted, but ignored during the translation.
+var reMSVCExt = regexp.MustCompile(`\b(__declspec\s*\([^)]*\)|__cdecl|__stdcall|__fastcall|__thiscall|__vectorcall)`)
+
+// msvcNotes reports MSVC calling conventions and declspec attributes found in the C file.
+// They are defined as no-ops in the builtin header, thus these are only visible in the source.
+func msvcNotes(conf Config, fname string, fnc func(n Note)) error {
+	data, err := readSource(conf, fname)
+	if err != nil {
+		return err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for line := 1; sc.Scan(); line++ {
+		for _, m := range reMSVCExt.FindAllIndex(sc.Bytes(), -1) {
+			fnc(Note{
+				Where: token.Position{Filename: fname, Line: line, Column: m[0] + 1},
+				Msg:   "MSVC extension ignored: " + string(sc.Bytes()[m[0]:m[1]]),
+			})
+		}
+	}
+	return sc.Err()
+}
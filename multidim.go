@@ -0,0 +1,201 @@
+package cxgo
+
+import (
+	"fmt"
+	"go/ast"
+
+	"github.com/gotranspile/cxgo/types"
+	"modernc.org/token"
+)
+
+// multiDimType converts a multi-dimensional C array for HintSlices and HintFlat:
+//
+//	T a[X][Y] -> [][]T (slices)
+//	T a[X][Y] -> []T   (flat, indexed with a[i*Y+j])
+//
+// Slices only replace the first two dimensions, while flat slices replace all of them.
+func (g *translator) multiDimType(hint TypeHint, t types.Type, where token.Position) types.Type {
+	at, ok := types.Unwrap(t).(types.ArrayType)
+	var row types.ArrayType
+	if ok {
+		row, ok = types.Unwrap(at.Elem()).(types.ArrayType)
+	}
+	if !ok || at.IsSlice() || row.IsSlice() {
+		panic(fmt.Errorf("expected a multi-dimensional array, got: %v, %#v; defined at: %v", t, t, where))
+	}
+	if hint == HintSlices {
+		return types.SliceT(types.SliceT(row.Elem()))
+	}
+	return types.SliceT(flatElem(row))
+}
+
+// flatElem returns the element type of nested fixed arrays.
+func flatElem(t types.Type) types.Type {
+	for {
+		at, ok := types.Unwrap(t).(types.ArrayType)
+		if !ok || at.IsSlice() {
+			return t
+		}
+		t = at.Elem()
+	}
+}
+
+// flatLen returns the number of elements in nested fixed arrays.
+func flatLen(t types.Type) int64 {
+	n := int64(1)
+	for {
+		at, ok := types.Unwrap(t).(types.ArrayType)
+		if !ok || at.IsSlice() {
+			return n
+		}
+		n *= int64(at.Len())
+		t = at.Elem()
+	}
+}
+
+// multiDimInit converts the initializer of a variable with HintSlices or HintFlat, given its C array type.
+// Variables without an initializer are allocated, since C arrays are never nil.
+func (g *translator) multiDimInit(hint TypeHint, ct, vt types.Type, init Expr, where token.Position) Expr {
+	at := types.Unwrap(ct).(types.ArrayType)
+	row := types.Unwrap(at.Elem()).(types.ArrayType)
+	gint := g.env.Go().Int()
+	if init == nil {
+		if hint == HintFlat {
+			return &MakeExpr{e: g.env.Env, Elem: flatElem(row), Size: cIntLit(flatLen(at), 10)}
+		}
+		fnc := types.NewIdentGo("makeSlices", "libc.MakeSlices", g.env.FuncTT(vt, gint, gint))
+		return g.NewCCallExpr(FuncIdent{fnc}, []Expr{cIntLit(int64(at.Len()), 10), cIntLit(int64(row.Len()), 10)})
+	}
+	lit, ok := cUnwrap(init).(*CCompLitExpr)
+	if !ok {
+		panic(fmt.Errorf("unsupported initializer for a multi-dimensional array: %T; defined at: %v", init, where))
+	}
+	if hint == HintFlat {
+		var fields []*CompLitField
+		g.flatLitFields(&fields, lit, 0, where)
+		return &CCompLitExpr{Type: vt, Fields: padLitFields(fields, flatLen(at), g.ZeroValue(flatElem(at)))}
+	}
+	rt := types.SliceT(row.Elem())
+	fields := make([]*CompLitField, 0, len(lit.Fields))
+	for _, f := range lit.Fields {
+		r, ok := cUnwrap(f.Value).(*CCompLitExpr)
+		if !ok {
+			panic(fmt.Errorf("unsupported initializer for a multi-dimensional array: %T; defined at: %v", f.Value, where))
+		}
+		fields = append(fields, &CompLitField{
+			Index: f.Index,
+			Value: &CCompLitExpr{Type: rt, Fields: padLitFields(r.Fields, int64(row.Len()), g.ZeroValue(row.Elem()))},
+		})
+	}
+	missing := &MakeExpr{e: g.env.Env, Elem: row.Elem(), Size: cIntLit(int64(row.Len()), 10)}
+	return &CCompLitExpr{Type: vt, Fields: padLitFields(fields, int64(at.Len()), missing)}
+}
+
+// flatLitFields appends elements of a nested array literal with indexes in a flat slice.
+func (g *translator) flatLitFields(out *[]*CompLitField, lit *CCompLitExpr, off int64, where token.Position) {
+	at := types.Unwrap(lit.Type).(types.ArrayType)
+	stride := flatLen(at.Elem())
+	for _, f := range lit.Fields {
+		ind, ok := cUnwrap(f.Index).(IntLit)
+		if !ok {
+			panic(fmt.Errorf("unsupported index in a multi-dimensional array initializer; defined at: %v", where))
+		}
+		i := off + ind.Int()*stride
+		if stride == 1 {
+			*out = append(*out, &CompLitField{Index: cIntLit(i, 10), Value: f.Value})
+			continue
+		}
+		r, ok := cUnwrap(f.Value).(*CCompLitExpr)
+		if !ok {
+			panic(fmt.Errorf("unsupported initializer for a multi-dimensional array: %T; defined at: %v", f.Value, where))
+		}
+		g.flatLitFields(out, r, i, where)
+	}
+}
+
+// padLitFields adds the last element to the slice literal, if it is shorter than n.
+func padLitFields(fields []*CompLitField, n int64, zero Expr) []*CompLitField {
+	last := int64(-1)
+	for _, f := range fields {
+		if ind, ok := cUnwrap(f.Index).(IntLit); ok && ind.Int() > last {
+			last = ind.Int()
+		}
+	}
+	if last < n-1 {
+		fields = append(fields, &CompLitField{Index: cIntLit(n-1, 10), Value: zero})
+	}
+	return fields
+}
+
+// flatRow returns a row of a flat slice (see HintFlat), if x is a flat slice and typ is a C array. It returns nil otherwise.
+func (g *translator) flatRow(x Expr, at types.ArrayType, ind Expr, typ types.Type) Expr {
+	if typ == nil || !at.IsSlice() || types.Same(typ, at.Elem()) {
+		return nil
+	}
+	rt, ok := types.Unwrap(typ).(types.ArrayType)
+	if !ok || rt.IsSlice() || rt.Len() == 0 || !types.Same(flatElem(rt), at.Elem()) {
+		return nil
+	}
+	return &CFlatRow{
+		ctype: at,
+		X:     x,
+		Off:   g.NewCBinaryExpr(ind, BinOpMult, cIntLit(flatLen(rt), 10)),
+	}
+}
+
+// flatIndex indexes a row of a flat slice. The result is either the element, or the next row.
+func (g *translator) flatIndex(row *CFlatRow, ind Expr, typ types.Type) Expr {
+	stride := int64(1)
+	if rt, ok := types.Unwrap(typ).(types.ArrayType); ok && !rt.IsSlice() {
+		stride = flatLen(rt)
+	}
+	if stride != 1 {
+		ind = g.NewCBinaryExpr(ind, BinOpMult, cIntLit(stride, 10))
+	}
+	off := row.Off
+	if l, ok := ind.(IntLit); !ok || !l.IsZero() {
+		off = g.NewCBinaryExpr(off, BinOpAdd, ind)
+	}
+	if stride != 1 {
+		return &CFlatRow{ctype: row.ctype, X: row.X, Off: off}
+	}
+	return g.NewCIndexExpr(row.X, off, typ)
+}
+
+var _ Expr = (*CFlatRow)(nil)
+
+// CFlatRow is a row of a multi-dimensional C array stored in a flat slice; see HintFlat.
+// Indexing the row adds the index to the offset of the row.
+type CFlatRow struct {
+	ctype types.Type
+	X     Expr // flat slice
+	Off   Expr // index of the first element of the row
+}
+
+func (e *CFlatRow) Visit(v Visitor) {
+	v(e.X)
+	v(e.Off)
+}
+
+func (e *CFlatRow) CType(types.Type) types.Type {
+	return e.ctype
+}
+
+func (e *CFlatRow) AsExpr() GoExpr {
+	return &ast.SliceExpr{X: e.X.AsExpr(), Low: e.Off.AsExpr()}
+}
+
+func (e *CFlatRow) IsConst() bool {
+	return false
+}
+
+func (e *CFlatRow) HasSideEffects() bool {
+	return e.X.HasSideEffects() || e.Off.HasSideEffects()
+}
+
+func (e *CFlatRow) Uses() []types.Usage {
+	var list []types.Usage
+	list = append(list, e.X.Uses()...)
+	list = append(list, types.UseRead(e.Off)...)
+	return list
+}
@@ -563,12 +563,13 @@ void foo(int a) {
 `,
 		exp: `
 func foo(a int32) {
-	foo(int32(func() uint32 {
-		if a != 0 {
-			return 0
-		}
-		return 99999
-	}()))
+	var tmp uint32
+	if a != 0 {
+		tmp = 0
+	} else {
+		tmp = 99999
+	}
+	foo(int32(tmp))
 }
 `,
 	},
@@ -581,12 +582,13 @@ void foo(int a) {
 `,
 		exp: `
 func foo(a int32) {
-	foo(func() int32 {
-		if a != 0 {
-			return 128
-		}
-		return -128
-	}())
+	var tmp int32
+	if a != 0 {
+		tmp = 128
+	} else {
+		tmp = -128
+	}
+	foo(tmp)
 }
 `,
 	},
@@ -599,10 +601,8 @@ void foo(int a) {
 `,
 		exp: `
 func foo(a int32) {
-	a = func() int32 {
-		a = 1
-		return 1
-	}()
+	a = 1
+	a = 1
 }
 `,
 	},
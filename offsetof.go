@@ -0,0 +1,140 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// offsetofName is a builtin function used by the offsetof macro:
+//
+//	offsetof(T, member) -> _cxgo_offsetof((T)0, "member")
+const offsetofName = "_cxgo_offsetof"
+
+// offsetofCall converts offsetof calls to unsafe.Offsetof. The member may refer to fields of nested structs and
+// to array elements with constant indexes. It returns nil if the member cannot be resolved.
+func (g *translator) offsetofCall(fnc *types.Ident, args []Expr) Expr {
+	if fnc.Name != offsetofName || len(args) != 2 {
+		return nil
+	}
+	lit, ok := args[0].(*CCompLitExpr)
+	if !ok {
+		return nil
+	}
+	name, ok := args[1].(StringLit)
+	if !ok {
+		return nil
+	}
+	e := &OffsetofExpr{typ: g.env.Go().Uintptr(), Type: lit.Type}
+	typ := lit.Type
+	// the member is stringified by the macro, thus it may contain spaces
+	path := strings.Join(strings.Fields(name.Value()), "")
+	for path != "" {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			i := strings.IndexByte(path, ']')
+			if i < 0 {
+				return nil
+			}
+			ind, err := parseCIntLit(path[1:i], false)
+			if err != nil || ind.IsNeg() {
+				return nil
+			}
+			at, ok := types.Unwrap(typ).(types.ArrayType)
+			if !ok || at.IsSlice() {
+				return nil
+			}
+			e.Steps = append(e.Steps, OffsetofStep{Type: at, Index: int64(ind.Uint())})
+			typ, path = at.Elem(), path[i+1:]
+		default:
+			i := strings.IndexAny(path, ".[")
+			if i < 0 {
+				i = len(path)
+			}
+			st, ok := types.Unwrap(typ).(*types.StructType)
+			if !ok {
+				return nil
+			}
+			var field *types.Field
+			for _, f := range st.Fields() {
+				if f.Name.Name == path[:i] {
+					field = f
+					break
+				}
+			}
+			if field == nil {
+				return nil
+			}
+			e.Steps = append(e.Steps, OffsetofStep{Type: typ, Field: field})
+			typ, path = field.Type(), path[i:]
+		}
+	}
+	if len(e.Steps) == 0 || e.Steps[0].Field == nil {
+		return nil
+	}
+	return e
+}
+
+// OffsetofStep is a component of the member path in offsetof.
+type OffsetofStep struct {
+	Type  types.Type   // struct with the field, or an array
+	Field *types.Field // nil for array elements
+	Index int64        // index of the array element
+}
+
+var _ Expr = (*OffsetofExpr)(nil)
+
+// OffsetofExpr is an offset of the struct member in Go. Offsets of nested members are added together:
+//
+//	offsetof(T, a.b[2]) -> unsafe.Offsetof(T{}.A) + unsafe.Offsetof(A{}.B) + 2*unsafe.Sizeof(int32(0))
+type OffsetofExpr struct {
+	typ   types.Type
+	Type  types.Type
+	Steps []OffsetofStep
+}
+
+func (e *OffsetofExpr) Visit(v Visitor) {}
+
+func (e *OffsetofExpr) CType(types.Type) types.Type {
+	return e.typ
+}
+
+func (e *OffsetofExpr) AsExpr() GoExpr {
+	var x GoExpr
+	for _, s := range e.Steps {
+		var off GoExpr
+		if s.Field != nil {
+			off = call(ident("unsafe.Offsetof"), &ast.SelectorExpr{
+				X:   &ast.CompositeLit{Type: s.Type.GoType()},
+				Sel: s.Field.Name.GoIdent(),
+			})
+		} else if s.Index != 0 {
+			elem := types.Unwrap(s.Type).(types.ArrayType).Elem()
+			off = &ast.BinaryExpr{X: intLit64(s.Index, 10), Op: token.MUL, Y: sizeOf(elem)}
+		} else {
+			continue
+		}
+		if x == nil {
+			x = off
+		} else {
+			x = &ast.BinaryExpr{X: x, Op: token.ADD, Y: off}
+		}
+	}
+	return x
+}
+
+func (e *OffsetofExpr) IsConst() bool {
+	return true
+}
+
+func (e *OffsetofExpr) HasSideEffects() bool {
+	return false
+}
+
+func (e *OffsetofExpr) Uses() []types.Usage {
+	return nil
+}
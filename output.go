@@ -0,0 +1,37 @@
+package cxgo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Output receives generated Go files; see Config.Output.
+type Output interface {
+	// WriteFile writes a file with a given path. The path is joined with the output directory passed to Translate.
+	WriteFile(path string, data []byte) error
+}
+
+// MemOutput collects generated files in memory, by slash-separated path.
+type MemOutput map[string][]byte
+
+func (m MemOutput) WriteFile(path string, data []byte) error {
+	m[filepath.ToSlash(path)] = append([]byte{}, data...)
+	return nil
+}
+
+// dirOutput writes generated files to the OS filesystem.
+type dirOutput struct{}
+
+func (dirOutput) WriteFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (c *Config) output() Output {
+	if c.Output == nil {
+		return dirOutput{}
+	}
+	return c.Output
+}
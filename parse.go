@@ -2,7 +2,10 @@ package cxgo
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -13,11 +16,19 @@ import (
 )
 
 type SourceConfig struct {
+	Profile          PredefProfile
 	Predef           string
 	Define           []Define
 	Include          []string
 	SysInclude       []string
+	HostSysInclude   []string
+	HostPredef       string
 	IgnoreIncludeDir bool
+	C23              bool
+	Encoding         string // encoding of source files that are not valid UTF-8
+	FS               fs.FS  // read files from this filesystem instead of the OS one
+	Context          context.Context
+	Logger           *slog.Logger
 }
 
 func Parse(c *libs.Env, root, fname string, sconf SourceConfig) (*cc.AST, error) {
@@ -25,13 +36,18 @@ func Parse(c *libs.Env, root, fname string, sconf SourceConfig) (*cc.AST, error)
 	if root == "" {
 		root = path
 	}
-	srcs := []cc.Source{{Name: fname}}
-	if sconf.Predef != "" {
-		srcs = []cc.Source{
-			{Name: "predef.h", Value: sconf.Predef}, // FIXME: this should preappend to the file content instead
-			{Name: fname},
-		}
+	profile, err := sconf.Profile.Predef(c)
+	if err != nil {
+		return nil, err
+	}
+	var srcs []cc.Source
+	if predef := profile + sconf.Predef; predef != "" {
+		srcs = append(srcs, cc.Source{Name: "predef.h", Value: predef}) // FIXME: this should preappend to the file content instead
+	}
+	if sconf.HostPredef != "" {
+		srcs = append(srcs, cc.Source{Name: hostPredefName, Value: sconf.HostPredef})
 	}
+	srcs = append(srcs, cc.Source{Name: fname})
 	var (
 		inc []string
 		sys []string
@@ -53,12 +69,18 @@ func Parse(c *libs.Env, root, fname string, sconf SourceConfig) (*cc.AST, error)
 		"@",
 	)
 	return ParseSource(c, ParseConfig{
-		Sources:     srcs,
-		WorkDir:     path,
-		Includes:    inc,
-		SysIncludes: sys,
-		Predefines:  true,
-		Define:      sconf.Define,
+		Sources:         srcs,
+		WorkDir:         path,
+		Includes:        inc,
+		SysIncludes:     sys,
+		HostSysIncludes: sconf.HostSysInclude,
+		Predefines:      true,
+		Define:          sconf.Define,
+		C23:             sconf.C23,
+		Encoding:        sconf.Encoding,
+		FS:              sconf.FS,
+		Context:         sconf.Context,
+		Logger:          sconf.Logger,
 	})
 }
 
@@ -77,12 +99,18 @@ type Define struct {
 }
 
 type ParseConfig struct {
-	WorkDir     string
-	Includes    []string
-	SysIncludes []string
-	Predefines  bool
-	Define      []Define
-	Sources     []cc.Source
+	WorkDir         string
+	Includes        []string
+	SysIncludes     []string
+	HostSysIncludes []string // searched after the bundled headers
+	Predefines      bool
+	Define          []Define
+	Sources         []cc.Source
+	C23             bool            // support C23 keywords and syntax, see rewriteC23
+	Encoding        string          // encoding of source files that are not valid UTF-8, see decodeSource
+	FS              fs.FS           // read files from this filesystem instead of the OS one
+	Context         context.Context // stops parsing when cancelled
+	Logger          *slog.Logger    // logs resolution of headers and #warning directives
 }
 
 func ParseSource(env *libs.Env, c ParseConfig) (*cc.AST, error) {
@@ -103,22 +131,55 @@ func ParseSource(env *libs.Env, c ParseConfig) (*cc.AST, error) {
 	if c.Predefines {
 		srcs = append(srcs, cc.Source{Name: "cxgo_predef.h", Value: fmt.Sprintf(gccPredefine, "int")})
 	}
-	srcs = append(srcs, c.Sources...)
+	fs := cc.LocalFS()
+	if c.FS != nil {
+		fs = newSourceFS(c.FS)
+	}
+	if c.C23 {
+		srcs = append(srcs, cc.Source{Name: "cxgo_c23.h", Value: c23Predefine})
+	}
+	cs, err := sourceCharset(c.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	fs = newRewriteFS(fs, c.C23, cs)
+	for _, s := range c.Sources {
+		if s.Value != "" {
+			s.Value = string(rewriteSource([]byte(s.Value), c.C23, cs))
+		}
+		srcs = append(srcs, s)
+	}
+	if c.Context != nil {
+		if err := c.Context.Err(); err != nil {
+			return nil, err
+		}
+		fs = newCancelFS(c.Context, fs)
+	}
+	logger, ctx := c.Logger, c.Context
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	includes := addIncludeOverridePath(c.Includes)
-	sysIncludes := addIncludeOverridePath(c.SysIncludes)
+	sysIncludes := append(addIncludeOverridePath(c.SysIncludes), c.HostSysIncludes...)
 	return cc.Translate(&cc.Config{
 		Config3: cc.Config3{
 			WorkingDir: c.WorkDir,
-			Filesystem: cc.Overlay(cc.LocalFS(), newIncludeFS(env)),
+			Filesystem: cc.Overlay(fs, newIncludeFS(env, c.Logger)),
 		},
 		ABI: libcc.NewABI(env.Env),
 		PragmaHandler: func(p cc.Pragma, toks []cc.Token) {
 			if len(toks) == 0 {
 				return
 			}
-			name := toks[0].Value.String()
+			name, pos := toks[0].Value.String(), toks[0].Position()
 			toks = toks[1:]
 			switch name {
+			case pragmaWarning:
+				pos.Column = 0 // the name of the pragma is a part of the directive
+				logger.WarnContext(ctx, strings.TrimSpace("#warning "+warningMessage(toks)), "pos", pos.String())
 			case "push_macro":
 				if len(toks) != 3 {
 					return
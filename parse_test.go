@@ -51,6 +51,13 @@ func withAlias(name string) configFunc {
 	}
 }
 
+func withFlatten(name string) configFunc {
+	return func(c *Config) {
+		flatten := true
+		c.Idents = append(c.Idents, IdentConfig{Name: name, Flatten: &flatten})
+	}
+}
+
 func withRename(from, to string) configFunc {
 	return func(c *Config) {
 		c.Idents = append(c.Idents, IdentConfig{Name: from, Rename: to})
@@ -141,9 +148,7 @@ func foo(a int32) {
 		fallthrough
 	case 3:
 		foo(3)
-	case 4:
-		fallthrough
-	case 5:
+	case 4, 5:
 		foo(5)
 		return
 	case 6:
@@ -195,9 +200,7 @@ func foo(a int32) {
 		fallthrough
 	case 3:
 		foo(3)
-	case 4:
-		fallthrough
-	case 5:
+	case 4, 5:
 		foo(5)
 		return
 		a++
@@ -206,6 +209,161 @@ func foo(a int32) {
 		foo(6)
 	}
 }
+`,
+	},
+	{
+		name: "switch case range",
+		src: `
+void foo(int a) {
+	switch (a) {
+	case 1 ... 3:
+		foo(1);
+		break;
+	case 5:
+	case 6 ... 7:
+		foo(5);
+		break;
+	}
+}
+`,
+		exp: `
+func foo(a int32) {
+	switch a {
+	case 1, 2, 3:
+		foo(1)
+	case 5, 6, 7:
+		foo(5)
+	}
+}
+`,
+	},
+	{
+		name: "switch case range compare",
+		src: `
+int bar(void);
+void foo(int a) {
+	switch (a) {
+	case 0:
+		foo(0);
+	case 'a' ... 'z':
+		foo(1);
+		break;
+	case 10 ... 1000:
+		foo(2);
+		break;
+	}
+	switch (bar() + a) {
+	case 1 ... 100:
+		foo(3);
+		break;
+	default:
+		foo(4);
+	}
+}
+`,
+		exp: `
+func bar() int32
+func foo(a int32) {
+	switch {
+	case a == 0:
+		foo(0)
+		fallthrough
+	case a >= 'a' && a <= 'z':
+		foo(1)
+	case a >= 10 && a <= 1000:
+		foo(2)
+	}
+	switch v := bar() + a; {
+	case v >= 1 && v <= 100:
+		foo(3)
+	default:
+		foo(4)
+	}
+}
+`,
+	},
+	{
+		name: "do while",
+		src: `
+int next(void);
+void foo(int n) {
+	do {
+		n--;
+	} while (n > 0);
+	do {
+		if (n == 3) continue;
+		n++;
+	} while (n < 10 && next() != 2);
+}
+`,
+		exp: `
+func next() int32
+func foo(n int32) {
+	for {
+		n--
+		if n <= 0 {
+			break
+		}
+	}
+	for ok := true; ok; ok = n < 10 && next() != 2 {
+		if n == 3 {
+			continue
+		}
+		n++
+	}
+}
+`,
+	},
+	{
+		name: "constant loop conditions",
+		src: `
+void foo(int n) {
+	do {
+		n++;
+	} while (0);
+	do {
+		if (n) break;
+		n++;
+	} while (0);
+	do {
+		n++;
+	} while (1);
+	while (1 == 1) {
+		if (n > 6) break;
+		n++;
+	}
+	for (; 2 > 1;) {
+		if (n > 7) break;
+	}
+}
+`,
+		exp: `
+func foo(n int32) {
+	{
+		n++
+	}
+	for {
+		if n != 0 {
+			break
+		}
+		n++
+		break
+	}
+	for {
+		n++
+	}
+	for {
+		if n > 6 {
+			break
+		}
+		n++
+	}
+	for {
+		if n > 7 {
+			break
+		}
+	}
+}
 `,
 	},
 	{
@@ -790,6 +948,121 @@ func foo(a int32) {
 		b = 0
 	}
 }
+`,
+	},
+	{
+		name: "lift ternary",
+		src: `
+int bar(int a, int b);
+int foo(int a, int tmp) {
+	bar(a ? 1 : 2, tmp);
+	return bar(a, a ? tmp : 3) + 1;
+}
+`,
+		exp: `
+func bar(a int32, b int32) int32
+func foo(a int32, tmp int32) int32 {
+	var tmp2 int32
+	if a != 0 {
+		tmp2 = 1
+	} else {
+		tmp2 = 2
+	}
+	bar(tmp2, tmp)
+	var tmp3 int32
+	if a != 0 {
+		tmp3 = tmp
+	} else {
+		tmp3 = 3
+	}
+	return bar(a, tmp3) + 1
+}
+`,
+	},
+	{
+		name: "lift comma",
+		src: `
+int bar(int a);
+void foo(int a, int b) {
+	if ((a = bar(a), a > b)) {
+		b = bar((a++, b));
+	}
+}
+`,
+		exp: `
+func bar(a int32) int32
+func foo(a int32, b int32) {
+	a = bar(a)
+	if a > b {
+		a++
+		b = bar(b)
+	}
+}
+`,
+	},
+	{
+		name: "lift conditional",
+		src: `
+int bar(int a);
+void foo(int a, int b) {
+	if (a && bar(b ? 1 : 2)) {
+		return;
+	} else if (bar(b ? 3 : 4)) {
+		return;
+	}
+}
+`,
+		exp: `
+func bar(a int32) int32
+func foo(a int32, b int32) {
+	if a != 0 && bar(func() int32 {
+		if b != 0 {
+			return 1
+		}
+		return 2
+	}()) != 0 {
+		return
+	} else {
+		var tmp int32
+		if b != 0 {
+			tmp = 3
+		} else {
+			tmp = 4
+		}
+		if bar(tmp) != 0 {
+			return
+		}
+	}
+}
+`,
+	},
+	{
+		name: "lift goto",
+		src: `
+int bar(int a);
+void foo(int a) {
+	if (a) goto end;
+	bar(a ? 1 : 2);
+end:
+	return;
+}
+`,
+		exp: `
+func bar(a int32) int32
+func foo(a int32) {
+	var tmp int32
+	if a != 0 {
+		goto end
+	}
+	if a != 0 {
+		tmp = 1
+	} else {
+		tmp = 2
+	}
+	bar(tmp)
+end:
+	return
+}
 `,
 	},
 	{
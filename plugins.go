@@ -1,16 +1,15 @@
 package cxgo
 
 import (
-	"log"
-
 	"modernc.org/cc/v3"
 )
 
 func (g *translator) runASTPluginsC(cur string, _ *cc.AST, decl []CDecl) []CDecl {
 	if g.conf.Hooks {
-		for _, f := range astHooksC {
+		for i, f := range astHooksC {
+			g.conf.debug("running AST hook", "file", cur, "hook", i)
 			if err := f(g.conf, cur, decl); err != nil {
-				log.Println("error executing hook:", err)
+				g.conf.logger().Error("error executing hook", "file", cur, "hook", i, "err", err)
 			}
 		}
 	}
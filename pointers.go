@@ -22,6 +22,10 @@ func (g *translator) ToPointer(x Expr) PtrExpr {
 	if x, ok := cUnwrap(x).(IntLit); ok && x.IsZero() {
 		return g.Nil()
 	}
+	if _, ok := x.(*CParentExpr); ok && IsNil(x) {
+		// ((void*)0)
+		return g.Nil()
+	}
 	xt := x.CType(nil)
 	xk := xt.Kind()
 	if xk.IsFunc() {
@@ -210,6 +214,10 @@ func (g *translator) cAddr(x Expr) PtrExpr {
 	if x, ok := cUnwrap(x).(*Deref); ok {
 		return x.X
 	}
+	if e, ok := cUnwrap(x).(*AtomicIdent); ok {
+		// the pointer is used directly, without atomics
+		x = e.X
+	}
 	xt := x.CType(nil)
 	if xt.Kind().IsFunc() {
 		return g.ToPointer(x)
@@ -323,6 +331,9 @@ func (e *Deref) CType(types.Type) types.Type {
 }
 
 func (e *Deref) AsExpr() GoExpr {
+	if p, t, ok := e.byteOrderPtr(); ok {
+		return e.g.byteOrderLoad(p, t)
+	}
 	return deref(e.X.AsExpr())
 }
 
@@ -353,8 +364,9 @@ func (g *translator) cPtrToPtr(typ types.Type, x PtrExpr) PtrExpr {
 		return x
 	case *IntToPtr:
 		return &IntToPtr{
-			X:  x.X,
-			To: ptyp,
+			X:      x.X,
+			To:     ptyp,
+			handle: x.handle,
 		}
 	case *PtrOffset:
 		return &PtrOffset{
@@ -448,14 +460,16 @@ func (g *translator) cIntToPtr(x Expr) PtrExpr {
 		x = l.OverflowUint(g.env.PtrSize())
 	}
 	return &IntToPtr{
-		X:  x,
-		To: g.env.PtrT(nil),
+		X:      x,
+		To:     g.env.PtrT(nil),
+		handle: g.conf.PtrIntCast == PtrIntHandle && !x.IsConst(),
 	}
 }
 
 type IntToPtr struct {
-	X  Expr
-	To types.PtrType
+	X      Expr
+	To     types.PtrType
+	handle bool // convert via libc.HandleToPtr; see PtrIntHandle
 }
 
 func (e *IntToPtr) Visit(v Visitor) {
@@ -474,6 +488,13 @@ func (e *IntToPtr) AsExpr() GoExpr {
 	tp := e.To.GoType()
 	x := e.X.AsExpr()
 	x = call(ident("uintptr"), x)
+	if e.handle {
+		x = call(ident("libc.HandleToPtr"), x)
+		if e.To.Elem() == nil {
+			return x
+		}
+		return call(paren(tp), x)
+	}
 	if e.To.Elem() == nil {
 		return call(tp, x)
 	}
@@ -504,14 +525,16 @@ func (g *translator) cPtrToInt(typ types.Type, x PtrExpr) Expr {
 		typ = g.env.DefUintT()
 	}
 	return &PtrToInt{
-		X:  x,
-		To: typ,
+		X:      x,
+		To:     typ,
+		handle: g.conf.PtrIntCast == PtrIntHandle,
 	}
 }
 
 type PtrToInt struct {
-	X  PtrExpr
-	To types.Type
+	X      PtrExpr
+	To     types.Type
+	handle bool // convert via libc.PtrToHandle; see PtrIntHandle
 }
 
 func (e *PtrToInt) Visit(v Visitor) {
@@ -528,7 +551,11 @@ func (e *PtrToInt) AsExpr() GoExpr {
 	if !e.X.CType(nil).Kind().IsUnsafePtr() {
 		x = call(unsafePtr(), x)
 	}
-	x = call(ident("uintptr"), x)
+	if e.handle {
+		x = call(ident("libc.PtrToHandle"), x)
+	} else {
+		x = call(ident("uintptr"), x)
+	}
 	return call(e.CType(nil).GoType(), x)
 }
 
@@ -926,6 +926,50 @@ func foo() {
 }
 `,
 	},
+	{
+		name:     "custom allocators",
+		builtins: true,
+		src: `
+typedef struct { int x; } T;
+void* my_alloc(void* a, unsigned long size);
+void* my_calloc(void* a, int n, unsigned long size);
+void* get_arena();
+void foo(void* a, int n) {
+	T* p = my_alloc(a, sizeof(T));
+	int* q = my_calloc(a, n, sizeof(int));
+	char* s = my_alloc(a, 10);
+	T* p2 = my_alloc(get_arena(), sizeof(T));
+}
+`,
+		exp: `
+type T struct {
+	X int32
+}
+
+func my_alloc(a unsafe.Pointer, size uint32) unsafe.Pointer
+func my_calloc(a unsafe.Pointer, n int32, size uint32) unsafe.Pointer
+func get_arena() unsafe.Pointer
+func foo(a unsafe.Pointer, n int32) {
+	var p *T = new(T)
+	_ = p
+	var q *int32 = &make([]int32, int(n))[0]
+	_ = q
+	var s *byte = (*byte)(my_alloc(a, 10))
+	_ = s
+	var p2 *T = (*T)(my_alloc(get_arena(), uint32(unsafe.Sizeof(T{}))))
+	_ = p2
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				count := 1
+				c.Allocators = []AllocConfig{
+					{Name: "my_alloc", Size: 1},
+					{Name: "my_calloc", Size: 2, Count: &count},
+				}
+			},
+		},
+	},
 	{
 		name: "memset sizeof",
 		src: `
@@ -1348,6 +1392,91 @@ var c *int32 = &make([]int32, int(n))[0]
 			withIdent(IdentConfig{Name: "b", Type: HintSlice}),
 		},
 	},
+	{
+		name: "slice realloc",
+		src: `
+#include <stdlib.h>
+
+typedef struct {
+	int* items;
+	int cap;
+} vec;
+
+void grow(vec* v) {
+	v->cap = v->cap ? v->cap * 2 : 8;
+	v->items = realloc(v->items, v->cap * sizeof(int));
+}
+char* grow2(char* s, int n) {
+	if (n == 0) {
+		return realloc(0, 1);
+	}
+	return realloc(s, n);
+}
+`,
+		exp: `
+type vec struct {
+	Items []int32
+	Cap   int32
+}
+
+func grow(v *vec) {
+	if v.Cap != 0 {
+		v.Cap = v.Cap * 2
+	} else {
+		v.Cap = 8
+	}
+	v.Items = libc.ReallocSlice(v.Items, int(v.Cap))
+}
+func grow2(s []byte, n int32) []byte {
+	if n == 0 {
+		return make([]byte, 1)
+	}
+	return libc.ReallocSlice(s, int(n))
+}
+`,
+		configFuncs: []configFunc{
+			withIdentField("vec", IdentConfig{Name: "items", Type: HintSlice}),
+			withIdent(IdentConfig{Name: "grow2", Fields: []IdentConfig{
+				{Name: "s", Type: HintSlice},
+				{Name: "return", Type: HintSlice},
+			}}),
+		},
+	},
+	{
+		name: "multi-dimensional arrays",
+		src: `
+int a[3][4];
+int b[2][3] = {{1, 2, 3}, {4}};
+int c[2][2][2];
+
+int foo(int i, int j) {
+	int d[2][2] = {{1, 2}};
+	int *p = a[i];
+	c[i][j][1] = d[j][i];
+	return a[i][j] + b[i][j] + *p;
+}
+`,
+		exp: `
+var a []int32 = make([]int32, 12)
+var b []int32 = []int32{0: 1, 1: 2, 2: 3, 3: 4, 5: 0}
+var c []int32 = make([]int32, 8)
+
+func foo(i int32, j int32) int32 {
+	var (
+		d [][]int32 = [][]int32{{1, 2}, make([]int32, 2)}
+		p *int32    = &a[i*4]
+	)
+	c[i*4+j*2+1] = d[j][i]
+	return a[i*4+j] + b[i*3+j] + *p
+}
+`,
+		configFuncs: []configFunc{
+			withIdent(IdentConfig{Name: "a", Type: HintFlat}),
+			withIdent(IdentConfig{Name: "b", Type: HintFlat}),
+			withIdent(IdentConfig{Name: "c", Type: HintFlat}),
+			withIdent(IdentConfig{Name: "d", Type: HintSlices}),
+		},
+	},
 	{
 		name: "slice safe calloc",
 		src: `
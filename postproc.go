@@ -1,6 +1,10 @@
 package cxgo
 
-import "github.com/gotranspile/cxgo/types"
+import (
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
 
 func (g *translator) adaptMain(decl []CDecl) []CDecl {
 	for _, d := range decl {
@@ -16,21 +20,84 @@ func (g *translator) adaptMain(decl []CDecl) []CDecl {
 	return decl
 }
 
+// isFlattened checks if the function control flow must be flattened, as configured for the function or the file.
+func (g *translator) isFlattened(name string) bool {
+	if c, ok := g.idents[name]; ok && c.Flatten != nil {
+		return *c.Flatten
+	}
+	return g.conf.FlattenAll
+}
+
+// flattenReason checks if the function control flow must be flattened. The explicit setting of the function takes
+// precedence over FlattenAll, which takes precedence over gotos that are invalid in Go and heuristics. For the last
+// two, the reason is returned, so it can be reported.
+func (g *translator) flattenReason(f *CFuncDecl) (string, bool) {
+	if c, ok := g.idents[f.Name.Name]; ok && c.Flatten != nil {
+		if !*c.Flatten {
+			if reason := g.fixGotoScopes(f); reason != "" {
+				g.flattenNote(f, reason+", but flattening is disabled for "+declDesc(f))
+			}
+			return "", false
+		}
+		return "", true
+	}
+	if g.conf.FlattenAll {
+		return "", true
+	}
+	if reason := g.fixGotoScopes(f); reason != "" {
+		return reason, true
+	}
+	if (!g.conf.FlattenAuto && g.conf.FlattenGotos <= 0) || !hasGotos(f.Body.Stmts) {
+		return "", false
+	}
+	s := newGotoScopes(f)
+	if n := len(s.gotos); g.conf.FlattenGotos > 0 && n >= g.conf.FlattenGotos {
+		return strconv.Itoa(n) + " gotos", true
+	}
+	if g.conf.FlattenAuto {
+		for _, r := range s.gotos {
+			if l, ok := s.labels[r.label]; ok && l.before(r.pos) {
+				return "backward goto " + r.label, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (g *translator) flattenNote(f *CFuncDecl, msg string) {
+	if g.conf.OnNote != nil {
+		g.conf.OnNote(Note{Where: g.declPos[f], Msg: msg})
+	}
+}
+
 func (g *translator) flatten(decl []CDecl) {
 	for _, d := range decl {
 		f, ok := d.(*CFuncDecl)
 		if !ok || f.Body == nil {
 			continue
 		}
-		flatten := g.conf.FlattenAll
-		if c, ok := g.idents[f.Name.Name]; ok && c.Flatten != nil {
-			flatten = *c.Flatten
-		}
-		if !flatten {
+		reason, ok := g.flattenReason(f)
+		if !ok {
 			continue
 		}
+		g.checkCancel()
 		cf := g.NewControlFlow(f.Body.Stmts)
+		if stmts, ok := cf.Structure(); ok {
+			f.Body.Stmts = stmts
+			if reason != "" {
+				g.flattenNote(f, reason+", flattening "+declDesc(f))
+			}
+			continue
+		}
+		g.conf.debug("cannot restore structured control flow, falling back to flattening", "func", f.Name.Name)
 		f.Body.Stmts = cf.Flatten()
+		if m := g.conf.Metrics; m != nil {
+			m.Flattened++
+		}
+		if reason == "" {
+			reason = "flattening is enabled"
+		}
+		g.flattenNote(f, reason+", flattening "+declDesc(f)+" to labeled blocks, since its control flow is irreducible")
 	}
 }
 
@@ -39,6 +106,8 @@ func (g *translator) fixUnusedVars(decl []CDecl) {
 		switch d := d.(type) {
 		case *CFuncDecl:
 			g.fixUnusedVarsBlock(d.Body)
+			g.renameUnusedVars(d)
+			g.fixUnusedParams(d)
 		}
 	}
 }
@@ -229,6 +298,10 @@ func (g *translator) rewriteStmt(st CStmt) (CStmt, bool) {
 	case *BlockStmt:
 		g.rewriteStmts(st.Stmts)
 	case *CIfStmt:
+		if sw, ok := g.strcmpSwitch(st); ok {
+			g.rewriteStmt(sw)
+			return sw, true
+		}
 		g.rewriteStmts(st.Then.Stmts)
 		if st.Else != nil {
 			if e, ok := g.rewriteStmt(st.Else); ok {
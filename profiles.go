@@ -0,0 +1,104 @@
+package cxgo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+// PredefProfile is a set of predefined macros that C compilers define for a given platform.
+type PredefProfile string
+
+const (
+	ProfileNone        = PredefProfile("")
+	ProfileLinuxGNU    = PredefProfile("linux-gnu")
+	ProfileDarwin      = PredefProfile("darwin")
+	ProfileWindowsMSVC = PredefProfile("windows-msvc")
+	ProfileWasm        = PredefProfile("wasm")
+)
+
+// PredefProfiles returns all supported profiles.
+func PredefProfiles() []PredefProfile {
+	return []PredefProfile{
+		ProfileLinuxGNU,
+		ProfileDarwin,
+		ProfileWindowsMSVC,
+		ProfileWasm,
+	}
+}
+
+// DataModel returns a C data model used by compilers for this platform.
+func (p PredefProfile) DataModel() (types.DataModel, error) {
+	switch p {
+	case ProfileLinuxGNU, ProfileDarwin:
+		return types.LP64, nil
+	case ProfileWindowsMSVC:
+		return types.LLP64, nil
+	case ProfileWasm:
+		return types.ILP32, nil
+	}
+	return "", fmt.Errorf("unsupported predefine profile: %q", p)
+}
+
+// Predef returns C source with predefined macros for this profile. Macros that depend on
+// the pointer size are defined according to the environment.
+func (p PredefProfile) Predef(env *libs.Env) (string, error) {
+	ptr64 := env.PtrSize() == 8
+	var defs []string
+	switch p {
+	case ProfileNone:
+		return "", nil
+	case ProfileLinuxGNU:
+		// __linux__ is already defined by the builtin header
+		defs = []string{
+			"__linux 1", "linux 1",
+			"__unix__ 1", "__unix 1", "unix 1",
+			"__gnu_linux__ 1",
+			"__ELF__ 1",
+			"__GNUC__ 4", "__GNUC_MINOR__ 2",
+		}
+		if ptr64 {
+			defs = append(defs, "__x86_64__ 1", "__x86_64 1")
+		} else {
+			defs = append(defs, "__i386__ 1", "__i386 1")
+		}
+	case ProfileDarwin:
+		defs = []string{
+			"__APPLE__ 1", "__MACH__ 1",
+			"__GNUC__ 4", "__GNUC_MINOR__ 2",
+		}
+		if ptr64 {
+			defs = append(defs, "__x86_64__ 1")
+		} else {
+			defs = append(defs, "__i386__ 1")
+		}
+	case ProfileWindowsMSVC:
+		defs = []string{"_WIN32 1", "_MSC_VER 1930", "_MSC_FULL_VER 193000000"}
+		if ptr64 {
+			defs = append(defs, "_WIN64 1", "_M_X64 100", "_M_AMD64 100")
+		} else {
+			defs = append(defs, "_M_IX86 600")
+		}
+	case ProfileWasm:
+		defs = []string{"__wasm__ 1", "__wasm 1"}
+		if ptr64 {
+			defs = append(defs, "__wasm64__ 1", "__wasm64 1")
+		} else {
+			defs = append(defs, "__wasm32__ 1", "__wasm32 1")
+		}
+	default:
+		return "", fmt.Errorf("unsupported predefine profile: %q", p)
+	}
+	var buf strings.Builder
+	if p != ProfileLinuxGNU {
+		buf.WriteString("#undef __linux__\n")
+	}
+	for _, d := range defs {
+		buf.WriteString("#define ")
+		buf.WriteString(d)
+		buf.WriteByte('\n')
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,29 @@
+package cxgo
+
+// Pass is a name of a translation pass reported to Config.OnProgress.
+type Pass string
+
+const (
+	PassParse   = Pass("parse")   // parsing the C file and includes
+	PassConvert = Pass("convert") // converting C declarations
+	PassRewrite = Pass("rewrite") // rewriting statements and expressions
+	PassFlatten = Pass("flatten") // flattening control flow of functions
+	PassEmit    = Pass("emit")    // generating Go declarations
+	PassWrite   = Pass("write")   // printing and writing Go files
+)
+
+// Progress of translating a single C file.
+type Progress struct {
+	File  string // C file being translated
+	Pass  Pass   // current pass
+	Done  int    // declarations processed in the current pass
+	Total int    // total declarations for the current pass; zero if unknown
+}
+
+// progress reports the progress of the translation, if Config.OnProgress is set.
+func (c *Config) progress(file string, pass Pass, done, total int) {
+	if c.OnProgress == nil {
+		return
+	}
+	c.OnProgress(Progress{File: file, Pass: pass, Done: done, Total: total})
+}
@@ -0,0 +1,216 @@
+package cxgo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"modernc.org/cc/v3"
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+// TranslatorProject holds the type registry shared between multiple translation units.
+//
+// Translating each file separately with TranslateAST will produce a new set of types for each call,
+// thus the same C struct declared in a common header will have a different identity in each file.
+// Translating files via the same project guarantees that such types are only created once.
+type TranslatorProject struct {
+	env *libs.Env
+
+	ctypes    map[cc.Type]types.Type
+	namedPtrs map[string]types.PtrType
+	named     map[string]types.Named
+	layouts   map[*types.StructType]*structLayout
+	aliases   map[string]types.Type
+	macros    map[string]*types.Ident
+	macroInts map[*types.Ident]IntLit  // values of integer macros; see translator.exactIntConst
+	adapters  map[string][]funcAdapter // function adapters shared by all files; see translator.funcAdapter
+	typeDefs  map[string]projectDef    // C definitions of named types, by C name
+	macroVals map[string]projectDef    // values of macros, by name
+	units     int                      // number of translation units translated so far
+
+	shared     map[string][]GoDecl // declarations accumulated for shared files; see LayoutByKind
+	sharedConf *Config             // config of shared files; see sharedConfig
+	symbols    symbolTable         // symbols of declarations that are not written yet; see Config.OnSymbol
+
+	pending []pendingFile       // files delayed until Flush; see Config.TreeShake
+	roots   []string            // Go names of root declarations in pending files
+	weak    weakSymbols         // weak declarations delayed until Flush
+	inline  *pendingFile        // inline functions from headers; see Config.InlineHeaders
+	inlines map[string]struct{} // Go names of functions in the inline file
+}
+
+// NewProject creates a new project with an empty type registry.
+func NewProject(env *libs.Env) *TranslatorProject {
+	return &TranslatorProject{
+		env:       env,
+		ctypes:    make(map[cc.Type]types.Type),
+		namedPtrs: make(map[string]types.PtrType),
+		named:     make(map[string]types.Named),
+		layouts:   make(map[*types.StructType]*structLayout),
+		aliases:   make(map[string]types.Type),
+		macros:    make(map[string]*types.Ident),
+		macroInts: make(map[*types.Ident]IntLit),
+		adapters:  make(map[string][]funcAdapter),
+		typeDefs:  make(map[string]projectDef),
+		macroVals: make(map[string]projectDef),
+	}
+}
+
+// Env returns the environment used by the project.
+func (p *TranslatorProject) Env() *libs.Env {
+	return p.env
+}
+
+// NamedType returns a named type with a given C name, if it was already translated.
+func (p *TranslatorProject) NamedType(name string) (types.Named, bool) {
+	t, ok := p.named[name]
+	return t, ok
+}
+
+// NamedTypes returns all named types translated so far, sorted by C name.
+func (p *TranslatorProject) NamedTypes() []types.Named {
+	out := make([]types.Named, 0, len(p.named))
+	for _, t := range p.named {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name().Name < out[j].Name().Name
+	})
+	return out
+}
+
+// Translate parses and translates a C file, and writes Go files to the output directory.
+// See the package-level Translate function for details.
+func (p *TranslatorProject) Translate(root, fname, out string, conf Config) error {
+	return translateFile(p, root, fname, out, conf)
+}
+
+// TranslateAST takes a C translation unit and converts it to a list of Go declarations.
+// Types are shared with all other files translated by this project.
+//
+// If Config.TreeShake is set, only declarations reachable from roots declared in this translation unit are returned.
+func (p *TranslatorProject) TranslateAST(fname string, tu *cc.AST, conf Config) ([]GoDecl, error) {
+	tr, err := p.translateAST(fname, tu, conf)
+	if err != nil {
+		return nil, err
+	}
+	decls := tr.decls
+	if conf.TreeShake {
+		decls = filterReachable(decls, tr.roots)
+	}
+	return decls, nil
+}
+
+// translation is a result of translating a single C translation unit.
+type translation struct {
+	decls    []GoDecl
+	roots    []string    // Go names of root declarations for Config.TreeShake
+	bench    []GoDecl    // benchmarks for Config.Benchmarks
+	cwrap    []GoDecl    // cgo wrappers for benchmarks of C functions
+	cbench   []GoDecl    // benchmarks of C functions
+	embeds   []embedFile // binary files for Config.EmbedArrays
+	weakDefs []string    // Go names of weak definitions
+	weakRefs []GoDecl    // zero values for weak declarations without a definition
+	inlines  []string    // Go names of inline functions from headers
+	cgo      []string    // C code added to Config.CgoPreamble
+}
+
+// translateAST translates a C translation unit to Go declarations, as well as additional declarations
+// that are written to separate files.
+func (p *TranslatorProject) translateAST(fname string, tu *cc.AST, conf Config) (*translation, error) {
+	return p.translateUnit(fname, ccUnit{tu}, conf)
+}
+
+// translateUnit is similar to translateAST, but accepts a translation unit from any frontend.
+func (p *TranslatorProject) translateUnit(fname string, unit sourceUnit, conf Config) (_ *translation, rerr error) {
+	defer recoverError(&rerr)
+	t := p.newTranslator(conf)
+	if err := t.compileSkip(); err != nil {
+		return nil, err
+	}
+	if err := t.compileRoots(); err != nil {
+		return nil, err
+	}
+	if err := t.compileGenerics(); err != nil {
+		return nil, err
+	}
+	if err := conf.PtrIntCast.validate(); err != nil {
+		return nil, err
+	}
+	tr := &translation{}
+	tr.decls, tr.roots = t.translate(fname, unit)
+	tr.embeds = t.embeds
+	tr.weakDefs = t.weakDefs
+	tr.cgo = t.cgoPreamble
+	for _, id := range t.inlines {
+		tr.inlines = append(tr.inlines, id.GoIdent().Name)
+	}
+	for _, d := range t.weakRefs {
+		tr.weakRefs = append(tr.weakRefs, d.AsDecl()...)
+	}
+	if err := sortDecls(tr.decls, conf.DeclOrder); err != nil {
+		return nil, err
+	}
+	var err error
+	tr.bench, tr.cwrap, tr.cbench, err = t.benchmarks()
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// TranslateCAST takes a C translation unit and converts it to a list of cxgo declarations.
+// Types are shared with all other files translated by this project.
+func (p *TranslatorProject) TranslateCAST(fname string, tu *cc.AST, conf Config) (_ []CDecl, rerr error) {
+	defer recoverError(&rerr)
+	t := p.newTranslator(conf)
+	if err := t.compileSkip(); err != nil {
+		return nil, err
+	}
+	if err := t.compileRoots(); err != nil {
+		return nil, err
+	}
+	return t.translateC(fname, ccUnit{tu}), nil
+}
+
+// projectDef is a definition of a named type or a macro, which is shared by all translation units of the project.
+type projectDef struct {
+	key  string // C definition or value, compared to find conflicting definitions
+	unit int    // translation unit that defined it first
+	pos  token.Position
+}
+
+// checkDef records the definition of a named type or a macro shared by the project.
+// Since the first definition is reused by all translation units, it fails if a different translation unit
+// defines the same name differently.
+func (g *translator) checkDef(defs map[string]projectDef, kind, name, key string, pos token.Position) {
+	prev, ok := defs[name]
+	if !ok {
+		defs[name] = projectDef{key: key, unit: g.unit, pos: pos}
+		return
+	}
+	if prev.unit != g.unit && prev.key != key {
+		panic(&ErrConflictingDefinition{Kind: kind, Name: name, Pos: pos, Prev: prev.pos})
+	}
+}
+
+// ccDefKey returns a definition of the C type, which is the same for equal types in different translation units.
+func ccDefKey(t cc.Type) string {
+	switch t.Kind() {
+	case cc.Struct, cc.Union:
+	default:
+		return t.String()
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%v {", t.Kind())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.FieldByIndex([]int{i})
+		fmt.Fprintf(&buf, " %s %v;", f.Name(), f.Type())
+	}
+	buf.WriteString(" }")
+	return buf.String()
+}
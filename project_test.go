@@ -0,0 +1,94 @@
+package cxgo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func TestProjectSharedTypes(t *testing.T) {
+	const hdr = `
+typedef struct point {
+	int x;
+	int y;
+} point;
+`
+	env := libs.NewEnv(types.Config32())
+	p := NewProject(env)
+
+	var funcs []*CFuncDecl
+	for _, src := range []struct{ name, src string }{
+		{"a.c", hdr + "int fa(point* p) { return p->x; }"},
+		{"b.c", hdr + "int fb(point* p) { return p->y; }"},
+	} {
+		ast, err := ParseSource(env, ParseConfig{
+			Sources: []cc.Source{{Name: src.name, Value: src.src}},
+		})
+		require.NoError(t, err)
+		decls, err := p.TranslateCAST(src.name, ast, Config{})
+		require.NoError(t, err)
+		for _, d := range decls {
+			if f, ok := d.(*CFuncDecl); ok {
+				funcs = append(funcs, f)
+			}
+		}
+	}
+	require.Len(t, funcs, 2)
+	pa := funcs[0].Type.Args()[0].Type()
+	pb := funcs[1].Type.Args()[0].Type()
+	require.True(t, pa == pb, "expected the same pointer type in both files")
+
+	nt, ok := p.NamedType("point")
+	require.True(t, ok)
+	require.True(t, pa.(types.PtrType).Elem() == nt)
+	require.Len(t, p.NamedTypes(), 1)
+}
+
+func TestProjectConflictingDefinitions(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		kind string
+		a, b string
+	}{
+		{
+			name: "struct",
+			kind: "type",
+			a:    "struct point { int x; int y; }; int fa(struct point* p) { return p->x; }",
+			b:    "struct point { int x; double y; }; int fb(struct point* p) { return p->x; }",
+		},
+		{
+			name: "typedef",
+			kind: "type",
+			a:    "typedef int num; num fa() { return 1; }",
+			b:    "typedef long long num; num fb() { return 2; }",
+		},
+		{
+			name: "macro",
+			kind: "macro",
+			a:    "#define SIZE 10\nint fa() { return SIZE; }",
+			b:    "#define SIZE 20\nint fb() { return SIZE; }",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			env := libs.NewEnv(types.Config32())
+			p := NewProject(env)
+			var errs []error
+			for _, src := range []struct{ name, src string }{{"a.c", c.a}, {"b.c", c.b}} {
+				ast, err := ParseSource(env, ParseConfig{
+					Sources: []cc.Source{{Name: src.name, Value: src.src}},
+				})
+				require.NoError(t, err)
+				_, err = p.TranslateCAST(src.name, ast, Config{})
+				errs = append(errs, err)
+			}
+			require.NoError(t, errs[0])
+			var derr *ErrConflictingDefinition
+			require.ErrorAs(t, errs[1], &derr)
+			require.Equal(t, c.kind, derr.Kind)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package cxgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"modernc.org/token"
+)
+
+// HeaderInfo is passed to the Config.Header template.
+type HeaderInfo struct {
+	Version    string // cxgo version; see Config.Version
+	Source     string // C file the Go file was generated from, relative to the root; empty for shared files
+	ConfigHash string // hash of the config; see Config.ConfigHash
+}
+
+// writeHeader writes the header comment generated from the Config.Header template.
+func writeHeader(w io.Writer, conf Config) error {
+	if conf.Header == "" {
+		return nil
+	}
+	t, err := template.New("header").Parse(conf.Header)
+	if err != nil {
+		return fmt.Errorf("invalid header template: %w", err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, HeaderInfo{
+		Version:    conf.Version,
+		Source:     conf.source,
+		ConfigHash: conf.ConfigHash,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid header template: %w", err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		if line == "" {
+			fmt.Fprintln(w, "//")
+		} else {
+			fmt.Fprintln(w, "// "+line)
+		}
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// sourceName returns the name of the C file relative to the root, as used in generated comments.
+func sourceName(root, fname string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(root, fname); err == nil && !strings.HasPrefix(rel, "..") {
+			fname = rel
+		}
+	}
+	return filepath.ToSlash(fname)
+}
+
+// addProvenance adds a comment with the origin C file and line to Go declarations; see Config.Provenance.
+func (g *translator) addProvenance(decls []GoDecl, pos token.Position) {
+	if !g.conf.Provenance || !pos.IsValid() {
+		return
+	}
+	text := fmt.Sprintf("// from %s:%d", sourceName(g.conf.Root, pos.Filename), pos.Line)
+	for _, d := range decls {
+		c := &ast.Comment{Text: text}
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			d.Doc = appendComment(d.Doc, c)
+		case *ast.GenDecl:
+			d.Doc = appendComment(d.Doc, c)
+		}
+	}
+}
+
+func appendComment(g *ast.CommentGroup, c *ast.Comment) *ast.CommentGroup {
+	if g == nil {
+		g = &ast.CommentGroup{}
+	}
+	g.List = append(g.List, c)
+	return g
+}
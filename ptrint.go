@@ -0,0 +1,142 @@
+package cxgo
+
+import (
+	"fmt"
+	"go/ast"
+)
+
+// PtrIntCast selects how casts between pointers and integers are translated; see Config.PtrIntCast.
+type PtrIntCast string
+
+const (
+	// PtrIntDefault converts pointers to integers and back via uintptr.
+	PtrIntDefault = PtrIntCast("")
+	// PtrIntUintptr is the same as PtrIntDefault, but also marks Go functions that convert integers back to pointers
+	// with //go:nocheckptr, so the code can be tested with -race or -d=checkptr.
+	PtrIntUintptr = PtrIntCast("uintptr")
+	// PtrIntHandle converts pointers to integer handles and back via libc.PtrToHandle and libc.HandleToPtr.
+	// Handles keep the objects alive and don't break the GC pointer rules, but arithmetic on them is not supported,
+	// thus casts used in arithmetic are converted via uintptr; see ptrIntHandleDecl.
+	PtrIntHandle = PtrIntCast("handle")
+	// PtrIntError fails the translation if a pointer is converted to an integer or back.
+	PtrIntError = PtrIntCast("error")
+)
+
+func (c PtrIntCast) validate() error {
+	switch c {
+	case PtrIntDefault, PtrIntUintptr, PtrIntHandle, PtrIntError:
+		return nil
+	}
+	return fmt.Errorf("unsupported pointer to integer cast policy: %q", c)
+}
+
+// isPtrIntCast checks if the node converts a pointer to an integer, or a non-constant integer to a pointer.
+// Constant integers, like (void*)-1, never point to Go memory, thus they are always converted via uintptr.
+func isPtrIntCast(n Node) bool {
+	switch n := n.(type) {
+	case *PtrToInt:
+		return true
+	case *IntToPtr:
+		return !n.X.IsConst()
+	}
+	return false
+}
+
+// ptrIntCheckDecl fails the translation for pointer to integer casts if Config.PtrIntCast is PtrIntError.
+func (g *translator) ptrIntCheckDecl(d CDecl) {
+	if g.conf.PtrIntCast != PtrIntError {
+		return
+	}
+	Walk(d, func(n Node) bool {
+		if !isPtrIntCast(n) {
+			return true
+		}
+		msg := "pointer converted to an integer"
+		if _, ok := n.(*IntToPtr); ok {
+			msg = "integer converted to a pointer"
+		}
+		panic(&ErrUnsupportedConstruct{Kind: msg + " in " + declDesc(d) + " (see ptr_int_cast)", Pos: g.declPos[d]})
+	})
+}
+
+// handleCast returns a pointer to integer cast that converts to a handle, if it's the value of the expression.
+func handleCast(e Expr) *PtrToInt {
+	for {
+		switch x := e.(type) {
+		case *CParentExpr:
+			e = x.Expr
+		case *CCastExpr:
+			e = x.Expr
+		case *PtrToInt:
+			if x.handle {
+				return x
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// ptrIntHandleDecl converts pointers via uintptr instead of handles if Config.PtrIntCast is PtrIntHandle,
+// but the integer is used in arithmetic or in an ordered comparison, for example in an alignment check.
+// Handles are opaque, thus such expressions would silently give a different result. Each cast is reported
+// with Config.OnNote.
+func (g *translator) ptrIntHandleDecl(d CDecl) {
+	if g.conf.PtrIntCast != PtrIntHandle {
+		return
+	}
+	fallback := func(exprs ...Expr) {
+		for _, e := range exprs {
+			x := handleCast(e)
+			if x == nil {
+				continue
+			}
+			x.handle = false
+			if g.conf.OnNote != nil {
+				g.conf.OnNote(Note{Where: g.declPos[d], Msg: "pointer used in integer arithmetic is converted via uintptr in " + declDesc(d) + " (see ptr_int_cast)"})
+			}
+		}
+	}
+	Walk(d, func(n Node) bool {
+		switch n := n.(type) {
+		case *CBinaryExpr:
+			fallback(n.Left, n.Right)
+		case *Comparison:
+			if n.Op.IsRelational() {
+				fallback(n.X, n.Y)
+			}
+		case *CAssignStmt:
+			if n.Op != "" {
+				fallback(n.Right)
+			}
+		}
+		return true
+	})
+}
+
+// addNoCheckPtr adds the //go:nocheckptr directive to Go functions that convert integers to pointers,
+// if Config.PtrIntCast is PtrIntUintptr.
+func (g *translator) addNoCheckPtr(d CDecl, decls []GoDecl) {
+	if g.conf.PtrIntCast != PtrIntUintptr {
+		return
+	}
+	if _, ok := d.(*CFuncDecl); !ok {
+		return
+	}
+	found := false
+	Walk(d, func(n Node) bool {
+		if x, ok := n.(*IntToPtr); ok && isPtrIntCast(x) {
+			found = true
+		}
+		return !found
+	})
+	if !found {
+		return
+	}
+	for _, gd := range decls {
+		if f, ok := gd.(*ast.FuncDecl); ok {
+			f.Doc = appendComment(f.Doc, &ast.Comment{Text: "//go:nocheckptr"})
+		}
+	}
+}
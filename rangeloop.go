@@ -0,0 +1,302 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// CRangeStmt is a Go range loop over an array. It is only produced for canonical C loops; see Config.RangeLoops.
+type CRangeStmt struct {
+	Key   *types.Ident // optional
+	Value *types.Ident // optional
+	X     Expr
+	Body  BlockStmt
+}
+
+func (s *CRangeStmt) Visit(v Visitor) {
+	if s.Key != nil {
+		v(IdentExpr{s.Key})
+	}
+	if s.Value != nil {
+		v(IdentExpr{s.Value})
+	}
+	v(s.X)
+	v(&s.Body)
+}
+
+func (s *CRangeStmt) EachStmt(fnc CStmtFunc) bool {
+	return s.Body.EachStmt(fnc)
+}
+
+func (s *CRangeStmt) AsStmt() []GoStmt {
+	st := &ast.RangeStmt{
+		X:    s.X.AsExpr(),
+		Body: s.Body.GoBlockStmt(),
+	}
+	if s.Key != nil || s.Value != nil {
+		st.Tok = token.DEFINE
+		st.Key = ident("_")
+		if s.Key != nil {
+			st.Key = s.Key.GoIdent()
+		}
+		if s.Value != nil {
+			st.Value = s.Value.GoIdent()
+		}
+	}
+	return []GoStmt{st}
+}
+
+func (s *CRangeStmt) Uses() []types.Usage {
+	var list []types.Usage
+	list = append(list, types.UseRead(s.X)...)
+	list = append(list, s.Body.Uses()...)
+	return list
+}
+
+// rangeLoops replaces canonical loops over arrays with range loops, if Config.RangeLoops is set.
+func (g *translator) rangeLoops(decl []CDecl) {
+	if !g.conf.RangeLoops {
+		return
+	}
+	for _, d := range decl {
+		f, ok := d.(*CFuncDecl)
+		if !ok || f.Body == nil {
+			continue
+		}
+		cEachBlockStmt(func(stmts []CStmt) {
+			for i, st := range stmts {
+				if l, ok := st.(*CForStmt); ok {
+					if r := g.rangeLoop(f, l); r != nil {
+						stmts[i] = r
+					}
+				}
+			}
+		}, f.Body.Stmts)
+	}
+}
+
+// rangeLoop converts "for (int i = 0; i < N; i++)" to a range loop over an array of length N, if the index is only
+// used to access elements of arrays. The loop over a[i] is converted to "for _, v := range a" if the body only reads
+// those elements and has no other side effects, since the value is read before each iteration. It returns nil
+// if the loop cannot be converted.
+func (g *translator) rangeLoop(f *CFuncDecl, l *CForStmt) *CRangeStmt {
+	i, n, ok := canonicalLoop(l)
+	if !ok {
+		return nil
+	}
+	var (
+		uses    = make(map[*types.Ident]int) // all uses of identifiers in the body
+		indexed = make(map[*types.Ident]int) // uses of arrays indexed by i
+		arrays  []*types.Ident
+		memory  = writesMemory(f, &l.Body)
+	)
+	Walk(&l.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case IdentExpr:
+			uses[n.Ident]++
+		case *CIndexExpr:
+			if id, ok := cUnwrap(n.Index).(IdentExpr); ok && id.Ident == i {
+				if _, ok := types.Unwrap(n.Expr.CType(nil)).(types.ArrayType); !ok {
+					break
+				}
+				if a, ok := cUnwrap(n.Expr).(IdentExpr); ok {
+					if indexed[a.Ident] == 0 {
+						arrays = append(arrays, a.Ident)
+					}
+					indexed[a.Ident]++
+				}
+				// i as an index of any other array is fine as well
+				uses[i]--
+			}
+		}
+		return true
+	})
+	if uses[i] != 0 {
+		// used in some other way
+		return nil
+	}
+	var arr *types.Ident
+	for _, a := range arrays {
+		if at, ok := types.Unwrap(a.CType(nil)).(types.ArrayType); !ok || at.IsSlice() || int64(at.Len()) != n {
+			continue
+		}
+		if arr == nil {
+			arr = a
+		}
+		if !memory && uses[a] == indexed[a] {
+			// only elements are read, iterate over values
+			arr = a
+			break
+		}
+	}
+	if arr == nil {
+		return nil
+	}
+	r := &CRangeStmt{X: IdentExpr{arr}, Body: l.Body}
+	if memory || uses[arr] != indexed[arr] {
+		r.Key = i
+		return r
+	}
+	at := types.Unwrap(arr.CType(nil)).(types.ArrayType)
+	r.Value = types.NewIdent(unusedName(f, "v"), at.Elem())
+	Rewrite(&r.Body, nil, func(c *Cursor) bool {
+		if e, ok := c.Node().(*CIndexExpr); ok {
+			a, ok1 := cUnwrap(e.Expr).(IdentExpr)
+			id, ok2 := cUnwrap(e.Index).(IdentExpr)
+			if ok1 && ok2 && a.Ident == arr && id.Ident == i {
+				c.Replace(IdentExpr{r.Value})
+			}
+		}
+		return true
+	})
+	if len(indexed) > 1 {
+		// i is still used for other arrays
+		r.Key = i
+	}
+	return r
+}
+
+// canonicalLoop matches "for (int i = 0; i < N; i++)" with a constant N and no other writes to i.
+func canonicalLoop(l *CForStmt) (*types.Ident, int64, bool) {
+	ds, ok := l.Init.(*CDeclStmt)
+	if !ok {
+		return nil, 0, false
+	}
+	d, ok := ds.Decl.(*CVarDecl)
+	if !ok || len(d.Names) != 1 || len(d.Inits) != 1 {
+		return nil, 0, false
+	}
+	if v, ok := cUnwrap(d.Inits[0]).(IntLit); !ok || !v.IsZero() {
+		return nil, 0, false
+	}
+	i := d.Names[0]
+	if _, ok := types.Unwrap(i.CType(nil)).(types.IntType); !ok {
+		return nil, 0, false
+	}
+	cond, ok := l.Cond.(*Comparison)
+	if !ok || cond.Op != BinOpLt {
+		return nil, 0, false
+	}
+	if x, ok := cUnwrap(cond.X).(IdentExpr); !ok || x.Ident != i {
+		return nil, 0, false
+	}
+	n, ok := constIntValue(cond.Y)
+	if !ok || n <= 0 {
+		return nil, 0, false
+	}
+	iter, ok := l.Iter.(*CIncrStmt)
+	if !ok || iter.Decr {
+		return nil, 0, false
+	}
+	if x, ok := cUnwrap(iter.Expr).(IdentExpr); !ok || x.Ident != i {
+		return nil, 0, false
+	}
+	return i, n, true
+}
+
+// constIntValue evaluates simple constant integer expressions, like array lengths computed with sizeof.
+func constIntValue(e Expr) (int64, bool) {
+	switch e := e.(type) {
+	case IntLit:
+		if e.IsNeg() {
+			return e.Int(), true
+		}
+		v := e.Uint()
+		return int64(v), int64(v) >= 0
+	case *CParentExpr:
+		return constIntValue(e.Expr)
+	case *CCastExpr:
+		if _, ok := types.Unwrap(e.Type).(types.IntType); !ok {
+			return 0, false
+		}
+		return constIntValue(e.Expr)
+	case *CSizeofExpr:
+		return int64(e.Type.Sizeof()), true
+	case *CBinaryExpr:
+		x, ok1 := constIntValue(e.Left)
+		y, ok2 := constIntValue(e.Right)
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+		switch e.Op {
+		case BinOpAdd:
+			return x + y, true
+		case BinOpSub:
+			return x - y, true
+		case BinOpMult:
+			return x * y, true
+		case BinOpDiv:
+			if y == 0 {
+				return 0, false
+			}
+			return x / y, true
+		}
+	}
+	return 0, false
+}
+
+// unusedName returns a Go name with a given prefix that is not used in the function.
+func unusedName(f *CFuncDecl, prefix string) string {
+	used := make(map[string]struct{})
+	for _, a := range f.Type.Args() {
+		if a.Name != nil {
+			used[a.Name.GoIdent().Name] = struct{}{}
+		}
+	}
+	Walk(f, func(n Node) bool {
+		if id, ok := n.(IdentExpr); ok {
+			used[id.GoIdent().Name] = struct{}{}
+		}
+		return true
+	})
+	name := prefix
+	for i := 2; ; i++ {
+		if _, ok := used[name]; !ok {
+			return name
+		}
+		name = prefix + strconv.Itoa(i)
+	}
+}
+
+// writesMemory checks if the node has calls, takes addresses or writes memory other than local variables
+// of the function. Global variables may alias the memory of pointers.
+func writesMemory(f *CFuncDecl, n Node) bool {
+	locals := make(map[*types.Ident]struct{})
+	for _, a := range f.Type.Args() {
+		locals[a.Name] = struct{}{}
+	}
+	Walk(f.Body, func(n Node) bool {
+		if d, ok := n.(*CVarDecl); ok {
+			for _, id := range d.Names {
+				locals[id] = struct{}{}
+			}
+		}
+		return true
+	})
+	isLocal := func(e Expr) bool {
+		id, ok := cUnwrap(e).(IdentExpr)
+		if !ok {
+			return false
+		}
+		_, ok = locals[id.Ident]
+		return ok
+	}
+	memory := false
+	Walk(n, func(n Node) bool {
+		switch n := n.(type) {
+		case *CallExpr, *TakeAddr:
+			memory = true
+		case *CAssignStmt:
+			memory = memory || !isLocal(n.Left)
+		case *CIncrStmt:
+			memory = memory || !isLocal(n.Expr)
+		case *CIncrExpr:
+			memory = memory || !isLocal(n.Expr)
+		}
+		return !memory
+	})
+	return memory
+}
@@ -0,0 +1,56 @@
+package cxgo
+
+import (
+	"github.com/gotranspile/cxgo/types"
+)
+
+// reallocSlice converts realloc of a slice to libc.ReallocSlice, which grows the slice with append:
+//
+//	s = realloc(s, n * sizeof(T))    -> s = libc.ReallocSlice(s, n)
+//	s = realloc(NULL, n * sizeof(T)) -> s = make([]T, n)
+//
+// It returns nil if the call is not a realloc of a slice with the given type.
+func (g *translator) reallocSlice(to types.ArrayType, x Expr) Expr {
+	c, ok := x.(*CallExpr)
+	if !ok || len(c.Args) != 2 {
+		return nil
+	}
+	if f, ok := c.Fun.(Ident); !ok || f.Identifier().GoName != "libc.Realloc" {
+		return nil
+	}
+	var s Expr
+	switch a := unwrapPtrCasts(c.Args[0]).(type) {
+	case *TakeAddr:
+		// &s[0]
+		ind, ok := a.X.(*CIndexExpr)
+		if !ok || !ind.IndexZero() || !types.Same(ind.Expr.CType(nil), to) {
+			return nil
+		}
+		s = ind.Expr
+	default:
+		// realloc(NULL, sz) is the same as malloc
+		if !IsNil(a) {
+			return nil
+		}
+	}
+	var n Expr
+	if mul, ok := unwrapCasts(c.Args[1]).(*CBinaryExpr); ok && mul.Op == BinOpMult {
+		if tp := asSizeofT(mul.Right); tp != nil && types.Same(tp, to.Elem()) {
+			n = mul.Left
+		} else if tp = asSizeofT(mul.Left); tp != nil && types.Same(tp, to.Elem()) {
+			n = mul.Right
+		}
+	}
+	if n == nil && to.Elem().Sizeof() == 1 {
+		n = c.Args[1]
+	}
+	if n == nil {
+		return nil
+	}
+	gint := g.env.Go().Int()
+	if s == nil {
+		return &MakeExpr{e: g.env.Env, Elem: to.Elem(), Size: g.cCast(gint, n)}
+	}
+	fnc := types.NewIdentGo("realloc", "libc.ReallocSlice", g.env.FuncTT(to, to, gint))
+	return g.NewCCallExpr(FuncIdent{fnc}, []Expr{s, g.cCast(gint, n)})
+}
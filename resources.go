@@ -0,0 +1,228 @@
+package cxgo
+
+import (
+	"go/ast"
+	"strings"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// openCall checks if the expression opens a file with fopen, or a descriptor with open.
+func openCall(e Expr) (*CallExpr, bool) {
+	c, ok := unwrapCasts(e).(*CallExpr)
+	if !ok {
+		return nil, false
+	}
+	f, ok := c.Fun.(Ident)
+	if !ok {
+		return nil, false
+	}
+	switch f.Identifier().GoName {
+	case "stdio.FOpen":
+		return c, false
+	case "stdio.Open":
+		return c, true
+	}
+	return nil, false
+}
+
+// finalizers closes files and descriptors opened in functions, in case the C code leaks them,
+// if Config.Finalizers is set.
+//
+// Local variables that are only used with stdio functions are closed with defer after they are opened,
+// since closing a file twice is a no-op. Other files from fopen are closed by a finalizer; see stdio.Finalize.
+func (g *translator) finalizers(decl []CDecl) {
+	if !g.conf.Finalizers {
+		return
+	}
+	for _, d := range decl {
+		f, ok := d.(*CFuncDecl)
+		if !ok || f.Body == nil {
+			continue
+		}
+		locals := g.localResources(f)
+		deferred := make(map[*CallExpr]struct{})
+		f.Body.Stmts = g.deferCloses(f.Body.Stmts, locals, deferred)
+		Rewrite(f.Body, func(c *Cursor) bool {
+			call, ok := c.Node().(*CallExpr)
+			if !ok {
+				return true
+			}
+			if _, ok := deferred[call]; ok {
+				return true
+			}
+			if oc, fd := openCall(call); oc == nil || fd {
+				// descriptors cannot have finalizers
+				return true
+			}
+			if g.conf.TinyGo {
+				g.tinygoNote(g.declPos[d], "finalizers are not supported, file opened in "+declDesc(d)+" may leak")
+				return true
+			}
+			fin := types.NewIdentGo("finalize", "stdio.Finalize", g.env.FuncTT(call.CType(nil), call.CType(nil)))
+			c.Replace(g.NewCCallExpr(FuncIdent{fin}, []Expr{call}))
+			return false
+		}, nil)
+	}
+}
+
+// localResources returns local variables of the function that hold files or descriptors and do not escape it:
+// they are only assigned, compared, used as receivers of methods, or passed to stdio functions.
+// The value is true for descriptors.
+func (g *translator) localResources(f *CFuncDecl) map[*types.Ident]bool {
+	opened := make(map[*types.Ident]bool)
+	Walk(f.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *CVarDecl:
+			for i, id := range n.Names {
+				if i < len(n.Inits) && n.Inits[i] != nil {
+					if c, fd := openCall(n.Inits[i]); c != nil {
+						opened[id] = fd
+					}
+				}
+			}
+		case *CAssignStmt:
+			if id, ok := cUnwrap(n.Left).(IdentExpr); ok {
+				if c, fd := openCall(n.Right); c != nil {
+					opened[id.Ident] = fd
+				}
+			}
+		}
+		return true
+	})
+	if len(opened) == 0 {
+		return nil
+	}
+	total := make(map[*types.Ident]int)
+	safe := make(map[*types.Ident]int)
+	use := func(e Expr) {
+		if id, ok := unwrapCasts(e).(IdentExpr); ok {
+			safe[id.Ident]++
+		}
+	}
+	Walk(f.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case IdentExpr:
+			total[n.Ident]++
+		case *CVarDecl:
+			for _, id := range n.Names {
+				safe[id]++
+			}
+		case *CAssignStmt:
+			use(n.Left)
+		case *Comparison:
+			use(n.X)
+			use(n.Y)
+		case *PtrComparison:
+			use(n.X)
+			use(n.Y)
+		case *Not:
+			use(n.X)
+		case *CSelectExpr:
+			use(n.Expr)
+		case *CallExpr:
+			if fnc, ok := n.Fun.(Ident); ok && strings.HasPrefix(fnc.Identifier().GoName, "stdio.") {
+				for _, a := range n.Args {
+					use(a)
+				}
+			}
+		}
+		return true
+	})
+	for id := range opened {
+		if total[id] != safe[id] {
+			delete(opened, id)
+		}
+	}
+	return opened
+}
+
+// deferCloses inserts deferred closes after statements that open files for local variables.
+// Statements in loops are skipped, since defers would accumulate until the function returns.
+func (g *translator) deferCloses(stmts []CStmt, locals map[*types.Ident]bool, deferred map[*CallExpr]struct{}) []CStmt {
+	if len(locals) == 0 {
+		return stmts
+	}
+	var out []CStmt
+	for _, st := range stmts {
+		out = append(out, st)
+		var opened []*CAssignStmt
+		switch st := st.(type) {
+		case *CDeclStmt:
+			if d, ok := st.Decl.(*CVarDecl); ok {
+				for i, id := range d.Names {
+					if i < len(d.Inits) && d.Inits[i] != nil {
+						opened = append(opened, &CAssignStmt{Left: IdentExpr{id}, Right: d.Inits[i]})
+					}
+				}
+			}
+		case *CAssignStmt:
+			opened = append(opened, st)
+		case *BlockStmt:
+			st.Stmts = g.deferCloses(st.Stmts, locals, deferred)
+		case *CIfStmt:
+			// opened in the condition: if ((f = fopen(...)) == NULL)
+			Walk(st.Cond, func(n Node) bool {
+				if e, ok := n.(*CAssignExpr); ok {
+					opened = append(opened, e.Stmt)
+				}
+				return true
+			})
+			st.Then.Stmts = g.deferCloses(st.Then.Stmts, locals, deferred)
+			if st.Else != nil {
+				st.Else = g.toElseStmt(g.deferCloses([]CStmt{st.Else}, locals, deferred)...)
+			}
+		case *CSwitchStmt:
+			for _, c := range st.Cases {
+				c.Stmts = g.deferCloses(c.Stmts, locals, deferred)
+			}
+		}
+		for _, a := range opened {
+			id, ok := cUnwrap(a.Left).(IdentExpr)
+			if !ok || a.Op != "" {
+				continue
+			}
+			fd, ok := locals[id.Ident]
+			if !ok {
+				continue
+			}
+			c, _ := openCall(a.Right)
+			if c == nil {
+				continue
+			}
+			var x Expr = id
+			if fd {
+				byFD, ok := g.env.IdentByName("_cxgo_fileByFD")
+				if !ok {
+					continue
+				}
+				// unlike ByFD, it doesn't set errno if open failed
+				ft := byFD.CType(nil).(*types.FuncType)
+				lookup := types.NewIdentGo("lookupFD", "stdio.LookupFD", g.env.FuncTT(ft.Return(), g.env.Go().Uintptr()))
+				x = g.NewCCallExpr(FuncIdent{lookup}, []Expr{g.cCast(g.env.Go().Uintptr(), id)})
+			}
+			deferred[c] = struct{}{}
+			out = append(out, &CDeferCloseStmt{X: x})
+		}
+	}
+	return out
+}
+
+// CDeferCloseStmt closes a file when the function returns:
+//
+//	defer f.Close()
+type CDeferCloseStmt struct {
+	X Expr
+}
+
+func (s *CDeferCloseStmt) Visit(v Visitor) {
+	v(s.X)
+}
+
+func (s *CDeferCloseStmt) AsStmt() []GoStmt {
+	return []GoStmt{&ast.DeferStmt{Call: call(&ast.SelectorExpr{X: s.X.AsExpr(), Sel: ident("Close")})}}
+}
+
+func (s *CDeferCloseStmt) Uses() []types.Usage {
+	return types.UseRead(s.X)
+}
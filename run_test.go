@@ -37,6 +37,54 @@ int main() {
     printf("Result: %d\n", final ); 
     return 0;
 }
+`,
+	},
+	{
+		name: "integer promotions",
+		src: `
+#include <stdio.h>
+
+int main() {
+	unsigned char a = 200, b = 100, uc = 0x81, q = 250, d = 200;
+	signed char sc = -3;
+	unsigned short us = 0xFFF0, w = 1000;
+	short ss = -300;
+	unsigned u = 3;
+	int i = -1;
+	printf("%d %d %d\n", a + b, a - b - b, (unsigned char)(a + b));
+	printf("%d %d %d %d\n", ~uc, -uc, -us, ~ss);
+	printf("%d %d\n", -uc >> 1, (~uc) & 0xFFFF);
+	printf("%d %d\n", i < u, sc < u);
+	unsigned short n = ~us;
+	printf("%d\n", n);
+	q -= 300;
+	printf("%d\n", q);
+	d /= i;
+	printf("%d\n", d);
+	d = 200;
+	d %= i;
+	printf("%d\n", d);
+	d = 200;
+	d /= -1;
+	printf("%d\n", d);
+	w /= sc;
+	printf("%d\n", w);
+	sc = -100;
+	sc /= u;
+	printf("%d\n", sc);
+	u = 10;
+	i = 10;
+	i /= u * -1;
+	printf("%d\n", i);
+	ss = 1000;
+	ss /= 3.5;
+	printf("%d\n", ss);
+	ss *= 1.5;
+	printf("%d\n", ss);
+	ss += 0.5;
+	printf("%d\n", ss);
+	return 0;
+}
 `,
 	},
 }
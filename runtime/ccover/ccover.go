@@ -0,0 +1,121 @@
+// Package ccover collects coverage counters inserted into translated C code, keyed by lines of the original C files.
+package ccover
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultPath is a file written by Exit, if CXGO_COVER environment variable is not set.
+const DefaultPath = "cxgo-cover.info"
+
+var (
+	mu    sync.Mutex
+	files []*File
+)
+
+// File holds coverage counters for blocks of a single C file.
+type File struct {
+	Name   string
+	Lines  []int    // lines where each block starts
+	Counts []uint64 // number of executions of each block
+}
+
+// Register adds counters for blocks of a C file that start at given lines. It is called by the generated code.
+func Register(name string, lines ...int) *File {
+	f := &File{Name: name, Lines: lines, Counts: make([]uint64, len(lines))}
+	mu.Lock()
+	files = append(files, f)
+	mu.Unlock()
+	return f
+}
+
+// Hit increments the counter for the block with a given index.
+func (f *File) Hit(i int) {
+	atomic.AddUint64(&f.Counts[i], 1)
+}
+
+// lineCounts returns execution counts for each line of the file that has blocks.
+// Lines with multiple blocks report the maximal count.
+func (f *File) lineCounts() map[int]uint64 {
+	m := make(map[int]uint64, len(f.Lines))
+	for i, line := range f.Lines {
+		if n := atomic.LoadUint64(&f.Counts[i]); n >= m[line] {
+			m[line] = n
+		}
+	}
+	return m
+}
+
+// WriteLCOV writes all counters in the LCOV tracefile format, the same as produced by lcov or gcovr for C code.
+func WriteLCOV(w io.Writer) error {
+	mu.Lock()
+	list := append([]*File{}, files...)
+	mu.Unlock()
+	// the same file may be registered by multiple packages
+	counts := make(map[string]map[int]uint64)
+	var names []string
+	for _, f := range list {
+		m := counts[f.Name]
+		if m == nil {
+			m = make(map[int]uint64)
+			counts[f.Name] = m
+			names = append(names, f.Name)
+		}
+		for line, n := range f.lineCounts() {
+			m[line] += n
+		}
+	}
+	sort.Strings(names)
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		m := counts[name]
+		lines := make([]int, 0, len(m))
+		hit := 0
+		for line, n := range m {
+			lines = append(lines, line)
+			if n != 0 {
+				hit++
+			}
+		}
+		sort.Ints(lines)
+		fmt.Fprintf(bw, "SF:%s\n", name)
+		for _, line := range lines {
+			fmt.Fprintf(bw, "DA:%d,%d\n", line, m[line])
+		}
+		fmt.Fprintf(bw, "LH:%d\nLF:%d\nend_of_record\n", hit, len(lines))
+	}
+	return bw.Flush()
+}
+
+// Dump writes all counters to a file in the LCOV format; see WriteLCOV.
+func Dump(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = WriteLCOV(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Exit writes all counters and exits the program. Translated main functions call it instead of os.Exit.
+//
+// Counters are written to a file set by CXGO_COVER environment variable, or to DefaultPath.
+func Exit(code int) {
+	path := os.Getenv("CXGO_COVER")
+	if path == "" {
+		path = DefaultPath
+	}
+	if err := Dump(path); err != nil {
+		fmt.Fprintln(os.Stderr, "cannot write coverage:", err)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,33 @@
+package ccover
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLCOV(t *testing.T) {
+	f := Register("b.c", 2, 5, 5, 9)
+	f.Hit(0)
+	f.Hit(1)
+	f.Hit(2)
+	f.Hit(2)
+	Register("a.c", 1).Hit(0)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteLCOV(&buf))
+	require.Equal(t, `SF:a.c
+DA:1,1
+LH:1
+LF:1
+end_of_record
+SF:b.c
+DA:2,1
+DA:5,2
+DA:9,0
+LH:2
+LF:3
+end_of_record
+`, buf.String())
+}
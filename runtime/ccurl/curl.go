@@ -0,0 +1,378 @@
+// Package ccurl implements the easy API of libcurl (curl/curl.h) on top of net/http.
+//
+// Only the common HTTP options are supported, setting other options returns CURLE_UNKNOWN_OPTION.
+package ccurl
+
+import (
+	"net/http"
+	"reflect"
+	"time"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+const Version = "7.88.1"
+
+const (
+	CURLE_OK                       = 0
+	CURLE_UNSUPPORTED_PROTOCOL     = 1
+	CURLE_FAILED_INIT              = 2
+	CURLE_URL_MALFORMAT            = 3
+	CURLE_COULDNT_RESOLVE_HOST     = 6
+	CURLE_COULDNT_CONNECT          = 7
+	CURLE_HTTP_RETURNED_ERROR      = 22
+	CURLE_WRITE_ERROR              = 23
+	CURLE_READ_ERROR               = 26
+	CURLE_OUT_OF_MEMORY            = 27
+	CURLE_OPERATION_TIMEDOUT       = 28
+	CURLE_ABORTED_BY_CALLBACK      = 42
+	CURLE_BAD_FUNCTION_ARGUMENT    = 43
+	CURLE_TOO_MANY_REDIRECTS       = 47
+	CURLE_UNKNOWN_OPTION           = 48
+	CURLE_GOT_NOTHING              = 52
+	CURLE_RECV_ERROR               = 56
+	CURLE_PEER_FAILED_VERIFICATION = 60
+)
+
+const (
+	CURL_GLOBAL_NOTHING = 0
+	CURL_GLOBAL_SSL     = 1
+	CURL_GLOBAL_WIN32   = 2
+	CURL_GLOBAL_ALL     = CURL_GLOBAL_SSL | CURL_GLOBAL_WIN32
+	CURL_GLOBAL_DEFAULT = CURL_GLOBAL_ALL
+)
+
+// Option types are encoded in option values, the same way as in libcurl.
+const (
+	optLong     = 0
+	optPointer  = 10000
+	optFunction = 20000
+	optOffT     = 30000
+)
+
+const (
+	CURLOPT_WRITEDATA         = optPointer + 1
+	CURLOPT_URL               = optPointer + 2
+	CURLOPT_USERPWD           = optPointer + 5
+	CURLOPT_ERRORBUFFER       = optPointer + 10
+	CURLOPT_WRITEFUNCTION     = optFunction + 11
+	CURLOPT_TIMEOUT           = optLong + 13
+	CURLOPT_POSTFIELDS        = optPointer + 15
+	CURLOPT_REFERER           = optPointer + 16
+	CURLOPT_USERAGENT         = optPointer + 18
+	CURLOPT_COOKIE            = optPointer + 22
+	CURLOPT_HTTPHEADER        = optPointer + 23
+	CURLOPT_HEADERDATA        = optPointer + 29
+	CURLOPT_CUSTOMREQUEST     = optPointer + 36
+	CURLOPT_VERBOSE           = optLong + 41
+	CURLOPT_NOPROGRESS        = optLong + 43
+	CURLOPT_NOBODY            = optLong + 44
+	CURLOPT_FAILONERROR       = optLong + 45
+	CURLOPT_POST              = optLong + 47
+	CURLOPT_FOLLOWLOCATION    = optLong + 52
+	CURLOPT_POSTFIELDSIZE     = optLong + 60
+	CURLOPT_SSL_VERIFYPEER    = optLong + 64
+	CURLOPT_MAXREDIRS         = optLong + 68
+	CURLOPT_CONNECTTIMEOUT    = optLong + 78
+	CURLOPT_HEADERFUNCTION    = optFunction + 79
+	CURLOPT_HTTPGET           = optLong + 80
+	CURLOPT_SSL_VERIFYHOST    = optLong + 81
+	CURLOPT_ACCEPT_ENCODING   = optPointer + 102
+	CURLOPT_TIMEOUT_MS        = optLong + 155
+	CURLOPT_CONNECTTIMEOUT_MS = optLong + 156
+)
+
+// Info types are encoded in info values, the same way as in libcurl.
+const (
+	infoString = 0x100000
+	infoLong   = 0x200000
+	infoDouble = 0x300000
+)
+
+const (
+	CURLINFO_EFFECTIVE_URL  = infoString + 1
+	CURLINFO_RESPONSE_CODE  = infoLong + 2
+	CURLINFO_TOTAL_TIME     = infoDouble + 3
+	CURLINFO_SIZE_DOWNLOAD  = infoDouble + 8
+	CURLINFO_CONTENT_TYPE   = infoString + 18
+	CURLINFO_REDIRECT_COUNT = infoLong + 20
+)
+
+// Slist is a linked list of strings (curl_slist).
+type Slist struct {
+	Data *byte
+	Next *Slist
+}
+
+// SlistAppend implements curl_slist_append.
+func SlistAppend(l *Slist, s string) *Slist {
+	e := &Slist{Data: libc.CString(s)}
+	if l == nil {
+		return e
+	}
+	last := l
+	for last.Next != nil {
+		last = last.Next
+	}
+	last.Next = e
+	return l
+}
+
+// SlistFreeAll implements curl_slist_free_all.
+func SlistFreeAll(l *Slist) {}
+
+// GlobalInit implements curl_global_init.
+func GlobalInit(flags int32) int32 {
+	return CURLE_OK
+}
+
+// GlobalCleanup implements curl_global_cleanup.
+func GlobalCleanup() {}
+
+// CurlVersion implements curl_version.
+func CurlVersion() *byte {
+	return libc.CString("libcurl/" + Version + " (Go net/http)")
+}
+
+// CURL is an easy handle (CURL).
+type CURL struct {
+	url       string
+	method    string
+	post      *byte
+	postSize  int // -1 if the data is a C string
+	hasBody   bool
+	headers   []string
+	userpwd   string
+	userAgent string
+	referer   string
+	cookie    string
+	encoding  *string
+	follow    bool
+	maxRedirs int
+	failOnErr bool
+	noBody    bool
+	insecure  bool
+	verbose   bool
+	timeout   time.Duration
+	connect   time.Duration
+	errBuf    *byte
+	lastErr   error
+
+	write      reflect.Value
+	writeData  interface{}
+	header     reflect.Value
+	headerData interface{}
+
+	// info
+	effectiveURL string
+	code         int
+	contentType  string
+	redirects    int
+	total        time.Duration
+	downloaded   int
+}
+
+// EasyInit implements curl_easy_init.
+func EasyInit() *CURL {
+	return &CURL{maxRedirs: -1, postSize: -1}
+}
+
+// EasyCleanup implements curl_easy_cleanup.
+func EasyCleanup(c *CURL) {}
+
+// EasyReset implements curl_easy_reset.
+func EasyReset(c *CURL) {
+	*c = *EasyInit()
+}
+
+// EasyDupHandle implements curl_easy_duphandle.
+func EasyDupHandle(c *CURL) *CURL {
+	c2 := *c
+	c2.headers = append([]string{}, c.headers...)
+	return &c2
+}
+
+func argString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case *byte:
+		return libc.GoString(v), true
+	case unsafe.Pointer:
+		return libc.GoString((*byte)(v)), true
+	case nil:
+		return "", true
+	}
+	return "", false
+}
+
+func argLong(v interface{}) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint()), true
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func argPointer(v interface{}) unsafe.Pointer {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.UnsafePointer:
+		return rv.UnsafePointer()
+	}
+	return nil
+}
+
+// EasySetopt implements curl_easy_setopt.
+func EasySetopt(c *CURL, opt int32, args ...interface{}) int32 {
+	if c == nil {
+		return CURLE_BAD_FUNCTION_ARGUMENT
+	}
+	var arg interface{}
+	if len(args) != 0 {
+		arg = args[0]
+	}
+	switch {
+	case opt >= optOffT:
+		return CURLE_UNKNOWN_OPTION
+	case opt >= optFunction:
+		fnc := reflect.ValueOf(arg)
+		if arg != nil && (fnc.Kind() != reflect.Func || fnc.Type().NumIn() != 4 || fnc.Type().NumOut() != 1) {
+			return CURLE_BAD_FUNCTION_ARGUMENT
+		}
+		switch opt {
+		case CURLOPT_WRITEFUNCTION:
+			c.write = fnc
+		case CURLOPT_HEADERFUNCTION:
+			c.header = fnc
+		default:
+			return CURLE_UNKNOWN_OPTION
+		}
+		return CURLE_OK
+	case opt >= optPointer:
+		return c.setPointer(opt, arg)
+	}
+	v, ok := argLong(arg)
+	if !ok {
+		return CURLE_BAD_FUNCTION_ARGUMENT
+	}
+	switch opt {
+	case CURLOPT_TIMEOUT:
+		c.timeout = time.Duration(v) * time.Second
+	case CURLOPT_TIMEOUT_MS:
+		c.timeout = time.Duration(v) * time.Millisecond
+	case CURLOPT_CONNECTTIMEOUT:
+		c.connect = time.Duration(v) * time.Second
+	case CURLOPT_CONNECTTIMEOUT_MS:
+		c.connect = time.Duration(v) * time.Millisecond
+	case CURLOPT_VERBOSE:
+		c.verbose = v != 0
+	case CURLOPT_NOPROGRESS:
+	case CURLOPT_NOBODY:
+		c.noBody = v != 0
+	case CURLOPT_FAILONERROR:
+		c.failOnErr = v != 0
+	case CURLOPT_POST:
+		if v != 0 {
+			c.method = http.MethodPost
+			c.hasBody = true
+		}
+	case CURLOPT_HTTPGET:
+		if v != 0 {
+			c.method, c.hasBody, c.noBody = "", false, false
+		}
+	case CURLOPT_FOLLOWLOCATION:
+		c.follow = v != 0
+	case CURLOPT_MAXREDIRS:
+		c.maxRedirs = int(v)
+	case CURLOPT_POSTFIELDSIZE:
+		c.postSize = int(v)
+	case CURLOPT_SSL_VERIFYPEER:
+		c.insecure = v == 0
+	case CURLOPT_SSL_VERIFYHOST:
+		if v == 0 {
+			c.insecure = true
+		}
+	default:
+		return CURLE_UNKNOWN_OPTION
+	}
+	return CURLE_OK
+}
+
+func (c *CURL) setPointer(opt int32, arg interface{}) int32 {
+	switch opt {
+	case CURLOPT_WRITEDATA:
+		c.writeData = arg
+		return CURLE_OK
+	case CURLOPT_HEADERDATA:
+		c.headerData = arg
+		return CURLE_OK
+	case CURLOPT_ERRORBUFFER:
+		c.errBuf = (*byte)(argPointer(arg))
+		return CURLE_OK
+	case CURLOPT_HTTPHEADER:
+		var l *Slist
+		switch arg := arg.(type) {
+		case *Slist:
+			l = arg
+		case unsafe.Pointer:
+			l = (*Slist)(arg)
+		case nil:
+		default:
+			return CURLE_BAD_FUNCTION_ARGUMENT
+		}
+		c.headers = c.headers[:0]
+		for ; l != nil; l = l.Next {
+			c.headers = append(c.headers, libc.GoString(l.Data))
+		}
+		return CURLE_OK
+	case CURLOPT_POSTFIELDS:
+		// libcurl doesn't copy the data, and the size can be set after it
+		switch arg := arg.(type) {
+		case string:
+			c.post = libc.CString(arg)
+		case nil:
+			c.post = nil
+		default:
+			c.post = (*byte)(argPointer(arg))
+		}
+		c.method, c.hasBody = http.MethodPost, true
+		return CURLE_OK
+	}
+	s, ok := argString(arg)
+	if !ok {
+		return CURLE_BAD_FUNCTION_ARGUMENT
+	}
+	switch opt {
+	case CURLOPT_URL:
+		c.url = s
+	case CURLOPT_USERPWD:
+		c.userpwd = s
+	case CURLOPT_USERAGENT:
+		c.userAgent = s
+	case CURLOPT_REFERER:
+		c.referer = s
+	case CURLOPT_COOKIE:
+		c.cookie = s
+	case CURLOPT_CUSTOMREQUEST:
+		c.method = s
+	case CURLOPT_ACCEPT_ENCODING:
+		if arg == nil {
+			c.encoding = nil
+		} else {
+			c.encoding = &s
+		}
+	default:
+		return CURLE_UNKNOWN_OPTION
+	}
+	return CURLE_OK
+}
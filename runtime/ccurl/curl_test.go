@@ -0,0 +1,127 @@
+package ccurl
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func writeBuf(p *byte, size, n uint32, ud unsafe.Pointer) uint32 {
+	buf := (*bytes.Buffer)(ud)
+	buf.Write(unsafe.Slice(p, size*n))
+	return size * n
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("X-Agent", r.UserAgent())
+			io.WriteString(w, r.Method+" "+r.Header.Get("X-Test")+" "+string(body))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEasyGet(t *testing.T) {
+	srv := newServer(t)
+	c := EasyInit()
+	defer EasyCleanup(c)
+	var body, hdr bytes.Buffer
+	EasySetopt(c, CURLOPT_URL, srv.URL+"/redirect")
+	EasySetopt(c, CURLOPT_FOLLOWLOCATION, int32(1))
+	EasySetopt(c, CURLOPT_USERAGENT, "test/1.0")
+	EasySetopt(c, CURLOPT_WRITEFUNCTION, writeBuf)
+	EasySetopt(c, CURLOPT_WRITEDATA, unsafe.Pointer(&body))
+	EasySetopt(c, CURLOPT_HEADERFUNCTION, writeBuf)
+	EasySetopt(c, CURLOPT_HEADERDATA, unsafe.Pointer(&hdr))
+	if e := EasyPerform(c); e != CURLE_OK {
+		t.Fatalf("perform: %d", e)
+	}
+	if s := body.String(); s != "GET  " {
+		t.Fatalf("unexpected body: %q", s)
+	}
+	if !bytes.Contains(hdr.Bytes(), []byte("X-Agent: test/1.0\r\n")) || !bytes.HasSuffix(hdr.Bytes(), []byte("\r\n\r\n")) {
+		t.Fatalf("unexpected headers: %q", hdr.String())
+	}
+	var (
+		code int32
+		ct   *byte
+		n    int32
+	)
+	EasyGetinfo(c, CURLINFO_RESPONSE_CODE, &code)
+	EasyGetinfo(c, CURLINFO_CONTENT_TYPE, &ct)
+	EasyGetinfo(c, CURLINFO_REDIRECT_COUNT, &n)
+	if code != 200 || libc.GoString(ct) != "text/plain" || n != 1 {
+		t.Fatalf("unexpected info: %d, %q, %d", code, libc.GoString(ct), n)
+	}
+}
+
+func TestEasyPost(t *testing.T) {
+	srv := newServer(t)
+	c := EasyInit()
+	defer EasyCleanup(c)
+	var body bytes.Buffer
+	list := SlistAppend(nil, "X-Test: 1")
+	list = SlistAppend(list, "Accept:")
+	defer SlistFreeAll(list)
+	EasySetopt(c, CURLOPT_URL, srv.URL+"/echo")
+	EasySetopt(c, CURLOPT_HTTPHEADER, list)
+	EasySetopt(c, CURLOPT_POSTFIELDS, libc.CString("a=1&b=2"))
+	EasySetopt(c, CURLOPT_POSTFIELDSIZE, 3)
+	EasySetopt(c, CURLOPT_WRITEFUNCTION, writeBuf)
+	EasySetopt(c, CURLOPT_WRITEDATA, unsafe.Pointer(&body))
+	if e := EasyPerform(c); e != CURLE_OK {
+		t.Fatalf("perform: %d", e)
+	}
+	if s := body.String(); s != "POST 1 a=1" {
+		t.Fatalf("unexpected body: %q", s)
+	}
+}
+
+func TestEasyErrors(t *testing.T) {
+	srv := newServer(t)
+	c := EasyInit()
+	defer EasyCleanup(c)
+	var errBuf [CURL_ERROR_SIZE]byte
+	EasySetopt(c, CURLOPT_ERRORBUFFER, &errBuf[0])
+	EasySetopt(c, CURLOPT_URL, "ftp://example.com")
+	if e := EasyPerform(c); e != CURLE_UNSUPPORTED_PROTOCOL {
+		t.Fatalf("unexpected code: %d", e)
+	}
+	if libc.GoString(&errBuf[0]) == "" {
+		t.Fatal("expected an error message")
+	}
+	EasySetopt(c, CURLOPT_URL, srv.URL+"/missing")
+	EasySetopt(c, CURLOPT_FAILONERROR, 1)
+	EasySetopt(c, CURLOPT_WRITEFUNCTION, writeBuf)
+	if e := EasyPerform(c); e != CURLE_HTTP_RETURNED_ERROR {
+		t.Fatalf("unexpected code: %d", e)
+	}
+	if e := EasySetopt(c, 99, 1); e != CURLE_UNKNOWN_OPTION {
+		t.Fatalf("unexpected code: %d", e)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	s := EasyEscape(nil, libc.CString("a b/c~"), 0)
+	if v := libc.GoString(s); v != "a%20b%2Fc~" {
+		t.Fatalf("unexpected escape: %q", v)
+	}
+	var n int32
+	if v := libc.GoString(EasyUnescape(nil, s, 0, &n)); v != "a b/c~" || n != 6 {
+		t.Fatalf("unexpected unescape: %q", v)
+	}
+}
@@ -0,0 +1,455 @@
+package ccurl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+	"github.com/gotranspile/cxgo/runtime/stdio"
+)
+
+const (
+	CURL_ERROR_SIZE     = 256
+	CURL_MAX_WRITE_SIZE = 16384
+)
+
+var errTooManyRedirects = errors.New("maximum redirects followed")
+
+type curlError struct {
+	code int32
+	err  error
+}
+
+func (e *curlError) Error() string {
+	return e.err.Error()
+}
+
+// EasyPerform implements curl_easy_perform.
+func EasyPerform(c *CURL) int32 {
+	if c == nil {
+		return CURLE_BAD_FUNCTION_ARGUMENT
+	}
+	start := time.Now()
+	code := c.perform()
+	c.total = time.Since(start)
+	if code != CURLE_OK && c.errBuf != nil {
+		msg := libc.GoString(EasyStrerror(code))
+		if c.lastErr != nil {
+			msg = c.lastErr.Error()
+		}
+		if len(msg) >= CURL_ERROR_SIZE {
+			msg = msg[:CURL_ERROR_SIZE-1]
+		}
+		buf := unsafe.Slice(c.errBuf, CURL_ERROR_SIZE)
+		buf[copy(buf, msg)] = 0
+	}
+	return code
+}
+
+func (c *CURL) fail(code int32, err error) int32 {
+	c.lastErr = err
+	return code
+}
+
+func (c *CURL) newRequest() (*http.Request, int32) {
+	addr := c.url
+	if addr == "" {
+		return nil, c.fail(CURLE_URL_MALFORMAT, errors.New("no URL set"))
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, c.fail(CURLE_URL_MALFORMAT, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, c.fail(CURLE_UNSUPPORTED_PROTOCOL, fmt.Errorf("protocol %q not supported", u.Scheme))
+	}
+	method := c.method
+	if c.noBody {
+		method = http.MethodHead
+	} else if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if c.hasBody {
+		n := c.postSize
+		if n < 0 && c.post != nil {
+			n = libc.StrLen(c.post)
+		}
+		var data []byte
+		if n > 0 {
+			data = append(data, unsafe.Slice(c.post, n)...)
+		}
+		body = strings.NewReader(string(data))
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, c.fail(CURLE_URL_MALFORMAT, err)
+	}
+	req.Header.Set("Accept", "*/*")
+	if c.hasBody {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.referer != "" {
+		req.Header.Set("Referer", c.referer)
+	}
+	if c.cookie != "" {
+		req.Header.Set("Cookie", c.cookie)
+	}
+	if c.userpwd != "" {
+		user, pass, _ := strings.Cut(c.userpwd, ":")
+		req.SetBasicAuth(user, pass)
+	}
+	for _, h := range c.headers {
+		if name, val, ok := strings.Cut(h, ":"); ok {
+			val = strings.TrimSpace(val)
+			if val == "" {
+				// "Name:" removes the header, like in libcurl
+				req.Header.Del(name)
+			} else if strings.EqualFold(name, "Host") {
+				req.Host = val
+			} else {
+				req.Header.Set(name, val)
+			}
+		} else if name, ok := strings.CutSuffix(h, ";"); ok {
+			req.Header.Set(name, "")
+		}
+	}
+	return req, CURLE_OK
+}
+
+func (c *CURL) client() *http.Client {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if c.insecure {
+		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if c.connect > 0 {
+		d := &net.Dialer{Timeout: c.connect}
+		tr.DialContext = d.DialContext
+		tr.TLSHandshakeTimeout = c.connect
+	}
+	// libcurl doesn't decompress the response, unless the encoding is set
+	tr.DisableCompression = c.encoding == nil
+	return &http.Client{
+		Transport: tr,
+		Timeout:   c.timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !c.follow {
+				return http.ErrUseLastResponse
+			}
+			if c.maxRedirs >= 0 && len(via) > c.maxRedirs {
+				return &curlError{code: CURLE_TOO_MANY_REDIRECTS, err: errTooManyRedirects}
+			}
+			c.redirects = len(via)
+			return nil
+		},
+	}
+}
+
+func errorCode(err error) int32 {
+	var (
+		cerr *curlError
+		nerr net.Error
+		derr *net.DNSError
+		uerr x509.UnknownAuthorityError
+		herr x509.HostnameError
+		verr *tls.CertificateVerificationError
+	)
+	switch {
+	case errors.As(err, &cerr):
+		return cerr.code
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &nerr) && nerr.Timeout():
+		return CURLE_OPERATION_TIMEDOUT
+	case errors.As(err, &derr):
+		return CURLE_COULDNT_RESOLVE_HOST
+	case errors.As(err, &uerr), errors.As(err, &herr), errors.As(err, &verr):
+		return CURLE_PEER_FAILED_VERIFICATION
+	}
+	return CURLE_COULDNT_CONNECT
+}
+
+func (c *CURL) perform() int32 {
+	c.effectiveURL, c.code, c.contentType, c.redirects, c.downloaded, c.lastErr = "", 0, "", 0, 0, nil
+	req, code := c.newRequest()
+	if code != CURLE_OK {
+		return code
+	}
+	c.effectiveURL = req.URL.String()
+	if c.verbose {
+		fmt.Fprintf(os.Stderr, "> %s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
+	}
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return c.fail(errorCode(err), err)
+	}
+	defer resp.Body.Close()
+	c.effectiveURL = resp.Request.URL.String()
+	c.code = resp.StatusCode
+	c.contentType = resp.Header.Get("Content-Type")
+	if c.verbose {
+		fmt.Fprintf(os.Stderr, "< %s %s\n", resp.Proto, resp.Status)
+	}
+	if c.failOnErr && resp.StatusCode >= 400 {
+		return c.fail(CURLE_HTTP_RETURNED_ERROR, fmt.Errorf("the requested URL returned error: %d", resp.StatusCode))
+	}
+	if c.header.IsValid() || c.headerData != nil {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s %s\r\n", resp.Proto, resp.Status)
+		for name, vals := range resp.Header {
+			for _, v := range vals {
+				fmt.Fprintf(&sb, "%s: %s\r\n", name, v)
+			}
+		}
+		lines := strings.SplitAfter(sb.String()+"\r\n", "\r\n")
+		for _, line := range lines[:len(lines)-1] {
+			if !c.deliver(c.header, c.headerData, []byte(line)) {
+				return c.fail(CURLE_WRITE_ERROR, errors.New("failed writing header"))
+			}
+		}
+	}
+	buf := make([]byte, CURL_MAX_WRITE_SIZE)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			c.downloaded += n
+			data := c.writeData
+			if !c.write.IsValid() && data == nil {
+				data = stdio.Stdout()
+			}
+			if !c.deliver(c.write, data, buf[:n]) {
+				return c.fail(CURLE_WRITE_ERROR, errors.New("failed writing received data"))
+			}
+		}
+		if err == io.EOF {
+			return CURLE_OK
+		} else if err != nil {
+			return c.fail(CURLE_RECV_ERROR, err)
+		}
+	}
+}
+
+// deliver passes the data to a write callback, or writes it to a FILE, if the callback is not set.
+func (c *CURL) deliver(fnc reflect.Value, data interface{}, p []byte) bool {
+	if !fnc.IsValid() || fnc.IsNil() {
+		var f *stdio.File
+		switch data := data.(type) {
+		case *stdio.File:
+			f = data
+		case unsafe.Pointer:
+			f = (*stdio.File)(data)
+		}
+		if f == nil {
+			return true
+		}
+		return f.Write(&p[0], len(p)) == int32(len(p))
+	}
+	t := fnc.Type()
+	args := []reflect.Value{
+		pointerValue(t.In(0), unsafe.Pointer(&p[0])),
+		reflect.ValueOf(1).Convert(t.In(1)),
+		reflect.ValueOf(len(p)).Convert(t.In(2)),
+		dataValue(t.In(3), data),
+	}
+	n, _ := argLong(fnc.Call(args)[0].Interface())
+	return n == int64(len(p))
+}
+
+func pointerValue(t reflect.Type, p unsafe.Pointer) reflect.Value {
+	if t.Kind() == reflect.Ptr {
+		return reflect.NewAt(t.Elem(), p)
+	}
+	return reflect.ValueOf(p).Convert(t)
+}
+
+func dataValue(t reflect.Type, data interface{}) reflect.Value {
+	if data == nil {
+		return reflect.Zero(t)
+	}
+	v := reflect.ValueOf(data)
+	if v.Type().AssignableTo(t) {
+		return v
+	}
+	if p := argPointer(data); p != nil {
+		return pointerValue(t, p)
+	}
+	return reflect.Zero(t)
+}
+
+// EasyGetinfo implements curl_easy_getinfo.
+func EasyGetinfo(c *CURL, info int32, args ...interface{}) int32 {
+	if c == nil || len(args) == 0 {
+		return CURLE_BAD_FUNCTION_ARGUMENT
+	}
+	p := reflect.ValueOf(args[0])
+	if p.Kind() != reflect.Ptr || p.IsNil() {
+		return CURLE_BAD_FUNCTION_ARGUMENT
+	}
+	v := p.Elem()
+	switch info & 0xf00000 {
+	case infoString:
+		var s string
+		switch info {
+		case CURLINFO_EFFECTIVE_URL:
+			s = c.effectiveURL
+		case CURLINFO_CONTENT_TYPE:
+			s = c.contentType
+		default:
+			return CURLE_UNKNOWN_OPTION
+		}
+		var cs *byte
+		if s != "" {
+			cs = libc.CString(s)
+		}
+		if v.Kind() == reflect.UnsafePointer {
+			v.SetPointer(unsafe.Pointer(cs))
+		} else {
+			v.Set(reflect.ValueOf(cs))
+		}
+	case infoLong:
+		var n int64
+		switch info {
+		case CURLINFO_RESPONSE_CODE:
+			n = int64(c.code)
+		case CURLINFO_REDIRECT_COUNT:
+			n = int64(c.redirects)
+		default:
+			return CURLE_UNKNOWN_OPTION
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(uint64(n))
+		default:
+			return CURLE_BAD_FUNCTION_ARGUMENT
+		}
+	case infoDouble:
+		var f float64
+		switch info {
+		case CURLINFO_TOTAL_TIME:
+			f = c.total.Seconds()
+		case CURLINFO_SIZE_DOWNLOAD:
+			f = float64(c.downloaded)
+		default:
+			return CURLE_UNKNOWN_OPTION
+		}
+		if v.Kind() != reflect.Float64 && v.Kind() != reflect.Float32 {
+			return CURLE_BAD_FUNCTION_ARGUMENT
+		}
+		v.SetFloat(f)
+	default:
+		return CURLE_UNKNOWN_OPTION
+	}
+	return CURLE_OK
+}
+
+var errorStrings = map[int32]string{
+	CURLE_OK:                       "No error",
+	CURLE_UNSUPPORTED_PROTOCOL:     "Unsupported protocol",
+	CURLE_FAILED_INIT:              "Failed initialization",
+	CURLE_URL_MALFORMAT:            "URL using bad/illegal format or missing URL",
+	CURLE_COULDNT_RESOLVE_HOST:     "Couldn't resolve host name",
+	CURLE_COULDNT_CONNECT:          "Couldn't connect to server",
+	CURLE_HTTP_RETURNED_ERROR:      "HTTP response code said error",
+	CURLE_WRITE_ERROR:              "Failed writing received data to disk/application",
+	CURLE_READ_ERROR:               "Failed to open/read local data from file/application",
+	CURLE_OUT_OF_MEMORY:            "Out of memory",
+	CURLE_OPERATION_TIMEDOUT:       "Timeout was reached",
+	CURLE_ABORTED_BY_CALLBACK:      "Operation was aborted by an application callback",
+	CURLE_BAD_FUNCTION_ARGUMENT:    "A libcurl function was given a bad argument",
+	CURLE_TOO_MANY_REDIRECTS:       "Number of redirects hit maximum amount",
+	CURLE_UNKNOWN_OPTION:           "An unknown option was passed in to libcurl",
+	CURLE_GOT_NOTHING:              "Server returned nothing (no headers, no data)",
+	CURLE_RECV_ERROR:               "Failure when receiving data from the peer",
+	CURLE_PEER_FAILED_VERIFICATION: "SSL peer certificate or SSH remote key was not OK",
+}
+
+// EasyStrerror implements curl_easy_strerror.
+func EasyStrerror(code int32) *byte {
+	s, ok := errorStrings[code]
+	if !ok {
+		s = "Unknown error"
+	}
+	return libc.CString(s)
+}
+
+// EasyEscape implements curl_easy_escape. If n is zero, the length of the string is used.
+func EasyEscape(c *CURL, s *byte, n int32) *byte {
+	if s == nil {
+		return nil
+	}
+	if n <= 0 {
+		n = int32(libc.StrLen(s))
+	}
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for _, b := range unsafe.Slice(s, n) {
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9', b == '-', b == '.', b == '_', b == '~':
+			sb.WriteByte(b)
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(hex[b>>4])
+			sb.WriteByte(hex[b&0xf])
+		}
+	}
+	return libc.CString(sb.String())
+}
+
+// EasyUnescape implements curl_easy_unescape. If n is zero, the length of the string is used.
+func EasyUnescape(c *CURL, s *byte, n int32, outLen *int32) *byte {
+	if s == nil {
+		return nil
+	}
+	if n <= 0 {
+		n = int32(libc.StrLen(s))
+	}
+	in := unsafe.Slice(s, n)
+	out := make([]byte, 0, len(in))
+	for i := 0; i < len(in); i++ {
+		b := in[i]
+		if b == '%' && i+2 < len(in) && isHex(in[i+1]) && isHex(in[i+2]) {
+			b = unhex(in[i+1])<<4 | unhex(in[i+2])
+			i += 2
+		}
+		out = append(out, b)
+	}
+	if outLen != nil {
+		*outLen = int32(len(out))
+	}
+	return libc.CBytes(out)
+}
+
+func isHex(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+func unhex(b byte) byte {
+	switch {
+	case b >= 'a':
+		return b - 'a' + 10
+	case b >= 'A':
+		return b - 'A' + 10
+	}
+	return b - '0'
+}
+
+// Free implements curl_free.
+func Free(p unsafe.Pointer) {}
@@ -0,0 +1,729 @@
+package cjson
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+const Version = "1.7.15"
+
+const (
+	CJSON_VERSION_MAJOR = 1
+	CJSON_VERSION_MINOR = 7
+	CJSON_VERSION_PATCH = 15
+)
+
+// Item types of cJSON.
+const (
+	CJSON_Invalid       = 0
+	CJSON_False         = 1 << 0
+	CJSON_True          = 1 << 1
+	CJSON_NULL          = 1 << 2
+	CJSON_Number        = 1 << 3
+	CJSON_String        = 1 << 4
+	CJSON_Array         = 1 << 5
+	CJSON_Object        = 1 << 6
+	CJSON_Raw           = 1 << 7
+	CJSON_IsReference   = 256
+	CJSON_StringIsConst = 512
+	CJSON_NESTING_LIMIT = 1000
+	cjsonTypeMask       = 0xff
+)
+
+// JSON is a cJSON item (cJSON). The layout matches the C struct, since the code usually accesses fields directly.
+type JSON struct {
+	Next        *JSON
+	Prev        *JSON
+	Child       *JSON
+	Type        int32
+	ValueString *byte
+	ValueInt    int32
+	ValueDouble float64
+	String      *byte
+}
+
+var errorPtr *byte
+
+// CJSONVersion implements cJSON_Version.
+func CJSONVersion() *byte {
+	return libc.CString(Version)
+}
+
+// Parse implements cJSON_Parse.
+func Parse(s *byte) *JSON {
+	if s == nil {
+		return nil
+	}
+	return ParseWithLength(s, libc.StrLen(s))
+}
+
+// ParseWithLength implements cJSON_ParseWithLength.
+func ParseWithLength(s *byte, n int) *JSON {
+	errorPtr = nil
+	if s == nil || n <= 0 {
+		return nil
+	}
+	data := unsafe.Slice(s, n)
+	v, off, err := parse(data)
+	if err != nil {
+		if off >= n {
+			off = n - 1
+		}
+		errorPtr = &data[off]
+		return nil
+	}
+	return fromNode(v, nil)
+}
+
+// GetErrorPtr implements cJSON_GetErrorPtr.
+func GetErrorPtr() *byte {
+	return errorPtr
+}
+
+func fromNode(n *node, key *string) *JSON {
+	it := &JSON{}
+	if key != nil {
+		it.String = libc.CString(*key)
+	}
+	switch n.kind {
+	case 'n':
+		it.Type = CJSON_NULL
+	case 't':
+		it.Type = CJSON_True
+	case 'f':
+		it.Type = CJSON_False
+	case 's':
+		it.Type = CJSON_String
+		it.ValueString = libc.CString(n.str)
+	case '0':
+		it.setNumber(parseFloat(n.num))
+	case '{':
+		it.Type = CJSON_Object
+		for i, v := range n.vals {
+			it.appendChild(fromNode(v, &n.keys[i]))
+		}
+	case '[':
+		it.Type = CJSON_Array
+		for _, v := range n.vals {
+			it.appendChild(fromNode(v, nil))
+		}
+	}
+	return it
+}
+
+func (it *JSON) setNumber(v float64) {
+	it.Type = CJSON_Number
+	it.ValueDouble = v
+	it.ValueInt = saturate(v)
+}
+
+func (it *JSON) appendChild(c *JSON) {
+	if it.Child == nil {
+		it.Child = c
+		c.Prev, c.Next = c, nil
+		return
+	}
+	// like in cJSON, prev of the first child points to the last one
+	last := it.Child.Prev
+	last.Next = c
+	c.Prev, c.Next = last, nil
+	it.Child.Prev = c
+}
+
+// detach removes the child from the item.
+func (it *JSON) detach(c *JSON) *JSON {
+	if it == nil || c == nil {
+		return nil
+	}
+	if c != it.Child {
+		c.Prev.Next = c.Next
+	}
+	if c.Next != nil {
+		c.Next.Prev = c.Prev
+	}
+	if c == it.Child {
+		it.Child = c.Next
+	} else if c.Next == nil {
+		it.Child.Prev = c.Prev
+	}
+	c.Prev, c.Next = nil, nil
+	return c
+}
+
+// replace replaces the child with a new item.
+func (it *JSON) replace(c, n *JSON) bool {
+	if it == nil || c == nil || n == nil {
+		return false
+	}
+	if c == n {
+		return true
+	}
+	n.Next, n.Prev = c.Next, c.Prev
+	if n.Next != nil {
+		n.Next.Prev = n
+	}
+	if c == it.Child {
+		if it.Child.Prev == it.Child {
+			n.Prev = n
+		}
+		it.Child = n
+	} else {
+		if n.Prev != nil {
+			n.Prev.Next = n
+		}
+		if n.Next == nil {
+			it.Child.Prev = n
+		}
+	}
+	c.Next, c.Prev = nil, nil
+	return true
+}
+
+// Delete implements cJSON_Delete. The memory is managed by Go, thus it does nothing.
+func Delete(it *JSON) {}
+
+// Free implements cJSON_free.
+func Free(p unsafe.Pointer) {}
+
+// Print implements cJSON_Print.
+func Print(it *JSON) *byte {
+	if it == nil {
+		return nil
+	}
+	var sb strings.Builder
+	it.print(&sb, true, 0)
+	return libc.CString(sb.String())
+}
+
+// PrintUnformatted implements cJSON_PrintUnformatted.
+func PrintUnformatted(it *JSON) *byte {
+	if it == nil {
+		return nil
+	}
+	var sb strings.Builder
+	it.print(&sb, false, 0)
+	return libc.CString(sb.String())
+}
+
+func formatNumber(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "null"
+	}
+	if v == float64(int64(v)) && math.Abs(v) < 1<<53 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	s := strconv.FormatFloat(v, 'g', 15, 64)
+	if f, _ := strconv.ParseFloat(s, 64); f != v {
+		s = strconv.FormatFloat(v, 'g', 17, 64)
+	}
+	return s
+}
+
+func (it *JSON) print(sb *strings.Builder, format bool, depth int) {
+	switch it.Type & cjsonTypeMask {
+	case CJSON_NULL:
+		sb.WriteString("null")
+	case CJSON_False:
+		sb.WriteString("false")
+	case CJSON_True:
+		sb.WriteString("true")
+	case CJSON_Number:
+		sb.WriteString(formatNumber(it.ValueDouble))
+	case CJSON_Raw:
+		sb.WriteString(libc.GoString(it.ValueString))
+	case CJSON_String:
+		writeString(sb, libc.GoString(it.ValueString), false)
+	case CJSON_Array:
+		sb.WriteByte('[')
+		for c := it.Child; c != nil; c = c.Next {
+			c.print(sb, format, depth+1)
+			if c.Next != nil {
+				sb.WriteByte(',')
+				if format {
+					sb.WriteByte(' ')
+				}
+			}
+		}
+		sb.WriteByte(']')
+	case CJSON_Object:
+		sb.WriteByte('{')
+		if format {
+			sb.WriteByte('\n')
+		}
+		for c := it.Child; c != nil; c = c.Next {
+			if format {
+				sb.WriteString(strings.Repeat("\t", depth+1))
+			}
+			writeString(sb, libc.GoString(c.String), false)
+			sb.WriteByte(':')
+			if format {
+				sb.WriteByte('\t')
+			}
+			c.print(sb, format, depth+1)
+			if c.Next != nil {
+				sb.WriteByte(',')
+			}
+			if format {
+				sb.WriteByte('\n')
+			}
+		}
+		if format {
+			sb.WriteString(strings.Repeat("\t", depth))
+		}
+		sb.WriteByte('}')
+	}
+}
+
+// Minify implements cJSON_Minify.
+func Minify(s *byte) {
+	if s == nil {
+		return
+	}
+	data := unsafe.Slice(s, libc.StrLen(s)+1)
+	j := 0
+	for i := 0; i < len(data)-1; i++ {
+		c := data[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			continue
+		case c == '/' && i+1 < len(data)-1 && data[i+1] == '/':
+			for i < len(data)-1 && data[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(data)-1 && data[i+1] == '*':
+			i += 2
+			for i < len(data)-1 && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+			continue
+		case c == '"':
+			data[j] = c
+			j++
+			for i++; i < len(data)-1 && data[i] != '"'; i++ {
+				if data[i] == '\\' && i+1 < len(data)-1 {
+					data[j] = data[i]
+					j++
+					i++
+				}
+				data[j] = data[i]
+				j++
+			}
+			if i < len(data)-1 {
+				data[j] = '"'
+				j++
+			}
+			continue
+		}
+		data[j] = c
+		j++
+	}
+	data[j] = 0
+}
+
+// GetArraySize implements cJSON_GetArraySize.
+func GetArraySize(it *JSON) int32 {
+	if it == nil {
+		return 0
+	}
+	n := int32(0)
+	for c := it.Child; c != nil; c = c.Next {
+		n++
+	}
+	return n
+}
+
+// GetArrayItem implements cJSON_GetArrayItem.
+func GetArrayItem(it *JSON, i int32) *JSON {
+	if it == nil || i < 0 {
+		return nil
+	}
+	c := it.Child
+	for ; c != nil && i > 0; i-- {
+		c = c.Next
+	}
+	return c
+}
+
+func (it *JSON) find(key string, caseSensitive bool) *JSON {
+	if it == nil {
+		return nil
+	}
+	for c := it.Child; c != nil; c = c.Next {
+		if c.String == nil {
+			continue
+		}
+		name := libc.GoString(c.String)
+		if name == key || (!caseSensitive && strings.EqualFold(name, key)) {
+			return c
+		}
+	}
+	return nil
+}
+
+// GetObjectItem implements cJSON_GetObjectItem. Like in cJSON, the key is case-insensitive.
+func GetObjectItem(it *JSON, key string) *JSON {
+	return it.find(key, false)
+}
+
+// GetObjectItemCaseSensitive implements cJSON_GetObjectItemCaseSensitive.
+func GetObjectItemCaseSensitive(it *JSON, key string) *JSON {
+	return it.find(key, true)
+}
+
+// HasObjectItem implements cJSON_HasObjectItem.
+func HasObjectItem(it *JSON, key string) bool {
+	return it.find(key, false) != nil
+}
+
+// GetStringValue implements cJSON_GetStringValue.
+func GetStringValue(it *JSON) *byte {
+	if !IsString(it) {
+		return nil
+	}
+	return it.ValueString
+}
+
+// GetNumberValue implements cJSON_GetNumberValue.
+func GetNumberValue(it *JSON) float64 {
+	if !IsNumber(it) {
+		return math.NaN()
+	}
+	return it.ValueDouble
+}
+
+func (it *JSON) is(typ int32) bool {
+	return it != nil && it.Type&cjsonTypeMask == typ
+}
+
+// IsInvalid implements cJSON_IsInvalid.
+func IsInvalid(it *JSON) bool { return it.is(CJSON_Invalid) }
+
+// IsFalse implements cJSON_IsFalse.
+func IsFalse(it *JSON) bool { return it.is(CJSON_False) }
+
+// IsTrue implements cJSON_IsTrue.
+func IsTrue(it *JSON) bool { return it.is(CJSON_True) }
+
+// IsBool implements cJSON_IsBool.
+func IsBool(it *JSON) bool { return it.is(CJSON_True) || it.is(CJSON_False) }
+
+// IsNull implements cJSON_IsNull.
+func IsNull(it *JSON) bool { return it.is(CJSON_NULL) }
+
+// IsNumber implements cJSON_IsNumber.
+func IsNumber(it *JSON) bool { return it.is(CJSON_Number) }
+
+// IsString implements cJSON_IsString.
+func IsString(it *JSON) bool { return it.is(CJSON_String) }
+
+// IsArray implements cJSON_IsArray.
+func IsArray(it *JSON) bool { return it.is(CJSON_Array) }
+
+// IsObject implements cJSON_IsObject.
+func IsObject(it *JSON) bool { return it.is(CJSON_Object) }
+
+// IsRaw implements cJSON_IsRaw.
+func IsRaw(it *JSON) bool { return it.is(CJSON_Raw) }
+
+// CreateNull implements cJSON_CreateNull.
+func CreateNull() *JSON { return &JSON{Type: CJSON_NULL} }
+
+// CreateTrue implements cJSON_CreateTrue.
+func CreateTrue() *JSON { return &JSON{Type: CJSON_True} }
+
+// CreateFalse implements cJSON_CreateFalse.
+func CreateFalse() *JSON { return &JSON{Type: CJSON_False} }
+
+// CreateBool implements cJSON_CreateBool.
+func CreateBool(v bool) *JSON {
+	if v {
+		return CreateTrue()
+	}
+	return CreateFalse()
+}
+
+// CreateNumber implements cJSON_CreateNumber.
+func CreateNumber(v float64) *JSON {
+	it := &JSON{}
+	it.setNumber(v)
+	return it
+}
+
+// CreateString implements cJSON_CreateString.
+func CreateString(s string) *JSON {
+	return &JSON{Type: CJSON_String, ValueString: libc.CString(s)}
+}
+
+// CreateRaw implements cJSON_CreateRaw.
+func CreateRaw(s string) *JSON {
+	return &JSON{Type: CJSON_Raw, ValueString: libc.CString(s)}
+}
+
+// CreateArray implements cJSON_CreateArray.
+func CreateArray() *JSON { return &JSON{Type: CJSON_Array} }
+
+// CreateObject implements cJSON_CreateObject.
+func CreateObject() *JSON { return &JSON{Type: CJSON_Object} }
+
+// CreateIntArray implements cJSON_CreateIntArray.
+func CreateIntArray(p *int32, n int32) *JSON {
+	if n < 0 || (p == nil && n != 0) {
+		return nil
+	}
+	arr := CreateArray()
+	if n != 0 {
+		for _, v := range unsafe.Slice(p, n) {
+			arr.appendChild(CreateNumber(float64(v)))
+		}
+	}
+	return arr
+}
+
+// CreateDoubleArray implements cJSON_CreateDoubleArray.
+func CreateDoubleArray(p *float64, n int32) *JSON {
+	if n < 0 || (p == nil && n != 0) {
+		return nil
+	}
+	arr := CreateArray()
+	if n != 0 {
+		for _, v := range unsafe.Slice(p, n) {
+			arr.appendChild(CreateNumber(v))
+		}
+	}
+	return arr
+}
+
+// CreateStringArray implements cJSON_CreateStringArray.
+func CreateStringArray(p **byte, n int32) *JSON {
+	if n < 0 || (p == nil && n != 0) {
+		return nil
+	}
+	arr := CreateArray()
+	if n != 0 {
+		for _, v := range unsafe.Slice(p, n) {
+			arr.appendChild(CreateString(libc.GoString(v)))
+		}
+	}
+	return arr
+}
+
+// Duplicate implements cJSON_Duplicate.
+func Duplicate(it *JSON, recurse bool) *JSON {
+	if it == nil {
+		return nil
+	}
+	d := &JSON{
+		Type:        it.Type &^ CJSON_IsReference,
+		ValueInt:    it.ValueInt,
+		ValueDouble: it.ValueDouble,
+	}
+	if it.ValueString != nil {
+		d.ValueString = libc.CString(libc.GoString(it.ValueString))
+	}
+	if it.String != nil {
+		d.String = libc.CString(libc.GoString(it.String))
+		d.Type &^= CJSON_StringIsConst
+	}
+	if recurse {
+		for c := it.Child; c != nil; c = c.Next {
+			d.appendChild(Duplicate(c, true))
+		}
+	}
+	return d
+}
+
+// AddItemToArray implements cJSON_AddItemToArray.
+func AddItemToArray(arr, it *JSON) bool {
+	if arr == nil || it == nil || arr == it {
+		return false
+	}
+	arr.appendChild(it)
+	return true
+}
+
+// AddItemToObject implements cJSON_AddItemToObject.
+func AddItemToObject(obj *JSON, key string, it *JSON) bool {
+	if obj == nil || it == nil || obj == it {
+		return false
+	}
+	it.String = libc.CString(key)
+	it.Type &^= CJSON_StringIsConst
+	obj.appendChild(it)
+	return true
+}
+
+// AddItemToObjectCS implements cJSON_AddItemToObjectCS.
+func AddItemToObjectCS(obj *JSON, key string, it *JSON) bool {
+	return AddItemToObject(obj, key, it)
+}
+
+// DetachItemViaPointer implements cJSON_DetachItemViaPointer.
+func DetachItemViaPointer(parent, it *JSON) *JSON {
+	return parent.detach(it)
+}
+
+// DetachItemFromArray implements cJSON_DetachItemFromArray.
+func DetachItemFromArray(arr *JSON, i int32) *JSON {
+	return arr.detach(GetArrayItem(arr, i))
+}
+
+// DeleteItemFromArray implements cJSON_DeleteItemFromArray.
+func DeleteItemFromArray(arr *JSON, i int32) {
+	DetachItemFromArray(arr, i)
+}
+
+// DetachItemFromObject implements cJSON_DetachItemFromObject.
+func DetachItemFromObject(obj *JSON, key string) *JSON {
+	return obj.detach(obj.find(key, false))
+}
+
+// DetachItemFromObjectCaseSensitive implements cJSON_DetachItemFromObjectCaseSensitive.
+func DetachItemFromObjectCaseSensitive(obj *JSON, key string) *JSON {
+	return obj.detach(obj.find(key, true))
+}
+
+// DeleteItemFromObject implements cJSON_DeleteItemFromObject.
+func DeleteItemFromObject(obj *JSON, key string) {
+	DetachItemFromObject(obj, key)
+}
+
+// DeleteItemFromObjectCaseSensitive implements cJSON_DeleteItemFromObjectCaseSensitive.
+func DeleteItemFromObjectCaseSensitive(obj *JSON, key string) {
+	DetachItemFromObjectCaseSensitive(obj, key)
+}
+
+// ReplaceItemViaPointer implements cJSON_ReplaceItemViaPointer.
+func ReplaceItemViaPointer(parent, it, n *JSON) bool {
+	return parent.replace(it, n)
+}
+
+// ReplaceItemInArray implements cJSON_ReplaceItemInArray.
+func ReplaceItemInArray(arr *JSON, i int32, n *JSON) bool {
+	return arr.replace(GetArrayItem(arr, i), n)
+}
+
+// ReplaceItemInObject implements cJSON_ReplaceItemInObject.
+func ReplaceItemInObject(obj *JSON, key string, n *JSON) bool {
+	if n == nil {
+		return false
+	}
+	n.String = libc.CString(key)
+	return obj.replace(obj.find(key, false), n)
+}
+
+// ReplaceItemInObjectCaseSensitive implements cJSON_ReplaceItemInObjectCaseSensitive.
+func ReplaceItemInObjectCaseSensitive(obj *JSON, key string, n *JSON) bool {
+	if n == nil {
+		return false
+	}
+	n.String = libc.CString(key)
+	return obj.replace(obj.find(key, true), n)
+}
+
+func addToObject(obj *JSON, key string, it *JSON) *JSON {
+	if AddItemToObject(obj, key, it) {
+		return it
+	}
+	return nil
+}
+
+// AddNullToObject implements cJSON_AddNullToObject.
+func AddNullToObject(obj *JSON, key string) *JSON {
+	return addToObject(obj, key, CreateNull())
+}
+
+// AddTrueToObject implements cJSON_AddTrueToObject.
+func AddTrueToObject(obj *JSON, key string) *JSON {
+	return addToObject(obj, key, CreateTrue())
+}
+
+// AddFalseToObject implements cJSON_AddFalseToObject.
+func AddFalseToObject(obj *JSON, key string) *JSON {
+	return addToObject(obj, key, CreateFalse())
+}
+
+// AddBoolToObject implements cJSON_AddBoolToObject.
+func AddBoolToObject(obj *JSON, key string, v bool) *JSON {
+	return addToObject(obj, key, CreateBool(v))
+}
+
+// AddNumberToObject implements cJSON_AddNumberToObject.
+func AddNumberToObject(obj *JSON, key string, v float64) *JSON {
+	return addToObject(obj, key, CreateNumber(v))
+}
+
+// AddStringToObject implements cJSON_AddStringToObject.
+func AddStringToObject(obj *JSON, key string, v string) *JSON {
+	return addToObject(obj, key, CreateString(v))
+}
+
+// AddRawToObject implements cJSON_AddRawToObject.
+func AddRawToObject(obj *JSON, key string, v string) *JSON {
+	return addToObject(obj, key, CreateRaw(v))
+}
+
+// AddObjectToObject implements cJSON_AddObjectToObject.
+func AddObjectToObject(obj *JSON, key string) *JSON {
+	return addToObject(obj, key, CreateObject())
+}
+
+// AddArrayToObject implements cJSON_AddArrayToObject.
+func AddArrayToObject(obj *JSON, key string) *JSON {
+	return addToObject(obj, key, CreateArray())
+}
+
+// SetNumberHelper implements cJSON_SetNumberHelper, which is used by cJSON_SetNumberValue.
+func SetNumberHelper(it *JSON, v float64) float64 {
+	it.ValueInt = saturate(v)
+	it.ValueDouble = v
+	return v
+}
+
+// SetValuestring implements cJSON_SetValuestring.
+func SetValuestring(it *JSON, s string) *byte {
+	if !IsString(it) || it.Type&CJSON_IsReference != 0 {
+		return nil
+	}
+	it.ValueString = libc.CString(s)
+	return it.ValueString
+}
+
+// Compare implements cJSON_Compare.
+func Compare(a, b *JSON, caseSensitive bool) bool {
+	if a == nil || b == nil || a.Type&cjsonTypeMask != b.Type&cjsonTypeMask {
+		return false
+	}
+	switch a.Type & cjsonTypeMask {
+	case CJSON_False, CJSON_True, CJSON_NULL:
+		return true
+	case CJSON_Number:
+		return a.ValueDouble == b.ValueDouble
+	case CJSON_String, CJSON_Raw:
+		return a.ValueString != nil && b.ValueString != nil && libc.GoString(a.ValueString) == libc.GoString(b.ValueString)
+	case CJSON_Array:
+		ca, cb := a.Child, b.Child
+		for ; ca != nil && cb != nil; ca, cb = ca.Next, cb.Next {
+			if !Compare(ca, cb, caseSensitive) {
+				return false
+			}
+		}
+		return ca == nil && cb == nil
+	case CJSON_Object:
+		if GetArraySize(a) != GetArraySize(b) {
+			return false
+		}
+		for c := a.Child; c != nil; c = c.Next {
+			if !Compare(c, b.find(libc.GoString(c.String), caseSensitive), caseSensitive) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package cjson
+
+import (
+	"testing"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+const testJSON = `{"name": "cxgo", "version": 1.5, "tags": ["c", "go"], "Nested": {"ok": true, "n": null}} trailing`
+
+func TestCJSONParse(t *testing.T) {
+	root := Parse(libc.CString(testJSON))
+	if root == nil {
+		t.Fatal("cannot parse")
+	}
+	defer Delete(root)
+	if v := GetObjectItem(root, "name"); !IsString(v) || libc.GoString(v.ValueString) != "cxgo" || libc.GoString(v.String) != "name" {
+		t.Fatalf("unexpected name: %+v", v)
+	}
+	if v := GetNumberValue(GetObjectItemCaseSensitive(root, "version")); v != 1.5 {
+		t.Fatalf("unexpected version: %v", v)
+	}
+	tags := GetObjectItem(root, "tags")
+	if GetArraySize(tags) != 2 || libc.GoString(GetStringValue(GetArrayItem(tags, 1))) != "go" {
+		t.Fatal("unexpected tags")
+	}
+	if GetObjectItemCaseSensitive(root, "nested") != nil || !IsTrue(GetObjectItem(GetObjectItem(root, "nested"), "ok")) {
+		t.Fatal("unexpected nested object")
+	}
+	if s := libc.GoString(PrintUnformatted(root)); s != `{"name":"cxgo","version":1.5,"tags":["c","go"],"Nested":{"ok":true,"n":null}}` {
+		t.Fatalf("unexpected output: %s", s)
+	}
+}
+
+func TestCJSONError(t *testing.T) {
+	s := libc.CString(`{"a": [1, 2,, 3]}`)
+	if Parse(s) != nil {
+		t.Fatal("expected an error")
+	}
+	if off := libc.GoString(GetErrorPtr()); off == "" || len(off) > 6 {
+		t.Fatalf("unexpected error position: %q", off)
+	}
+}
+
+func TestCJSONBuild(t *testing.T) {
+	root := CreateObject()
+	AddStringToObject(root, "name", "a\"b\n")
+	AddNumberToObject(root, "n", 3)
+	arr := AddArrayToObject(root, "list")
+	AddItemToArray(arr, CreateNumber(0.1))
+	AddItemToArray(arr, CreateBool(false))
+	AddItemToArray(arr, CreateNull())
+	AddObjectToObject(root, "empty")
+	exp := "{\n\t\"name\":\t\"a\\\"b\\n\",\n\t\"n\":\t3,\n\t\"list\":\t[0.1, false, null],\n\t\"empty\":\t{\n\t}\n}"
+	if s := libc.GoString(Print(root)); s != exp {
+		t.Fatalf("unexpected output:\n%s", s)
+	}
+
+	DeleteItemFromArray(arr, 0)
+	ReplaceItemInObject(root, "n", CreateString("x"))
+	DeleteItemFromObject(root, "empty")
+	if v := DetachItemFromArray(arr, 1); !IsNull(v) || v.Next != nil {
+		t.Fatal("unexpected detached item")
+	}
+	if s := libc.GoString(PrintUnformatted(root)); s != `{"name":"a\"b\n","n":"x","list":[false]}` {
+		t.Fatalf("unexpected output: %s", s)
+	}
+	if d := Duplicate(root, true); !Compare(root, d, true) {
+		t.Fatal("duplicate is not equal")
+	}
+	n := 0
+	for c := root.Child; c != nil; c = c.Next {
+		n++
+	}
+	if n != 3 || root.Child.Prev.String == nil || libc.GoString(root.Child.Prev.String) != "list" {
+		t.Fatal("unexpected children")
+	}
+}
+
+func TestJSONC(t *testing.T) {
+	o := TokenerParse(testJSON)
+	if ObjectGetType(o) != Json_type_object {
+		t.Fatal("cannot parse")
+	}
+	defer ObjectPut(o)
+	var v *Object
+	if !ObjectObjectGetEx(o, "version", &v) || !ObjectIsType(v, Json_type_double) || ObjectGetDouble(v) != 1.5 {
+		t.Fatalf("unexpected version: %+v", v)
+	}
+	tags := ObjectObjectGet(o, "tags")
+	if ObjectArrayLength(tags) != 2 || libc.GoString(ObjectGetString(ObjectArrayGetIdx(tags, 0))) != "c" {
+		t.Fatal("unexpected tags")
+	}
+	if !ObjectObjectGetEx(ObjectObjectGet(o, "Nested"), "n", &v) || v != nil {
+		t.Fatal("expected null")
+	}
+
+	obj := ObjectNewObject()
+	ObjectObjectAdd(obj, "a/b", ObjectNewInt(1))
+	ObjectObjectAdd(obj, "d", ObjectNewDouble(2))
+	arr := ObjectNewArray()
+	ObjectArrayAdd(arr, ObjectNewString("x"))
+	ObjectArrayAdd(arr, ObjectNewBoolean(true))
+	ObjectObjectAdd(obj, "arr", arr)
+	if s := libc.GoString(ObjectToJSONString(obj)); s != `{ "a\/b": 1, "d": 2.0, "arr": [ "x", true ] }` {
+		t.Fatalf("unexpected output: %s", s)
+	}
+	if s := libc.GoString(ObjectToJSONStringExt(obj, JSON_C_TO_STRING_PLAIN|JSON_C_TO_STRING_NOSLASHESCAPE)); s != `{"a/b":1,"d":2.0,"arr":["x",true]}` {
+		t.Fatalf("unexpected output: %s", s)
+	}
+	exp := "{\n  \"a\\/b\":1,\n  \"d\":2.0,\n  \"arr\":[\n    \"x\",\n    true\n  ]\n}"
+	if s := libc.GoString(ObjectToJSONStringExt(obj, JSON_C_TO_STRING_PRETTY)); s != exp {
+		t.Fatalf("unexpected output:\n%s", s)
+	}
+	var (
+		key  *byte
+		val  *Object
+		keys string
+	)
+	for i := int32(0); ObjectObjectIter(obj, i, &key, &val); i++ {
+		keys += libc.GoString(key) + ","
+	}
+	if keys != "a/b,d,arr," {
+		t.Fatalf("unexpected keys: %q", keys)
+	}
+}
@@ -0,0 +1,430 @@
+package cjson
+
+import (
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// Object types of json-c (json_type).
+const (
+	Json_type_null    = 0
+	Json_type_boolean = 1
+	Json_type_double  = 2
+	Json_type_int     = 3
+	Json_type_object  = 4
+	Json_type_array   = 5
+	Json_type_string  = 6
+)
+
+// Flags for ObjectToJSONStringExt.
+const (
+	JSON_C_TO_STRING_PLAIN         = 0
+	JSON_C_TO_STRING_SPACED        = 1 << 0
+	JSON_C_TO_STRING_PRETTY        = 1 << 1
+	JSON_C_TO_STRING_PRETTY_TAB    = 1 << 3
+	JSON_C_TO_STRING_NOZERO        = 1 << 2
+	JSON_C_TO_STRING_NOSLASHESCAPE = 1 << 4
+)
+
+// Object is a json-c object (json_object). A nil object represents JSON null.
+type Object struct {
+	typ  int32
+	b    bool
+	i    int64
+	d    float64
+	s    string
+	keys []string
+	vals []*Object
+	refs int
+}
+
+// TokenerParse implements json_tokener_parse.
+func TokenerParse(s string) *Object {
+	v, _, err := parse([]byte(s))
+	if err != nil {
+		return nil
+	}
+	return objectFromNode(v)
+}
+
+func objectFromNode(n *node) *Object {
+	switch n.kind {
+	case 'n':
+		return nil
+	case 't', 'f':
+		return ObjectNewBoolean(n.kind == 't')
+	case 's':
+		return ObjectNewString(n.str)
+	case '0':
+		if isInt(n.num) {
+			if v, err := strconv.ParseInt(string(n.num), 10, 64); err == nil {
+				return ObjectNewInt64(v)
+			}
+		}
+		return ObjectNewDouble(parseFloat(n.num))
+	case '{':
+		o := ObjectNewObject()
+		for i, v := range n.vals {
+			ObjectObjectAdd(o, n.keys[i], objectFromNode(v))
+		}
+		return o
+	case '[':
+		o := ObjectNewArray()
+		for _, v := range n.vals {
+			ObjectArrayAdd(o, objectFromNode(v))
+		}
+		return o
+	}
+	return nil
+}
+
+// ObjectNewObject implements json_object_new_object.
+func ObjectNewObject() *Object { return &Object{typ: Json_type_object, refs: 1} }
+
+// ObjectNewArray implements json_object_new_array.
+func ObjectNewArray() *Object { return &Object{typ: Json_type_array, refs: 1} }
+
+// ObjectNewString implements json_object_new_string.
+func ObjectNewString(s string) *Object { return &Object{typ: Json_type_string, s: s, refs: 1} }
+
+// ObjectNewStringLen implements json_object_new_string_len.
+func ObjectNewStringLen(s *byte, n int32) *Object {
+	if n <= 0 {
+		return ObjectNewString("")
+	}
+	return ObjectNewString(string(unsafe.Slice(s, n)))
+}
+
+// ObjectNewInt implements json_object_new_int.
+func ObjectNewInt(v int32) *Object { return ObjectNewInt64(int64(v)) }
+
+// ObjectNewInt64 implements json_object_new_int64.
+func ObjectNewInt64(v int64) *Object { return &Object{typ: Json_type_int, i: v, refs: 1} }
+
+// ObjectNewDouble implements json_object_new_double.
+func ObjectNewDouble(v float64) *Object { return &Object{typ: Json_type_double, d: v, refs: 1} }
+
+// ObjectNewBoolean implements json_object_new_boolean.
+func ObjectNewBoolean(v bool) *Object { return &Object{typ: Json_type_boolean, b: v, refs: 1} }
+
+// ObjectGet implements json_object_get.
+func ObjectGet(o *Object) *Object {
+	if o != nil {
+		o.refs++
+	}
+	return o
+}
+
+// ObjectPut implements json_object_put. The memory is managed by Go, thus it only decrements the reference count.
+func ObjectPut(o *Object) int32 {
+	if o == nil {
+		return 0
+	}
+	o.refs--
+	if o.refs <= 0 {
+		return 1
+	}
+	return 0
+}
+
+// ObjectGetType implements json_object_get_type.
+func ObjectGetType(o *Object) int32 {
+	if o == nil {
+		return Json_type_null
+	}
+	return o.typ
+}
+
+// ObjectIsType implements json_object_is_type.
+func ObjectIsType(o *Object, typ int32) bool {
+	return ObjectGetType(o) == typ
+}
+
+// ObjectGetBoolean implements json_object_get_boolean.
+func ObjectGetBoolean(o *Object) bool {
+	switch ObjectGetType(o) {
+	case Json_type_boolean:
+		return o.b
+	case Json_type_int:
+		return o.i != 0
+	case Json_type_double:
+		return o.d != 0
+	case Json_type_string:
+		return o.s != ""
+	}
+	return false
+}
+
+// ObjectGetInt64 implements json_object_get_int64.
+func ObjectGetInt64(o *Object) int64 {
+	switch ObjectGetType(o) {
+	case Json_type_boolean:
+		if o.b {
+			return 1
+		}
+	case Json_type_int:
+		return o.i
+	case Json_type_double:
+		return int64(o.d)
+	case Json_type_string:
+		v, _ := strconv.ParseInt(strings.TrimSpace(o.s), 10, 64)
+		return v
+	}
+	return 0
+}
+
+// ObjectGetInt implements json_object_get_int. The value is saturated, like in json-c.
+func ObjectGetInt(o *Object) int32 {
+	v := ObjectGetInt64(o)
+	switch {
+	case v > 2147483647:
+		return 2147483647
+	case v < -2147483648:
+		return -2147483648
+	}
+	return int32(v)
+}
+
+// ObjectGetDouble implements json_object_get_double.
+func ObjectGetDouble(o *Object) float64 {
+	switch ObjectGetType(o) {
+	case Json_type_boolean:
+		if o.b {
+			return 1
+		}
+	case Json_type_int:
+		return float64(o.i)
+	case Json_type_double:
+		return o.d
+	case Json_type_string:
+		v, _ := strconv.ParseFloat(strings.TrimSpace(o.s), 64)
+		return v
+	}
+	return 0
+}
+
+// ObjectGetString implements json_object_get_string. For non-string objects, it returns the JSON representation.
+func ObjectGetString(o *Object) *byte {
+	if o == nil {
+		return nil
+	}
+	if o.typ == Json_type_string {
+		return libc.CString(o.s)
+	}
+	return ObjectToJSONString(o)
+}
+
+// ObjectGetStringLen implements json_object_get_string_len.
+func ObjectGetStringLen(o *Object) int32 {
+	if ObjectGetType(o) != Json_type_string {
+		return 0
+	}
+	return int32(len(o.s))
+}
+
+func (o *Object) index(key string) int {
+	for i, k := range o.keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// ObjectObjectAdd implements json_object_object_add. It takes the ownership of the value.
+func ObjectObjectAdd(o *Object, key string, v *Object) int32 {
+	if ObjectGetType(o) != Json_type_object {
+		return -1
+	}
+	if i := o.index(key); i >= 0 {
+		o.vals[i] = v
+		return 0
+	}
+	o.keys = append(o.keys, key)
+	o.vals = append(o.vals, v)
+	return 0
+}
+
+// ObjectObjectGetEx implements json_object_object_get_ex.
+func ObjectObjectGetEx(o *Object, key string, out **Object) bool {
+	if ObjectGetType(o) != Json_type_object {
+		if out != nil {
+			*out = nil
+		}
+		return false
+	}
+	i := o.index(key)
+	if out != nil {
+		*out = nil
+		if i >= 0 {
+			*out = o.vals[i]
+		}
+	}
+	return i >= 0
+}
+
+// ObjectObjectGet implements json_object_object_get.
+func ObjectObjectGet(o *Object, key string) *Object {
+	var v *Object
+	ObjectObjectGetEx(o, key, &v)
+	return v
+}
+
+// ObjectObjectDel implements json_object_object_del.
+func ObjectObjectDel(o *Object, key string) {
+	if ObjectGetType(o) != Json_type_object {
+		return
+	}
+	if i := o.index(key); i >= 0 {
+		o.keys = append(o.keys[:i], o.keys[i+1:]...)
+		o.vals = append(o.vals[:i], o.vals[i+1:]...)
+	}
+}
+
+// ObjectObjectLength implements json_object_object_length.
+func ObjectObjectLength(o *Object) int32 {
+	if ObjectGetType(o) != Json_type_object {
+		return 0
+	}
+	return int32(len(o.keys))
+}
+
+// ObjectObjectIter returns the key and the value of the i-th field of an object.
+// It is used by the json_object_object_foreach macro.
+func ObjectObjectIter(o *Object, i int32, key **byte, val **Object) bool {
+	if ObjectGetType(o) != Json_type_object || i < 0 || int(i) >= len(o.keys) {
+		return false
+	}
+	*key = libc.CString(o.keys[i])
+	*val = o.vals[i]
+	return true
+}
+
+// ObjectArrayLength implements json_object_array_length.
+func ObjectArrayLength(o *Object) int {
+	if ObjectGetType(o) != Json_type_array {
+		return 0
+	}
+	return len(o.vals)
+}
+
+// ObjectArrayGetIdx implements json_object_array_get_idx.
+func ObjectArrayGetIdx(o *Object, i int) *Object {
+	if ObjectGetType(o) != Json_type_array || i < 0 || i >= len(o.vals) {
+		return nil
+	}
+	return o.vals[i]
+}
+
+// ObjectArrayAdd implements json_object_array_add. It takes the ownership of the value.
+func ObjectArrayAdd(o *Object, v *Object) int32 {
+	if ObjectGetType(o) != Json_type_array {
+		return -1
+	}
+	o.vals = append(o.vals, v)
+	return 0
+}
+
+// ObjectArrayPutIdx implements json_object_array_put_idx. The array grows with nulls, if necessary.
+func ObjectArrayPutIdx(o *Object, i int, v *Object) int32 {
+	if ObjectGetType(o) != Json_type_array || i < 0 {
+		return -1
+	}
+	for len(o.vals) <= i {
+		o.vals = append(o.vals, nil)
+	}
+	o.vals[i] = v
+	return 0
+}
+
+// ObjectArrayDelIdx implements json_object_array_del_idx.
+func ObjectArrayDelIdx(o *Object, i, n int) int32 {
+	if ObjectGetType(o) != Json_type_array || i < 0 || n < 0 || i+n > len(o.vals) {
+		return -1
+	}
+	o.vals = append(o.vals[:i], o.vals[i+n:]...)
+	return 0
+}
+
+// ObjectToJSONString implements json_object_to_json_string.
+func ObjectToJSONString(o *Object) *byte {
+	return ObjectToJSONStringExt(o, JSON_C_TO_STRING_SPACED)
+}
+
+// ObjectToJSONStringExt implements json_object_to_json_string_ext.
+func ObjectToJSONStringExt(o *Object, flags int32) *byte {
+	var sb strings.Builder
+	o.print(&sb, flags, 0)
+	return libc.CString(sb.String())
+}
+
+func (o *Object) indent(sb *strings.Builder, flags int32, depth int) {
+	if flags&JSON_C_TO_STRING_PRETTY == 0 {
+		return
+	}
+	sb.WriteByte('\n')
+	if flags&JSON_C_TO_STRING_PRETTY_TAB != 0 {
+		sb.WriteString(strings.Repeat("\t", depth))
+	} else {
+		sb.WriteString(strings.Repeat("  ", depth))
+	}
+}
+
+func (o *Object) print(sb *strings.Builder, flags int32, depth int) {
+	spaced := flags&JSON_C_TO_STRING_SPACED != 0
+	pretty := flags&JSON_C_TO_STRING_PRETTY != 0
+	switch ObjectGetType(o) {
+	case Json_type_null:
+		sb.WriteString("null")
+	case Json_type_boolean:
+		sb.WriteString(strconv.FormatBool(o.b))
+	case Json_type_int:
+		sb.WriteString(strconv.FormatInt(o.i, 10))
+	case Json_type_double:
+		s := strconv.FormatFloat(o.d, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eInN") {
+			s += ".0"
+		}
+		sb.WriteString(s)
+	case Json_type_string:
+		writeString(sb, o.s, flags&JSON_C_TO_STRING_NOSLASHESCAPE == 0)
+	case Json_type_object, Json_type_array:
+		isObj := o.typ == Json_type_object
+		if isObj {
+			sb.WriteByte('{')
+		} else {
+			sb.WriteByte('[')
+		}
+		for i, v := range o.vals {
+			if i != 0 {
+				sb.WriteByte(',')
+			}
+			if pretty {
+				o.indent(sb, flags, depth+1)
+			} else if spaced {
+				sb.WriteByte(' ')
+			}
+			if isObj {
+				writeString(sb, o.keys[i], flags&JSON_C_TO_STRING_NOSLASHESCAPE == 0)
+				sb.WriteByte(':')
+				if spaced {
+					sb.WriteByte(' ')
+				}
+			}
+			v.print(sb, flags, depth+1)
+		}
+		if pretty && len(o.vals) != 0 {
+			o.indent(sb, flags, depth)
+		} else if spaced {
+			sb.WriteByte(' ')
+		}
+		if isObj {
+			sb.WriteByte('}')
+		} else {
+			sb.WriteByte(']')
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package cjson implements cJSON (cJSON.h) and json-c (json-c/json.h) APIs on top of encoding/json.
+package cjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// node is a parsed JSON value, which is then converted to the library-specific representation.
+type node struct {
+	kind byte // one of: n, t, f, s, d ('0' for numbers), {, [
+	str  string
+	num  json.Number
+	keys []string
+	vals []*node
+}
+
+var errSyntax = errors.New("invalid JSON value")
+
+// parse parses the first JSON value from the data. It returns the offset of the last read byte on error.
+func parse(data []byte) (*node, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := parseValue(dec)
+	if err != nil {
+		return nil, int(dec.InputOffset()), err
+	}
+	return n, int(dec.InputOffset()), nil
+}
+
+func parseValue(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case nil:
+		return &node{kind: 'n'}, nil
+	case bool:
+		if tok {
+			return &node{kind: 't'}, nil
+		}
+		return &node{kind: 'f'}, nil
+	case string:
+		return &node{kind: 's', str: tok}, nil
+	case json.Number:
+		return &node{kind: '0', num: tok}, nil
+	case json.Delim:
+		switch tok {
+		case '{':
+			n := &node{kind: '{'}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := parseValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+				n.vals = append(n.vals, v)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case '[':
+			n := &node{kind: '['}
+			for dec.More() {
+				v, err := parseValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				n.vals = append(n.vals, v)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	}
+	return nil, errSyntax
+}
+
+// isInt checks if the number is written as an integer.
+func isInt(num json.Number) bool {
+	return !strings.ContainsAny(string(num), ".eE")
+}
+
+// writeString writes a quoted and escaped JSON string.
+func writeString(sb *strings.Builder, s string, escapeSlash bool) {
+	const hex = "0123456789abcdef"
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '/':
+			if escapeSlash {
+				sb.WriteString(`\/`)
+			} else {
+				sb.WriteByte(c)
+			}
+		default:
+			if c < 0x20 {
+				sb.WriteString(`\u00`)
+				sb.WriteByte(hex[c>>4])
+				sb.WriteByte(hex[c&0xf])
+			} else {
+				sb.WriteByte(c)
+			}
+		}
+	}
+	sb.WriteByte('"')
+}
+
+// saturate converts a float to int32, the same way as cJSON.
+func saturate(v float64) int32 {
+	switch {
+	case v >= 2147483647:
+		return 2147483647
+	case v <= -2147483648:
+		return -2147483648
+	case v != v:
+		return 0
+	}
+	return int32(v)
+}
+
+func parseFloat(num json.Number) float64 {
+	v, _ := strconv.ParseFloat(string(num), 64)
+	return v
+}
@@ -0,0 +1,372 @@
+// Package csqlite implements the sqlite3 client API (sqlite3.h) on top of database/sql.
+//
+// The package doesn't import any SQLite driver. Programs must register one, for example with
+// the pure-Go driver: import _ "modernc.org/sqlite".
+package csqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// DriverName is the name of the database/sql driver used to open databases.
+var DriverName = "sqlite"
+
+const Version = "3.41.2"
+
+const (
+	SQLITE_OK         = 0
+	SQLITE_ERROR      = 1
+	SQLITE_INTERNAL   = 2
+	SQLITE_PERM       = 3
+	SQLITE_ABORT      = 4
+	SQLITE_BUSY       = 5
+	SQLITE_LOCKED     = 6
+	SQLITE_NOMEM      = 7
+	SQLITE_READONLY   = 8
+	SQLITE_INTERRUPT  = 9
+	SQLITE_IOERR      = 10
+	SQLITE_CORRUPT    = 11
+	SQLITE_NOTFOUND   = 12
+	SQLITE_FULL       = 13
+	SQLITE_CANTOPEN   = 14
+	SQLITE_PROTOCOL   = 15
+	SQLITE_EMPTY      = 16
+	SQLITE_SCHEMA     = 17
+	SQLITE_TOOBIG     = 18
+	SQLITE_CONSTRAINT = 19
+	SQLITE_MISMATCH   = 20
+	SQLITE_MISUSE     = 21
+	SQLITE_NOLFS      = 22
+	SQLITE_AUTH       = 23
+	SQLITE_FORMAT     = 24
+	SQLITE_RANGE      = 25
+	SQLITE_NOTADB     = 26
+	SQLITE_NOTICE     = 27
+	SQLITE_WARNING    = 28
+	SQLITE_ROW        = 100
+	SQLITE_DONE       = 101
+)
+
+const (
+	SQLITE_CONSTRAINT_CHECK      = SQLITE_CONSTRAINT | 1<<8
+	SQLITE_CONSTRAINT_FOREIGNKEY = SQLITE_CONSTRAINT | 3<<8
+	SQLITE_CONSTRAINT_NOTNULL    = SQLITE_CONSTRAINT | 5<<8
+	SQLITE_CONSTRAINT_PRIMARYKEY = SQLITE_CONSTRAINT | 6<<8
+	SQLITE_CONSTRAINT_UNIQUE     = SQLITE_CONSTRAINT | 8<<8
+)
+
+const (
+	SQLITE_INTEGER = 1
+	SQLITE_FLOAT   = 2
+	SQLITE_TEXT    = 3
+	SQLITE_BLOB    = 4
+	SQLITE_NULL    = 5
+)
+
+const (
+	SQLITE_OPEN_READONLY     = 0x00000001
+	SQLITE_OPEN_READWRITE    = 0x00000002
+	SQLITE_OPEN_CREATE       = 0x00000004
+	SQLITE_OPEN_URI          = 0x00000040
+	SQLITE_OPEN_MEMORY       = 0x00000080
+	SQLITE_OPEN_NOMUTEX      = 0x00008000
+	SQLITE_OPEN_FULLMUTEX    = 0x00010000
+	SQLITE_OPEN_SHAREDCACHE  = 0x00020000
+	SQLITE_OPEN_PRIVATECACHE = 0x00040000
+)
+
+// Destructor is a destructor for bound values (sqlite3_destructor_type). Values are always copied, thus it's never called.
+type Destructor func(unsafe.Pointer)
+
+var (
+	// SQLITE_STATIC implements SQLITE_STATIC.
+	SQLITE_STATIC Destructor
+	// SQLITE_TRANSIENT implements SQLITE_TRANSIENT.
+	SQLITE_TRANSIENT Destructor = func(unsafe.Pointer) {}
+)
+
+// DB is a database connection (sqlite3).
+//
+// A single connection from the pool is used, thus temporary tables, in-memory databases and
+// transactions work the same way as in SQLite.
+type DB struct {
+	db      *sql.DB
+	conn    *sql.Conn
+	code    int32
+	msg     string
+	changes int64
+	total   int64
+	lastID  int64
+}
+
+// Libversion implements sqlite3_libversion.
+func Libversion() *byte {
+	return libc.CString(Version)
+}
+
+// Free implements sqlite3_free.
+func Free(p unsafe.Pointer) {}
+
+// Open implements sqlite3_open.
+func Open(filename string, out **DB) int32 {
+	return OpenV2(filename, out, SQLITE_OPEN_READWRITE|SQLITE_OPEN_CREATE, nil)
+}
+
+// OpenV2 implements sqlite3_open_v2. Flags and VFS are ignored.
+func OpenV2(filename string, out **DB, flags int32, vfs *byte) int32 {
+	d := &DB{}
+	*out = d
+	db, err := sql.Open(DriverName, filename)
+	if err != nil {
+		return d.setErr(SQLITE_CANTOPEN, err)
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		return d.setErr(SQLITE_CANTOPEN, err)
+	}
+	d.db, d.conn = db, conn
+	return SQLITE_OK
+}
+
+// Close implements sqlite3_close.
+func Close(d *DB) int32 {
+	if d == nil || d.db == nil {
+		return SQLITE_OK
+	}
+	d.conn.Close()
+	err := d.db.Close()
+	d.db, d.conn = nil, nil
+	if err != nil {
+		return d.setErr(SQLITE_ERROR, err)
+	}
+	return SQLITE_OK
+}
+
+// CloseV2 implements sqlite3_close_v2.
+func CloseV2(d *DB) int32 {
+	return Close(d)
+}
+
+// errorCode returns a SQLite error code for the driver error. The driver error must implement Code() int.
+func errorCode(err error, def int32) int32 {
+	var cerr interface{ Code() int }
+	if errors.As(err, &cerr) {
+		return int32(cerr.Code())
+	}
+	return def
+}
+
+func (d *DB) setErr(def int32, err error) int32 {
+	if err == nil {
+		d.code, d.msg = SQLITE_OK, ""
+		return SQLITE_OK
+	}
+	d.code = errorCode(err, def)
+	d.msg = err.Error()
+	return d.code & 0xff
+}
+
+func (d *DB) clearErr() {
+	d.code, d.msg = SQLITE_OK, ""
+}
+
+// Errcode implements sqlite3_errcode.
+func Errcode(d *DB) int32 {
+	if d == nil {
+		return SQLITE_NOMEM
+	}
+	return d.code & 0xff
+}
+
+// ExtendedErrcode implements sqlite3_extended_errcode.
+func ExtendedErrcode(d *DB) int32 {
+	if d == nil {
+		return SQLITE_NOMEM
+	}
+	return d.code
+}
+
+// Errmsg implements sqlite3_errmsg.
+func Errmsg(d *DB) *byte {
+	if d == nil {
+		return Errstr(SQLITE_NOMEM)
+	}
+	if d.msg == "" {
+		return Errstr(d.code)
+	}
+	return libc.CString(d.msg)
+}
+
+var errStrings = map[int32]string{
+	SQLITE_OK:         "not an error",
+	SQLITE_ERROR:      "SQL logic error",
+	SQLITE_INTERNAL:   "internal error",
+	SQLITE_PERM:       "access permission denied",
+	SQLITE_ABORT:      "query aborted",
+	SQLITE_BUSY:       "database is locked",
+	SQLITE_LOCKED:     "database table is locked",
+	SQLITE_NOMEM:      "out of memory",
+	SQLITE_READONLY:   "attempt to write a readonly database",
+	SQLITE_INTERRUPT:  "interrupted",
+	SQLITE_IOERR:      "disk I/O error",
+	SQLITE_CORRUPT:    "database disk image is malformed",
+	SQLITE_NOTFOUND:   "unknown operation",
+	SQLITE_FULL:       "database or disk is full",
+	SQLITE_CANTOPEN:   "unable to open database file",
+	SQLITE_PROTOCOL:   "locking protocol",
+	SQLITE_SCHEMA:     "database schema has changed",
+	SQLITE_TOOBIG:     "string or blob too big",
+	SQLITE_CONSTRAINT: "constraint failed",
+	SQLITE_MISMATCH:   "datatype mismatch",
+	SQLITE_MISUSE:     "bad parameter or other API misuse",
+	SQLITE_NOLFS:      "large file support is disabled",
+	SQLITE_AUTH:       "authorization denied",
+	SQLITE_RANGE:      "column index out of range",
+	SQLITE_NOTADB:     "file is not a database",
+	SQLITE_ROW:        "another row available",
+	SQLITE_DONE:       "no more rows available",
+}
+
+// Errstr implements sqlite3_errstr.
+func Errstr(code int32) *byte {
+	s, ok := errStrings[code&0xff]
+	if !ok {
+		s = "unknown error"
+	}
+	return libc.CString(s)
+}
+
+// Changes implements sqlite3_changes.
+func Changes(d *DB) int32 {
+	return int32(d.changes)
+}
+
+// TotalChanges implements sqlite3_total_changes.
+func TotalChanges(d *DB) int32 {
+	return int32(d.total)
+}
+
+// LastInsertRowid implements sqlite3_last_insert_rowid.
+func LastInsertRowid(d *DB) int64 {
+	return d.lastID
+}
+
+// BusyTimeout implements sqlite3_busy_timeout.
+func BusyTimeout(d *DB, ms int32) int32 {
+	if d == nil || d.conn == nil {
+		return SQLITE_MISUSE
+	}
+	_, err := d.conn.ExecContext(context.Background(), "PRAGMA busy_timeout = "+strconv.Itoa(int(ms)))
+	return d.setErr(SQLITE_ERROR, err)
+}
+
+// ExecCallback is a callback for Exec. It receives the number of columns, column values and names.
+type ExecCallback func(arg unsafe.Pointer, n int32, vals **byte, names **byte) int32
+
+// Exec implements sqlite3_exec.
+func Exec(d *DB, query string, cb ExecCallback, arg unsafe.Pointer, errmsg **byte) int32 {
+	if d == nil || d.conn == nil {
+		return SQLITE_MISUSE
+	}
+	code := d.exec(query, cb, arg)
+	if errmsg != nil {
+		*errmsg = nil
+		if code != SQLITE_OK {
+			*errmsg = Errmsg(d)
+		}
+	}
+	return code
+}
+
+func (d *DB) exec(query string, cb ExecCallback, arg unsafe.Pointer) int32 {
+	for {
+		query = strings.TrimSpace(query)
+		if query == "" {
+			d.clearErr()
+			return SQLITE_OK
+		}
+		stmt, rest := splitStatement(query)
+		query = rest
+		s, code := d.prepare(stmt)
+		if code != SQLITE_OK {
+			return code
+		} else if s == nil {
+			continue
+		}
+		names := make([]*byte, 0)
+		for {
+			code = Step(s)
+			if code != SQLITE_ROW {
+				break
+			}
+			if cb == nil {
+				continue
+			}
+			n := ColumnCount(s)
+			if len(names) == 0 {
+				for i := int32(0); i < n; i++ {
+					names = append(names, ColumnName(s, i))
+				}
+			}
+			vals := make([]*byte, n)
+			for i := range vals {
+				if ColumnType(s, int32(i)) != SQLITE_NULL {
+					vals[i] = (*byte)(unsafe.Pointer(ColumnText(s, int32(i))))
+				}
+			}
+			var pv, pn **byte
+			if n > 0 {
+				pv, pn = &vals[0], &names[0]
+			}
+			if cb(arg, n, pv, pn) != 0 {
+				Finalize(s)
+				d.code, d.msg = SQLITE_ABORT, ""
+				return SQLITE_ABORT
+			}
+		}
+		if code != SQLITE_DONE {
+			Finalize(s)
+			return code
+		}
+		Finalize(s)
+	}
+}
+
+// splitStatement returns the first SQL statement and the rest of the query.
+func splitStatement(s string) (string, string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			if j := strings.Index(s[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(s)
+			}
+		case c == ';':
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
@@ -0,0 +1,281 @@
+package csqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// testDriver is a tiny database driver with a single table of (id, name) rows.
+type testDriver struct {
+	mu   sync.Mutex
+	rows [][]driver.Value
+}
+
+type testError struct {
+	code int
+	msg  string
+}
+
+func (e *testError) Error() string { return e.msg }
+func (e *testError) Code() int     { return e.code }
+
+func (d *testDriver) Open(name string) (driver.Conn, error) {
+	return &testConn{d: d}, nil
+}
+
+type testConn struct {
+	d *testDriver
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	for _, p := range []string{"CREATE", "INSERT", "SELECT", "DELETE"} {
+		if strings.HasPrefix(q, p) {
+			return &testStmt{c: c, kind: p, n: strings.Count(q, "?"), vals: parseValues(query)}, nil
+		}
+	}
+	return nil, &testError{code: SQLITE_ERROR, msg: "syntax error"}
+}
+
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+// parseValues parses literal values of an INSERT statement: VALUES (1, 'a').
+func parseValues(query string) []driver.Value {
+	i := strings.Index(query, "VALUES (")
+	if i < 0 || strings.Contains(query, "?") {
+		return nil
+	}
+	var vals []driver.Value
+	for _, v := range strings.Split(strings.TrimSuffix(query[i+8:], ")"), ", ") {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			vals = append(vals, n)
+		} else {
+			vals = append(vals, strings.Trim(v, "'"))
+		}
+	}
+	return vals
+}
+
+type testStmt struct {
+	c    *testConn
+	kind string
+	n    int
+	vals []driver.Value
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return s.n }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch s.kind {
+	case "INSERT":
+		if s.vals != nil {
+			args = s.vals
+		}
+		if name, _ := args[1].(string); name == "" {
+			return nil, &testError{code: SQLITE_CONSTRAINT_NOTNULL, msg: "NOT NULL constraint failed: t.name"}
+		}
+		d.rows = append(d.rows, args)
+		return testResult{id: int64(len(d.rows)), n: 1}, nil
+	case "DELETE":
+		n := len(d.rows)
+		d.rows = nil
+		return testResult{n: int64(n)}, nil
+	}
+	return testResult{}, nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &testRows{rows: append([][]driver.Value{}, d.rows...)}, nil
+}
+
+type testResult struct {
+	id, n int64
+}
+
+func (r testResult) LastInsertId() (int64, error) { return r.id, nil }
+func (r testResult) RowsAffected() (int64, error) { return r.n, nil }
+
+type testRows struct {
+	rows [][]driver.Value
+}
+
+func (r *testRows) Columns() []string { return []string{"id", "name"} }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dst []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dst, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var registerOnce sync.Once
+
+func openTest(t *testing.T) *DB {
+	registerOnce.Do(func() {
+		sql.Register("csqlite-test", &testDriver{})
+	})
+	old := DriverName
+	DriverName = "csqlite-test"
+	defer func() {
+		DriverName = old
+	}()
+	var d *DB
+	if code := Open(":memory:", &d); code != SQLITE_OK {
+		t.Fatalf("open: %d", code)
+	}
+	t.Cleanup(func() {
+		Close(d)
+	})
+	var errmsg *byte
+	if code := Exec(d, "DELETE FROM t", nil, nil, &errmsg); code != SQLITE_OK {
+		t.Fatalf("exec: %s", libc.GoString(errmsg))
+	}
+	return d
+}
+
+func TestPrepare(t *testing.T) {
+	d := openTest(t)
+	query := libc.CString("INSERT INTO t (id, name) VALUES (?, ?); SELECT id, name FROM t")
+	var (
+		st   *Stmt
+		tail *byte
+	)
+	if code := PrepareV2(d, query, -1, &st, &tail); code != SQLITE_OK {
+		t.Fatalf("prepare: %s", libc.GoString(Errmsg(d)))
+	}
+	if got := libc.GoString(tail); got != " SELECT id, name FROM t" {
+		t.Fatalf("unexpected tail: %q", got)
+	}
+	if n := BindParameterCount(st); n != 2 {
+		t.Fatalf("unexpected parameter count: %d", n)
+	}
+	for i, name := range []string{"a", "b"} {
+		BindInt(st, 1, int32(i+1))
+		BindText(st, 2, libc.CString(name+"xyz"), 1, SQLITE_TRANSIENT)
+		if code := Step(st); code != SQLITE_DONE {
+			t.Fatalf("step: %d", code)
+		}
+		if id := LastInsertRowid(d); id != int64(i+1) {
+			t.Fatalf("unexpected rowid: %d", id)
+		}
+		Reset(st)
+	}
+	if code := BindInt(st, 3, 0); code != SQLITE_RANGE {
+		t.Fatalf("unexpected bind result: %d", code)
+	}
+	BindNull(st, 2)
+	if code := Step(st); code != SQLITE_CONSTRAINT {
+		t.Fatalf("unexpected step result: %d", code)
+	}
+	if code := ExtendedErrcode(d); code != SQLITE_CONSTRAINT_NOTNULL {
+		t.Fatalf("unexpected extended code: %d", code)
+	}
+	if msg := libc.GoString(Errmsg(d)); msg != "NOT NULL constraint failed: t.name" {
+		t.Fatalf("unexpected error: %q", msg)
+	}
+	Finalize(st)
+
+	if code := PrepareV2(d, tail, -1, &st, nil); code != SQLITE_OK {
+		t.Fatalf("prepare: %s", libc.GoString(Errmsg(d)))
+	}
+	defer Finalize(st)
+	var got []string
+	for Step(st) == SQLITE_ROW {
+		if ColumnType(st, 0) != SQLITE_INTEGER || ColumnType(st, 1) != SQLITE_TEXT {
+			t.Fatalf("unexpected column types")
+		}
+		name := libc.GoStringS(unsafe.Slice(ColumnText(st, 1), ColumnBytes(st, 1)))
+		got = append(got, libc.GoString(ColumnName(st, 1))+"="+name+":"+libc.GoString(ColumnText(st, 0)))
+		if v := ColumnDouble(st, 0); v != float64(ColumnInt(st, 0)) {
+			t.Fatalf("unexpected double: %v", v)
+		}
+	}
+	if strings.Join(got, ",") != "name=a:1,name=b:2" {
+		t.Fatalf("unexpected rows: %q", got)
+	}
+	// statement is reset automatically
+	if code := Step(st); code != SQLITE_ROW {
+		t.Fatalf("unexpected step result: %d", code)
+	}
+}
+
+func TestExec(t *testing.T) {
+	d := openTest(t)
+	total := TotalChanges(d)
+	var errmsg *byte
+	code := Exec(d, `
+		CREATE TABLE t (id INTEGER, name TEXT);
+		INSERT INTO t VALUES (1, 'a'); -- comment; with a semicolon
+		INSERT INTO t VALUES (2, 'b;c');
+	`, nil, nil, &errmsg)
+	if code != SQLITE_OK || errmsg != nil {
+		t.Fatalf("exec: %s", libc.GoString(errmsg))
+	}
+	if n := TotalChanges(d) - total; n != 2 {
+		t.Fatalf("unexpected changes: %d", n)
+	}
+	var got []string
+	cb := func(arg unsafe.Pointer, n int32, vals **byte, names **byte) int32 {
+		v := unsafe.Slice(vals, n)
+		cols := unsafe.Slice(names, n)
+		for i := range v {
+			got = append(got, libc.GoString(cols[i])+"="+libc.GoString(v[i]))
+		}
+		*(*int)(arg)++
+		return 0
+	}
+	var rows int
+	if code = Exec(d, "SELECT * FROM t", cb, unsafe.Pointer(&rows), nil); code != SQLITE_OK {
+		t.Fatalf("exec: %d", code)
+	}
+	if rows != 2 || strings.Join(got, ",") != "id=1,name=a,id=2,name=b;c" {
+		t.Fatalf("unexpected rows: %d, %q", rows, got)
+	}
+	rows = 0
+	abort := func(arg unsafe.Pointer, n int32, vals **byte, names **byte) int32 {
+		rows++
+		return 1
+	}
+	if code = Exec(d, "SELECT * FROM t", abort, nil, nil); code != SQLITE_ABORT || rows != 1 {
+		t.Fatalf("unexpected exec result: %d, %d", code, rows)
+	}
+	if code = Exec(d, "DROP TABLE t", nil, nil, &errmsg); code != SQLITE_ERROR {
+		t.Fatalf("unexpected exec result: %d", code)
+	}
+	if msg := libc.GoString(errmsg); msg != "syntax error" {
+		t.Fatalf("unexpected error: %q", msg)
+	}
+}
+
+func TestParams(t *testing.T) {
+	st := &Stmt{params: parseParams("SELECT ?, :a, @b, ':c', ?5, :a -- ?")}
+	if n := BindParameterCount(st); n != 5 {
+		t.Fatalf("unexpected count: %d", n)
+	}
+	if i := BindParameterIndex(st, ":a"); i != 2 {
+		t.Fatalf("unexpected index: %d", i)
+	}
+	if i := BindParameterIndex(st, "@b"); i != 3 {
+		t.Fatalf("unexpected index: %d", i)
+	}
+}
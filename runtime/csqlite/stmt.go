@@ -0,0 +1,576 @@
+package csqlite
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// Stmt is a prepared statement (sqlite3_stmt).
+//
+// The database/sql API distinguishes queries and other statements, thus the statement kind is guessed from the SQL:
+// SELECT, WITH, VALUES, PRAGMA, EXPLAIN and statements with RETURNING clause are queries, and all other are executed
+// when Step is called for the first time.
+type Stmt struct {
+	db     *DB
+	stmt   *sql.Stmt
+	sql    string
+	query  bool
+	params []string // parameter names, empty for anonymous parameters
+	args   []interface{}
+
+	rows *sql.Rows
+	cols []string
+	row  []interface{}
+	done bool
+}
+
+// Prepare implements sqlite3_prepare.
+func Prepare(d *DB, query *byte, n int32, out **Stmt, tail **byte) int32 {
+	return PrepareV2(d, query, n, out, tail)
+}
+
+// PrepareV3 implements sqlite3_prepare_v3. Flags are ignored.
+func PrepareV3(d *DB, query *byte, n int32, flags uint32, out **Stmt, tail **byte) int32 {
+	return PrepareV2(d, query, n, out, tail)
+}
+
+// PrepareV2 implements sqlite3_prepare_v2. Only the first statement is prepared, and tail is set to the rest of the SQL.
+func PrepareV2(d *DB, query *byte, n int32, out **Stmt, tail **byte) int32 {
+	if out != nil {
+		*out = nil
+	}
+	if d == nil || d.conn == nil || query == nil || out == nil {
+		return SQLITE_MISUSE
+	}
+	var src string
+	if n < 0 {
+		src = libc.GoString(query)
+	} else {
+		src = libc.GoStringS(unsafe.Slice(query, n))
+	}
+	stmt, rest := splitStatement(src)
+	if tail != nil {
+		*tail = (*byte)(unsafe.Add(unsafe.Pointer(query), len(src)-len(rest)))
+	}
+	s, code := d.prepare(stmt)
+	*out = s
+	return code
+}
+
+// prepare the statement. It returns nil statement if the SQL is empty.
+func (d *DB) prepare(query string) (*Stmt, int32) {
+	query = skipSpace(query)
+	kw := firstKeyword(query)
+	if kw == "" {
+		d.clearErr()
+		return nil, SQLITE_OK
+	}
+	st, err := d.conn.PrepareContext(context.Background(), query)
+	if err != nil {
+		return nil, d.setErr(SQLITE_ERROR, err)
+	}
+	d.clearErr()
+	s := &Stmt{db: d, stmt: st, sql: query, params: parseParams(query)}
+	switch kw {
+	case "SELECT", "WITH", "VALUES", "PRAGMA", "EXPLAIN":
+		s.query = true
+	default:
+		s.query = hasKeyword(query, "RETURNING")
+	}
+	return s, SQLITE_OK
+}
+
+// skipSpace skips whitespaces and comments.
+func skipSpace(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n\f\v")
+		switch {
+		case strings.HasPrefix(s, "--"):
+			if i := strings.IndexByte(s, '\n'); i >= 0 {
+				s = s[i+1:]
+			} else {
+				s = ""
+			}
+		case strings.HasPrefix(s, "/*"):
+			if i := strings.Index(s[2:], "*/"); i >= 0 {
+				s = s[i+4:]
+			} else {
+				s = ""
+			}
+		default:
+			return s
+		}
+	}
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func firstKeyword(s string) string {
+	s = skipSpace(s)
+	i := 0
+	for i < len(s) && isWordChar(s[i]) {
+		i++
+	}
+	return strings.ToUpper(s[:i])
+}
+
+// eachToken calls fn for each character of the query outside of quotes and comments.
+func eachToken(s string, fn func(i int)) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			if j := strings.Index(s[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(s)
+			}
+		default:
+			fn(i)
+		}
+	}
+}
+
+func hasKeyword(s, kw string) bool {
+	found := false
+	eachToken(s, func(i int) {
+		if found || (i > 0 && isWordChar(s[i-1])) || len(s)-i < len(kw) || !strings.EqualFold(s[i:i+len(kw)], kw) {
+			return
+		}
+		found = i+len(kw) == len(s) || !isWordChar(s[i+len(kw)])
+	})
+	return found
+}
+
+// parseParams returns names of statement parameters. Anonymous parameters have an empty name.
+func parseParams(s string) []string {
+	var params []string
+	skip := 0
+	eachToken(s, func(i int) {
+		if i < skip {
+			return
+		}
+		c := s[i]
+		if c != '?' && c != ':' && c != '@' && c != '$' {
+			return
+		}
+		j := i + 1
+		for j < len(s) && isWordChar(s[j]) {
+			j++
+		}
+		skip = j
+		name := s[i:j]
+		switch {
+		case c == '?' && j == i+1:
+			params = append(params, "")
+		case c == '?':
+			n, _ := strconv.Atoi(name[1:])
+			for len(params) < n {
+				params = append(params, "")
+			}
+		case j == i+1:
+			// not a parameter
+		default:
+			for _, p := range params {
+				if p == name {
+					return
+				}
+			}
+			params = append(params, name)
+		}
+	})
+	return params
+}
+
+// Sql implements sqlite3_sql.
+func Sql(s *Stmt) *byte {
+	if s == nil {
+		return nil
+	}
+	return libc.CString(s.sql)
+}
+
+func (s *Stmt) closeRows() {
+	if s.rows != nil {
+		s.rows.Close()
+		s.rows = nil
+	}
+	s.row = nil
+	s.done = false
+}
+
+// Finalize implements sqlite3_finalize.
+func Finalize(s *Stmt) int32 {
+	if s == nil {
+		return SQLITE_OK
+	}
+	s.closeRows()
+	if s.stmt != nil {
+		s.stmt.Close()
+		s.stmt = nil
+	}
+	return SQLITE_OK
+}
+
+// Reset implements sqlite3_reset. Bindings are preserved.
+func Reset(s *Stmt) int32 {
+	if s == nil {
+		return SQLITE_OK
+	}
+	s.closeRows()
+	return SQLITE_OK
+}
+
+// Step implements sqlite3_step. Like in SQLite, the statement is reset automatically after SQLITE_DONE.
+func Step(s *Stmt) int32 {
+	if s == nil || s.stmt == nil {
+		return SQLITE_MISUSE
+	}
+	d := s.db
+	if s.done {
+		s.closeRows()
+	}
+	ctx := context.Background()
+	if !s.query {
+		res, err := s.stmt.ExecContext(ctx, s.args...)
+		if err != nil {
+			return d.setErr(SQLITE_ERROR, err)
+		}
+		d.clearErr()
+		if n, err := res.RowsAffected(); err == nil {
+			d.changes = n
+			d.total += n
+		}
+		if id, err := res.LastInsertId(); err == nil {
+			d.lastID = id
+		}
+		s.done = true
+		return SQLITE_DONE
+	}
+	if s.rows == nil {
+		rows, err := s.stmt.QueryContext(ctx, s.args...)
+		if err != nil {
+			return d.setErr(SQLITE_ERROR, err)
+		}
+		cols, err := rows.Columns()
+		if err != nil {
+			rows.Close()
+			return d.setErr(SQLITE_ERROR, err)
+		}
+		s.rows, s.cols = rows, cols
+	}
+	s.row = nil
+	if !s.rows.Next() {
+		err := s.rows.Err()
+		s.rows.Close()
+		s.rows = nil
+		s.done = true
+		if err != nil {
+			return d.setErr(SQLITE_ERROR, err)
+		}
+		d.clearErr()
+		return SQLITE_DONE
+	}
+	row := make([]interface{}, len(s.cols))
+	ptrs := make([]interface{}, len(row))
+	for i := range row {
+		ptrs[i] = &row[i]
+	}
+	if err := s.rows.Scan(ptrs...); err != nil {
+		return d.setErr(SQLITE_ERROR, err)
+	}
+	s.row = row
+	d.clearErr()
+	return SQLITE_ROW
+}
+
+// BindParameterCount implements sqlite3_bind_parameter_count.
+func BindParameterCount(s *Stmt) int32 {
+	return int32(len(s.params))
+}
+
+// BindParameterIndex implements sqlite3_bind_parameter_index.
+func BindParameterIndex(s *Stmt, name string) int32 {
+	for i, p := range s.params {
+		if p != "" && p == name {
+			return int32(i + 1)
+		}
+	}
+	return 0
+}
+
+// BindParameterName implements sqlite3_bind_parameter_name.
+func BindParameterName(s *Stmt, i int32) *byte {
+	if i < 1 || int(i) > len(s.params) || s.params[i-1] == "" {
+		return nil
+	}
+	return libc.CString(s.params[i-1])
+}
+
+// ClearBindings implements sqlite3_clear_bindings.
+func ClearBindings(s *Stmt) int32 {
+	for i := range s.args {
+		s.args[i] = nil
+	}
+	return SQLITE_OK
+}
+
+func (s *Stmt) bind(i int32, v interface{}) int32 {
+	if s == nil || s.stmt == nil {
+		return SQLITE_MISUSE
+	}
+	if s.rows != nil {
+		return SQLITE_MISUSE
+	}
+	if i < 1 || int(i) > len(s.params) {
+		return SQLITE_RANGE
+	}
+	if len(s.args) < len(s.params) {
+		args := make([]interface{}, len(s.params))
+		copy(args, s.args)
+		s.args = args
+	}
+	s.args[i-1] = v
+	return SQLITE_OK
+}
+
+// BindNull implements sqlite3_bind_null.
+func BindNull(s *Stmt, i int32) int32 {
+	return s.bind(i, nil)
+}
+
+// BindInt implements sqlite3_bind_int.
+func BindInt(s *Stmt, i int32, v int32) int32 {
+	return s.bind(i, int64(v))
+}
+
+// BindInt64 implements sqlite3_bind_int64.
+func BindInt64(s *Stmt, i int32, v int64) int32 {
+	return s.bind(i, v)
+}
+
+// BindDouble implements sqlite3_bind_double.
+func BindDouble(s *Stmt, i int32, v float64) int32 {
+	return s.bind(i, v)
+}
+
+// BindText implements sqlite3_bind_text. The text is always copied, thus the destructor is never called.
+func BindText(s *Stmt, i int32, p *byte, n int32, _ Destructor) int32 {
+	if p == nil {
+		return s.bind(i, nil)
+	}
+	if n < 0 {
+		return s.bind(i, libc.GoString(p))
+	}
+	return s.bind(i, string(unsafe.Slice(p, n)))
+}
+
+// BindBlob implements sqlite3_bind_blob. The data is always copied, thus the destructor is never called.
+func BindBlob(s *Stmt, i int32, p unsafe.Pointer, n int32, _ Destructor) int32 {
+	if p == nil {
+		return s.bind(i, nil)
+	}
+	b := make([]byte, n)
+	copy(b, unsafe.Slice((*byte)(p), n))
+	return s.bind(i, b)
+}
+
+// ColumnCount implements sqlite3_column_count. For queries, it returns zero until Step is called.
+func ColumnCount(s *Stmt) int32 {
+	if s == nil {
+		return 0
+	}
+	return int32(len(s.cols))
+}
+
+// DataCount implements sqlite3_data_count.
+func DataCount(s *Stmt) int32 {
+	if s == nil {
+		return 0
+	}
+	return int32(len(s.row))
+}
+
+// ColumnName implements sqlite3_column_name.
+func ColumnName(s *Stmt, i int32) *byte {
+	if s == nil || i < 0 || int(i) >= len(s.cols) {
+		return nil
+	}
+	return libc.CString(s.cols[i])
+}
+
+func (s *Stmt) value(i int32) interface{} {
+	if s == nil || i < 0 || int(i) >= len(s.row) {
+		return nil
+	}
+	return s.row[i]
+}
+
+// ColumnType implements sqlite3_column_type.
+func ColumnType(s *Stmt, i int32) int32 {
+	switch s.value(i).(type) {
+	case nil:
+		return SQLITE_NULL
+	case int64, int32, int, bool:
+		return SQLITE_INTEGER
+	case float64, float32:
+		return SQLITE_FLOAT
+	case []byte:
+		return SQLITE_BLOB
+	default:
+		return SQLITE_TEXT
+	}
+}
+
+// ColumnInt implements sqlite3_column_int.
+func ColumnInt(s *Stmt, i int32) int32 {
+	return int32(ColumnInt64(s, i))
+}
+
+// ColumnInt64 implements sqlite3_column_int64.
+func ColumnInt64(s *Stmt, i int32) int64 {
+	switch v := s.value(i).(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return int64(v)
+	case float32:
+		return int64(v)
+	case nil:
+		return 0
+	default:
+		t := strings.TrimSpace(textValue(v))
+		if n, err := strconv.ParseInt(t, 10, 64); err == nil {
+			return n
+		}
+		return int64(parseNumber(t))
+	}
+}
+
+// ColumnDouble implements sqlite3_column_double.
+func ColumnDouble(s *Stmt, i int32) float64 {
+	switch v := s.value(i).(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case nil:
+		return 0
+	case string, []byte, time.Time:
+		return parseNumber(textValue(v))
+	default:
+		return float64(ColumnInt64(s, i))
+	}
+}
+
+// parseNumber parses the longest numeric prefix of the string, the same way SQLite converts text to numbers.
+func parseNumber(s string) float64 {
+	s = strings.TrimSpace(s)
+	for end := len(s); end > 0; end-- {
+		if v, err := strconv.ParseFloat(s[:end], 64); err == nil {
+			return v
+		}
+	}
+	return 0
+}
+
+// textValue converts the column value to text.
+func textValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		s := strconv.FormatFloat(v, 'g', 15, 64)
+		if !strings.ContainsAny(s, ".eEnN") {
+			s += ".0"
+		}
+		return s
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999999-07:00")
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int:
+		return strconv.Itoa(v)
+	case float32:
+		return textValue(float64(v))
+	}
+	return ""
+}
+
+// ColumnText implements sqlite3_column_text.
+func ColumnText(s *Stmt, i int32) *byte {
+	v := s.value(i)
+	if v == nil {
+		return nil
+	}
+	return libc.CString(textValue(v))
+}
+
+// ColumnBlob implements sqlite3_column_blob.
+func ColumnBlob(s *Stmt, i int32) unsafe.Pointer {
+	v := s.value(i)
+	if v == nil {
+		return nil
+	}
+	b, ok := v.([]byte)
+	if !ok {
+		b = []byte(textValue(v))
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	p := make([]byte, len(b))
+	copy(p, b)
+	return unsafe.Pointer(&p[0])
+}
+
+// ColumnBytes implements sqlite3_column_bytes.
+func ColumnBytes(s *Stmt, i int32) int32 {
+	switch v := s.value(i).(type) {
+	case nil:
+		return 0
+	case []byte:
+		return int32(len(v))
+	default:
+		return int32(len(textValue(v)))
+	}
+}
@@ -0,0 +1,86 @@
+package cssl
+
+import (
+	"encoding/hex"
+	"testing"
+	"unsafe"
+)
+
+var testData = []byte("The quick brown fox jumps over the lazy dog")
+
+func TestDigest(t *testing.T) {
+	cases := []struct {
+		name string
+		sum  func(p *byte, n int, md *byte) *byte
+		md   *MD
+		exp  string
+	}{
+		{"md5", MD5, EVPMd5(), "9e107d9d372bb6826bd81d3542a419d6"},
+		{"sha1", SHA1, EVPSha1(), "2fd4e1c67a2d28fced849ee1bb76e7391b93eb12"},
+		{"sha256", SHA256, EVPSha256(), "d7a8fbb307d7809469ca9abcb0082e4f8d5651e46d3cdb762d02d0bf37c9e592"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var md [EVP_MAX_MD_SIZE]byte
+			c.sum(&testData[0], len(testData), &md[0])
+			if s := hex.EncodeToString(md[:c.md.size]); s != c.exp {
+				t.Fatalf("unexpected digest: %s", s)
+			}
+
+			ctx := EVPMDCtxNew()
+			defer EVPMDCtxFree(ctx)
+			EVPDigestInitEx(ctx, c.md, nil)
+			EVPDigestUpdate(ctx, unsafe.Pointer(&testData[0]), 10)
+			EVPDigestUpdate(ctx, unsafe.Pointer(&testData[10]), len(testData)-10)
+			var n uint32
+			md = [EVP_MAX_MD_SIZE]byte{}
+			if EVPDigestFinalEx(ctx, &md[0], &n) != 1 || int32(n) != EVPMDSize(c.md) {
+				t.Fatalf("unexpected size: %d", n)
+			}
+			if s := hex.EncodeToString(md[:n]); s != c.exp {
+				t.Fatalf("unexpected EVP digest: %s", s)
+			}
+		})
+	}
+}
+
+func TestSHA256Ctx(t *testing.T) {
+	var (
+		ctx SHA256Ctx
+		md  [SHA256_DIGEST_LENGTH]byte
+	)
+	SHA256Init(&ctx)
+	SHA256Update(&ctx, unsafe.Pointer(&testData[0]), len(testData))
+	SHA256Final(&md[0], &ctx)
+	if s := hex.EncodeToString(md[:]); s != "d7a8fbb307d7809469ca9abcb0082e4f8d5651e46d3cdb762d02d0bf37c9e592" {
+		t.Fatalf("unexpected digest: %s", s)
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	const exp = "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	key := []byte("key")
+	var (
+		md [EVP_MAX_MD_SIZE]byte
+		n  uint32
+	)
+	HMAC(EVPSha256(), unsafe.Pointer(&key[0]), int32(len(key)), &testData[0], len(testData), &md[0], &n)
+	if s := hex.EncodeToString(md[:n]); s != exp {
+		t.Fatalf("unexpected HMAC: %s", s)
+	}
+	ctx := HMACCtxNew()
+	defer HMACCtxFree(ctx)
+	HMACInitEx(ctx, unsafe.Pointer(&key[0]), int32(len(key)), EVPSha256(), nil)
+	HMACUpdate(ctx, &testData[0], len(testData))
+	HMACFinal(ctx, &md[0], &n)
+	if s := hex.EncodeToString(md[:n]); s != exp {
+		t.Fatalf("unexpected HMAC: %s", s)
+	}
+	// reuse the key
+	HMACInitEx(ctx, nil, 0, nil, nil)
+	HMACUpdate(ctx, &testData[0], len(testData))
+	HMACFinal(ctx, &md[0], &n)
+	if s := hex.EncodeToString(md[:n]); s != exp {
+		t.Fatalf("unexpected HMAC: %s", s)
+	}
+}
@@ -0,0 +1,204 @@
+// Package cssl implements a subset of OpenSSL digest APIs (openssl/md5.h, openssl/sha.h, openssl/evp.h and
+// openssl/hmac.h) on top of Go crypto packages.
+//
+// Following OpenSSL conventions, functions return 1 on success and 0 on failure.
+package cssl
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"unsafe"
+)
+
+const (
+	MD5_DIGEST_LENGTH    = md5.Size
+	SHA_DIGEST_LENGTH    = sha1.Size
+	SHA224_DIGEST_LENGTH = sha256.Size224
+	SHA256_DIGEST_LENGTH = sha256.Size
+	SHA384_DIGEST_LENGTH = sha512.Size384
+	SHA512_DIGEST_LENGTH = sha512.Size
+)
+
+func bytesOf(p unsafe.Pointer, n int) []byte {
+	if p == nil || n <= 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(p), n)
+}
+
+func update(h hash.Hash, p unsafe.Pointer, n int) int32 {
+	if h == nil {
+		return 0
+	}
+	h.Write(bytesOf(p, n))
+	return 1
+}
+
+// final writes the digest to md and resets the hash.
+func final(h hash.Hash, md *byte) int32 {
+	if h == nil || md == nil {
+		return 0
+	}
+	copy(unsafe.Slice(md, h.Size()), h.Sum(nil))
+	h.Reset()
+	return 1
+}
+
+// oneShot computes the digest of the data. If md is nil, a static buffer is used, like in OpenSSL.
+func oneShot(h hash.Hash, p *byte, n int, md *byte) *byte {
+	if md == nil {
+		md = &make([]byte, h.Size())[0]
+	}
+	h.Write(bytesOf(unsafe.Pointer(p), n))
+	final(h, md)
+	return md
+}
+
+// MD5Ctx is a MD5 context (MD5_CTX).
+type MD5Ctx struct {
+	h hash.Hash
+}
+
+// MD5Init implements MD5_Init.
+func MD5Init(c *MD5Ctx) int32 {
+	c.h = md5.New()
+	return 1
+}
+
+// MD5Update implements MD5_Update.
+func MD5Update(c *MD5Ctx, data unsafe.Pointer, n int) int32 {
+	return update(c.h, data, n)
+}
+
+// MD5Final implements MD5_Final.
+func MD5Final(md *byte, c *MD5Ctx) int32 {
+	return final(c.h, md)
+}
+
+// MD5 implements MD5.
+func MD5(data *byte, n int, md *byte) *byte {
+	return oneShot(md5.New(), data, n, md)
+}
+
+// SHACtx is a SHA-1 context (SHA_CTX).
+type SHACtx struct {
+	h hash.Hash
+}
+
+// SHA1Init implements SHA1_Init.
+func SHA1Init(c *SHACtx) int32 {
+	c.h = sha1.New()
+	return 1
+}
+
+// SHA1Update implements SHA1_Update.
+func SHA1Update(c *SHACtx, data unsafe.Pointer, n int) int32 {
+	return update(c.h, data, n)
+}
+
+// SHA1Final implements SHA1_Final.
+func SHA1Final(md *byte, c *SHACtx) int32 {
+	return final(c.h, md)
+}
+
+// SHA1 implements SHA1.
+func SHA1(data *byte, n int, md *byte) *byte {
+	return oneShot(sha1.New(), data, n, md)
+}
+
+// SHA256Ctx is a SHA-224 or SHA-256 context (SHA256_CTX).
+type SHA256Ctx struct {
+	h hash.Hash
+}
+
+// SHA224Init implements SHA224_Init.
+func SHA224Init(c *SHA256Ctx) int32 {
+	c.h = sha256.New224()
+	return 1
+}
+
+// SHA224Update implements SHA224_Update.
+func SHA224Update(c *SHA256Ctx, data unsafe.Pointer, n int) int32 {
+	return update(c.h, data, n)
+}
+
+// SHA224Final implements SHA224_Final.
+func SHA224Final(md *byte, c *SHA256Ctx) int32 {
+	return final(c.h, md)
+}
+
+// SHA224 implements SHA224.
+func SHA224(data *byte, n int, md *byte) *byte {
+	return oneShot(sha256.New224(), data, n, md)
+}
+
+// SHA256Init implements SHA256_Init.
+func SHA256Init(c *SHA256Ctx) int32 {
+	c.h = sha256.New()
+	return 1
+}
+
+// SHA256Update implements SHA256_Update.
+func SHA256Update(c *SHA256Ctx, data unsafe.Pointer, n int) int32 {
+	return update(c.h, data, n)
+}
+
+// SHA256Final implements SHA256_Final.
+func SHA256Final(md *byte, c *SHA256Ctx) int32 {
+	return final(c.h, md)
+}
+
+// SHA256 implements SHA256.
+func SHA256(data *byte, n int, md *byte) *byte {
+	return oneShot(sha256.New(), data, n, md)
+}
+
+// SHA512Ctx is a SHA-384 or SHA-512 context (SHA512_CTX).
+type SHA512Ctx struct {
+	h hash.Hash
+}
+
+// SHA384Init implements SHA384_Init.
+func SHA384Init(c *SHA512Ctx) int32 {
+	c.h = sha512.New384()
+	return 1
+}
+
+// SHA384Update implements SHA384_Update.
+func SHA384Update(c *SHA512Ctx, data unsafe.Pointer, n int) int32 {
+	return update(c.h, data, n)
+}
+
+// SHA384Final implements SHA384_Final.
+func SHA384Final(md *byte, c *SHA512Ctx) int32 {
+	return final(c.h, md)
+}
+
+// SHA384 implements SHA384.
+func SHA384(data *byte, n int, md *byte) *byte {
+	return oneShot(sha512.New384(), data, n, md)
+}
+
+// SHA512Init implements SHA512_Init.
+func SHA512Init(c *SHA512Ctx) int32 {
+	c.h = sha512.New()
+	return 1
+}
+
+// SHA512Update implements SHA512_Update.
+func SHA512Update(c *SHA512Ctx, data unsafe.Pointer, n int) int32 {
+	return update(c.h, data, n)
+}
+
+// SHA512Final implements SHA512_Final.
+func SHA512Final(md *byte, c *SHA512Ctx) int32 {
+	return final(c.h, md)
+}
+
+// SHA512 implements SHA512.
+func SHA512(data *byte, n int, md *byte) *byte {
+	return oneShot(sha512.New(), data, n, md)
+}
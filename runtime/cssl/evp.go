@@ -0,0 +1,197 @@
+package cssl
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"unsafe"
+)
+
+const EVP_MAX_MD_SIZE = 64
+
+// MD is a message digest algorithm (EVP_MD).
+type MD struct {
+	size int
+	new  func() hash.Hash
+}
+
+var (
+	mdMD5    = &MD{size: md5.Size, new: md5.New}
+	mdSHA1   = &MD{size: sha1.Size, new: sha1.New}
+	mdSHA224 = &MD{size: sha256.Size224, new: sha256.New224}
+	mdSHA256 = &MD{size: sha256.Size, new: sha256.New}
+	mdSHA384 = &MD{size: sha512.Size384, new: sha512.New384}
+	mdSHA512 = &MD{size: sha512.Size, new: sha512.New}
+)
+
+// EVPMd5 implements EVP_md5.
+func EVPMd5() *MD { return mdMD5 }
+
+// EVPSha1 implements EVP_sha1.
+func EVPSha1() *MD { return mdSHA1 }
+
+// EVPSha224 implements EVP_sha224.
+func EVPSha224() *MD { return mdSHA224 }
+
+// EVPSha256 implements EVP_sha256.
+func EVPSha256() *MD { return mdSHA256 }
+
+// EVPSha384 implements EVP_sha384.
+func EVPSha384() *MD { return mdSHA384 }
+
+// EVPSha512 implements EVP_sha512.
+func EVPSha512() *MD { return mdSHA512 }
+
+// EVPMDSize implements EVP_MD_size.
+func EVPMDSize(md *MD) int32 {
+	if md == nil {
+		return -1
+	}
+	return int32(md.size)
+}
+
+// Engine is an OpenSSL engine (ENGINE). Engines are not supported, and are always ignored.
+type Engine struct{}
+
+// MDCtx is a digest context (EVP_MD_CTX).
+type MDCtx struct {
+	md *MD
+	h  hash.Hash
+}
+
+// EVPMDCtxNew implements EVP_MD_CTX_new.
+func EVPMDCtxNew() *MDCtx {
+	return &MDCtx{}
+}
+
+// EVPMDCtxFree implements EVP_MD_CTX_free.
+func EVPMDCtxFree(c *MDCtx) {}
+
+// EVPMDCtxReset implements EVP_MD_CTX_reset.
+func EVPMDCtxReset(c *MDCtx) int32 {
+	*c = MDCtx{}
+	return 1
+}
+
+// EVPMDCtxMD implements EVP_MD_CTX_md.
+func EVPMDCtxMD(c *MDCtx) *MD {
+	return c.md
+}
+
+// EVPDigestInitEx implements EVP_DigestInit_ex.
+func EVPDigestInitEx(c *MDCtx, md *MD, _ *Engine) int32 {
+	if c == nil || md == nil {
+		return 0
+	}
+	c.md, c.h = md, md.new()
+	return 1
+}
+
+// EVPDigestInit implements EVP_DigestInit.
+func EVPDigestInit(c *MDCtx, md *MD) int32 {
+	return EVPDigestInitEx(c, md, nil)
+}
+
+// EVPDigestUpdate implements EVP_DigestUpdate.
+func EVPDigestUpdate(c *MDCtx, data unsafe.Pointer, n int) int32 {
+	return update(c.h, data, n)
+}
+
+// EVPDigestFinalEx implements EVP_DigestFinal_ex.
+func EVPDigestFinalEx(c *MDCtx, md *byte, size *uint32) int32 {
+	if c.h == nil || final(c.h, md) == 0 {
+		return 0
+	}
+	if size != nil {
+		*size = uint32(c.md.size)
+	}
+	return 1
+}
+
+// EVPDigestFinal implements EVP_DigestFinal.
+func EVPDigestFinal(c *MDCtx, md *byte, size *uint32) int32 {
+	return EVPDigestFinalEx(c, md, size)
+}
+
+// EVPDigest implements EVP_Digest.
+func EVPDigest(data unsafe.Pointer, n int, md *byte, size *uint32, typ *MD, _ *Engine) int32 {
+	if typ == nil || md == nil {
+		return 0
+	}
+	oneShot(typ.new(), (*byte)(data), n, md)
+	if size != nil {
+		*size = uint32(typ.size)
+	}
+	return 1
+}
+
+// HMACCtx is a HMAC context (HMAC_CTX).
+type HMACCtx struct {
+	md  *MD
+	key []byte
+	h   hash.Hash
+}
+
+// HMACCtxNew implements HMAC_CTX_new.
+func HMACCtxNew() *HMACCtx {
+	return &HMACCtx{}
+}
+
+// HMACCtxFree implements HMAC_CTX_free.
+func HMACCtxFree(c *HMACCtx) {}
+
+// HMACCtxReset implements HMAC_CTX_reset.
+func HMACCtxReset(c *HMACCtx) int32 {
+	*c = HMACCtx{}
+	return 1
+}
+
+// HMACInitEx implements HMAC_Init_ex. If key is nil, the previous key is reused. If md is nil, the previous
+// digest is reused.
+func HMACInitEx(c *HMACCtx, key unsafe.Pointer, keyLen int32, md *MD, _ *Engine) int32 {
+	if c == nil {
+		return 0
+	}
+	if md != nil {
+		c.md = md
+	}
+	if key != nil {
+		c.key = append([]byte{}, bytesOf(key, int(keyLen))...)
+	}
+	if c.md == nil {
+		return 0
+	}
+	c.h = hmac.New(c.md.new, c.key)
+	return 1
+}
+
+// HMACUpdate implements HMAC_Update.
+func HMACUpdate(c *HMACCtx, data *byte, n int) int32 {
+	return update(c.h, unsafe.Pointer(data), n)
+}
+
+// HMACFinal implements HMAC_Final.
+func HMACFinal(c *HMACCtx, md *byte, size *uint32) int32 {
+	if c.h == nil || final(c.h, md) == 0 {
+		return 0
+	}
+	if size != nil {
+		*size = uint32(c.md.size)
+	}
+	return 1
+}
+
+// HMAC implements HMAC. If md is nil, a static buffer is used, like in OpenSSL.
+func HMAC(typ *MD, key unsafe.Pointer, keyLen int32, data *byte, n int, md *byte, size *uint32) *byte {
+	if typ == nil {
+		return nil
+	}
+	md = oneShot(hmac.New(typ.new, bytesOf(key, int(keyLen))), data, n, md)
+	if size != nil {
+		*size = uint32(typ.size)
+	}
+	return md
+}
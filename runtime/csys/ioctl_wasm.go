@@ -0,0 +1,13 @@
+package csys
+
+import (
+	"syscall"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// Ioctl is not supported on js and wasip1; it always fails with ENOTTY.
+func Ioctl(fd uintptr, req uintptr, args ...interface{}) int32 {
+	libc.SetErr(syscall.ENOTTY)
+	return -1
+}
@@ -1,11 +1,19 @@
 package csys
 
 import (
+	"time"
 	"unsafe"
 
 	"github.com/gotranspile/cxgo/runtime/libc"
 )
 
+// GetTimeOfDay uses the Go clock instead of the syscall, thus it's also available on js and wasip1.
+// The timezone argument is obsolete and ignored.
 func GetTimeOfDay(t *libc.TimeVal, p unsafe.Pointer) int32 {
-	panic("TODO")
+	if t == nil {
+		return 0
+	}
+	now := time.Now()
+	*t = libc.TimeVal{Sec: libc.Time(now.Unix()), USec: int64(now.Nanosecond() / 1000)}
+	return 0
 }
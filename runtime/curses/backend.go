@@ -0,0 +1,277 @@
+package curses
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Cell is a character on the terminal.
+type Cell struct {
+	Ch     rune
+	Attr   uint32 // attributes, without the color pair
+	Fg, Bg int16  // colors, or -1 for default ones
+}
+
+// Backend is a terminal used by curses.
+type Backend interface {
+	// Init prepares the terminal, for example, switches it to the raw mode.
+	Init() error
+	// Fini restores the terminal.
+	Fini()
+	// Size returns the size of the terminal.
+	Size() (w, h int)
+	// SetCell sets the character at a given position. It should be visible after Flush.
+	SetCell(x, y int, c Cell)
+	// ShowCursor sets the position and the visibility of the cursor.
+	ShowCursor(x, y int, visible bool)
+	// Flush shows all changes on the terminal.
+	Flush()
+	// ReadKey waits for a key for a given time, or forever if the timeout is negative.
+	// Special keys are returned as KEY_* codes.
+	ReadKey(timeout time.Duration) (int32, bool)
+	// Beep rings the terminal bell.
+	Beep()
+}
+
+// NewBackend creates a terminal for Initscr. By default, it uses ANSI escape sequences on stdin and stdout.
+// It can be replaced, for example, to use a terminal library.
+var NewBackend = func() Backend {
+	return NewANSI(os.Stdin, os.Stdout)
+}
+
+// NewANSI creates a terminal backend that uses ANSI escape sequences.
+func NewANSI(in *os.File, out *os.File) Backend {
+	return &ansiTerm{in: in, out: out, w: bufio.NewWriter(out)}
+}
+
+type ansiTerm struct {
+	in, out *os.File
+	w       *bufio.Writer
+	restore func()
+	keys    chan byte
+	pending []byte
+	width   int
+	height  int
+	front   []Cell
+	back    []Cell
+	cx, cy  int
+	cursor  bool
+}
+
+func (t *ansiTerm) Init() error {
+	restore, err := makeRaw(t.in)
+	if err != nil {
+		return err
+	}
+	t.restore = restore
+	t.width, t.height = termSize(t.out)
+	t.front = make([]Cell, t.width*t.height)
+	t.back = make([]Cell, t.width*t.height)
+	for i := range t.back {
+		t.back[i] = Cell{Ch: ' ', Fg: -1, Bg: -1}
+	}
+	t.keys = make(chan byte, 64)
+	go t.readLoop(t.in)
+	// switch to the alternative screen and clear it
+	t.w.WriteString("\x1b[?1049h\x1b[0m\x1b[2J")
+	return t.w.Flush()
+}
+
+func (t *ansiTerm) readLoop(r io.Reader) {
+	var buf [64]byte
+	for {
+		n, err := r.Read(buf[:])
+		for _, b := range buf[:n] {
+			t.keys <- b
+		}
+		if err != nil {
+			close(t.keys)
+			return
+		}
+	}
+}
+
+func (t *ansiTerm) Fini() {
+	t.w.WriteString("\x1b[0m\x1b[?25h\x1b[?1049l")
+	t.w.Flush()
+	if t.restore != nil {
+		t.restore()
+	}
+}
+
+func (t *ansiTerm) Size() (int, int) {
+	return t.width, t.height
+}
+
+func (t *ansiTerm) SetCell(x, y int, c Cell) {
+	if x < 0 || y < 0 || x >= t.width || y >= t.height {
+		return
+	}
+	t.back[y*t.width+x] = c
+}
+
+func (t *ansiTerm) ShowCursor(x, y int, visible bool) {
+	t.cx, t.cy, t.cursor = x, y, visible
+}
+
+func (t *ansiTerm) sgr(c Cell) string {
+	s := "\x1b[0"
+	if c.Attr&A_BOLD != 0 {
+		s += ";1"
+	}
+	if c.Attr&A_DIM != 0 {
+		s += ";2"
+	}
+	if c.Attr&A_UNDERLINE != 0 {
+		s += ";4"
+	}
+	if c.Attr&A_BLINK != 0 {
+		s += ";5"
+	}
+	if c.Attr&(A_REVERSE|A_STANDOUT) != 0 {
+		s += ";7"
+	}
+	if c.Fg >= 0 {
+		s += ";" + strconv.Itoa(30+int(c.Fg%8))
+	}
+	if c.Bg >= 0 {
+		s += ";" + strconv.Itoa(40+int(c.Bg%8))
+	}
+	return s + "m"
+}
+
+func (t *ansiTerm) Flush() {
+	t.w.WriteString("\x1b[?25l")
+	var last *Cell
+	for i, c := range t.back {
+		if t.front[i] == c {
+			continue
+		}
+		t.front[i] = c
+		fmt.Fprintf(t.w, "\x1b[%d;%dH", i/t.width+1, i%t.width+1)
+		if last == nil || last.Attr != c.Attr || last.Fg != c.Fg || last.Bg != c.Bg {
+			t.w.WriteString(t.sgr(c))
+			last = &t.back[i]
+		}
+		t.w.WriteRune(c.Ch)
+	}
+	fmt.Fprintf(t.w, "\x1b[%d;%dH", t.cy+1, t.cx+1)
+	if t.cursor {
+		t.w.WriteString("\x1b[?25h")
+	}
+	t.w.Flush()
+}
+
+func (t *ansiTerm) Beep() {
+	t.w.WriteString("\a")
+	t.w.Flush()
+}
+
+// escDelay is the time to wait for the rest of an escape sequence.
+const escDelay = 25 * time.Millisecond
+
+func (t *ansiTerm) readByte(timeout time.Duration) (byte, bool) {
+	if len(t.pending) != 0 {
+		b := t.pending[0]
+		t.pending = t.pending[1:]
+		return b, true
+	}
+	if timeout < 0 {
+		b, ok := <-t.keys
+		return b, ok
+	}
+	select {
+	case b, ok := <-t.keys:
+		return b, ok
+	case <-time.After(timeout):
+		return 0, false
+	}
+}
+
+func (t *ansiTerm) ReadKey(timeout time.Duration) (int32, bool) {
+	b, ok := t.readByte(timeout)
+	if !ok {
+		return 0, false
+	}
+	switch b {
+	case 0x7f:
+		return KEY_BACKSPACE, true
+	case 0x1b:
+	default:
+		return int32(b), true
+	}
+	b, ok = t.readByte(escDelay)
+	if !ok {
+		return 0x1b, true
+	} else if b != '[' && b != 'O' {
+		// not an escape sequence, return the byte on the next call
+		t.pending = append(t.pending, b)
+		return 0x1b, true
+	}
+	var num int32
+	for {
+		b, ok = t.readByte(escDelay)
+		if !ok {
+			return 0x1b, true
+		}
+		if b < '0' || b > '9' {
+			break
+		}
+		num = num*10 + int32(b-'0')
+	}
+	switch b {
+	case 'A':
+		return KEY_UP, true
+	case 'B':
+		return KEY_DOWN, true
+	case 'C':
+		return KEY_RIGHT, true
+	case 'D':
+		return KEY_LEFT, true
+	case 'H':
+		return KEY_HOME, true
+	case 'F':
+		return KEY_END, true
+	case 'P', 'Q', 'R', 'S':
+		return KEY_F0 + 1 + int32(b-'P'), true
+	case '~':
+		switch num {
+		case 1, 7:
+			return KEY_HOME, true
+		case 2:
+			return KEY_IC, true
+		case 3:
+			return KEY_DC, true
+		case 4, 8:
+			return KEY_END, true
+		case 5:
+			return KEY_PPAGE, true
+		case 6:
+			return KEY_NPAGE, true
+		case 15:
+			return KEY_F0 + 5, true
+		case 17, 18, 19, 20, 21:
+			return KEY_F0 + 6 + num - 17, true
+		case 23, 24:
+			return KEY_F0 + 11 + num - 23, true
+		}
+	}
+	return 0x1b, true
+}
+
+// envSize returns the terminal size from LINES and COLUMNS env variables, or the default 80x24 size.
+func envSize() (int, int) {
+	w, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	h, _ := strconv.Atoi(os.Getenv("LINES"))
+	if w <= 0 {
+		w = 80
+	}
+	if h <= 0 {
+		h = 24
+	}
+	return w, h
+}
@@ -0,0 +1,479 @@
+// Package curses implements a subset of the curses library on top of a terminal Backend.
+//
+// The default backend uses ANSI escape sequences. A backend for a different terminal library can be set with NewBackend.
+// Input is always in cbreak mode: keys are available immediately, and special keys are always decoded.
+package curses
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/gotranspile/cxgo/runtime/stdio"
+)
+
+const (
+	OK  = 0
+	ERR = -1
+)
+
+// Attributes of characters, the same as in ncurses.
+const (
+	A_NORMAL     = 0
+	A_CHARTEXT   = 0xff
+	A_COLOR      = 0xff << 8
+	A_STANDOUT   = 1 << 16
+	A_UNDERLINE  = 1 << 17
+	A_REVERSE    = 1 << 18
+	A_BLINK      = 1 << 19
+	A_DIM        = 1 << 20
+	A_BOLD       = 1 << 21
+	A_ATTRIBUTES = 0xffffff00
+)
+
+const (
+	COLOR_BLACK = iota
+	COLOR_RED
+	COLOR_GREEN
+	COLOR_YELLOW
+	COLOR_BLUE
+	COLOR_MAGENTA
+	COLOR_CYAN
+	COLOR_WHITE
+)
+
+// Codes of special keys, the same as in ncurses.
+const (
+	KEY_DOWN      = 0402
+	KEY_UP        = 0403
+	KEY_LEFT      = 0404
+	KEY_RIGHT     = 0405
+	KEY_HOME      = 0406
+	KEY_BACKSPACE = 0407
+	KEY_F0        = 0410
+	KEY_DC        = 0512
+	KEY_IC        = 0513
+	KEY_NPAGE     = 0522
+	KEY_PPAGE     = 0523
+	KEY_ENTER     = 0527
+	KEY_END       = 0550
+	KEY_RESIZE    = 0632
+)
+
+var (
+	// LINES is the number of lines on the screen. It is set by Initscr.
+	LINES int32
+	// COLS is the number of columns on the screen. It is set by Initscr.
+	COLS int32
+	// Stdscr is the window for the whole screen. It is set by Initscr.
+	Stdscr *Window
+)
+
+var scr *screen
+
+type screen struct {
+	be     Backend
+	echo   bool
+	cursor int32
+	colors bool
+	pairs  map[int16][2]int16
+}
+
+type cell struct {
+	ch   rune
+	attr uint32
+}
+
+// Window is a rectangular area of the screen (WINDOW).
+type Window struct {
+	y, x   int
+	h, w   int
+	cy, cx int
+	attr   uint32
+	delay  int32
+	cells  []cell
+}
+
+func newWindow(h, w, y, x int) *Window {
+	win := &Window{y: y, x: x, h: h, w: w, delay: -1, cells: make([]cell, h*w)}
+	win.erase()
+	return win
+}
+
+// Initscr initializes the terminal and returns the window for the whole screen.
+func Initscr() *Window {
+	be := NewBackend()
+	if err := be.Init(); err != nil {
+		panic(err)
+	}
+	scr = &screen{be: be, echo: true, cursor: 1, pairs: make(map[int16][2]int16)}
+	w, h := be.Size()
+	LINES, COLS = int32(h), int32(w)
+	Stdscr = newWindow(h, w, 0, 0)
+	return Stdscr
+}
+
+// Endwin restores the terminal.
+func Endwin() int32 {
+	if scr == nil {
+		return ERR
+	}
+	scr.be.Fini()
+	scr = nil
+	return OK
+}
+
+// Echo enables echoing of typed characters in Getch.
+func Echo() int32 {
+	scr.echo = true
+	return OK
+}
+
+// Noecho disables echoing of typed characters in Getch.
+func Noecho() int32 {
+	scr.echo = false
+	return OK
+}
+
+// Cbreak is a no-op, since input is always in cbreak mode.
+func Cbreak() int32 { return OK }
+
+// Nocbreak is a no-op, since input is always in cbreak mode.
+func Nocbreak() int32 { return OK }
+
+// Raw is the same as Cbreak.
+func Raw() int32 { return OK }
+
+// Noraw is the same as Nocbreak.
+func Noraw() int32 { return OK }
+
+// Keypad is a no-op, since special keys are always decoded.
+func Keypad(w *Window, on bool) int32 { return OK }
+
+// CursSet sets the cursor visibility and returns the previous one.
+func CursSet(v int32) int32 {
+	prev := scr.cursor
+	scr.cursor = v
+	return prev
+}
+
+// HasColors reports if the terminal supports colors.
+func HasColors() bool {
+	return true
+}
+
+// StartColor enables colors.
+func StartColor() int32 {
+	scr.colors = true
+	return OK
+}
+
+// InitPair sets colors of the color pair.
+func InitPair(pair, fg, bg int16) int32 {
+	if pair <= 0 || pair > 0xff {
+		return ERR
+	}
+	scr.pairs[pair] = [2]int16{fg, bg}
+	return OK
+}
+
+// ColorPair returns attributes for a color pair.
+func ColorPair(n int32) uint32 {
+	return uint32(n<<8) & A_COLOR
+}
+
+// Beep rings the terminal bell.
+func Beep() int32 {
+	scr.be.Beep()
+	return OK
+}
+
+// Napms sleeps for a given number of milliseconds.
+func Napms(ms int32) int32 {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+	return OK
+}
+
+// Newwin creates a new window. Zero height or width extends the window to the edge of the screen.
+func Newwin(h, w, y, x int32) *Window {
+	if h == 0 {
+		h = LINES - y
+	}
+	if w == 0 {
+		w = COLS - x
+	}
+	if h <= 0 || w <= 0 || y < 0 || x < 0 {
+		return nil
+	}
+	return newWindow(int(h), int(w), int(y), int(x))
+}
+
+// Delwin deletes the window.
+func Delwin(w *Window) int32 {
+	if w == nil || w == Stdscr {
+		return ERR
+	}
+	w.cells = nil
+	return OK
+}
+
+func (w *Window) erase() {
+	for i := range w.cells {
+		w.cells[i] = cell{ch: ' '}
+	}
+	w.cy, w.cx = 0, 0
+}
+
+// Werase clears the window.
+func Werase(w *Window) int32 {
+	w.erase()
+	return OK
+}
+
+// Wclear clears the window.
+func Wclear(w *Window) int32 {
+	return Werase(w)
+}
+
+// Wclrtoeol clears the window from the cursor to the end of the line.
+func Wclrtoeol(w *Window) int32 {
+	for x := w.cx; x < w.w; x++ {
+		w.cells[w.cy*w.w+x] = cell{ch: ' '}
+	}
+	return OK
+}
+
+// Wmove moves the cursor of the window.
+func Wmove(w *Window, y, x int32) int32 {
+	if y < 0 || x < 0 || int(y) >= w.h || int(x) >= w.w {
+		return ERR
+	}
+	w.cy, w.cx = int(y), int(x)
+	return OK
+}
+
+// Waddch adds a character to the window. Attributes of the character are combined with the attributes of the window.
+func Waddch(w *Window, ch uint32) int32 {
+	c := rune(ch & A_CHARTEXT)
+	attr := (ch & A_ATTRIBUTES) | w.attr
+	return w.add(c, attr)
+}
+
+func (w *Window) add(c rune, attr uint32) int32 {
+	if w.cy >= w.h {
+		return ERR
+	}
+	switch c {
+	case '\n':
+		Wclrtoeol(w)
+		return w.newline()
+	case '\r':
+		w.cx = 0
+		return OK
+	case '\b':
+		if w.cx > 0 {
+			w.cx--
+		}
+		return OK
+	case '\t':
+		for n := 8 - w.cx%8; n > 0; n-- {
+			if w.add(' ', attr) != OK {
+				return ERR
+			}
+		}
+		return OK
+	}
+	w.cells[w.cy*w.w+w.cx] = cell{ch: c, attr: attr}
+	w.cx++
+	if w.cx >= w.w {
+		return w.newline()
+	}
+	return OK
+}
+
+func (w *Window) newline() int32 {
+	w.cx = 0
+	if w.cy+1 >= w.h {
+		return ERR
+	}
+	w.cy++
+	return OK
+}
+
+// Waddstr adds a string to the window.
+func Waddstr(w *Window, s string) int32 {
+	for _, c := range s {
+		if w.add(c, w.attr) != OK {
+			return ERR
+		}
+	}
+	return OK
+}
+
+// Wprintw adds a formatted string to the window.
+func Wprintw(w *Window, format string, args ...interface{}) int32 {
+	var buf bytes.Buffer
+	if _, err := stdio.FprintfGo(&buf, format, args...); err != nil {
+		return ERR
+	}
+	return Waddstr(w, buf.String())
+}
+
+// Box draws a border around the window. Zero characters are replaced with default ones.
+func Box(w *Window, vert, hor uint32) int32 {
+	if vert == 0 {
+		vert = '|'
+	}
+	if hor == 0 {
+		hor = '-'
+	}
+	set := func(y, x int, ch uint32) {
+		w.cells[y*w.w+x] = cell{ch: rune(ch & A_CHARTEXT), attr: (ch & A_ATTRIBUTES) | w.attr}
+	}
+	for x := 0; x < w.w; x++ {
+		set(0, x, hor)
+		set(w.h-1, x, hor)
+	}
+	for y := 0; y < w.h; y++ {
+		set(y, 0, vert)
+		set(y, w.w-1, vert)
+	}
+	for _, p := range [][2]int{{0, 0}, {0, w.w - 1}, {w.h - 1, 0}, {w.h - 1, w.w - 1}} {
+		set(p[0], p[1], '+')
+	}
+	return OK
+}
+
+// Wattron enables attributes for characters added to the window.
+func Wattron(w *Window, attr uint32) int32 {
+	if attr&A_COLOR != 0 {
+		w.attr &^= A_COLOR
+	}
+	w.attr |= attr
+	return OK
+}
+
+// Wattroff disables attributes for characters added to the window.
+func Wattroff(w *Window, attr uint32) int32 {
+	w.attr &^= attr
+	return OK
+}
+
+// Wattrset sets attributes for characters added to the window.
+func Wattrset(w *Window, attr uint32) int32 {
+	w.attr = attr
+	return OK
+}
+
+// Wtimeout sets the delay of Wgetch in milliseconds. Negative delay blocks until a key is pressed.
+func Wtimeout(w *Window, ms int32) {
+	w.delay = ms
+}
+
+// Nodelay makes Wgetch non-blocking.
+func Nodelay(w *Window, on bool) int32 {
+	if on {
+		w.delay = 0
+	} else {
+		w.delay = -1
+	}
+	return OK
+}
+
+// Wrefresh draws the window on the terminal.
+func Wrefresh(w *Window) int32 {
+	for y := 0; y < w.h; y++ {
+		for x := 0; x < w.w; x++ {
+			c := w.cells[y*w.w+x]
+			scr.be.SetCell(w.x+x, w.y+y, scr.cell(c))
+		}
+	}
+	scr.be.ShowCursor(w.x+w.cx, w.y+w.cy, scr.cursor != 0)
+	scr.be.Flush()
+	return OK
+}
+
+func (s *screen) cell(c cell) Cell {
+	out := Cell{Ch: c.ch, Attr: c.attr &^ A_COLOR, Fg: -1, Bg: -1}
+	if s.colors {
+		if p, ok := s.pairs[int16((c.attr&A_COLOR)>>8)]; ok {
+			out.Fg, out.Bg = p[0], p[1]
+		}
+	}
+	return out
+}
+
+// Wgetch refreshes the window and waits for a key. It returns ERR if no key was pressed before the timeout.
+func Wgetch(w *Window) int32 {
+	Wrefresh(w)
+	timeout := time.Duration(w.delay) * time.Millisecond
+	key, ok := scr.be.ReadKey(timeout)
+	if !ok {
+		return ERR
+	}
+	if scr.echo && key < 0x100 {
+		w.add(rune(key), w.attr)
+		Wrefresh(w)
+	}
+	return key
+}
+
+// Getmaxy returns the height of the window.
+func Getmaxy(w *Window) int32 { return int32(w.h) }
+
+// Getmaxx returns the width of the window.
+func Getmaxx(w *Window) int32 { return int32(w.w) }
+
+// Getcury returns the cursor line of the window.
+func Getcury(w *Window) int32 { return int32(w.cy) }
+
+// Getcurx returns the cursor column of the window.
+func Getcurx(w *Window) int32 { return int32(w.cx) }
+
+// Mvwaddstr moves the cursor and adds a string to the window.
+func Mvwaddstr(w *Window, y, x int32, s string) int32 {
+	if Wmove(w, y, x) != OK {
+		return ERR
+	}
+	return Waddstr(w, s)
+}
+
+// Mvwaddch moves the cursor and adds a character to the window.
+func Mvwaddch(w *Window, y, x int32, ch uint32) int32 {
+	if Wmove(w, y, x) != OK {
+		return ERR
+	}
+	return Waddch(w, ch)
+}
+
+// Mvwprintw moves the cursor and adds a formatted string to the window.
+func Mvwprintw(w *Window, y, x int32, format string, args ...interface{}) int32 {
+	if Wmove(w, y, x) != OK {
+		return ERR
+	}
+	return Wprintw(w, format, args...)
+}
+
+// Functions for Stdscr.
+
+func Refresh() int32                      { return Wrefresh(Stdscr) }
+func Clear() int32                        { return Wclear(Stdscr) }
+func Erase() int32                        { return Werase(Stdscr) }
+func Clrtoeol() int32                     { return Wclrtoeol(Stdscr) }
+func Move(y, x int32) int32               { return Wmove(Stdscr, y, x) }
+func Addch(ch uint32) int32               { return Waddch(Stdscr, ch) }
+func Addstr(s string) int32               { return Waddstr(Stdscr, s) }
+func Mvaddch(y, x int32, ch uint32) int32 { return Mvwaddch(Stdscr, y, x, ch) }
+func Mvaddstr(y, x int32, s string) int32 { return Mvwaddstr(Stdscr, y, x, s) }
+func Attron(attr uint32) int32            { return Wattron(Stdscr, attr) }
+func Attroff(attr uint32) int32           { return Wattroff(Stdscr, attr) }
+func Attrset(attr uint32) int32           { return Wattrset(Stdscr, attr) }
+func Timeout(ms int32)                    { Wtimeout(Stdscr, ms) }
+func Getch() int32                        { return Wgetch(Stdscr) }
+
+func Printw(format string, args ...interface{}) int32 {
+	return Wprintw(Stdscr, format, args...)
+}
+
+func Mvprintw(y, x int32, format string, args ...interface{}) int32 {
+	return Mvwprintw(Stdscr, y, x, format, args...)
+}
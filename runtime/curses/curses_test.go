@@ -0,0 +1,73 @@
+package curses
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeTerm struct {
+	cells [3][10]Cell
+	keys  []int32
+}
+
+func (t *fakeTerm) Init() error                 { return nil }
+func (t *fakeTerm) Fini()                       {}
+func (t *fakeTerm) Size() (int, int)            { return 10, 3 }
+func (t *fakeTerm) SetCell(x, y int, c Cell)    { t.cells[y][x] = c }
+func (t *fakeTerm) ShowCursor(x, y int, _ bool) {}
+func (t *fakeTerm) Flush()                      {}
+func (t *fakeTerm) Beep()                       {}
+
+func (t *fakeTerm) ReadKey(time.Duration) (int32, bool) {
+	if len(t.keys) == 0 {
+		return 0, false
+	}
+	k := t.keys[0]
+	t.keys = t.keys[1:]
+	return k, true
+}
+
+func (t *fakeTerm) line(y int) string {
+	var sb strings.Builder
+	for _, c := range t.cells[y] {
+		sb.WriteRune(c.Ch)
+	}
+	return sb.String()
+}
+
+func TestCurses(t *testing.T) {
+	term := &fakeTerm{keys: []int32{'a', KEY_UP}}
+	NewBackend = func() Backend { return term }
+	Initscr()
+	defer Endwin()
+	if LINES != 3 || COLS != 10 {
+		t.Fatalf("unexpected size: %dx%d", COLS, LINES)
+	}
+	StartColor()
+	InitPair(1, COLOR_RED, COLOR_BLACK)
+	Printw("n=%d\n", 42)
+	Attron(A_BOLD | ColorPair(1))
+	Mvaddstr(1, 2, "hi")
+	Attroff(A_BOLD | ColorPair(1))
+	Refresh()
+	if l := term.line(0); l != "n=42      " {
+		t.Fatalf("unexpected line: %q", l)
+	}
+	if c := term.cells[1][2]; c.Ch != 'h' || c.Attr != A_BOLD || c.Fg != COLOR_RED || c.Bg != COLOR_BLACK {
+		t.Fatalf("unexpected cell: %+v", c)
+	}
+	if c := Getch(); c != 'a' {
+		t.Fatalf("unexpected key: %d", c)
+	}
+	if l := term.line(1); l != "  hia     " {
+		t.Fatalf("expected echo: %q", l)
+	}
+	Noecho()
+	if c := Getch(); c != KEY_UP {
+		t.Fatalf("unexpected key: %d", c)
+	}
+	if c := Getch(); c != ERR {
+		t.Fatalf("expected no key: %d", c)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build linux
+// +build linux
+
+package curses
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func ioctl(f *os.File, req uintptr, p unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(p))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// makeRaw switches the terminal to cbreak mode without echo, and returns a function to restore it.
+// It is a no-op if the file is not a terminal.
+func makeRaw(f *os.File) (func(), error) {
+	var old syscall.Termios
+	if err := ioctl(f, syscall.TCGETS, unsafe.Pointer(&old)); err != nil {
+		return func() {}, nil
+	}
+	t := old
+	t.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+	if err := ioctl(f, syscall.TCSETS, unsafe.Pointer(&t)); err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = ioctl(f, syscall.TCSETS, unsafe.Pointer(&old))
+	}, nil
+}
+
+func termSize(f *os.File) (int, int) {
+	var ws struct {
+		Row, Col, X, Y uint16
+	}
+	if err := ioctl(f, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil || ws.Col == 0 || ws.Row == 0 {
+		return envSize()
+	}
+	return int(ws.Col), int(ws.Row)
+}
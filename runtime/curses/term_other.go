@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package curses
+
+import "os"
+
+// makeRaw is a no-op on this platform: input is line-buffered and echoed by the terminal.
+func makeRaw(f *os.File) (func(), error) {
+	return func() {}, nil
+}
+
+func termSize(f *os.File) (int, int) {
+	return envSize()
+}
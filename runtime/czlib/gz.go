@@ -0,0 +1,208 @@
+package czlib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/stdio"
+)
+
+// GzFile is a gzip file (gzFile). Files opened for reading may also be uncompressed, like in zlib.
+type GzFile struct {
+	f   *os.File
+	r   *bufio.Reader
+	w   *gzip.Writer
+	eof bool
+}
+
+// Gzopen implements gzopen.
+func Gzopen(path, mode string) *GzFile {
+	level := gzip.DefaultCompression
+	flags := os.O_RDONLY
+	for _, c := range mode {
+		switch {
+		case c == 'w':
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		case c == 'a':
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		case c >= '0' && c <= '9':
+			level, _ = strconv.Atoi(string(c))
+		}
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil
+	}
+	gz := &GzFile{f: f}
+	if flags == os.O_RDONLY {
+		br := bufio.NewReader(f)
+		gz.r = br
+		if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+			zr, err := gzip.NewReader(br)
+			if err != nil {
+				f.Close()
+				return nil
+			}
+			gz.r = bufio.NewReader(zr)
+		}
+	} else {
+		gz.w, err = gzip.NewWriterLevel(f, level)
+		if err != nil {
+			f.Close()
+			return nil
+		}
+	}
+	return gz
+}
+
+// Gzread implements gzread.
+func Gzread(f *GzFile, buf unsafe.Pointer, n uint32) int32 {
+	if f == nil || f.r == nil {
+		return -1
+	}
+	if n == 0 {
+		return 0
+	}
+	got, err := io.ReadFull(f.r, unsafe.Slice((*byte)(buf), n))
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		f.eof = true
+	} else if err != nil {
+		return -1
+	}
+	return int32(got)
+}
+
+// Gzwrite implements gzwrite.
+func Gzwrite(f *GzFile, buf unsafe.Pointer, n uint32) int32 {
+	if f == nil || f.w == nil {
+		return 0
+	}
+	if n == 0 {
+		return 0
+	}
+	got, err := f.w.Write(unsafe.Slice((*byte)(buf), n))
+	if err != nil {
+		return 0
+	}
+	return int32(got)
+}
+
+// Gzgets implements gzgets.
+func Gzgets(f *GzFile, buf *byte, n int32) *byte {
+	if f == nil || f.r == nil || n <= 0 {
+		return nil
+	}
+	dst := unsafe.Slice(buf, n)
+	i := 0
+	for i < int(n)-1 {
+		c, err := f.r.ReadByte()
+		if err != nil {
+			f.eof = true
+			break
+		}
+		dst[i] = c
+		i++
+		if c == '\n' {
+			break
+		}
+	}
+	if i == 0 {
+		return nil
+	}
+	dst[i] = 0
+	return buf
+}
+
+// Gzgetc implements gzgetc.
+func Gzgetc(f *GzFile) int32 {
+	if f == nil || f.r == nil {
+		return -1
+	}
+	c, err := f.r.ReadByte()
+	if err != nil {
+		f.eof = true
+		return -1
+	}
+	return int32(c)
+}
+
+// Gzputs implements gzputs.
+func Gzputs(f *GzFile, s string) int32 {
+	if f == nil || f.w == nil {
+		return -1
+	}
+	n, err := io.WriteString(f.w, s)
+	if err != nil {
+		return -1
+	}
+	return int32(n)
+}
+
+// Gzputc implements gzputc.
+func Gzputc(f *GzFile, c int32) int32 {
+	if f == nil || f.w == nil {
+		return -1
+	}
+	if _, err := f.w.Write([]byte{byte(c)}); err != nil {
+		return -1
+	}
+	return c & 0xff
+}
+
+// Gzprintf implements gzprintf.
+func Gzprintf(f *GzFile, format string, args ...interface{}) int32 {
+	if f == nil || f.w == nil {
+		return -1
+	}
+	var sb strings.Builder
+	stdio.FprintfGo(&sb, format, args...)
+	return Gzputs(f, sb.String())
+}
+
+// Gzflush implements gzflush.
+func Gzflush(f *GzFile, flush int32) int32 {
+	if f == nil || f.w == nil {
+		return Z_STREAM_ERROR
+	}
+	var err error
+	if flush == Z_FINISH {
+		err = f.w.Close()
+	} else {
+		err = f.w.Flush()
+	}
+	if err != nil {
+		return Z_ERRNO
+	}
+	return Z_OK
+}
+
+// Gzeof implements gzeof.
+func Gzeof(f *GzFile) int32 {
+	if f != nil && f.eof {
+		return 1
+	}
+	return 0
+}
+
+// Gzclose implements gzclose.
+func Gzclose(f *GzFile) int32 {
+	if f == nil {
+		return Z_STREAM_ERROR
+	}
+	res := int32(Z_OK)
+	if f.w != nil {
+		if err := f.w.Close(); err != nil {
+			res = Z_ERRNO
+		}
+	}
+	if err := f.f.Close(); err != nil {
+		res = Z_ERRNO
+	}
+	return res
+}
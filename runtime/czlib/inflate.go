@@ -0,0 +1,171 @@
+package czlib
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+)
+
+const (
+	formatZlib = iota
+	formatRaw
+	formatGzip
+	formatAuto
+)
+
+var errClosed = errors.New("stream closed")
+
+// inflater runs a Go decompressor in a goroutine, and feeds it with the input passed to Inflate.
+// The goroutine only runs while Inflate waits for it, thus the output buffer is never accessed concurrently.
+type inflater struct {
+	format   int
+	r        *feeder
+	done     chan error
+	waiting  bool // the goroutine waits for more input
+	finished bool
+	err      error
+}
+
+func newInflater(format int, out io.Writer) *inflater {
+	f := &inflater{
+		format: format,
+		r: &feeder{
+			in:     make(chan []byte),
+			hungry: make(chan struct{}),
+			quit:   make(chan struct{}),
+		},
+		done: make(chan error, 1),
+	}
+	go func() {
+		f.done <- f.run(out)
+	}()
+	return f
+}
+
+func (f *inflater) run(out io.Writer) error {
+	format := f.format
+	if format == formatAuto {
+		b, err := f.r.peek()
+		if err != nil {
+			return err
+		}
+		format = formatZlib
+		if b == 0x1f {
+			format = formatGzip
+		}
+	}
+	var (
+		zr  io.Reader
+		err error
+	)
+	switch format {
+	case formatRaw:
+		zr = flate.NewReader(f.r)
+	case formatGzip:
+		var gr *gzip.Reader
+		gr, err = gzip.NewReader(f.r)
+		if err == nil {
+			gr.Multistream(false)
+			zr = gr
+		}
+	default:
+		zr, err = zlib.NewReader(f.r)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, zr)
+	return err
+}
+
+// wait until the goroutine needs more input or finishes. It returns false if it's finished.
+func (f *inflater) wait() bool {
+	if f.finished {
+		return false
+	} else if f.waiting {
+		return true
+	}
+	select {
+	case <-f.r.hungry:
+		f.waiting = true
+		return true
+	case err := <-f.done:
+		f.finished, f.err = true, err
+		return false
+	}
+}
+
+// feed passes the input to the decompressor and returns the number of consumed bytes.
+func (f *inflater) feed(in []byte) int {
+	if !f.wait() || len(in) == 0 {
+		return 0
+	}
+	f.r.in <- in
+	f.waiting = false
+	if !f.wait() {
+		// the stream ended, the rest of the input is not consumed
+		return len(in) - len(f.r.cur)
+	}
+	return len(in)
+}
+
+// close stops the goroutine.
+func (f *inflater) close() {
+	if f.finished {
+		return
+	}
+	close(f.r.quit)
+	f.err = <-f.done
+	f.finished = true
+}
+
+// feeder is a reader that requests input from Inflate.
+type feeder struct {
+	cur    []byte
+	in     chan []byte
+	hungry chan struct{}
+	quit   chan struct{}
+}
+
+func (r *feeder) fill() error {
+	for len(r.cur) == 0 {
+		select {
+		case r.hungry <- struct{}{}:
+		case <-r.quit:
+			return errClosed
+		}
+		select {
+		case r.cur = <-r.in:
+		case <-r.quit:
+			return errClosed
+		}
+	}
+	return nil
+}
+
+func (r *feeder) peek() (byte, error) {
+	if err := r.fill(); err != nil {
+		return 0, err
+	}
+	return r.cur[0], nil
+}
+
+func (r *feeder) Read(p []byte) (int, error) {
+	if err := r.fill(); err != nil {
+		return 0, err
+	}
+	n := copy(p, r.cur)
+	r.cur = r.cur[n:]
+	return n, nil
+}
+
+func (r *feeder) ReadByte() (byte, error) {
+	if err := r.fill(); err != nil {
+		return 0, err
+	}
+	b := r.cur[0]
+	r.cur = r.cur[1:]
+	return b, nil
+}
@@ -0,0 +1,385 @@
+// Package czlib implements zlib.h on top of compress/zlib, compress/flate and compress/gzip.
+package czlib
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"io"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+const Version = "1.2.13"
+
+const (
+	Z_OK            = 0
+	Z_STREAM_END    = 1
+	Z_NEED_DICT     = 2
+	Z_ERRNO         = -1
+	Z_STREAM_ERROR  = -2
+	Z_DATA_ERROR    = -3
+	Z_MEM_ERROR     = -4
+	Z_BUF_ERROR     = -5
+	Z_VERSION_ERROR = -6
+)
+
+const (
+	Z_NO_FLUSH      = 0
+	Z_PARTIAL_FLUSH = 1
+	Z_SYNC_FLUSH    = 2
+	Z_FULL_FLUSH    = 3
+	Z_FINISH        = 4
+	Z_BLOCK         = 5
+)
+
+const (
+	Z_NO_COMPRESSION      = 0
+	Z_BEST_SPEED          = 1
+	Z_BEST_COMPRESSION    = 9
+	Z_DEFAULT_COMPRESSION = -1
+)
+
+const (
+	Z_DEFAULT_STRATEGY = 0
+	Z_FILTERED         = 1
+	Z_HUFFMAN_ONLY     = 2
+	Z_RLE              = 3
+	Z_FIXED            = 4
+)
+
+const (
+	Z_DEFLATED = 8
+	MAX_WBITS  = 15
+)
+
+// Stream is a compression or decompression stream (z_stream).
+//
+// Unlike in zlib, Inflate may delay the output until the end of the current block, or the end of the stream.
+type Stream struct {
+	NextIn   *byte
+	AvailIn  uint32
+	TotalIn  uint
+	NextOut  *byte
+	AvailOut uint32
+	TotalOut uint
+	Msg      *byte
+	state    *stream
+	Zalloc   unsafe.Pointer
+	Zfree    unsafe.Pointer
+	Opaque   unsafe.Pointer
+	DataType int32
+	Adler    uint
+	Reserved uint
+}
+
+type stream struct {
+	out bytes.Buffer // pending output
+	sum hash.Hash32
+
+	// deflate
+	w interface {
+		io.WriteCloser
+		Flush() error
+	}
+	closed bool
+
+	// inflate
+	inf *inflater
+}
+
+// ZlibVersion implements zlibVersion.
+func ZlibVersion() *byte {
+	return libc.CString(Version)
+}
+
+func (s *Stream) input() []byte {
+	if s.AvailIn == 0 || s.NextIn == nil {
+		return nil
+	}
+	return unsafe.Slice(s.NextIn, s.AvailIn)
+}
+
+func (s *Stream) consume(n int) {
+	s.NextIn = (*byte)(unsafe.Add(unsafe.Pointer(s.NextIn), n))
+	s.AvailIn -= uint32(n)
+	s.TotalIn += uint(n)
+}
+
+// flushOut copies pending output to the output buffer of the stream. It returns the copied data.
+func (s *Stream) flushOut() []byte {
+	st := s.state
+	if s.AvailOut == 0 || s.NextOut == nil || st.out.Len() == 0 {
+		return nil
+	}
+	p := unsafe.Slice(s.NextOut, s.AvailOut)
+	n := copy(p, st.out.Next(int(s.AvailOut)))
+	s.NextOut = (*byte)(unsafe.Add(unsafe.Pointer(s.NextOut), n))
+	s.AvailOut -= uint32(n)
+	s.TotalOut += uint(n)
+	return p[:n]
+}
+
+func (s *Stream) setError(err error) {
+	s.Msg = libc.CString(err.Error())
+}
+
+// DeflateInit implements deflateInit.
+func DeflateInit(s *Stream, level int32) int32 {
+	return DeflateInit2(s, level, Z_DEFLATED, MAX_WBITS, 8, Z_DEFAULT_STRATEGY)
+}
+
+// DeflateInit2 implements deflateInit2. Window size and memory level are ignored, except for selecting the format:
+// negative window bits produce raw deflate data, and window bits above 15 produce a gzip stream.
+func DeflateInit2(s *Stream, level, method, windowBits, memLevel, strategy int32) int32 {
+	if s == nil || method != Z_DEFLATED {
+		return Z_STREAM_ERROR
+	}
+	lvl := int(level)
+	if strategy == Z_HUFFMAN_ONLY {
+		lvl = flate.HuffmanOnly
+	}
+	st := &stream{}
+	var err error
+	switch {
+	case windowBits < 0:
+		st.w, err = flate.NewWriter(&st.out, lvl)
+	case windowBits > MAX_WBITS:
+		st.w, err = gzip.NewWriterLevel(&st.out, lvl)
+		st.sum = crc32.NewIEEE()
+	default:
+		st.w, err = zlib.NewWriterLevel(&st.out, lvl)
+		st.sum = adler32.New()
+	}
+	if err != nil {
+		return Z_STREAM_ERROR
+	}
+	s.state = st
+	s.TotalIn, s.TotalOut, s.Msg = 0, 0, nil
+	s.updateSum(nil)
+	return Z_OK
+}
+
+func (s *Stream) updateSum(p []byte) {
+	if sum := s.state.sum; sum != nil {
+		sum.Write(p)
+		s.Adler = uint(sum.Sum32())
+	}
+}
+
+// Deflate implements deflate. The input is always consumed completely.
+func Deflate(s *Stream, flush int32) int32 {
+	if s == nil || s.state == nil || s.state.w == nil {
+		return Z_STREAM_ERROR
+	}
+	st := s.state
+	in := s.input()
+	if len(in) != 0 {
+		if st.closed {
+			return Z_STREAM_ERROR
+		}
+		if _, err := st.w.Write(in); err != nil {
+			s.setError(err)
+			return Z_STREAM_ERROR
+		}
+		s.updateSum(in)
+		s.consume(len(in))
+	}
+	switch flush {
+	case Z_NO_FLUSH:
+	case Z_FINISH:
+		if !st.closed {
+			st.closed = true
+			if err := st.w.Close(); err != nil {
+				s.setError(err)
+				return Z_STREAM_ERROR
+			}
+		}
+	default:
+		if !st.closed {
+			if err := st.w.Flush(); err != nil {
+				s.setError(err)
+				return Z_STREAM_ERROR
+			}
+		}
+	}
+	out := s.flushOut()
+	if st.closed && st.out.Len() == 0 {
+		return Z_STREAM_END
+	}
+	if len(in) == 0 && len(out) == 0 && flush == Z_NO_FLUSH {
+		return Z_BUF_ERROR
+	}
+	return Z_OK
+}
+
+// DeflateEnd implements deflateEnd.
+func DeflateEnd(s *Stream) int32 {
+	if s == nil || s.state == nil || s.state.w == nil {
+		return Z_STREAM_ERROR
+	}
+	s.state = nil
+	return Z_OK
+}
+
+// DeflateBound implements deflateBound.
+func DeflateBound(s *Stream, n uint) uint {
+	return CompressBound(n) + 18 // gzip header and trailer
+}
+
+// InflateInit implements inflateInit.
+func InflateInit(s *Stream) int32 {
+	return InflateInit2(s, MAX_WBITS)
+}
+
+// InflateInit2 implements inflateInit2. Negative window bits are used for raw deflate data, window bits above 15
+// for gzip streams, and above 31 to detect zlib or gzip stream automatically.
+func InflateInit2(s *Stream, windowBits int32) int32 {
+	if s == nil {
+		return Z_STREAM_ERROR
+	}
+	st := &stream{sum: adler32.New()}
+	format := formatZlib
+	switch {
+	case windowBits < 0:
+		format, st.sum = formatRaw, nil
+	case windowBits > 2*MAX_WBITS+1:
+		format = formatAuto
+	case windowBits > MAX_WBITS:
+		format, st.sum = formatGzip, crc32.NewIEEE()
+	}
+	st.inf = newInflater(format, &st.out)
+	s.state = st
+	s.TotalIn, s.TotalOut, s.Msg = 0, 0, nil
+	s.updateSum(nil)
+	return Z_OK
+}
+
+// Inflate implements inflate.
+func Inflate(s *Stream, flush int32) int32 {
+	if s == nil || s.state == nil || s.state.inf == nil {
+		return Z_STREAM_ERROR
+	}
+	st := s.state
+	in := s.input()
+	consumed := st.inf.feed(in)
+	s.consume(consumed)
+	out := s.flushOut()
+	s.updateSum(out)
+	if st.inf.finished && st.out.Len() == 0 {
+		if err := st.inf.err; err != nil {
+			s.setError(err)
+			return Z_DATA_ERROR
+		}
+		return Z_STREAM_END
+	}
+	if consumed == 0 && len(out) == 0 {
+		return Z_BUF_ERROR
+	}
+	return Z_OK
+}
+
+// InflateReset implements inflateReset.
+func InflateReset(s *Stream) int32 {
+	if s == nil || s.state == nil || s.state.inf == nil {
+		return Z_STREAM_ERROR
+	}
+	st := s.state
+	st.inf.close()
+	st.out.Reset()
+	st.inf = newInflater(st.inf.format, &st.out)
+	if st.sum != nil {
+		st.sum.Reset()
+	}
+	s.TotalIn, s.TotalOut, s.Msg = 0, 0, nil
+	s.updateSum(nil)
+	return Z_OK
+}
+
+// InflateEnd implements inflateEnd.
+func InflateEnd(s *Stream) int32 {
+	if s == nil || s.state == nil || s.state.inf == nil {
+		return Z_STREAM_ERROR
+	}
+	s.state.inf.close()
+	s.state = nil
+	return Z_OK
+}
+
+// CompressBound implements compressBound.
+func CompressBound(n uint) uint {
+	return n + n>>12 + n>>14 + n>>25 + 13
+}
+
+// Compress implements compress.
+func Compress(dst *byte, dstLen *uint, src *byte, srcLen uint) int32 {
+	return Compress2(dst, dstLen, src, srcLen, Z_DEFAULT_COMPRESSION)
+}
+
+// Compress2 implements compress2.
+func Compress2(dst *byte, dstLen *uint, src *byte, srcLen uint, level int32) int32 {
+	var buf bytes.Buffer
+	w, err := zlib.NewWriterLevel(&buf, int(level))
+	if err != nil {
+		return Z_STREAM_ERROR
+	}
+	if srcLen != 0 {
+		w.Write(unsafe.Slice(src, srcLen))
+	}
+	w.Close()
+	return copyResult(dst, dstLen, buf.Bytes())
+}
+
+// Uncompress implements uncompress.
+func Uncompress(dst *byte, dstLen *uint, src *byte, srcLen uint) int32 {
+	if srcLen == 0 {
+		return Z_DATA_ERROR
+	}
+	r, err := zlib.NewReader(bytes.NewReader(unsafe.Slice(src, srcLen)))
+	if err != nil {
+		return Z_DATA_ERROR
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Z_DATA_ERROR
+	}
+	return copyResult(dst, dstLen, data)
+}
+
+func copyResult(dst *byte, dstLen *uint, data []byte) int32 {
+	if uint(len(data)) > *dstLen {
+		return Z_BUF_ERROR
+	}
+	if len(data) != 0 {
+		copy(unsafe.Slice(dst, len(data)), data)
+	}
+	*dstLen = uint(len(data))
+	return Z_OK
+}
+
+// Crc32 implements crc32.
+func Crc32(crc uint, buf *byte, n uint32) uint {
+	if buf == nil {
+		return 0
+	}
+	return uint(crc32.Update(uint32(crc), crc32.IEEETable, unsafe.Slice(buf, n)))
+}
+
+// Adler32 implements adler32.
+func Adler32(adler uint, buf *byte, n uint32) uint {
+	if buf == nil {
+		return 1
+	}
+	const mod = 65521
+	a, b := uint32(adler)&0xffff, uint32(adler)>>16
+	for _, c := range unsafe.Slice(buf, n) {
+		a = (a + uint32(c)) % mod
+		b = (b + a) % mod
+	}
+	return uint(b<<16 | a)
+}
@@ -0,0 +1,193 @@
+package czlib
+
+import (
+	"bytes"
+	"hash/adler32"
+	"hash/crc32"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+var testData = bytes.Repeat([]byte("hello zlib, hello deflate; "), 200)
+
+func deflateAll(t *testing.T, windowBits int32, data []byte) []byte {
+	var s Stream
+	if e := DeflateInit2(&s, Z_BEST_COMPRESSION, Z_DEFLATED, windowBits, 8, Z_DEFAULT_STRATEGY); e != Z_OK {
+		t.Fatalf("deflateInit2: %d", e)
+	}
+	var out []byte
+	buf := make([]byte, 64)
+	for i := 0; ; i += 100 {
+		flush := int32(Z_NO_FLUSH)
+		if i >= len(data) {
+			flush = Z_FINISH
+		} else {
+			n := 100
+			if len(data)-i < n {
+				n = len(data) - i
+			}
+			s.NextIn, s.AvailIn = &data[i], uint32(n)
+		}
+		for {
+			s.NextOut, s.AvailOut = &buf[0], uint32(len(buf))
+			e := Deflate(&s, flush)
+			out = append(out, buf[:len(buf)-int(s.AvailOut)]...)
+			if e == Z_STREAM_END {
+				DeflateEnd(&s)
+				return out
+			} else if e != Z_OK && e != Z_BUF_ERROR {
+				t.Fatalf("deflate: %d", e)
+			}
+			if s.AvailOut != 0 {
+				break
+			}
+		}
+	}
+}
+
+func inflateAll(t *testing.T, windowBits int32, data []byte) []byte {
+	var s Stream
+	if e := InflateInit2(&s, windowBits); e != Z_OK {
+		t.Fatalf("inflateInit2: %d", e)
+	}
+	defer InflateEnd(&s)
+	var out []byte
+	buf := make([]byte, 50)
+	for i := 0; i < len(data) || s.AvailIn != 0; {
+		if s.AvailIn == 0 {
+			n := 7
+			if len(data)-i < n {
+				n = len(data) - i
+			}
+			s.NextIn, s.AvailIn = &data[i], uint32(n)
+			i += n
+		}
+		s.NextOut, s.AvailOut = &buf[0], uint32(len(buf))
+		e := Inflate(&s, Z_NO_FLUSH)
+		out = append(out, buf[:len(buf)-int(s.AvailOut)]...)
+		if e == Z_STREAM_END {
+			return out
+		} else if e != Z_OK && e != Z_BUF_ERROR {
+			t.Fatalf("inflate: %d", e)
+		}
+	}
+	for {
+		s.NextOut, s.AvailOut = &buf[0], uint32(len(buf))
+		e := Inflate(&s, Z_FINISH)
+		out = append(out, buf[:len(buf)-int(s.AvailOut)]...)
+		if e == Z_STREAM_END {
+			return out
+		} else if e != Z_OK {
+			t.Fatalf("inflate: %d", e)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		deflate int32
+		inflate int32
+		sum     uint32
+	}{
+		{"zlib", MAX_WBITS, MAX_WBITS, adler32.Checksum(testData)},
+		{"raw", -MAX_WBITS, -MAX_WBITS, 0},
+		{"gzip", MAX_WBITS + 16, MAX_WBITS + 16, crc32.ChecksumIEEE(testData)},
+		{"auto", MAX_WBITS + 16, MAX_WBITS + 32, 0},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			z := deflateAll(t, c.deflate, testData)
+			if len(z) >= len(testData) {
+				t.Fatalf("data is not compressed: %d", len(z))
+			}
+			out := inflateAll(t, c.inflate, z)
+			if !bytes.Equal(out, testData) {
+				t.Fatalf("unexpected output: %q", out)
+			}
+		})
+	}
+}
+
+func TestStreamTrailing(t *testing.T) {
+	z := append(deflateAll(t, MAX_WBITS, testData), "tail"...)
+	var s Stream
+	InflateInit(&s)
+	defer InflateEnd(&s)
+	out := make([]byte, len(testData))
+	s.NextIn, s.AvailIn = &z[0], uint32(len(z))
+	s.NextOut, s.AvailOut = &out[0], uint32(len(out))
+	if e := Inflate(&s, Z_FINISH); e != Z_STREAM_END {
+		t.Fatalf("inflate: %d", e)
+	}
+	if s.AvailIn != 4 || s.AvailOut != 0 || s.Adler != uint(adler32.Checksum(testData)) {
+		t.Fatalf("unexpected state: in=%d, out=%d", s.AvailIn, s.AvailOut)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	dst := make([]byte, CompressBound(uint(len(testData))))
+	n := uint(len(dst))
+	if e := Compress(&dst[0], &n, &testData[0], uint(len(testData))); e != Z_OK {
+		t.Fatalf("compress: %d", e)
+	}
+	out := make([]byte, len(testData))
+	m := uint(len(out) - 1)
+	if e := Uncompress(&out[0], &m, &dst[0], n); e != Z_BUF_ERROR {
+		t.Fatalf("expected buffer error: %d", e)
+	}
+	m = uint(len(out))
+	if e := Uncompress(&out[0], &m, &dst[0], n); e != Z_OK || m != uint(len(testData)) {
+		t.Fatalf("uncompress: %d", e)
+	}
+	if !bytes.Equal(out, testData) {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	p := &testData[0]
+	n := uint32(len(testData))
+	if v := Crc32(Crc32(0, nil, 0), p, n); v != uint(crc32.ChecksumIEEE(testData)) {
+		t.Fatalf("unexpected crc32: %x", v)
+	}
+	if v := Adler32(Adler32(0, nil, 0), p, n); v != uint(adler32.Checksum(testData)) {
+		t.Fatalf("unexpected adler32: %x", v)
+	}
+}
+
+func TestGzFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.gz")
+	f := Gzopen(path, "wb9")
+	if f == nil {
+		t.Fatal("cannot create file")
+	}
+	Gzputs(f, "line 1\n")
+	Gzprintf(f, "line %d\n", 2)
+	Gzwrite(f, unsafe.Pointer(&testData[0]), uint32(len(testData)))
+	if e := Gzclose(f); e != Z_OK {
+		t.Fatalf("gzclose: %d", e)
+	}
+
+	f = Gzopen(path, "rb")
+	if f == nil {
+		t.Fatal("cannot open file")
+	}
+	defer Gzclose(f)
+	var line [16]byte
+	for _, exp := range []string{"line 1\n", "line 2\n"} {
+		if Gzgets(f, &line[0], int32(len(line))) == nil {
+			t.Fatal("unexpected EOF")
+		}
+		if s := string(line[:bytes.IndexByte(line[:], 0)]); s != exp {
+			t.Fatalf("unexpected line: %q", s)
+		}
+	}
+	buf := make([]byte, len(testData)+10)
+	if n := Gzread(f, unsafe.Pointer(&buf[0]), uint32(len(buf))); n != int32(len(testData)) {
+		t.Fatalf("unexpected size: %d", n)
+	}
+	if !bytes.Equal(buf[:len(testData)], testData) || Gzeof(f) == 0 {
+		t.Fatal("unexpected data")
+	}
+}
@@ -71,3 +71,15 @@ func TestConvFuncStruct2(t *testing.T) {
 	AddrAsFunc(uintptr(p2.fnc), (*func())(nil)).(func())()
 	require.True(t, v)
 }
+
+func TestPtrHandle(t *testing.T) {
+	a, b := new(int32), new(int32)
+	ha, hb := PtrToHandle(unsafe.Pointer(a)), PtrToHandle(unsafe.Pointer(b))
+	require.NotEqual(t, ha, hb)
+	require.Equal(t, ha, PtrToHandle(unsafe.Pointer(a)))
+	require.Equal(t, unsafe.Pointer(a), HandleToPtr(ha))
+	require.Equal(t, unsafe.Pointer(b), HandleToPtr(hb))
+	require.Zero(t, PtrToHandle(nil))
+	require.Nil(t, HandleToPtr(0))
+	require.Panics(t, func() { HandleToPtr(hb + 100) })
+}
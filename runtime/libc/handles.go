@@ -0,0 +1,48 @@
+package libc
+
+import (
+	"sync"
+	"unsafe"
+)
+
+var handles struct {
+	sync.Mutex
+	byPtr map[unsafe.Pointer]uintptr
+	ptrs  []unsafe.Pointer
+}
+
+// PtrToHandle returns an integer handle for a pointer, which can be converted back with HandleToPtr.
+//
+// Unlike uintptr(p), the handle keeps the object alive and doesn't break if the object is moved by the GC.
+// The same pointer always gets the same handle, and nil pointer gets 0. Handles are never released,
+// and arithmetic on them doesn't correspond to pointer arithmetic.
+func PtrToHandle(p unsafe.Pointer) uintptr {
+	if p == nil {
+		return 0
+	}
+	handles.Lock()
+	defer handles.Unlock()
+	if h, ok := handles.byPtr[p]; ok {
+		return h
+	}
+	if handles.byPtr == nil {
+		handles.byPtr = make(map[unsafe.Pointer]uintptr)
+	}
+	handles.ptrs = append(handles.ptrs, p)
+	h := uintptr(len(handles.ptrs))
+	handles.byPtr[p] = h
+	return h
+}
+
+// HandleToPtr returns a pointer for the handle returned by PtrToHandle. It panics for unknown handles.
+func HandleToPtr(h uintptr) unsafe.Pointer {
+	if h == 0 {
+		return nil
+	}
+	handles.Lock()
+	defer handles.Unlock()
+	if h > uintptr(len(handles.ptrs)) {
+		panic("invalid pointer handle")
+	}
+	return handles.ptrs[h-1]
+}
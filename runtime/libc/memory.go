@@ -66,6 +66,24 @@ func Realloc(buf unsafe.Pointer, sz int) unsafe.Pointer {
 	return unsafe.Pointer(&p[0])
 }
 
+// ReallocSlice resizes the slice to n elements, similar to Realloc.
+// If the slice has no capacity for n elements, they are appended, thus the capacity grows at least twice.
+func ReallocSlice[T any](s []T, n int) []T {
+	if n <= cap(s) {
+		return s[:n]
+	}
+	return append(s[:cap(s)], make([]T, n-cap(s))...)
+}
+
+// MakeSlices allocates n slices with m elements each, as a replacement for a two-dimensional C array.
+func MakeSlices[T any](n, m int) [][]T {
+	s := make([][]T, n)
+	for i := range s {
+		s[i] = make([]T, m)
+	}
+	return s
+}
+
 // Free marks the memory as freed. May be a nop in Go.
 func Free(p unsafe.Pointer) {
 	allocs.Delete(p)
@@ -144,3 +162,13 @@ func UnsafeUint32N(ptr unsafe.Pointer, sz int) []uint32 {
 func Uint32N(p *uint32, sz int) []uint32 {
 	return unsafe.Slice(p, sz)
 }
+
+// ContainerOf returns a pointer to a struct T that contains a field pointed by p at a given offset.
+// It replaces the container_of idiom from C, but it's as unsafe: p must point to the field of T at offset off.
+// It returns nil if p is nil.
+func ContainerOf[T, F any](p *F, off uintptr) *T {
+	if p == nil {
+		return nil
+	}
+	return (*T)(unsafe.Add(unsafe.Pointer(p), -int(off)))
+}
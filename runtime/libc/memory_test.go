@@ -1,9 +1,59 @@
 package libc
 
-import "testing"
+import (
+	"testing"
+	"unsafe"
+)
 
 func TestRealloc(t *testing.T) {
 	p := Malloc(1)
 	p = Realloc(p, 32*1024*1024)
 	Free(p)
 }
+
+func TestReallocSlice(t *testing.T) {
+	var s []int32
+	for i := 0; i < 100; i++ {
+		s = ReallocSlice(s, i+1)
+		s[i] = int32(i)
+	}
+	for i, v := range s {
+		if v != int32(i) {
+			t.Fatalf("unexpected value at %d: %d", i, v)
+		}
+	}
+	s = ReallocSlice(s, 10)
+	if len(s) != 10 || s[9] != 9 {
+		t.Fatalf("unexpected slice after shrink: %v", s)
+	}
+}
+
+func TestMakeSlices(t *testing.T) {
+	s := MakeSlices[int32](3, 4)
+	if len(s) != 3 {
+		t.Fatalf("unexpected length: %d", len(s))
+	}
+	for i, r := range s {
+		if len(r) != 4 {
+			t.Fatalf("unexpected length of row %d: %d", i, len(r))
+		}
+	}
+}
+
+func TestContainerOf(t *testing.T) {
+	type node struct {
+		next *node
+	}
+	type item struct {
+		v    int32
+		node node
+	}
+	it := &item{v: 3}
+	p := ContainerOf[item](&it.node, unsafe.Offsetof(it.node))
+	if p != it {
+		t.Fatalf("unexpected pointer: %p vs %p", p, it)
+	}
+	if p := ContainerOf[item, node](nil, unsafe.Offsetof(it.node)); p != nil {
+		t.Fatalf("expected nil, got %p", p)
+	}
+}
@@ -3,6 +3,7 @@ package libc
 import (
 	"math"
 	"math/rand"
+	"sync"
 )
 
 const RandMax = math.MaxInt32
@@ -14,3 +15,75 @@ func Rand() int32 {
 func SeedRand(seed uint32) {
 	rand.Seed(int64(seed))
 }
+
+// glibcRand is the additive feedback generator used by glibc for random and rand (TYPE_3).
+type glibcRand struct {
+	r    [31]int32
+	f, b int
+}
+
+func (g *glibcRand) seed(seed uint32) {
+	if seed == 0 {
+		seed = 1
+	}
+	g.r[0] = int32(seed)
+	for i := 1; i < len(g.r); i++ {
+		// 16807 * r[i-1] % 2147483647, without overflowing 31 bits
+		hi, lo := g.r[i-1]/127773, g.r[i-1]%127773
+		v := 16807*lo - 2836*hi
+		if v < 0 {
+			v += 2147483647
+		}
+		g.r[i] = v
+	}
+	g.f, g.b = 3, 0
+	for i := 0; i < 10*len(g.r); i++ {
+		g.next()
+	}
+}
+
+func (g *glibcRand) next() int32 {
+	g.r[g.f] = int32(uint32(g.r[g.f]) + uint32(g.r[g.b]))
+	v := int32(uint32(g.r[g.f]) >> 1)
+	g.f = (g.f + 1) % len(g.r)
+	g.b = (g.b + 1) % len(g.r)
+	return v
+}
+
+var glibcState struct {
+	sync.Mutex
+	init bool
+	g    glibcRand
+}
+
+// GlibcRand implements rand and random, producing the same sequence as glibc.
+func GlibcRand() int32 {
+	glibcState.Lock()
+	defer glibcState.Unlock()
+	if !glibcState.init {
+		glibcState.g.seed(1)
+		glibcState.init = true
+	}
+	return glibcState.g.next()
+}
+
+// GlibcSeedRand implements srand and srandom for GlibcRand.
+func GlibcSeedRand(seed uint32) {
+	glibcState.Lock()
+	defer glibcState.Unlock()
+	glibcState.g.seed(seed)
+	glibcState.init = true
+}
+
+// RandR implements rand_r with the same algorithm as glibc.
+func RandR(seed *uint32) int32 {
+	next := *seed
+	next = next*1103515245 + 12345
+	res := (next / 65536) % 2048
+	next = next*1103515245 + 12345
+	res = res<<10 ^ (next/65536)%1024
+	next = next*1103515245 + 12345
+	res = res<<10 ^ (next/65536)%1024
+	*seed = next
+	return int32(res)
+}
@@ -0,0 +1,37 @@
+package libc
+
+import "testing"
+
+func TestGlibcRand(t *testing.T) {
+	// sequences printed by glibc
+	GlibcSeedRand(1)
+	for i, exp := range []int32{1804289383, 846930886, 1681692777, 1714636915, 1957747793} {
+		if v := GlibcRand(); v != exp {
+			t.Fatalf("seed 1, value %d: expected %d, got %d", i, exp, v)
+		}
+	}
+	GlibcSeedRand(42)
+	for i, exp := range []int32{71876166, 708592740, 1483128881} {
+		if v := GlibcRand(); v != exp {
+			t.Fatalf("seed 42, value %d: expected %d, got %d", i, exp, v)
+		}
+	}
+	// rand and random share the state
+	GlibcSeedRand(7)
+	if v1, v2 := GlibcRand(), GlibcRand(); v1 != 1045618677 || v2 != 1863967299 {
+		t.Fatalf("seed 7: unexpected values: %d, %d", v1, v2)
+	}
+	GlibcSeedRand(0)
+	if v := GlibcRand(); v != 1804289383 {
+		t.Fatalf("seed 0 should be the same as 1, got %d", v)
+	}
+}
+
+func TestRandR(t *testing.T) {
+	seed := uint32(1)
+	for i, exp := range []int32{476707713, 1186278907, 505671508} {
+		if v := RandR(&seed); v != exp {
+			t.Fatalf("value %d: expected %d, got %d", i, exp, v)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package libc
+
+import (
+	"sync"
+	"time"
+)
+
+const TIMER_ABSTIME = 1
+
+var sleepers struct {
+	sync.Mutex
+	intr chan struct{}
+}
+
+// InterruptSleep wakes up all sleeping goroutines, as if the process received a signal.
+// Interrupted calls return the remaining time and fail with EINTR, as defined for each function.
+func InterruptSleep() {
+	sleepers.Lock()
+	defer sleepers.Unlock()
+	if sleepers.intr != nil {
+		close(sleepers.intr)
+		sleepers.intr = nil
+	}
+}
+
+// sleepUntil sleeps until a given time. It returns the remaining duration, if the sleep was interrupted.
+func sleepUntil(end time.Time) time.Duration {
+	d := time.Until(end)
+	if d <= 0 {
+		return 0
+	}
+	sleepers.Lock()
+	if sleepers.intr == nil {
+		sleepers.intr = make(chan struct{})
+	}
+	intr := sleepers.intr
+	sleepers.Unlock()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return 0
+	case <-intr:
+		if rem := time.Until(end); rem > 0 {
+			return rem
+		}
+		return 0
+	}
+}
+
+func (ts *TimeSpec) valid() bool {
+	return ts.Sec >= 0 && ts.NSec >= 0 && ts.NSec < int64(time.Second)
+}
+
+func (ts *TimeSpec) duration() time.Duration {
+	return time.Duration(ts.Sec)*time.Second + time.Duration(ts.NSec)
+}
+
+func durationToTimeSpec(d time.Duration) TimeSpec {
+	return TimeSpec{Sec: Time(d / time.Second), NSec: int64(d % time.Second)}
+}
+
+// NanoSleep implements nanosleep. If interrupted, the remaining time is written to rem.
+func NanoSleep(req, rem *TimeSpec) int32 {
+	if req == nil || !req.valid() {
+		Errno = EINVAL
+		return -1
+	}
+	left := sleepUntil(time.Now().Add(req.duration()))
+	if left == 0 {
+		return 0
+	}
+	if rem != nil {
+		*rem = durationToTimeSpec(left)
+	}
+	Errno = EINTR
+	return -1
+}
+
+// ClockNanoSleep implements clock_nanosleep. Unlike nanosleep, it returns the error code instead of setting errno.
+// The remaining time is not written for absolute sleeps.
+func ClockNanoSleep(c Clock, flags int32, req, rem *TimeSpec) int32 {
+	if req == nil || !req.valid() {
+		return EINVAL
+	}
+	var end time.Time
+	switch {
+	case flags&TIMER_ABSTIME == 0:
+		end = time.Now().Add(req.duration())
+	case c == CLOCK_REALTIME:
+		end = time.Unix(int64(req.Sec), req.NSec)
+	case c == CLOCK_MONOTONIC:
+		end = clockStart.Add(req.duration())
+	default:
+		return EINVAL
+	}
+	left := sleepUntil(end)
+	if left == 0 {
+		return 0
+	}
+	if rem != nil && flags&TIMER_ABSTIME == 0 {
+		*rem = durationToTimeSpec(left)
+	}
+	return EINTR
+}
+
+// Sleep implements sleep. It returns the number of unslept seconds, if interrupted.
+func Sleep(sec uint32) uint32 {
+	left := sleepUntil(time.Now().Add(time.Duration(sec) * time.Second))
+	return uint32(left.Round(time.Second) / time.Second)
+}
+
+// USleep implements usleep.
+func USleep(usec uint32) int32 {
+	if sleepUntil(time.Now().Add(time.Duration(usec)*time.Microsecond)) != 0 {
+		Errno = EINTR
+		return -1
+	}
+	return 0
+}
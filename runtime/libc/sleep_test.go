@@ -0,0 +1,50 @@
+package libc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNanoSleep(t *testing.T) {
+	start := time.Now()
+	if NanoSleep(&TimeSpec{NSec: int64(20 * time.Millisecond)}, nil) != 0 {
+		t.Fatal("sleep failed")
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatalf("sleep is too short: %v", d)
+	}
+	if NanoSleep(&TimeSpec{NSec: int64(time.Second)}, nil) != -1 || Errno != EINVAL {
+		t.Fatalf("unexpected result: %d", Errno)
+	}
+	if ClockNanoSleep(CLOCK_REALTIME, TIMER_ABSTIME, &TimeSpec{Sec: 1}, nil) != 0 {
+		t.Fatal("sleep in the past failed")
+	}
+}
+
+func TestSleepInterrupt(t *testing.T) {
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		InterruptSleep()
+	}()
+	var rem TimeSpec
+	if NanoSleep(&TimeSpec{Sec: 10}, &rem) != -1 || Errno != EINTR {
+		t.Fatalf("unexpected result: %d", Errno)
+	}
+	if rem.Sec < 9 || rem.Sec >= 10 {
+		t.Fatalf("unexpected remaining time: %+v", rem)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		InterruptSleep()
+	}()
+	if left := Sleep(5); left != 5 {
+		t.Fatalf("unexpected remaining time: %d", left)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		InterruptSleep()
+	}()
+	if USleep(10000000) != -1 || Errno != EINTR {
+		t.Fatalf("unexpected result: %d", Errno)
+	}
+}
@@ -17,8 +17,12 @@ func (va *ArgList) Start(typ interface{}, rest []interface{}) {
 	va.args = rest
 }
 
+// Args returns the arguments that were not yet read by Arg, for example to pass them to a v-function like vprintf.
 func (va *ArgList) Args() []interface{} {
-	return va.args
+	if va.cur >= len(va.args) {
+		return nil
+	}
+	return va.args[va.cur:]
 }
 
 func (va *ArgList) Arg() interface{} {
@@ -0,0 +1,23 @@
+package libc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArgListArgs(t *testing.T) {
+	var va ArgList
+	va.Start(nil, []interface{}{1, "a", 2.0})
+	if v := va.Arg(); v != 1 {
+		t.Fatalf("unexpected arg: %v", v)
+	}
+	if args := va.Args(); !reflect.DeepEqual(args, []interface{}{"a", 2.0}) {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	va.Arg()
+	va.Arg()
+	if args := va.Args(); len(args) != 0 {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	va.End()
+}
@@ -107,6 +107,10 @@ func ClockGetTime(c Clock, ts *TimeSpec) int32 {
 		d := time.Since(clockStart)
 		*ts = TimeSpec{Sec: Time(d / time.Second), NSec: int64(d % time.Second)}
 		return 0
+	case CLOCK_REALTIME:
+		now := time.Now()
+		*ts = TimeSpec{Sec: Time(now.Unix()), NSec: int64(now.Nanosecond())}
+		return 0
 	}
 	panic("TODO")
 }
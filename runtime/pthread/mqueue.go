@@ -0,0 +1,259 @@
+package pthread
+
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// O_NONBLOCK is the only flag of the message queue descriptor that can be changed.
+const O_NONBLOCK = 0x800
+
+const (
+	mqDefMaxMsg  = 10
+	mqDefMsgSize = 8192
+	mqPrioMax    = 32768
+)
+
+// MQ is a message queue descriptor (mqd_t).
+type MQ int32
+
+// MQAttr describes message queue attributes (struct mq_attr).
+type MQAttr struct {
+	Flags   int
+	MaxMsg  int
+	MsgSize int
+	CurMsgs int
+}
+
+type mqMessage struct {
+	data []byte
+	prio uint32
+}
+
+type mqueue struct {
+	mu      sync.Mutex
+	maxMsg  int
+	msgSize int
+	msgs    []mqMessage // ordered by priority, and by the send time within the same priority
+	sent    notifier
+	recv    notifier
+}
+
+type mqDesc struct {
+	q      *mqueue
+	flags  int32 // protected by the queue mutex
+	closed bool
+}
+
+// message queues are only shared within the process
+var mqueues struct {
+	sync.Mutex
+	byName map[string]*mqueue
+	descs  []*mqDesc
+}
+
+func getMQ(mqd MQ) *mqDesc {
+	mqueues.Lock()
+	defer mqueues.Unlock()
+	if mqd < 0 || int(mqd) >= len(mqueues.descs) || mqueues.descs[mqd].closed {
+		return nil
+	}
+	return mqueues.descs[mqd]
+}
+
+// MqOpen implements mq_open. The mode argument is ignored.
+func MqOpen(name string, oflag int32, args ...interface{}) MQ {
+	mqueues.Lock()
+	defer mqueues.Unlock()
+	q, ok := mqueues.byName[name]
+	if ok && oflag&(csys.O_CREAT|csys.O_EXCL) == csys.O_CREAT|csys.O_EXCL {
+		return MQ(setErrno(libc.EEXIST))
+	} else if !ok && oflag&csys.O_CREAT == 0 {
+		return MQ(setErrno(libc.ENOENT))
+	}
+	if !ok {
+		q = &mqueue{maxMsg: mqDefMaxMsg, msgSize: mqDefMsgSize}
+		if len(args) > 1 {
+			if attr, _ := args[1].(*MQAttr); attr != nil {
+				if attr.MaxMsg <= 0 || attr.MsgSize <= 0 {
+					return MQ(setErrno(libc.EINVAL))
+				}
+				q.maxMsg, q.msgSize = attr.MaxMsg, attr.MsgSize
+			}
+		}
+		if mqueues.byName == nil {
+			mqueues.byName = make(map[string]*mqueue)
+		}
+		mqueues.byName[name] = q
+	}
+	mqueues.descs = append(mqueues.descs, &mqDesc{q: q, flags: oflag})
+	return MQ(len(mqueues.descs) - 1)
+}
+
+// MqClose implements mq_close.
+func MqClose(mqd MQ) int32 {
+	d := getMQ(mqd)
+	if d == nil {
+		return setErrno(libc.EBADF)
+	}
+	mqueues.Lock()
+	d.closed = true
+	mqueues.Unlock()
+	return 0
+}
+
+// MqUnlink implements mq_unlink. The queue remains usable by descriptors that are already open.
+func MqUnlink(name string) int32 {
+	mqueues.Lock()
+	defer mqueues.Unlock()
+	if _, ok := mqueues.byName[name]; !ok {
+		return setErrno(libc.ENOENT)
+	}
+	delete(mqueues.byName, name)
+	return 0
+}
+
+func (d *mqDesc) canRead() bool {
+	return d.flags&(csys.O_RDONLY|csys.O_WRONLY|csys.O_RDWR) != csys.O_WRONLY
+}
+
+func (d *mqDesc) canWrite() bool {
+	return d.flags&(csys.O_WRONLY|csys.O_RDWR) != 0
+}
+
+func (d *mqDesc) send(msg *byte, n int, prio uint32, abs *libc.TimeSpec) int32 {
+	q := d.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !d.canWrite() {
+		return setErrno(libc.EBADF)
+	} else if n > q.msgSize {
+		return setErrno(libc.EMSGSIZE)
+	} else if prio >= mqPrioMax {
+		return setErrno(libc.EINVAL)
+	}
+	data := make([]byte, n)
+	if n != 0 {
+		copy(data, unsafe.Slice(msg, n))
+	}
+	for len(q.msgs) >= q.maxMsg {
+		if d.flags&O_NONBLOCK != 0 {
+			return setErrno(libc.EAGAIN)
+		}
+		if !waitNotify(&q.mu, &q.recv, abs) && len(q.msgs) >= q.maxMsg {
+			return setErrno(libc.ETIMEDOUT)
+		}
+	}
+	i := len(q.msgs)
+	for i > 0 && q.msgs[i-1].prio < prio {
+		i--
+	}
+	q.msgs = append(q.msgs, mqMessage{})
+	copy(q.msgs[i+1:], q.msgs[i:])
+	q.msgs[i] = mqMessage{data: data, prio: prio}
+	q.sent.notify()
+	return 0
+}
+
+func (d *mqDesc) receive(buf *byte, n int, prio *uint32, abs *libc.TimeSpec) int {
+	q := d.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !d.canRead() {
+		return int(setErrno(libc.EBADF))
+	} else if n < q.msgSize {
+		return int(setErrno(libc.EMSGSIZE))
+	}
+	for len(q.msgs) == 0 {
+		if d.flags&O_NONBLOCK != 0 {
+			return int(setErrno(libc.EAGAIN))
+		}
+		if !waitNotify(&q.mu, &q.sent, abs) && len(q.msgs) == 0 {
+			return int(setErrno(libc.ETIMEDOUT))
+		}
+	}
+	m := q.msgs[0]
+	q.msgs = q.msgs[1:]
+	q.recv.notify()
+	if len(m.data) != 0 {
+		copy(unsafe.Slice(buf, n), m.data)
+	}
+	if prio != nil {
+		*prio = m.prio
+	}
+	return len(m.data)
+}
+
+// MqSend implements mq_send.
+func MqSend(mqd MQ, msg *byte, n int, prio uint32) int32 {
+	d := getMQ(mqd)
+	if d == nil {
+		return setErrno(libc.EBADF)
+	}
+	return d.send(msg, n, prio, nil)
+}
+
+// MqTimedSend implements mq_timedsend.
+func MqTimedSend(mqd MQ, msg *byte, n int, prio uint32, abs *libc.TimeSpec) int32 {
+	d := getMQ(mqd)
+	if d == nil {
+		return setErrno(libc.EBADF)
+	}
+	return d.send(msg, n, prio, abs)
+}
+
+// MqReceive implements mq_receive.
+func MqReceive(mqd MQ, buf *byte, n int, prio *uint32) int {
+	d := getMQ(mqd)
+	if d == nil {
+		return int(setErrno(libc.EBADF))
+	}
+	return d.receive(buf, n, prio, nil)
+}
+
+// MqTimedReceive implements mq_timedreceive.
+func MqTimedReceive(mqd MQ, buf *byte, n int, prio *uint32, abs *libc.TimeSpec) int {
+	d := getMQ(mqd)
+	if d == nil {
+		return int(setErrno(libc.EBADF))
+	}
+	return d.receive(buf, n, prio, abs)
+}
+
+// MqGetAttr implements mq_getattr.
+func MqGetAttr(mqd MQ, attr *MQAttr) int32 {
+	d := getMQ(mqd)
+	if d == nil {
+		return setErrno(libc.EBADF)
+	}
+	q := d.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	*attr = MQAttr{
+		Flags:   int(d.flags & O_NONBLOCK),
+		MaxMsg:  q.maxMsg,
+		MsgSize: q.msgSize,
+		CurMsgs: len(q.msgs),
+	}
+	return 0
+}
+
+// MqSetAttr implements mq_setattr. Only O_NONBLOCK flag can be changed.
+func MqSetAttr(mqd MQ, attr, old *MQAttr) int32 {
+	if old != nil {
+		if r := MqGetAttr(mqd, old); r != 0 {
+			return r
+		}
+	}
+	d := getMQ(mqd)
+	if d == nil {
+		return setErrno(libc.EBADF)
+	}
+	d.q.mu.Lock()
+	d.flags = d.flags&^O_NONBLOCK | int32(attr.Flags)&O_NONBLOCK
+	d.q.mu.Unlock()
+	return 0
+}
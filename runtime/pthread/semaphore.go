@@ -0,0 +1,202 @@
+package pthread
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+const SEM_VALUE_MAX = 0x7fffffff
+
+// notifier wakes up all goroutines waiting for a state change. It must be protected by a mutex.
+type notifier struct {
+	ch chan struct{}
+}
+
+func (n *notifier) wait() <-chan struct{} {
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	return n.ch
+}
+
+func (n *notifier) notify() {
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+}
+
+// waitNotify unlocks the mutex and waits for a notification, or until the absolute time (if set).
+// The mutex is locked again before returning. It returns false if the wait timed out.
+func waitNotify(mu *sync.Mutex, n *notifier, abs *libc.TimeSpec) bool {
+	ch := n.wait()
+	mu.Unlock()
+	defer mu.Lock()
+	if abs == nil {
+		<-ch
+		return true
+	}
+	d := time.Until(time.Unix(int64(abs.Sec), abs.NSec))
+	if d <= 0 {
+		return false
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ch:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+func setErrno(code int) int32 {
+	libc.Errno = code
+	return -1
+}
+
+// argInt returns an optional integer argument of a variadic function.
+func argInt(args []interface{}, i int) (int64, bool) {
+	if i >= len(args) {
+		return 0, false
+	}
+	v := reflect.ValueOf(args[i])
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// Sem is a counting semaphore (sem_t). The zero value is a semaphore with the value of zero.
+type Sem struct {
+	mu    sync.Mutex
+	value uint32
+	post  notifier
+}
+
+// SemInit implements sem_init. Semaphores are never shared between processes.
+func SemInit(s *Sem, pshared int32, value uint32) int32 {
+	if value > SEM_VALUE_MAX {
+		return setErrno(libc.EINVAL)
+	}
+	*s = Sem{value: value}
+	return 0
+}
+
+// SemDestroy implements sem_destroy.
+func SemDestroy(s *Sem) int32 {
+	return 0
+}
+
+// SemPost implements sem_post.
+func SemPost(s *Sem) int32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.value == SEM_VALUE_MAX {
+		return setErrno(libc.EOVERFLOW)
+	}
+	s.value++
+	s.post.notify()
+	return 0
+}
+
+func (s *Sem) wait(abs *libc.TimeSpec) int32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.value == 0 {
+		if !waitNotify(&s.mu, &s.post, abs) && s.value == 0 {
+			return setErrno(libc.ETIMEDOUT)
+		}
+	}
+	s.value--
+	return 0
+}
+
+// SemWait implements sem_wait.
+func SemWait(s *Sem) int32 {
+	return s.wait(nil)
+}
+
+// SemTimedWait implements sem_timedwait.
+func SemTimedWait(s *Sem, abs *libc.TimeSpec) int32 {
+	if abs == nil || abs.NSec < 0 || abs.NSec >= 1e9 {
+		return setErrno(libc.EINVAL)
+	}
+	return s.wait(abs)
+}
+
+// SemTryWait implements sem_trywait.
+func SemTryWait(s *Sem) int32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.value == 0 {
+		return setErrno(libc.EAGAIN)
+	}
+	s.value--
+	return 0
+}
+
+// SemGetValue implements sem_getvalue.
+func SemGetValue(s *Sem, val *int32) int32 {
+	s.mu.Lock()
+	*val = int32(s.value)
+	s.mu.Unlock()
+	return 0
+}
+
+// named semaphores are only shared within the process
+var namedSems struct {
+	sync.Mutex
+	byName map[string]*Sem
+}
+
+// SemOpen implements sem_open. The mode argument is ignored.
+func SemOpen(name string, oflag int32, args ...interface{}) *Sem {
+	namedSems.Lock()
+	defer namedSems.Unlock()
+	if s, ok := namedSems.byName[name]; ok {
+		if oflag&(csys.O_CREAT|csys.O_EXCL) == csys.O_CREAT|csys.O_EXCL {
+			setErrno(libc.EEXIST)
+			return nil
+		}
+		return s
+	}
+	if oflag&csys.O_CREAT == 0 {
+		setErrno(libc.ENOENT)
+		return nil
+	}
+	value, _ := argInt(args, 1)
+	if value < 0 || value > SEM_VALUE_MAX {
+		setErrno(libc.EINVAL)
+		return nil
+	}
+	s := &Sem{value: uint32(value)}
+	if namedSems.byName == nil {
+		namedSems.byName = make(map[string]*Sem)
+	}
+	namedSems.byName[name] = s
+	return s
+}
+
+// SemClose implements sem_close.
+func SemClose(s *Sem) int32 {
+	return 0
+}
+
+// SemUnlink implements sem_unlink. The semaphore remains usable by the code that opened it.
+func SemUnlink(name string) int32 {
+	namedSems.Lock()
+	defer namedSems.Unlock()
+	if _, ok := namedSems.byName[name]; !ok {
+		return setErrno(libc.ENOENT)
+	}
+	delete(namedSems.byName, name)
+	return 0
+}
@@ -0,0 +1,136 @@
+package pthread
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func deadline(d time.Duration) *libc.TimeSpec {
+	t := time.Now().Add(d)
+	return &libc.TimeSpec{Sec: libc.Time(t.Unix()), NSec: int64(t.Nanosecond())}
+}
+
+func TestSem(t *testing.T) {
+	var s Sem
+	if SemInit(&s, 0, 1) != 0 {
+		t.Fatal("init failed")
+	}
+	if SemTryWait(&s) != 0 {
+		t.Fatal("trywait failed")
+	}
+	if SemTryWait(&s) != -1 || libc.Errno != libc.EAGAIN {
+		t.Fatalf("unexpected trywait result: %d", libc.Errno)
+	}
+	if SemTimedWait(&s, deadline(10*time.Millisecond)) != -1 || libc.Errno != libc.ETIMEDOUT {
+		t.Fatalf("unexpected timedwait result: %d", libc.Errno)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			SemWait(&s)
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		SemPost(&s)
+	}
+	wg.Wait()
+	var v int32
+	SemGetValue(&s, &v)
+	if v != 0 {
+		t.Fatalf("unexpected value: %d", v)
+	}
+	SemDestroy(&s)
+}
+
+func TestSemNamed(t *testing.T) {
+	if SemOpen("/test", 0) != nil || libc.Errno != libc.ENOENT {
+		t.Fatalf("unexpected open result: %d", libc.Errno)
+	}
+	s := SemOpen("/test", csys.O_CREAT, 0644, uint32(2))
+	if s == nil {
+		t.Fatal("open failed")
+	}
+	if SemOpen("/test", csys.O_CREAT|csys.O_EXCL, 0644, uint32(2)) != nil || libc.Errno != libc.EEXIST {
+		t.Fatalf("unexpected open result: %d", libc.Errno)
+	}
+	if s2 := SemOpen("/test", 0); s2 != s {
+		t.Fatal("expected the same semaphore")
+	}
+	var v int32
+	SemGetValue(s, &v)
+	if v != 2 {
+		t.Fatalf("unexpected value: %d", v)
+	}
+	SemClose(s)
+	if SemUnlink("/test") != 0 || SemUnlink("/test") != -1 {
+		t.Fatal("unexpected unlink result")
+	}
+}
+
+func TestMQ(t *testing.T) {
+	attr := &MQAttr{MaxMsg: 2, MsgSize: 16}
+	w := MqOpen("/test", csys.O_CREAT|csys.O_WRONLY|O_NONBLOCK, 0644, attr)
+	if w < 0 {
+		t.Fatalf("open failed: %d", libc.Errno)
+	}
+	defer MqUnlink("/test")
+	r := MqOpen("/test", csys.O_RDONLY)
+	if r < 0 {
+		t.Fatalf("open failed: %d", libc.Errno)
+	}
+	send := func(s string, prio uint32) int32 {
+		return MqSend(w, libc.CString(s), len(s), prio)
+	}
+	if send("low", 1) != 0 || send("high", 5) != 0 {
+		t.Fatalf("send failed: %d", libc.Errno)
+	}
+	if send("full", 1) != -1 || libc.Errno != libc.EAGAIN {
+		t.Fatalf("unexpected send result: %d", libc.Errno)
+	}
+	if send("this message is too long", 1) != -1 || libc.Errno != libc.EMSGSIZE {
+		t.Fatalf("unexpected send result: %d", libc.Errno)
+	}
+	var got MQAttr
+	MqGetAttr(w, &got)
+	if got != (MQAttr{Flags: O_NONBLOCK, MaxMsg: 2, MsgSize: 16, CurMsgs: 2}) {
+		t.Fatalf("unexpected attrs: %+v", got)
+	}
+	var buf [16]byte
+	recv := func() (string, uint32) {
+		var prio uint32
+		n := MqTimedReceive(r, &buf[0], len(buf), &prio, deadline(time.Second))
+		if n < 0 {
+			t.Fatalf("receive failed: %d", libc.Errno)
+		}
+		return string(buf[:n]), prio
+	}
+	if s, prio := recv(); s != "high" || prio != 5 {
+		t.Fatalf("unexpected message: %q, %d", s, prio)
+	}
+	if s, prio := recv(); s != "low" || prio != 1 {
+		t.Fatalf("unexpected message: %q, %d", s, prio)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		send("later", 0)
+	}()
+	if s, _ := recv(); s != "later" {
+		t.Fatalf("unexpected message: %q", s)
+	}
+	if n := MqTimedReceive(r, &buf[0], len(buf), nil, deadline(10*time.Millisecond)); n != -1 || libc.Errno != libc.ETIMEDOUT {
+		t.Fatalf("unexpected receive result: %d, %d", n, libc.Errno)
+	}
+	if MqSend(r, &buf[0], 1, 0) != -1 || libc.Errno != libc.EBADF {
+		t.Fatalf("unexpected send result: %d", libc.Errno)
+	}
+	MqClose(r)
+	if MqClose(r) != -1 {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,224 @@
+package simd
+
+import "unsafe"
+
+// Int32x4 is a vector of four signed 32 bit integers (int32x4_t).
+type Int32x4 struct {
+	v [4]int32
+}
+
+// Uint32x4 is a vector of four unsigned 32 bit integers (uint32x4_t).
+type Uint32x4 struct {
+	v [4]uint32
+}
+
+// Uint8x16 is a vector of sixteen unsigned bytes (uint8x16_t).
+type Uint8x16 struct {
+	v [16]uint8
+}
+
+// Float32x4 is a vector of four floats (float32x4_t).
+type Float32x4 struct {
+	v [4]float32
+}
+
+func mapS32(a, b Int32x4, fnc func(x, y int32) int32) (r Int32x4) {
+	for i := range r.v {
+		r.v[i] = fnc(a.v[i], b.v[i])
+	}
+	return
+}
+
+func mapU8(a, b Uint8x16, fnc func(x, y uint8) uint8) (r Uint8x16) {
+	for i := range r.v {
+		r.v[i] = fnc(a.v[i], b.v[i])
+	}
+	return
+}
+
+func mapF32(a, b Float32x4, fnc func(x, y float32) float32) (r Float32x4) {
+	for i := range r.v {
+		r.v[i] = fnc(a.v[i], b.v[i])
+	}
+	return
+}
+
+// VdupqNS32 implements vdupq_n_s32.
+func VdupqNS32(v int32) Int32x4 {
+	return Int32x4{v: [4]int32{v, v, v, v}}
+}
+
+// Vld1qS32 implements vld1q_s32.
+func Vld1qS32(p *int32) Int32x4 {
+	return Int32x4{v: *(*[4]int32)(unsafe.Pointer(p))}
+}
+
+// Vst1qS32 implements vst1q_s32.
+func Vst1qS32(p *int32, a Int32x4) {
+	*(*[4]int32)(unsafe.Pointer(p)) = a.v
+}
+
+// VgetqLaneS32 implements vgetq_lane_s32.
+func VgetqLaneS32(a Int32x4, lane int32) int32 {
+	return a.v[lane]
+}
+
+// VaddqS32 implements vaddq_s32.
+func VaddqS32(a, b Int32x4) Int32x4 {
+	return mapS32(a, b, func(x, y int32) int32 { return x + y })
+}
+
+// VsubqS32 implements vsubq_s32.
+func VsubqS32(a, b Int32x4) Int32x4 {
+	return mapS32(a, b, func(x, y int32) int32 { return x - y })
+}
+
+// VmulqS32 implements vmulq_s32.
+func VmulqS32(a, b Int32x4) Int32x4 {
+	return mapS32(a, b, func(x, y int32) int32 { return x * y })
+}
+
+// VandqS32 implements vandq_s32.
+func VandqS32(a, b Int32x4) Int32x4 {
+	return mapS32(a, b, func(x, y int32) int32 { return x & y })
+}
+
+// VorrqS32 implements vorrq_s32.
+func VorrqS32(a, b Int32x4) Int32x4 {
+	return mapS32(a, b, func(x, y int32) int32 { return x | y })
+}
+
+// VeorqS32 implements veorq_s32.
+func VeorqS32(a, b Int32x4) Int32x4 {
+	return mapS32(a, b, func(x, y int32) int32 { return x ^ y })
+}
+
+// VceqqS32 implements vceqq_s32.
+func VceqqS32(a, b Int32x4) (r Uint32x4) {
+	for i := range r.v {
+		r.v[i] = mask32(a.v[i] == b.v[i])
+	}
+	return
+}
+
+// VaddvqS32 implements vaddvq_s32.
+func VaddvqS32(a Int32x4) int32 {
+	return a.v[0] + a.v[1] + a.v[2] + a.v[3]
+}
+
+// VgetqLaneU32 implements vgetq_lane_u32.
+func VgetqLaneU32(a Uint32x4, lane int32) uint32 {
+	return a.v[lane]
+}
+
+// VdupqNU8 implements vdupq_n_u8.
+func VdupqNU8(v uint8) (r Uint8x16) {
+	for i := range r.v {
+		r.v[i] = v
+	}
+	return
+}
+
+// Vld1qU8 implements vld1q_u8.
+func Vld1qU8(p *uint8) Uint8x16 {
+	return Uint8x16{v: *(*[16]uint8)(unsafe.Pointer(p))}
+}
+
+// Vst1qU8 implements vst1q_u8.
+func Vst1qU8(p *uint8, a Uint8x16) {
+	*(*[16]uint8)(unsafe.Pointer(p)) = a.v
+}
+
+// VgetqLaneU8 implements vgetq_lane_u8.
+func VgetqLaneU8(a Uint8x16, lane int32) uint8 {
+	return a.v[lane]
+}
+
+// VaddqU8 implements vaddq_u8.
+func VaddqU8(a, b Uint8x16) Uint8x16 {
+	return mapU8(a, b, func(x, y uint8) uint8 { return x + y })
+}
+
+// VsubqU8 implements vsubq_u8.
+func VsubqU8(a, b Uint8x16) Uint8x16 {
+	return mapU8(a, b, func(x, y uint8) uint8 { return x - y })
+}
+
+// VandqU8 implements vandq_u8.
+func VandqU8(a, b Uint8x16) Uint8x16 {
+	return mapU8(a, b, func(x, y uint8) uint8 { return x & y })
+}
+
+// VorrqU8 implements vorrq_u8.
+func VorrqU8(a, b Uint8x16) Uint8x16 {
+	return mapU8(a, b, func(x, y uint8) uint8 { return x | y })
+}
+
+// VeorqU8 implements veorq_u8.
+func VeorqU8(a, b Uint8x16) Uint8x16 {
+	return mapU8(a, b, func(x, y uint8) uint8 { return x ^ y })
+}
+
+// VceqqU8 implements vceqq_u8.
+func VceqqU8(a, b Uint8x16) Uint8x16 {
+	return mapU8(a, b, func(x, y uint8) uint8 { return mask8(x == y) })
+}
+
+// VmaxvqU8 implements vmaxvq_u8.
+func VmaxvqU8(a Uint8x16) uint8 {
+	var m uint8
+	for _, v := range a.v {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// VdupqNF32 implements vdupq_n_f32.
+func VdupqNF32(v float32) Float32x4 {
+	return Float32x4{v: [4]float32{v, v, v, v}}
+}
+
+// Vld1qF32 implements vld1q_f32.
+func Vld1qF32(p *float32) Float32x4 {
+	return Float32x4{v: *(*[4]float32)(unsafe.Pointer(p))}
+}
+
+// Vst1qF32 implements vst1q_f32.
+func Vst1qF32(p *float32, a Float32x4) {
+	*(*[4]float32)(unsafe.Pointer(p)) = a.v
+}
+
+// VgetqLaneF32 implements vgetq_lane_f32.
+func VgetqLaneF32(a Float32x4, lane int32) float32 {
+	return a.v[lane]
+}
+
+// VaddqF32 implements vaddq_f32.
+func VaddqF32(a, b Float32x4) Float32x4 {
+	return mapF32(a, b, func(x, y float32) float32 { return x + y })
+}
+
+// VsubqF32 implements vsubq_f32.
+func VsubqF32(a, b Float32x4) Float32x4 {
+	return mapF32(a, b, func(x, y float32) float32 { return x - y })
+}
+
+// VmulqF32 implements vmulq_f32.
+func VmulqF32(a, b Float32x4) Float32x4 {
+	return mapF32(a, b, func(x, y float32) float32 { return x * y })
+}
+
+// VmlaqF32 implements vmlaq_f32: a + b*c.
+func VmlaqF32(a, b, c Float32x4) (r Float32x4) {
+	for i := range r.v {
+		r.v[i] = a.v[i] + b.v[i]*c.v[i]
+	}
+	return
+}
+
+// VaddvqF32 implements vaddvq_f32.
+func VaddvqF32(a Float32x4) float32 {
+	return (a.v[0] + a.v[1]) + (a.v[2] + a.v[3])
+}
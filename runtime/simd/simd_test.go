@@ -0,0 +1,63 @@
+package simd
+
+import "testing"
+
+func TestSSE(t *testing.T) {
+	a := SetEpi32(4, 3, 2, 1)
+	b := Set1Epi32(-1)
+	r := AddEpi32(a, b)
+	var out [4]int32
+	for i := range out {
+		out[i] = int32(r.epi32(i))
+	}
+	if out != [4]int32{0, 1, 2, 3} {
+		t.Fatalf("unexpected sum: %v", out)
+	}
+	if v := Cvtsi128Si32(SubEpi32(a, b)); v != 2 {
+		t.Fatalf("unexpected lane: %d", v)
+	}
+	if m := MovemaskEpi8(CmpeqEpi8(a, Cvtsi32Si128(1))); m != 0xeeef {
+		t.Fatalf("unexpected mask: %#x", m)
+	}
+	if v := Cvtsi128Si32(SraiEpi32(Set1Epi32(-8), 2)); v != -2 {
+		t.Fatalf("unexpected shift: %d", v)
+	}
+	var p M128i
+	StoreSi128(&p, XorSi128(a, a))
+	if LoadSi128(&p) != SetzeroSi128() {
+		t.Fatalf("expected zero vector")
+	}
+
+	f := []float32{1, 2, 3, 4}
+	StorePs(&f[0], MulPs(LoadPs(&f[0]), Set1Ps(2)))
+	if f[0] != 2 || f[3] != 8 {
+		t.Fatalf("unexpected floats: %v", f)
+	}
+	if v := CvtsdF64(SqrtPd(SetPd(1, 16))); v != 4 {
+		t.Fatalf("unexpected sqrt: %v", v)
+	}
+}
+
+func TestNEON(t *testing.T) {
+	s := []int32{1, 2, 3, 4}
+	v := VaddqS32(Vld1qS32(&s[0]), VdupqNS32(10))
+	Vst1qS32(&s[0], v)
+	if s[0] != 11 || s[3] != 14 {
+		t.Fatalf("unexpected ints: %v", s)
+	}
+	if sum := VaddvqS32(v); sum != 50 {
+		t.Fatalf("unexpected sum: %d", sum)
+	}
+	if l := VgetqLaneU32(VceqqS32(v, VdupqNS32(12)), 1); l != 0xffffffff {
+		t.Fatalf("unexpected lane: %#x", l)
+	}
+	b := make([]uint8, 16)
+	b[5] = 7
+	if m := VmaxvqU8(Vld1qU8(&b[0])); m != 7 {
+		t.Fatalf("unexpected max: %d", m)
+	}
+	f := VmlaqF32(VdupqNF32(1), VdupqNF32(2), VdupqNF32(3))
+	if l := VgetqLaneF32(f, 3); l != 7 {
+		t.Fatalf("unexpected lane: %v", l)
+	}
+}
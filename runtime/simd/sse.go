@@ -0,0 +1,430 @@
+// Package simd implements scalar fallbacks for SIMD intrinsics from emmintrin.h and arm_neon.h.
+//
+// Vectors are stored in little-endian lane order, the same way as in the registers.
+// A package with the same API that uses native SIMD instructions can be used instead with the import_paths option.
+package simd
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+)
+
+var le = binary.LittleEndian
+
+// M128i is a vector of integers (__m128i).
+type M128i struct {
+	v [16]byte
+}
+
+// M128 is a vector of four floats (__m128).
+type M128 struct {
+	v [4]float32
+}
+
+// M128d is a vector of two doubles (__m128d).
+type M128d struct {
+	v [2]float64
+}
+
+func (a M128i) epi16(i int) uint16 { return le.Uint16(a.v[2*i:]) }
+func (a M128i) epi32(i int) uint32 { return le.Uint32(a.v[4*i:]) }
+func (a M128i) epi64(i int) uint64 { return le.Uint64(a.v[8*i:]) }
+
+func (a *M128i) setEpi16(i int, v uint16) { le.PutUint16(a.v[2*i:], v) }
+func (a *M128i) setEpi32(i int, v uint32) { le.PutUint32(a.v[4*i:], v) }
+func (a *M128i) setEpi64(i int, v uint64) { le.PutUint64(a.v[8*i:], v) }
+
+func mapEpi8(a, b M128i, fnc func(x, y byte) byte) (r M128i) {
+	for i := range r.v {
+		r.v[i] = fnc(a.v[i], b.v[i])
+	}
+	return
+}
+
+func mapEpi16(a, b M128i, fnc func(x, y uint16) uint16) (r M128i) {
+	for i := 0; i < 8; i++ {
+		r.setEpi16(i, fnc(a.epi16(i), b.epi16(i)))
+	}
+	return
+}
+
+func mapEpi32(a, b M128i, fnc func(x, y uint32) uint32) (r M128i) {
+	for i := 0; i < 4; i++ {
+		r.setEpi32(i, fnc(a.epi32(i), b.epi32(i)))
+	}
+	return
+}
+
+func mapEpi64(a, b M128i, fnc func(x, y uint64) uint64) (r M128i) {
+	for i := 0; i < 2; i++ {
+		r.setEpi64(i, fnc(a.epi64(i), b.epi64(i)))
+	}
+	return
+}
+
+func mask8(v bool) byte {
+	if v {
+		return 0xff
+	}
+	return 0
+}
+
+func mask16(v bool) uint16 {
+	if v {
+		return 0xffff
+	}
+	return 0
+}
+
+func mask32(v bool) uint32 {
+	if v {
+		return 0xffffffff
+	}
+	return 0
+}
+
+// SetzeroSi128 implements _mm_setzero_si128.
+func SetzeroSi128() M128i {
+	return M128i{}
+}
+
+// Set1Epi8 implements _mm_set1_epi8.
+func Set1Epi8(v int8) (r M128i) {
+	for i := range r.v {
+		r.v[i] = byte(v)
+	}
+	return
+}
+
+// Set1Epi16 implements _mm_set1_epi16.
+func Set1Epi16(v int16) (r M128i) {
+	for i := 0; i < 8; i++ {
+		r.setEpi16(i, uint16(v))
+	}
+	return
+}
+
+// Set1Epi32 implements _mm_set1_epi32.
+func Set1Epi32(v int32) (r M128i) {
+	for i := 0; i < 4; i++ {
+		r.setEpi32(i, uint32(v))
+	}
+	return
+}
+
+// Set1Epi64x implements _mm_set1_epi64x.
+func Set1Epi64x(v int64) (r M128i) {
+	r.setEpi64(0, uint64(v))
+	r.setEpi64(1, uint64(v))
+	return
+}
+
+// SetEpi32 implements _mm_set_epi32. Arguments are passed from the highest lane to the lowest one.
+func SetEpi32(e3, e2, e1, e0 int32) (r M128i) {
+	r.setEpi32(0, uint32(e0))
+	r.setEpi32(1, uint32(e1))
+	r.setEpi32(2, uint32(e2))
+	r.setEpi32(3, uint32(e3))
+	return
+}
+
+// LoadSi128 implements _mm_load_si128 and _mm_loadu_si128.
+func LoadSi128(p *M128i) M128i {
+	return *p
+}
+
+// StoreSi128 implements _mm_store_si128 and _mm_storeu_si128.
+func StoreSi128(p *M128i, a M128i) {
+	*p = a
+}
+
+// Cvtsi32Si128 implements _mm_cvtsi32_si128.
+func Cvtsi32Si128(v int32) (r M128i) {
+	r.setEpi32(0, uint32(v))
+	return
+}
+
+// Cvtsi128Si32 implements _mm_cvtsi128_si32.
+func Cvtsi128Si32(a M128i) int32 {
+	return int32(a.epi32(0))
+}
+
+// AddEpi8 implements _mm_add_epi8.
+func AddEpi8(a, b M128i) M128i {
+	return mapEpi8(a, b, func(x, y byte) byte { return x + y })
+}
+
+// AddEpi16 implements _mm_add_epi16.
+func AddEpi16(a, b M128i) M128i {
+	return mapEpi16(a, b, func(x, y uint16) uint16 { return x + y })
+}
+
+// AddEpi32 implements _mm_add_epi32.
+func AddEpi32(a, b M128i) M128i {
+	return mapEpi32(a, b, func(x, y uint32) uint32 { return x + y })
+}
+
+// AddEpi64 implements _mm_add_epi64.
+func AddEpi64(a, b M128i) M128i {
+	return mapEpi64(a, b, func(x, y uint64) uint64 { return x + y })
+}
+
+// SubEpi8 implements _mm_sub_epi8.
+func SubEpi8(a, b M128i) M128i {
+	return mapEpi8(a, b, func(x, y byte) byte { return x - y })
+}
+
+// SubEpi16 implements _mm_sub_epi16.
+func SubEpi16(a, b M128i) M128i {
+	return mapEpi16(a, b, func(x, y uint16) uint16 { return x - y })
+}
+
+// SubEpi32 implements _mm_sub_epi32.
+func SubEpi32(a, b M128i) M128i {
+	return mapEpi32(a, b, func(x, y uint32) uint32 { return x - y })
+}
+
+// SubEpi64 implements _mm_sub_epi64.
+func SubEpi64(a, b M128i) M128i {
+	return mapEpi64(a, b, func(x, y uint64) uint64 { return x - y })
+}
+
+// MulloEpi16 implements _mm_mullo_epi16.
+func MulloEpi16(a, b M128i) M128i {
+	return mapEpi16(a, b, func(x, y uint16) uint16 { return x * y })
+}
+
+// AndSi128 implements _mm_and_si128.
+func AndSi128(a, b M128i) M128i {
+	return mapEpi64(a, b, func(x, y uint64) uint64 { return x & y })
+}
+
+// AndnotSi128 implements _mm_andnot_si128.
+func AndnotSi128(a, b M128i) M128i {
+	return mapEpi64(a, b, func(x, y uint64) uint64 { return ^x & y })
+}
+
+// OrSi128 implements _mm_or_si128.
+func OrSi128(a, b M128i) M128i {
+	return mapEpi64(a, b, func(x, y uint64) uint64 { return x | y })
+}
+
+// XorSi128 implements _mm_xor_si128.
+func XorSi128(a, b M128i) M128i {
+	return mapEpi64(a, b, func(x, y uint64) uint64 { return x ^ y })
+}
+
+// CmpeqEpi8 implements _mm_cmpeq_epi8.
+func CmpeqEpi8(a, b M128i) M128i {
+	return mapEpi8(a, b, func(x, y byte) byte { return mask8(x == y) })
+}
+
+// CmpeqEpi16 implements _mm_cmpeq_epi16.
+func CmpeqEpi16(a, b M128i) M128i {
+	return mapEpi16(a, b, func(x, y uint16) uint16 { return mask16(x == y) })
+}
+
+// CmpeqEpi32 implements _mm_cmpeq_epi32.
+func CmpeqEpi32(a, b M128i) M128i {
+	return mapEpi32(a, b, func(x, y uint32) uint32 { return mask32(x == y) })
+}
+
+// CmpgtEpi8 implements _mm_cmpgt_epi8.
+func CmpgtEpi8(a, b M128i) M128i {
+	return mapEpi8(a, b, func(x, y byte) byte { return mask8(int8(x) > int8(y)) })
+}
+
+// CmpgtEpi32 implements _mm_cmpgt_epi32.
+func CmpgtEpi32(a, b M128i) M128i {
+	return mapEpi32(a, b, func(x, y uint32) uint32 { return mask32(int32(x) > int32(y)) })
+}
+
+// MovemaskEpi8 implements _mm_movemask_epi8.
+func MovemaskEpi8(a M128i) int32 {
+	var m int32
+	for i, b := range a.v {
+		m |= int32(b>>7) << i
+	}
+	return m
+}
+
+// SlliEpi32 implements _mm_slli_epi32.
+func SlliEpi32(a M128i, n int32) (r M128i) {
+	if n < 0 || n > 31 {
+		return M128i{}
+	}
+	for i := 0; i < 4; i++ {
+		r.setEpi32(i, a.epi32(i)<<n)
+	}
+	return
+}
+
+// SrliEpi32 implements _mm_srli_epi32.
+func SrliEpi32(a M128i, n int32) (r M128i) {
+	if n < 0 || n > 31 {
+		return M128i{}
+	}
+	for i := 0; i < 4; i++ {
+		r.setEpi32(i, a.epi32(i)>>n)
+	}
+	return
+}
+
+// SraiEpi32 implements _mm_srai_epi32.
+func SraiEpi32(a M128i, n int32) (r M128i) {
+	if n < 0 || n > 31 {
+		n = 31
+	}
+	for i := 0; i < 4; i++ {
+		r.setEpi32(i, uint32(int32(a.epi32(i))>>n))
+	}
+	return
+}
+
+// SetzeroPs implements _mm_setzero_ps.
+func SetzeroPs() M128 {
+	return M128{}
+}
+
+// Set1Ps implements _mm_set1_ps.
+func Set1Ps(v float32) M128 {
+	return M128{v: [4]float32{v, v, v, v}}
+}
+
+// SetPs implements _mm_set_ps. Arguments are passed from the highest lane to the lowest one.
+func SetPs(e3, e2, e1, e0 float32) M128 {
+	return M128{v: [4]float32{e0, e1, e2, e3}}
+}
+
+// LoadPs implements _mm_load_ps and _mm_loadu_ps.
+func LoadPs(p *float32) M128 {
+	return M128{v: *(*[4]float32)(unsafe.Pointer(p))}
+}
+
+// StorePs implements _mm_store_ps and _mm_storeu_ps.
+func StorePs(p *float32, a M128) {
+	*(*[4]float32)(unsafe.Pointer(p)) = a.v
+}
+
+// CvtssF32 implements _mm_cvtss_f32.
+func CvtssF32(a M128) float32 {
+	return a.v[0]
+}
+
+func mapPs(a, b M128, fnc func(x, y float32) float32) (r M128) {
+	for i := range r.v {
+		r.v[i] = fnc(a.v[i], b.v[i])
+	}
+	return
+}
+
+// AddPs implements _mm_add_ps.
+func AddPs(a, b M128) M128 {
+	return mapPs(a, b, func(x, y float32) float32 { return x + y })
+}
+
+// SubPs implements _mm_sub_ps.
+func SubPs(a, b M128) M128 {
+	return mapPs(a, b, func(x, y float32) float32 { return x - y })
+}
+
+// MulPs implements _mm_mul_ps.
+func MulPs(a, b M128) M128 {
+	return mapPs(a, b, func(x, y float32) float32 { return x * y })
+}
+
+// DivPs implements _mm_div_ps.
+func DivPs(a, b M128) M128 {
+	return mapPs(a, b, func(x, y float32) float32 { return x / y })
+}
+
+// MinPs implements _mm_min_ps. Like the instruction, it returns the second value if any of the values is NaN.
+func MinPs(a, b M128) M128 {
+	return mapPs(a, b, func(x, y float32) float32 {
+		if x < y {
+			return x
+		}
+		return y
+	})
+}
+
+// MaxPs implements _mm_max_ps. Like the instruction, it returns the second value if any of the values is NaN.
+func MaxPs(a, b M128) M128 {
+	return mapPs(a, b, func(x, y float32) float32 {
+		if x > y {
+			return x
+		}
+		return y
+	})
+}
+
+// SqrtPs implements _mm_sqrt_ps.
+func SqrtPs(a M128) (r M128) {
+	for i, v := range a.v {
+		r.v[i] = float32(math.Sqrt(float64(v)))
+	}
+	return
+}
+
+// SetzeroPd implements _mm_setzero_pd.
+func SetzeroPd() M128d {
+	return M128d{}
+}
+
+// Set1Pd implements _mm_set1_pd.
+func Set1Pd(v float64) M128d {
+	return M128d{v: [2]float64{v, v}}
+}
+
+// SetPd implements _mm_set_pd. Arguments are passed from the highest lane to the lowest one.
+func SetPd(e1, e0 float64) M128d {
+	return M128d{v: [2]float64{e0, e1}}
+}
+
+// LoadPd implements _mm_load_pd and _mm_loadu_pd.
+func LoadPd(p *float64) M128d {
+	return M128d{v: *(*[2]float64)(unsafe.Pointer(p))}
+}
+
+// StorePd implements _mm_store_pd and _mm_storeu_pd.
+func StorePd(p *float64, a M128d) {
+	*(*[2]float64)(unsafe.Pointer(p)) = a.v
+}
+
+// CvtsdF64 implements _mm_cvtsd_f64.
+func CvtsdF64(a M128d) float64 {
+	return a.v[0]
+}
+
+func mapPd(a, b M128d, fnc func(x, y float64) float64) (r M128d) {
+	for i := range r.v {
+		r.v[i] = fnc(a.v[i], b.v[i])
+	}
+	return
+}
+
+// AddPd implements _mm_add_pd.
+func AddPd(a, b M128d) M128d {
+	return mapPd(a, b, func(x, y float64) float64 { return x + y })
+}
+
+// SubPd implements _mm_sub_pd.
+func SubPd(a, b M128d) M128d {
+	return mapPd(a, b, func(x, y float64) float64 { return x - y })
+}
+
+// MulPd implements _mm_mul_pd.
+func MulPd(a, b M128d) M128d {
+	return mapPd(a, b, func(x, y float64) float64 { return x * y })
+}
+
+// DivPd implements _mm_div_pd.
+func DivPd(a, b M128d) M128d {
+	return mapPd(a, b, func(x, y float64) float64 { return x / y })
+}
+
+// SqrtPd implements _mm_sqrt_pd.
+func SqrtPd(a M128d) M128d {
+	return M128d{v: [2]float64{math.Sqrt(a.v[0]), math.Sqrt(a.v[1])}}
+}
@@ -0,0 +1,97 @@
+package stdio
+
+import (
+	"bufio"
+	"os"
+	"sync"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// bufferedFile buffers writes to a file, as C does for stdout. Line buffered files are flushed after each newline.
+type bufferedFile struct {
+	FileI
+	mu   sync.Mutex
+	w    *bufio.Writer
+	line bool
+}
+
+func newBufferedFile(f FileI, line bool) *bufferedFile {
+	return &bufferedFile{FileI: f, w: bufio.NewWriter(f), line: line}
+}
+
+func (f *bufferedFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.w.Write(p)
+	if err == nil && f.line {
+		for _, b := range p {
+			if b == '\n' {
+				err = f.w.Flush()
+				break
+			}
+		}
+	}
+	return n, err
+}
+
+// Sync writes the buffer to the file. The file itself is not synced, since it's not supported for terminals and pipes.
+func (f *bufferedFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.w.Flush()
+}
+
+func (f *bufferedFile) Close() error {
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.FileI.Close()
+}
+
+var bufStdout struct {
+	once sync.Once
+	f    *File
+}
+
+// BufferedStdout returns stdout with a buffer, as in C. Writes are buffered until fflush, exit, or until the buffer
+// is full; if stdout is a terminal, the buffer is also written after each line. Go code writing to os.Stdout directly
+// must call FlushStdout first to keep the order of the output.
+func BufferedStdout() *File {
+	bufStdout.once.Do(func() {
+		f := defaultFS.fs.Stdout()
+		line := false
+		if of, ok := f.(*os.File); ok {
+			if st, err := of.Stat(); err == nil && st.Mode()&os.ModeCharDevice != 0 {
+				line = true
+			}
+		}
+		bufStdout.f = defaultFS.OpenFrom(newBufferedFile(f, line))
+	})
+	return bufStdout.f
+}
+
+// FlushStdout writes the buffer of BufferedStdout, if it's used.
+func FlushStdout() int32 {
+	if bufStdout.f == nil {
+		return 0
+	}
+	return bufStdout.f.Flush()
+}
+
+// Exit flushes BufferedStdout and exits the program, as C exit does.
+func Exit(code int) {
+	FlushStdout()
+	os.Exit(code)
+}
+
+// BufferedPrintf is the same as Printf, but writes to BufferedStdout.
+func BufferedPrintf(format string, args ...interface{}) int {
+	n, _ := FprintfGo(BufferedStdout().file, format, args...)
+	return n
+}
+
+// BufferedVprintf is the same as Vprintf, but writes to BufferedStdout.
+func BufferedVprintf(format string, args libc.ArgList) int {
+	return BufferedPrintf(format, args.Args()...)
+}
@@ -0,0 +1,39 @@
+package stdio
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferedFile(t *testing.T) {
+	m := NewMemFS()
+	open := func() FileI {
+		f, err := m.Open("out.txt", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		return f
+	}
+	read := func() string {
+		data, err := m.ReadFile("out.txt")
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	f := newBufferedFile(open(), false)
+	_, err := f.Write([]byte("a\nb"))
+	require.NoError(t, err)
+	require.Equal(t, "", read())
+	require.NoError(t, f.Sync())
+	require.Equal(t, "a\nb", read())
+
+	f = newBufferedFile(open(), true)
+	_, err = f.Write([]byte("a\nb"))
+	require.NoError(t, err)
+	require.Equal(t, "a\nb", read())
+	_, err = f.Write([]byte("c"))
+	require.NoError(t, err)
+	require.Equal(t, "a\nb", read())
+	require.NoError(t, f.Close())
+	require.Equal(t, "a\nbc", read())
+}
@@ -39,7 +39,7 @@ func init() {
 
 func SetFS(fs Filesystem) {
 	if fs == nil {
-		fs = localFS{}
+		fs = defaultFilesystem()
 	}
 	// TODO: mount stdout, stderr, stdin
 	defaultFS = &filesystem{
@@ -64,6 +64,12 @@ func ByFD(fd uintptr) *File {
 	return f
 }
 
+// LookupFD returns the file for a descriptor, or nil if it is not open. Unlike ByFD, it does not set errno.
+func LookupFD(fd uintptr) *File {
+	f, _ := defaultFS.fileByFD(fd)
+	return f
+}
+
 type filesystem struct {
 	fs Filesystem
 	sync.RWMutex
@@ -0,0 +1,8 @@
+//go:build !js
+// +build !js
+
+package stdio
+
+func defaultFilesystem() Filesystem {
+	return localFS{}
+}
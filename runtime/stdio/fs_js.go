@@ -0,0 +1,6 @@
+package stdio
+
+// defaultFilesystem returns an in-memory filesystem, since browsers provide no filesystem to js programs.
+func defaultFilesystem() Filesystem {
+	return NewMemFS()
+}
@@ -0,0 +1,294 @@
+package stdio
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewMemFS creates an in-memory filesystem. Standard streams are mapped to the ones of the process.
+//
+// It is used by default on js, where the OS filesystem is not available in browsers.
+func NewMemFS() *MemFS {
+	return &MemFS{
+		wd:    "/",
+		files: make(map[string]*memData),
+		dirs:  map[string]struct{}{"/": {}},
+		fd:    3,
+	}
+}
+
+// MemFS is an in-memory filesystem; see NewMemFS.
+type MemFS struct {
+	mu    sync.Mutex
+	wd    string
+	files map[string]*memData
+	dirs  map[string]struct{}
+	fd    uintptr
+}
+
+type memData struct {
+	data  []byte
+	mode  os.FileMode
+	mtime time.Time
+}
+
+func (*MemFS) Stdout() FileI {
+	return os.Stdout
+}
+
+func (*MemFS) Stderr() FileI {
+	return os.Stderr
+}
+
+func (*MemFS) Stdin() FileI {
+	return os.Stdin
+}
+
+func (m *MemFS) abs(p string) string {
+	if !path.IsAbs(p) {
+		p = path.Join(m.wd, p)
+	}
+	return path.Clean(p)
+}
+
+func (m *MemFS) Getwd() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.wd, nil
+}
+
+func (m *MemFS) Chdir(p string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p = m.abs(p)
+	if _, ok := m.dirs[p]; !ok {
+		return &fs.PathError{Op: "chdir", Path: p, Err: fs.ErrNotExist}
+	}
+	m.wd = p
+	return nil
+}
+
+// Mkdir creates a directory with all its parents.
+func (m *MemFS) Mkdir(p string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for p = m.abs(p); p != "/"; p = path.Dir(p) {
+		m.dirs[p] = struct{}{}
+	}
+}
+
+func (m *MemFS) Rmdir(p string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p = m.abs(p)
+	if _, ok := m.dirs[p]; !ok {
+		return &fs.PathError{Op: "rmdir", Path: p, Err: fs.ErrNotExist}
+	}
+	// same as localFS, which removes the directory recursively
+	pref := strings.TrimSuffix(p, "/") + "/"
+	for name := range m.files {
+		if strings.HasPrefix(name, pref) {
+			delete(m.files, name)
+		}
+	}
+	for name := range m.dirs {
+		if name != "/" && (name == p || strings.HasPrefix(name, pref)) {
+			delete(m.dirs, name)
+		}
+	}
+	return nil
+}
+
+func (m *MemFS) Unlink(p string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p = m.abs(p)
+	if _, ok := m.files[p]; !ok {
+		return &fs.PathError{Op: "remove", Path: p, Err: fs.ErrNotExist}
+	}
+	delete(m.files, p)
+	return nil
+}
+
+func (m *MemFS) Stat(p string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p = m.abs(p)
+	if d, ok := m.files[p]; ok {
+		return &memInfo{name: path.Base(p), size: int64(len(d.data)), mode: d.mode, mtime: d.mtime}, nil
+	}
+	if _, ok := m.dirs[p]; ok {
+		return &memInfo{name: path.Base(p), mode: fs.ModeDir | 0755}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: p, Err: fs.ErrNotExist}
+}
+
+func (m *MemFS) Open(p string, flag int, mode os.FileMode) (FileI, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p = m.abs(p)
+	d, ok := m.files[p]
+	switch {
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrExist}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+	case !ok:
+		if _, ok := m.dirs[path.Dir(p)]; !ok {
+			return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+		}
+		d = &memData{mode: mode, mtime: time.Now()}
+		m.files[p] = d
+	}
+	if flag&os.O_TRUNC != 0 {
+		d.data = d.data[:0]
+		d.mtime = time.Now()
+	}
+	f := &memFile{fs: m, name: p, d: d, fd: m.fd, flag: flag}
+	m.fd++
+	return f, nil
+}
+
+// WriteFile creates or replaces a file with given contents. Parent directories are created as well.
+func (m *MemFS) WriteFile(p string, data []byte) {
+	m.Mkdir(path.Dir(p))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[m.abs(p)] = &memData{data: append([]byte{}, data...), mode: defPermFile, mtime: time.Now()}
+}
+
+// ReadFile returns contents of a file.
+func (m *MemFS) ReadFile(p string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p = m.abs(p)
+	d, ok := m.files[p]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+	}
+	return append([]byte{}, d.data...), nil
+}
+
+// Names returns names of all files in the filesystem.
+func (m *MemFS) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []string
+	for name := range m.files {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+type memFile struct {
+	fs     *MemFS
+	name   string
+	d      *memData
+	fd     uintptr
+	flag   int
+	off    int64
+	closed bool
+}
+
+var errMemClosed = errors.New("file already closed")
+
+func (f *memFile) Fd() uintptr {
+	return f.fd
+}
+
+func (f *memFile) Name() string {
+	return f.name
+}
+
+func (f *memFile) Sync() error {
+	return nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return 0, errMemClosed
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrPermission}
+	}
+	if f.off >= int64(len(f.d.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.d.data[f.off:])
+	f.off += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return 0, errMemClosed
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == os.O_RDONLY {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.off = int64(len(f.d.data))
+	}
+	if end := f.off + int64(len(p)); end > int64(len(f.d.data)) {
+		f.d.data = append(f.d.data, make([]byte, end-int64(len(f.d.data)))...)
+	}
+	n := copy(f.d.data[f.off:], p)
+	f.off += int64(n)
+	f.d.mtime = time.Now()
+	return n, nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return 0, errMemClosed
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += int64(len(f.d.data))
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	f.off = offset
+	return offset, nil
+}
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return errMemClosed
+	}
+	f.closed = true
+	return nil
+}
+
+type memInfo struct {
+	name  string
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+}
+
+func (fi *memInfo) Name() string       { return fi.name }
+func (fi *memInfo) Size() int64        { return fi.size }
+func (fi *memInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *memInfo) ModTime() time.Time { return fi.mtime }
+func (fi *memInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *memInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,67 @@
+package stdio
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemFS(t *testing.T) {
+	m := NewMemFS()
+	_, err := m.Open("a.txt", os.O_RDONLY, 0)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	f, err := m.Open("a.txt", os.O_CREATE|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	f, err = m.Open("/a.txt", os.O_APPEND|os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte(" world"))
+	require.NoError(t, err)
+	_, err = f.Seek(6, io.SeekStart)
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "world", string(data))
+
+	fi, err := m.Stat("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, int64(11), fi.Size())
+
+	m.WriteFile("/dir/b.txt", []byte("b"))
+	require.NoError(t, m.Chdir("dir"))
+	data, err = m.ReadFile("b.txt")
+	require.NoError(t, err)
+	require.Equal(t, "b", string(data))
+	require.Equal(t, []string{"/a.txt", "/dir/b.txt"}, m.Names())
+
+	require.NoError(t, m.Rmdir("/dir"))
+	require.NoError(t, m.Unlink("/a.txt"))
+	require.Empty(t, m.Names())
+}
+
+func TestFileClose(t *testing.T) {
+	m := NewMemFS()
+	m.WriteFile("/a.txt", []byte("a"))
+	SetFS(m)
+	defer SetFS(nil)
+
+	f := FOpen("a.txt", "r")
+	require.NotNil(t, f)
+	require.Equal(t, f, ByFD(f.FileNo()))
+	require.Equal(t, int32(0), f.Close())
+	require.Nil(t, ByFD(f.FileNo()))
+	require.Nil(t, LookupFD(f.FileNo()))
+	require.Equal(t, int32(-1), f.Close())
+
+	f = Finalize(FOpen("a.txt", "r"))
+	require.NotNil(t, f)
+	require.Nil(t, ByFD(f.FileNo()))
+	require.Equal(t, int32(0), f.Close())
+	require.Nil(t, Finalize(nil))
+}
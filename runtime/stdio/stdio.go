@@ -4,6 +4,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"runtime"
 	"strings"
 	"unsafe"
 
@@ -141,6 +142,9 @@ type File struct {
 	file FileI
 	err  error
 	c    *int
+	// closed is set after the first Close, so a deferred or finalizer Close is a no-op.
+	// It also prevents removing another file with the same descriptor from the descriptor table.
+	closed bool
 }
 
 func (f *File) SetErr(err error) {
@@ -175,18 +179,36 @@ func (f *File) Flush() int32 {
 }
 
 func (f *File) Close() int32 {
-	if f == nil {
+	if f == nil || f.closed {
 		return -1
 	}
+	f.closed = true
 	if err := f.file.Close(); err != nil {
 		f.err = err
 	}
 	f.fs.Lock()
-	delete(f.fs.byFD, f.fd)
+	if f.fs.byFD[f.fd] == f {
+		delete(f.fs.byFD, f.fd)
+	}
 	f.fs.Unlock()
 	return 0 // TODO
 }
 
+// Finalize closes the file when it becomes unreachable, in case the program never closes it.
+// The file is removed from the descriptor table, thus its descriptor cannot be used with functions like read.
+func Finalize(f *File) *File {
+	if f == nil {
+		return nil
+	}
+	f.fs.Lock()
+	if f.fs.byFD[f.fd] == f {
+		delete(f.fs.byFD, f.fd)
+	}
+	f.fs.Unlock()
+	runtime.SetFinalizer(f, (*File).Close)
+	return f
+}
+
 func (f *File) WriteN(p *byte, size, cnt int) int32 {
 	n := f.Write(p, size*cnt)
 	if n > 0 {
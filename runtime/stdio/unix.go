@@ -26,7 +26,7 @@ func Open(path *byte, flags int32, ctls ...interface{}) uintptr {
 	f := defaultFS.Open(spath, os.O_RDONLY)
 	log.Printf("open(%q, %x): %v", spath, flags, f)
 	if f == nil {
-		return 0
+		return ^uintptr(0)
 	}
 	return f.FileNo()
 }
@@ -0,0 +1,80 @@
+package cxgo
+
+import "errors"
+
+// pendingFile is a translated file that is written only after all files of the project are translated.
+type pendingFile struct {
+	out    string
+	gofile string
+	pkg    string
+	decls  []GoDecl
+	conf   Config
+}
+
+func (g *translator) compileRoots() error {
+	for _, s := range g.conf.Roots {
+		r, err := compileSkipRule(s)
+		if err != nil {
+			return err
+		}
+		g.roots = append(g.roots, r)
+	}
+	return nil
+}
+
+// isRoot checks if the declaration with a given C name is an entry point for Config.TreeShake.
+func (g *translator) isRoot(name string) bool {
+	if len(g.roots) == 0 {
+		return name == "main"
+	}
+	for i := range g.roots {
+		r := &g.roots[i]
+		if r.matchFile(g.cur) && r.matchName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush writes all files that were delayed because of Config.TreeShake, as well as weak C definitions that were not
+// replaced by strong definitions from other files, and the shared file for Config.InlineHeaders.
+//
+// Declarations are removed only if they are not reachable from roots of any file translated by the project,
+// thus a function defined in one file and used in another one is preserved. Flush does nothing if tree shaking
+// was not enabled for any of the files.
+func (p *TranslatorProject) Flush() error {
+	if err := p.flushWeak(); err != nil {
+		return err
+	}
+	if err := p.flushInline(); err != nil {
+		return err
+	}
+	pending := p.pending
+	roots := p.roots
+	p.pending, p.roots = nil, nil
+	if len(pending) == 0 {
+		return nil
+	}
+	if len(roots) == 0 {
+		return errors.New("tree shaking: none of the root declarations were found")
+	}
+	var all []GoDecl
+	for _, f := range pending {
+		all = append(all, f.decls...)
+	}
+	keep := reachableDecls(all, roots)
+	i := 0
+	for _, f := range pending {
+		var decls []GoDecl
+		for _, d := range f.decls {
+			if keep[i] {
+				decls = append(decls, d)
+			}
+			i++
+		}
+		if err := p.writeFile(f.out, f.gofile, f.pkg, decls, f.conf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
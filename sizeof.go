@@ -0,0 +1,104 @@
+package cxgo
+
+import (
+	"fmt"
+	gotypes "go/types"
+
+	"modernc.org/cc/v3"
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// hintChanged checks if the Go type t has a different representation than the default conversion of the C type,
+// because of a type hint in the config (see IdentConfig.Type). Such types have a different size in Go.
+func (g *translator) hintChanged(def, t types.Type) bool {
+	if types.Same(def, t) {
+		return false
+	}
+	switch ut := types.Unwrap(t).(type) {
+	case types.ArrayType:
+		if ut.IsSlice() {
+			return true
+		}
+	case types.BoolType:
+		return !def.Kind().IsBool()
+	}
+	return t == g.env.Go().String() || t == g.env.Go().Any()
+}
+
+// hintedField returns the path of a struct field with a type changed by a type hint, or an empty string.
+// Such structs have a different size and layout than in C.
+func (g *translator) hintedField(ct cc.Type, t types.Type, where token.Position) string {
+	switch ct.Kind() {
+	case cc.Array:
+		if at, ok := types.Unwrap(t).(types.ArrayType); ok && !at.IsSlice() {
+			return g.hintedField(ct.Elem(), at.Elem(), where)
+		}
+	case cc.Struct, cc.Union:
+		st, ok := types.Unwrap(t).(*types.StructType)
+		if !ok {
+			return ""
+		}
+		fields := make(map[string]types.Type)
+		for _, f := range st.Fields() {
+			if f.Name != nil {
+				fields[f.Name.Name] = f.Type()
+			}
+		}
+		for i := 0; i < ct.NumField(); i++ {
+			f := ct.FieldByIndex([]int{i})
+			if f.Name() == 0 {
+				continue
+			}
+			ft, ok := fields[f.Name().String()]
+			if !ok {
+				continue
+			}
+			if g.hintChanged(g.convertType(IdentConfig{}, f.Type(), where), ft) {
+				return f.Name().String()
+			}
+			if sub := g.hintedField(f.Type(), ft, where); sub != "" {
+				return f.Name().String() + "." + sub
+			}
+		}
+	}
+	return ""
+}
+
+func (g *translator) sizeofNote(where token.Position, msg string) {
+	if g.conf.OnNote != nil {
+		g.conf.OnNote(Note{Where: where, Msg: msg})
+	}
+}
+
+// convertSizeofExpr converts "sizeof x". If the type of x is changed by a type hint, for example a pointer
+// becomes a Go slice, unsafe.Sizeof would return the size of the Go representation, thus the C size is used instead.
+func (g *translator) convertSizeofExpr(d *cc.UnaryExpression) Expr {
+	x := g.convertUnaryExpr(d.UnaryExpression)
+	ct := d.UnaryExpression.Operand.Type()
+	if ct.Kind() != cc.Invalid && ct.Size() > 0 {
+		xt := x.CType(nil)
+		if g.hintChanged(g.convertType(IdentConfig{}, ct, d.Position()), xt) {
+			g.sizeofNote(d.Position(), fmt.Sprintf("sizeof uses the C size %d of %v, since the type is changed to %v by the config", ct.Size(), ct, gotypes.ExprString(xt.GoType())))
+			return g.cCast(g.env.UintPtrT(), cUintLit(uint64(ct.Size()), 10))
+		}
+		g.checkSizeofType(ct, xt, d.Position(), "sizeof")
+	}
+	return g.NewCUnaryExprT(
+		UnarySizeof,
+		x,
+		g.convertTypeOper(d.Operand, d.Position()),
+	)
+}
+
+// checkSizeofType reports sizeof and alignof of structs with fields changed by type hints, since they are different
+// from C. The Go size is still used, since it's usually passed to malloc.
+func (g *translator) checkSizeofType(ct cc.Type, t types.Type, where token.Position, op string) {
+	if g.conf.OnNote == nil {
+		return
+	}
+	if f := g.hintedField(ct, t, where); f != "" {
+		g.sizeofNote(where, fmt.Sprintf("%s(%v) differs from C, since the type of field %s is changed by the config", op, ct, f))
+	}
+}
@@ -0,0 +1,103 @@
+package cxgo
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// skipRule is a compiled SkipDecl entry.
+type skipRule struct {
+	file  string         // optional file glob
+	exact string         // exact identifier name
+	glob  string         // identifier glob
+	re    *regexp.Regexp // identifier regexp
+}
+
+// compileSkipRule parses a single SkipDecl entry.
+//
+// Supported forms are:
+//
+//	name          - exact identifier name
+//	test_*        - glob pattern (see path.Match)
+//	/^test_.*$/   - regular expression
+//	tests.c:rule  - any of the above, applied only to files matching a glob
+func compileSkipRule(s string) (skipRule, error) {
+	var r skipRule
+	if !strings.HasPrefix(s, "/") {
+		if i := strings.Index(s, ":"); i >= 0 {
+			r.file, s = s[:i], s[i+1:]
+			if _, err := path.Match(r.file, ""); err != nil {
+				return r, fmt.Errorf("invalid file pattern %q: %w", r.file, err)
+			}
+		}
+	}
+	switch {
+	case len(s) >= 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/"):
+		re, err := regexp.Compile(s[1 : len(s)-1])
+		if err != nil {
+			return r, fmt.Errorf("invalid skip regexp %q: %w", s, err)
+		}
+		r.re = re
+	case strings.ContainsAny(s, "*?["):
+		if _, err := path.Match(s, ""); err != nil {
+			return r, fmt.Errorf("invalid skip pattern %q: %w", s, err)
+		}
+		r.glob = s
+	default:
+		r.exact = s
+	}
+	return r, nil
+}
+
+func (r *skipRule) matchFile(fname string) bool {
+	if r.file == "" {
+		return true
+	}
+	if ok, _ := path.Match(r.file, fname); ok {
+		return true
+	}
+	ok, _ := path.Match(r.file, path.Base(fname))
+	return ok
+}
+
+func (r *skipRule) matchName(name string) bool {
+	switch {
+	case r.re != nil:
+		return r.re.MatchString(name)
+	case r.glob != "":
+		ok, _ := path.Match(r.glob, name)
+		return ok
+	default:
+		return r.exact == name
+	}
+}
+
+func (g *translator) compileSkip() error {
+	for s, skip := range g.conf.SkipDecl {
+		if !skip {
+			continue
+		}
+		r, err := compileSkipRule(s)
+		if err != nil {
+			return err
+		}
+		g.skip = append(g.skip, r)
+	}
+	return nil
+}
+
+// isSkipped checks if the declaration with a given C name should be omitted from the output.
+func (g *translator) isSkipped(name string) bool {
+	if g.conf.SkipDecl[name] {
+		return true
+	}
+	for i := range g.skip {
+		r := &g.skip[i]
+		if r.matchFile(g.cur) && r.matchName(name) {
+			return true
+		}
+	}
+	return false
+}
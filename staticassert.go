@@ -0,0 +1,173 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/gotranspile/cxgo/types"
+	"modernc.org/cc/v3"
+)
+
+// staticAssertPrefix is a name prefix of variables declared by the _Static_assert macro:
+//
+//	const char* _cxgo_static_assert_N = (cond) ? "message" : "";
+//
+// The parser doesn't support static assertions, thus the macro is defined in the builtin header.
+const staticAssertPrefix = "_cxgo_static_assert_"
+
+// isStaticAssert checks if the declarator was generated by the _Static_assert macro.
+func isStaticAssert(id *cc.InitDeclarator) bool {
+	return id.Initializer != nil && strings.HasPrefix(id.Declarator.Name().String(), staticAssertPrefix)
+}
+
+// convertStaticAssert converts a declaration generated by the _Static_assert macro.
+func (g *translator) convertStaticAssert(id *cc.InitDeclarator) *CStaticAssert {
+	a := id.Initializer.AssignmentExpression
+	if id.Initializer.Case != cc.InitializerExpr || a.Case != cc.AssignmentExpressionCond || a.ConditionalExpression.Case != cc.ConditionalExpressionCond {
+		panic(unsupported(id, id.Case))
+	}
+	c := a.ConditionalExpression
+	s := &CStaticAssert{Cond: g.ToBool(g.convertLOrExpr(c.LogicalOrExpression))}
+	if lit, ok := cUnwrap(g.convertExpr(c.Expression)).(StringLit); ok {
+		s.Msg = lit.Value()
+	}
+	s.checks = g.staticAssertChecks(s.Cond)
+	return s
+}
+
+// staticAssertChecks returns array lengths that are negative (or overflow) if the condition is false.
+// It returns nil if the condition cannot be checked by the Go compiler.
+func (g *translator) staticAssertChecks(c BoolExpr) []Expr {
+	sub := func(x, y Expr) Expr {
+		return g.NewCBinaryExpr(x, BinOpSub, y)
+	}
+	switch c := c.(type) {
+	case *BinaryBoolExpr:
+		if c.Op != BinOpAnd {
+			return nil
+		}
+		x, y := g.staticAssertChecks(c.X), g.staticAssertChecks(c.Y)
+		if x == nil || y == nil {
+			return nil
+		}
+		return append(x, y...)
+	case *Comparison:
+		if !g.isGoConst(c.X) || !g.isGoConst(c.Y) {
+			return nil
+		}
+		switch c.Op {
+		case BinOpEq:
+			return []Expr{sub(c.X, c.Y), sub(c.Y, c.X)}
+		case BinOpGte:
+			return []Expr{sub(c.X, c.Y)}
+		case BinOpGt:
+			return []Expr{sub(sub(c.X, c.Y), cIntLit(1, 10))}
+		case BinOpLte:
+			return []Expr{sub(c.Y, c.X)}
+		case BinOpLt:
+			return []Expr{sub(sub(c.Y, c.X), cIntLit(1, 10))}
+		}
+	}
+	return nil
+}
+
+// isGoConst checks if the expression is a Go constant, including constants defined by macros.
+func (g *translator) isGoConst(e Expr) bool {
+	if e.IsConst() {
+		return true
+	}
+	switch e := e.(type) {
+	case IdentExpr:
+		m, ok := g.macros[e.Name]
+		return ok && m == e.Ident && (e.CType(nil).Kind().IsInt() || e.CType(nil).Kind().IsFloat())
+	case *CBinaryExpr:
+		return g.isGoConst(e.Left) && g.isGoConst(e.Right)
+	case *CCastExpr:
+		return !e.Assert && g.isGoConst(e.Expr)
+	case *CParentExpr:
+		return g.isGoConst(e.Expr)
+	}
+	return false
+}
+
+var (
+	_ CDecl = (*CStaticAssert)(nil)
+	_ CStmt = (*CStaticAssert)(nil)
+)
+
+// CStaticAssert is a C static assertion. Comparisons of constants are checked by the Go compiler:
+//
+//	_Static_assert(sizeof(T) == 8, "msg") -> var _ [unsafe.Sizeof(T{}) - 8]struct{}
+//
+// Other conditions are checked when the package is initialized, or when the statement is executed in a function.
+type CStaticAssert struct {
+	Cond   BoolExpr
+	Msg    string
+	checks []Expr
+}
+
+func (s *CStaticAssert) Visit(v Visitor) {
+	v(s.Cond)
+}
+
+func (s *CStaticAssert) comment() *ast.CommentGroup {
+	text := "// static assertion"
+	if s.Msg != "" {
+		text += ": " + s.Msg
+	}
+	return &ast.CommentGroup{List: []*ast.Comment{{Text: text}}}
+}
+
+// checkDecl returns compile-time checks, or nil if the condition must be checked at runtime.
+func (s *CStaticAssert) checkDecl() *ast.GenDecl {
+	if len(s.checks) == 0 {
+		return nil
+	}
+	d := &ast.GenDecl{Tok: token.VAR, Doc: s.comment()}
+	for _, n := range s.checks {
+		d.Specs = append(d.Specs, &ast.ValueSpec{
+			Names: []*ast.Ident{ident("_")},
+			Type:  &ast.ArrayType{Len: n.AsExpr(), Elt: ident("struct{}")},
+		})
+	}
+	return d
+}
+
+// checkStmt returns a runtime check of the condition.
+func (s *CStaticAssert) checkStmt() GoStmt {
+	msg := "static assertion failed"
+	if s.Msg != "" {
+		msg += ": " + s.Msg
+	}
+	return &ast.IfStmt{
+		Cond: s.Cond.Negate().AsExpr(),
+		Body: &ast.BlockStmt{List: []GoStmt{
+			&ast.ExprStmt{X: call(ident("panic"), &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(msg)})},
+		}},
+	}
+}
+
+func (s *CStaticAssert) AsDecl() []GoDecl {
+	if d := s.checkDecl(); d != nil {
+		return []GoDecl{d}
+	}
+	return []GoDecl{&ast.FuncDecl{
+		Doc:  s.comment(),
+		Name: ident("init"),
+		Type: &ast.FuncType{Params: &ast.FieldList{}},
+		Body: &ast.BlockStmt{List: []GoStmt{s.checkStmt()}},
+	}}
+}
+
+func (s *CStaticAssert) AsStmt() []GoStmt {
+	if d := s.checkDecl(); d != nil {
+		return []GoStmt{&ast.DeclStmt{Decl: d}}
+	}
+	return []GoStmt{s.checkStmt()}
+}
+
+func (s *CStaticAssert) Uses() []types.Usage {
+	return types.UseRead(s.Cond)
+}
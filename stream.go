@@ -0,0 +1,150 @@
+package cxgo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	token2 "go/token"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// StreamOutput is an Output that can also write files incrementally; see Config.Stream.
+type StreamOutput interface {
+	Output
+	// Create creates a file with a given path. The file is complete when the writer is closed.
+	Create(path string) (io.WriteCloser, error)
+}
+
+func (o dirOutput) Create(path string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// replaceAll runs replacements defined in the config.
+func replaceAll(reps []Replacer, data []byte) []byte {
+	for _, rep := range reps {
+		if rep.Re != nil {
+			data = rep.Re.ReplaceAll(data, []byte(rep.New))
+		} else {
+			data = bytes.ReplaceAll(data, []byte(rep.Old), []byte(rep.New))
+		}
+	}
+	return data
+}
+
+// writeGoFileStream is the same as writeGoFileTag, but formats and writes declarations one by one,
+// thus the memory used for printing is bounded by the largest declaration, not by the file size.
+// Replacements are applied to each declaration separately. Declarations themselves are still built in memory.
+func writeGoFileStream(env *libs.Env, gopath, pkg, tag string, decls []GoDecl, conf Config) error {
+	so, ok := conf.output().(StreamOutput)
+	if !ok {
+		var buf bytes.Buffer
+		if err := printGoStream(&buf, env, pkg, tag, decls, conf); err != nil {
+			return fmt.Errorf("%w %s: %w", ErrFormat, filepath.Base(gopath), err)
+		}
+		return conf.output().WriteFile(gopath, buf.Bytes())
+	}
+	f, err := so.Create(gopath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	if err = printGoStream(w, env, pkg, tag, decls, conf); err != nil {
+		return fmt.Errorf("%w %s: %w", ErrFormat, filepath.Base(gopath), err)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func printGoStream(w io.Writer, env *libs.Env, pkg, tag string, decls []GoDecl, conf Config) error {
+	var buf bytes.Buffer
+	if tag != "" {
+		fmt.Fprintf(&buf, "//go:build %s\n\n", tag)
+	}
+	if err := writeHeader(&buf, conf); err != nil {
+		return err
+	}
+	if conf.DoNotEdit {
+		buf.WriteString("// Code generated by cxgo. DO NOT EDIT.\n\n")
+	}
+	fmt.Fprintf(&buf, "package %s\n", pkg)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	header := importsFor(env, decls, conf.ImportPaths, conf.ImportAliases)
+	fset := token2.NewFileSet()
+	prev := token2.ILLEGAL
+	for _, list := range [][]GoDecl{header, decls} {
+		for _, d := range list {
+			buf.Reset()
+			// same as go/printer: separate declarations of different kinds, or with doc comments, by an empty line
+			tok, doc := declToken(d)
+			if tok != prev || doc {
+				buf.WriteByte('\n')
+			}
+			prev = tok
+			if err := printDecl(&buf, fset, d); err != nil {
+				return err
+			}
+			buf.WriteByte('\n')
+			data := buf.Bytes()
+			if len(conf.Replace) != 0 {
+				data = replaceAll(conf.Replace, data)
+				fdata, err := format.Source(data)
+				if err != nil {
+					return err
+				}
+				data = fdata
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// printDecl prints a single declaration. The printer only handles doc comments for files, thus they are written separately.
+func printDecl(buf *bytes.Buffer, fset *token2.FileSet, d GoDecl) error {
+	var doc **ast.CommentGroup
+	switch d := d.(type) {
+	case *ast.GenDecl:
+		doc = &d.Doc
+	case *ast.FuncDecl:
+		doc = &d.Doc
+	}
+	if doc != nil && *doc != nil {
+		g := *doc
+		for _, c := range g.List {
+			buf.WriteString(c.Text)
+			buf.WriteByte('\n')
+		}
+		*doc = nil
+		defer func() {
+			*doc = g
+		}()
+	}
+	return format.Node(buf, fset, d)
+}
+
+// declToken returns the token of a declaration and reports if it has a doc comment.
+func declToken(d GoDecl) (token2.Token, bool) {
+	switch d := d.(type) {
+	case *ast.GenDecl:
+		return d.Tok, d.Doc != nil
+	case *ast.FuncDecl:
+		return token2.FUNC, d.Doc != nil
+	}
+	return token2.ILLEGAL, false
+}
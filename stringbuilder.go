@@ -0,0 +1,352 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// builderReaders are functions that only read a C string from their arguments; see Config.StringBuilders.
+// Functions returning pointers into the string (strchr, strstr) are not listed, since they would point to a copy.
+var builderReaders = map[string][]int{
+	"libc.StrLen":      {0},
+	"libc.StrCmp":      {0, 1},
+	"libc.StrNCmp":     {0, 1},
+	"libc.StrCaseCmp":  {0, 1},
+	"libc.StrNCaseCmp": {0, 1},
+	"libc.StrCpy":      {1},
+	"libc.StrNCpy":     {1},
+	"libc.StrCat":      {1},
+	"libc.StrNCat":     {1},
+}
+
+// builderPrintf are functions with C format strings that accept Go strings for %s.
+var builderPrintf = map[string]struct{}{
+	"stdio.Printf":   {},
+	"stdio.Fprintf":  {},
+	"stdio.Sprintf":  {},
+	"stdio.Snprintf": {},
+}
+
+// stringBuilders replaces local fixed char buffers that are only built with strcpy, strcat and sprintf, and read
+// as C strings, with strings.Builder, if Config.StringBuilders is set.
+func (g *translator) stringBuilders(decl []CDecl) {
+	if !g.conf.StringBuilders {
+		return
+	}
+	for _, d := range decl {
+		f, ok := d.(*CFuncDecl)
+		if !ok || f.Body == nil {
+			continue
+		}
+		cEachBlockStmt(func(stmts []CStmt) {
+			for i, st := range stmts {
+				ds, ok := st.(*CDeclStmt)
+				if !ok {
+					continue
+				}
+				vd, ok := ds.Decl.(*CVarDecl)
+				if !ok || len(vd.Names) != 1 || len(vd.Inits) != 0 {
+					continue
+				}
+				at, ok := types.Unwrap(vd.Type).(types.ArrayType)
+				if !ok || at.IsSlice() || !types.Same(at.Elem(), g.env.Go().Byte()) {
+					continue
+				}
+				if b := g.stringBuilder(f, vd.Names[0]); b != nil {
+					vd.Type, vd.Names[0] = b.CType(nil), b
+					if i+1 < len(stmts) {
+						// the builder is already empty
+						if s, ok := stmts[i+1].(*CBuilderStmt); ok && s.Builder == b && s.Format == nil && len(s.Args) == 0 {
+							s.Reset = false
+						}
+					}
+				}
+			}
+		}, f.Body.Stmts)
+	}
+}
+
+// stringBuilder rewrites all uses of the buffer in the function and returns the builder variable,
+// or returns nil if the buffer is used in some other way.
+func (g *translator) stringBuilder(f *CFuncDecl, buf *types.Ident) *types.Ident {
+	total, matched := 0, 0
+	Walk(f.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case IdentExpr:
+			if n.Ident == buf {
+				total++
+			}
+		case CStmt:
+			if s := g.builderStmt(n, buf, nil); s != nil {
+				// arguments cannot refer to the buffer
+				cnt := countIdent(n, buf)
+				total += cnt
+				matched += cnt
+				return false
+			}
+		case *CallExpr:
+			matched += len(g.builderReads(n, buf))
+		}
+		return true
+	})
+	// the declaration itself
+	total--
+	if total != matched || total == 0 {
+		return nil
+	}
+	b := types.NewIdentGo(buf.Name, buf.GoIdent().Name, types.NamedTGo("strings.Builder", "strings.Builder", g.env.Go().Any()))
+	Rewrite(f.Body, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case CStmt:
+			if s := g.builderStmt(n, buf, b); s != nil {
+				c.Replace(s)
+				return false
+			}
+		case *CallExpr:
+			if f, ok := n.Fun.(Ident); ok && len(n.Args) == 1 && isBufPtr(n.Args[0], buf) {
+				switch {
+				case f.Identifier() == g.env.StringC2Go():
+					c.Replace(g.newBuilderString(b))
+					return false
+				case f.Identifier().GoName == "libc.StrLen":
+					s := g.newBuilderString(b)
+					s.Len, s.typ = true, g.env.Go().Int()
+					c.Replace(s)
+					return false
+				}
+			}
+			for _, i := range g.builderReads(n, buf) {
+				if _, ok := builderPrintf[n.Fun.(Ident).Identifier().GoName]; ok {
+					n.Args[i] = g.newBuilderString(b)
+				} else {
+					n.Args[i] = g.cCast(g.env.C().String(), g.newBuilderString(b))
+				}
+			}
+		}
+		return true
+	}, nil)
+	return b
+}
+
+// countIdent counts uses of the identifier in the node.
+func countIdent(n Node, id *types.Ident) int {
+	cnt := 0
+	Walk(n, func(n Node) bool {
+		if e, ok := n.(IdentExpr); ok && e.Ident == id {
+			cnt++
+		}
+		return true
+	})
+	return cnt
+}
+
+// isBufPtr matches "&buf[0]", which is the result of the array to pointer decay.
+func isBufPtr(e Expr, buf *types.Ident) bool {
+	addr, ok := cUnwrap(e).(*TakeAddr)
+	if !ok {
+		return false
+	}
+	ind, ok := cUnwrap(addr.X).(*CIndexExpr)
+	if !ok {
+		return false
+	}
+	if id, ok := cUnwrap(ind.Expr).(IdentExpr); !ok || id.Ident != buf {
+		return false
+	}
+	return ind.IndexZero()
+}
+
+// isBufEnd matches "&buf[strlen(buf)]", which is a result of "buf + strlen(buf)".
+func (g *translator) isBufEnd(e Expr, buf *types.Ident) bool {
+	addr, ok := cUnwrap(e).(*TakeAddr)
+	if !ok {
+		return false
+	}
+	ind, ok := cUnwrap(addr.X).(*CIndexExpr)
+	if !ok {
+		return false
+	}
+	if id, ok := cUnwrap(ind.Expr).(IdentExpr); !ok || id.Ident != buf {
+		return false
+	}
+	c, ok := cUnwrap(ind.Index).(*CallExpr)
+	if !ok || len(c.Args) != 1 || !isBufPtr(c.Args[0], buf) {
+		return false
+	}
+	f, ok := c.Fun.(Ident)
+	return ok && f.Identifier().GoName == "libc.StrLen"
+}
+
+// builderStmt matches statements writing the buffer. It returns nil if the statement doesn't write to the buffer,
+// or if the builder cannot be used for it. The builder b may be nil when only checking the statement.
+func (g *translator) builderStmt(st CStmt, buf, b *types.Ident) *CBuilderStmt {
+	switch st := st.(type) {
+	case *CAssignStmt:
+		// buf[0] = 0
+		ind, ok := cUnwrap(st.Left).(*CIndexExpr)
+		if !ok || st.Op != "" || !ind.IndexZero() {
+			return nil
+		}
+		if id, ok := cUnwrap(ind.Expr).(IdentExpr); !ok || id.Ident != buf {
+			return nil
+		}
+		if v, ok := cUnwrap(st.Right).(IntLit); !ok || !v.IsZero() {
+			return nil
+		}
+		return &CBuilderStmt{Builder: b, Reset: true}
+	case *CExprStmt:
+		c, ok := st.Expr.(*CallExpr)
+		if !ok || len(c.Args) < 2 {
+			return nil
+		}
+		f, ok := c.Fun.(Ident)
+		if !ok {
+			return nil
+		}
+		for _, a := range c.Args[1:] {
+			if countIdent(a, buf) != 0 {
+				return nil
+			}
+		}
+		switch name := f.Identifier().GoName; name {
+		case "libc.StrCpy", "libc.StrCat":
+			if len(c.Args) != 2 || !isBufPtr(c.Args[0], buf) {
+				return nil
+			}
+			s := c.Args[1]
+			if lit, ok := g.fromCString(s).(StringLit); ok {
+				s = lit
+			} else {
+				s = g.cCast(g.env.Go().String(), s)
+			}
+			return &CBuilderStmt{Builder: b, Reset: name == "libc.StrCpy", Args: []Expr{s}}
+		case "stdio.Sprintf":
+			reset := isBufPtr(c.Args[0], buf)
+			if !reset && !g.isBufEnd(c.Args[0], buf) {
+				return nil
+			}
+			return &CBuilderStmt{Builder: b, Reset: reset, Format: c.Args[1], Args: c.Args[2:]}
+		}
+	}
+	return nil
+}
+
+// builderReads returns indexes of call arguments that only read the buffer as a C string.
+func (g *translator) builderReads(c *CallExpr, buf *types.Ident) []int {
+	f, ok := c.Fun.(Ident)
+	if !ok {
+		return nil
+	}
+	name := f.Identifier().GoName
+	var list []int
+	if f.Identifier() == g.env.StringC2Go() {
+		if len(c.Args) == 1 && isBufPtr(c.Args[0], buf) {
+			list = append(list, 0)
+		}
+		return list
+	}
+	if _, ok := builderPrintf[name]; ok {
+		ft := c.Fun.FuncType(nil)
+		for i := ft.ArgN(); i < len(c.Args); i++ {
+			if isBufPtr(c.Args[i], buf) {
+				list = append(list, i)
+			}
+		}
+		return list
+	}
+	for _, i := range builderReaders[name] {
+		if i < len(c.Args) && isBufPtr(c.Args[i], buf) {
+			list = append(list, i)
+		}
+	}
+	return list
+}
+
+// CBuilderStmt writes to a strings.Builder that replaced a C buffer; see Config.StringBuilders.
+type CBuilderStmt struct {
+	Builder *types.Ident
+	Reset   bool   // reset the builder before writing
+	Format  Expr   // optional C format, Args are formatted with it
+	Args    []Expr // Go strings to write, or arguments for Format
+}
+
+func (s *CBuilderStmt) Visit(v Visitor) {
+	v(IdentExpr{s.Builder})
+	v(s.Format)
+	for _, a := range s.Args {
+		v(a)
+	}
+}
+
+func (s *CBuilderStmt) AsStmt() []GoStmt {
+	b := s.Builder.GoIdent()
+	var out []GoStmt
+	if s.Reset {
+		out = append(out, &ast.ExprStmt{X: call(&ast.SelectorExpr{X: b, Sel: ident("Reset")})})
+	}
+	if s.Format != nil {
+		args := []GoExpr{&ast.UnaryExpr{Op: token.AND, X: b}, s.Format.AsExpr()}
+		for _, a := range s.Args {
+			args = append(args, a.AsExpr())
+		}
+		out = append(out, &ast.ExprStmt{X: call(ident("stdio.FprintfGo"), args...)})
+		return out
+	}
+	for _, a := range s.Args {
+		out = append(out, &ast.ExprStmt{X: call(&ast.SelectorExpr{X: b, Sel: ident("WriteString")}, a.AsExpr())})
+	}
+	return out
+}
+
+func (s *CBuilderStmt) Uses() []types.Usage {
+	list := []types.Usage{{Ident: s.Builder, Access: types.AccessWrite}}
+	if s.Format != nil {
+		list = append(list, types.UseRead(s.Format)...)
+	}
+	for _, a := range s.Args {
+		list = append(list, types.UseRead(a)...)
+	}
+	return list
+}
+
+var _ Expr = (*CBuilderString)(nil)
+
+func (g *translator) newBuilderString(b *types.Ident) *CBuilderString {
+	return &CBuilderString{typ: g.env.Go().String(), Builder: b}
+}
+
+// CBuilderString returns the contents of a strings.Builder that replaced a C buffer as a Go string.
+type CBuilderString struct {
+	typ     types.Type
+	Builder *types.Ident
+	Len     bool // return the length instead
+}
+
+func (e *CBuilderString) Visit(v Visitor) {
+	v(IdentExpr{e.Builder})
+}
+
+func (e *CBuilderString) CType(types.Type) types.Type {
+	return e.typ
+}
+
+func (e *CBuilderString) AsExpr() GoExpr {
+	if e.Len {
+		return call(&ast.SelectorExpr{X: e.Builder.GoIdent(), Sel: ident("Len")})
+	}
+	return call(&ast.SelectorExpr{X: e.Builder.GoIdent(), Sel: ident("String")})
+}
+
+func (e *CBuilderString) IsConst() bool {
+	return false
+}
+
+func (e *CBuilderString) HasSideEffects() bool {
+	return false
+}
+
+func (e *CBuilderString) Uses() []types.Usage {
+	return []types.Usage{{Ident: e.Builder, Access: types.AccessRead}}
+}
@@ -0,0 +1,130 @@
+package cxgo
+
+import "github.com/gotranspile/cxgo/types"
+
+// strcmpSwitch converts chains like "if (!strcmp(s, "a")) ... else if (!strcmp(s, "b")) ..." to a switch on s,
+// if s is a Go string (see HintString). Chains with unlabeled breaks are preserved, since the switch would capture them.
+func (g *translator) strcmpSwitch(st *CIfStmt) (*CSwitchStmt, bool) {
+	var (
+		x     *types.Ident
+		cases []*CCaseStmt
+		seen  = make(map[string]struct{})
+	)
+	var cur IfElseStmt = st
+loop:
+	for cur != nil {
+		switch s := cur.(type) {
+		case *CIfStmt:
+			id, vals, ok := g.strcmpEqual(s.Cond)
+			if !ok || (x != nil && id != x) {
+				return nil, false
+			}
+			x = id
+			for _, v := range vals {
+				val := v.(StringLit).Value()
+				if _, ok := seen[val]; ok {
+					return nil, false
+				}
+				seen[val] = struct{}{}
+			}
+			cases = append(cases, &CCaseStmt{g: g, Expr: vals[0], Alt: vals[1:], Stmts: s.Then.Stmts})
+			cur = s.Else
+		case *BlockStmt:
+			cases = append(cases, &CCaseStmt{g: g, Stmts: s.Stmts})
+			break loop
+		default:
+			return nil, false
+		}
+	}
+	if len(seen) < 2 {
+		return nil, false
+	}
+	for _, c := range cases {
+		for _, st := range c.Stmts {
+			if hasSwitchBreak(st) {
+				return nil, false
+			}
+		}
+	}
+	for _, c := range cases {
+		// prevent the fallthrough
+		c.Stmts = append(c.Stmts, &CBreakStmt{})
+	}
+	return &CSwitchStmt{g: g, Cond: IdentExpr{x}, Cases: cases}, true
+}
+
+// strcmpEqual matches "strcmp(s, "a") == 0", as well as a disjunction of such comparisons for the same string s,
+// and returns s with the string literals.
+func (g *translator) strcmpEqual(e BoolExpr) (*types.Ident, []Expr, bool) {
+	switch e := e.(type) {
+	case *BinaryBoolExpr:
+		if e.Op != BinOpOr {
+			return nil, nil, false
+		}
+		x, xv, ok := g.strcmpEqual(e.X)
+		if !ok {
+			return nil, nil, false
+		}
+		y, yv, ok := g.strcmpEqual(e.Y)
+		if !ok || x != y {
+			return nil, nil, false
+		}
+		return x, append(xv, yv...), true
+	case *Comparison:
+		if e.Op != BinOpEq {
+			return nil, nil, false
+		}
+		if lit, ok := cUnwrap(e.Y).(IntLit); !ok || !lit.IsZero() {
+			return nil, nil, false
+		}
+		c, ok := cUnwrap(e.X).(*CallExpr)
+		if !ok || len(c.Args) != 2 {
+			return nil, nil, false
+		}
+		if f, ok := c.Fun.(Ident); !ok || f.Identifier().GoName != "libc.StrCmp" {
+			return nil, nil, false
+		}
+		x, y := g.fromCString(c.Args[0]), g.fromCString(c.Args[1])
+		if _, ok := x.(StringLit); ok {
+			x, y = y, x
+		}
+		id, ok := x.(IdentExpr)
+		if !ok || !types.Same(id.CType(nil), g.env.Go().String()) {
+			return nil, nil, false
+		}
+		if lit, ok := y.(StringLit); !ok || lit.IsWide() {
+			return nil, nil, false
+		}
+		return id.Ident, []Expr{y}, true
+	}
+	return nil, nil, false
+}
+
+// fromCString returns the Go string converted to a C string by the expression, or nil.
+func (g *translator) fromCString(e Expr) Expr {
+	c, ok := cUnwrap(e).(*CallExpr)
+	if !ok || len(c.Args) != 1 {
+		return nil
+	}
+	if f, ok := c.Fun.(Ident); !ok || f.Identifier() != g.env.StringGo2C() {
+		return nil
+	}
+	return cUnwrap(c.Args[0])
+}
+
+// hasSwitchBreak checks if the statement has an unlabeled break that is not nested in a loop or a switch.
+func hasSwitchBreak(st CStmt) bool {
+	found := false
+	Walk(st, func(n Node) bool {
+		switch n := n.(type) {
+		case *CForStmt, *CSwitchStmt:
+			return false
+		case *CBreakStmt:
+			if n.Label == "" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
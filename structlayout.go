@@ -0,0 +1,95 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+	"modernc.org/cc/v3"
+)
+
+// structLayout is the layout of a C struct marked with IdentConfig.Layout.
+type structLayout struct {
+	size   int64
+	align  int64
+	fields []fieldLayout
+}
+
+type fieldLayout struct {
+	name   *types.Ident
+	offset int64
+}
+
+// addLayout records the C layout of the struct, if it was marked with IdentConfig.Layout.
+// Bit fields and fields of anonymous structs are not checked.
+func (g *translator) addLayout(conf IdentConfig, t cc.Type, s *types.StructType) {
+	if !conf.Layout || t.Kind() != cc.Struct {
+		return
+	}
+	l := &structLayout{size: int64(t.Size()), align: int64(t.Align())}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.FieldByIndex([]int{i})
+		if f.Name() == 0 || f.IsBitField() {
+			continue
+		}
+		for _, sf := range s.Fields() {
+			if sf.Name.Name == f.Name().String() {
+				l.fields = append(l.fields, fieldLayout{name: sf.Name, offset: int64(f.Offset())})
+				break
+			}
+		}
+	}
+	g.layouts[s] = l
+}
+
+// layoutCheck returns compile-time assertions that the Go type has the same size,
+// alignment and field offsets as the C struct. It returns nil if the type wasn't marked with IdentConfig.Layout.
+//
+//	func _() {
+//		var x [1]struct{}
+//		_ = x[unsafe.Sizeof(T{})-16]
+//		_ = x[unsafe.Offsetof(T{}.F)-8]
+//	}
+//
+// The index is out of range, or overflows, if the values differ.
+func (g *translator) layoutCheck(d *CTypeDef) []GoDecl {
+	s, ok := d.Underlying().(*types.StructType)
+	if !ok {
+		return nil
+	}
+	l := g.layouts[s]
+	if l == nil {
+		return nil
+	}
+	lit := &ast.CompositeLit{Type: d.Name().GoIdent()}
+	check := func(fnc string, arg GoExpr, exp int64) GoStmt {
+		var ind GoExpr = call(ident(fnc), arg)
+		if exp != 0 {
+			ind = &ast.BinaryExpr{X: ind, Op: token.SUB, Y: &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(exp, 10)}}
+		}
+		return &ast.AssignStmt{
+			Lhs: []GoExpr{ident("_")},
+			Tok: token.ASSIGN,
+			Rhs: []GoExpr{&ast.IndexExpr{X: ident("x"), Index: ind}},
+		}
+	}
+	stmts := []GoStmt{
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{
+			&ast.ValueSpec{Names: []*ast.Ident{ident("x")}, Type: &ast.ArrayType{
+				Len: &ast.BasicLit{Kind: token.INT, Value: "1"},
+				Elt: ident("struct{}"),
+			}},
+		}}},
+		check("unsafe.Sizeof", lit, l.size),
+		check("unsafe.Alignof", lit, l.align),
+	}
+	for _, f := range l.fields {
+		stmts = append(stmts, check("unsafe.Offsetof", &ast.SelectorExpr{X: lit, Sel: f.name.GoIdent()}, f.offset))
+	}
+	return []GoDecl{&ast.FuncDecl{
+		Name: ident("_"),
+		Type: &ast.FuncType{Params: &ast.FieldList{}},
+		Body: &ast.BlockStmt{List: stmts},
+	}}
+}
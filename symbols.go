@@ -0,0 +1,80 @@
+package cxgo
+
+import (
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// SymbolKind is a kind of the declaration generated for a C symbol.
+type SymbolKind string
+
+const (
+	SymbolFunc  = SymbolKind("func")
+	SymbolVar   = SymbolKind("var")
+	SymbolConst = SymbolKind("const")
+	SymbolType  = SymbolKind("type")
+)
+
+// Symbol maps a C declaration to the Go declaration generated for it; see Config.OnSymbol.
+type Symbol struct {
+	C      string         // C name
+	Go     string         // Go name
+	Kind   SymbolKind     // kind of the Go declaration
+	GoFile string         // path of the Go file, as written to the output
+	Where  token.Position // position of the C declaration
+}
+
+// symbolTable holds symbols of declarations that are not written yet; see Config.OnSymbol.
+type symbolTable map[GoDecl][]Symbol
+
+// addSymbols records C symbols of the declaration, which are reported when Go declarations are written.
+func (g *translator) addSymbols(d CDecl, out []GoDecl) {
+	if g.symbols == nil || len(out) == 0 {
+		return
+	}
+	pos := g.declPos[d]
+	add := func(kind SymbolKind, id *types.Ident) {
+		if id == nil || id.Name == "" {
+			return
+		}
+		g.symbols[out[0]] = append(g.symbols[out[0]], Symbol{
+			C: id.Name, Go: id.GoIdent().Name, Kind: kind, Where: pos,
+		})
+	}
+	switch d := d.(type) {
+	case *CFuncDecl:
+		add(SymbolFunc, d.Name)
+	case *CgoFuncDecl:
+		add(SymbolFunc, d.Name)
+	case *CVarDecl:
+		kind := SymbolVar
+		if d.Const {
+			kind = SymbolConst
+		}
+		for _, id := range d.Names {
+			add(kind, id)
+		}
+	case *CTypeDef:
+		add(SymbolType, d.Name())
+	}
+}
+
+// reportSymbols calls Config.OnSymbol for all C symbols of declarations written to a Go file.
+// Each symbol is only reported once, even if the file is written multiple times.
+func reportSymbols(gopath string, decls []GoDecl, conf Config) {
+	if conf.OnSymbol == nil || conf.symbols == nil {
+		return
+	}
+	for _, d := range decls {
+		syms, ok := conf.symbols[d]
+		if !ok {
+			continue
+		}
+		delete(conf.symbols, d)
+		for _, s := range syms {
+			s.GoFile = gopath
+			conf.OnSymbol(s)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package cxgo
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// compactTable encodes the initializer of a large global integer array as a string, if Config.CompactTables is set.
+// Elements are written as varints, thus small values take a single byte, and the string is decoded when the package
+// is initialized:
+//
+//	var tbl [1024]int32 = func() (t [1024]int32) {
+//		b, _ := base64.StdEncoding.DecodeString("AgQG...")
+//		for i := range t {
+//			v, n := binary.Varint(b)
+//			t[i] = int32(v)
+//			b = b[n:]
+//		}
+//		return
+//	}()
+//
+// The table is decoded in its own initializer instead of init, thus other initializers that depend on it are ordered
+// correctly, and tree shaking keeps the decoding together with the table. It returns nil if the declaration is not
+// such an array.
+func (g *translator) compactTable(d CDecl) []GoDecl {
+	if g.conf.CompactTables <= 0 {
+		return nil
+	}
+	v, ok := d.(*CVarDecl)
+	if !ok || len(v.Names) != 1 || len(v.Inits) != 1 {
+		return nil
+	}
+	at, ok := types.Unwrap(v.Type).(types.ArrayType)
+	if !ok {
+		return nil
+	}
+	dims, elem, ok := tableDims(at)
+	if !ok {
+		return nil
+	}
+	n := 1
+	for _, d := range dims {
+		n *= d
+	}
+	et := types.Unwrap(elem).(types.IntType)
+	if n < g.conf.CompactTables {
+		return nil
+	}
+	vals := make([]int64, n)
+	if !tableValues(v.Inits[0], dims, vals) {
+		return nil
+	}
+	var data []byte
+	for _, x := range vals {
+		if et.Signed() {
+			data = binary.AppendVarint(data, x)
+		} else {
+			u := uint64(x)
+			if sz := et.Sizeof(); sz < 8 {
+				u &= 1<<(8*sz) - 1
+			}
+			data = binary.AppendUvarint(data, u)
+		}
+	}
+	decode := "binary.Uvarint"
+	if et.Signed() {
+		decode = "binary.Varint"
+	}
+	// t[i][j] = T(v)
+	var (
+		x    GoExpr = ident("t")
+		vars []string
+	)
+	for k := range dims {
+		vars = append(vars, tableLoopVar(k))
+		x = index(x, ident(vars[k]))
+	}
+	var body GoStmt = block(
+		&ast.AssignStmt{Lhs: []GoExpr{ident("v"), ident("n")}, Tok: token.DEFINE, Rhs: []GoExpr{call(ident(decode), ident("b"))}},
+		assign(x, call(elem.GoType(), ident("v"))),
+		assign(ident("b"), &ast.SliceExpr{X: ident("b"), Low: ident("n")}),
+	)
+	for k := len(dims) - 1; k >= 0; k-- {
+		var x GoExpr = ident("t")
+		for _, name := range vars[:k] {
+			x = index(x, ident(name))
+		}
+		body = &ast.RangeStmt{Key: ident(vars[k]), Tok: token.DEFINE, X: x, Body: block(body)}
+	}
+	fnc := &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  noFields(),
+			Results: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ident("t")}, Type: at.GoType()}}},
+		},
+		Body: block(
+			&ast.AssignStmt{
+				Lhs: []GoExpr{ident("b"), ident("_")},
+				Tok: token.DEFINE,
+				Rhs: []GoExpr{call(ident("base64.StdEncoding.DecodeString"), &ast.BasicLit{
+					Kind:  token.STRING,
+					Value: strconv.Quote(base64.StdEncoding.EncodeToString(data)),
+				})},
+			},
+			body,
+			returnStmt(),
+		),
+	}
+	return []GoDecl{&ast.GenDecl{
+		Tok: token.VAR,
+		Specs: []ast.Spec{&ast.ValueSpec{
+			Names:  []*ast.Ident{v.Names[0].GoIdent()},
+			Type:   at.GoType(),
+			Values: []ast.Expr{call(fnc)},
+		}},
+	}}
+}
+
+// tableLoopVar returns a name of the loop variable for a given dimension of the table.
+func tableLoopVar(k int) string {
+	if k < 3 {
+		return string("ijk"[k])
+	}
+	return "i" + strconv.Itoa(k)
+}
+
+// tableDims returns dimensions and the element type of a possibly multidimensional array of integers.
+func tableDims(at types.ArrayType) ([]int, types.Type, bool) {
+	var dims []int
+	for {
+		if at.IsSlice() || at.Len() == 0 {
+			return nil, nil, false
+		}
+		dims = append(dims, at.Len())
+		switch et := types.Unwrap(at.Elem()).(type) {
+		case types.ArrayType:
+			at = et
+		case types.IntType:
+			return dims, at.Elem(), true
+		default:
+			return nil, nil, false
+		}
+	}
+}
+
+// tableValues writes elements of a constant array initializer to a flat list of values.
+func tableValues(e Expr, dims []int, vals []int64) bool {
+	lit, ok := cUnwrap(e).(*CCompLitExpr)
+	if !ok {
+		return false
+	}
+	stride := len(vals) / dims[0]
+	i := 0
+	for _, f := range lit.Fields {
+		if f.Field != nil {
+			return false
+		}
+		if f.Index != nil {
+			ind, ok := constIntValue(f.Index)
+			if !ok {
+				return false
+			}
+			i = int(ind)
+		}
+		if i < 0 || i >= dims[0] {
+			return false
+		}
+		if len(dims) > 1 {
+			if !tableValues(f.Value, dims[1:], vals[i*stride:(i+1)*stride]) {
+				return false
+			}
+		} else if v, ok := constInitValue(f.Value); ok {
+			vals[i] = v
+		} else {
+			return false
+		}
+		i++
+	}
+	return true
+}
@@ -0,0 +1,274 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// CTailCallStmt replaces a self tail call by assigning new values to function arguments and jumping to the beginning
+// of the function body, which is wrapped in a loop; see Config.TailCalls.
+//
+//	a, b = x, y
+//	continue
+type CTailCallStmt struct {
+	Args  []*types.Ident
+	Vals  []Expr
+	Label string // optional loop label
+}
+
+func (s *CTailCallStmt) Visit(v Visitor) {
+	for _, a := range s.Args {
+		v(IdentExpr{a})
+	}
+	for _, x := range s.Vals {
+		v(x)
+	}
+}
+
+func (s *CTailCallStmt) AsStmt() []GoStmt {
+	var out []GoStmt
+	if len(s.Args) != 0 {
+		st := &ast.AssignStmt{Tok: token.ASSIGN}
+		for i, a := range s.Args {
+			st.Lhs = append(st.Lhs, a.GoIdent())
+			st.Rhs = append(st.Rhs, s.Vals[i].AsExpr())
+		}
+		out = append(out, st)
+	}
+	return append(out, (&CContinueStmt{Label: s.Label}).AsStmt()...)
+}
+
+func (s *CTailCallStmt) Uses() []types.Usage {
+	var list []types.Usage
+	for _, a := range s.Args {
+		list = append(list, types.UseWrite(IdentExpr{a})...)
+	}
+	for _, x := range s.Vals {
+		list = append(list, types.UseRead(x)...)
+	}
+	return list
+}
+
+// tailCalls rewrites self tail calls to loops, if Config.TailCalls is set.
+func (g *translator) tailCalls(decl []CDecl) {
+	if !g.conf.TailCalls {
+		return
+	}
+	for _, d := range decl {
+		if f, ok := d.(*CFuncDecl); ok && f.Body != nil {
+			g.tailCallLoop(f)
+		}
+	}
+}
+
+// tailCallLoop wraps the function body in a loop, if it has self tail calls, and replaces those calls with
+// assignments to arguments:
+//
+//	int gcd(int a, int b) {
+//		if (b == 0) return a;
+//		return gcd(b, a % b);
+//	}
+//
+// becomes:
+//
+//	func gcd(a int32, b int32) int32 {
+//		for {
+//			if b == 0 {
+//				return a
+//			}
+//			a, b = b, a%b
+//			continue
+//		}
+//	}
+//
+// Functions that take addresses of arguments or defer calls are not converted, since each call must have its own state.
+func (g *translator) tailCallLoop(f *CFuncDecl) {
+	if f.Type.Variadic() || !canTailCall(f) {
+		return
+	}
+	stmts := f.Body.Stmts
+	if len(stmts) == 0 {
+		return
+	}
+	ret := f.Type.Return()
+	if ret != nil && !g.allBranchesJump(stmts[len(stmts)-1]) {
+		// would loop forever instead of falling through
+		return
+	}
+	t := &tailCalls{f: f, label: tailLabel(f)}
+	stmts = t.rewrite(stmts, true, false)
+	if t.calls == 0 {
+		return
+	}
+	if last := stmts[len(stmts)-1]; ret == nil && !g.allBranchesJump(last) {
+		stmts = append(stmts, &CReturnStmt{})
+	}
+	var out []CStmt
+	if t.labeled {
+		out = append(out, &CLabelStmt{Label: t.label})
+	} else {
+		t.clearLabels(stmts)
+	}
+	out = append(out, &CForStmt{Body: BlockStmt{g: g, Stmts: stmts}})
+	f.Body.Stmts = out
+}
+
+// canTailCall checks that the state of the function is only kept in arguments, thus they can be reassigned.
+func canTailCall(f *CFuncDecl) bool {
+	args := make(map[*types.Ident]struct{})
+	names := make(map[string]struct{})
+	for _, a := range f.Type.Args() {
+		if a.Name == nil {
+			return false
+		}
+		args[a.Name] = struct{}{}
+		names[a.Name.GoIdent().Name] = struct{}{}
+	}
+	ok := true
+	Walk(f.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *CDeferCloseStmt, *CTraceStmt:
+			ok = false
+		case *CVarDecl:
+			for _, id := range n.Names {
+				if _, shadow := names[id.GoIdent().Name]; shadow {
+					ok = false
+				}
+			}
+		case *TakeAddr:
+			Walk(n, func(n Node) bool {
+				if id, isIdent := n.(IdentExpr); isIdent {
+					if _, isArg := args[id.Ident]; isArg {
+						ok = false
+					}
+				}
+				return ok
+			})
+		}
+		return ok
+	})
+	return ok
+}
+
+// selfCall returns the call of the function itself, if the expression is one.
+func selfCall(f *CFuncDecl, e Expr) *CallExpr {
+	c, ok := cUnwrap(e).(*CallExpr)
+	if !ok {
+		return nil
+	}
+	if fn, ok := c.Fun.(FuncIdent); !ok || fn.Ident != f.Name || len(c.Args) != len(f.Type.Args()) {
+		return nil
+	}
+	return c
+}
+
+// tailLabel returns a loop label that is not used in the function.
+func tailLabel(f *CFuncDecl) string {
+	used := make(map[string]struct{})
+	cEachStmt(func(s CStmt) bool {
+		if l, ok := s.(*CLabelStmt); ok {
+			used[l.Label] = struct{}{}
+		}
+		return true
+	}, f.Body.Stmts)
+	name := "tail"
+	for i := 2; ; i++ {
+		if _, ok := used[name]; !ok {
+			return name
+		}
+		name = "tail" + strconv.Itoa(i)
+	}
+}
+
+type tailCalls struct {
+	f       *CFuncDecl
+	label   string
+	calls   int
+	labeled bool // some calls are in nested loops
+}
+
+// rewrite replaces tail calls in the list of statements. If the list is at the end of the function body, the last
+// call of a void function is a tail call as well.
+func (t *tailCalls) rewrite(stmts []CStmt, end, loop bool) []CStmt {
+	out := make([]CStmt, 0, len(stmts))
+	for i := 0; i < len(stmts); i++ {
+		switch s := stmts[i].(type) {
+		case *CReturnStmt:
+			if s.Expr != nil {
+				if c := selfCall(t.f, s.Expr); c != nil {
+					out = append(out, t.tailCall(c, loop))
+					continue
+				}
+			}
+		case *CExprStmt:
+			c := selfCall(t.f, s.Expr)
+			if c == nil || t.f.Type.Return() != nil {
+				break
+			}
+			if i == len(stmts)-1 && end {
+				out = append(out, t.tailCall(c, loop))
+				continue
+			}
+			if r, ok := stmts[i+1].(*CReturnStmt); ok && r.Expr == nil {
+				out = append(out, t.tailCall(c, loop))
+				i++
+				continue
+			}
+		case *BlockStmt:
+			s.Stmts = t.rewrite(s.Stmts, end && i == len(stmts)-1, loop)
+		case *CIfStmt:
+			t.rewriteIf(s, end && i == len(stmts)-1, loop)
+		case *CForStmt:
+			s.Body.Stmts = t.rewrite(s.Body.Stmts, false, true)
+		case *CRangeStmt:
+			s.Body.Stmts = t.rewrite(s.Body.Stmts, false, true)
+		case *CSwitchStmt:
+			for _, c := range s.Cases {
+				c.Stmts = t.rewrite(c.Stmts, false, loop)
+			}
+		}
+		out = append(out, stmts[i])
+	}
+	return out
+}
+
+func (t *tailCalls) rewriteIf(s *CIfStmt, end, loop bool) {
+	s.Then.Stmts = t.rewrite(s.Then.Stmts, end, loop)
+	switch e := s.Else.(type) {
+	case *BlockStmt:
+		e.Stmts = t.rewrite(e.Stmts, end, loop)
+	case *CIfStmt:
+		t.rewriteIf(e, end, loop)
+	}
+}
+
+// tailCall assigns call arguments to function arguments. Arguments passed unchanged are skipped.
+func (t *tailCalls) tailCall(c *CallExpr, loop bool) *CTailCallStmt {
+	t.calls++
+	s := &CTailCallStmt{Label: t.label}
+	if loop {
+		t.labeled = true
+	}
+	for i, a := range t.f.Type.Args() {
+		v := cUnwrap(c.Args[i])
+		if id, ok := v.(IdentExpr); ok && id.Ident == a.Name {
+			continue
+		}
+		s.Args = append(s.Args, a.Name)
+		s.Vals = append(s.Vals, v)
+	}
+	return s
+}
+
+// clearLabels removes the loop label from tail calls, if it is not needed.
+func (t *tailCalls) clearLabels(stmts []CStmt) {
+	Walk(&BlockStmt{Stmts: stmts}, func(n Node) bool {
+		if s, ok := n.(*CTailCallStmt); ok {
+			s.Label = ""
+		}
+		return true
+	})
+}
@@ -0,0 +1,140 @@
+package cxgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// Target is a platform for TargetProject. Each target has its own environment, thus it may use a different data model.
+type Target struct {
+	Name    string        // file name suffix for target-specific declarations, for example "linux_386"
+	Build   string        // Go build constraint for target-specific declarations, for example "linux && 386"
+	Env     *libs.Env     // environment of the target
+	Profile PredefProfile // predefined macros for the target; overrides Config.Profile if set
+	Define  []Define      // defines added only for this target
+}
+
+// TargetProject translates the same C files for multiple targets.
+//
+// Declarations that are the same for all targets are written to a regular Go file,
+// while the rest are written to target-specific files with build constraints.
+type TargetProject struct {
+	targets []Target
+	projs   []*TranslatorProject
+}
+
+// NewTargetProject creates a project for translating C files for given targets.
+func NewTargetProject(targets []Target) (*TargetProject, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("at least one target is required")
+	}
+	p := &TargetProject{targets: targets}
+	names := make(map[string]struct{})
+	for _, t := range targets {
+		if t.Name == "" || t.Env == nil {
+			return nil, errors.New("target name and environment must be set")
+		}
+		if _, ok := names[t.Name]; ok {
+			return nil, fmt.Errorf("duplicate target: %q", t.Name)
+		}
+		names[t.Name] = struct{}{}
+		p.projs = append(p.projs, NewProject(t.Env))
+	}
+	return p, nil
+}
+
+// Translate parses and translates a C file for all targets, and writes Go files to the output directory.
+func (p *TargetProject) Translate(root, fname, out string, conf Config) error {
+	switch {
+	case conf.Layout != LayoutDefault, conf.TreeShake, conf.hasCgo(), len(conf.Benchmarks) != 0:
+		return errors.New("layout, tree shaking, cgo and benchmarks are not supported for multiple targets")
+	}
+	decls := make([][]GoDecl, len(p.targets))
+	for i, t := range p.targets {
+		tconf := conf
+		if t.Profile != ProfileNone {
+			tconf.Profile = t.Profile
+		}
+		tconf.Define = append(append([]Define{}, conf.Define...), t.Define...)
+		tr, err := p.projs[i].parseAndTranslate(root, fname, tconf)
+		if err != nil {
+			return fmt.Errorf("target %s: %w", t.Name, err)
+		}
+		decls[i] = tr.decls
+	}
+	shared, split, err := splitTargetDecls(decls)
+	if err != nil {
+		return err
+	}
+	pkg := conf.packageName()
+	if conf.Output == nil {
+		_ = os.MkdirAll(out, 0755)
+	}
+	gofile, err := goFileName(root, fname, conf)
+	if err != nil {
+		return err
+	}
+	if len(shared) != 0 {
+		sconf := conf
+		sconf.symbols = p.projs[0].symbols
+		if err = p.projs[0].writeFile(out, gofile, pkg, shared, sconf); err != nil {
+			return err
+		}
+	}
+	base := strings.TrimSuffix(gofile, ".go")
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(out, base)
+	}
+	for i, t := range p.targets {
+		if len(split[i]) == 0 {
+			continue
+		}
+		tconf := conf
+		tconf.symbols = p.projs[i].symbols
+		if err = writeGoFileTag(t.Env, base+"_"+t.Name+".go", pkg, t.Build, split[i], tconf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitTargetDecls separates declarations that are identical for all targets from target-specific ones.
+// Shared declarations are returned in the order of the first target.
+func splitTargetDecls(decls [][]GoDecl) (shared []GoDecl, split [][]GoDecl, _ error) {
+	texts := make([][]string, len(decls))
+	count := make(map[string]int)
+	for i, list := range decls {
+		seen := make(map[string]struct{})
+		for _, d := range list {
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), d); err != nil {
+				return nil, nil, err
+			}
+			s := buf.String()
+			texts[i] = append(texts[i], s)
+			if _, ok := seen[s]; !ok {
+				seen[s] = struct{}{}
+				count[s]++
+			}
+		}
+	}
+	split = make([][]GoDecl, len(decls))
+	for i, list := range decls {
+		for j, d := range list {
+			if count[texts[i][j]] != len(decls) {
+				split[i] = append(split[i], d)
+			} else if i == 0 {
+				shared = append(shared, d)
+			}
+		}
+	}
+	return shared, split, nil
+}
@@ -0,0 +1,87 @@
+package cxgo
+
+import (
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// tinygoImports are packages that TinyGo does not support, or runtime packages that depend on them; see Config.TinyGo.
+var tinygoImports = map[string]string{
+	"net":                       "",
+	"os/exec":                   "",
+	"os/signal":                 "",
+	"os/user":                   "",
+	"plugin":                    "",
+	libs.RuntimePrefix + "cnet": "net",
+}
+
+// tinygoNote reports a construct that is not supported by TinyGo.
+func (g *translator) tinygoNote(pos token.Position, msg string) {
+	if g.conf.OnNote == nil {
+		return
+	}
+	g.conf.OnNote(Note{Where: pos, Msg: "TinyGo: " + msg})
+}
+
+// funcAddrIssue describes conversions of function pointers to addresses, which depend on the interface layout
+// of the gc compiler. It returns an empty string for other nodes.
+func funcAddrIssue(n Node) string {
+	switch n := n.(type) {
+	case *FuncToInt, *FuncToPtr:
+		return "function pointer converted to an address"
+	case *IntToFunc, *PtrToFunc:
+		return "address converted to a function pointer"
+	case *FuncComparison:
+		if _, ok := n.Y.(Nil); !ok {
+			return "function pointers compared by address"
+		}
+	}
+	return ""
+}
+
+// declDesc describes a declaration for messages about the translation.
+func declDesc(d CDecl) string {
+	if f, ok := d.(*CFuncDecl); ok {
+		return "function " + f.Name.Name
+	}
+	return "declaration"
+}
+
+// tinygoCheckDecl reports constructs of the declaration that rely on runtime helpers which do not work in TinyGo.
+func (g *translator) tinygoCheckDecl(d CDecl) {
+	if !g.conf.TinyGo {
+		return
+	}
+	pos := g.declPos[d]
+	seen := make(map[string]struct{})
+	Walk(d, func(n Node) bool {
+		msg := funcAddrIssue(n)
+		if msg == "" {
+			return true
+		}
+		if _, ok := seen[msg]; !ok {
+			seen[msg] = struct{}{}
+			g.tinygoNote(pos, msg+" in "+declDesc(d))
+		}
+		return true
+	})
+}
+
+// tinygoCheckImports reports packages used by the generated code that are not supported by TinyGo.
+func (g *translator) tinygoCheckImports(cur string, decls []GoDecl) {
+	if !g.conf.TinyGo {
+		return
+	}
+	for _, path := range usedImportPaths(g.env, decls) {
+		dep, ok := tinygoImports[path]
+		if !ok {
+			continue
+		}
+		msg := "package " + path + " is not supported"
+		if dep != "" {
+			msg = "package " + path + " depends on " + dep + ", which is not supported"
+		}
+		g.tinygoNote(token.Position{Filename: cur}, msg)
+	}
+}
@@ -0,0 +1,57 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// traceFunc inserts a call to the tracing function at the function entry; see Config.Trace.
+func (g *translator) traceFunc(name string, ft *types.FuncType, body *BlockStmt) *BlockStmt {
+	if g.conf.Trace == "" || body == nil {
+		return body
+	}
+	s := &CTraceStmt{Func: g.conf.Trace, Name: name}
+	for _, f := range ft.Args() {
+		if f.Name == nil || f.Name.Name == "" || f.Name.Name == "_" {
+			continue
+		}
+		s.Args = append(s.Args, f.Name)
+	}
+	body.Stmts = append([]CStmt{s}, body.Stmts...)
+	return body
+}
+
+// CTraceStmt calls the tracing function with the function name and arguments on entry,
+// and calls the function it returns on exit:
+//
+//	defer trace("name", a, b)()
+type CTraceStmt struct {
+	Func string
+	Name string
+	Args []*types.Ident
+}
+
+func (s *CTraceStmt) Visit(v Visitor) {
+	for _, a := range s.Args {
+		v(IdentExpr{a})
+	}
+}
+
+func (s *CTraceStmt) AsStmt() []GoStmt {
+	args := []GoExpr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(s.Name)}}
+	for _, a := range s.Args {
+		args = append(args, a.GoIdent())
+	}
+	return []GoStmt{&ast.DeferStmt{Call: call(call(ident(s.Func), args...))}}
+}
+
+func (s *CTraceStmt) Uses() []types.Usage {
+	var list []types.Usage
+	for _, a := range s.Args {
+		list = append(list, types.UseRead(IdentExpr{a})...)
+	}
+	return list
+}
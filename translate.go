@@ -2,11 +2,15 @@ package cxgo
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"go/format"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"modernc.org/cc/v3"
@@ -23,22 +27,84 @@ type Config struct {
 	GoFilePref         string
 	Include            []string
 	SysInclude         []string
+	HostSysInclude     []string // searched after the bundled headers; see DetectHostCompiler
+	HostPredef         string   // predefined macros of the host compiler; see HostCompiler.Predef
 	IncludeMap         map[string]string
 	MaxDecls           int
+	Layout             OutputLayout  // how to split declarations into Go files
+	DeclOrder          DeclOrder     // how to order declarations in Go files
+	Profile            PredefProfile // predefined macros for the platform
+	C23                bool          // support C23 keywords and syntax
+	SourceEncoding     string        // encoding of C files that are not valid UTF-8: latin1 or windows-1252
+	Frontend           Frontend      // parser used to read C files
+	Clang              string        // clang command for FrontendClang, "clang" by default
+	ClangArgs          []string      // additional clang arguments for FrontendClang, for example --target
 	Predef             string
 	Define             []Define
 	FlattenAll         bool
+	FlattenAuto        bool // flatten functions with backward gotos, restoring loops from them where possible
+	FlattenGotos       int  // flatten functions with at least this many gotos
 	ForwardDecl        bool
-	SkipDecl           map[string]bool
+	SkipDecl           map[string]bool // names, globs or /regexps/ of declarations to skip; optionally prefixed with "file.c:"
+	Only               []string        // translate only these functions and declarations they depend on
+	TreeShake          bool            // drop declarations that are not reachable from Roots
+	Roots              []string        // names, globs or /regexps/ of root declarations for TreeShake; "main" by default
 	Idents             []IdentConfig
 	Replace            []Replacer
 	Hooks              bool
 	FixImplicitReturns bool
 	IgnoreIncludeDir   bool
-	UnexportedFields   bool // do not export struct fields for Go
-	IntReformat        bool // automatically select new base for formatting int literals
-	KeepFree           bool // do not rewrite free() calls to nil assignments
-	DoNotEdit          bool // generate DO NOT EDIT header comments
+	UnexportedFields   bool               // do not export struct fields for Go
+	IntReformat        bool               // automatically select new base for formatting int literals
+	KeepFree           bool               // do not rewrite free() calls to nil assignments
+	Header             string             // text/template for the header comment of Go files; see HeaderInfo
+	Version            string             // cxgo version for the header
+	ConfigHash         string             // hash of the config for the header
+	Provenance         bool               // annotate Go declarations with the origin C file and line
+	MacroComments      bool               // annotate constants with the #define they come from, and use them in place of its expansions
+	ImportPaths        map[string]string  // replaces import path prefixes, e.g. to use a fork of the runtime
+	ImportAliases      map[string]string  // import aliases, by the package name used in the generated code
+	FS                 fs.FS              // read C files and includes from this filesystem instead of the OS one
+	Output             Output             // write generated files to this output instead of the OS filesystem
+	Stream             bool               // print declarations one by one instead of formatting whole files in memory
+	DoNotEdit          bool               // generate DO NOT EDIT header comments
+	AnonTypeNames      bool               // generate named types for anonymous structs and unions used as struct fields
+	ContainerOf        bool               // replace the container_of idiom with libc.ContainerOf helper calls
+	VolatileAtomic     bool               // access volatile integer variables with sync/atomic loads and stores
+	WrapSigned         bool               // emit signed arithmetic via unsigned types to rely on wraparound explicitly
+	FixedByteOrder     bool               // access integers via byte pointers with encoding/binary in the target byte order
+	Cgo                bool               // generate cgo bindings for C functions instead of translating function bodies
+	CgoPreamble        string             // C code placed before import "C"; includes the C file by default
+	Coverage           bool               // count executions of blocks by C lines, see runtime/ccover
+	TinyGo             bool               // report constructs that rely on runtime helpers not supported by TinyGo
+	WASM               bool               // fail on syscalls and unsafe pointer arithmetic that break on js/wasm
+	PtrIntCast         PtrIntCast         // how casts between pointers and integers are translated
+	ConstPtrParams     ConstPtrParams     // translate const and restrict pointer parameters of static functions to slices or values
+	UnusedParams       UnusedPolicy       // how unused function parameters are handled; kept as is by default
+	UnusedVars         UnusedPolicy       // how unused local variables are handled; assigned to _ by default
+	RangeLoops         bool               // translate canonical loops over arrays to range loops
+	TailCalls          bool               // rewrite self tail calls to loops, since Go has no tail call optimization
+	EmbedArrays        int                // write global byte arrays with at least this many elements to files embedded with go:embed
+	CompactTables      int                // encode global integer arrays with at least this many elements as strings decoded on start
+	InlineHeaders      bool               // translate inline functions defined in headers once per project, to a shared inline.go
+	StringBuilders     bool               // replace char buffers built with strcat and sprintf by strings.Builder
+	GCAlloc            bool               // convert more allocations to new and make, and remove free() calls on them
+	Allocators         []AllocConfig      // custom C functions that allocate memory like malloc or calloc
+	Finalizers         bool               // close files from fopen and open on return or by finalizers, in case they leak
+	DefineGroups       bool               // group integer #define constants with a common prefix into typed const blocks
+	Trace              string             // Go function called on entry of each function as: defer Trace("name", args...)()
+	Benchmarks         []BenchConfig      // generate Go benchmarks for these functions
+	Generics           []GenericConfig    // merge declarations stamped out by macros into Go generics
+	OnNote             func(n Note)       // called for informational notes about the translation, for example ignored C extensions
+	OnProgress         func(p Progress)   // called when the translation of a file advances
+	Logger             *slog.Logger       // logger for warnings and debug events; slog.Default is used if not set
+	OnConversion       func(c Conversion) // called for implicit conversions that may change the value
+	OnSymbol           func(s Symbol)     // called for each C symbol, when the Go declaration for it is written
+	Metrics            *Metrics           // counts declarations, unsafe usages and runtime calls in written files
+
+	source  string          // C file name for the header, relative to the root
+	ctx     context.Context // cancels the translation; see TranslateContext
+	symbols symbolTable     // symbols of the project for OnSymbol
 }
 
 type TypeHint string
@@ -48,6 +114,8 @@ const (
 	HintSlice  = TypeHint("slice")  // force type to Go slice (for pointers and arrays)
 	HintIface  = TypeHint("iface")  // force type to Go interface{}
 	HintString = TypeHint("string") // force type to Go string
+	HintSlices = TypeHint("slices") // force multi-dimensional array type to Go slices of slices
+	HintFlat   = TypeHint("flat")   // force multi-dimensional array type to a flat Go slice
 )
 
 type IdentConfig struct {
@@ -56,8 +124,13 @@ type IdentConfig struct {
 	Rename  string        `yaml:"rename" json:"rename"`   // rename the identifier
 	Alias   bool          `yaml:"alias" json:"alias"`     // omit declaration, use underlying type instead
 	Type    TypeHint      `yaml:"type" json:"type"`       // changes the Go type of this identifier
+	Return  TypeHint      `yaml:"return" json:"return"`   // changes the Go type of the function return value
 	Flatten *bool         `yaml:"flatten" json:"flatten"` // flattens function control flow to workaround invalid gotos
-	Fields  []IdentConfig `yaml:"fields" json:"fields"`   // configs for struct fields or func arguments
+	Only    bool          `yaml:"only" json:"only"`       // translate only this function (and others marked this way) with its dependencies
+	Cgo     bool          `yaml:"cgo" json:"cgo"`         // keep this function in C and call it via cgo; see Config.Cgo
+	Atomic  bool          `yaml:"atomic" json:"atomic"`   // access this variable with sync/atomic; see Config.VolatileAtomic
+	Layout  bool          `yaml:"layout" json:"layout"`   // assert at compile time that the struct has the same layout as in C
+	Fields  []IdentConfig `yaml:"fields" json:"fields"`   // configs for struct fields, func arguments or local variables
 }
 
 type Replacer struct {
@@ -66,6 +139,16 @@ type Replacer struct {
 	New string
 }
 
+// Note is an informational message about the translation that does not prevent it from succeeding.
+type Note struct {
+	Where token.Position
+	Msg   string
+}
+
+func (n Note) String() string {
+	return n.Where.String() + ": " + n.Msg
+}
+
 type FileError struct {
 	Err   error
 	Where token.Position
@@ -94,48 +177,174 @@ func ErrorfWithPos(where token.Position, format string, args ...any) error {
 }
 
 func Translate(root, fname, out string, env *libs.Env, conf Config) error {
-	cname := fname
-	tu, err := Parse(env, root, cname, SourceConfig{
+	p := NewProject(env)
+	if err := p.Translate(root, fname, out, conf); err != nil {
+		return err
+	}
+	return p.Flush()
+}
+
+// TranslateFS is similar to Translate, but reads C files from fsys and returns generated Go files in memory,
+// by slash-separated path. Paths of C files are resolved relative to the root of fsys.
+func TranslateFS(fsys fs.FS, root, fname string, env *libs.Env, conf Config) (map[string][]byte, error) {
+	out := make(MemOutput)
+	conf.FS = fsys
+	conf.Output = out
+	if err := Translate(root, fname, "", env, conf); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// parseAndTranslate parses a C file and translates it to Go declarations.
+func (p *TranslatorProject) parseAndTranslate(root, fname string, conf Config) (*translation, error) {
+	if _, err := sourceCharset(conf.SourceEncoding); err != nil {
+		return nil, err
+	}
+	if err := conf.Frontend.validate(); err != nil {
+		return nil, err
+	}
+	if conf.Frontend == FrontendClang {
+		return p.parseClangAndTranslate(root, fname, conf)
+	}
+	conf.progress(fname, PassParse, 0, 0)
+	tu, err := Parse(p.env, root, fname, SourceConfig{
+		Profile:          conf.Profile,
 		Predef:           conf.Predef,
 		Define:           conf.Define,
 		Include:          conf.Include,
 		SysInclude:       conf.SysInclude,
+		HostSysInclude:   conf.HostSysInclude,
+		HostPredef:       conf.HostPredef,
 		IgnoreIncludeDir: conf.IgnoreIncludeDir,
+		C23:              conf.C23,
+		Encoding:         conf.SourceEncoding,
+		FS:               conf.FS,
+		Context:          conf.ctx,
+		Logger:           conf.Logger,
 	})
 	if err != nil {
-		return fmt.Errorf("parsing failed: %w", err)
+		if conf.ctx != nil && conf.ctx.Err() != nil {
+			return nil, conf.ctx.Err()
+		}
+		return nil, splitParseErrors(err)
+	}
+	if conf.Root == "" {
+		conf.Root = root
+	}
+	if conf.OnNote != nil {
+		if err = msvcNotes(conf, fname, conf.OnNote); err != nil {
+			return nil, err
+		}
+	}
+	return p.translateAST(fname, tu, conf)
+}
+
+// goFileName returns a name of the Go file for a given C file, relative to the output directory.
+func goFileName(root, fname string, conf Config) (string, error) {
+	if conf.GoFile != "" {
+		return conf.GoFile, nil
+	}
+	gofile, err := filepath.Rel(root, fname)
+	if err != nil {
+		return "", err
+	}
+	if conf.GoFilePref != "" {
+		dir, base := filepath.Split(gofile)
+		gofile = dir + conf.GoFilePref + base
+	}
+	// flatten C source file path to make a single large Go package
+	// TODO: auto-generate Go packages based on dir structure
+	gofile = strings.ReplaceAll(gofile, string(filepath.Separator), "_")
+	gofile = strings.TrimSuffix(gofile, ".c")
+	gofile = strings.TrimSuffix(gofile, ".h")
+	gofile += ".go"
+	return gofile, nil
+}
+
+// relPath is similar to filepath.Rel, but also accepts an absolute and a relative path.
+func relPath(base, path string) (string, error) {
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(base, path)
+}
+
+func (c *Config) packageName() string {
+	if c.Package == "" {
+		return "lib"
 	}
-	decls, err := TranslateAST(cname, tu, env, conf)
+	return c.Package
+}
+
+func translateFile(p *TranslatorProject, root, fname, out string, conf Config) error {
+	tr, err := p.parseAndTranslate(root, fname, conf)
 	if err != nil {
 		return err
 	}
-	pkg := conf.Package
-	if pkg == "" {
-		pkg = "lib"
+	conf.source = sourceName(root, fname)
+	conf.symbols = p.symbols
+	conf.progress(fname, PassWrite, 0, 0)
+	decls := tr.decls
+	pkg := conf.packageName()
+	if conf.Output == nil {
+		_ = os.MkdirAll(out, 0755)
 	}
-	_ = os.MkdirAll(out, 0755)
-	bbuf := bytes.NewBuffer(nil)
-	gofile := conf.GoFile
-	if gofile == "" {
-		gofile, err = filepath.Rel(root, fname)
+	gofile, err := goFileName(root, fname, conf)
+	if err != nil {
+		return err
+	}
+	if (conf.hasCgo() || conf.hasCBench()) && conf.CgoPreamble == "" {
+		path, err := relPath(out, fname)
 		if err != nil {
-			return err
+			path = fname
 		}
-		if conf.GoFilePref != "" {
-			dir, base := filepath.Split(gofile)
-			gofile = dir + conf.GoFilePref + base
-		}
-		// flatten C source file path to make a single large Go package
-		// TODO: auto-generate Go packages based on dir structure
-		gofile = strings.ReplaceAll(gofile, string(filepath.Separator), "_")
-		gofile = strings.TrimSuffix(gofile, ".c")
-		gofile = strings.TrimSuffix(gofile, ".h")
-		gofile += ".go"
+		// C main would conflict with Go main when linking
+		conf.CgoPreamble = "#define main cxgo_c_main\n#include " + strconv.Quote(filepath.ToSlash(path)) + "\n#undef main"
+	}
+	if len(tr.cgo) != 0 {
+		conf.CgoPreamble += "\n" + strings.Join(tr.cgo, "\n")
+	}
+	if err = p.writeBenchmarks(out, gofile, pkg, tr.bench, tr.cwrap, tr.cbench, conf); err != nil {
+		return err
+	}
+	if err = writeEmbeds(out, gofile, tr.embeds, conf); err != nil {
+		return err
+	}
+	decls = p.splitInline(pendingFile{out: out, gofile: gofile, pkg: pkg, decls: decls, conf: conf}, tr)
+	// weak declarations of any file may be replaced by strong definitions from other files
+	decls = p.splitWeak(pendingFile{out: out, gofile: gofile, pkg: pkg, decls: decls, conf: conf}, tr)
+	if conf.TreeShake {
+		// other files may use declarations from this one, thus a decision can only be made in Flush
+		p.roots = append(p.roots, tr.roots...)
+		p.pending = append(p.pending, pendingFile{out: out, gofile: gofile, pkg: pkg, decls: decls, conf: conf})
+		return nil
+	}
+	return p.writeFile(out, gofile, pkg, decls, conf)
+}
+
+// writeFile writes Go files corresponding to a single C file, according to the output layout.
+func (p *TranslatorProject) writeFile(out, gofile, pkg string, decls []GoDecl, conf Config) error {
+	switch conf.Layout {
+	case LayoutDefault:
+	case LayoutByKind:
+		return p.writeByKind(out, gofile, pkg, decls, conf)
+	default:
+		return fmt.Errorf("unsupported output layout: %q", conf.Layout)
 	}
 	max := conf.MaxDecls
 	if max == 0 {
 		max = 100
 	}
+	if conf.hasCgo() {
+		// the preamble may contain C definitions, thus it must be included only once
+		max = -1
+	}
 	// optionally split large files by N declaration per file
 	for i := 0; len(decls) > 0; i++ {
 		cur := decls
@@ -144,17 +353,6 @@ func Translate(root, fname, out string, env *libs.Env, conf Config) error {
 		}
 		decls = decls[len(cur):]
 
-		// generate Go file header with a package name and a list of imports
-		header := ImportsFor(env, cur)
-		buf := make([]GoDecl, 0, len(header)+len(cur))
-		buf = append(buf, header...)
-		buf = append(buf, cur...)
-
-		bbuf.Reset()
-		err = PrintGo(bbuf, pkg, buf, conf.DoNotEdit)
-		if err != nil {
-			return err
-		}
 		suff := fmt.Sprintf("_p%d", i+1)
 		if i == 0 && len(decls) == 0 {
 			suff = ""
@@ -163,59 +361,113 @@ func Translate(root, fname, out string, env *libs.Env, conf Config) error {
 		if !filepath.IsAbs(gopath) {
 			gopath = filepath.Join(out, gopath)
 		}
-
-		fdata := bbuf.Bytes()
-		// run replacements defined in the config
-		for _, rep := range conf.Replace {
-			if rep.Re != nil {
-				fdata = rep.Re.ReplaceAll(fdata, []byte(rep.New))
-			} else {
-				fdata = bytes.ReplaceAll(fdata, []byte(rep.Old), []byte(rep.New))
-			}
-		}
-
-		fmtdata, err := format.Source(fdata)
-		if err != nil {
-			// write anyway for examination
-			_ = os.WriteFile(gopath, fdata, 0644)
-			return fmt.Errorf("error formatting %s: %v", filepath.Base(gofile), err)
-		}
-		err = os.WriteFile(gopath, fmtdata, 0644)
-		if err != nil {
+		if err := writeGoFile(p.env, gopath, pkg, cur, conf); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// writeGoFile prints, post-processes and writes a single Go file with given declarations.
+func writeGoFile(env *libs.Env, gopath, pkg string, decls []GoDecl, conf Config) error {
+	return writeGoFileTag(env, gopath, pkg, "", decls, conf)
+}
+
+// writeGoFileTag is similar to writeGoFile, but adds a build constraint, if tag is set.
+func writeGoFileTag(env *libs.Env, gopath, pkg, tag string, decls []GoDecl, conf Config) error {
+	reportSymbols(gopath, decls, conf)
+	reportMetrics(env, gopath, decls, conf)
+	if conf.Stream && (conf.CgoPreamble == "" || !usesCgo(decls)) {
+		return writeGoFileStream(env, gopath, pkg, tag, decls, conf)
+	}
+	// generate Go file header with a package name and a list of imports
+	header := importsFor(env, decls, conf.ImportPaths, conf.ImportAliases)
+	buf := make([]GoDecl, 0, len(header)+len(decls))
+	buf = append(buf, header...)
+	buf = append(buf, decls...)
+
+	bbuf := bytes.NewBuffer(nil)
+	if tag != "" {
+		fmt.Fprintf(bbuf, "//go:build %s\n\n", tag)
+	}
+	if err := writeHeader(bbuf, conf); err != nil {
+		return err
+	}
+	err := PrintGo(bbuf, pkg, buf, conf.DoNotEdit)
+	if err != nil {
+		return err
+	}
+
+	fdata := bbuf.Bytes()
+	if conf.CgoPreamble != "" && usesCgo(decls) {
+		fdata = insertCgoPreamble(fdata, conf.CgoPreamble)
+	}
+	// run replacements defined in the config
+	fdata = replaceAll(conf.Replace, fdata)
+
+	fmtdata, err := format.Source(fdata)
+	if err != nil {
+		// write anyway for examination
+		_ = conf.output().WriteFile(gopath, fdata)
+		return fmt.Errorf("%w %s: %w", ErrFormat, filepath.Base(gopath), err)
+	}
+	return conf.output().WriteFile(gopath, fmtdata)
+}
+
 // TranslateAST takes a C translation unit and converts it to a list of Go declarations.
 func TranslateAST(fname string, tu *cc.AST, env *libs.Env, conf Config) ([]GoDecl, error) {
-	t := newTranslator(env, conf)
-	return t.translate(fname, tu), nil
+	return NewProject(env).TranslateAST(fname, tu, conf)
 }
 
 // TranslateCAST takes a C translation unit and converts it to a list of cxgo declarations.
 func TranslateCAST(fname string, tu *cc.AST, env *libs.Env, conf Config) ([]CDecl, error) {
-	t := newTranslator(env, conf)
-	return t.translateC(fname, tu), nil
+	return NewProject(env).TranslateCAST(fname, tu, conf)
 }
 
 func newTranslator(env *libs.Env, conf Config) *translator {
+	return NewProject(env).newTranslator(conf)
+}
+
+func (p *TranslatorProject) newTranslator(conf Config) *translator {
 	tr := &translator{
-		env:       env,
-		tenv:      env.Clone(),
-		conf:      conf,
-		idents:    make(map[string]IdentConfig),
-		ctypes:    make(map[cc.Type]types.Type),
-		decls:     make(map[cc.Node]*types.Ident),
-		namedPtrs: make(map[string]types.PtrType),
-		named:     make(map[string]types.Named),
-		aliases:   make(map[string]types.Type),
-		macros:    make(map[string]*types.Ident),
+		env:        p.env,
+		tenv:       p.env.Clone(),
+		conf:       conf,
+		idents:     make(map[string]IdentConfig),
+		ctypes:     p.ctypes,
+		decls:      make(map[cc.Node]*types.Ident),
+		namedPtrs:  p.namedPtrs,
+		named:      p.named,
+		layouts:    p.layouts,
+		aliases:    p.aliases,
+		macros:     p.macros,
+		macroInts:  p.macroInts,
+		adapters:   p.adapters,
+		typeDefs:   p.typeDefs,
+		macroVals:  p.macroVals,
+		unit:       p.units,
+		funcIdents: make(map[*types.Ident]struct{}),
+		constPtrs:  make(map[*types.Ident][]constPtr),
+		vaLists:    make(map[*types.Ident]struct{}),
+		gcAllocs:   make(map[Expr]gcAllocOrig),
+		anonTypes:  make(map[string][]types.Named),
+		bench:      make(map[string]*benchFunc),
+		declPos:    make(map[CDecl]token.Position),
+		macroDefs:  make(map[string]macroDef),
+	}
+	p.units++
+	if conf.OnSymbol != nil {
+		if p.symbols == nil {
+			p.symbols = make(symbolTable)
+		}
+		tr.symbols = p.symbols
 	}
 	for _, v := range conf.Idents {
 		tr.idents[v.Name] = v
 	}
+	for _, b := range conf.Benchmarks {
+		tr.bench[b.Name] = &benchFunc{conf: b}
+	}
 	_, _ = tr.tenv.GetLibrary(libs.BuiltinH)
 	_, _ = tr.tenv.GetLibrary(libs.StdlibH)
 	_, _ = tr.tenv.GetLibrary(libs.StdioH)
@@ -231,12 +483,45 @@ type translator struct {
 	cur  string
 
 	idents    map[string]IdentConfig
+	locals    map[string]IdentConfig // configs for local variables of the function being converted
 	ctypes    map[cc.Type]types.Type
 	namedPtrs map[string]types.PtrType
 	named     map[string]types.Named
+	layouts   map[*types.StructType]*structLayout // C layouts of structs marked with IdentConfig.Layout
 	aliases   map[string]types.Type
 	macros    map[string]*types.Ident
+	macroInts map[*types.Ident]IntLit // values of integer macros; see exactIntConst
+	typeDefs  map[string]projectDef   // definitions of named types shared by the project; see checkDef
+	macroVals map[string]projectDef   // values of macros shared by the project; see checkDef
+	unit      int                     // index of the translation unit in the project
+	macroDefs map[string]macroDef     // constants converted from macros of the current file; see Config.MacroComments
+	srcLines  map[string][][]byte     // C sources read for Config.MacroComments, by file name
 	decls     map[cc.Node]*types.Ident
+	skip      []skipRule
+	roots     []skipRule
+	bench     map[string]*benchFunc // benchmarks from the config, by C function name
+	generics  [][]genericPattern    // name patterns from Config.Generics
+
+	pos        token.Position           // position of the statement or declaration being converted
+	declPos    map[CDecl]token.Position // positions of top-level declarations for messages and Config.Provenance
+	cover      map[string]*coverFile    // coverage counters, by C file name
+	coverOrder []*coverFile
+	typeScope  []string                 // C names of named types being converted
+	anonTypes  map[string][]types.Named // named anonymous types, by C name of the parent type
+
+	funcIdents   map[*types.Ident]struct{}   // identifiers referring to C functions, not function pointers
+	adapters     map[string][]funcAdapter    // function adapters, by C name of the adapted function
+	adapterDecls []CDecl                     // function adapters generated for the current file
+	symbols      symbolTable                 // C symbols of generated declarations, only set if Config.OnSymbol is set
+	embeds       []embedFile                 // binary files for Config.EmbedArrays
+	symAttrs     []symbolAttr                // weak and alias attributes of the current file
+	weakDefs     []string                    // Go names of weak definitions
+	weakRefs     []CDecl                     // zero values for weak declarations without a definition
+	inlines      []*types.Ident              // inline functions from headers; see Config.InlineHeaders
+	constPtrs    map[*types.Ident][]constPtr // const and restrict pointer parameters of static functions; see Config.ConstPtrParams
+	vaLists      map[*types.Ident]struct{}   // static functions with a va_list as the last parameter; see vaForward
+	cgoPreamble  []string                    // C code added to Config.CgoPreamble; see cgoGlobals
+	gcAllocs     map[Expr]gcAllocOrig        // original calls of allocations converted by Config.GCAlloc
 }
 
 func (g *translator) Nil() Nil {
@@ -249,10 +534,25 @@ func (g *translator) Iota() Expr {
 
 const (
 	libcCStringSliceName = "libc.CStringSlice"
+	stdioExitName        = "stdio.Exit"
+	stdioFlushName       = "stdio.FlushStdout"
 )
 
-func (g *translator) translateMain(d *CFuncDecl) {
+// exitFunc returns the function called on return from main. It's os.Exit, unless stdout is buffered; see libs.StdoutMode.
+func (g *translator) exitFunc() *types.Ident {
 	osExit := g.env.Go().OsExitFunc()
+	if g.env.Stdout == libs.StdoutBuffered {
+		return types.NewIdent(stdioExitName, osExit.CType(nil))
+	}
+	return osExit
+}
+
+func (g *translator) translateMain(d *CFuncDecl) {
+	osExit := g.exitFunc()
+	if g.conf.Coverage {
+		// write coverage counters on exit
+		osExit = types.NewIdent("ccover.Exit", osExit.CType(nil))
+	}
 	if d.Type.ArgN() == 2 {
 		libcCSlice := types.NewIdent(libcCStringSliceName, g.env.FuncTT(g.env.PtrT(g.env.C().String()), types.SliceT(g.env.Go().String())))
 		osArgs := types.NewIdent("os.Args", types.SliceT(g.env.Go().String()))
@@ -279,6 +579,14 @@ func (g *translator) translateMain(d *CFuncDecl) {
 		d.Body.Stmts = stmts
 		d.Type = g.env.FuncT(d.Type.Return())
 	}
+	if g.conf.Coverage || g.env.Stdout == libs.StdoutBuffered {
+		// falling off the end of main must run the exit function as well
+		ret := d.Type.Return()
+		if ret == nil {
+			ret = types.IntT(4)
+		}
+		d.Body.Stmts = g.fixImplicitReturnStmts(ret, d.Body.Stmts)
+	}
 	d.Body.Stmts, _ = cReplaceEachStmt(func(s CStmt) ([]CStmt, bool) {
 		r, ok := s.(*CReturnStmt)
 		if !ok {
@@ -289,73 +597,172 @@ func (g *translator) translateMain(d *CFuncDecl) {
 			e = cIntLit(0, 10)
 		}
 		ex := g.NewCCallExpr(FuncIdent{osExit}, []Expr{g.cCast(g.env.Go().Int(), e)})
+		if g.conf.Coverage && g.env.Stdout == libs.StdoutBuffered {
+			flush := g.NewCCallExpr(FuncIdent{types.NewIdent(stdioFlushName, g.env.FuncTT(types.IntT(4)))}, nil)
+			return append(NewCExprStmt(flush), NewCExprStmt(ex)...), true
+		}
 		return NewCExprStmt(ex), true
 	}, d.Body.Stmts)
 	d.Type = g.env.FuncT(nil, d.Type.Args()...)
 }
 
-func (g *translator) translate(cur string, ast *cc.AST) []GoDecl {
-	decl := g.translateC(cur, ast)
+// translate converts a C translation unit to Go declarations.
+// It also returns Go names of the root declarations for Config.TreeShake.
+func (g *translator) translate(cur string, unit sourceUnit) ([]GoDecl, []string) {
+	decl := g.translateC(cur, unit)
+	g.conf.progress(cur, PassRewrite, 0, 0)
+	g.gcFrees(decl)
 	g.rewriteStatements(decl)
+	g.constPtrParams(decl)
+	g.vaForward(decl)
+	g.cgoGlobals(decl)
+	g.stringBuilders(decl)
+	// replace ternary and comma expressions with statements, where possible
+	g.liftExprs(decl)
+	g.finalizers(decl)
 	if g.conf.FixImplicitReturns {
 		g.fixImplicitReturns(decl)
 	}
 	// adapt well-known decls like main
 	decl = g.adaptMain(decl)
 	// run plugin hooks
-	decl = g.runASTPluginsC(cur, ast, decl)
+	decl = g.runASTPluginsC(cur, g.file, decl)
+	g.checkCancel()
+	g.conf.progress(cur, PassFlatten, 0, 0)
 	// flatten functions, if needed
 	g.flatten(decl)
+	g.checkCancel()
 	// fix unused variables
 	g.fixUnusedVars(decl)
+	g.rangeLoops(decl)
+	g.tailCalls(decl)
 	// convert to Go AST
-	var gdecl []GoDecl
-	for _, d := range decl {
+	var (
+		gdecl     []GoDecl
+		only      []string
+		roots     []string
+		cgoChecks []GoStmt // layout checks of cgo bindings; see CgoFuncDecl.layoutChecks
+	)
+	cgoSeen := make(map[string]struct{})
+	for i, d := range decl {
+		g.conf.progress(cur, PassEmit, i, len(decl))
+		var name string
 		switch d := d.(type) {
 		case *CFuncDecl:
-			if g.conf.SkipDecl[d.Name.Name] {
+			name = d.Name.Name
+			if g.isSkipped(name) {
+				continue
+			}
+			if g.isOnly(name) {
+				only = append(only, d.Name.GoIdent().Name)
+			}
+			if b := g.bench[name]; b != nil && d.Body != nil {
+				b.name, b.typ = d.Name, d.Type
+			}
+		case *CgoFuncDecl:
+			name = d.Name.Name
+			// main cannot be called via cgo
+			if name == "main" || g.isSkipped(name) {
 				continue
 			}
+			if g.isOnly(name) {
+				only = append(only, d.Name.GoIdent().Name)
+			}
+			if b := g.bench[name]; b != nil {
+				b.name, b.typ = d.Name, d.Type
+			}
 		case *CVarDecl:
-			// TODO: skip any single one
-			if len(d.Names) == 1 && g.conf.SkipDecl[d.Names[0].Name] {
+			// global variables are only accessible via the C pseudo-package in cgo mode
+			if g.conf.Cgo && !d.Const {
 				continue
 			}
+			// TODO: skip any single one
+			if len(d.Names) == 1 {
+				name = d.Names[0].Name
+				if g.isSkipped(name) {
+					continue
+				}
+			}
 		case *CTypeDef:
-			if g.conf.SkipDecl[d.Name().Name] {
+			name = d.Name().Name
+			if g.isSkipped(name) {
 				continue
 			}
 		}
-		gdecl = append(gdecl, d.AsDecl()...)
+		g.tinygoCheckDecl(d)
+		g.wasmCheckDecl(d)
+		g.ptrIntCheckDecl(d)
+		g.ptrIntHandleDecl(d)
+		out := d.AsDecl()
+		if e := g.embedArray(d); e != nil {
+			out = e
+		} else if e = g.compactTable(d); e != nil {
+			out = e
+		}
+		switch d := d.(type) {
+		case *CTypeDef:
+			out = append(out, g.layoutCheck(d)...)
+		case *CgoFuncDecl:
+			cgoChecks = append(cgoChecks, d.layoutChecks(cgoSeen)...)
+		case *CgoVarDecl:
+			cgoChecks = append(cgoChecks, d.layoutChecks(cgoSeen)...)
+		}
+		g.addProvenance(out, g.declPos[d])
+		g.addMacroComments(d, out)
+		g.addSymbols(d, out)
+		g.addNoCheckPtr(d, out)
+		g.addUnusedNolint(d, out)
+		// benchmarked functions must be preserved as well
+		if g.conf.TreeShake && name != "" && (g.isRoot(name) || g.bench[name] != nil) {
+			for _, gd := range out {
+				roots = append(roots, goDeclNames(gd)...)
+			}
+		}
+		gdecl = append(gdecl, out...)
+	}
+	g.conf.progress(cur, PassEmit, len(decl), len(decl))
+	if len(cgoChecks) != 0 {
+		gdecl = append(gdecl, cgoLayoutCheck(cgoChecks))
+	}
+	gdecl = g.applyGenerics(gdecl)
+	if g.hasOnly() {
+		gdecl = filterReachable(gdecl, only)
 	}
-	return gdecl
+	gdecl = initCycles(gdecl)
+	g.tinygoCheckImports(cur, gdecl)
+	g.wasmCheckImports(cur, gdecl)
+	return gdecl, roots
 }
 
-func (g *translator) translateC(cur string, ast *cc.AST) []CDecl {
-	g.file, g.cur = ast, strings.TrimLeft(cur, "./")
-
-	decl := g.convertMacros(ast)
-
-	tu := ast.TranslationUnit
-	for tu != nil {
-		d := tu.ExternalDeclaration
-		tu = tu.TranslationUnit
-		if d == nil {
-			continue
+// hasOnly checks if the translation is limited to a specific set of functions.
+func (g *translator) hasOnly() bool {
+	if len(g.conf.Only) != 0 {
+		return true
+	}
+	for _, c := range g.idents {
+		if c.Only {
+			return true
 		}
-		var cd []CDecl
-		switch d.Case {
-		case cc.ExternalDeclarationFuncDef:
-			cd = g.convertFuncDef(d.FunctionDefinition)
-		case cc.ExternalDeclarationDecl:
-			cd = g.convertDecl(d.Declaration)
-		case cc.ExternalDeclarationEmpty:
-			// TODO
-		default:
-			panic(d.Case.String() + " " + d.Position().String())
+	}
+	return false
+}
+
+// isOnly checks if a given C function was explicitly selected for translation.
+func (g *translator) isOnly(name string) bool {
+	if c, ok := g.idents[name]; ok && c.Only {
+		return true
+	}
+	for _, s := range g.conf.Only {
+		if s == name {
+			return true
 		}
-		decl = append(decl, cd...)
 	}
+	return false
+}
+
+func (g *translator) translateC(cur string, unit sourceUnit) []CDecl {
+	g.cur = strings.TrimLeft(cur, "./")
+	decl := unit.convertDecls(g, cur)
 	// remove forward declarations
 	m := make(map[string]CDecl)
 	skip := make(map[CDecl]struct{})
@@ -373,6 +780,12 @@ func (g *translator) translateC(cur string, ast *cc.AST) []CDecl {
 				m[d.Name.Name] = d
 				skip[d2] = struct{}{}
 			}
+		case *CgoFuncDecl:
+			if _, ok := m[d.Name.Name].(*CgoFuncDecl); ok {
+				skip[d] = struct{}{}
+			} else {
+				m[d.Name.Name] = d
+			}
 		case *CTypeDef:
 			d2, ok := m[d.Name().Name].(*CTypeDef)
 			if !ok {
@@ -391,6 +804,25 @@ func (g *translator) translateC(cur string, ast *cc.AST) []CDecl {
 			continue
 		}
 		decl2 = append(decl2, d)
+		if td, ok := d.(*CTypeDef); ok {
+			decl2 = g.appendAnonTypes(decl2, td.Name().Name)
+		}
+	}
+	decl2 = g.symbolAttrDecls(decl2)
+	decl2 = append(decl2, g.adapterDeclList()...)
+	return append(decl2, g.coverDecls()...)
+}
+
+// needDeclPos checks if positions of top-level declarations must be recorded in declPos.
+func (g *translator) needDeclPos() bool {
+	return g.conf.Provenance || g.conf.TinyGo || g.conf.WASM || g.conf.PtrIntCast == PtrIntError || g.conf.GCAlloc || g.conf.Finalizers || g.conf.OnNote != nil || g.symbols != nil
+}
+
+// appendAnonTypes adds declarations for named anonymous types nested in a given type.
+func (g *translator) appendAnonTypes(decl []CDecl, parent string) []CDecl {
+	for _, nt := range g.anonTypes[parent] {
+		decl = append(decl, &CTypeDef{nt})
+		decl = g.appendAnonTypes(decl, nt.Name().Name)
 	}
-	return decl2
+	return decl
 }
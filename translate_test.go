@@ -2,19 +2,29 @@ package cxgo
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"math"
 	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
 )
 
 const testDataDir = "./.testdata"
@@ -154,207 +164,3586 @@ func foo() {
 }
 `,
 	},
+	{
+		name: "only",
+		src: `
+typedef struct {
+	int x;
+} point;
+typedef struct {
+	int y;
+} unused_t;
+
+int gv = 1;
+int unused_v = 2;
+
+static int helper(point* p) {
+	return p->x + gv;
 }
 
-const (
-	kindUint = 1
-	kindInt  = 2
-)
+int target(point* p) {
+	return helper(p);
+}
 
-type intVal struct {
-	Size int
-	Kind int
-	ValI int64
-	ValU uint64
+int other() {
+	return unused_v;
+}
+`,
+		exp: `
+type point struct {
+	X int32
 }
 
-func (v intVal) CType() string {
-	u := ""
-	if v.Kind == kindUint {
-		u = "u"
-	}
-	return fmt.Sprintf("%sint%d_t", u, v.Size*8)
+var gv int32 = 1
+
+func helper(p *point) int32 {
+	return p.X + gv
+}
+func target(p *point) int32 {
+	return helper(p)
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.Only = []string{"target"}
+			},
+		},
+	},
+	{
+		name: "only ident",
+		src: `
+int a() { return 1; }
+int b() { return a(); }
+int c() { return 2; }
+`,
+		exp: `
+func a() int32 {
+	return 1
+}
+func b() int32 {
+	return a()
+}
+`,
+		configFuncs: []configFunc{
+			withIdent(IdentConfig{Name: "b", Only: true}),
+		},
+	},
+	{
+		name: "tree shake main",
+		src: `
+typedef struct { int x; } used_t;
+typedef struct { int y; } unused_t;
+int counter;
+int unused_var;
+int used(used_t* p) { return p->x + counter; }
+int unused(unused_t* p) { return p->y; }
+void main() { used(0); }
+`,
+		exp: `
+type used_t struct {
+	X int32
 }
 
-func (v intVal) CValue() string {
-	switch v.Kind {
-	case kindUint:
-		return strconv.FormatUint(v.ValU, 10) + "ul"
-	case kindInt:
-		return strconv.FormatInt(v.ValI, 10) + "l"
-	default:
-		panic("should not happen")
-	}
+var counter int32
+
+func used(p *used_t) int32 {
+	return p.X + counter
+}
+func main() {
+	used(nil)
 }
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.TreeShake = true
+			},
+		},
+	},
+	{
+		name: "tree shake init",
+		src: `
+int counter;
+void init() { counter = 1; }
+void unused() { counter = 2; }
+void main() { }
+`,
+		exp: `
+var counter int32
 
-type binopTest struct {
-	To intVal
-	X  intVal
-	Op BinaryOp
-	Y  intVal
+func init() {
+	counter = 1
+}
+func main() {
 }
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.TreeShake = true
+			},
+		},
+	},
+	{
+		name: "tree shake roots",
+		src: `
+int helper() { return 1; }
+int api_get() { return helper(); }
+int api_set() { return 2; }
+int internal() { return 3; }
+`,
+		exp: `
+func helper() int32 {
+	return 1
+}
+func api_get() int32 {
+	return helper()
+}
+func api_set() int32 {
+	return 2
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.TreeShake = true
+				c.Roots = []string{"api_*"}
+			},
+		},
+	},
+	{
+		name: "cgo bindings",
+		src: `
+#define MAX 10
+typedef struct point { int x; int y; } point;
+int counter;
+int add(int a, int b) { return a + b + counter; }
+point mid(point a, point b);
+void scale(point* p, double k);
+const char* name(void* p, unsigned char c);
+int sum(int n, ...);
+int main() { return add(1, 2); }
+`,
+		exp: `
+const MAX = 10
 
-func (b binopTest) CSrc() string {
-	verb := "d"
-	if b.To.Kind == kindUint {
-		verb = "u"
-	}
-	if b.To.Size == 8 {
-		verb = "ll" + verb
-	}
-	return fmt.Sprintf(`
-#include <stdint.h>
-#include <stdio.h>
+type point struct {
+	X int32
+	Y int32
+}
 
-int main() {
-	%s a = %s;
-	%s b = %s;
-	%s c = 0;
-	c = a %s b;
-	printf("%%%s\n", c);
-	return 0;
+func add(a int32, b int32) int32 {
+	return int32(C.add(C.int(a), C.int(b)))
+}
+func mid(a point, b point) point {
+	r := C.mid(*(*C.point)(unsafe.Pointer(&a)), *(*C.point)(unsafe.Pointer(&b)))
+	return *(*point)(unsafe.Pointer(&r))
+}
+func scale(p *point, k float64) {
+	C.scale((*C.point)(unsafe.Pointer(p)), C.double(k))
+}
+func name(p unsafe.Pointer, c uint8) *byte {
+	return (*byte)(unsafe.Pointer(C.name(unsafe.Pointer(p), C.uchar(c))))
+}
+func sum(n int32, _rest ...interface{}) int32 {
+	panic("cxgo: cannot call sum via cgo: variadic functions are not supported by cgo")
+}
+func _() {
+	var x [1]struct{}
+	_ = x[unsafe.Sizeof(point{})-unsafe.Sizeof(C.point{})]
+	_ = x[unsafe.Offsetof(point{}.X)-unsafe.Offsetof(C.point{}.x)]
+	_ = x[unsafe.Offsetof(point{}.Y)-unsafe.Offsetof(C.point{}.y)]
+	_ = x[unsafe.Sizeof(*new(byte))-unsafe.Sizeof(*new(C.char))]
 }
 `,
-		b.X.CType(), b.X.CValue(),
-		b.Y.CType(), b.Y.CValue(),
-		b.To.CType(),
-		string(b.Op),
-		verb,
-	)
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.Cgo = true
+			},
+		},
+	},
+	{
+		name: "cgo ident",
+		src: `
+int counter;
+static int hidden = 2;
+int slow(int* p, int n) { return p[n] + counter + hidden; }
+int run(int* p) { counter++; hidden++; return slow(p, 1); }
+`,
+		exp: `
+var counter = (*int32)(unsafe.Pointer(&C.counter))
+var hidden = (*int32)(unsafe.Pointer(C.cxgo_addr_hidden()))
+
+func slow(p *int32, n int32) int32 {
+	return int32(C.slow((*C.int)(unsafe.Pointer(p)), C.int(n)))
+}
+func run(p *int32) int32 {
+	*counter++
+	*hidden++
+	return slow(p, 1)
 }
+func _() {
+	var x [1]struct{}
+	_ = x[unsafe.Sizeof(*new(int32))-unsafe.Sizeof(C.counter)]
+	_ = x[unsafe.Sizeof(*new(int32))-unsafe.Sizeof(*C.cxgo_addr_hidden())]
+	_ = x[unsafe.Sizeof(*new(int32))-unsafe.Sizeof(*new(C.int))]
+}
+`,
+		configFuncs: []configFunc{
+			withIdent(IdentConfig{Name: "slow", Cgo: true}),
+		},
+	},
+	{
+		name: "generic macros",
+		src: `
+#define DEFINE_VECTOR(T) \
+typedef struct { T* data; int len; } vector_##T; \
+void vector_##T##_push(vector_##T* v, T x) { v->data[v->len++] = x; } \
+T vector_##T##_last(vector_##T* v) { return v->data[v->len-1]; }
 
-func randIntVal() intVal {
-	v := intVal{
-		Kind: kindUint,
-		Size: 1 << (rand.Int() % 4),
-	}
-	switch v.Size {
-	case 1, 2, 4, 8:
-	default:
-		panic(v.Size)
-	}
-	if rand.Int()%2 == 0 {
-		v.Kind = kindInt
-	}
-	switch v.Kind {
-	case kindUint:
-		v.ValU = rand.Uint64()
-		switch v.Size {
-		case 1:
-			v.ValU %= math.MaxUint8 + 1
-		case 2:
-			v.ValU %= math.MaxUint16 + 1
-		case 4:
-			v.ValU %= math.MaxUint32 + 1
-		}
-	case kindInt:
-		v.ValI = rand.Int63()
-		switch v.Size {
-		case 1:
-			v.ValI %= math.MaxInt8 + 1
-		case 2:
-			v.ValI %= math.MaxInt16 + 1
-		case 4:
-			v.ValI %= math.MaxInt32 + 1
-		}
-		if rand.Int()%2 == 0 {
-			v.ValI = -v.ValI
-		}
-		switch v.Size {
-		case 1:
-			for v.ValI > math.MaxInt8 {
-				v.ValI -= math.MaxInt8
-			}
-			for v.ValI < math.MinInt8 {
-				v.ValI += math.MinInt8
-			}
-		case 2:
-			for v.ValI > math.MaxInt16 {
-				v.ValI -= math.MaxInt16
-			}
-			for v.ValI < math.MinInt16 {
-				v.ValI += math.MinInt16
-			}
-		case 4:
-			for v.ValI > math.MaxInt32 {
-				v.ValI -= math.MaxInt32
-			}
-			for v.ValI < math.MinInt32 {
-				v.ValI += math.MinInt32
-			}
-		}
-	}
-	return v
+DEFINE_VECTOR(int)
+DEFINE_VECTOR(float)
+`,
+		exp: `
+type vector_types interface {
+	int32 | float32
+}
+type vector[T vector_types] struct {
+	Data *T
+	Len  int32
 }
+type vector_int = vector[int32]
 
-func randBinop(op BinaryOp) binopTest {
-	return binopTest{
-		To: randIntVal(),
-		X:  randIntVal(),
-		Op: op,
-		Y:  randIntVal(),
-	}
+func vector_push[T vector_types](v *vector[T], x T) {
+	*(*T)(unsafe.Add(unsafe.Pointer(v.Data), unsafe.Sizeof(T(0))*uintptr(func() int32 {
+		p := &v.Len
+		x := *p
+		*p++
+		return x
+	}()))) = x
 }
 
-func TestImplicitCompat(t *testing.T) {
-	for _, op := range []BinaryOp{
-		BinOpAdd,
-		BinOpSub,
-		BinOpMult,
-		BinOpDiv,
-		BinOpMod,
-	} {
-		op := op
-		t.Run(string(op), func(t *testing.T) {
-			t.Parallel()
-			for i := 0; i < 25; i++ {
-				func() {
-					b := randBinop(op)
-					for (b.Op == BinOpMod || b.Op == BinOpDiv) && b.Y.ValI == 0 && b.Y.ValU == 0 {
-						b = randBinop(op)
-					}
-					testTranspileOut(t, b.CSrc())
-				}()
-			}
-		})
-	}
+var vector_int_push = vector_push[int32]
+
+func vector_last[T vector_types](v *vector[T]) T {
+	return *(*T)(unsafe.Add(unsafe.Pointer(v.Data), unsafe.Sizeof(T(0))*uintptr(v.Len-1)))
 }
 
-func testTranspileOut(t testing.TB, csrc string) {
-	dir, err := os.MkdirTemp("", "cxgo_cout")
-	require.NoError(t, err)
-	defer os.RemoveAll(dir)
+var vector_int_last = vector_last[int32]
 
-	cdir := filepath.Join(dir, "c")
-	err = os.MkdirAll(cdir, 0755)
-	require.NoError(t, err)
+type vector_float = vector[float32]
 
-	cfile := filepath.Join(cdir, "main.c")
-	err = os.WriteFile(cfile, []byte(csrc), 0644)
-	require.NoError(t, err)
+var vector_float_push = vector_push[float32]
+var vector_float_last = vector_last[float32]
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.Generics = []GenericConfig{{Names: []string{"vector_{T}", "vector_{T}_push", "vector_{T}_last"}}}
+			},
+		},
+	},
+	{
+		name:     "offsetof",
+		builtins: true,
+		src: `
+#include <stddef.h>
 
-	// this is required for test to wait other goroutines in case it fails earlier on the main one
-	var wg sync.WaitGroup
-	wg.Add(1)
-	cch := make(chan progOut, 1)
-	go func() {
-		defer wg.Done()
-		cch <- gccCompileAndExec(t, cdir, cfile)
-	}()
-	defer wg.Wait()
+struct in { int x; int y; };
+typedef struct { char tag; struct in pos; int arr[4]; } T;
 
-	godir := filepath.Join(dir, "golang")
-	err = os.MkdirAll(godir, 0755)
-	require.NoError(t, err)
+size_t a = offsetof(T, pos);
+size_t b = offsetof(T, pos.y);
+size_t c = offsetof(T, arr[2]);
+int foo() { return offsetof(T, tag) + offsetof(struct in, y); }
+`,
+		exp: `
+type in struct {
+	X int32
+	Y int32
+}
+type T struct {
+	Tag int8
+	Pos in
+	Arr [4]int32
+}
 
-	goout := goTranspileAndExec(t, ".", godir, cfile)
-	cout := <-cch
-	require.Equal(t, cout.Code, goout.Code,
-		"\nC code: %d (%v)\nGo code: %s (%v)",
-		cout.Code, cout.Err,
-		goout.Code, goout.Err,
-	)
-	require.Equal(t, cout.Err, goout.Err)
-	t.Logf("// === Output ===\n%s", cout.Out)
-	require.Equal(t, cout.Out, goout.Out, "\n// === C source ===\n%s", csrc)
+var a uint32 = uint32(unsafe.Offsetof(T{}.Pos))
+var b uint32 = uint32(unsafe.Offsetof(T{}.Pos) + unsafe.Offsetof(in{}.Y))
+var c uint32 = uint32(unsafe.Offsetof(T{}.Arr) + 2*unsafe.Sizeof(int32(0)))
+
+func foo() int32 {
+	return int32(unsafe.Offsetof(T{}.Tag) + unsafe.Offsetof(in{}.Y))
+}
+`,
+	},
+	{
+		name: "container of",
+		src: `
+#include <stddef.h>
+#define container_of(ptr, type, member) ((type *)((char *)(ptr) - offsetof(type, member)))
+
+struct list_head { struct list_head *next, *prev; };
+struct item { int v; struct list_head node; };
+
+int sum(struct list_head *head) {
+	int s = 0;
+	for (struct list_head *p = head->next; p != head; p = p->next)
+		s += container_of(p, struct item, node)->v;
+	return s;
+}
+`,
+		exp: `
+type list_head struct {
+	Next *list_head
+	Prev *list_head
+}
+type item struct {
+	V    int32
+	Node list_head
+}
+
+func sum(head *list_head) int32 {
+	var s int32 = 0
+	for p := (*list_head)(head.Next); p != head; p = p.Next {
+		s += (libc.ContainerOf[item](p, unsafe.Offsetof(item{}.Node))).V
+	}
+	return s
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.ContainerOf = true
+			},
+		},
+	},
+	{
+		name: "fixed byte order",
+		src: `
+#include <stdint.h>
+
+typedef union { uint32_t v; uint8_t b[4]; } word;
+
+uint32_t load(uint8_t *p) {
+	return *(uint32_t*)p;
+}
+void store(uint8_t *p, int16_t v) {
+	*(int16_t*)p = v;
+	*(uint32_t*)(p+2) += 1;
+	(*(uint16_t*)p)++;
+	(*(uint16_t*)p) = 3;
+}
+uint32_t swap(uint32_t v) {
+	return __builtin_bswap32(v);
+}
+`,
+		exp: `
+type word struct {
+	// union; depends on byte order
+	V uint32
+	B [4]uint8
+}
+
+func load(p *uint8) uint32 {
+	return binary.LittleEndian.Uint32(unsafe.Slice(p, 4))
+}
+func store(p *uint8, v int16) {
+	binary.LittleEndian.PutUint16(unsafe.Slice(p, 2), uint16(v))
+	binary.LittleEndian.PutUint32(unsafe.Slice((*uint8)(unsafe.Add(unsafe.Pointer(p), 2)), 4), binary.LittleEndian.Uint32(unsafe.Slice((*uint8)(unsafe.Add(unsafe.Pointer(p), 2)), 4))+1)
+	binary.LittleEndian.PutUint16(unsafe.Slice(p, 2), binary.LittleEndian.Uint16(unsafe.Slice(p, 2))+1)
+	binary.LittleEndian.PutUint16(unsafe.Slice(p, 2), 3)
+}
+func swap(v uint32) uint32 {
+	return bits.ReverseBytes32(v)
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.FixedByteOrder = true
+			},
+		},
+	},
+	{
+		name: "wrap signed",
+		src: `
+int hash(int h, unsigned char c, int n) {
+	h = h * 31 + c;
+	h -= 7;
+	h += -1;
+	h = -h;
+	return h << n;
+}
+`,
+		exp: `
+func hash(h int32, c uint8, n int32) int32 {
+	h = int32(uint32(h)*31 + uint32(int32(c)))
+	h = int32(uint32(h) - 7)
+	h = int32(uint32(h) - 1)
+	h = int32(-uint32(h))
+	return int32(uint32(h) << n)
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.WrapSigned = true
+			},
+		},
+	},
+	{
+		name: "volatile atomic",
+		src: `
+volatile int ready;
+volatile unsigned int counter;
+int shared;
+
+void signal() {
+	counter++;
+	counter += 2;
+	counter--;
+	ready = 1;
+}
+int wait() {
+	while (!ready) {}
+	shared = counter * 2;
+	return shared;
+}
+`,
+		exp: `
+var ready int32
+var counter uint32
+var shared int32
+
+func signal() {
+	atomic.AddUint32(&counter, 1)
+	atomic.AddUint32(&counter, 2)
+	atomic.AddUint32(&counter, ^uint32(0))
+	atomic.StoreInt32(&ready, 1)
+}
+func wait() int32 {
+	for atomic.LoadInt32(&ready) == 0 {
+	}
+	shared = int32(atomic.LoadUint32(&counter) * 2)
+	return shared
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.VolatileAtomic = true
+			},
+		},
+	},
+	{
+		name: "atomic ident",
+		src: `
+long long seq;
+
+void next() {
+	seq++;
+}
+long long get() {
+	return seq;
+}
+`,
+		exp: `
+var seq int64
+
+func next() {
+	atomic.AddInt64(&seq, 1)
+}
+func get() int64 {
+	return atomic.LoadInt64(&seq)
+}
+`,
+		configFuncs: []configFunc{
+			withIdent(IdentConfig{Name: "seq", Atomic: true}),
+		},
+	},
+	{
+		name: "volatile atomic expr",
+		src: `
+volatile int g;
+volatile unsigned u;
+
+int f() {
+	int c = (g = 7);
+	int d = g += 2;
+	d = g *= 3;
+	c = g++;
+	c = ++g;
+	c = g--;
+	c = u--;
+	return c + d;
+}
+`,
+		exp: `
+var g int32
+var u uint32
+
+func f() int32 {
+	var (
+		c int32 = (func() int32 {
+			atomic.StoreInt32(&g, 7)
+			return atomic.LoadInt32(&g)
+		}())
+		d int32 = atomic.AddInt32(&g, 2)
+	)
+	d = func() int32 {
+		atomic.StoreInt32(&g, atomic.LoadInt32(&g)*3)
+		return atomic.LoadInt32(&g)
+	}()
+	c = atomic.AddInt32(&g, 1) - 1
+	c = atomic.AddInt32(&g, 1)
+	c = atomic.AddInt32(&g, -1) + 1
+	c = int32(atomic.AddUint32(&u, ^uint32(0)) + 1)
+	return c + d
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.VolatileAtomic = true
+			},
+		},
+	},
+	{
+		name: "trace functions",
+		src: `
+int add(int a, int b) {
+	return a + b;
+}
+void nop(int) {}
+`,
+		exp: `
+func add(a int32, b int32) int32 {
+	defer traceCall("add", a, b)()
+	return a + b
+}
+func nop(int32) {
+	defer traceCall("nop")()
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.Trace = "traceCall"
+			},
+		},
+	},
+	{
+		name: "coverage",
+		src: `
+int sign(int x) {
+	if (x < 0)
+		return -1;
+	else if (x == 0)
+		return 0;
+	while (x > 1) {
+		x--;
+	}
+	switch (x) {
+	case 1:
+		return 1;
+	}
+	return 2;
+}
+`,
+		exp: `
+func sign(x int32) int32 {
+	cxgoCover_coverage_c.Hit(0)
+	if x < 0 {
+		cxgoCover_coverage_c.Hit(1)
+		return -1
+	} else if x == 0 {
+		cxgoCover_coverage_c.Hit(2)
+		return 0
+	}
+	for x > 1 {
+		cxgoCover_coverage_c.Hit(3)
+		x--
+	}
+	switch x {
+	case 1:
+		cxgoCover_coverage_c.Hit(4)
+		return 1
+	}
+	return 2
+}
+
+var cxgoCover_coverage_c = ccover.Register("coverage.c", 3, 4, 6, 8, 12)
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.Coverage = true
+			},
+		},
+	},
+	{
+		name: "ptr int handle arithmetic",
+		src: `
+typedef unsigned long uintptr_t;
+int aligned(int *p) { return ((uintptr_t)p & 3) == 0; }
+int before(int *p, int *q) { return (uintptr_t)p < (uintptr_t)q; }
+uintptr_t handle(int *p) { return (uintptr_t)p; }
+`,
+		exp: `
+type uintptr_t uint32
+
+func aligned(p *int32) int32 {
+	return libc.BoolToInt((uintptr_t(uintptr(unsafe.Pointer(p))) & 3) == 0)
+}
+func before(p *int32, q *int32) int32 {
+	return libc.BoolToInt(uintptr_t(uintptr(unsafe.Pointer(p))) < uintptr_t(uintptr(unsafe.Pointer(q))))
+}
+func handle(p *int32) uintptr_t {
+	return uintptr_t(libc.PtrToHandle(unsafe.Pointer(p)))
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.PtrIntCast = PtrIntHandle
+			},
+		},
+	},
+	{
+		name: "coverage main",
+		src: `
+void f();
+int main() {
+	f();
+}
+`,
+		exp: `
+func f()
+func main() {
+	cxgoCover_coverage_main_c.Hit(0)
+	f()
+	ccover.Exit(0)
+}
+
+var cxgoCover_coverage_main_c = ccover.Register("coverage_main.c", 4)
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.Coverage = true
+			},
+		},
+	},
+	{
+		name: "order by name",
+		src: `
+#define ZETA 2
+#define ALPHA 1
+int zoo() { return ZETA; }
+int bar = ALPHA;
+typedef int mid_t;
+`,
+		exp: `
+const ALPHA = 1
+const ZETA = 2
+
+var bar int32 = ALPHA
+
+type mid_t int32
+
+func zoo() int32 {
+	return ZETA
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.DeclOrder = OrderName
+			},
+		},
+	},
+	{
+		name:     "static assert",
+		builtins: true,
+		src: `
+#define N 4
+typedef struct { int a; int b; } pair;
+_Static_assert(sizeof(pair) == 8, "pair must be 8 bytes");
+_Static_assert(N > 2 && N <= 8, "N");
+int tbl[N];
+
+int foo(int x) {
+	_Static_assert(N != 0, "local");
+	return x + tbl[0];
+}
+`,
+		exp: `
+const N = 4
+
+type pair struct {
+	A int32
+	B int32
+}
+// static assertion: pair must be 8 bytes
+var (
+	_ [unsafe.Sizeof(pair{}) - 8]struct{}
+	_ [8 - unsafe.Sizeof(pair{})]struct{}
+)
+// static assertion: N
+var (
+	_ [int32(N-2) - 1]struct{}
+	_ [int32(8 - N)]struct{}
+)
+var tbl [4]int32
+
+func foo(x int32) int32 {
+	if N == 0 {
+		panic("static assertion failed: local")
+	}
+	return x + tbl[0]
+}
+`,
+	},
+	{
+		name: "struct layout",
+		src: `
+typedef struct {
+	char tag;
+	int len;
+	char *data;
+} hdr_t;
+struct rec {
+	short a;
+	long long b;
+	int flags : 3;
+};
+`,
+		exp: `
+type hdr_t struct {
+	Tag  int8
+	Len  int32
+	Data *byte
+}
+
+func _() {
+	var x [1]struct{}
+	_ = x[unsafe.Sizeof(hdr_t{})-12]
+	_ = x[unsafe.Alignof(hdr_t{})-4]
+	_ = x[unsafe.Offsetof(hdr_t{}.Tag)]
+	_ = x[unsafe.Offsetof(hdr_t{}.Len)-4]
+	_ = x[unsafe.Offsetof(hdr_t{}.Data)-8]
+}
+
+type rec struct {
+	A     int16
+	B     int64
+	Flags int32
+}
+
+func _() {
+	var x [1]struct{}
+	_ = x[unsafe.Sizeof(rec{})-16]
+	_ = x[unsafe.Alignof(rec{})-4]
+	_ = x[unsafe.Offsetof(rec{}.A)]
+	_ = x[unsafe.Offsetof(rec{}.B)-4]
+}
+`,
+		configFuncs: []configFunc{
+			withIdent(IdentConfig{Name: "hdr_t", Layout: true}),
+			withIdent(IdentConfig{Name: "rec", Layout: true}),
+		},
+	},
+	{
+		name: "define groups",
+		src: `
+#define COLOR_RED 0
+#define COLOR_GREEN 1
+#define COLOR_BLUE 2
+#define FLAG_A 0x1
+#define FLAG_B 0x2
+#define FLAG_C 0x4
+#define MAX 10
+int pick(int c, int f) {
+	switch (c) {
+	case COLOR_RED:
+		return f & FLAG_A;
+	case COLOR_BLUE:
+		return MAX;
+	}
+	return COLOR_GREEN | FLAG_B;
+}
+`,
+		exp: `
+type COLOR int32
+
+const (
+	COLOR_RED = COLOR(iota)
+	COLOR_GREEN
+	COLOR_BLUE
+)
+
+type FLAG int32
+
+const (
+	FLAG_A FLAG = 0x1
+	FLAG_B FLAG = 0x2
+	FLAG_C FLAG = 0x4
+)
+const MAX = 10
+
+func pick(c int32, f int32) int32 {
+	switch c {
+	case int32(COLOR_RED):
+		return f & int32(FLAG_A)
+	case int32(COLOR_BLUE):
+		return MAX
+	}
+	return int32(COLOR_GREEN | COLOR(FLAG_B))
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.DefineGroups = true
+			},
+		},
+	},
+	{
+		name: "anon type names",
+		src: `
+typedef struct {
+	struct {
+		int x;
+		union {
+			int i;
+			float f;
+		} val;
+	} pos;
+	struct {
+		int w;
+	} size;
+} foo;
+
+int get(foo* p) {
+	return p->pos.val.i + p->size.w;
+}
+`,
+		exp: `
+type foo struct {
+	Pos  fooPos
+	Size FooSize
+}
+type fooPos struct {
+	X   int32
+	Val fooPosVal
+}
+type fooPosVal struct {
+	// union
+	I int32
+	F float32
+}
+type FooSize struct {
+	W int32
+}
+
+func get(p *foo) int32 {
+	return p.Pos.Val.I + p.Size.W
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.AnonTypeNames = true
+			},
+			withRename("foo.size", "FooSize"),
+		},
+	},
+	{
+		name: "skip patterns",
+		src: `
+int test_a() { return 1; }
+int test_b() { return 2; }
+int bench_a() { return 3; }
+int keep() { return 4; }
+int foo_internal() { return 5; }
+int bar() { return 6; }
+`,
+		exp: `
+func keep() int32 {
+	return 4
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.SkipDecl = map[string]bool{
+					"test_*":              true,
+					"/^bench_/":           true,
+					"/_internal$/":        true,
+					"skip_patterns.c:bar": true,
+					"other.c:keep":        true,
+				}
+			},
+		},
+	},
+	{
+		name:     "range loops",
+		builtins: true,
+		src: `
+int a[10];
+int b[10];
+
+int sum(int v) {
+	int s = 0;
+	for (int i = 0; i < 10; i++) {
+		s += a[i];
+	}
+	for (int i = 0; i < sizeof(a)/sizeof(a[0]); ++i) {
+		a[i] = v;
+	}
+	for (int i = 0; i < 10; i++) {
+		s += a[i] * b[i];
+	}
+	for (int i = 0; i < 10; i++) {
+		s += i;
+	}
+	for (int i = 0; i < 5; i++) {
+		s += a[i];
+	}
+	for (int i = 0; i < 10; i++) {
+		s++;
+	}
+	return s;
+}
+`,
+		exp: `
+var a [10]int32
+var b [10]int32
+
+func sum(v int32) int32 {
+	var s int32 = 0
+	for _, v2 := range a {
+		s += v2
+	}
+	for i := range a {
+		a[i] = v
+	}
+	for i, v3 := range a {
+		s += v3 * b[i]
+	}
+	for i := int32(0); i < 10; i++ {
+		s += i
+	}
+	for i := int32(0); i < 5; i++ {
+		s += a[i]
+	}
+	for i := int32(0); i < 10; i++ {
+		s++
+	}
+	return s
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.RangeLoops = true
+			},
+		},
+	},
+	{
+		name: "init cycles",
+		src: `
+struct node { struct node *next; int v; void (*cb)(void); };
+struct node self = {&self, 1};
+extern struct node b;
+struct node a = {&b, 2};
+struct node b = {&a, 3};
+void reset(void);
+struct node c = {0, 4, reset};
+void reset(void) { c.v = 0; }
+struct node *list[] = {&a, &b};
+`,
+		exp: `
+type node struct {
+	Next *node
+	V    int32
+	Cb   func()
+}
+
+var self node
+var a node
+var b node = node{Next: &a, V: 3}
+var c node
+
+func reset() {
+	c.V = 0
+}
+
+var list [2]*node = [2]*node{&a, &b}
+
+func init() {
+	self = node{Next: (*node)(unsafe.Pointer(&self)), V: 1}
+	a = node{Next: &b, V: 2}
+	c = node{Next: nil, V: 4, Cb: reset}
+}
+`,
+	},
+	{
+		name: "tail calls",
+		src: `
+int gcd(int a, int b) {
+	if (b == 0) return a;
+	return gcd(b, a % b);
+}
+
+int sum(int n, int acc) {
+	for (; n > 10; n--) {
+		if (n % 7 == 0) return sum(n / 7, acc + n);
+	}
+	if (n == 0) return acc;
+	return sum(n - 1, acc + n);
+}
+
+void walk(int n) {
+	if (n > 0) {
+		walk(n - 1);
+	}
+}
+
+int addr(int n) {
+	int *p = &n;
+	if (n == 0) return 0;
+	return addr(*p - 1);
+}
+`,
+		exp: `
+func gcd(a int32, b int32) int32 {
+	for {
+		if b == 0 {
+			return a
+		}
+		a, b = b, a%b
+		continue
+	}
+}
+func sum(n int32, acc int32) int32 {
+tail:
+	for {
+		for ; n > 10; n-- {
+			if n%7 == 0 {
+				n, acc = n/7, acc+n
+				continue tail
+			}
+		}
+		if n == 0 {
+			return acc
+		}
+		n, acc = n-1, acc+n
+		continue tail
+	}
+}
+func walk(n int32) {
+	for {
+		if n > 0 {
+			n = n - 1
+			continue
+		}
+		return
+	}
+}
+func addr(n int32) int32 {
+	var p *int32 = &n
+	if n == 0 {
+		return 0
+	}
+	return addr(*p - 1)
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.TailCalls = true
+			},
+		},
+	},
+	{
+		name: "compact tables",
+		src: `
+typedef unsigned short u16;
+static const int tbl[6] = {1, -2, 300, 'A', [5] = 7};
+static const u16 grid[2][3] = {{1, 2, 3}, {-1, 5}};
+int small[2] = {1, 2};
+`,
+		exp: `
+type u16 uint16
+
+var tbl [6]int32 = func() (t [6]int32) {
+	b, _ := base64.StdEncoding.DecodeString("AgPYBIIBAA4=")
+	for i := range t {
+		v, n := binary.Varint(b)
+		t[i] = int32(v)
+		b = b[n:]
+	}
+	return
+}()
+var grid [2][3]u16 = func() (t [2][3]u16) {
+	b, _ := base64.StdEncoding.DecodeString("AQID//8DBQA=")
+	for i := range t {
+		for j := range t[i] {
+			v, n := binary.Uvarint(b)
+			t[i][j] = u16(v)
+			b = b[n:]
+		}
+	}
+	return
+}()
+var small [2]int32 = [2]int32{1, 2}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.CompactTables = 4
+			},
+		},
+	},
+	{
+		name:     "sizeof hints",
+		builtins: true,
+		src: `
+struct S {
+	int *buf;
+	char name[16];
+	int n;
+};
+int f(struct S *s) {
+	int n = sizeof(s->buf) + sizeof(s->name) + sizeof(*s->buf) + sizeof(s->n);
+	n += sizeof(struct S);
+	return n;
+}
+`,
+		exp: `
+type S struct {
+	Buf  []int32
+	Name string
+	N    int32
+}
+
+func f(s *S) int32 {
+	var n int32 = int32(4 + 16 + unsafe.Sizeof(int32(0)) + unsafe.Sizeof(int32(0)))
+	n += int32(unsafe.Sizeof(S{}))
+	return n
+}
+`,
+		configFuncs: []configFunc{
+			withIdent(IdentConfig{Name: "S", Fields: []IdentConfig{{Name: "buf", Type: HintSlice}, {Name: "name", Type: HintString}}}),
+		},
+	},
+	{
+		name: "unused assign",
+		src: `
+int g(int);
+int f(int a, int b, int *p) {
+	int x = 1;
+	int *q;
+	q = 0;
+	x = g(b);
+	return 0;
+}
+`,
+		exp: `
+func g(int32) int32
+func f(a int32, b int32, p *int32) int32 {
+	_ = a
+	_ = p
+	var x int32 = 1
+	_ = x
+	var q *int32
+	_ = q
+	q = nil
+	x = g(b)
+	return 0
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.UnusedParams = UnusedAssign
+				c.UnusedVars = UnusedAssign
+			},
+		},
+	},
+	{
+		name: "unused rename",
+		src: `
+int g(int);
+int f(int a, int b, int *p) {
+	int x = 1;
+	int *q;
+	q = 0;
+	x = g(b);
+	return 0;
+}
+`,
+		exp: `
+func g(int32) int32
+func f(_ int32, b int32, _ *int32) int32 {
+	var (
+		_ int32 = 1
+		q *int32
+	)
+	_ = q
+	q = nil
+	_ = g(b)
+	return 0
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.UnusedParams = UnusedRename
+				c.UnusedVars = UnusedRename
+			},
+		},
+	},
+	{
+		name: "unused nolint",
+		src: `
+int g(int);
+int f(int a, int b, int *p) {
+	int x = 1;
+	int *q;
+	q = 0;
+	x = g(b);
+	return 0;
+}
+`,
+		exp: `
+func g(int32) int32
+//nolint:revive,unparam
+func f(a int32, b int32, p *int32) int32 {
+	var x int32 = 1
+	_ = x
+	var q *int32
+	_ = q
+	q = nil
+	x = g(b)
+	return 0
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.UnusedParams = UnusedNolint
+				c.UnusedVars = UnusedNolint
+			},
+		},
+	},
+	{
+		name: "va forward",
+		src: `
+#include <stdio.h>
+#include <stdarg.h>
+
+static void logv(const char* fmt, va_list ap) {
+	vfprintf(stderr, fmt, ap);
+}
+
+void log_msg(int level, const char* fmt, ...) {
+	va_list ap;
+	va_start(ap, fmt);
+	logv(fmt, ap);
+	va_end(ap);
+}
+
+void log_out(const char* fmt, ...) {
+	va_list ap;
+	va_start(ap, fmt);
+	vprintf(fmt, ap);
+	va_end(ap);
+}
+
+int first(int n, ...) {
+	va_list ap;
+	va_start(ap, n);
+	int v = va_arg(ap, int);
+	vprintf("%d", ap);
+	va_end(ap);
+	return v;
+}
+`,
+		exp: `
+func logv(fmt *byte, _rest ...interface{}) {
+	stdio.Fprintf(stdio.Stderr(), libc.GoString(fmt), _rest...)
+}
+func log_msg(level int32, fmt *byte, _rest ...interface{}) {
+	logv(fmt, _rest...)
+}
+func log_out(fmt *byte, _rest ...interface{}) {
+	stdio.Printf(libc.GoString(fmt), _rest...)
+}
+func first(n int32, _rest ...interface{}) int32 {
+	var ap libc.ArgList
+	ap.Start(n, _rest)
+	var v int32 = ap.Arg().(int32)
+	stdio.Vprintf("%d", ap)
+	ap.End()
+	return v
+}
+`,
+	},
+	{
+		name: "const ptr value writes",
+		src: `
+typedef struct { int w, h; } rect;
+rect gr;
+int other(void);
+static int area(const rect *r) { return r->w * r->h; }
+static int sneaky(const rect *r) { gr.w = 10; return r->w; }
+static int calls(const rect *r) { other(); return r->w; }
+int use() {
+	return area(&gr) + sneaky(&gr) + calls(&gr);
+}
+`,
+		exp: `
+type rect struct {
+	W int32
+	H int32
+}
+
+var gr rect
+
+func other() int32
+func area(r rect) int32 {
+	return r.W * r.H
+}
+func sneaky(r *rect) int32 {
+	gr.W = 10
+	return r.W
+}
+func calls(r *rect) int32 {
+	other()
+	return r.W
+}
+func use() int32 {
+	return area(gr) + sneaky(&gr) + calls(&gr)
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.ConstPtrParams = ConstPtrValue
+			},
+		},
+	},
+	{
+		name: "const ptr slice",
+		src: `
+typedef struct { int x, y; } point;
+static int sum(const int* a, int n) {
+	int s = 0;
+	for (int i = 0; i < n; i++) s += a[i];
+	return s;
+}
+static int px(const point* p) { return p->x + (*p).y; }
+static int next(const int* a) { return *(a+1); }
+int use(const point* q) {
+	int arr[4] = {1, 2, 3, 4};
+	point pt = {1, 2};
+	return sum(arr, 4) + sum(&arr[1], 2) + sum(0, 0) + px(&pt) + px(q) + next(arr);
+}
+`,
+		exp: `
+type point struct {
+	X int32
+	Y int32
+}
+
+func sum(a []int32, n int32) int32 {
+	var s int32 = 0
+	for i := int32(0); i < n; i++ {
+		s += a[i]
+	}
+	return s
+}
+func px(p *point) int32 {
+	return p.X + (*p).Y
+}
+func next(a *int32) int32 {
+	return *((*int32)(unsafe.Add(unsafe.Pointer(a), unsafe.Sizeof(int32(0))*1)))
+}
+func use(q *point) int32 {
+	var (
+		arr [4]int32 = [4]int32{1, 2, 3, 4}
+		pt  point    = point{X: 1, Y: 2}
+	)
+	return sum(arr[:], 4) + sum(arr[1:], 2) + sum(nil, 0) + px(&pt) + px(q) + next(&arr[0])
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.ConstPtrParams = ConstPtrSlice
+			},
+		},
+	},
+	{
+		name: "const ptr value",
+		src: `
+typedef struct { int x, y; } point;
+static int sum(const int* a, int n) {
+	int s = 0;
+	for (int i = 0; i < n; i++) s += a[i];
+	return s;
+}
+static int px(const point* p) { return p->x + (*p).y; }
+static int next(const int* a) { return *(a+1); }
+int use(const point* q) {
+	int arr[4] = {1, 2, 3, 4};
+	point pt = {1, 2};
+	return sum(arr, 4) + sum(&arr[1], 2) + sum(0, 0) + px(&pt) + px(q) + next(arr);
+}
+`,
+		exp: `
+type point struct {
+	X int32
+	Y int32
+}
+
+func sum(a []int32, n int32) int32 {
+	var s int32 = 0
+	for i := int32(0); i < n; i++ {
+		s += a[i]
+	}
+	return s
+}
+func px(p point) int32 {
+	return p.X + p.Y
+}
+func next(a *int32) int32 {
+	return *((*int32)(unsafe.Add(unsafe.Pointer(a), unsafe.Sizeof(int32(0))*1)))
+}
+func use(q *point) int32 {
+	var (
+		arr [4]int32 = [4]int32{1, 2, 3, 4}
+		pt  point    = point{X: 1, Y: 2}
+	)
+	return sum(arr[:], 4) + sum(arr[1:], 2) + sum(nil, 0) + px(pt) + px(*q) + next(&arr[0])
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.ConstPtrParams = ConstPtrValue
+			},
+		},
+	},
+	{
+		name: "restrict params",
+		src: `
+typedef struct { float x, y; } vec2;
+static void axpy(int n, float a, const float* restrict x, float* restrict y) {
+	for (int i = 0; i < n; i++) y[i] += a * x[i];
+}
+static float dot(const vec2* restrict a, vec2* restrict b) { return a->x * b->x + a->y * b->y; }
+static void scale(vec2* restrict v, float k) { v->x *= k; v->y *= k; }
+static void unrelated(float* x) { x[0] = 0; }
+void use(vec2* p) {
+	float xs[4], ys[4];
+	vec2 v = {1, 2};
+	axpy(4, 2, xs, ys);
+	scale(p, dot(&v, p));
+	unrelated(ys);
+}
+`,
+		exp: `
+type vec2 struct {
+	X float32
+	Y float32
+}
+
+func axpy(n int32, a float32, x []float32, y []float32) {
+	for i := int32(0); i < n; i++ {
+		y[i] += a * x[i]
+	}
+}
+func dot(a vec2, b vec2) float32 {
+	return a.X*b.X + a.Y*b.Y
+}
+func scale(v *vec2, k float32) {
+	v.X *= k
+	v.Y *= k
+}
+func unrelated(x *float32) {
+	*(*float32)(unsafe.Add(unsafe.Pointer(x), unsafe.Sizeof(float32(0))*0)) = 0
+}
+func use(p *vec2) {
+	var (
+		xs [4]float32
+		ys [4]float32
+		v  vec2 = vec2{X: 1, Y: 2}
+	)
+	axpy(4, 2, xs[:], ys[:])
+	scale(p, dot(v, *p))
+	unrelated(&ys[0])
+}
+`,
+		configFuncs: []configFunc{
+			func(c *Config) {
+				c.ConstPtrParams = ConstPtrValue
+			},
+		},
+	},
+	{
+		name:     "data model ILP32",
+		builtins: true,
+		src: `
+long get(long v) { return v + sizeof(long); }
+#if __SIZEOF_POINTER__ == 8
+int ptr_size() { return 8; }
+#else
+int ptr_size() { return 4; }
+#endif
+`,
+		exp: `
+func get(v int32) int32 {
+	return v + int32(unsafe.Sizeof(int32(0)))
+}
+func ptr_size() int32 {
+	return 4
+}
+`,
+		envFuncs: []envFunc{
+			func(c *types.Config) {
+				*c, _ = types.ConfigFor(types.ILP32)
+			},
+		},
+	},
+	{
+		name:     "data model LP64",
+		builtins: true,
+		src: `
+long get(long v) { return v + sizeof(long); }
+#if __SIZEOF_POINTER__ == 8
+int ptr_size() { return 8; }
+#else
+int ptr_size() { return 4; }
+#endif
+`,
+		exp: `
+func get(v int64) int64 {
+	return v + int64(unsafe.Sizeof(int64(0)))
+}
+func ptr_size() int32 {
+	return 8
+}
+`,
+		envFuncs: []envFunc{
+			func(c *types.Config) {
+				*c, _ = types.ConfigFor(types.LP64)
+			},
+		},
+	},
+	{
+		name:     "data model LLP64",
+		builtins: true,
+		src: `
+long get(long v) { return v + sizeof(long); }
+#if __SIZEOF_POINTER__ == 8
+int ptr_size() { return 8; }
+#else
+int ptr_size() { return 4; }
+#endif
+`,
+		exp: `
+func get(v int32) int32 {
+	return int32(uint32(uintptr(v) + unsafe.Sizeof(int32(0))))
+}
+func ptr_size() int32 {
+	return 8
+}
+`,
+		envFuncs: []envFunc{
+			func(c *types.Config) {
+				*c, _ = types.ConfigFor(types.LLP64)
+			},
+		},
+	},
+}
+
+const (
+	kindUint = 1
+	kindInt  = 2
+)
+
+type intVal struct {
+	Size int
+	Kind int
+	ValI int64
+	ValU uint64
+}
+
+func (v intVal) CType() string {
+	u := ""
+	if v.Kind == kindUint {
+		u = "u"
+	}
+	return fmt.Sprintf("%sint%d_t", u, v.Size*8)
+}
+
+func (v intVal) CValue() string {
+	switch v.Kind {
+	case kindUint:
+		return strconv.FormatUint(v.ValU, 10) + "ul"
+	case kindInt:
+		return strconv.FormatInt(v.ValI, 10) + "l"
+	default:
+		panic("should not happen")
+	}
+}
+
+type binopTest struct {
+	To intVal
+	X  intVal
+	Op BinaryOp
+	Y  intVal
+}
+
+func (b binopTest) CSrc() string {
+	verb := "d"
+	if b.To.Kind == kindUint {
+		verb = "u"
+	}
+	if b.To.Size == 8 {
+		verb = "ll" + verb
+	}
+	return fmt.Sprintf(`
+#include <stdint.h>
+#include <stdio.h>
+
+int main() {
+	%s a = %s;
+	%s b = %s;
+	%s c = 0;
+	c = a %s b;
+	printf("%%%s\n", c);
+	return 0;
+}
+`,
+		b.X.CType(), b.X.CValue(),
+		b.Y.CType(), b.Y.CValue(),
+		b.To.CType(),
+		string(b.Op),
+		verb,
+	)
+}
+
+func randIntVal() intVal {
+	v := intVal{
+		Kind: kindUint,
+		Size: 1 << (rand.Int() % 4),
+	}
+	switch v.Size {
+	case 1, 2, 4, 8:
+	default:
+		panic(v.Size)
+	}
+	if rand.Int()%2 == 0 {
+		v.Kind = kindInt
+	}
+	switch v.Kind {
+	case kindUint:
+		v.ValU = rand.Uint64()
+		switch v.Size {
+		case 1:
+			v.ValU %= math.MaxUint8 + 1
+		case 2:
+			v.ValU %= math.MaxUint16 + 1
+		case 4:
+			v.ValU %= math.MaxUint32 + 1
+		}
+	case kindInt:
+		v.ValI = rand.Int63()
+		switch v.Size {
+		case 1:
+			v.ValI %= math.MaxInt8 + 1
+		case 2:
+			v.ValI %= math.MaxInt16 + 1
+		case 4:
+			v.ValI %= math.MaxInt32 + 1
+		}
+		if rand.Int()%2 == 0 {
+			v.ValI = -v.ValI
+		}
+		switch v.Size {
+		case 1:
+			for v.ValI > math.MaxInt8 {
+				v.ValI -= math.MaxInt8
+			}
+			for v.ValI < math.MinInt8 {
+				v.ValI += math.MinInt8
+			}
+		case 2:
+			for v.ValI > math.MaxInt16 {
+				v.ValI -= math.MaxInt16
+			}
+			for v.ValI < math.MinInt16 {
+				v.ValI += math.MinInt16
+			}
+		case 4:
+			for v.ValI > math.MaxInt32 {
+				v.ValI -= math.MaxInt32
+			}
+			for v.ValI < math.MinInt32 {
+				v.ValI += math.MinInt32
+			}
+		}
+	}
+	return v
+}
+
+func randBinop(op BinaryOp) binopTest {
+	return binopTest{
+		To: randIntVal(),
+		X:  randIntVal(),
+		Op: op,
+		Y:  randIntVal(),
+	}
+}
+
+func TestImplicitCompat(t *testing.T) {
+	for _, op := range []BinaryOp{
+		BinOpAdd,
+		BinOpSub,
+		BinOpMult,
+		BinOpDiv,
+		BinOpMod,
+	} {
+		op := op
+		t.Run(string(op), func(t *testing.T) {
+			t.Parallel()
+			for i := 0; i < 25; i++ {
+				func() {
+					b := randBinop(op)
+					for (b.Op == BinOpMod || b.Op == BinOpDiv) && b.Y.ValI == 0 && b.Y.ValU == 0 {
+						b = randBinop(op)
+					}
+					testTranspileOut(t, b.CSrc())
+				}()
+			}
+		})
+	}
+}
+
+func testTranspileOut(t testing.TB, csrc string) {
+	dir, err := os.MkdirTemp("", "cxgo_cout")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cdir := filepath.Join(dir, "c")
+	err = os.MkdirAll(cdir, 0755)
+	require.NoError(t, err)
+
+	cfile := filepath.Join(cdir, "main.c")
+	err = os.WriteFile(cfile, []byte(csrc), 0644)
+	require.NoError(t, err)
+
+	// this is required for test to wait other goroutines in case it fails earlier on the main one
+	var wg sync.WaitGroup
+	wg.Add(1)
+	cch := make(chan progOut, 1)
+	go func() {
+		defer wg.Done()
+		cch <- gccCompileAndExec(t, cdir, cfile)
+	}()
+	defer wg.Wait()
+
+	godir := filepath.Join(dir, "golang")
+	err = os.MkdirAll(godir, 0755)
+	require.NoError(t, err)
+
+	goout := goTranspileAndExec(t, ".", godir, cfile)
+	cout := <-cch
+	require.Equal(t, cout.Code, goout.Code,
+		"\nC code: %d (%v)\nGo code: %s (%v)",
+		cout.Code, cout.Err,
+		goout.Code, goout.Err,
+	)
+	require.Equal(t, cout.Err, goout.Err)
+	t.Logf("// === Output ===\n%s", cout.Out)
+	require.Equal(t, cout.Out, goout.Out, "\n// === C source ===\n%s", csrc)
+}
+
+func TestRelPath(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	path, err := relPath(filepath.Join(wd, "out"), filepath.Join("src", "a.c"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("..", "src", "a.c"), path)
+}
+
+// testDir is a temporary directory with C sources, translated into a separate output directory.
+type testDir struct {
+	t   testing.TB
+	src string // C files
+	out string // Go files
+}
+
+func newTestDir(t testing.TB, files map[string]string) *testDir {
+	dir := t.TempDir()
+	d := &testDir{t: t, src: filepath.Join(dir, "c"), out: filepath.Join(dir, "out")}
+	require.NoError(t, os.MkdirAll(d.src, 0755))
+	for name, data := range files {
+		d.write(name, data)
+	}
+	return d
+}
+
+// write a C file to the source directory.
+func (d *testDir) write(name, data string) {
+	require.NoError(d.t, os.WriteFile(d.path(name), []byte(data), 0644))
+}
+
+// path returns a path to the C file.
+func (d *testDir) path(name string) string {
+	return filepath.Join(d.src, name)
+}
+
+// translate a given C file to the output directory.
+func (d *testDir) translate(name string, env *libs.Env, conf Config) error {
+	return Translate(d.src, d.path(name), d.out, env, conf)
+}
+
+// read a generated Go file.
+func (d *testDir) read(name string) string {
+	data, err := os.ReadFile(filepath.Join(d.out, name))
+	require.NoError(d.t, err)
+	return string(data)
+}
+
+func TestTranslateLayoutByKind(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+#define A_MAX 10
+typedef struct { int x; } a_t;
+int a_count = 1;
+int a_get(a_t* p) { return p->x + A_MAX; }
+`,
+		"b.c": `
+typedef struct { int y; } b_t;
+int b_get(b_t* p) { return p->y; }
+`,
+	})
+
+	p := NewProject(libs.NewEnv(types.Config32()))
+	for _, name := range []string{"a.c", "b.c"} {
+		err := p.Translate(d.src, d.path(name), d.out, Config{
+			Package: "lib",
+			Layout:  LayoutByKind,
+		})
+		require.NoError(t, err)
+	}
+	typ := d.read("types.go")
+	require.Contains(t, typ, "type a_t struct")
+	require.Contains(t, typ, "type b_t struct")
+	require.Contains(t, d.read("consts.go"), "const A_MAX = 10")
+	require.Contains(t, d.read("vars.go"), "var a_count int32 = 1")
+	require.Contains(t, d.read("a.go"), "func a_get(")
+	require.NotContains(t, d.read("a.go"), "type a_t")
+	require.Contains(t, d.read("b.go"), "func b_get(")
+
+	// shared files cannot be written with different replacements
+	d.write("c.c", "int c_get() { return 3; }\n")
+	err := p.Translate(d.src, d.path("c.c"), d.out, Config{
+		Package: "lib",
+		Layout:  LayoutByKind,
+		Replace: []Replacer{{Old: "a_count", New: "aCount"}},
+	})
+	require.ErrorContains(t, err, "Replace must be the same for all files")
+}
+
+func TestTranslatePredefProfile(t *testing.T) {
+	for _, c := range []struct {
+		profile PredefProfile
+		os      string
+	}{
+		{ProfileLinuxGNU, "linux"},
+		{ProfileDarwin, "darwin"},
+		{ProfileWindowsMSVC, "windows"},
+		{ProfileWasm, "wasm"},
+	} {
+		t.Run(string(c.profile), func(t *testing.T) {
+			d := newTestDir(t, map[string]string{
+				"a.c": `
+#if defined(_WIN64)
+const char* os = "windows";
+#elif defined(__APPLE__)
+const char* os = "darwin";
+#elif defined(__wasm32__)
+const char* os = "wasm";
+#elif defined(__linux__) && defined(__x86_64__)
+const char* os = "linux";
+#else
+const char* os = "unknown";
+#endif
+`,
+			})
+			m, err := c.profile.DataModel()
+			require.NoError(t, err)
+			tconf, err := types.ConfigFor(m)
+			require.NoError(t, err)
+			err = d.translate("a.c", libs.NewEnv(tconf), Config{
+				Package: "lib",
+				Profile: c.profile,
+			})
+			require.NoError(t, err)
+			data := d.read("a.go")
+			require.Contains(t, data, fmt.Sprintf("%q", c.os))
+		})
+	}
+}
+
+func TestTranslateMSVC(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+#pragma warning(push)
+#pragma warning(disable: 4996)
+typedef unsigned __int64 u64;
+__declspec(dllexport) int __stdcall add(int a, __int64 b) { return a + (int)b; }
+static __forceinline int inc(int a) { return a + 1; }
+#pragma warning(pop)
+`,
+	})
+	var notes []string
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		OnNote: func(n Note) {
+			notes = append(notes, fmt.Sprintf("%d:%d: %s", n.Where.Line, n.Where.Column, n.Msg))
+		},
+	})
+	require.NoError(t, err)
+	data := d.read("a.go")
+	require.Equal(t, `package lib
+
+type u64 uint64
+
+func add(a int32, b int64) int32 {
+	return a + int32(b)
+}
+func inc(a int32) int32 {
+	return a + 1
+}
+`, data)
+	require.Equal(t, []string{
+		"5:1: MSVC extension ignored: __declspec(dllexport)",
+		"5:27: MSVC extension ignored: __stdcall",
+	}, notes)
+}
+
+func TestTranslateExternC(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.h": `
+#ifdef __cplusplus
+extern "C" {
+#endif
+int add(int a, int b);
+#ifdef __cplusplus
+}
+#endif
+
+extern "C" {
+struct point { int x, y; };
+// extern "C" in a comment
+extern "C" const char *name(void);
+}
+`,
+		"a.c": `#include "a.h"
+const char *name(void) { return "extern \"C\" {"; }
+int add(int a, int b) { struct point p = {a, b}; return p.x + p.y; }
+`,
+	})
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+	})
+	require.NoError(t, err)
+	data := d.read("a.go")
+	require.Equal(t, `package lib
+
+import "github.com/gotranspile/cxgo/runtime/libc"
+
+type point struct {
+	X int32
+	Y int32
+}
+
+func name() *byte {
+	return libc.CString("extern \"C\" {")
+}
+func add(a int32, b int32) int32 {
+	var p point = point{X: a, Y: b}
+	return p.X + p.Y
+}
+`, data)
+}
+
+func TestTranslateSourceEncoding(t *testing.T) {
+	// UTF-8 header with a BOM and a Latin-1 file with mixed line endings
+	d := newTestDir(t, map[string]string{
+		"a.h": "\xef\xbb\xbfconst char *greeting(void);\r\n",
+		"a.c": "#include \"a.h\"\r\n" +
+			"// caf\xe9\r" +
+			"#define GREETING \\\r\n\t\"caf\xe9\"\r\n" +
+			"const char *greeting(void) { return GREETING; }\r\n" +
+			"unsigned char accent(void) { return '\xe9'; }\r\n",
+	})
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package:        "lib",
+		SourceEncoding: "latin1",
+	})
+	require.NoError(t, err)
+	data := d.read("a.go")
+	require.Equal(t, `package lib
+
+import "github.com/gotranspile/cxgo/runtime/libc"
+
+const GREETING = "café"
+
+func greeting() *byte {
+	return libc.CString(GREETING)
+}
+func accent() uint8 {
+	return '\xe9'
+}
+`, data)
+
+	err = d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package:        "lib",
+		SourceEncoding: "koi8-r",
+	})
+	require.EqualError(t, err, `unsupported source encoding: "koi8-r"`)
+}
+
+func TestTranslateTinyGo(t *testing.T) {
+	fsys := fstest.MapFS{"a.c": {Data: []byte(`#include <arpa/inet.h>
+typedef void (*cb_t)(void);
+void noop(void) {}
+unsigned short port(unsigned short p) { return htons(p); }
+int same(cb_t f) { return f == noop || f == 0; }
+void *addr(cb_t f) { return (void*)f; }
+`)}}
+	for _, tinygo := range []bool{false, true} {
+		var notes []string
+		_, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+			Package: "lib",
+			TinyGo:  tinygo,
+			OnNote: func(n Note) {
+				notes = append(notes, fmt.Sprintf("%s:%d: %s", n.Where.Filename, n.Where.Line, n.Msg))
+			},
+		})
+		require.NoError(t, err)
+		if !tinygo {
+			require.Empty(t, notes)
+			continue
+		}
+		require.Equal(t, []string{
+			"a.c:5: TinyGo: function pointers compared by address in function same",
+			"a.c:6: TinyGo: function pointer converted to an address in function addr",
+			"a.c:0: TinyGo: package github.com/gotranspile/cxgo/runtime/cnet depends on net, which is not supported",
+		}, notes)
+	}
+}
+
+func TestTranslateWASM(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{name: "ok", src: "int a[4];\nint get(int *p, int i) { return *p + a[i]; }\n"},
+		{name: "ptr arith", src: "int x;\nint *next(int *p) { return p + 1; }\n", err: "a.c:2:1: unsupported C construct: wasm: unsafe pointer arithmetic in function next"},
+		{name: "ptr to int", src: "unsigned long addr(int *p) { return (unsigned long)p; }\n", err: "a.c:1:1: unsupported C construct: wasm: pointer converted to an integer in function addr"},
+		{name: "import", src: "#include <arpa/inet.h>\nunsigned short port(unsigned short p) { return htons(p); }\n",
+			err: "a.c: unsupported C construct: wasm: package github.com/gotranspile/cxgo/runtime/cnet depends on net, which is not supported"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"a.c": {Data: []byte(c.src)}}
+			_, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{Package: "lib", WASM: true})
+			if c.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			var e *ErrUnsupportedConstruct
+			require.ErrorAs(t, err, &e)
+			require.EqualError(t, e, c.err)
+			// the same code is accepted without the option
+			_, err = TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{Package: "lib"})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTranslatePtrIntCast(t *testing.T) {
+	const src = `typedef unsigned long uintptr_t;
+uintptr_t addr(int *p) { return (uintptr_t)p; }
+int *back(uintptr_t v) { return (int*)v; }
+void *end() { return (void*)-1; }
+`
+	cases := []struct {
+		mode PtrIntCast
+		exp  []string
+		err  string
+	}{
+		{mode: PtrIntDefault, exp: []string{"uint32(uintptr(unsafe.Pointer(p)))", "(*int32)(unsafe.Pointer(uintptr(v)))"}},
+		{mode: PtrIntUintptr, exp: []string{"//go:nocheckptr\nfunc back(", "}\nfunc end("}},
+		{mode: PtrIntHandle, exp: []string{"uint32(libc.PtrToHandle(unsafe.Pointer(p)))", "(*int32)(libc.HandleToPtr(uintptr(v)))", "unsafe.Pointer(uintptr(4294967295))"}},
+		{mode: PtrIntError, err: "a.c:2:1: unsupported C construct: pointer converted to an integer in function addr (see ptr_int_cast)"},
+		{mode: "box", err: `unsupported pointer to integer cast policy: "box"`},
+	}
+	for _, c := range cases {
+		t.Run(string(c.mode), func(t *testing.T) {
+			fsys := fstest.MapFS{"a.c": {Data: []byte(src)}}
+			out, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{Package: "lib", PtrIntCast: c.mode})
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			code := string(out["a.go"])
+			for _, s := range c.exp {
+				require.Contains(t, code, s)
+			}
+		})
+	}
+}
+
+func TestTranslateC23(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.h": `
+[[deprecated("use b")]] int a(int x [[maybe_unused]]);
+`,
+		"a.c": `
+#include "a.h"
+
+int n = 1'000'000; // it's a comment
+long h = 0xFF'FF;
+const char* s = "it's [[not]] an attribute";
+typeof(n) m = 1;
+
+[[nodiscard]] bool is_null(int *p) {
+	return p == nullptr;
+}
+int a(int x) {
+	switch (x) {
+	case 1:
+		x++;
+		[[fallthrough]];
+	default:
+		return x ? true : false;
+	}
+}
+`,
+	})
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		C23:     true,
+	})
+	require.NoError(t, err)
+	data := d.read("a.go")
+	require.Equal(t, `package lib
+
+import "github.com/gotranspile/cxgo/runtime/libc"
+
+var n int32 = 1000000
+var h int32 = 0xFFFF
+var s *byte = libc.CString("it's [[not]] an attribute")
+var m int32 = 1
+
+func is_null(p *int32) bool {
+	return p == nil
+}
+func a(x int32) int32 {
+	switch x {
+	case 1:
+		x++
+		fallthrough
+	default:
+		if x != 0 {
+			return 1
+		}
+		return 0
+	}
+}
+`, data)
+}
+
+func TestTranslateConversions(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+void g(short v);
+char f(int x, double d) {
+	unsigned char b = x;
+	unsigned int u = x;
+	long long l = x;
+	g(x);
+	u = d;
+	b = 1;
+	return x;
+}
+`,
+	})
+	var convs []string
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		OnConversion: func(c Conversion) {
+			convs = append(convs, fmt.Sprintf("%d: %s: %s -> %s", c.Where.Line, c.Risk, c.From, c.To))
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"4: high: int32 -> uint8",
+		"5: low: int32 -> uint32",
+		"7: medium: int32 -> int16",
+		"8: high: float64 -> uint32",
+		"10: medium: int32 -> int8",
+	}, convs)
+}
+
+func TestTranslateSymbols(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+typedef struct { int x; } point_t;
+int count, total;
+
+int sum_point(point_t *p) {
+	return p->x + count;
+}
+`,
+	})
+	var syms []string
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		Idents: []IdentConfig{
+			{Name: "sum_point", Rename: "SumPoint"},
+		},
+		OnSymbol: func(s Symbol) {
+			syms = append(syms, fmt.Sprintf("%d: %s %s -> %s (%s)", s.Where.Line, s.Kind, s.C, s.Go, filepath.Base(s.GoFile)))
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"2: type point_t -> point_t (a.go)",
+		"3: var count -> count (a.go)",
+		"3: var total -> total (a.go)",
+		"5: func sum_point -> SumPoint (a.go)",
+	}, syms)
+}
+
+func TestTranslateProvenance(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+int x;
+
+int f(int a) {
+	return a + x;
+}
+`,
+	})
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package:    "lib",
+		Header:     "Generated by cxgo {{.Version}} from {{.Source}}.\n\nConfig: {{.ConfigHash}}",
+		Version:    "v1.0",
+		ConfigHash: "abc",
+		Provenance: true,
+	})
+	require.NoError(t, err)
+	data := d.read("a.go")
+	require.Equal(t, `// Generated by cxgo v1.0 from a.c.
+//
+// Config: abc
+
+package lib
+
+// from a.c:2
+var x int32
+
+// from a.c:4
+func f(a int32) int32 {
+	return a + x
+}
+`, data)
+}
+
+func TestTranslateMacroComments(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.h": `
+#define BUF_SIZE (4*1024)
+#define MIN_VAL -5
+#define NAME "abc"
+`,
+		"a.c": `
+#include "a.h"
+#define SUM (1) + (2)
+#define MASK \
+	(0x1 | 0x4)
+
+int f(int a) {
+	const char* s = NAME;
+	return a + BUF_SIZE - MIN_VAL + SUM * 2 + (MASK);
+}
+`,
+	})
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package:       "lib",
+		MacroComments: true,
+	})
+	require.NoError(t, err)
+	data := d.read("a.go")
+	require.Equal(t, `package lib
+
+import "github.com/gotranspile/cxgo/runtime/libc"
+
+// from a.c:3: #define SUM (1) + (2)
+const SUM = 3
+
+// from a.c:4: #define MASK (0x1 | 0x4)
+const MASK = 5
+
+// from a.h:2: #define BUF_SIZE (4*1024)
+const BUF_SIZE = 4096
+
+// from a.h:3: #define MIN_VAL -5
+const MIN_VAL = -5
+
+// from a.h:4: #define NAME "abc"
+const NAME = "abc"
+
+func f(a int32) int32 {
+	var s *byte = libc.CString(NAME)
+	_ = s
+	return a + BUF_SIZE - MIN_VAL + 1 + 2*2 + MASK
+}
+`, data)
+}
+
+func TestTranslateImportPaths(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+#include <stdlib.h>
+#include <string.h>
+
+int f(char* s) {
+	return strlen(s) + abs(-1);
+}
+`,
+	})
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		ImportPaths: map[string]string{
+			"github.com/gotranspile/cxgo": "example.com/fork/cxgo",
+		},
+		ImportAliases: map[string]string{
+			"libc": "clibc",
+		},
+	})
+	require.NoError(t, err)
+	data := d.read("a.go")
+	require.Equal(t, `package lib
+
+import (
+	"example.com/fork/cxgo/runtime/cmath"
+	clibc "example.com/fork/cxgo/runtime/libc"
+)
+
+func f(s *byte) int32 {
+	return int32(int64(clibc.StrLen(s)) + cmath.Abs(-1))
+}
+`, data)
+}
+
+func TestTranslateBlankImports(t *testing.T) {
+	fsys := fstest.MapFS{"a.c": {Data: []byte(`
+#include <sqlite3.h>
+
+int f(sqlite3* db) {
+	return sqlite3_close(db);
+}
+`)}}
+	out, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		ImportPaths: map[string]string{
+			"modernc.org/sqlite": "example.com/sqlite",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `package lib
+
+import (
+	_ "example.com/sqlite"
+	"github.com/gotranspile/cxgo/runtime/csqlite"
+)
+
+func f(db *csqlite.DB) int32 {
+	return csqlite.Close(db)
+}
+`, string(out["a.go"]))
+}
+
+func TestReplaceImportPath(t *testing.T) {
+	paths := map[string]string{
+		"github.com/gotranspile":              "example.com/a",
+		"github.com/gotranspile/cxgo/runtime": "example.com/b",
+	}
+	require.Equal(t, "example.com/b/libc", ReplaceImportPath(paths, "github.com/gotranspile/cxgo/runtime/libc"))
+	require.Equal(t, "example.com/a/glfw", ReplaceImportPath(paths, "github.com/gotranspile/glfw"))
+	require.Equal(t, "github.com/gotranspile2/x", ReplaceImportPath(paths, "github.com/gotranspile2/x"))
+	require.Equal(t, "math", ReplaceImportPath(paths, "math"))
+}
+
+func TestTranslateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/a.h": {Data: []byte(`
+#define N 3
+int f(int a);
+`)},
+		"src/a.c": {Data: []byte(`
+#include "a.h"
+#include <stdlib.h>
+
+int f(int a) {
+	return abs(a) + N;
+}
+`)},
+	}
+	files, err := TranslateFS(fsys, "src", "src/a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, `package lib
+
+import "github.com/gotranspile/cxgo/runtime/cmath"
+
+const N = 3
+
+func f(a int32) int32 {
+	return int32(cmath.Abs(int64(a)) + N)
+}
+`, string(files["a.go"]))
+}
+
+func TestTranslateStream(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`
+#include <string.h>
+
+typedef struct {
+	int x;
+	char* s;
+} A;
+
+static int n = 1;
+
+// returns the length
+int f(A* a) {
+	return strlen(a->s) + a->x + n;
+}
+
+void g(void) {}
+`)},
+	}
+	for _, conf := range []Config{
+		{Package: "lib", DoNotEdit: true, Replace: []Replacer{{Old: "int32", New: "int"}}},
+		{Package: "lib", Provenance: true},
+	} {
+		exp, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), conf)
+		require.NoError(t, err)
+		conf.Stream = true
+		got, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), conf)
+		require.NoError(t, err)
+		require.Equal(t, string(exp["a.go"]), string(got["a.go"]))
+	}
+
+	out := t.TempDir()
+	err := Translate("", "a.c", out, libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		FS:      fsys,
+		Stream:  true,
+	})
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(out, "a.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "func g() {\n}\n")
+}
+
+func TestTranslateProgress(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`
+int f(int a) { return a + 1; }
+int g(int a) { return a - 1; }
+`)},
+	}
+	var (
+		passes []Pass
+		last   = make(map[Pass]Progress)
+	)
+	_, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		OnProgress: func(p Progress) {
+			require.Equal(t, "a.c", p.File)
+			if len(passes) == 0 || passes[len(passes)-1] != p.Pass {
+				passes = append(passes, p.Pass)
+			}
+			last[p.Pass] = p
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Pass{PassParse, PassConvert, PassRewrite, PassFlatten, PassEmit, PassWrite}, passes)
+	require.Equal(t, last[PassConvert].Total, last[PassConvert].Done)
+	require.Equal(t, Progress{File: "a.c", Pass: PassEmit, Done: 2, Total: 2}, last[PassEmit])
+}
+
+func TestTranslateLogger(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`
+#include <stdlib.h>
+int f(int a) { return a + 1; }
+`)},
+	}
+	var buf bytes.Buffer
+	_, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: LevelTrace})),
+		Idents: []IdentConfig{{Name: "f", Rename: "F"}},
+	})
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `msg="header resolved to a library" path=/_cxgo_overrides/stdlib.h`)
+	require.Contains(t, buf.String(), `level=DEBUG msg="renaming identifier" name=f to=F`)
+}
+
+func TestTranslateWarningDirectives(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`#ifdef LEGACY
+#error "LEGACY is not supported"
+#endif
+#warning "deprecated" API\n
+#if 0
+#warning disabled
+#endif
+int x;
+`)},
+	}
+	var buf bytes.Buffer
+	_, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		Logger: slog.New(slog.NewTextHandler(&buf, nil)),
+	})
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `level=WARN msg="#warning \"deprecated\" API\\n" pos=a.c:4`)
+	require.NotContains(t, buf.String(), "disabled")
+
+	_, err = TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		Define: []Define{{Name: "LEGACY"}},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.ErrorIs(t, err, ErrParse)
+	require.ErrorContains(t, err, `a.c:2:1: "LEGACY is not supported"`)
+}
+
+func TestTranslateEmbedArrays(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`#include <stdint.h>
+const unsigned char data[8] = {0x7f, 'E', 'L', 'F', -1, [6] = 2};
+uint8_t small[] = {1, 2, 3};
+signed char sdata[8] = {1, 2, 3};
+`)},
+	}
+	out, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		Package:     "lib",
+		EmbedArrays: 4,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x7f, 'E', 'L', 'F', 0xff, 0, 2, 0}, out["data.bin"])
+	require.Len(t, out, 2)
+	require.Equal(t, `package lib
+
+import _ "embed"
+
+//go:embed data.bin
+var data_bin []byte
+var data [8]uint8 = [8]uint8(data_bin)
+var small [3]uint8 = [3]uint8{1, 2, 3}
+var sdata [8]int8 = [8]int8{0: 1, 1: 2, 2: 3}
+`, string(out["a.go"]))
+}
+
+func TestTranslateWeakSymbols(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`
+int counter = 1;
+extern int count __attribute__((alias("counter")));
+
+void impl(int x) { counter += x; }
+void api(int x) __attribute__((alias("impl")));
+
+__attribute__((weak)) int version(void) { return 1; }
+extern void hook(void) __attribute__((weak));
+
+#pragma weak debug
+void debug(void) {}
+
+void run(void) {
+	count++;
+	api(2);
+	if (hook) hook();
+}
+`)},
+		"b.c": {Data: []byte(`
+int version(void) { return 2; }
+`)},
+	}
+	out := make(MemOutput)
+	p := NewProject(libs.NewEnv(types.Config32()))
+	conf := Config{FS: fsys, Output: out, Package: "lib"}
+	for _, name := range []string{"a.c", "b.c"} {
+		require.NoError(t, p.Translate("", name, "", conf))
+	}
+	require.NoError(t, p.Flush())
+	require.Len(t, out, 3)
+	require.Equal(t, `package lib
+
+var counter int32 = 1
+
+func impl(x int32) {
+	counter += x
+}
+func run() {
+	counter++
+	api(2)
+	if hook != nil {
+		hook()
+	}
+}
+
+var api func(x int32) = impl
+`, string(out["a.go"]))
+	require.Equal(t, `package lib
+
+func version() int32 {
+	return 2
+}
+`, string(out["b.go"]))
+	require.Equal(t, `package lib
+
+func debug() {
+}
+
+var hook func()
+`, string(out["a_weak.go"]))
+}
+
+func TestTranslateInlineHeaders(t *testing.T) {
+	fsys := fstest.MapFS{
+		"util.h": {Data: []byte(`
+static inline int sq(int x) { return x * x; }
+static inline int cube(int x) { return sq(x) * x; }
+`)},
+		"a.c": {Data: []byte(`#include "util.h"
+int fa(int x) { return sq(x); }
+`)},
+		"b.c": {Data: []byte(`#include "util.h"
+int fb(int x) { return cube(x); }
+`)},
+	}
+	out := make(MemOutput)
+	p := NewProject(libs.NewEnv(types.Config32()))
+	conf := Config{FS: fsys, Output: out, Package: "lib", InlineHeaders: true}
+	// the header itself is translated as well, its inline functions must not be redeclared
+	for _, name := range []string{"a.c", "util.h", "b.c"} {
+		require.NoError(t, p.Translate("", name, "", conf))
+	}
+	require.NoError(t, p.Flush())
+	require.Len(t, out, 3)
+	require.Equal(t, `package lib
+
+func fa(x int32) int32 {
+	return sq(x)
+}
+`, string(out["a.go"]))
+	require.Equal(t, `package lib
+
+func fb(x int32) int32 {
+	return cube(x)
+}
+`, string(out["b.go"]))
+	require.Equal(t, `package lib
+
+func sq(x int32) int32 {
+	return x * x
+}
+func cube(x int32) int32 {
+	return sq(x) * x
+}
+`, string(out["inline.go"]))
+}
+
+func TestTranslateMetrics(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`#include <stdlib.h>
+#define SIZE 16
+typedef struct { int x; } point;
+point* cur;
+void reset(void) {
+	free(cur);
+	cur = malloc(SIZE);
+	free(malloc(1));
+}
+`)},
+	}
+	var m Metrics
+	_, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{Metrics: &m})
+	require.NoError(t, err)
+	require.Equal(t, 1, m.Files)
+	require.Equal(t, 1, m.Funcs)
+	require.Equal(t, 1, m.Types)
+	require.Equal(t, 1, m.Vars)
+	require.Equal(t, 1, m.Consts)
+	require.Equal(t, map[string]int{"libc.Free": 2, "libc.Malloc": 2}, m.Runtime)
+	require.Equal(t, `1 files: 1 funcs, 1 types, 1 vars, 1 consts
+0 flattened funcs, 1 unsafe usages, 0 stubs
+4 runtime calls: libc.Free 2 libc.Malloc 2
+`, m.String())
+}
+
+func TestTranslateGotoScopes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`
+int f(int n) {
+	if (n) goto out;
+	int x = 5, y;
+	n += x;
+	y = n;
+	n += y;
+out:
+	return n;
+}
+int g(int n) {
+	if (n) goto in;
+	if (n > 5) { n = 1; in: n++; }
+	return n;
+}
+int h(int n) {
+	int x = 1;
+	{
+		n += x;
+		if (n) goto out;
+		int x = 2;
+		n += x;
+	out:
+		n++;
+	}
+	return n;
+}
+`)},
+	}
+	var notes []string
+	out, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		OnNote: func(n Note) {
+			notes = append(notes, n.String())
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.c:11:1: goto in jumps into a block, flattening function g"}, notes)
+	require.Equal(t, `package lib
+
+func f(n int32) int32 {
+	var (
+		x int32
+		y int32
+	)
+	if n != 0 {
+		goto out
+	}
+	x = 5
+	n += x
+	y = n
+	n += y
+out:
+	return n
+}
+func g(n int32) int32 {
+	if n == 0 {
+		if n > 5 {
+			n = 1
+		} else {
+			goto L_6
+		}
+	}
+	n++
+L_6:
+	return n
+}
+func h(n int32) int32 {
+	var x int32 = 1
+	{
+		var x2 int32
+		n += x
+		if n != 0 {
+			goto out
+		}
+		x2 = 2
+		n += x2
+	out:
+		n++
+	}
+	return n
+}
+`, string(out["a.go"]))
+}
+
+func TestTranslateFlattenAuto(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`
+int f(int n) {
+	int s = 0;
+again:
+	s += n;
+	n--;
+	if (n) goto again;
+	return s;
+}
+int g(int n) {
+	if (n == 1) goto a;
+	if (n == 2) goto b;
+	return 0;
+a:
+	return 1;
+b:
+	return 2;
+}
+int h(int n) {
+	if (n) goto in;
+	while (n > 5) { n--; in: n++; }
+	return n;
+}
+`)},
+	}
+	disabled := false
+	var notes []string
+	out, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		Package:      "lib",
+		FlattenAuto:  true,
+		FlattenGotos: 2,
+		Idents:       []IdentConfig{{Name: "h", Flatten: &disabled}},
+		OnNote: func(n Note) {
+			notes = append(notes, n.String())
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"a.c:2:1: backward goto again, flattening function f",
+		"a.c:10:1: 2 gotos, flattening function g",
+		"a.c:19:1: goto in jumps into a block, but flattening is disabled for function h",
+	}, notes)
+	require.Equal(t, `package lib
+
+func f(n int32) int32 {
+	var s int32
+	s = 0
+	for {
+		s += n
+		n--
+		if n == 0 {
+			break
+		}
+	}
+	return s
+}
+func g(n int32) int32 {
+	if n == 1 {
+		return 1
+	}
+	if n == 2 {
+		return 2
+	}
+	return 0
+}
+func h(n int32) int32 {
+	if n != 0 {
+		goto in
+	}
+	for n > 5 {
+		n--
+	in:
+		n++
+	}
+	return n
+}
+`, string(out["a.go"]))
+}
+
+func TestTranslateSizeofHints(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`
+struct S {
+	int *buf;
+	char name[16];
+	int n;
+};
+int f(struct S *s) {
+	int n = sizeof(s->buf) + sizeof(s->name) + sizeof(*s->buf) + sizeof(s->n);
+	n += sizeof(struct S);
+	return n;
+}
+`)},
+	}
+	var notes []string
+	_, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		Idents: []IdentConfig{
+			{Name: "S", Fields: []IdentConfig{{Name: "buf", Type: HintSlice}, {Name: "name", Type: HintString}}},
+		},
+		OnNote: func(n Note) {
+			notes = append(notes, n.String())
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"a.c:8:10: sizeof uses the C size 4 of pointer to int, since the type is changed to []int32 by the config",
+		"a.c:8:27: sizeof uses the C size 16 of array of 16 char, since the type is changed to string by the config",
+		"a.c:9:7: sizeof(struct S) differs from C, since the type of field buf is changed by the config",
+	}, notes)
+}
+
+func TestTranslateStdoutBuffered(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`
+#include <stdio.h>
+#include <stdlib.h>
+
+int main() {
+	printf("a %d\n", 1);
+	fputs("b", stdout);
+	fflush(stdout);
+	if (0) exit(2);
+	if (0) _Exit(3);
+	return 0;
+}
+`)},
+		"b.c": {Data: []byte(`
+#include <stdio.h>
+
+int main() {
+	printf("p");
+}
+`)},
+	}
+	env := libs.NewEnv(types.Config32())
+	env.Stdout = libs.StdoutBuffered
+	out, err := TranslateFS(fsys, "", "a.c", env, Config{Package: "main"})
+	require.NoError(t, err)
+	require.Equal(t, `package main
+
+import (
+	"github.com/gotranspile/cxgo/runtime/libc"
+	"github.com/gotranspile/cxgo/runtime/stdio"
+	"os"
+)
+
+func main() {
+	stdio.BufferedPrintf("a %d\n", 1)
+	stdio.BufferedStdout().PutS(libc.CString("b"))
+	stdio.BufferedStdout().Flush()
+	if false {
+		stdio.Exit(2)
+	}
+	if false {
+		os.Exit(3)
+	}
+	stdio.Exit(0)
+}
+`, string(out["a.go"]))
+
+	// falling off the end of main must write the buffer as well
+	out, err = TranslateFS(fsys, "", "b.c", env, Config{Package: "main"})
+	require.NoError(t, err)
+	require.Equal(t, `package main
+
+import "github.com/gotranspile/cxgo/runtime/stdio"
+
+func main() {
+	stdio.BufferedPrintf("p")
+	stdio.Exit(0)
+}
+`, string(out["b.go"]))
+}
+
+func TestErrorList(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte("int f(int a) { return a }\nint x = ;\n")},
+		"b.c": {Data: []byte("int g(int a) { return a; }\n")},
+		"c.c": {Data: []byte("int h(int a) { return b; }\n")},
+	}
+	var errs ErrorList
+	for _, name := range []string{"a.c", "b.c", "c.c"} {
+		_, err := TranslateFS(fsys, "", name, libs.NewEnv(types.Config32()), Config{})
+		errs.Add(name, err)
+	}
+	require.Equal(t, 3, errs.Len())
+	require.Equal(t, `a.c:
+	parsing failed: a.c:1:25: `+"`}`"+`: expected ;
+	parsing failed: a.c:2:9: `+"`;`"+`: expected primary-expression
+c.c: parsing failed: c.c:1:23: front-end: undefined: b`, errs.Err().Error())
+	var ferr *FileErrors
+	require.True(t, errors.As(errs.Err(), &ferr))
+	require.Equal(t, "a.c", ferr.File)
+	require.NoError(t, ErrorList(nil).Err())
+}
+
+func TestTranslateErrors(t *testing.T) {
+	translate := func(src string, conf Config) error {
+		fsys := fstest.MapFS{"a.c": {Data: []byte(src)}}
+		_, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), conf)
+		return err
+	}
+	err := translate("int f(int a) { return a }\n", Config{})
+	require.ErrorIs(t, err, ErrParse)
+
+	err = translate("void f(void) {\n\tgoto *&&l;\nl:\n\treturn;\n}\n", Config{})
+	var uerr *ErrUnsupportedConstruct
+	require.True(t, errors.As(err, &uerr), "%v", err)
+	require.Equal(t, "JumpStatementGotoExpr", uerr.Kind)
+	require.Equal(t, 2, uerr.Pos.Line)
+	require.NotNil(t, uerr.Node)
+
+	err = translate("int f(void) { return 1; }\n", Config{Replace: []Replacer{{Old: "func", New: "fun"}}})
+	require.ErrorIs(t, err, ErrFormat)
+}
+
+func TestDumpAST(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.h": {Data: []byte("int g(void);\n")},
+		"a.c": {Data: []byte("#include \"a.h\"\nint x;\n")},
+	}
+	env := libs.NewEnv(types.Config32())
+	tu, err := Parse(env, "", "a.c", SourceConfig{FS: fsys})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, DumpAST(&buf, tu, "a.c"))
+	require.True(t, strings.HasPrefix(buf.String(), "a.c:2:1:\n&cc.ExternalDeclaration{\n"), buf.String())
+	require.NotContains(t, buf.String(), `"g"`)
+
+	buf.Reset()
+	require.NoError(t, DumpASTJSON(&buf, tu, "a.c"))
+	var decls []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decls))
+	require.Len(t, decls, 1)
+	require.Equal(t, "ExternalDeclaration", decls[0]["node"])
+	require.Equal(t, "ExternalDeclarationDecl", decls[0]["case"])
+	decl := decls[0]["Declaration"].(map[string]any)
+	spec := decl["DeclarationSpecifiers"].(map[string]any)["TypeSpecifier"].(map[string]any)
+	require.Equal(t, map[string]any{"token": "int", "pos": "a.c:2:1"}, spec["Token"])
+
+	buf.Reset()
+	require.NoError(t, DumpASTJSON(&buf, tu, "a.h"))
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decls))
+	require.Len(t, decls, 1)
+}
+
+func TestDumpIR(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte("typedef struct { int a; } T;\nint f(T* t) {\n  if (t->a > 2) return 1;\n  return t->a + 1;\n}\n")},
+	}
+	env := libs.NewEnv(types.Config32())
+	tu, err := Parse(env, "", "a.c", SourceConfig{FS: fsys})
+	require.NoError(t, err)
+	decls, err := TranslateCAST("a.c", tu, env, Config{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, DumpIR(&buf, decls))
+	require.Equal(t, `CTypeDef
+  Name: T
+  Type: struct {
+  	A int32
+  }
+
+CFuncDecl
+  Name: f
+  Type: func(t *T) int32
+  Body: BlockStmt
+    Stmts:
+      CDeclStmt
+        Decl: CVarDecl
+          Static: true
+          Type: [2]byte
+          Names:
+            __func__
+          Inits:
+            StringLit "f" (string)
+      CIfStmt
+        Cond: Comparison (bool)
+          X: CSelectExpr (int32)
+            Expr: IdentExpr t (*T)
+            Sel: A
+          Op: >
+          Y: IntLit 2 (uint8)
+        Then: BlockStmt
+          Stmts:
+            CReturnStmt
+              Expr: IntLit 1 (uint8)
+      CReturnStmt
+        Expr: CBinaryExpr (int32)
+          Left: CSelectExpr (int32)
+            Expr: IdentExpr t (*T)
+            Sel: A
+          Op: +
+          Right: IntLit 1 (uint8)
+  Range: Range
+    Start: 29
+    StartLine: 2
+
+`, buf.String())
+}
+
+const testIRWalkSrc = `
+void trace(int);
+int f(int a) {
+  trace(a);
+  if (a > 2) {
+    trace(1);
+    return a + 1;
+  }
+  return 1;
+}
+`
+
+func translateTestIR(t testing.TB, src string) []CDecl {
+	fsys := fstest.MapFS{"a.c": {Data: []byte(src)}}
+	env := libs.NewEnv(types.Config32())
+	tu, err := Parse(env, "", "a.c", SourceConfig{FS: fsys})
+	require.NoError(t, err)
+	decls, err := TranslateCAST("a.c", tu, env, Config{})
+	require.NoError(t, err)
+	return decls
+}
+
+func TestWalk(t *testing.T) {
+	decls := translateTestIR(t, testIRWalkSrc)
+	var (
+		depth int
+		calls []string
+		lits  []int64
+	)
+	for _, d := range decls {
+		Walk(d, func(n Node) bool {
+			switch n := n.(type) {
+			case nil:
+				depth--
+				return false
+			case *CallExpr:
+				calls = append(calls, n.Fun.(FuncIdent).Name)
+			case IntLit:
+				lits = append(lits, n.Int())
+			}
+			depth++
+			return true
+		})
+	}
+	require.Equal(t, 0, depth)
+	require.Equal(t, []string{"trace", "trace"}, calls)
+	require.Equal(t, []int64{2, 1, 1, 1}, lits)
+}
+
+func TestRewrite(t *testing.T) {
+	decls := translateTestIR(t, testIRWalkSrc)
+	var parents []string
+	for i, d := range decls {
+		decls[i] = Rewrite(d, func(c *Cursor) bool {
+			switch n := c.Node().(type) {
+			case *CExprStmt:
+				// remove trace calls
+				if call, ok := n.Expr.(*CallExpr); ok && call.Fun.(FuncIdent).Name == "trace" {
+					c.Delete()
+					return false
+				}
+			case *CReturnStmt:
+				c.InsertBefore(&CLabelStmt{Label: "ret" + strconv.Itoa(c.Index())})
+			case IntLit:
+				parents = append(parents, fmt.Sprintf("%T.%s", c.Parent(), c.Name()))
+				if n.Int() == 1 {
+					c.Replace(cIntLit(7, 10))
+				}
+			}
+			return true
+		}, nil).(CDecl)
+	}
+	require.Equal(t, []string{"*cxgo.Comparison.Y", "*cxgo.CBinaryExpr.Right", "*cxgo.CReturnStmt.Expr"}, parents)
+
+	var buf bytes.Buffer
+	require.NoError(t, DumpIR(&buf, decls))
+	require.NotContains(t, buf.String(), "trace")
+	require.Equal(t, 2, strings.Count(buf.String(), "IntLit 7"))
+	require.Contains(t, buf.String(), "CLabelStmt\n              Label: ret0\n            CReturnStmt")
+	require.Contains(t, buf.String(), "CLabelStmt\n        Label: ret2\n      CReturnStmt")
+
+	// stop the traversal from post
+	n := 0
+	Rewrite(decls[len(decls)-1], nil, func(c *Cursor) bool {
+		n++
+		return false
+	})
+	require.Equal(t, 1, n)
+}
+
+func TestTranslateContext(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.c": {Data: []byte(`
+int f(int a) { return a + 1; }
+`)},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := TranslateContext(ctx, "", "a.c", "", libs.NewEnv(types.Config32()), Config{
+		FS:     fsys,
+		Output: make(MemOutput),
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	env := libs.NewEnv(types.Config32())
+	tu, err := Parse(env, "", "a.c", SourceConfig{FS: fsys})
+	require.NoError(t, err)
+	_, err = TranslateASTContext(ctx, "a.c", tu, env, Config{})
+	require.ErrorIs(t, err, context.Canceled)
+	decls, err := TranslateASTContext(context.Background(), "a.c", tu, env, Config{})
+	require.NoError(t, err)
+	require.NotEmpty(t, decls)
+}
+
+func TestTranslateTargets(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+int add(int a, int b) { return a + b; }
+long get(long v) { return v; }
+#ifdef _WIN32
+const char* os_name() { return "windows"; }
+#else
+const char* os_name() { return "linux"; }
+#endif
+`,
+	})
+	var targets []Target
+	for _, c := range []struct {
+		goos, goarch string
+		win          bool
+	}{
+		{"linux", "386", false},
+		{"linux", "amd64", false},
+		{"windows", "amd64", true},
+	} {
+		tconf, err := types.ConfigForTarget(c.goos, c.goarch)
+		require.NoError(t, err)
+		tg := Target{Name: c.goos + "_" + c.goarch, Build: c.goos + " && " + c.goarch, Env: libs.NewEnv(tconf)}
+		if c.win {
+			tg.Define = []Define{{Name: "_WIN32"}}
+		}
+		targets = append(targets, tg)
+	}
+	p, err := NewTargetProject(targets)
+	require.NoError(t, err)
+	err = p.Translate(d.src, d.path("a.c"), d.out, Config{Package: "lib"})
+	require.NoError(t, err)
+	shared := d.read("a.go")
+	require.Contains(t, shared, "func add(a int32, b int32) int32 {")
+	require.NotContains(t, shared, "func get(")
+
+	l386 := d.read("a_linux_386.go")
+	require.Contains(t, l386, "//go:build linux && 386")
+	require.Contains(t, l386, "func get(v int32) int32 {")
+	require.Contains(t, l386, `"linux"`)
+	require.NotContains(t, l386, "func add(")
+
+	require.Contains(t, d.read("a_linux_amd64.go"), "func get(v int64) int64 {")
+
+	win := d.read("a_windows_amd64.go")
+	require.Contains(t, win, "func get(v int32) int32 {")
+	require.Contains(t, win, `"windows"`)
+}
+
+func TestTranslateCgo(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+int a_get(int v) { return v; }
+`,
+	})
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package:  "lib",
+		Cgo:      true,
+		MaxDecls: 1,
+	})
+	require.NoError(t, err)
+	data := d.read("a.go")
+	require.Equal(t, `package lib
+
+/*
+#define main cxgo_c_main
+#include "../c/a.c"
+#undef main
+*/
+import "C"
+
+func a_get(v int32) int32 {
+	return int32(C.a_get(C.int(v)))
+}
+`, data)
+}
+
+func TestTranslateBenchmarks(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+int fib(int n) { return n < 2 ? n : fib(n-1) + fib(n-2); }
+int sum(int* p, int n) { return n == 0 ? 0 : p[0] + sum(p+1, n-1); }
+`,
+	})
+	err := d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		Benchmarks: []BenchConfig{
+			{Name: "fib", Args: []string{"20"}, Cgo: true},
+			{Name: "sum"},
+			{Name: "other"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `package lib
+
+import "testing"
+
+func BenchmarkFib(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		fib(20)
+	}
+}
+func BenchmarkSum(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sum(nil, 0)
+	}
+}
+`, d.read("a_bench_test.go"))
+	require.Equal(t, `//go:build cxgobench
+
+package lib
+
+/*
+#define main cxgo_c_main
+#include "../c/a.c"
+#undef main
+*/
+import "C"
+
+func cbench_fib(n int32) int32 {
+	return int32(C.fib(C.int(n)))
+}
+`, d.read("a_cbench.go"))
+	require.Contains(t, d.read("a_cbench_test.go"), "func BenchmarkFib_C(b *testing.B) {")
+	require.NotContains(t, d.read("a.go"), "import \"C\"")
+
+	err = d.translate("a.c", libs.NewEnv(types.Config32()), Config{
+		Package:    "lib",
+		Benchmarks: []BenchConfig{{Name: "fib", Args: []string{"1", "2"}}},
+	})
+	require.Error(t, err)
+}
+
+func TestTranslateTreeShake(t *testing.T) {
+	d := newTestDir(t, map[string]string{
+		"a.c": `
+int a_used() { return 1; }
+int a_unused() { return 2; }
+`,
+		"b.c": `
+int a_used();
+void main() { a_used(); }
+`,
+	})
+
+	p := NewProject(libs.NewEnv(types.Config32()))
+	for _, name := range []string{"a.c", "b.c"} {
+		err := p.Translate(d.src, d.path(name), d.out, Config{
+			Package:   "main",
+			TreeShake: true,
+		})
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(d.out, "a.go"))
+		require.True(t, os.IsNotExist(err), "files must be written by Flush")
+	}
+	require.NoError(t, p.Flush())
+	data := d.read("a.go")
+	require.Contains(t, data, "func a_used(")
+	require.NotContains(t, data, "a_unused")
+	data = d.read("b.go")
+	require.Contains(t, data, "func main(")
+
+	p = NewProject(libs.NewEnv(types.Config32()))
+	err := p.Translate(d.src, d.path("a.c"), d.out, Config{
+		Package:   "lib",
+		TreeShake: true,
+	})
+	require.NoError(t, err)
+	require.Error(t, p.Flush())
+}
+
+func TestTranslateDeterministic(t *testing.T) {
+	const src = `
+#include <stdio.h>
+#include <setjmp.h>
+
+#define B 2
+#define A 1
+
+typedef struct { int x; union { int a; float b; }; } s_t;
+
+jmp_buf buf;
+
+int foo(s_t* p) {
+	if (setjmp(buf)) return A;
+	printf("%d\n", p->x + B);
+	return p->a;
+}
+`
+	var prev string
+	for i := 0; i < 5; i++ {
+		env := libs.NewEnv(types.Config32())
+		ast, err := ParseSource(env, ParseConfig{
+			Sources: []cc.Source{{Name: "det.c", Value: src}},
+		})
+		require.NoError(t, err)
+		decls, err := TranslateAST("det.c", ast, env, Config{})
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		err = PrintGo(&buf, "lib", decls, false)
+		require.NoError(t, err)
+		if i != 0 {
+			require.Equal(t, prev, buf.String())
+		}
+		prev = buf.String()
+	}
+}
+
+func TestTranslateGCAlloc(t *testing.T) {
+	fsys := fstest.MapFS{"a.c": {Data: []byte(`#include <stdlib.h>
+typedef struct { int x; } T;
+int foo(int n, int* q) {
+	T* p = calloc(1, sizeof(T));
+	int* a = malloc(n * sizeof(int));
+	int* b;
+	b = malloc(sizeof(int));
+	int* c = malloc(10);
+	int* d = malloc(n * sizeof(int));
+	d = realloc(d, 2 * n * sizeof(int));
+	p->x = *a + *b + *c + *d;
+	free(p);
+	free(a);
+	free(b);
+	free(c);
+	free(d);
+	free(q);
+	return p->x;
+}
+`)}}
+	var notes []string
+	out, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+		Package: "lib",
+		GCAlloc: true,
+		OnNote: func(n Note) {
+			notes = append(notes, fmt.Sprintf("%s:%d: %s", n.Where.Filename, n.Where.Line, n.Msg))
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `package lib
+
+import (
+	"github.com/gotranspile/cxgo/runtime/libc"
+	"unsafe"
+)
+
+type T struct {
+	X int32
+}
+
+func foo(n int32, q *int32) int32 {
+	var (
+		p *T     = new(T)
+		a *int32 = &make([]int32, int(n))[0]
+		b *int32
+	)
+	b = new(int32)
+	var c *int32 = (*int32)(libc.Malloc(10))
+	var d *int32 = (*int32)(libc.Malloc(int(n * int32(unsafe.Sizeof(int32(0))))))
+	d = (*int32)(libc.Realloc(unsafe.Pointer(d), int(n*2*int32(unsafe.Sizeof(int32(0))))))
+	p.X = *a + *b + *c + *d
+	libc.Free(unsafe.Pointer(c))
+	libc.Free(unsafe.Pointer(d))
+	libc.Free(unsafe.Pointer(q))
+	return p.X
+}
+`, string(out["a.go"]))
+	require.Equal(t, []string{
+		"a.c:3: kept the C allocation of d passed to realloc in function foo",
+		"a.c:3: removed free(p) of a Go allocation in function foo",
+		"a.c:3: removed free(a) of a Go allocation in function foo",
+		"a.c:3: removed free(b) of a Go allocation in function foo",
+	}, notes)
+}
+
+func TestTranslateFinalizers(t *testing.T) {
+	fsys := fstest.MapFS{"a.c": {Data: []byte(`#include <stdio.h>
+#include <fcntl.h>
+#include <unistd.h>
+typedef struct { FILE* f; } S;
+int count(const char* path) {
+	FILE* f = fopen(path, "r");
+	if (!f) return -1;
+	int n = 0;
+	while (fgetc(f) != EOF) n++;
+	return n;
+}
+void keep(S* s, const char* path) {
+	s->f = fopen(path, "r");
+}
+int rd(const char* path) {
+	char buf[10];
+	int fd = open(path, O_RDONLY);
+	return read(fd, buf, 10);
+}
+`)}}
+	for _, tinygo := range []bool{false, true} {
+		var notes []string
+		out, err := TranslateFS(fsys, "", "a.c", libs.NewEnv(types.Config32()), Config{
+			Package:    "lib",
+			Finalizers: true,
+			TinyGo:     tinygo,
+			OnNote: func(n Note) {
+				notes = append(notes, fmt.Sprintf("%s:%d: %s", n.Where.Filename, n.Where.Line, n.Msg))
+			},
+		})
+		require.NoError(t, err)
+		keep := "s.F = stdio.Finalize(stdio.FOpen(libc.GoString(path), \"r\"))"
+		if tinygo {
+			keep = "s.F = stdio.FOpen(libc.GoString(path), \"r\")"
+			require.Equal(t, []string{
+				"a.c:12: TinyGo: finalizers are not supported, file opened in function keep may leak",
+			}, notes)
+		} else {
+			require.Empty(t, notes)
+		}
+		require.Equal(t, `package lib
+
+import (
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+	"github.com/gotranspile/cxgo/runtime/stdio"
+)
+
+type S struct {
+	F *stdio.File
+}
+
+func count(path *byte) int32 {
+	var f *stdio.File = stdio.FOpen(libc.GoString(path), "r")
+	defer f.Close()
+	if f == nil {
+		return -1
+	}
+	var n int32 = 0
+	for f.GetC() != stdio.EOF {
+		n++
+	}
+	return n
+}
+func keep(s *S, path *byte) {
+	`+keep+`
+}
+func rd(path *byte) int32 {
+	var (
+		buf [10]byte
+		fd  int32 = int32(stdio.Open(path, csys.O_RDONLY))
+	)
+	defer stdio.LookupFD(uintptr(fd)).Close()
+	return stdio.ByFD(uintptr(fd)).Read(&buf[0], 10)
+}
+`, string(out["a.go"]))
+	}
 }
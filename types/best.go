@@ -106,16 +106,16 @@ func (e *Env) CommonType(x, y Type) (otyp Type) {
 			return x
 		}
 	case BoolType:
-		switch y := y.(type) {
+		switch yu := Unwrap(y).(type) {
 		case IntType:
 			// bool+int = int
-			if y.Kind().IsUntypedInt() {
+			if yu.Kind().IsUntypedInt() {
 				return e.DefIntT()
 			}
 			return y
 		case FloatType:
 			// bool+float = float
-			if y.Kind().IsUntyped() {
+			if yu.Kind().IsUntyped() {
 				return e.DefFloatT()
 			}
 			return y
@@ -135,3 +135,16 @@ func (e *Env) CommonType(x, y Type) (otyp Type) {
 	// TODO
 	return x
 }
+
+// IntPromote returns the type of an integer expression after C integer promotion:
+// integer types smaller than int are promoted to int. Other types are returned as-is.
+func (e *Env) IntPromote(t Type) Type {
+	if !t.Kind().IsInt() || t.Kind().IsUntyped() {
+		return t
+	}
+	def := e.DefIntT()
+	if t.Sizeof() < def.Sizeof() {
+		return def
+	}
+	return t
+}
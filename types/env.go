@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/binary"
 	"os"
 	"sort"
 	"unsafe"
@@ -31,9 +32,11 @@ func Config64() Config {
 type Config struct {
 	PtrSize     int  // size of pointers in bytes
 	IntSize     int  // default int size in bytes
+	LongSize    int  // long size in bytes; defaults to IntSize
 	WCharSize   int  // wchar_t size
 	WCharSigned bool // is wchar_t signed?
-	UseGoInt    bool // use Go int for C int and long
+	UseGoInt    bool // use Go int for C int, and for long of the same size
+	BigEndian   bool // target byte order is big endian
 }
 
 func (c *Config) setDefaults() {
@@ -60,6 +63,9 @@ func (c *Config) setDefaults() {
 			c.IntSize = int(unsafe.Sizeof(int(0)))
 		}
 	}
+	if c.LongSize == 0 {
+		c.LongSize = c.IntSize
+	}
 }
 
 func NewEnv(c Config) *Env {
@@ -114,6 +120,24 @@ func (e *Env) IntSize() int {
 	return e.conf.IntSize
 }
 
+// BigEndian checks if the target byte order is big endian.
+func (e *Env) BigEndian() bool {
+	return e.conf.BigEndian
+}
+
+// ByteOrder returns the target byte order.
+func (e *Env) ByteOrder() binary.ByteOrder {
+	if e.conf.BigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// LongSize returns the size of C long type.
+func (e *Env) LongSize() int {
+	return e.conf.LongSize
+}
+
 // PtrT returns a pointer type with a specified element.
 func (e *Env) PtrT(t Type) PtrType {
 	return PtrT(e.conf.PtrSize, t)
@@ -178,9 +202,14 @@ func (e *Env) VarFuncTT(ret Type, args ...Type) *FuncType {
 }
 
 func (e *Env) MethStructT(meth map[string]*FuncType) *StructType {
+	names := make([]string, 0, len(meth))
+	for name := range meth {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 	fields := make([]*Field, 0, len(meth))
-	for name, ft := range meth {
-		id := NewIdent(name, ft)
+	for _, name := range names {
+		id := NewIdent(name, meth[name])
 		fields = append(fields, &Field{
 			Name: id,
 		})
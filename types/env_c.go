@@ -168,11 +168,17 @@ func (c *C) UnsignedInt() Type {
 
 // Long returns C long type.
 func (c *C) Long() Type {
+	if sz := c.e.conf.LongSize; sz != c.e.conf.IntSize {
+		return IntT(sz)
+	}
 	return c.Int()
 }
 
 // UnsignedLong returns C unsigned long type.
 func (c *C) UnsignedLong() Type {
+	if sz := c.e.conf.LongSize; sz != c.e.conf.IntSize {
+		return UintT(sz)
+	}
 	return c.UnsignedInt()
 }
 
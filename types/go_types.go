@@ -109,7 +109,11 @@ func (f *Field) GoField() *ast.Field {
 func (t *StructType) GoType() GoType {
 	fields := &ast.FieldList{}
 	if t.union {
-		fields.List = append(fields.List, &ast.Field{Type: ident("// union")})
+		comment := "// union"
+		if t.dependsOnByteOrder() {
+			comment += "; depends on byte order"
+		}
+		fields.List = append(fields.List, &ast.Field{Type: ident(comment)})
 	}
 	for _, f := range t.fields {
 		fields.List = append(fields.List, f.GoField())
@@ -117,6 +121,25 @@ func (t *StructType) GoType() GoType {
 	return &ast.StructType{Fields: fields}
 }
 
+// dependsOnByteOrder checks if the union is used for type punning of integers and bytes (as in union { uint32_t v; uint8_t b[4]; }),
+// thus its behavior depends on the byte order of the target.
+func (t *StructType) dependsOnByteOrder() bool {
+	hasInt, hasBytes := false, false
+	for _, f := range t.fields {
+		switch ft := Unwrap(f.Type()).(type) {
+		case IntType:
+			if ft.Sizeof() > 1 {
+				hasInt = true
+			}
+		case ArrayType:
+			if e := ft.Elem(); e.Kind().IsInt() && e.Sizeof() == 1 {
+				hasBytes = true
+			}
+		}
+	}
+	return hasInt && hasBytes
+}
+
 func (t *FuncType) GoType() GoType {
 	return t.GoFuncType()
 }
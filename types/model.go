@@ -0,0 +1,62 @@
+package types
+
+import "fmt"
+
+// DataModel is a C data model that defines sizes of int, long and pointer types.
+type DataModel string
+
+const (
+	ILP32 = DataModel("ILP32") // int, long and pointers are 32 bit; all 32 bit targets
+	LP64  = DataModel("LP64")  // long and pointers are 64 bit; 64 bit Unix systems
+	LLP64 = DataModel("LLP64") // only pointers are 64 bit; 64 bit Windows
+)
+
+// ConfigFor returns a types config for a given data model.
+func ConfigFor(m DataModel) (Config, error) {
+	var c Config
+	switch m {
+	case ILP32:
+		c = Config{IntSize: 4, LongSize: 4, PtrSize: 4}
+	case LP64:
+		c = Config{IntSize: 4, LongSize: 8, PtrSize: 8}
+	case LLP64:
+		c = Config{IntSize: 4, LongSize: 4, PtrSize: 8}
+	default:
+		return Config{}, fmt.Errorf("unsupported data model: %q", m)
+	}
+	c.setDefaults()
+	return c, nil
+}
+
+// TargetDataModel returns a data model used by C compilers for a given Go target.
+func TargetDataModel(goos, goarch string) (DataModel, error) {
+	switch goarch {
+	case "386", "arm", "mips", "mipsle":
+		return ILP32, nil
+	case "amd64", "arm64", "loong64", "mips64", "mips64le", "ppc64", "ppc64le", "riscv64", "s390x", "wasm":
+		// Go always uses 64 bit pointers on these architectures, thus they must be translated as 64 bit targets
+	default:
+		return "", fmt.Errorf("unsupported GOARCH: %q", goarch)
+	}
+	if goos == "windows" {
+		return LLP64, nil
+	}
+	return LP64, nil
+}
+
+// ConfigForTarget returns a types config for a given Go target, including its byte order.
+func ConfigForTarget(goos, goarch string) (Config, error) {
+	m, err := TargetDataModel(goos, goarch)
+	if err != nil {
+		return Config{}, err
+	}
+	c, err := ConfigFor(m)
+	if err != nil {
+		return Config{}, err
+	}
+	switch goarch {
+	case "mips", "mips64", "ppc64", "s390x":
+		c.BigEndian = true
+	}
+	return c, nil
+}
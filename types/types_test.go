@@ -48,6 +48,7 @@ func TestCommonType(t *testing.T) {
 	}{
 		{"bool and int", BoolT(), IntT(1), IntT(1)},
 		{"bool and untyped int", BoolT(), AsUntypedIntT(IntT(1)), IntT(8)},
+		{"bool and named int", BoolT(), NamedTGo("byte", "byte", UintT(1)), NamedTGo("byte", "byte", UintT(1))},
 		{"bool and float", BoolT(), FloatT(8), FloatT(8)},
 		{"bool and untyped float", BoolT(), AsUntypedFloatT(FloatT(8)), FloatT(8)},
 	}
@@ -61,3 +62,26 @@ func TestCommonType(t *testing.T) {
 		})
 	}
 }
+
+func TestTargetDataModel(t *testing.T) {
+	cases := []struct {
+		goos, goarch string
+		exp          DataModel
+	}{
+		{"linux", "386", ILP32},
+		{"linux", "arm", ILP32},
+		{"windows", "386", ILP32},
+		{"linux", "amd64", LP64},
+		{"darwin", "arm64", LP64},
+		{"windows", "amd64", LLP64},
+	}
+	for _, c := range cases {
+		t.Run(c.goos+"/"+c.goarch, func(t *testing.T) {
+			m, err := TargetDataModel(c.goos, c.goarch)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, m)
+		})
+	}
+	_, err := TargetDataModel("linux", "z80")
+	require.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package cxgo
+
+import (
+	"go/ast"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// UnusedPolicy selects how unused parameters and local variables are handled;
+// see Config.UnusedParams and Config.UnusedVars.
+type UnusedPolicy string
+
+const (
+	// UnusedDefault keeps unused parameters as is, and assigns unused variables to _.
+	UnusedDefault = UnusedPolicy("")
+	// UnusedAssign assigns unused parameters and variables to _.
+	UnusedAssign = UnusedPolicy("assign")
+	// UnusedRename renames unused parameters and variables to _. Variables are only renamed, if they are never
+	// read, incremented or addressed, and are assigned to _ otherwise.
+	UnusedRename = UnusedPolicy("rename")
+	// UnusedNolint keeps unused parameters, and adds a //nolint directive for revive and unparam to the function.
+	// Unused variables are assigned to _, since Go doesn't allow them.
+	UnusedNolint = UnusedPolicy("nolint")
+)
+
+// unusedNolint is the directive added to functions with unused parameters for UnusedNolint.
+const unusedNolint = "//nolint:revive,unparam"
+
+// unusedParams returns indexes of named parameters of the function that are not used in its body.
+func unusedParams(f *CFuncDecl) []int {
+	if f.Body == nil {
+		return nil
+	}
+	var out []int
+	for i, p := range f.Type.Args() {
+		if p.Name == nil || p.Name.Name == "" || p.Name.GoIdent().Name == "_" {
+			continue
+		}
+		if countIdent(f.Body, p.Name) == 0 {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// fixUnusedParams assigns unused parameters of the function to _ or renames them, depending on Config.UnusedParams.
+func (g *translator) fixUnusedParams(f *CFuncDecl) {
+	switch g.conf.UnusedParams {
+	case UnusedAssign, UnusedRename:
+	default:
+		return
+	}
+	unused := unusedParams(f)
+	if len(unused) == 0 {
+		return
+	}
+	if g.conf.UnusedParams == UnusedAssign {
+		stmts := make([]CStmt, 0, len(unused)+len(f.Body.Stmts))
+		for _, i := range unused {
+			stmts = append(stmts, &UnusedVar{Name: f.Type.Args()[i].Name})
+		}
+		f.Body.Stmts = append(stmts, f.Body.Stmts...)
+		return
+	}
+	args := append([]*types.Field{}, f.Type.Args()...)
+	for _, i := range unused {
+		id := args[i].Name
+		args[i] = &types.Field{Name: types.NewIdentGo(id.Name, "_", id.CType(nil))}
+	}
+	if f.Type.Variadic() {
+		f.Type = g.env.VarFuncT(f.Type.Return(), args...)
+	} else {
+		f.Type = g.env.FuncT(f.Type.Return(), args...)
+	}
+}
+
+// renameUnusedVars renames variables assigned to _ by fixUnusedVars, if Config.UnusedVars is UnusedRename.
+// Values can be assigned to _ as well, thus variables that are only declared and assigned are renamed.
+func (g *translator) renameUnusedVars(f *CFuncDecl) {
+	if g.conf.UnusedVars != UnusedRename || f.Body == nil {
+		return
+	}
+	Rewrite(f.Body, nil, func(c *Cursor) bool {
+		if u, ok := c.Node().(*UnusedVar); ok && canRenameUnused(f.Body, u.Name) {
+			u.Name.GoName = "_"
+			c.Delete()
+		}
+		return true
+	})
+}
+
+// canRenameUnused checks if the variable is only used by its declaration and plain assignments.
+// Untyped nil cannot be assigned to _.
+func canRenameUnused(body *BlockStmt, id *types.Ident) bool {
+	allowed := 0
+	Walk(body, func(n Node) bool {
+		switch n := n.(type) {
+		case *CVarDecl:
+			for _, name := range n.Names {
+				if name == id {
+					allowed++
+				}
+			}
+		case *CAssignStmt:
+			if x, ok := n.Left.(IdentExpr); ok && x.Ident == id && n.Op == "" {
+				if _, ok := cUnwrap(n.Right).(Nil); !ok {
+					allowed++
+				}
+			}
+		}
+		return true
+	})
+	return allowed != 0 && countIdent(body, id) == allowed
+}
+
+// addUnusedNolint adds the //nolint directive to Go functions with unused parameters, if Config.UnusedParams
+// is UnusedNolint.
+func (g *translator) addUnusedNolint(d CDecl, decls []GoDecl) {
+	if g.conf.UnusedParams != UnusedNolint {
+		return
+	}
+	f, ok := d.(*CFuncDecl)
+	if !ok || len(unusedParams(f)) == 0 {
+		return
+	}
+	for _, gd := range decls {
+		if fd, ok := gd.(*ast.FuncDecl); ok {
+			fd.Doc = appendComment(fd.Doc, &ast.Comment{Text: unusedNolint})
+		}
+	}
+}
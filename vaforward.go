@@ -0,0 +1,280 @@
+package cxgo
+
+import (
+	"strings"
+
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// isVaList checks if the type is va_list, which is translated to libc.ArgList.
+func isVaList(t types.Type) bool {
+	nt, ok := t.(types.Named)
+	return ok && nt.Name().Name == "__builtin_va_list"
+}
+
+// recordVaList remembers a static function with a va_list as the last parameter, which may be converted to
+// a variadic function by vaForward.
+func (g *translator) recordVaList(d *cc.Declarator, name *types.Ident, ft *types.FuncType) {
+	if !d.IsStatic() || ft.Variadic() || ft.ArgN() == 0 || !isVaList(ft.Args()[ft.ArgN()-1].Type()) {
+		return
+	}
+	g.vaLists[name] = struct{}{}
+}
+
+// vaVariadic returns a variadic library function for a library function that accepts va_list as the last argument,
+// for example printf for vprintf, or nil if there is none.
+func (g *translator) vaVariadic(id *types.Ident) *types.Ident {
+	ft, ok := id.CType(nil).(*types.FuncType)
+	if !ok || ft.Variadic() || ft.ArgN() == 0 || !isVaList(ft.Args()[ft.ArgN()-1].Type()) || !strings.HasPrefix(id.Name, "v") {
+		return nil
+	}
+	if lid, ok := g.env.IdentByName(id.Name); !ok || lid != id {
+		return nil
+	}
+	vid, ok := g.env.IdentByName(id.Name[1:])
+	if !ok {
+		return nil
+	}
+	vt, ok := vid.CType(nil).(*types.FuncType)
+	if !ok || !vt.Variadic() || vt.ArgN() != ft.ArgN()-1 {
+		return nil
+	}
+	for i, a := range vt.Args() {
+		if !types.Same(a.Type(), ft.Args()[i].Type()) {
+			return nil
+		}
+	}
+	return vid
+}
+
+// vaCall is a call that passes a va_list as the last argument, either to a library function like vprintf,
+// or to another function that forwards it.
+type vaCall struct {
+	call *CallExpr
+	fun  *types.Ident // called function
+}
+
+// vaFunc is a function that forwards a va_list: either a variadic function with a local va_list,
+// or a function with a va_list parameter.
+type vaFunc struct {
+	f     *CFuncDecl
+	va    *types.Ident // va_list variable or parameter
+	param bool         // va is the last parameter of the function
+	calls []vaCall     // calls forwarding va
+	other bool         // va is used in other ways, for example by va_arg
+}
+
+// vaForward converts functions that forward their variable arguments to printf-like functions to Go variadic
+// forwarding. A va_list started in a variadic function and only passed to functions like vprintf is removed,
+// and the variadic counterpart of the function is called instead:
+//
+//	va_list ap; va_start(ap, format); vprintf(format, ap); va_end(ap); -> stdio.Printf(format, _rest...)
+//
+// Static functions with a va_list parameter, which only forward it this way, become variadic functions,
+// if all their calls forward a va_list that is converted as well.
+func (g *translator) vaForward(decl []CDecl) {
+	var funcs []*vaFunc
+	byName := make(map[*types.Ident]*vaFunc)
+	uses := make(map[*types.Ident]int)
+	calls := make(map[*types.Ident]int)
+	for _, d := range decl {
+		Walk(d, func(n Node) bool {
+			switch n := n.(type) {
+			case *CallExpr:
+				if id, ok := n.Fun.(Ident); ok {
+					calls[id.Identifier()]++
+				}
+			case Ident:
+				uses[n.Identifier()]++
+			}
+			return true
+		})
+		f, ok := d.(*CFuncDecl)
+		if !ok || f.Body == nil {
+			continue
+		}
+		// the name of the declaration itself
+		uses[f.Name]--
+		var vf *vaFunc
+		if _, ok := g.vaLists[f.Name]; ok {
+			vf = &vaFunc{f: f, va: f.Type.Args()[f.Type.ArgN()-1].Name, param: true}
+			byName[f.Name] = vf
+		} else if f.Type.Variadic() {
+			vf = g.vaLocal(f)
+		}
+		if vf != nil {
+			funcs = append(funcs, vf)
+		}
+	}
+	if len(funcs) == 0 {
+		return
+	}
+	for _, vf := range funcs {
+		g.vaUses(vf)
+	}
+	// functions with va_list parameters are only converted with all their callers
+	conv := make(map[*vaFunc]bool)
+	for _, vf := range funcs {
+		conv[vf] = !vf.other && (!vf.param || uses[vf.f.Name] == calls[vf.f.Name])
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, vf := range funcs {
+			if !conv[vf] {
+				continue
+			}
+			for _, c := range vf.calls {
+				if to := byName[c.fun]; to != nil && !conv[to] {
+					conv[vf], changed = false, true
+					break
+				}
+			}
+		}
+		// a function with a va_list parameter needs a converted va_list in each call
+		for _, to := range funcs {
+			if !to.param || !conv[to] {
+				continue
+			}
+			n := 0
+			for _, vf := range funcs {
+				if !conv[vf] {
+					continue
+				}
+				for _, c := range vf.calls {
+					if c.fun == to.f.Name {
+						n++
+					}
+				}
+			}
+			if n != calls[to.f.Name] {
+				conv[to], changed = false, true
+			}
+		}
+	}
+	for _, vf := range funcs {
+		if conv[vf] {
+			g.vaConvert(vf)
+		}
+	}
+}
+
+// vaLocal finds a local va_list of a variadic function. Functions with multiple va_list variables are not supported.
+func (g *translator) vaLocal(f *CFuncDecl) *vaFunc {
+	var vf *vaFunc
+	multiple := false
+	Walk(f.Body, func(n Node) bool {
+		d, ok := n.(*CVarDecl)
+		if !ok {
+			return true
+		}
+		for _, id := range d.Names {
+			if isVaList(id.CType(nil)) {
+				if vf != nil || len(d.Names) != 1 {
+					multiple = true
+				}
+				vf = &vaFunc{f: f, va: id}
+			}
+		}
+		return true
+	})
+	if multiple {
+		return nil
+	}
+	return vf
+}
+
+// vaMethod returns the name of the libc.ArgList method called on the variable, for example "Start".
+func vaMethod(e Expr, va *types.Ident) string {
+	c, ok := e.(*CallExpr)
+	if !ok {
+		return ""
+	}
+	fa, ok := c.Fun.(FuncAssert)
+	if !ok {
+		return ""
+	}
+	sel, ok := fa.X.(*CSelectExpr)
+	if !ok {
+		return ""
+	}
+	if x, ok := sel.Expr.(IdentExpr); !ok || x.Ident != va {
+		return ""
+	}
+	return sel.Sel.Name
+}
+
+// vaUses finds all uses of the va_list of the function.
+func (g *translator) vaUses(vf *vaFunc) {
+	total := countIdent(vf.f.Body, vf.va)
+	known := 0
+	Walk(vf.f.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *CVarDecl:
+			for _, id := range n.Names {
+				if id == vf.va {
+					known++
+				}
+			}
+		case *CExprStmt:
+			switch vaMethod(n.Expr, vf.va) {
+			case "Start":
+				if !vf.param {
+					known++
+				}
+			case "End":
+				known++
+			}
+		case *CallExpr:
+			id, ok := n.Fun.(Ident)
+			if !ok || len(n.Args) == 0 {
+				break
+			}
+			x, ok := cUnwrap(n.Args[len(n.Args)-1]).(IdentExpr)
+			if !ok || x.Ident != vf.va {
+				break
+			}
+			_, fwd := g.vaLists[id.Identifier()]
+			if fwd || g.vaVariadic(id.Identifier()) != nil {
+				vf.calls = append(vf.calls, vaCall{call: n, fun: id.Identifier()})
+				known++
+			}
+		}
+		return true
+	})
+	vf.other = total != known || len(vf.calls) == 0
+}
+
+// vaConvert rewrites the function to pass variable arguments instead of the va_list.
+func (g *translator) vaConvert(vf *vaFunc) {
+	f := vf.f
+	g.conf.debug("forwarding variable arguments", "func", f.Name.Name, "va_list", vf.va.Name)
+	rest := IdentExpr{types.NewIdent("_rest", types.UnkT(1))}
+	for _, c := range vf.calls {
+		fun := c.call.Fun
+		if vid := g.vaVariadic(c.fun); vid != nil {
+			fun = FuncIdent{vid}
+		}
+		args := append([]Expr{}, c.call.Args[:len(c.call.Args)-1]...)
+		c.call.Fun = fun
+		c.call.Args = append(args, &ExpandExpr{X: rest})
+	}
+	Rewrite(f.Body, nil, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case *CExprStmt:
+			if m := vaMethod(n.Expr, vf.va); m == "Start" || m == "End" {
+				c.Delete()
+			}
+		case *CDeclStmt:
+			if d, ok := n.Decl.(*CVarDecl); ok && len(d.Names) == 1 && d.Names[0] == vf.va {
+				c.Delete()
+			}
+		}
+		return true
+	})
+	if vf.param {
+		args := f.Type.Args()
+		f.Type = g.env.VarFuncT(f.Type.Return(), args[:len(args)-1]...)
+	}
+}
@@ -1,8 +1,14 @@
 package cxgo
 
 import (
+	"bytes"
+	"context"
 	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -13,12 +19,16 @@ import (
 
 var timeRun = time.Now()
 
-func newIncludeFS(c *libs.Env) cc.Filesystem {
-	return includeFS{c: c}
+func newIncludeFS(c *libs.Env, log *slog.Logger) cc.Filesystem {
+	if log == nil {
+		log = slog.Default()
+	}
+	return includeFS{c: c, log: log}
 }
 
 type includeFS struct {
-	c *libs.Env
+	c   *libs.Env
+	log *slog.Logger
 }
 
 func (fs includeFS) content(path string, sys bool) (string, error) {
@@ -29,8 +39,10 @@ func (fs includeFS) content(path string, sys bool) (string, error) {
 	}
 	l, ok := fs.c.NewLibrary(path)
 	if !ok {
+		fs.log.Log(context.Background(), LevelTrace, "header not found in libraries", "path", path, "sys", sys)
 		return "", os.ErrNotExist
 	}
+	fs.log.Log(context.Background(), LevelTrace, "header resolved to a library", "path", path, "sys", sys)
 	return l.Header, nil
 }
 
@@ -78,3 +90,108 @@ func (fi includeFI) IsDir() bool {
 func (fi includeFI) Sys() interface{} {
 	return fi
 }
+
+// newSourceFS adapts fs.FS to the filesystem used by the C parser; see Config.FS.
+// Absolute paths are resolved relative to the root of fsys.
+func newSourceFS(fsys fs.FS) cc.Filesystem {
+	return &sourceFS{fsys: fsys}
+}
+
+type sourceFS struct {
+	fsys fs.FS
+}
+
+// fsPath converts a file path to a path in fs.FS.
+func fsPath(name string) string {
+	name = strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+	if name == "" {
+		name = "."
+	}
+	return name
+}
+
+func (s *sourceFS) Stat(name string, sys bool) (os.FileInfo, error) {
+	return fs.Stat(s.fsys, fsPath(name))
+}
+
+func (s *sourceFS) Open(name string, sys bool) (io.ReadCloser, error) {
+	return s.fsys.Open(fsPath(name))
+}
+
+// newRewriteFS wraps the filesystem to rewrite C syntax that the C parser doesn't support; see rewriteSource.
+func newRewriteFS(fs cc.Filesystem, c23 bool, cs *charset) cc.Filesystem {
+	return rewriteFS{fs: fs, c23: c23, cs: cs}
+}
+
+// rewriteFS must be comparable, since the C parser uses the filesystem as a cache key.
+type rewriteFS struct {
+	fs  cc.Filesystem
+	c23 bool
+	cs  *charset
+}
+
+func (fs rewriteFS) Stat(path string, sys bool) (os.FileInfo, error) {
+	fi, err := fs.fs.Stat(path, sys)
+	if err != nil || fi.IsDir() || (!fs.c23 && fs.cs == nil) {
+		// other rewrites preserve the size
+		return fi, err
+	}
+	data, err := fs.read(path, sys)
+	if err != nil {
+		return nil, err
+	}
+	return rewriteFI{FileInfo: fi, size: int64(len(data))}, nil
+}
+
+// rewriteFI reports the size of the rewritten file, preserving the rest of the file info.
+type rewriteFI struct {
+	os.FileInfo
+	size int64
+}
+
+func (fi rewriteFI) Size() int64 {
+	return fi.size
+}
+
+func (fs rewriteFS) Open(path string, sys bool) (io.ReadCloser, error) {
+	data, err := fs.read(path, sys)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (fs rewriteFS) read(path string, sys bool) ([]byte, error) {
+	f, err := fs.fs.Open(path, sys)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return rewriteSource(data, fs.c23, fs.cs), nil
+}
+
+// rewriteSource normalizes C source, converts it to UTF-8, removes C++ linkage specifications and replaces #warning
+// directives. If c23 is set, it also rewrites C23 syntax. See normalizeSource, decodeSource, rewriteExternC,
+// rewriteWarning and rewriteC23.
+func rewriteSource(data []byte, c23 bool, cs *charset) []byte {
+	data = normalizeSource(data)
+	data = decodeSource(data, cs)
+	data = rewriteExternC(data)
+	data = rewriteWarning(data)
+	if c23 {
+		data = rewriteC23(data)
+	}
+	return data
+}
+
+// readSource reads a C source file either from Config.FS, or from the OS.
+func readSource(conf Config, fname string) ([]byte, error) {
+	if conf.FS != nil {
+		return fs.ReadFile(conf.FS, fsPath(fname))
+	}
+	return os.ReadFile(fname)
+}
@@ -0,0 +1,123 @@
+package cxgo
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// atomicSuffix returns a suffix of sync/atomic functions for a given type, if they support it.
+func atomicSuffix(t types.Type) (string, bool) {
+	id, ok := t.GoType().(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	switch id.Name {
+	case "int32":
+		return "Int32", true
+	case "int64":
+		return "Int64", true
+	case "uint32":
+		return "Uint32", true
+	case "uint64":
+		return "Uint64", true
+	case "uintptr":
+		return "Uintptr", true
+	}
+	return "", false
+}
+
+// atomicIdent wraps the variable to be accessed via sync/atomic, if it's volatile and Config.VolatileAtomic is set,
+// or if it's enabled for this identifier with IdentConfig.Atomic.
+func (g *translator) atomicIdent(id IdentExpr, volatile bool) Expr {
+	if !(volatile && g.conf.VolatileAtomic) && !g.idents[id.Name].Atomic {
+		return id
+	}
+	if _, ok := atomicSuffix(id.CType(nil)); !ok {
+		return id
+	}
+	return &AtomicIdent{X: id}
+}
+
+// AtomicIdent is a variable that is read and written with sync/atomic functions.
+type AtomicIdent struct {
+	X IdentExpr
+}
+
+func (e *AtomicIdent) Visit(v Visitor) {
+	v(e.X)
+}
+
+func (e *AtomicIdent) CType(exp types.Type) types.Type {
+	return e.X.CType(exp)
+}
+
+func (e *AtomicIdent) IsConst() bool {
+	return false
+}
+
+func (e *AtomicIdent) HasSideEffects() bool {
+	return false
+}
+
+func (e *AtomicIdent) Uses() []types.Usage {
+	return e.X.Uses()
+}
+
+func (e *AtomicIdent) atomicFunc(name string) GoExpr {
+	suf, _ := atomicSuffix(e.CType(nil))
+	return ident("atomic." + name + suf)
+}
+
+func (e *AtomicIdent) AsExpr() GoExpr {
+	return call(e.atomicFunc("Load"), addr(e.X.AsExpr()))
+}
+
+// store returns an atomic store (or add) statement for the assignment to the variable.
+func (e *AtomicIdent) store(op BinaryOp, y GoExpr) GoStmt {
+	switch op {
+	case "":
+	case BinOpAdd:
+		return &ast.ExprStmt{X: e.add(y)}
+	default:
+		y = &ast.BinaryExpr{X: e.AsExpr(), Op: op.GoToken(), Y: y}
+	}
+	return &ast.ExprStmt{X: call(e.atomicFunc("Store"), addr(e.X.AsExpr()), y)}
+}
+
+// add returns an atomic add expression that evaluates to the new value of the variable.
+func (e *AtomicIdent) add(y GoExpr) GoExpr {
+	return call(e.atomicFunc("Add"), addr(e.X.AsExpr()), y)
+}
+
+// delta returns the value added by an increment or decrement.
+func (e *AtomicIdent) delta(decr bool) GoExpr {
+	if !decr {
+		return intLit(1)
+	}
+	if e.CType(nil).Kind().IsSigned() {
+		return intLit(-1)
+	}
+	// see sync/atomic docs for AddUint32
+	return &ast.UnaryExpr{Op: token.XOR, X: call(e.CType(nil).GoType(), intLit(0))}
+}
+
+// incr returns an atomic increment or decrement statement.
+func (e *AtomicIdent) incr(decr bool) GoStmt {
+	return &ast.ExprStmt{X: e.add(e.delta(decr))}
+}
+
+// incrExpr returns an atomic increment or decrement expression, which evaluates to the new value of the variable
+// if prefix is set, or to the old one otherwise.
+func (e *AtomicIdent) incrExpr(decr, prefix bool) GoExpr {
+	x := e.add(e.delta(decr))
+	if prefix {
+		return x
+	}
+	op := token.SUB
+	if decr {
+		op = token.ADD
+	}
+	return &ast.BinaryExpr{X: x, Op: op, Y: intLit(1)}
+}
@@ -0,0 +1,272 @@
+package cxgo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Walk traverses the IR in depth-first order, similar to ast.Inspect: it calls f(n), and if it returns true,
+// Walk is called recursively for each child of n, followed by a call of f(nil).
+//
+// Children are the nodes reported by Node.Visit.
+func Walk(n Node, f func(n Node) bool) {
+	if isNilNode(n) || !f(n) {
+		return
+	}
+	n.Visit(func(c Node) {
+		Walk(c, f)
+	})
+	f(nil)
+}
+
+func isNilNode(n Node) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// RewriteFunc is called by Rewrite for each node. See Rewrite for the meaning of the return value.
+type RewriteFunc func(c *Cursor) bool
+
+// Rewrite traverses the IR recursively, similar to astutil.Apply, and returns the root node, which might be replaced.
+//
+// If pre is not nil, it is called for each node before the children are traversed. If it returns false,
+// children of the node and post are skipped. If post is not nil, it is called after the children are traversed.
+// If it returns false, the traversal stops. The Cursor allows to replace the current node, or to modify the list
+// the node belongs to.
+//
+// Unlike Walk, Rewrite only traverses nodes stored in exported fields, since it must be able to update them.
+func Rewrite(root Node, pre, post RewriteFunc) (out Node) {
+	parent := &struct{ Node Node }{root}
+	a := &rewriter{pre: pre, post: post}
+	defer func() {
+		if r := recover(); r != nil && r != errRewriteStop {
+			panic(r)
+		}
+		out = parent.Node
+	}()
+	a.apply(nil, "Node", nil, reflect.ValueOf(parent).Elem().Field(0))
+	return parent.Node
+}
+
+var (
+	nodeType       = reflect.TypeOf((*Node)(nil)).Elem()
+	errRewriteStop = new(int)
+)
+
+type rewriter struct {
+	pre, post RewriteFunc
+	iter      iterator
+}
+
+// iterator tracks the position in a list during the traversal, similar to astutil.
+type iterator struct {
+	index, step int
+}
+
+// Cursor describes a node encountered during Rewrite.
+type Cursor struct {
+	parent Node
+	name   string
+	iter   *iterator     // position in the list, or nil
+	list   reflect.Value // settable list, if iter is set
+	field  reflect.Value // settable field, if iter is not set
+	node   Node
+}
+
+// Node returns the current node.
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the parent of the current node, or nil for the root.
+func (c *Cursor) Parent() Node { return c.parent }
+
+// Name returns the name of the field in the parent node that contains the current node.
+func (c *Cursor) Name() string { return c.name }
+
+// Index returns the index of the current node in the list the node belongs to, or -1 if it's not a part of a list.
+func (c *Cursor) Index() int {
+	if c.iter == nil {
+		return -1
+	}
+	return c.iter.index
+}
+
+func (c *Cursor) value() reflect.Value {
+	if c.iter == nil {
+		return c.field
+	}
+	return c.list.Index(c.iter.index)
+}
+
+// Replace the current node. The children of the new node are traversed instead of the old one.
+// It panics if the new node cannot be stored in the parent's field.
+func (c *Cursor) Replace(n Node) {
+	v := c.value()
+	if !v.CanSet() {
+		panic(fmt.Errorf("cannot replace %T in %s: field is not settable", c.node, c.name))
+	}
+	if isNilNode(n) {
+		v.Set(reflect.Zero(v.Type()))
+		c.node = nil
+		return
+	}
+	nv := reflect.ValueOf(n)
+	if v.Kind() == reflect.Struct && nv.Kind() == reflect.Ptr {
+		// nodes stored by value, like a loop body
+		nv = nv.Elem()
+	}
+	if !nv.Type().AssignableTo(v.Type()) {
+		panic(fmt.Errorf("cannot replace %T in %s with %T", c.node, c.name, n))
+	}
+	v.Set(nv)
+	c.node = n
+}
+
+// Delete the current node from the list it belongs to. The node's children and post call are skipped.
+func (c *Cursor) Delete() {
+	if c.iter == nil {
+		panic("Delete can only be called for nodes in a list")
+	}
+	i := c.iter.index
+	c.list.Set(reflect.AppendSlice(c.list.Slice(0, i), c.list.Slice(i+1, c.list.Len())))
+	c.iter.step--
+	c.node = nil
+}
+
+// InsertBefore inserts a node before the current one in the list it belongs to. The new node is not traversed.
+func (c *Cursor) InsertBefore(n Node) {
+	if c.iter == nil {
+		panic("InsertBefore can only be called for nodes in a list")
+	}
+	c.insert(c.iter.index, n)
+	c.iter.index++
+}
+
+// InsertAfter inserts a node after the current one in the list it belongs to. The new node is not traversed.
+func (c *Cursor) InsertAfter(n Node) {
+	if c.iter == nil {
+		panic("InsertAfter can only be called for nodes in a list")
+	}
+	c.insert(c.iter.index+1, n)
+	c.iter.step++
+}
+
+func (c *Cursor) insert(i int, n Node) {
+	nv := reflect.ValueOf(n)
+	if et := c.list.Type().Elem(); !nv.Type().AssignableTo(et) {
+		panic(fmt.Errorf("cannot insert %T into %s", n, c.name))
+	}
+	l := reflect.Append(c.list, reflect.Zero(c.list.Type().Elem()))
+	reflect.Copy(l.Slice(i+1, l.Len()), l.Slice(i, l.Len()-1))
+	l.Index(i).Set(nv)
+	c.list.Set(l)
+}
+
+// nodeOf returns a node stored in a value, if any.
+func nodeOf(v reflect.Value) Node {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+	case reflect.Struct:
+		if v.CanAddr() && v.Addr().Type().Implements(nodeType) {
+			return v.Addr().Interface().(Node)
+		}
+	}
+	if !v.CanInterface() {
+		return nil
+	}
+	n, _ := v.Interface().(Node)
+	return n
+}
+
+// apply calls the rewrite functions for a node in a given field or list element.
+func (a *rewriter) apply(parent Node, name string, iter *iterator, v reflect.Value) {
+	c := &Cursor{parent: parent, name: name, iter: iter}
+	if iter != nil {
+		c.list = v
+		v = v.Index(iter.index)
+	} else {
+		c.field = v
+	}
+	c.node = nodeOf(v)
+	if c.node == nil {
+		return
+	}
+	if a.pre != nil && !a.pre(c) {
+		return
+	}
+	if c.node == nil {
+		// deleted
+		return
+	}
+	n := c.node
+	nv := reflect.ValueOf(n)
+	switch {
+	case nv.Kind() == reflect.Ptr && nv.Elem().Kind() == reflect.Struct:
+		a.fields(n, nv.Elem())
+	case nv.Kind() == reflect.Struct:
+		// nodes stored by value in interfaces; children are updated in a copy that replaces the node
+		cp := reflect.New(nv.Type()).Elem()
+		cp.Set(nv)
+		a.fields(n, cp)
+		if cur := c.value(); cur.CanSet() {
+			cur.Set(cp)
+		}
+		c.node = cp.Interface().(Node)
+	}
+	if a.post != nil && !a.post(c) {
+		panic(errRewriteStop)
+	}
+}
+
+// fields traverses exported fields of a node, or a struct that belongs to a node.
+func (a *rewriter) fields(parent Node, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && !reflect.PtrTo(f.Type).Implements(nodeType) {
+			// embedded structs that are not nodes by themselves
+			a.fields(parent, fv)
+			continue
+		}
+		switch {
+		case fv.Kind() == reflect.Slice:
+			a.list(parent, f.Name, fv)
+		case isHelperStruct(fv):
+			a.fields(parent, fv.Elem())
+		default:
+			a.apply(parent, f.Name, nil, fv)
+		}
+	}
+}
+
+// isHelperStruct checks if the value is a pointer to a struct from this package that is not a node.
+// Such structs may still contain nodes.
+func isHelperStruct(v reflect.Value) bool {
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Type().Implements(nodeType) {
+		return false
+	}
+	t := v.Type().Elem()
+	return t.Kind() == reflect.Struct && t.PkgPath() == nodeType.PkgPath()
+}
+
+func (a *rewriter) list(parent Node, name string, l reflect.Value) {
+	saved := a.iter
+	for a.iter.index = 0; a.iter.index < l.Len(); a.iter.index += a.iter.step {
+		a.iter.step = 1
+		if e := l.Index(a.iter.index); isHelperStruct(e) {
+			a.fields(parent, e.Elem())
+			continue
+		}
+		a.apply(parent, name, &a.iter, l)
+	}
+	a.iter = saved
+}
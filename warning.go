@@ -0,0 +1,75 @@
+package cxgo
+
+import (
+	"bytes"
+	"strings"
+
+	"modernc.org/cc/v3"
+)
+
+// pragmaWarning is a name of the pragma that replaces #warning directives, see rewriteWarning.
+// It cannot be used by real pragmas, since it is not an identifier.
+const pragmaWarning = "!"
+
+// rewriteWarning replaces #warning directives with a pragma, since the C parser ignores unknown directives.
+// Pragmas are only handled in active preprocessor branches, the same as #warning, thus only warnings under
+// the configured defines are reported.
+//
+// The directive name is replaced with "pragma!", which has the same length, thus the size of the file
+// and positions of tokens are preserved.
+func rewriteWarning(data []byte) []byte {
+	if !bytes.Contains(data, []byte("warning")) {
+		return data
+	}
+	var out []byte
+	for off := 0; off < len(data); {
+		line := data[off:]
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i+1]
+		}
+		if i, ok := warningDirective(line); ok {
+			if out == nil {
+				out = append([]byte{}, data...)
+			}
+			copy(out[off+i:], "pragma"+pragmaWarning)
+		}
+		off += len(line)
+	}
+	if out == nil {
+		return data
+	}
+	return out
+}
+
+// warningDirective returns the offset of the directive name, if the line is a #warning directive.
+func warningDirective(line []byte) (int, bool) {
+	i := skipBlanks(line, 0)
+	if i >= len(line) || line[i] != '#' {
+		return 0, false
+	}
+	i = skipBlanks(line, i+1)
+	rest, ok := bytes.CutPrefix(line[i:], []byte("warning"))
+	if !ok || (len(rest) != 0 && isIdentByte(rest[0])) {
+		return 0, false
+	}
+	return i, true
+}
+
+func skipBlanks(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// warningMessage returns the message of the #warning directive, as written in the source.
+func warningMessage(toks []cc.Token) string {
+	var buf strings.Builder
+	for i, t := range toks {
+		if i != 0 {
+			buf.WriteString(t.Sep.String())
+		}
+		buf.WriteString(t.Value.String())
+	}
+	return buf.String()
+}
@@ -0,0 +1,70 @@
+package cxgo
+
+import (
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// wasmImports are packages that use syscalls unavailable on js/wasm, or runtime packages that depend on them;
+// see Config.WASM.
+var wasmImports = map[string]string{
+	"syscall":                   "",
+	"net":                       "",
+	"os/exec":                   "",
+	"os/signal":                 "",
+	"os/user":                   "",
+	"plugin":                    "",
+	libs.RuntimePrefix + "cnet": "net",
+}
+
+// wasmIssue describes patterns that break on js/wasm, in particular in GopherJS, which has no flat memory
+// for unsafe pointer arithmetic. It returns an empty string for other nodes.
+func wasmIssue(n Node) string {
+	switch n := n.(type) {
+	case *PtrOffset, *PtrElemOffset, *PtrVarOffset:
+		return "unsafe pointer arithmetic"
+	case *PtrDiff:
+		return "unsafe pointer difference"
+	case *PtrToInt:
+		return "pointer converted to an integer"
+	case *IntToPtr:
+		return "integer converted to a pointer"
+	case *PtrComparison:
+		if n.Op.IsRelational() {
+			return "pointers compared by address"
+		}
+	}
+	return funcAddrIssue(n)
+}
+
+// wasmCheckDecl fails the translation if the declaration uses patterns that break on js/wasm.
+func (g *translator) wasmCheckDecl(d CDecl) {
+	if !g.conf.WASM {
+		return
+	}
+	Walk(d, func(n Node) bool {
+		if msg := wasmIssue(n); msg != "" {
+			panic(&ErrUnsupportedConstruct{Kind: "wasm: " + msg + " in " + declDesc(d), Pos: g.declPos[d]})
+		}
+		return true
+	})
+}
+
+// wasmCheckImports fails the translation if the generated code uses packages that are not available on js/wasm.
+func (g *translator) wasmCheckImports(cur string, decls []GoDecl) {
+	if !g.conf.WASM {
+		return
+	}
+	for _, path := range usedImportPaths(g.env, decls) {
+		dep, ok := wasmImports[path]
+		if !ok {
+			continue
+		}
+		kind := "wasm: package " + path + " is not supported"
+		if dep != "" {
+			kind = "wasm: package " + path + " depends on " + dep + ", which is not supported"
+		}
+		panic(&ErrUnsupportedConstruct{Kind: kind, Pos: token.Position{Filename: cur}})
+	}
+}
@@ -0,0 +1,323 @@
+package cxgo
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+var (
+	reSymbolAttr = regexp.MustCompile(`__attribute(?:__)?\s*\(\((.*?)\)\)`)
+	reAttrWeak   = regexp.MustCompile(`\b(?:__)?weak(?:__)?\b`)
+	reAttrAlias  = regexp.MustCompile(`\b(?:__)?alias(?:__)?\s*\(\s*"(\w+)"`)
+	rePragmaWeak = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*pragma[ \t]+weak[ \t]+(\w+)(?:[ \t]*=[ \t]*(\w+))?`)
+)
+
+// symbolAttr is a weak or alias attribute of a top-level declaration in the current file.
+//
+// The C parser drops attributes, thus they are found in the source text and assigned to declarations by position.
+type symbolAttr struct {
+	line, col int    // position of the attribute; zero for #pragma weak
+	name      string // C name of the declaration; set for attributes once the declaration is found
+	weak      bool
+	alias     string // C name of the alias target
+}
+
+// scanSymbolAttrs finds weak and alias attributes, as well as #pragma weak directives in the current file.
+func (g *translator) scanSymbolAttrs(cur string) {
+	g.symAttrs = nil
+	data, err := readSource(g.conf, cur)
+	if err != nil || (!bytes.Contains(data, []byte("weak")) && !bytes.Contains(data, []byte("alias"))) {
+		return
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		for _, m := range reSymbolAttr.FindAllSubmatchIndex(line, -1) {
+			attr := line[m[2]:m[3]]
+			a := symbolAttr{line: i + 1, col: m[0] + 1, weak: reAttrWeak.Match(attr)}
+			if sm := reAttrAlias.FindSubmatch(attr); sm != nil {
+				a.alias = string(sm[1])
+			}
+			if a.weak || a.alias != "" {
+				g.symAttrs = append(g.symAttrs, a)
+			}
+		}
+	}
+	for _, m := range rePragmaWeak.FindAllSubmatch(data, -1) {
+		g.symAttrs = append(g.symAttrs, symbolAttr{name: string(m[1]), weak: true, alias: string(m[2])})
+	}
+}
+
+// declSymbolAttrs assigns attributes written before the end of a top-level declaration to it.
+func (g *translator) declSymbolAttrs(d *cc.ExternalDeclaration) {
+	var (
+		end   cc.Token
+		names []string
+	)
+	switch d.Case {
+	case cc.ExternalDeclarationFuncDef:
+		end = d.FunctionDefinition.CompoundStatement.Token2
+		names = append(names, d.FunctionDefinition.Declarator.Name().String())
+	case cc.ExternalDeclarationDecl:
+		end = d.Declaration.Token
+		for il := d.Declaration.InitDeclaratorList; il != nil; il = il.InitDeclaratorList {
+			if dd := il.InitDeclarator.Declarator; dd != nil {
+				names = append(names, dd.Name().String())
+			}
+		}
+	default:
+		return
+	}
+	pos := end.Position()
+	if strings.TrimLeft(pos.Filename, "./") != g.cur {
+		return
+	}
+	for i := range g.symAttrs {
+		a := &g.symAttrs[i]
+		if a.line == 0 || a.name != "" || a.line > pos.Line || (a.line == pos.Line && a.col > pos.Column) {
+			continue
+		}
+		a.name = "-" // consumed, even if the declaration has no names
+		for j, name := range names {
+			if j == 0 {
+				a.name = name
+			} else {
+				b := *a
+				b.name = name
+				g.symAttrs = append(g.symAttrs, b)
+			}
+		}
+	}
+}
+
+// fileIdent returns the identifier of a file-scope C declaration and its type.
+func (g *translator) fileIdent(name string) (*types.Ident, bool) {
+	nodes := g.file.Scope[cc.String(name)]
+	for _, n := range nodes {
+		d, ok := n.(*cc.Declarator)
+		if !ok {
+			continue
+		}
+		var t types.Type
+		if d.Type().Kind() == cc.Function {
+			t = g.convertFuncType(g.idents[name], d, d.Type(), d.Position())
+		} else {
+			t = g.convertType(g.idents[name], d.Type(), d.Position())
+		}
+		return g.convertIdentWith(name, t, nodes...).Ident, true
+	}
+	return nil, false
+}
+
+// symbolAttrDecls applies weak and alias attributes to top-level declarations.
+//
+// An alias of a function is declared as a variable that refers to the target function, while an alias of a variable is
+// replaced by the target variable everywhere in the file. Names of weak definitions are recorded, thus the project can
+// drop them if a strong definition is translated; see TranslatorProject.Flush. Weak declarations without a definition
+// are declared as zero values, which allows checking if an optional function is linked:
+//
+//	extern void hook(void) __attribute__((weak));
+//
+//	var hook func()
+func (g *translator) symbolAttrDecls(decl []CDecl) []CDecl {
+	if len(g.symAttrs) == 0 {
+		return decl
+	}
+	defs := make(map[string]CDecl)
+	for _, d := range decl {
+		switch d := d.(type) {
+		case *CFuncDecl:
+			if d.Body != nil {
+				defs[d.Name.Name] = d
+			}
+		case *CVarDecl:
+			for _, id := range d.Names {
+				defs[id.Name] = d
+			}
+		}
+	}
+	drop := make(map[CDecl]struct{})
+	for _, a := range g.symAttrs {
+		if a.alias == "" || a.name == "" {
+			continue
+		}
+		id, ok := g.fileIdent(a.name)
+		if !ok {
+			continue
+		}
+		switch target := defs[a.alias].(type) {
+		case *CFuncDecl:
+			d := &CVarDecl{CVarSpec: CVarSpec{
+				g:     g,
+				Type:  id.CType(nil),
+				Names: []*types.Ident{id},
+				Inits: []Expr{FuncIdent{target.Name}},
+			}}
+			if prev, ok := defs[a.name]; ok {
+				drop[prev] = struct{}{}
+			}
+			defs[a.name] = d
+			decl = append(decl, d)
+		case *CVarDecl:
+			for _, tid := range target.Names {
+				if tid.Name == a.alias {
+					id.GoName = tid.GoIdent().Name
+				}
+			}
+			if prev, ok := defs[a.name]; ok && prev != target {
+				drop[prev] = struct{}{}
+			}
+			delete(defs, a.name)
+		}
+	}
+	for _, a := range g.symAttrs {
+		if !a.weak || a.name == "" {
+			continue
+		}
+		if d, ok := defs[a.name]; ok {
+			if _, dropped := drop[d]; !dropped {
+				g.weakDefs = append(g.weakDefs, g.fileIdentName(a.name, d))
+			}
+			continue
+		}
+		id, ok := g.fileIdent(a.name)
+		if !ok || a.alias != "" {
+			continue
+		}
+		delete(g.funcIdents, id)
+		d := &CVarDecl{CVarSpec: CVarSpec{g: g, Type: id.CType(nil), Names: []*types.Ident{id}}}
+		defs[a.name] = d
+		g.weakRefs = append(g.weakRefs, d)
+	}
+	if len(drop) == 0 {
+		return decl
+	}
+	out := decl[:0]
+	for _, d := range decl {
+		if _, ok := drop[d]; !ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// fileIdentName returns the Go name of a C declaration defined by d.
+func (g *translator) fileIdentName(name string, d CDecl) string {
+	switch d := d.(type) {
+	case *CFuncDecl:
+		return d.Name.GoIdent().Name
+	case *CVarDecl:
+		for _, id := range d.Names {
+			if id.Name == name {
+				return id.GoIdent().Name
+			}
+		}
+	}
+	return name
+}
+
+// weakSymbols keeps weak declarations of the project until all files are translated, since a strong definition
+// may be found in any of them.
+type weakSymbols struct {
+	strong map[string]struct{}  // Go names of strong definitions
+	decls  map[string]*weakDecl // weak declarations, by Go name
+	order  []*weakDecl
+}
+
+// weakDecl is a weak definition or a weak declaration without a definition.
+type weakDecl struct {
+	file pendingFile // file where the declaration is written; only one declaration is set
+	ref  bool        // no definition; replaced by any weak definition
+	drop bool
+}
+
+// splitWeak removes weak declarations from the translated file, and keeps them until Flush.
+// Weak declarations are dropped if the project already has a strong definition with the same name.
+func (p *TranslatorProject) splitWeak(f pendingFile, tr *translation) []GoDecl {
+	if p.weak.strong == nil {
+		p.weak.strong = make(map[string]struct{})
+		p.weak.decls = make(map[string]*weakDecl)
+	}
+	weak := make(map[string]struct{}, len(tr.weakDefs))
+	for _, name := range tr.weakDefs {
+		weak[name] = struct{}{}
+	}
+	var out []GoDecl
+	for _, d := range f.decls {
+		names := goDeclNames(d)
+		if len(names) == 1 {
+			if _, ok := weak[names[0]]; ok {
+				p.addWeak(names[0], f, d, false)
+				continue
+			}
+		}
+		for _, name := range names {
+			p.weak.strong[name] = struct{}{}
+			if w := p.weak.decls[name]; w != nil {
+				w.drop = true
+				delete(p.weak.decls, name)
+			}
+		}
+		out = append(out, d)
+	}
+	for _, d := range tr.weakRefs {
+		if names := goDeclNames(d); len(names) == 1 {
+			p.addWeak(names[0], f, d, true)
+		}
+	}
+	return out
+}
+
+func (p *TranslatorProject) addWeak(name string, f pendingFile, d GoDecl, ref bool) {
+	if _, ok := p.weak.strong[name]; ok {
+		return
+	}
+	if w := p.weak.decls[name]; w != nil {
+		if ref || !w.ref {
+			// the first weak definition wins
+			return
+		}
+		w.drop = true
+	}
+	f.decls = []GoDecl{d}
+	w := &weakDecl{file: f, ref: ref}
+	p.weak.decls[name] = w
+	p.weak.order = append(p.weak.order, w)
+}
+
+// flushWeak writes weak declarations that were not replaced by strong definitions to separate files
+// with a "_weak" suffix, next to the files where they were defined.
+func (p *TranslatorProject) flushWeak() error {
+	order := p.weak.order
+	p.weak = weakSymbols{}
+	var (
+		files []*pendingFile
+		byGo  = make(map[string]*pendingFile)
+	)
+	for _, w := range order {
+		if w.drop {
+			continue
+		}
+		f := byGo[w.file.gofile]
+		if f == nil {
+			f = &pendingFile{out: w.file.out, gofile: strings.TrimSuffix(w.file.gofile, ".go") + "_weak.go", pkg: w.file.pkg, conf: w.file.conf}
+			byGo[w.file.gofile] = f
+			files = append(files, f)
+		}
+		f.decls = append(f.decls, w.file.decls...)
+	}
+	for _, f := range files {
+		if f.conf.TreeShake {
+			p.pending = append(p.pending, *f)
+			continue
+		}
+		if err := p.writeFile(f.out, f.gofile, f.pkg, f.decls, f.conf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cxgo
+
+import "github.com/gotranspile/cxgo/types"
+
+// wrapsOnOverflow checks if the signed operation may overflow and thus rely on two's-complement wraparound.
+func (op BinaryOp) wrapsOnOverflow() bool {
+	switch op {
+	case BinOpAdd, BinOpSub, BinOpMult, BinOpLsh:
+		return true
+	}
+	return false
+}
+
+// wrapUnsigned returns an unsigned type of the same size if typ is a signed integer type.
+func wrapUnsigned(typ types.Type) (types.IntType, bool) {
+	it, ok := types.Unwrap(typ).(types.IntType)
+	if !ok || !it.Signed() || it.Kind().IsUntyped() || it.Sizeof() <= 0 {
+		return types.IntType{}, false
+	}
+	return types.UintT(it.Sizeof()), true
+}
+
+// wrapSigned emits signed arithmetic in the unsigned type of the same size: int32(uint32(x) + uint32(y)).
+// In C signed overflow is undefined, thus the mode makes it explicit which operations rely on the wraparound.
+func (g *translator) wrapSigned(typ types.Type, x Expr, op BinaryOp, y Expr) (Expr, bool) {
+	if !g.conf.WrapSigned || !op.wrapsOnOverflow() || (x.IsConst() && y.IsConst()) {
+		return nil, false
+	}
+	ut, ok := wrapUnsigned(typ)
+	if !ok {
+		return nil, false
+	}
+	// negative constants cannot be converted to unsigned types
+	if l, ok := unwrapCasts(x).(IntLit); ok && l.IsNegative() {
+		return nil, false
+	}
+	if l, ok := unwrapCasts(y).(IntLit); ok && l.IsNegative() {
+		switch op {
+		case BinOpAdd:
+			op, y = BinOpSub, l.NegateLit()
+		case BinOpSub:
+			op, y = BinOpAdd, l.NegateLit()
+		default:
+			return nil, false
+		}
+	}
+	x = g.wrapCast(ut, x)
+	if op != BinOpLsh {
+		y = g.wrapCast(ut, y)
+	}
+	return g.cCast(typ, &CBinaryExpr{
+		Left:  cParenLazyOp(x, op),
+		Op:    op,
+		Right: cParenLazyOpR(y, op),
+	}), true
+}
+
+// wrapSignedNeg is the same as wrapSigned, but for the unary minus: int32(-uint32(x)).
+func (g *translator) wrapSignedNeg(x Expr) (Expr, bool) {
+	if !g.conf.WrapSigned || x.IsConst() {
+		return nil, false
+	}
+	typ := x.CType(nil)
+	ut, ok := wrapUnsigned(typ)
+	if !ok {
+		return nil, false
+	}
+	return g.cCast(typ, g.newUnaryExpr(UnaryMinus, g.wrapCast(ut, x))), true
+}
+
+// wrapCast converts x to an unsigned type, dropping the conversion of the nested wrapped operation:
+// uint32(int32(uint32(x) * 31)) -> uint32(x) * 31.
+func (g *translator) wrapCast(ut types.IntType, x Expr) Expr {
+	if c, ok := x.(*CCastExpr); ok && types.Same(c.Expr.CType(nil), ut) {
+		return c.Expr
+	}
+	return g.cCast(ut, x)
+}